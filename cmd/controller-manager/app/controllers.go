@@ -36,6 +36,7 @@ import (
 	"kubesphere.io/kubesphere/cmd/controller-manager/app/options"
 	"kubesphere.io/kubesphere/pkg/controller/alerting"
 	"kubesphere.io/kubesphere/pkg/controller/application"
+	"kubesphere.io/kubesphere/pkg/controller/certificate"
 	"kubesphere.io/kubesphere/pkg/controller/certificatesigningrequest"
 	"kubesphere.io/kubesphere/pkg/controller/cluster"
 	"kubesphere.io/kubesphere/pkg/controller/clusterrolebinding"
@@ -47,6 +48,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/controller/helm"
 	"kubesphere.io/kubesphere/pkg/controller/job"
 	"kubesphere.io/kubesphere/pkg/controller/loginrecord"
+	meteringcontroller "kubesphere.io/kubesphere/pkg/controller/metering"
 	"kubesphere.io/kubesphere/pkg/controller/namespace"
 	"kubesphere.io/kubesphere/pkg/controller/network/ippool"
 	"kubesphere.io/kubesphere/pkg/controller/network/nsnetworkpolicy"
@@ -67,11 +69,19 @@ import (
 	"kubesphere.io/kubesphere/pkg/controller/workspacetemplate"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/kubeconfig"
+	"kubesphere.io/kubesphere/pkg/models/metering"
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/acme"
+	alertingclient "kubesphere.io/kubesphere/pkg/simple/client/alerting"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
+	esclient "kubesphere.io/kubesphere/pkg/simple/client/logging/elasticsearch"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
 	ippoolclient "kubesphere.io/kubesphere/pkg/simple/client/network/ippool"
+	notificationclient "kubesphere.io/kubesphere/pkg/simple/client/notification"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 )
 
@@ -110,10 +120,19 @@ var allControllers = []string{
 	"groupbinding",
 	"group",
 	"notification",
+	"notificationhistory",
 	"pvcworkloadrestarter",
 	"rulegroup",
 	"clusterrulegroup",
 	"globalrulegroup",
+	"alerthistory",
+	"silence",
+	"clustersilence",
+	"logrulegroup",
+	"statement",
+	"budget",
+	"certificateexpiry",
+	"acme",
 }
 
 // setup all available controllers one by one
@@ -526,6 +545,11 @@ func addAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 		}
 	}
 
+	// "notificationhistory" controller
+	if cmOptions.IsControllerEnabled("notificationhistory") {
+		addControllerWithSetup(mgr, "notificationhistory", &notification.NotificationHistoryReconciler{})
+	}
+
 	// controllers for alerting
 	alertingOptionsEnable := cmOptions.AlertingOptions != nil && (cmOptions.AlertingOptions.PrometheusEndpoint != "" || cmOptions.AlertingOptions.ThanosRulerEndpoint != "")
 	if alertingOptionsEnable {
@@ -544,6 +568,116 @@ func addAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 			globalrulegroupReconciler := &alerting.GlobalRuleGroupReconciler{}
 			addControllerWithSetup(mgr, "globalrulegroup", globalrulegroupReconciler)
 		}
+		// "alerthistory" controller
+		if cmOptions.IsControllerEnabled("alerthistory") {
+			ruleClient, err := alertingclient.NewRuleClient(cmOptions.AlertingOptions)
+			if err != nil {
+				klog.Fatalf("Unable to create alerting rule client: %v", err)
+			}
+			alertHistoryRecorder := &alerting.AlertHistoryRecorder{RuleClient: ruleClient}
+			addControllerWithSetup(mgr, "alerthistory", alertHistoryRecorder)
+		}
+	}
+
+	// "silence" and "clustersilence" controller
+	if cmOptions.AlertingOptions != nil && cmOptions.AlertingOptions.AlertmanagerEndpoint != "" &&
+		(cmOptions.IsControllerEnabled("silence") || cmOptions.IsControllerEnabled("clustersilence")) {
+		silenceClient, err := alertingclient.NewSilenceClient(cmOptions.AlertingOptions)
+		if err != nil {
+			klog.Fatalf("Unable to create alertmanager silence client: %v", err)
+		}
+		if cmOptions.IsControllerEnabled("silence") {
+			silenceReconciler := &alerting.SilenceReconciler{SilenceClient: silenceClient}
+			addControllerWithSetup(mgr, "silence", silenceReconciler)
+		}
+		if cmOptions.IsControllerEnabled("clustersilence") {
+			clusterSilenceReconciler := &alerting.ClusterSilenceReconciler{SilenceClient: silenceClient}
+			addControllerWithSetup(mgr, "clustersilence", clusterSilenceReconciler)
+		}
+	}
+
+	// "logrulegroup" controller
+	if cmOptions.AlertingOptions != nil && cmOptions.AlertingOptions.AlertmanagerEndpoint != "" &&
+		cmOptions.LoggingOptions != nil && cmOptions.LoggingOptions.Host != "" &&
+		cmOptions.IsControllerEnabled("logrulegroup") {
+		loggingClient, err := esclient.NewClient(cmOptions.LoggingOptions)
+		if err != nil {
+			klog.Fatalf("Unable to create logging client: %v", err)
+		}
+		alertClient, err := alertingclient.NewAlertClient(cmOptions.AlertingOptions)
+		if err != nil {
+			klog.Fatalf("Unable to create alertmanager alert client: %v", err)
+		}
+		logRuleGroupReconciler := &alerting.LogRuleGroupReconciler{
+			LoggingClient: loggingClient,
+			AlertClient:   alertClient,
+		}
+		addControllerWithSetup(mgr, "logrulegroup", logRuleGroupReconciler)
+	}
+
+	// "statement" and "budget" controller
+	if monitoringOptionsEnable && (cmOptions.IsControllerEnabled("statement") || cmOptions.IsControllerEnabled("budget")) {
+		monitoringClient, err := prometheus.NewPrometheus(cmOptions.MonitoringOptions)
+		if err != nil {
+			klog.Fatalf("Unable to create Prometheus client: %v", err)
+		}
+		meteringOptions := cmOptions.MeteringOptions
+		if meteringOptions == nil {
+			meteringOptions = &meteringclient.DefaultMeteringOption
+		}
+		mo := monitoringmodel.NewMonitoringOperator(monitoringClient, nil, client.Kubernetes(), informerFactory, nil, nil)
+
+		if cmOptions.IsControllerEnabled("statement") {
+			statementReconciler := &meteringcontroller.StatementReconciler{
+				Calculator:          metering.NewStatementCalculator(mo, mgr.GetClient(), meteringOptions.Billing.PriceInfo),
+				RecalculateInterval: meteringOptions.Statement.RecalculateInterval,
+				CloseDelay:          meteringOptions.Statement.CloseDelay,
+			}
+			addControllerWithSetup(mgr, "statement", statementReconciler)
+		}
+
+		if cmOptions.IsControllerEnabled("budget") {
+			budgetReconciler := &meteringcontroller.BudgetReconciler{
+				Evaluator: metering.NewBudgetEvaluator(mo, mgr.GetClient(), meteringOptions.Billing.PriceInfo),
+			}
+			if cmOptions.NotificationOptions != nil && cmOptions.NotificationOptions.IsEnabled() {
+				budgetReconciler.Notifier, err = notificationclient.NewClient(cmOptions.NotificationOptions)
+				if err != nil {
+					klog.Fatalf("Unable to create notification client: %v", err)
+				}
+			}
+			addControllerWithSetup(mgr, "budget", budgetReconciler)
+		}
+	}
+
+	// "certificateexpiry" controller
+	if cmOptions.AlertingOptions != nil && cmOptions.AlertingOptions.AlertmanagerEndpoint != "" &&
+		cmOptions.IsControllerEnabled("certificateexpiry") {
+		alertClient, err := alertingclient.NewAlertClient(cmOptions.AlertingOptions)
+		if err != nil {
+			klog.Fatalf("Unable to create alertmanager alert client: %v", err)
+		}
+		expiryReconciler := &certificate.ExpiryReconciler{AlertClient: alertClient}
+		if cmOptions.GatewayOptions != nil {
+			expiryReconciler.ExpiryDays = cmOptions.GatewayOptions.CertificateExpiryDays
+		}
+		addControllerWithSetup(mgr, "certificateexpiry", expiryReconciler)
+	}
+
+	// "acme" controller
+	if cmOptions.GatewayOptions != nil && cmOptions.GatewayOptions.ACME.IsEnabled() &&
+		cmOptions.IsControllerEnabled("acme") {
+		acmeOptions := cmOptions.GatewayOptions.ACME
+		// the challenges presented by the leader are served by every replica the solver service routes to
+		solver := acme.NewHTTP01Solver(mgr.GetClient(), mgr.GetAPIReader(), acmeOptions.SolverBindAddress)
+		if err := mgr.Add(solver); err != nil {
+			klog.Fatalf("Unable to start acme solver: %v", err)
+		}
+		acmeReconciler := &certificate.ACMEReconciler{
+			Options: acmeOptions,
+			Solver:  solver,
+		}
+		addControllerWithSetup(mgr, "acme", acmeReconciler)
 	}
 
 	// log all controllers process result
@@ -36,9 +36,12 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/metering"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
 	"kubesphere.io/kubesphere/pkg/simple/client/multicluster"
 	"kubesphere.io/kubesphere/pkg/simple/client/network"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification"
 	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix"
 	"kubesphere.io/kubesphere/pkg/simple/client/servicemesh"
 )
@@ -55,6 +58,9 @@ type KubeSphereControllerManagerOptions struct {
 	GatewayOptions        *gateway.Options
 	MonitoringOptions     *prometheus.Options
 	AlertingOptions       *alerting.Options
+	MeteringOptions       *metering.Options
+	NotificationOptions   *notification.Options
+	LoggingOptions        *logging.Options
 	LeaderElect           bool
 	LeaderElection        *leaderelection.LeaderElectionConfig
 	WebhookCertDir        string
@@ -238,4 +244,7 @@ func (s *KubeSphereControllerManagerOptions) MergeConfig(cfg *controllerconfig.C
 	s.GatewayOptions = cfg.GatewayOptions
 	s.MonitoringOptions = cfg.MonitoringOptions
 	s.AlertingOptions = cfg.AlertingOptions
+	s.MeteringOptions = cfg.MeteringOptions
+	s.NotificationOptions = cfg.NotificationOptions
+	s.LoggingOptions = cfg.LoggingOptions
 }
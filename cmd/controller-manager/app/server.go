@@ -66,6 +66,9 @@ func NewControllerManagerCommand() *cobra.Command {
 			GatewayOptions:        conf.GatewayOptions,
 			MonitoringOptions:     conf.MonitoringOptions,
 			AlertingOptions:       conf.AlertingOptions,
+			MeteringOptions:       conf.MeteringOptions,
+			NotificationOptions:   conf.NotificationOptions,
+			LoggingOptions:        conf.LoggingOptions,
 			LeaderElection:        s.LeaderElection,
 			LeaderElect:           s.LeaderElect,
 			WebhookCertDir:        s.WebhookCertDir,
@@ -256,6 +259,18 @@ func run(s *options.KubeSphereControllerManagerOptions, ctx context.Context) err
 	if err := globalrulegroup.SetupWebhookWithManager(mgr); err != nil {
 		klog.Fatalf("Unable to setup GlobalRuleGroup webhook: %v", err)
 	}
+	silence := alertingv2beta1.Silence{}
+	if err := silence.SetupWebhookWithManager(mgr); err != nil {
+		klog.Fatalf("Unable to setup Silence webhook: %v", err)
+	}
+	clustersilence := alertingv2beta1.ClusterSilence{}
+	if err := clustersilence.SetupWebhookWithManager(mgr); err != nil {
+		klog.Fatalf("Unable to setup ClusterSilence webhook: %v", err)
+	}
+	logrulegroup := alertingv2beta1.LogRuleGroup{}
+	if err := logrulegroup.SetupWebhookWithManager(mgr); err != nil {
+		klog.Fatalf("Unable to setup LogRuleGroup webhook: %v", err)
+	}
 
 	klog.V(2).Info("registering metrics to the webhook server")
 	// Add an extra metric endpoint, so we can use the the same metric definition with ks-apiserver
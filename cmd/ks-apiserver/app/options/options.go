@@ -45,7 +45,9 @@ import (
 	"kubesphere.io/kubesphere/pkg/informers"
 	genericoptions "kubesphere.io/kubesphere/pkg/server/options"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
 	auditingclient "kubesphere.io/kubesphere/pkg/simple/client/auditing/elasticsearch"
+	auditingfile "kubesphere.io/kubesphere/pkg/simple/client/auditing/file"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
 	eventsclient "kubesphere.io/kubesphere/pkg/simple/client/events/elasticsearch"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
@@ -160,7 +162,11 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 		}
 	}
 
-	if s.AuditingOptions.Host != "" {
+	if s.AuditingOptions.Backend == auditing.BackendFile {
+		if apiServer.AuditingClient, err = auditingfile.NewClient(s.AuditingOptions); err != nil {
+			return nil, fmt.Errorf("failed to read auditing log file, error: %v", err)
+		}
+	} else if s.AuditingOptions.Host != "" {
 		if apiServer.AuditingClient, err = auditingclient.NewClient(s.AuditingOptions); err != nil {
 			return nil, fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
 		}
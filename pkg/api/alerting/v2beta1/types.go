@@ -17,9 +17,14 @@ limitations under the License.
 package v2beta1
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/common/model"
+
 	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
 )
 
@@ -36,8 +41,25 @@ const (
 	FieldAlertLabelFilters = "label_filters"
 	FieldAlertActiveAt     = "activeAt"
 	FieldAlertLabelMatcher = "label_matcher"
+
+	// for backtesting
+	ParameterBacktestStart = "start"
+	ParameterBacktestEnd   = "end"
+	ParameterBacktestStep  = "step"
+
+	// for alert history
+	ParameterHistoryStart     = "start"
+	ParameterHistoryEnd       = "end"
+	ParameterHistoryRuleGroup = "rulegroup"
+	ParameterHistoryAlert     = "alert"
 )
 
+// DefaultBacktestRange is the time range backtested by default, which ends at now.
+const DefaultBacktestRange = 24 * time.Hour
+
+// DefaultHistoryRange is the time range of the alert timeline by default, which ends at now.
+const DefaultHistoryRange = 7 * 24 * time.Hour
+
 var SortableFields = []string{
 	FieldRuleGroupEvaluationTime,
 	FieldRuleGroupLastEvaluation,
@@ -154,3 +176,133 @@ func ParseLabelFilters(filters string) LabelFilters {
 	}
 	return fs
 }
+
+type BacktestResult struct {
+	Start time.Time `json:"start" description:"start time of the backtesting range"`
+	End   time.Time `json:"end" description:"end time of the backtesting range"`
+	Step  string    `json:"step" description:"interval the rules are evaluated at"`
+
+	Rules []BacktestRuleResult `json:"rules" description:"backtesting results of the enabled rules"`
+}
+
+type BacktestRuleResult struct {
+	Alert  string           `json:"alert" description:"name of the rule"`
+	Expr   string           `json:"expr" description:"expression evaluated"`
+	Error  string           `json:"error,omitempty" description:"error of the evaluation"`
+	Alerts []*BacktestAlert `json:"alerts" description:"alerts which would have fired within the backtesting range"`
+}
+
+type BacktestAlert struct {
+	Labels   map[string]string `json:"labels,omitempty" description:"labels"`
+	ActiveAt time.Time         `json:"activeAt" description:"time when this alert became active"`
+	StartsAt time.Time         `json:"startsAt" description:"time when this alert started firing"`
+	EndsAt   *time.Time        `json:"endsAt,omitempty" description:"time when this alert was resolved, empty if it was still firing at the end of the range"`
+	Value    string            `json:"value,omitempty" description:"the value of the expression when this alert started firing"`
+}
+
+type BacktestOptions struct {
+	Start time.Time
+	End   time.Time
+	// Step overrides the evaluation interval of the rule group if it is not zero.
+	Step time.Duration
+}
+
+// ParseBacktestOptions parses the time range of the backtesting from the unix timestamps of `start` and `end`,
+// and the evaluation interval from `step`.
+func ParseBacktestOptions(req *restful.Request) (BacktestOptions, error) {
+	opts := BacktestOptions{}
+	var err error
+	opts.Start, opts.End, err = parseTimeRange(req, ParameterBacktestStart, ParameterBacktestEnd, DefaultBacktestRange)
+	if err != nil {
+		return opts, err
+	}
+	if step := req.QueryParameter(ParameterBacktestStep); step != "" {
+		d, err := model.ParseDuration(step)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid step %s", step)
+		}
+		opts.Step = time.Duration(d)
+	}
+	return opts, nil
+}
+
+// parseTimeRange parses the time range from the unix timestamps of the parameters,
+// the range ends at now and lasts for the default range if not specified.
+func parseTimeRange(req *restful.Request, startParam, endParam string, defaultRange time.Duration) (start, end time.Time, err error) {
+	end = time.Now()
+	if v := req.QueryParameter(endParam); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid %s %s: %s", endParam, v, err)
+		}
+		end = time.Unix(sec, 0)
+	}
+	start = end.Add(-defaultRange)
+	if v := req.QueryParameter(startParam); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid %s %s: %s", startParam, v, err)
+		}
+		start = time.Unix(sec, 0)
+	}
+	return start, end, nil
+}
+
+type AlertHistoryOptions struct {
+	Start time.Time
+	End   time.Time
+	// RuleGroup and Alert limit the timeline to the rule group or the rule if not empty.
+	RuleGroup string
+	Alert     string
+}
+
+// ParseAlertHistoryOptions parses the time range of the alert timeline from the unix timestamps of `start` and `end`,
+// and the rule the timeline is limited to from `rulegroup` and `alert`.
+func ParseAlertHistoryOptions(req *restful.Request) (AlertHistoryOptions, error) {
+	opts := AlertHistoryOptions{
+		RuleGroup: req.QueryParameter(ParameterHistoryRuleGroup),
+		Alert:     req.QueryParameter(ParameterHistoryAlert),
+	}
+	var err error
+	opts.Start, opts.End, err = parseTimeRange(req, ParameterHistoryStart, ParameterHistoryEnd, DefaultHistoryRange)
+	if err == nil && !opts.End.After(opts.Start) {
+		err = fmt.Errorf("the end time must be after the start time")
+	}
+	return opts, err
+}
+
+type AlertTimeline struct {
+	Start time.Time `json:"start" description:"start time of the timeline"`
+	End   time.Time `json:"end" description:"end time of the timeline"`
+
+	AlertStatistics `json:",inline"`
+
+	Rules   []*RuleAlertStatistics `json:"rules" description:"statistics of the rules which fired within the timeline"`
+	Records []*AlertTimelineRecord `json:"records" description:"alerts firing within the timeline, the latest first"`
+}
+
+type AlertStatistics struct {
+	Count  int `json:"count" description:"number of the alerts which started firing within the timeline"`
+	Firing int `json:"firing" description:"number of the alerts still firing"`
+	// durations are in seconds
+	FiringDuration float64 `json:"firingDuration" description:"total seconds the alerts fired within the timeline"`
+	MTTR           float64 `json:"mttr" description:"mean seconds to resolve the alerts resolved within the timeline, zero if none was resolved"`
+}
+
+type RuleAlertStatistics struct {
+	RuleGroup string `json:"ruleGroup" description:"rule group of the rule"`
+	Alert     string `json:"alert" description:"name of the rule"`
+
+	AlertStatistics `json:",inline"`
+}
+
+type AlertTimelineRecord struct {
+	RuleGroup string            `json:"ruleGroup" description:"rule group of the rule"`
+	Alert     string            `json:"alert" description:"name of the rule"`
+	Labels    map[string]string `json:"labels,omitempty" description:"labels"`
+	ActiveAt  time.Time         `json:"activeAt" description:"time when this alert became active"`
+	StartsAt  time.Time         `json:"startsAt" description:"time when this alert started firing"`
+	EndsAt    *time.Time        `json:"endsAt,omitempty" description:"time when this alert was resolved, empty if it is still firing"`
+	Duration  float64           `json:"duration" description:"seconds this alert fired, until now if it is still firing"`
+	Value     string            `json:"value,omitempty" description:"the value of the expression when this alert started firing"`
+}
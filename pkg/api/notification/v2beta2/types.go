@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta2
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	notificationv2beta2 "kubesphere.io/api/notification/v2beta2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+)
+
+const (
+	ParameterHistoryReceiver  = "receiver"
+	ParameterHistoryTenant    = "tenant"
+	ParameterHistoryAlertName = "alertname"
+	ParameterHistoryStatus    = "status"
+	ParameterHistoryStart     = "start"
+	ParameterHistoryEnd       = "end"
+
+	HistoryStatusSuccess = notificationv2beta2.NotificationStatusSuccess
+	HistoryStatusFailed  = notificationv2beta2.NotificationStatusFailed
+
+	// DefaultHistoryRange is the time range of the history queried if the start is not specified.
+	DefaultHistoryRange = 24 * time.Hour
+)
+
+// HistoryRecord is a notification sent to a receiver.
+type HistoryRecord struct {
+	ID           string            `json:"id" description:"id of the record"`
+	Time         time.Time         `json:"time" description:"time the notification was sent"`
+	Receiver     string            `json:"receiver" description:"name of the receiver"`
+	ReceiverType string            `json:"receiverType,omitempty" description:"type of the receiver, e.g. email"`
+	Tenant       string            `json:"tenant,omitempty" description:"tenant owning the receiver, empty for the global receivers"`
+	AlertName    string            `json:"alertname,omitempty" description:"name of the alert notified"`
+	Namespace    string            `json:"namespace,omitempty" description:"namespace of the alert notified"`
+	Status       string            `json:"status" description:"status of the notification, success or failed"`
+	Latency      float64           `json:"latency,omitempty" description:"seconds taken to send the notification"`
+	Message      string            `json:"message,omitempty" description:"error message if the notification failed"`
+	Labels       map[string]string `json:"labels,omitempty" description:"labels of the alert notified"`
+}
+
+type HistoryOptions struct {
+	Receiver  string
+	Tenant    string
+	AlertName string
+	Status    string
+	Start     time.Time
+	End       time.Time
+
+	Pagination *query.Pagination
+}
+
+// ParseHistoryOptions parses the filters of the history from the query parameters, and the time range
+// from the unix timestamps of `start` and `end`.
+func ParseHistoryOptions(req *restful.Request) (*HistoryOptions, error) {
+	opts := &HistoryOptions{
+		Receiver:   req.QueryParameter(ParameterHistoryReceiver),
+		Tenant:     req.QueryParameter(ParameterHistoryTenant),
+		AlertName:  req.QueryParameter(ParameterHistoryAlertName),
+		Status:     req.QueryParameter(ParameterHistoryStatus),
+		Pagination: query.ParseQueryParameter(req).Pagination,
+	}
+	if opts.Status != "" && opts.Status != HistoryStatusSuccess && opts.Status != HistoryStatusFailed {
+		return nil, fmt.Errorf("invalid %s %s", ParameterHistoryStatus, opts.Status)
+	}
+
+	opts.End = time.Now()
+	if v := req.QueryParameter(ParameterHistoryEnd); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %s", ParameterHistoryEnd, v, err)
+		}
+		opts.End = time.Unix(sec, 0)
+	}
+	opts.Start = opts.End.Add(-DefaultHistoryRange)
+	if v := req.QueryParameter(ParameterHistoryStart); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %s", ParameterHistoryStart, v, err)
+		}
+		opts.Start = time.Unix(sec, 0)
+	}
+	if !opts.End.After(opts.Start) {
+		return nil, fmt.Errorf("the end time must be after the start time")
+	}
+	return opts, nil
+}
+
+// Match returns whether the record matches the filters.
+func (o *HistoryOptions) Match(record *HistoryRecord) bool {
+	return (o.Receiver == "" || record.Receiver == o.Receiver) &&
+		(o.Tenant == "" || record.Tenant == o.Tenant) &&
+		(o.AlertName == "" || record.AlertName == o.AlertName) &&
+		(o.Status == "" || record.Status == o.Status) &&
+		!record.Time.Before(o.Start) && !record.Time.After(o.End)
+}
+
+type History struct {
+	TotalItems int                   `json:"totalItems" description:"number of the records matched"`
+	Items      []*HistoryRecord      `json:"items" description:"records of the page, the latest first"`
+	Receivers  []*ReceiverStatistics `json:"receivers" description:"statistics of the receivers of the records matched"`
+}
+
+type ReceiverStatistics struct {
+	Receiver     string `json:"receiver" description:"name of the receiver"`
+	ReceiverType string `json:"receiverType,omitempty" description:"type of the receiver"`
+	Total        int    `json:"total" description:"number of the notifications sent"`
+	Success      int    `json:"success" description:"number of the notifications sent successfully"`
+	Failed       int    `json:"failed" description:"number of the notifications failed"`
+	// SuccessRate is the ratio of the notifications sent successfully, between 0 and 1.
+	SuccessRate float64 `json:"successRate" description:"ratio of the notifications sent successfully"`
+	// latencies are in seconds
+	AverageLatency float64 `json:"averageLatency" description:"average seconds taken to send the notifications"`
+	P95Latency     float64 `json:"p95Latency" description:"95th percentile of the seconds taken to send the notifications"`
+}
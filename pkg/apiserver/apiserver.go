@@ -255,12 +255,12 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 		auth.NewLoginRecorder(s.KubernetesClient.KubeSphere(), userLister),
 		s.Config.AuthenticationOptions))
 	urlruntime.Must(servicemeshv1alpha2.AddToContainer(s.Config.ServiceMeshOptions, s.container, s.KubernetesClient.Kubernetes(), s.CacheClient))
-	urlruntime.Must(networkv1alpha2.AddToContainer(s.container, s.Config.NetworkOptions.WeaveScopeHost))
+	urlruntime.Must(networkv1alpha2.AddToContainer(s.container, s.Config.NetworkOptions, s.InformerFactory, s.KubernetesClient.KubeSphere(), s.MonitoringClient, rbacAuthorizer))
 	urlruntime.Must(kapisdevops.AddToContainer(s.container, s.Config.DevopsOptions.Endpoint))
 	urlruntime.Must(alertingv1.AddToContainer(s.container, s.Config.AlertingOptions.Endpoint))
 	urlruntime.Must(alertingv2alpha1.AddToContainer(s.container, s.InformerFactory,
-		s.KubernetesClient.Prometheus(), s.AlertingClient, s.Config.AlertingOptions))
-	urlruntime.Must(alertingv2beta1.AddToContainer(s.container, s.InformerFactory, s.AlertingClient))
+		s.KubernetesClient.Prometheus(), s.AlertingClient, s.Config.AlertingOptions, s.MonitoringClient))
+	urlruntime.Must(alertingv2beta1.AddToContainer(s.container, s.InformerFactory, s.AlertingClient, s.MonitoringClient, s.RuntimeCache, s.RuntimeClient))
 	urlruntime.Must(version.AddToContainer(s.container, s.KubernetesClient.Kubernetes().Discovery()))
 	urlruntime.Must(kubeedgev1alpha1.AddToContainer(s.container, s.Config.KubeEdgeOptions.Endpoint))
 	urlruntime.Must(edgeruntimev1alpha1.AddToContainer(s.container, s.Config.EdgeRuntimeOptions.Endpoint))
@@ -269,9 +269,9 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 		urlruntime.Must(notificationkapisv2beta1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.Kubernetes(),
 			s.KubernetesClient.KubeSphere()))
 		urlruntime.Must(notificationkapisv2beta2.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.Kubernetes(),
-			s.KubernetesClient.KubeSphere(), s.Config.NotificationOptions))
+			s.KubernetesClient.KubeSphere(), s.Config.NotificationOptions, s.RuntimeCache))
 	}
-	urlruntime.Must(gatewayv1alpha1.AddToContainer(s.container, s.Config.GatewayOptions, s.RuntimeCache, s.RuntimeClient, s.InformerFactory, s.KubernetesClient.Kubernetes(), s.LoggingClient))
+	urlruntime.Must(gatewayv1alpha1.AddToContainer(s.container, s.Config.GatewayOptions, s.RuntimeCache, s.RuntimeClient, s.InformerFactory, s.KubernetesClient.Kubernetes(), s.LoggingClient, s.MonitoringClient))
 }
 
 // installHealthz creates the healthz endpoint for this server
@@ -504,6 +504,10 @@ func (s *APIServer) waitForResourceSync(ctx context.Context) error {
 			"ippools",
 		},
 	}
+	if s.Config.NetworkOptions.EnableNetworkPolicy {
+		networkGroupVersion := schema.GroupVersion{Group: "network.kubesphere.io", Version: "v1alpha1"}
+		ksGVRs[networkGroupVersion] = append(ksGVRs[networkGroupVersion], "namespacenetworkpolicies")
+	}
 	if s.Config.NotificationOptions.IsEnabled() {
 		ksGVRs[schema.GroupVersion{Group: "notification.kubesphere.io", Version: "v2beta1"}] = []string{
 			notificationv2beta1.ResourcesPluralConfig,
@@ -28,6 +28,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
 	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing/file"
 )
 
 const (
@@ -44,6 +45,7 @@ type Backend struct {
 	senderCh           chan interface{}
 	cache              chan *v1alpha1.Event
 	client             http.Client
+	writer             *file.Writer
 	sendTimeout        time.Duration
 	getSenderTimeout   time.Duration
 	eventBatchSize     int
@@ -63,7 +65,17 @@ func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan
 		stopCh:             stopCh,
 	}
 
-	if len(b.url) == 0 {
+	if len(opts.LogPath) != 0 {
+		writer, err := file.NewWriter(opts)
+		if err != nil {
+			klog.Errorf("failed to open auditing log file %s, %s", opts.LogPath, err)
+		} else {
+			b.writer = writer
+		}
+	}
+
+	// Events are only sent to the default webhook when they are not written to the auditing log file.
+	if len(b.url) == 0 && b.writer == nil {
 		b.url = WebhookURL
 	}
 
@@ -107,7 +119,21 @@ func (b *Backend) worker() {
 			continue
 		}
 
-		go b.sendEvents(events)
+		if b.writer != nil {
+			if err := b.writer.Write(events.Items); err != nil {
+				klog.Errorf("write auditing events to file error, %s", err)
+			}
+		}
+
+		if len(b.url) != 0 {
+			go b.sendEvents(events)
+		}
+	}
+
+	if b.writer != nil {
+		if err := b.writer.Close(); err != nil {
+			klog.Errorf("close auditing log file error, %s", err)
+		}
 	}
 }
 
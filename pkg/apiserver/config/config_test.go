@@ -31,6 +31,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization"
 	"kubesphere.io/kubesphere/pkg/models/terminal"
+	"kubesphere.io/kubesphere/pkg/simple/client/acme"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
 	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
 	"kubesphere.io/kubesphere/pkg/simple/client/cache"
@@ -186,8 +187,10 @@ func newTestConfig() (*Config, error) {
 			RetentionDay: "7d",
 		},
 		GatewayOptions: &gateway.Options{
-			WatchesPath: "/etc/kubesphere/watches.yaml",
-			Namespace:   "kubesphere-controls-system",
+			WatchesPath:           "/etc/kubesphere/watches.yaml",
+			Namespace:             "kubesphere-controls-system",
+			CertificateExpiryDays: 30,
+			ACME:                  acme.NewACMEOptions(),
 		},
 		GPUOptions: &gpu.Options{
 			Kinds: []gpu.GPUKind{},
@@ -104,6 +104,7 @@ const (
 	GatewayTag = "Gateway"
 
 	NetworkTopologyTag = "Network Topology"
+	NetworkPolicyTag   = "Network Policy"
 
 	KubeSphereMetricsTag = "KubeSphere Metrics"
 	ClusterMetricsTag    = "Cluster Metrics"
@@ -129,6 +130,8 @@ const (
 	WorkloadMetersTag  = "Workload Meters"
 	PodMetersTag       = "Pod Meters"
 	ServiceMetricsTag  = "ServiceName Meters"
+	StatementTag       = "Statement"
+	RecommendationTag  = "Recommendation"
 
 	ApplicationReleaseName = "meta.helm.sh/release-name"
 	ApplicationReleaseNS   = "meta.helm.sh/release-namespace"
@@ -0,0 +1,309 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/go-logr/logr"
+	prommodel "github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+const (
+	DefaultAlertHistorySampleInterval = time.Minute
+	DefaultAlertHistoryRetention      = 30 * 24 * time.Hour
+	// the records of a rule are limited to keep the size of the resource bounded
+	DefaultAlertHistoryMaxRecords = 200
+
+	alertStateFiring   = "firing"
+	alertStateInactive = "inactive"
+)
+
+// AlertHistoryRecorder samples the alerts of the rules from the rulers periodically and records
+// the transitions of the alerts into the AlertHistory resources, one for each rule which has alerted.
+// An alert is recorded once it becomes active, and is dropped if it is resolved without firing.
+type AlertHistoryRecorder struct {
+	client.Client
+	Log        logr.Logger
+	RuleClient alerting.RuleClient
+
+	Interval   time.Duration
+	Retention  time.Duration
+	MaxRecords int
+
+	now func() time.Time
+}
+
+func (r *AlertHistoryRecorder) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Log.GetSink() == nil {
+		r.Log = mgr.GetLogger().WithName("alerthistory")
+	}
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Interval <= 0 {
+		r.Interval = DefaultAlertHistorySampleInterval
+	}
+	if r.Retention <= 0 {
+		r.Retention = DefaultAlertHistoryRetention
+	}
+	if r.MaxRecords <= 0 {
+		r.MaxRecords = DefaultAlertHistoryMaxRecords
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return mgr.Add(r)
+}
+
+// Start samples the alerts until the context is done, it runs on the leader only.
+func (r *AlertHistoryRecorder) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Sample(ctx); err != nil {
+			r.Log.Error(err, "failed to sample the alerts")
+		}
+	}, r.Interval)
+	return nil
+}
+
+type alertHistoryKey struct {
+	level     string
+	namespace string
+	group     string
+	alert     string
+}
+
+func (k alertHistoryKey) name() string {
+	h := fnv.New64a()
+	for _, s := range []string{k.level, k.namespace, k.group, k.alert} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s-%x", k.level, h.Sum64())
+}
+
+type sampledRule struct {
+	holdDuration time.Duration
+	alerts       []*alerting.Alert
+}
+
+// Sample samples the alerts of the rules once and records them.
+func (r *AlertHistoryRecorder) Sample(ctx context.Context) error {
+	// the alerts are not recorded if any ruler fails, or they would be resolved by mistake
+	var groups []*alerting.RuleGroup
+	promGroups, err := r.RuleClient.PrometheusRules(ctx)
+	if err != nil {
+		return err
+	}
+	groups = append(groups, promGroups...)
+	thanosGroups, err := r.RuleClient.ThanosRules(ctx)
+	if err != nil {
+		return err
+	}
+	groups = append(groups, thanosGroups...)
+
+	rules := make(map[alertHistoryKey]*sampledRule)
+	for _, group := range groups {
+		for _, rule := range group.Rules {
+			key := alertHistoryKey{
+				// the rules not managed by the rule groups are regarded as cluster level
+				level: string(RuleLevelCluster),
+				group: group.Name,
+				alert: rule.Name,
+			}
+			if level := rule.Labels[RuleLabelKeyRuleLevel]; level != "" {
+				key.level = level
+				key.group = rule.Labels[RuleLabelKeyRuleGroup]
+				if level == string(RuleLevelNamesapce) {
+					key.namespace = rule.Labels[RuleLabelKeyNamespace]
+				}
+			}
+			sampled, ok := rules[key]
+			if !ok {
+				sampled = &sampledRule{holdDuration: time.Duration(rule.Duration * float64(time.Second))}
+				rules[key] = sampled
+			}
+			sampled.alerts = append(sampled.alerts, rule.Alerts...)
+		}
+	}
+
+	histories := &alertingv2beta1.AlertHistoryList{}
+	if err := r.List(ctx, histories); err != nil {
+		return err
+	}
+	now := r.now()
+	existing := make(map[string]bool, len(histories.Items))
+	for i := range histories.Items {
+		history := &histories.Items[i]
+		existing[history.Name] = true
+		key := alertHistoryKey{
+			level:     history.Spec.RuleLevel,
+			namespace: history.Spec.Namespace,
+			group:     history.Spec.RuleGroup,
+			alert:     history.Spec.Alert,
+		}
+		// the alerts of the rules removed are resolved
+		var sampled sampledRule
+		if s, ok := rules[key]; ok {
+			sampled = *s
+		}
+		if err := r.record(ctx, history, &sampled, now); err != nil {
+			r.Log.Error(err, "failed to record the alerts", "alerthistory", history.Name)
+		}
+	}
+
+	for key, sampled := range rules {
+		if existing[key.name()] || !hasActiveAlerts(sampled.alerts) {
+			continue
+		}
+		history := &alertingv2beta1.AlertHistory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   key.name(),
+				Labels: map[string]string{alertingv2beta1.AlertHistoryLabelRuleLevel: key.level},
+			},
+			Spec: alertingv2beta1.AlertHistorySpec{
+				RuleLevel: key.level,
+				Namespace: key.namespace,
+				RuleGroup: key.group,
+				Alert:     key.alert,
+			},
+		}
+		if key.namespace != "" {
+			history.Labels[alertingv2beta1.AlertHistoryLabelRuleNamespace] = key.namespace
+		}
+		if err := r.Create(ctx, history); err != nil {
+			r.Log.Error(err, "failed to create the alert history", "alerthistory", history.Name)
+			continue
+		}
+		if err := r.record(ctx, history, sampled, now); err != nil {
+			r.Log.Error(err, "failed to record the alerts", "alerthistory", history.Name)
+		}
+	}
+	return nil
+}
+
+func (r *AlertHistoryRecorder) record(ctx context.Context, history *alertingv2beta1.AlertHistory, sampled *sampledRule, now time.Time) error {
+	records := mergeAlertRecords(history.Status.Records, sampled.alerts, sampled.holdDuration, now)
+	records = pruneAlertRecords(records, now.Add(-r.Retention), r.MaxRecords)
+	if len(records) == 0 {
+		// nothing is left to recall about the rule
+		return client.IgnoreNotFound(r.Delete(ctx, history))
+	}
+	if equality.Semantic.DeepEqual(records, history.Status.Records) && history.Status.LastSampleTime != nil {
+		return nil
+	}
+	sampleTime := metav1.NewTime(now)
+	history.Status.Records, history.Status.LastSampleTime = records, &sampleTime
+	return r.Status().Update(ctx, history)
+}
+
+func hasActiveAlerts(alerts []*alerting.Alert) bool {
+	for _, alert := range alerts {
+		if alert.State != alertStateInactive {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeAlertRecords merges the alerts sampled into the records. The alerts still active update the
+// records open, the other alerts open a new record each, and the records open without an alert sampled
+// are resolved or dropped if they have never fired.
+// An alert fires once it has been active for the hold duration of the rule, which is when it is regarded as
+// started firing rather than when it is sampled, as the alerts may change in between the samples.
+func mergeAlertRecords(records []alertingv2beta1.AlertRecord, alerts []*alerting.Alert, holdDuration time.Duration, now time.Time) []alertingv2beta1.AlertRecord {
+	startsAt := func(activeAt time.Time) *metav1.Time {
+		t := activeAt.Add(holdDuration)
+		if t.After(now) {
+			t = now
+		}
+		mt := metav1.NewTime(t)
+		return &mt
+	}
+
+	result := make([]alertingv2beta1.AlertRecord, 0, len(records)+len(alerts))
+	open := make(map[uint64]int)
+	for _, record := range records {
+		if record.EndsAt == nil {
+			open[prommodel.LabelsToSignature(record.Labels)] = len(result)
+		}
+		result = append(result, *record.DeepCopy())
+	}
+
+	sampled := make(map[int]bool)
+	for _, alert := range alerts {
+		if alert.State == alertStateInactive {
+			continue
+		}
+		activeAt := now
+		if alert.ActiveAt != nil {
+			activeAt = *alert.ActiveAt
+		}
+		i, ok := open[prommodel.LabelsToSignature(alert.Labels)]
+		if !ok {
+			i = len(result)
+			result = append(result, alertingv2beta1.AlertRecord{
+				Labels:   alert.Labels,
+				ActiveAt: metav1.NewTime(activeAt),
+			})
+		}
+		sampled[i] = true
+		if record := &result[i]; alert.State == alertStateFiring && record.StartsAt == nil {
+			record.StartsAt, record.Value = startsAt(record.ActiveAt.Time), alert.Value
+		}
+	}
+
+	merged := result[:0]
+	for i, record := range result {
+		if record.EndsAt == nil && !sampled[i] {
+			if record.StartsAt == nil {
+				continue
+			}
+			endsAt := metav1.NewTime(now)
+			record.EndsAt = &endsAt
+		}
+		merged = append(merged, record)
+	}
+	return merged
+}
+
+// pruneAlertRecords removes the records resolved before the time given, and the earliest records beyond the max.
+func pruneAlertRecords(records []alertingv2beta1.AlertRecord, before time.Time, max int) []alertingv2beta1.AlertRecord {
+	pruned := records[:0]
+	for _, record := range records {
+		if record.EndsAt != nil && record.EndsAt.Time.Before(before) {
+			continue
+		}
+		pruned = append(pruned, record)
+	}
+	if len(pruned) > max {
+		pruned = pruned[len(pruned)-max:]
+	}
+	return pruned
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+type fakeRuleClient struct {
+	groups []*alerting.RuleGroup
+}
+
+func (f *fakeRuleClient) PrometheusRules(ctx context.Context) ([]*alerting.RuleGroup, error) {
+	return nil, nil
+}
+
+func (f *fakeRuleClient) ThanosRules(ctx context.Context, matchers ...[]*labels.Matcher) ([]*alerting.RuleGroup, error) {
+	return f.groups, nil
+}
+
+func TestMergeAlertRecords(t *testing.T) {
+	t0 := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := t0.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	pod1 := map[string]string{"alertname": "PodCrash", "pod": "pod1"}
+	pod2 := map[string]string{"alertname": "PodCrash", "pod": "pod2"}
+	hold := 5 * time.Minute
+
+	// pod1 becomes pending and pod2 fires
+	records := mergeAlertRecords(nil, []*alerting.Alert{
+		{Labels: pod1, State: "pending", ActiveAt: at(8)},
+		{Labels: pod2, State: "firing", ActiveAt: at(0), Value: "3"},
+	}, hold, *at(10))
+	if len(records) != 2 || records[0].StartsAt != nil || !records[1].StartsAt.Time.Equal(*at(5)) || records[1].Value != "3" {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	// pod1 fires no earlier than sampled, and pod2 is resolved
+	records = mergeAlertRecords(records, []*alerting.Alert{
+		{Labels: pod1, State: "firing", ActiveAt: at(8)},
+	}, hold, *at(12))
+	if len(records) != 2 || !records[0].StartsAt.Time.Equal(*at(12)) || records[0].EndsAt != nil ||
+		!records[1].EndsAt.Time.Equal(*at(12)) {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	// pod2 alerts again as a new record, which is dropped after resolved without firing
+	records = mergeAlertRecords(records, []*alerting.Alert{
+		{Labels: pod1, State: "firing", ActiveAt: at(8)},
+		{Labels: pod2, State: "pending", ActiveAt: at(13)},
+	}, hold, *at(14))
+	if len(records) != 3 || records[2].StartsAt != nil {
+		t.Fatalf("unexpected records %v", records)
+	}
+	records = mergeAlertRecords(records, []*alerting.Alert{
+		{Labels: pod1, State: "firing", ActiveAt: at(8)},
+	}, hold, *at(15))
+	if len(records) != 2 || records[0].EndsAt != nil {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	pruned := pruneAlertRecords(records, *at(13), 10)
+	if len(pruned) != 1 || pruned[0].Labels["pod"] != "pod1" {
+		t.Fatalf("unexpected records pruned %v", pruned)
+	}
+}
+
+func TestSampleAlertHistory(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = alertingv2beta1.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 0, 10, 0, 0, time.UTC)
+	activeAt := now.Add(-10 * time.Minute)
+	ruleClient := &fakeRuleClient{groups: []*alerting.RuleGroup{{
+		Name: "alertrules-ns-test",
+		Rules: []*alerting.AlertingRule{{
+			Name:     "PodCrash",
+			Duration: 300,
+			Labels: map[string]string{
+				RuleLabelKeyRuleLevel: string(RuleLevelNamesapce),
+				RuleLabelKeyRuleGroup: "pods",
+				RuleLabelKeyNamespace: "test",
+			},
+			Alerts: []*alerting.Alert{{
+				Labels:   map[string]string{"alertname": "PodCrash", "pod": "pod1"},
+				State:    "firing",
+				ActiveAt: &activeAt,
+			}},
+		}, {
+			Name:   "Quiet",
+			Labels: map[string]string{RuleLabelKeyRuleLevel: string(RuleLevelCluster), RuleLabelKeyRuleGroup: "nodes"},
+		}},
+	}}}
+	r := &AlertHistoryRecorder{
+		Client:     fake.NewClientBuilder().WithScheme(sch).Build(),
+		Log:        ctrl.Log,
+		RuleClient: ruleClient,
+		Retention:  DefaultAlertHistoryRetention,
+		MaxRecords: DefaultAlertHistoryMaxRecords,
+		now:        func() time.Time { return now },
+	}
+	list := func() []alertingv2beta1.AlertHistory {
+		histories := &alertingv2beta1.AlertHistoryList{}
+		if err := r.List(context.Background(), histories); err != nil {
+			t.Fatal(err)
+		}
+		return histories.Items
+	}
+
+	// the histories are created for the rules alerting only
+	if err := r.Sample(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	histories := list()
+	if len(histories) != 1 {
+		t.Fatalf("unexpected histories %v", histories)
+	}
+	history := histories[0]
+	if history.Spec.Namespace != "test" || history.Spec.RuleGroup != "pods" ||
+		history.Labels[alertingv2beta1.AlertHistoryLabelRuleNamespace] != "test" ||
+		len(history.Status.Records) != 1 || !history.Status.Records[0].StartsAt.Time.Equal(activeAt.Add(5*time.Minute)) {
+		t.Fatalf("unexpected history %v", history)
+	}
+
+	// the alerts of the rules removed are resolved
+	ruleClient.groups = nil
+	now = now.Add(time.Minute)
+	if err := r.Sample(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	histories = list()
+	if len(histories) != 1 || histories[0].Status.Records[0].EndsAt == nil {
+		t.Fatalf("unexpected histories %v", histories)
+	}
+
+	// the histories are removed once all the records are beyond the retention
+	now = now.Add(DefaultAlertHistoryRetention + time.Minute)
+	if err := r.Sample(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if histories = list(); len(histories) != 0 {
+		t.Fatalf("unexpected histories %v", histories)
+	}
+}
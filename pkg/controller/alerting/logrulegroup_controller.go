@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	prommodel "github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+const (
+	defaultLogRuleGroupInterval = time.Minute
+
+	alertStatePending = "pending"
+)
+
+// LogRuleGroupReconciler evaluates the log based rules periodically by counting the logs selected,
+// and sends the alerts to the alertmanager, which notifies them the same as the alerts of the metric based rules.
+type LogRuleGroupReconciler struct {
+	client.Client
+	Log           logr.Logger
+	LoggingClient logging.Client
+	AlertClient   alerting.AlertClient
+
+	now func() time.Time
+}
+
+func (r *LogRuleGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Log.GetSink() == nil {
+		r.Log = mgr.GetLogger()
+	}
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("logrulegroup").
+		// the status updated after each evaluation doesn't trigger another evaluation
+		For(&alertingv2beta1.LogRuleGroup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=alerting.kubesphere.io,resources=logrulegroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=alerting.kubesphere.io,resources=logrulegroups/status,verbs=get;update;patch
+func (r *LogRuleGroupReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.Log.WithValues("logrulegroup", req.NamespacedName)
+
+	group := &alertingv2beta1.LogRuleGroup{}
+	if err := r.Get(ctx, req.NamespacedName, group); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !group.DeletionTimestamp.IsZero() {
+		// the alerts firing are resolved by the alertmanager once they are not sent any more
+		return reconcile.Result{}, nil
+	}
+
+	interval := defaultLogRuleGroupInterval
+	if group.Spec.Interval != "" {
+		d, err := prommodel.ParseDuration(string(group.Spec.Interval))
+		if err != nil {
+			log.Error(err, "invalid interval")
+			return reconcile.Result{}, nil
+		}
+		interval = time.Duration(d)
+	}
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: group.Namespace}, namespace); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := r.now()
+	previous := make(map[string]alertingv2beta1.LogRuleStatus, len(group.Status.RulesStatus))
+	for _, status := range group.Status.RulesStatus {
+		previous[status.Alert] = status
+	}
+
+	var (
+		statuses []alertingv2beta1.LogRuleStatus
+		alerts   []*alerting.PostableAlert
+	)
+	for i := range group.Spec.Rules {
+		rule := &group.Spec.Rules[i]
+		status := previous[rule.Alert]
+		if rule.Disable {
+			if status.State == alertStateFiring {
+				alerts = append(alerts, logRuleAlert(group, rule, &status, now))
+			}
+			continue
+		}
+
+		last := status
+		status = r.evaluate(namespace, rule, status, now)
+		if status.State == alertStateFiring {
+			// the alert is resolved by the alertmanager if it is not sent again in a few evaluations,
+			// the same as the alerts sent by Prometheus
+			alerts = append(alerts, logRuleAlert(group, rule, &status, now.Add(4*interval)))
+		} else if last.State == alertStateFiring {
+			alerts = append(alerts, logRuleAlert(group, rule, &last, now))
+		}
+		statuses = append(statuses, status)
+	}
+	// the alerts of the rules removed are left to be resolved by the alertmanager,
+	// whose labels are unknown any more
+
+	// the states are saved before the alerts are sent, so an alert is not sent for a state transition
+	// which is evaluated again as the update conflicts. The firing alerts are sent again in the next
+	// evaluation anyway if they fail to be sent.
+	group.Status.RulesStatus = statuses
+	if err := r.Status().Update(ctx, group); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.AlertClient.PostAlerts(ctx, alerts...); err != nil {
+		log.Error(err, "failed to send the alerts to the alertmanager")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// evaluate counts the logs selected by the rule and transits the state of the alert of the rule,
+// the state is kept if the logs fail to be counted.
+func (r *LogRuleGroupReconciler) evaluate(namespace *corev1.Namespace, rule *alertingv2beta1.LogRule,
+	status alertingv2beta1.LogRuleStatus, now time.Time) alertingv2beta1.LogRuleStatus {
+	// validated already
+	window, _ := prommodel.ParseDuration(string(rule.Condition.Window))
+	var holdDuration time.Duration
+	if rule.For != "" {
+		d, _ := prommodel.ParseDuration(string(rule.For))
+		holdDuration = time.Duration(d)
+	}
+
+	evaluation := metav1.NewTime(now)
+	status.Alert, status.LastEvaluation, status.LastError = rule.Alert, &evaluation, ""
+	if status.State == "" {
+		status.State = alertStateInactive
+	}
+
+	created := namespace.CreationTimestamp.Time
+	filter := rule.Condition.Filter
+	sf := logging.SearchFilter{
+		// the logs of a namespace deleted before and created again with the same name are not counted
+		NamespaceFilter: map[string]*time.Time{namespace.Name: &created},
+		WorkloadSearch:  filter.WorkloadSearch,
+		WorkloadFilter:  filter.WorkloadFilter,
+		PodSearch:       filter.PodSearch,
+		PodFilter:       filter.PodFilter,
+		ContainerSearch: filter.ContainerSearch,
+		ContainerFilter: filter.ContainerFilter,
+		LogSearch:       filter.LogSearch,
+		Starttime:       now.Add(-time.Duration(window)),
+		Endtime:         now,
+	}
+	histogram, err := r.LoggingClient.CountLogsByInterval(sf, fmt.Sprintf("%ds", int64(time.Duration(window).Seconds())))
+	if err != nil {
+		status.LastError = err.Error()
+		return status
+	}
+	status.Count = histogram.Total
+
+	if !rule.Condition.Compare(histogram.Total) {
+		status.State, status.ActiveAt, status.FiredAt = alertStateInactive, nil, nil
+		return status
+	}
+	if status.ActiveAt == nil {
+		status.State, status.ActiveAt = alertStatePending, &evaluation
+	}
+	if status.FiredAt == nil && now.Sub(status.ActiveAt.Time) >= holdDuration {
+		status.State, status.FiredAt = alertStateFiring, &evaluation
+	}
+	return status
+}
+
+// LogRuleAlertLabels returns the labels of the alert of the log based rule,
+// which are the same as the alerts of the namespace level rule groups have.
+func LogRuleAlertLabels(group *alertingv2beta1.LogRuleGroup, rule *alertingv2beta1.LogRule) map[string]string {
+	labels := make(map[string]string, len(rule.Labels)+6)
+	for name, value := range rule.Labels {
+		labels[name] = value
+	}
+	if rule.Severity != "" {
+		labels[RuleLabelKeySeverity] = string(rule.Severity)
+	}
+	labels[prommodel.AlertNameLabel] = rule.Alert
+	labels[RuleLabelKeyNamespace] = group.Namespace
+	labels[RuleLabelKeyRuleGroup] = group.Name
+	labels[RuleLabelKeyRuleLevel] = string(RuleLevelNamesapce)
+	labels[RuleLabelKeyAlertType] = RuleLabelValueAlertTypeLog
+	return labels
+}
+
+func logRuleAlert(group *alertingv2beta1.LogRuleGroup, rule *alertingv2beta1.LogRule,
+	status *alertingv2beta1.LogRuleStatus, endsAt time.Time) *alerting.PostableAlert {
+	alert := &alerting.PostableAlert{
+		Labels:      LogRuleAlertLabels(group, rule),
+		Annotations: rule.Annotations,
+		EndsAt:      endsAt,
+	}
+	if status.FiredAt != nil {
+		alert.StartsAt = status.FiredAt.Time
+	}
+	return alert
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+type fakeLoggingClient struct {
+	total   int64
+	filters []logging.SearchFilter
+}
+
+func (f *fakeLoggingClient) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, error) {
+	return logging.Statistics{}, nil
+}
+
+func (f *fakeLoggingClient) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
+	f.filters = append(f.filters, sf)
+	return logging.Histogram{Total: f.total}, nil
+}
+
+func (f *fakeLoggingClient) SearchLogs(sf logging.SearchFilter, from, size int64, order string) (logging.Logs, error) {
+	return logging.Logs{}, nil
+}
+
+func (f *fakeLoggingClient) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
+	return nil
+}
+
+type fakeAlertClient struct {
+	alerts []*alerting.PostableAlert
+}
+
+func (f *fakeAlertClient) PostAlerts(ctx context.Context, alerts ...*alerting.PostableAlert) error {
+	f.alerts = append(f.alerts, alerts...)
+	return nil
+}
+
+func TestLogRuleGroupReconcile(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+	_ = alertingv2beta1.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	loggingClient := &fakeLoggingClient{total: 60}
+	alertClient := &fakeAlertClient{}
+	r := &LogRuleGroupReconciler{
+		Client: fake.NewClientBuilder().WithScheme(sch).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+			&alertingv2beta1.LogRuleGroup{
+				ObjectMeta: metav1.ObjectMeta{Name: "oom", Namespace: "test"},
+				Spec: alertingv2beta1.LogRuleGroupSpec{Rules: []alertingv2beta1.LogRule{{
+					Alert: "OutOfMemory",
+					Condition: alertingv2beta1.LogCondition{
+						Filter:     alertingv2beta1.LogFilter{LogSearch: []string{"OutOfMemoryError"}},
+						Window:     "5m",
+						Comparator: alertingv2beta1.ComparatorGT,
+						Threshold:  50,
+					},
+					For:      "2m",
+					Severity: "critical",
+				}}},
+			},
+		).Build(),
+		Log:           ctrl.Log,
+		LoggingClient: loggingClient,
+		AlertClient:   alertClient,
+		now:           func() time.Time { return now },
+	}
+	reconcile := func() alertingv2beta1.LogRuleStatus {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "oom"}}
+		if result, err := r.Reconcile(context.Background(), req); err != nil || result.RequeueAfter != time.Minute {
+			t.Fatalf("unexpected result %v, %v", result, err)
+		}
+		group := &alertingv2beta1.LogRuleGroup{}
+		if err := r.Get(context.Background(), req.NamespacedName, group); err != nil {
+			t.Fatal(err)
+		}
+		if len(group.Status.RulesStatus) != 1 {
+			t.Fatalf("unexpected status %v", group.Status)
+		}
+		return group.Status.RulesStatus[0]
+	}
+
+	// the alert is pending until the condition holds for 2m
+	status := reconcile()
+	if status.State != alertStatePending || status.Count != 60 || len(alertClient.alerts) != 0 {
+		t.Fatalf("unexpected status %v", status)
+	}
+	sf := loggingClient.filters[0]
+	if _, ok := sf.NamespaceFilter["test"]; !ok || len(sf.LogSearch) != 1 ||
+		!sf.Starttime.Equal(now.Add(-5*time.Minute)) || !sf.Endtime.Equal(now) {
+		t.Fatalf("unexpected search filter %v", sf)
+	}
+
+	now = now.Add(2 * time.Minute)
+	status = reconcile()
+	if status.State != alertStateFiring || len(alertClient.alerts) != 1 {
+		t.Fatalf("unexpected status %v", status)
+	}
+	alert := alertClient.alerts[0]
+	if alert.Labels["alertname"] != "OutOfMemory" || alert.Labels[RuleLabelKeyNamespace] != "test" ||
+		alert.Labels[RuleLabelKeyRuleGroup] != "oom" || alert.Labels[RuleLabelKeyAlertType] != RuleLabelValueAlertTypeLog ||
+		alert.Labels[RuleLabelKeySeverity] != "critical" || !alert.StartsAt.Equal(now) || !alert.EndsAt.After(now) {
+		t.Fatalf("unexpected alert %v", alert)
+	}
+
+	// the alert is resolved once the condition doesn't hold
+	loggingClient.total = 10
+	now = now.Add(time.Minute)
+	status = reconcile()
+	if status.State != alertStateInactive || status.FiredAt != nil || len(alertClient.alerts) != 2 ||
+		!alertClient.alerts[1].EndsAt.Equal(now) {
+		t.Fatalf("unexpected status %v", status)
+	}
+}
+
+// conflictingStatusClient fails the status updates as if the object was modified in the meantime.
+type conflictingStatusClient struct {
+	client.Client
+}
+
+func (c *conflictingStatusClient) Status() client.SubResourceWriter {
+	return &conflictingStatusWriter{c.Client.Status()}
+}
+
+type conflictingStatusWriter struct {
+	client.SubResourceWriter
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return apierrors.NewConflict(alertingv2beta1.Resource("logrulegroups"), obj.GetName(), fmt.Errorf("the object has been modified"))
+}
+
+func TestLogRuleGroupReconcileConflict(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+	_ = alertingv2beta1.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	firedAt := metav1.NewTime(now.Add(-time.Hour))
+	alertClient := &fakeAlertClient{}
+	r := &LogRuleGroupReconciler{
+		Client: &conflictingStatusClient{fake.NewClientBuilder().WithScheme(sch).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+			&alertingv2beta1.LogRuleGroup{
+				ObjectMeta: metav1.ObjectMeta{Name: "oom", Namespace: "test"},
+				Spec: alertingv2beta1.LogRuleGroupSpec{Rules: []alertingv2beta1.LogRule{{
+					Alert: "OutOfMemory",
+					Condition: alertingv2beta1.LogCondition{
+						Window:     "5m",
+						Comparator: alertingv2beta1.ComparatorGT,
+						Threshold:  50,
+					},
+				}}},
+				Status: alertingv2beta1.LogRuleGroupStatus{RulesStatus: []alertingv2beta1.LogRuleStatus{{
+					Alert: "OutOfMemory", State: alertStateFiring, ActiveAt: &firedAt, FiredAt: &firedAt,
+				}}},
+			},
+		).Build()},
+		Log:           ctrl.Log,
+		LoggingClient: &fakeLoggingClient{total: 10},
+		AlertClient:   alertClient,
+		now:           func() time.Time { return now },
+	}
+
+	// the resolution is not sent before the state is saved
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "oom"}}
+	if _, err := r.Reconcile(context.Background(), req); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if len(alertClient.alerts) != 0 {
+		t.Fatalf("unexpected alerts %v", alertClient.alerts)
+	}
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+const defaultSilenceCreator = "kubesphere"
+
+// SilenceReconciler syncs the silences of the namespaces to the alertmanager,
+// the silences are limited to the alerts of their namespaces.
+type SilenceReconciler struct {
+	client.Client
+	Log           logr.Logger
+	SilenceClient alerting.SilenceClient
+
+	now func() time.Time
+}
+
+func (r *SilenceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Log.GetSink() == nil {
+		r.Log = mgr.GetLogger()
+	}
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("silence").
+		// the status updates don't trigger the silences to be synced again
+		For(&alertingv2beta1.Silence{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=alerting.kubesphere.io,resources=silences,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=alerting.kubesphere.io,resources=silences/status,verbs=get;update;patch
+func (r *SilenceReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	silence := &alertingv2beta1.Silence{}
+	if err := r.Get(ctx, req.NamespacedName, silence); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	s := &silenceSyncer{
+		Client:        r.Client,
+		log:           r.Log.WithValues("silence", req.NamespacedName),
+		silenceClient: r.SilenceClient,
+		now:           r.now(),
+		object:        silence,
+		kind:          alertingv2beta1.ResourceKindSilence,
+		spec:          &silence.Spec,
+		status:        &silence.Status,
+		scopeMatchers: []alerting.SilenceMatcher{{
+			Name:    RuleLabelKeyNamespace,
+			Value:   silence.Namespace,
+			IsEqual: true,
+		}},
+	}
+	return s.sync(ctx)
+}
+
+// ClusterSilenceReconciler syncs the silences of the workspaces and the cluster to the alertmanager,
+// the silences of the workspaces are limited to the alerts of the namespaces of the workspaces.
+type ClusterSilenceReconciler struct {
+	client.Client
+	Log           logr.Logger
+	SilenceClient alerting.SilenceClient
+
+	now func() time.Time
+}
+
+func (r *ClusterSilenceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Log.GetSink() == nil {
+		r.Log = mgr.GetLogger()
+	}
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clustersilence").
+		For(&alertingv2beta1.ClusterSilence{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// the silences of the workspaces follow the namespaces of the workspaces
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, r.namespaceHandler()).
+		Complete(r)
+}
+
+// namespaceHandler requeues the silences of the workspaces a namespace joins or leaves,
+// so that a namespace no longer in a workspace is not silenced by the silences of the workspace.
+func (r *ClusterSilenceReconciler) namespaceHandler() handler.Funcs {
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			r.enqueueWorkspaceSilences(q, e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			if e.ObjectOld.GetLabels()[constants.WorkspaceLabelKey] != e.ObjectNew.GetLabels()[constants.WorkspaceLabelKey] {
+				r.enqueueWorkspaceSilences(q, e.ObjectOld, e.ObjectNew)
+			}
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			r.enqueueWorkspaceSilences(q, e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+			r.enqueueWorkspaceSilences(q, e.Object)
+		},
+	}
+}
+
+func (r *ClusterSilenceReconciler) enqueueWorkspaceSilences(q workqueue.RateLimitingInterface, namespaces ...client.Object) {
+	workspaces := make(map[string]bool)
+	for _, ns := range namespaces {
+		if workspace := ns.GetLabels()[constants.WorkspaceLabelKey]; workspace != "" {
+			workspaces[workspace] = true
+		}
+	}
+	if len(workspaces) == 0 {
+		return
+	}
+	silences := &alertingv2beta1.ClusterSilenceList{}
+	if err := r.List(context.Background(), silences); err != nil {
+		r.Log.Error(err, "failed to list cluster silences")
+		return
+	}
+	for _, silence := range silences.Items {
+		if workspaces[silence.Spec.Workspace] {
+			q.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&silence)})
+		}
+	}
+}
+
+// +kubebuilder:rbac:groups=alerting.kubesphere.io,resources=clustersilences,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=alerting.kubesphere.io,resources=clustersilences/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+func (r *ClusterSilenceReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	silence := &alertingv2beta1.ClusterSilence{}
+	if err := r.Get(ctx, req.NamespacedName, silence); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	s := &silenceSyncer{
+		Client:        r.Client,
+		log:           r.Log.WithValues("clustersilence", req.Name),
+		silenceClient: r.SilenceClient,
+		now:           r.now(),
+		object:        silence,
+		kind:          alertingv2beta1.ResourceKindClusterSilence,
+		spec:          &silence.Spec.SilenceSpec,
+		status:        &silence.Status,
+	}
+
+	if workspace := silence.Spec.Workspace; workspace != "" && silence.DeletionTimestamp.IsZero() {
+		namespaces := &corev1.NamespaceList{}
+		if err := r.List(ctx, namespaces, client.MatchingLabels{constants.WorkspaceLabelKey: workspace}); err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(namespaces.Items) == 0 {
+			s.outOfScope = fmt.Sprintf("there are no namespaces in workspace %s", workspace)
+		} else {
+			names := make([]string, len(namespaces.Items))
+			for i := range namespaces.Items {
+				names[i] = regexp.QuoteMeta(namespaces.Items[i].Name)
+			}
+			sort.Strings(names)
+			s.scopeMatchers = []alerting.SilenceMatcher{{
+				Name:    RuleLabelKeyNamespace,
+				Value:   strings.Join(names, "|"),
+				IsRegex: true,
+				IsEqual: true,
+			}}
+		}
+	}
+	return s.sync(ctx)
+}
+
+// silenceSyncer syncs the current or the next window of a silence to the alertmanager.
+type silenceSyncer struct {
+	client.Client
+	log           logr.Logger
+	silenceClient alerting.SilenceClient
+	now           time.Time
+
+	object client.Object
+	kind   string
+	spec   *alertingv2beta1.SilenceSpec
+	status *alertingv2beta1.SilenceStatus
+	// scopeMatchers limit the silence to the alerts of its scope
+	scopeMatchers []alerting.SilenceMatcher
+	// outOfScope tells why there is nothing in the scope of the silence to silence if not empty
+	outOfScope string
+}
+
+func (s *silenceSyncer) sync(ctx context.Context) (reconcile.Result, error) {
+	if !s.object.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(s.object, alertingv2beta1.SilenceFinalizer) {
+			return reconcile.Result{}, nil
+		}
+		if err := s.expire(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(s.object, alertingv2beta1.SilenceFinalizer)
+		return reconcile.Result{}, s.Update(ctx, s.object)
+	}
+	if !controllerutil.ContainsFinalizer(s.object, alertingv2beta1.SilenceFinalizer) {
+		controllerutil.AddFinalizer(s.object, alertingv2beta1.SilenceFinalizer)
+		if err := s.Update(ctx, s.object); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	start, end, ok := silenceWindow(s.spec, s.object.GetCreationTimestamp().Time, s.now)
+	if !ok || s.outOfScope != "" {
+		if err := s.expire(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+		s.status.State, s.status.SilenceID = alertingv2beta1.SilenceStateExpired, ""
+		s.status.StartsAt, s.status.EndsAt = nil, nil
+		s.status.Message = s.outOfScope
+		return reconcile.Result{}, s.updateStatus(ctx)
+	}
+
+	id, err := s.post(ctx, start, end)
+	if err != nil {
+		s.log.Error(err, "failed to sync the silence to the alertmanager")
+		s.status.Message = err.Error()
+		if err := s.updateStatus(ctx); err != nil {
+			s.log.Error(err, "failed to update the status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	startsAt, endsAt := metav1.NewTime(start), metav1.NewTime(end)
+	s.status.SilenceID, s.status.StartsAt, s.status.EndsAt, s.status.Message = id, &startsAt, &endsAt, ""
+	// the silence is synced again once the window starts to update the state,
+	// and once the window ends to sync the next window of the schedule
+	requeueAfter := end.Sub(s.now)
+	s.status.State = alertingv2beta1.SilenceStateActive
+	if start.After(s.now) {
+		s.status.State = alertingv2beta1.SilenceStatePending
+		requeueAfter = start.Sub(s.now)
+	}
+	if err := s.updateStatus(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter + time.Second}, nil
+}
+
+func (s *silenceSyncer) post(ctx context.Context, start, end time.Time) (string, error) {
+	silence := &alerting.Silence{
+		ID:        s.status.SilenceID,
+		Matchers:  append(silenceMatchers(s.spec.Matchers), s.scopeMatchers...),
+		StartsAt:  start,
+		EndsAt:    end,
+		CreatedBy: defaultSilenceCreator,
+		Comment:   s.spec.Comment,
+	}
+	if creator := s.object.GetAnnotations()[constants.CreatorAnnotationKey]; creator != "" {
+		silence.CreatedBy = creator
+	}
+	if silence.Comment == "" {
+		silence.Comment = fmt.Sprintf("%s %s", s.kind, client.ObjectKeyFromObject(s.object))
+	}
+
+	id, err := s.silenceClient.PostSilence(ctx, silence)
+	if err == alerting.ErrSilenceNotFound {
+		// the silence has been removed from the alertmanager, e.g. garbage collected after expired
+		silence.ID = ""
+		id, err = s.silenceClient.PostSilence(ctx, silence)
+	}
+	return id, err
+}
+
+// expire expires the silence in the alertmanager unless it has ended already.
+func (s *silenceSyncer) expire(ctx context.Context) error {
+	if s.status.SilenceID == "" || (s.status.EndsAt != nil && !s.status.EndsAt.After(s.now)) {
+		return nil
+	}
+	if err := s.silenceClient.ExpireSilence(ctx, s.status.SilenceID); err != nil && err != alerting.ErrSilenceNotFound {
+		s.log.Error(err, "failed to expire the silence in the alertmanager")
+		return err
+	}
+	return nil
+}
+
+func (s *silenceSyncer) updateStatus(ctx context.Context) error {
+	now := metav1.NewTime(s.now)
+	s.status.LastSyncTime = &now
+	return s.Status().Update(ctx, s.object)
+}
+
+func silenceMatchers(matchers []alertingv2beta1.SilenceMatcher) []alerting.SilenceMatcher {
+	result := make([]alerting.SilenceMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		result = append(result, alerting.SilenceMatcher{
+			Name:    m.Name,
+			Value:   m.Value,
+			IsRegex: m.Type == alertingv2beta1.MatchRegexp || m.Type == alertingv2beta1.MatchNotRegexp,
+			IsEqual: m.Type == alertingv2beta1.MatchEqual || m.Type == alertingv2beta1.MatchRegexp,
+		})
+	}
+	return result
+}
+
+// silenceWindow returns the current or the next window of the silence, ok is false if the silence has ended.
+// A silence without the schedule starts once created if the start is not specified.
+func silenceWindow(spec *alertingv2beta1.SilenceSpec, created, now time.Time) (start, end time.Time, ok bool) {
+	if spec.Schedule == nil {
+		start = created
+		if spec.StartsAt != nil {
+			start = spec.StartsAt.Time
+		}
+		if spec.EndsAt == nil || !spec.EndsAt.After(now) {
+			return start, end, false
+		}
+		return start, spec.EndsAt.Time, true
+	}
+
+	schedule := spec.Schedule
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return start, end, false
+	}
+	startOfDay, err1 := time.Parse(alertingv2beta1.SilenceScheduleTimeLayout, schedule.Start)
+	endOfDay, err2 := time.Parse(alertingv2beta1.SilenceScheduleTimeLayout, schedule.End)
+	if err1 != nil || err2 != nil {
+		return start, end, false
+	}
+	days := make(map[time.Weekday]bool, len(schedule.DaysOfWeek))
+	for _, day := range schedule.DaysOfWeek {
+		if d, ok := alertingv2beta1.ParseWeekday(day); ok {
+			days[d] = true
+		}
+	}
+
+	from := now
+	if spec.StartsAt != nil && spec.StartsAt.After(from) {
+		from = spec.StartsAt.Time
+	}
+	from = from.In(loc)
+	// starts from the day before, whose window may last until today
+	for i := -1; i <= 7; i++ {
+		day := time.Date(from.Year(), from.Month(), from.Day()+i, 0, 0, 0, 0, loc)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+		start = time.Date(day.Year(), day.Month(), day.Day(), startOfDay.Hour(), startOfDay.Minute(), 0, 0, loc)
+		end = time.Date(day.Year(), day.Month(), day.Day(), endOfDay.Hour(), endOfDay.Minute(), 0, 0, loc)
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+
+		// the windows are clipped by the start and the end of the silence
+		if spec.StartsAt != nil && start.Before(spec.StartsAt.Time) {
+			start = spec.StartsAt.Time
+		}
+		if spec.EndsAt != nil && end.After(spec.EndsAt.Time) {
+			end = spec.EndsAt.Time
+		}
+		if end.After(start) && end.After(now) {
+			return start, end, true
+		}
+	}
+	return start, end, false
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+type fakeSilenceClient struct {
+	silences map[string]*alerting.Silence
+	expired  []string
+	nextID   int
+}
+
+func (f *fakeSilenceClient) PostSilence(ctx context.Context, silence *alerting.Silence) (string, error) {
+	id := silence.ID
+	if id == "" {
+		f.nextID++
+		id = fmt.Sprintf("silence-%d", f.nextID)
+	} else if _, ok := f.silences[id]; !ok {
+		return "", alerting.ErrSilenceNotFound
+	}
+	f.silences[id] = silence
+	return id, nil
+}
+
+func (f *fakeSilenceClient) ExpireSilence(ctx context.Context, id string) error {
+	if _, ok := f.silences[id]; !ok {
+		return alerting.ErrSilenceNotFound
+	}
+	delete(f.silences, id)
+	f.expired = append(f.expired, id)
+	return nil
+}
+
+func TestSilenceWindow(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	created := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	// Sunday
+	now := time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC)
+	timeOf := func(t time.Time) *metav1.Time {
+		mt := metav1.NewTime(t)
+		return &mt
+	}
+
+	tests := []struct {
+		description string
+		spec        alertingv2beta1.SilenceSpec
+		start, end  time.Time
+		ok          bool
+	}{{
+		description: "one-off silence starts once created",
+		spec:        alertingv2beta1.SilenceSpec{EndsAt: timeOf(now.Add(time.Hour))},
+		start:       created,
+		end:         now.Add(time.Hour),
+		ok:          true,
+	}, {
+		description: "one-off silence has ended",
+		spec:        alertingv2beta1.SilenceSpec{EndsAt: timeOf(now.Add(-time.Hour))},
+	}, {
+		description: "in the window of every Sunday",
+		spec: alertingv2beta1.SilenceSpec{Schedule: &alertingv2beta1.SilenceSchedule{
+			DaysOfWeek: []alertingv2beta1.Weekday{"Sunday"}, Start: "02:00", End: "04:00",
+		}},
+		start: time.Date(2023, 12, 10, 2, 0, 0, 0, time.UTC),
+		end:   time.Date(2023, 12, 10, 4, 0, 0, 0, time.UTC),
+		ok:    true,
+	}, {
+		description: "next window of every Sunday",
+		spec: alertingv2beta1.SilenceSpec{Schedule: &alertingv2beta1.SilenceSchedule{
+			DaysOfWeek: []alertingv2beta1.Weekday{"Sunday"}, Start: "04:00", End: "05:00",
+		}},
+		start: time.Date(2023, 12, 10, 4, 0, 0, 0, time.UTC),
+		end:   time.Date(2023, 12, 10, 5, 0, 0, 0, time.UTC),
+		ok:    true,
+	}, {
+		description: "window of Saturday lasts until Sunday",
+		spec: alertingv2beta1.SilenceSpec{Schedule: &alertingv2beta1.SilenceSchedule{
+			DaysOfWeek: []alertingv2beta1.Weekday{"Saturday"}, Start: "22:00", End: "06:00",
+		}},
+		start: time.Date(2023, 12, 9, 22, 0, 0, 0, time.UTC),
+		end:   time.Date(2023, 12, 10, 6, 0, 0, 0, time.UTC),
+		ok:    true,
+	}, {
+		description: "window in the time zone",
+		spec: alertingv2beta1.SilenceSpec{Schedule: &alertingv2beta1.SilenceSchedule{
+			Start: "02:00", End: "04:00", TimeZone: "Asia/Shanghai",
+		}},
+		start: time.Date(2023, 12, 11, 2, 0, 0, 0, shanghai),
+		end:   time.Date(2023, 12, 11, 4, 0, 0, 0, shanghai),
+		ok:    true,
+	}, {
+		description: "window clipped by the end of the silence",
+		spec: alertingv2beta1.SilenceSpec{
+			EndsAt: timeOf(time.Date(2023, 12, 10, 3, 30, 0, 0, time.UTC)),
+			Schedule: &alertingv2beta1.SilenceSchedule{
+				Start: "02:00", End: "04:00",
+			}},
+		start: time.Date(2023, 12, 10, 2, 0, 0, 0, time.UTC),
+		end:   time.Date(2023, 12, 10, 3, 30, 0, 0, time.UTC),
+		ok:    true,
+	}, {
+		description: "schedule takes effect in the future",
+		spec: alertingv2beta1.SilenceSpec{
+			StartsAt: timeOf(time.Date(2023, 12, 20, 0, 0, 0, 0, time.UTC)),
+			Schedule: &alertingv2beta1.SilenceSchedule{
+				DaysOfWeek: []alertingv2beta1.Weekday{"Sunday"}, Start: "02:00", End: "04:00",
+			}},
+		start: time.Date(2023, 12, 24, 2, 0, 0, 0, time.UTC),
+		end:   time.Date(2023, 12, 24, 4, 0, 0, 0, time.UTC),
+		ok:    true,
+	}, {
+		description: "schedule has stopped taking effect",
+		spec: alertingv2beta1.SilenceSpec{
+			EndsAt: timeOf(time.Date(2023, 12, 10, 1, 0, 0, 0, time.UTC)),
+			Schedule: &alertingv2beta1.SilenceSchedule{
+				Start: "02:00", End: "04:00",
+			}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			start, end, ok := silenceWindow(&test.spec, created, now)
+			if ok != test.ok {
+				t.Fatalf("expected ok %v, got %v", test.ok, ok)
+			}
+			if ok && (!start.Equal(test.start) || !end.Equal(test.end)) {
+				t.Fatalf("expected window %s - %s, got %s - %s", test.start, test.end, start, end)
+			}
+		})
+	}
+}
+
+func TestReconcileSilence(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = alertingv2beta1.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC)
+	silence := &alertingv2beta1.Silence{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "maintenance",
+			Namespace:   "test",
+			Annotations: map[string]string{constants.CreatorAnnotationKey: "admin"},
+		},
+		Spec: alertingv2beta1.SilenceSpec{
+			Matchers: []alertingv2beta1.SilenceMatcher{{
+				Name:    "alertname",
+				Matcher: alertingv2beta1.Matcher{Type: alertingv2beta1.MatchRegexp, Value: "Pod.*"},
+			}},
+			Schedule: &alertingv2beta1.SilenceSchedule{
+				DaysOfWeek: []alertingv2beta1.Weekday{"Sunday"}, Start: "02:00", End: "04:00",
+			},
+		},
+	}
+	silenceClient := &fakeSilenceClient{silences: map[string]*alerting.Silence{}}
+	r := &SilenceReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(sch).WithObjects(silence).Build(),
+		Log:           ctrl.Log,
+		SilenceClient: silenceClient,
+		now:           func() time.Time { return now },
+	}
+	key := types.NamespacedName{Namespace: silence.Namespace, Name: silence.Name}
+	reconcile := func() ctrl.Result {
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Get(context.Background(), key, silence); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// the current window is synced and limited to the namespace
+	result := reconcile()
+	if silence.Status.State != alertingv2beta1.SilenceStateActive || silence.Status.SilenceID != "silence-1" {
+		t.Fatalf("unexpected status %v", silence.Status)
+	}
+	if result.RequeueAfter != time.Hour+time.Second {
+		t.Fatalf("unexpected requeue after %s", result.RequeueAfter)
+	}
+	posted := silenceClient.silences["silence-1"]
+	if diff := cmp.Diff(posted.Matchers, []alerting.SilenceMatcher{
+		{Name: "alertname", Value: "Pod.*", IsRegex: true, IsEqual: true},
+		{Name: "namespace", Value: "test", IsEqual: true},
+	}); diff != "" {
+		t.Fatalf("matchers differ (-got, +want): %s", diff)
+	}
+	if posted.CreatedBy != "admin" || !posted.EndsAt.Equal(time.Date(2023, 12, 10, 4, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected silence %v", posted)
+	}
+
+	// the next window is synced once the current one ends, a new silence is created if the last one is gone
+	delete(silenceClient.silences, "silence-1")
+	now = time.Date(2023, 12, 10, 4, 0, 1, 0, time.UTC)
+	reconcile()
+	if silence.Status.State != alertingv2beta1.SilenceStatePending || silence.Status.SilenceID != "silence-2" ||
+		!silence.Status.StartsAt.Time.Equal(time.Date(2023, 12, 17, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected status %v", silence.Status)
+	}
+
+	// the silence is expired in the alertmanager before deleted
+	if err := r.Delete(context.Background(), silence); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(silenceClient.expired, []string{"silence-2"}); diff != "" {
+		t.Fatalf("expired silences differ (-got, +want): %s", diff)
+	}
+}
+
+func TestReconcileWorkspaceSilence(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = alertingv2beta1.AddToScheme(sch)
+	_ = corev1.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC)
+	endsAt := metav1.NewTime(now.Add(time.Hour))
+	silence := &alertingv2beta1.ClusterSilence{
+		ObjectMeta: metav1.ObjectMeta{Name: "maintenance"},
+		Spec: alertingv2beta1.ClusterSilenceSpec{
+			Workspace: "ws",
+			SilenceSpec: alertingv2beta1.SilenceSpec{
+				Matchers: []alertingv2beta1.SilenceMatcher{{
+					Name:    "severity",
+					Matcher: alertingv2beta1.Matcher{Type: alertingv2beta1.MatchNotEqual, Value: "critical"},
+				}},
+				EndsAt: &endsAt,
+			},
+		},
+	}
+	namespaces := []*corev1.Namespace{{
+		ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{constants.WorkspaceLabelKey: "ws"}},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{constants.WorkspaceLabelKey: "ws"}},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{constants.WorkspaceLabelKey: "other"}},
+	}}
+	silenceClient := &fakeSilenceClient{silences: map[string]*alerting.Silence{}}
+	r := &ClusterSilenceReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(sch).WithObjects(silence, namespaces[0], namespaces[1], namespaces[2]).Build(),
+		Log:           ctrl.Log,
+		SilenceClient: silenceClient,
+		now:           func() time.Time { return now },
+	}
+	key := types.NamespacedName{Name: silence.Name}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.Background(), key, silence); err != nil {
+		t.Fatal(err)
+	}
+	if silence.Status.State != alertingv2beta1.SilenceStateActive {
+		t.Fatalf("unexpected status %v", silence.Status)
+	}
+	if diff := cmp.Diff(silenceClient.silences[silence.Status.SilenceID].Matchers, []alerting.SilenceMatcher{
+		{Name: "severity", Value: "critical"},
+		{Name: "namespace", Value: "a|b", IsRegex: true, IsEqual: true},
+	}); diff != "" {
+		t.Fatalf("matchers differ (-got, +want): %s", diff)
+	}
+
+	// the silences of both the workspace the namespace leaves and the one it joins are requeued
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	moved := namespaces[0].DeepCopy()
+	moved.Labels[constants.WorkspaceLabelKey] = "other"
+	r.namespaceHandler().Update(event.UpdateEvent{ObjectOld: namespaces[0], ObjectNew: moved}, queue)
+	if queue.Len() != 1 {
+		t.Fatalf("expected the silence of the workspace left requeued, got %d", queue.Len())
+	}
+	if item, _ := queue.Get(); item.(reconcile.Request).Name != silence.Name {
+		t.Fatalf("unexpected request %v", item)
+	}
+	r.namespaceHandler().Update(event.UpdateEvent{ObjectOld: namespaces[2], ObjectNew: namespaces[2]}, queue)
+	r.namespaceHandler().Create(event.CreateEvent{Object: namespaces[2]}, queue)
+	if queue.Len() != 0 {
+		t.Fatalf("unexpected requests %d", queue.Len())
+	}
+}
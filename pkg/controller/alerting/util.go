@@ -54,6 +54,7 @@ const (
 	RuleLabelKeySeverity          = "severity"
 	RuleLabelKeyAlertType         = "alerttype"
 	RuleLabelValueAlertTypeMetric = "metric"
+	RuleLabelValueAlertTypeLog    = "log"
 
 	// label keys in RuleGroup/ClusterRuleGroup/GlobalRuleGroup.metadata.labels
 	SourceGroupResourceLabelKeyEnable        = "alerting.kubesphere.io/enable"
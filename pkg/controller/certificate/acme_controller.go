@@ -0,0 +1,331 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/gateway"
+	"kubesphere.io/kubesphere/pkg/simple/client/acme"
+)
+
+const (
+	acmeControllerName = "acme-controller"
+
+	// the account key of the ACME issuer is kept in the secret
+	acmeAccountSecretName = "kubesphere-acme-account"
+	acmeAccountKey        = "key.pem"
+
+	// the certificates are checked daily, in case the secrets are modified
+	maxACMERequeueInterval = 24 * time.Hour
+	acmeSolverSuffix       = "-acme-solver"
+)
+
+// ACMEReconciler issues the certificates of the TLS hosts of the ingresses annotated with kubernetes.io/tls-acme
+// through the ACME issuer, and renews them before they expire. The HTTP-01 challenges are routed to the solver
+// by temporary ingresses of the hosts.
+type ACMEReconciler struct {
+	client.Client
+	Logger  logr.Logger
+	Options *acme.Options
+	Solver  acme.ChallengeSolver
+
+	mutex  sync.Mutex
+	issuer acme.Issuer
+	now    func() time.Time
+}
+
+func (r *ACMEReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(acmeControllerName)
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(acmeControllerName).
+		For(&networkingv1.Ingress{}, builder.WithPredicates(predicate.NewPredicateFuncs(acmeRequested))).
+		Complete(r)
+}
+
+func acmeRequested(o client.Object) bool {
+	return o.GetAnnotations()[gateway.AnnotationTLSACME] == "true"
+}
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services;secrets,verbs=get;list;watch;create;update;patch;delete
+func (r *ACMEReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("ingress", req.NamespacedName)
+
+	ingress := &networkingv1.Ingress{}
+	if err := r.Get(ctx, req.NamespacedName, ingress); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !ingress.DeletionTimestamp.IsZero() || !acmeRequested(ingress) {
+		return ctrl.Result{}, nil
+	}
+
+	now := r.now()
+	requeueAfter := maxACMERequeueInterval
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName == "" || len(tls.Hosts) == 0 {
+			continue
+		}
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: ingress.Namespace, Name: tls.SecretName}, secret)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if err == nil {
+			renewAt := gateway.CertificateRenewalTime(secret, tls.Hosts, r.Options.RenewBefore)
+			if now.Before(renewAt) {
+				if d := renewAt.Sub(now); d < requeueAfter {
+					requeueAfter = d
+				}
+				continue
+			}
+		}
+
+		logger.Info("issue the certificate", "secret", tls.SecretName, "hosts", tls.Hosts)
+		if err := r.issue(ctx, ingress, tls); err != nil {
+			logger.Error(err, "failed to issue the certificate", "secret", tls.SecretName)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// issue obtains the certificate of the hosts and saves it into the secret, the challenges are routed to
+// the solver during the issuing.
+func (r *ACMEReconciler) issue(ctx context.Context, ingress *networkingv1.Ingress, tls networkingv1.IngressTLS) error {
+	issuer, err := r.getIssuer(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.cleanUpChallenges(ctx, ingress); err != nil {
+			r.Logger.Error(err, "failed to clean up the routes of the challenges", "ingress", ingress.Name)
+		}
+	}()
+	if err := r.routeChallenges(ctx, ingress, tls.Hosts); err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	chain, err := issuer.Issue(ctx, key, tls.Hosts)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: tls.SecretName}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		secret.Annotations[gateway.AnnotationACMEIssuer] = r.Options.DirectoryURL
+		secret.Type = corev1.SecretTypeTLS
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       chain,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		}
+		return nil
+	})
+	return err
+}
+
+// getIssuer creates the issuer with the account key kept in the secret, which is generated at the first time.
+func (r *ACMEReconciler) getIssuer(ctx context.Context) (acme.Issuer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.issuer != nil {
+		return r.issuer, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: acmeAccountSecretName}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var accountKey crypto.Signer
+	if err == nil {
+		block, _ := pem.Decode(secret.Data[acmeAccountKey])
+		if block == nil {
+			return nil, fmt.Errorf("invalid acme account key in secret %s/%s", constants.KubeSphereNamespace, acmeAccountSecretName)
+		}
+		if accountKey, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		keyPEM, err := encodeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: constants.KubeSphereNamespace, Name: acmeAccountSecretName},
+			Data:       map[string][]byte{acmeAccountKey: keyPEM},
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return nil, err
+		}
+		accountKey = key
+	}
+
+	issuer, err := acme.NewIssuer(r.Options, accountKey, r.Solver)
+	if err != nil {
+		return nil, err
+	}
+	r.issuer = issuer
+	return issuer, nil
+}
+
+// routeChallenges routes the HTTP-01 challenges of the hosts to the solver, by an ingress of the same class
+// as the ingress and an ExternalName service of the solver. The objects of the same names not created for
+// the ingress are left untouched, and the challenges are not routed.
+func (r *ACMEReconciler) routeChallenges(ctx context.Context, ingress *networkingv1.Ingress, hosts []string) error {
+	host, portName, err := net.SplitHostPort(r.Options.SolverService)
+	if err != nil {
+		return fmt.Errorf("invalid acme solver service %s: %v", r.Options.SolverService, err)
+	}
+	port, err := strconv.Atoi(portName)
+	if err != nil {
+		return fmt.Errorf("invalid acme solver service %s: %v", r.Options.SolverService, err)
+	}
+	name := ingress.Name + acmeSolverSuffix
+
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: name}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		if err := checkSolverOwner(ingress, service); err != nil {
+			return err
+		}
+		service.Spec.Type = corev1.ServiceTypeExternalName
+		service.Spec.ExternalName = host
+		service.Spec.Ports = []corev1.ServicePort{{Name: "http", Port: int32(port)}}
+		return controllerutil.SetControllerReference(ingress, service, r.Scheme())
+	}); err != nil {
+		return err
+	}
+
+	solver := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: name}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, solver, func() error {
+		if err := checkSolverOwner(ingress, solver); err != nil {
+			return err
+		}
+		// served by the same ingress controller as the ingress
+		if class, ok := ingress.Annotations["kubernetes.io/ingress.class"]; ok {
+			if solver.Annotations == nil {
+				solver.Annotations = make(map[string]string)
+			}
+			solver.Annotations["kubernetes.io/ingress.class"] = class
+		}
+		solver.Spec.IngressClassName = ingress.Spec.IngressClassName
+
+		pathType := networkingv1.PathTypePrefix
+		solver.Spec.Rules = nil
+		for _, h := range hosts {
+			solver.Spec.Rules = append(solver.Spec.Rules, networkingv1.IngressRule{
+				Host: h,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     acme.HTTP01ChallengePath,
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: name,
+							Port: networkingv1.ServiceBackendPort{Number: int32(port)},
+						}},
+					}},
+				}},
+			})
+		}
+		return controllerutil.SetControllerReference(ingress, solver, r.Scheme())
+	})
+	return err
+}
+
+// checkSolverOwner checks the existing object of the solver is created for the ingress.
+func checkSolverOwner(ingress *networkingv1.Ingress, obj client.Object) error {
+	if obj.GetResourceVersion() != "" && !metav1.IsControlledBy(obj, ingress) {
+		return fmt.Errorf("%s/%s exists and is not created for the acme challenges of ingress %s", obj.GetNamespace(), obj.GetName(), ingress.Name)
+	}
+	return nil
+}
+
+// cleanUpChallenges removes the routes of the challenges created for the ingress.
+func (r *ACMEReconciler) cleanUpChallenges(ctx context.Context, ingress *networkingv1.Ingress) error {
+	key := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name + acmeSolverSuffix}
+	for _, obj := range []client.Object{&networkingv1.Ingress{}, &corev1.Service{}} {
+		if err := r.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(obj, ingress) {
+			continue
+		}
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
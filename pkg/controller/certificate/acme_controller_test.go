@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/gateway"
+	"kubesphere.io/kubesphere/pkg/simple/client/acme"
+)
+
+// fakeIssuer issues self signed certificates, and checks the challenges are routed to the solver.
+type fakeIssuer struct {
+	t      *testing.T
+	client client.Client
+	now    time.Time
+	issued [][]string
+}
+
+func (f *fakeIssuer) Issue(ctx context.Context, key crypto.Signer, hosts []string) ([]byte, error) {
+	solver := &networkingv1.Ingress{}
+	if err := f.client.Get(ctx, types.NamespacedName{Namespace: "test", Name: "web" + acmeSolverSuffix}, solver); err != nil {
+		f.t.Fatalf("the challenges are not routed: %v", err)
+	}
+	if len(solver.Spec.Rules) != len(hosts) || solver.Spec.Rules[0].Host != hosts[0] ||
+		solver.Spec.Rules[0].HTTP.Paths[0].Path != acme.HTTP01ChallengePath {
+		f.t.Fatalf("unexpected solver ingress %+v", solver.Spec)
+	}
+	service := &corev1.Service{}
+	if err := f.client.Get(ctx, types.NamespacedName{Namespace: "test", Name: "web" + acmeSolverSuffix}, service); err != nil ||
+		service.Spec.ExternalName != "ks-controller-manager.kubesphere-system.svc" {
+		f.t.Fatalf("unexpected solver service %+v, %v", service.Spec, err)
+	}
+
+	f.issued = append(f.issued, hosts)
+	return newCertificate(f.t, key, f.now.Add(90*24*time.Hour), hosts...), nil
+}
+
+func TestACMEReconcile(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	ingressClass := "kubesphere-router-test"
+	c := fake.NewClientBuilder().WithScheme(sch).WithObjects(
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "web",
+				UID:         "web-uid",
+				Annotations: map[string]string{gateway.AnnotationTLSACME: "true"},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: &ingressClass,
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{"a.example.com", "b.example.com"}, SecretName: "web-tls"},
+					{Hosts: []string{"c.example.com"}, SecretName: "valid-tls"},
+				},
+			},
+		},
+		newTLSSecret(t, "test", "valid-tls", now.Add(60*24*time.Hour), "c.example.com"),
+	).Build()
+
+	options := acme.NewACMEOptions()
+	options.DirectoryURL = "https://localhost:14000/dir"
+	r := &ACMEReconciler{
+		Client:  c,
+		Logger:  ctrl.Log,
+		Options: options,
+		Solver:  acme.NewHTTP01Solver(c, c, ":8090"),
+		now:     func() time.Time { return now },
+	}
+
+	// the account key is generated once
+	if _, err := r.getIssuer(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	account := &corev1.Secret{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: acmeAccountSecretName}, account); err != nil ||
+		len(account.Data[acmeAccountKey]) == 0 {
+		t.Fatalf("unexpected account secret %v, %v", account.Data, err)
+	}
+
+	issuer := &fakeIssuer{t: t, client: c, now: now}
+	r.issuer = issuer
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "web"}}
+	result, err := r.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the valid certificate is renewed 30 days before it expires
+	if result.RequeueAfter != maxACMERequeueInterval {
+		t.Errorf("unexpected requeue after %s", result.RequeueAfter)
+	}
+	if len(issuer.issued) != 1 || len(issuer.issued[0]) != 2 {
+		t.Fatalf("unexpected certificates issued %v", issuer.issued)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "test", Name: "web-tls"}, secret); err != nil {
+		t.Fatal(err)
+	}
+	if secret.Type != corev1.SecretTypeTLS || secret.Annotations[gateway.AnnotationACMEIssuer] != options.DirectoryURL ||
+		len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		t.Errorf("unexpected secret %+v", secret)
+	}
+	if renewAt := gateway.CertificateRenewalTime(secret, []string{"a.example.com", "b.example.com"}, options.RenewBefore); !renewAt.Equal(now.Add(60 * 24 * time.Hour)) {
+		t.Errorf("unexpected renewal time %s", renewAt)
+	}
+
+	// the routes of the challenges are removed once the certificate is issued
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "test", Name: "web" + acmeSolverSuffix}, &networkingv1.Ingress{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the solver ingress to be removed, got %v", err)
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "test", Name: "web" + acmeSolverSuffix}, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the solver service to be removed, got %v", err)
+	}
+
+	// renewed before the certificate expires
+	now = now.Add(61 * 24 * time.Hour)
+	issuer.now = now
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(issuer.issued) != 3 {
+		t.Errorf("expected both certificates to be renewed, got %v", issuer.issued)
+	}
+}
+
+func TestACMEReconcileSolverConflict(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+
+	// a service of the user which happens to have the name of the solver
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "web" + acmeSolverSuffix},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: map[string]string{"app": "web"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).WithObjects(
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "web",
+				UID:         "web-uid",
+				Annotations: map[string]string{gateway.AnnotationTLSACME: "true"},
+			},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{Hosts: []string{"a.example.com"}, SecretName: "web-tls"}},
+			},
+		},
+		service,
+	).Build()
+
+	options := acme.NewACMEOptions()
+	issuer := &fakeIssuer{t: t, client: c, now: time.Now()}
+	r := &ACMEReconciler{
+		Client:  c,
+		Logger:  ctrl.Log,
+		Options: options,
+		Solver:  acme.NewHTTP01Solver(c, c, ":8090"),
+		issuer:  issuer,
+		now:     time.Now,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "web"}}
+	if _, err := r.Reconcile(context.Background(), req); err == nil {
+		t.Fatal("expected the challenges not routed")
+	}
+	if len(issuer.issued) != 0 {
+		t.Fatalf("unexpected certificates issued %v", issuer.issued)
+	}
+
+	// the service of the user is neither taken over nor removed
+	got := &corev1.Service{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(service), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Type != corev1.ServiceTypeClusterIP || len(got.OwnerReferences) != 0 {
+		t.Fatalf("unexpected service %+v", got)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kubesphere.io/kubesphere/pkg/models/gateway"
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+const (
+	expiryControllerName = "certificate-expiry-controller"
+
+	// the days remaining change daily, the certificates are scanned hourly to catch up with the changes
+	// not watched, e.g. the gateways in other namespaces
+	defaultScanInterval = time.Hour
+	defaultExpiryDays   = 30
+
+	certificateExpiringAlertName = "CertificateExpiring"
+	certificateExpiredAlertName  = "CertificateExpired"
+)
+
+// ExpiryReconciler scans the TLS certificates referenced by the ingresses and the gateways of each namespace,
+// and sends the alerts of the certificates expiring or expired to the alertmanager, which notifies them
+// to the tenants of the namespace.
+type ExpiryReconciler struct {
+	client.Client
+	Logger      logr.Logger
+	AlertClient alerting.AlertClient
+	// ExpiryDays is how many days before the certificates expire to alert.
+	ExpiryDays   int
+	ScanInterval time.Duration
+
+	now func() time.Time
+}
+
+func (r *ExpiryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(expiryControllerName)
+	}
+	if r.ExpiryDays <= 0 {
+		r.ExpiryDays = defaultExpiryDays
+	}
+	if r.ScanInterval <= 0 {
+		r.ScanInterval = defaultScanInterval
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	// the gateways referring to the secrets of each namespace are listed by the index,
+	// unless Gateway API is not installed
+	if err := gateway.IndexGatewaySecretNamespaces(context.Background(), mgr.GetFieldIndexer()); err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(expiryControllerName).
+		For(&corev1.Namespace{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(namespaceOf),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+				return o.(*corev1.Secret).Type == corev1.SecretTypeTLS
+			}))).
+		Watches(&source.Kind{Type: &networkingv1.Ingress{}}, handler.EnqueueRequestsFromMapFunc(namespaceOf),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+func namespaceOf(o client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: o.GetNamespace()}}}
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
+func (r *ExpiryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("namespace", req.Name)
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, req.NamespacedName, namespace); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !namespace.DeletionTimestamp.IsZero() {
+		// the alerts are resolved by the alertmanager once they are not sent any more
+		return ctrl.Result{}, nil
+	}
+
+	now := r.now()
+	certificates, err := gateway.ScanCertificates(ctx, r.Client, namespace.Name, r.ExpiryDays, now)
+	if err != nil {
+		logger.Error(err, "failed to scan the certificates")
+		return ctrl.Result{}, err
+	}
+
+	var alerts []*alerting.PostableAlert
+	for i := range certificates {
+		certificate := &certificates[i]
+		if certificate.Status != gateway.CertificateExpiring && certificate.Status != gateway.CertificateExpired {
+			continue
+		}
+		// the alert is resolved by the alertmanager if it is not sent again, e.g. the certificate is renewed
+		alerts = append(alerts, certificateAlert(certificate, r.ExpiryDays, now.Add(2*r.ScanInterval)))
+	}
+	if err := r.AlertClient.PostAlerts(ctx, alerts...); err != nil {
+		logger.Error(err, "failed to send the alerts to the alertmanager")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.ScanInterval}, nil
+}
+
+// certificateAlert returns the alert of the certificate, which starts when the certificate begins to expire
+// so that the alert is the same in every scan.
+func certificateAlert(certificate *gateway.Certificate, expiryDays int, endsAt time.Time) *alerting.PostableAlert {
+	alertName, severity := certificateExpiringAlertName, "warning"
+	summary := fmt.Sprintf("Certificate %s/%s expires in %d days", certificate.Namespace, certificate.SecretName, certificate.DaysRemaining)
+	startsAt := certificate.NotAfter.Add(-time.Duration(expiryDays) * 24 * time.Hour)
+	if certificate.Status == gateway.CertificateExpired {
+		alertName, severity = certificateExpiredAlertName, "critical"
+		summary = fmt.Sprintf("Certificate %s/%s is expired", certificate.Namespace, certificate.SecretName)
+		startsAt = certificate.NotAfter.Time
+	}
+
+	references := make([]string, 0, len(certificate.References))
+	for _, ref := range certificate.References {
+		references = append(references, fmt.Sprintf("%s %s/%s", strings.ToLower(ref.Kind), ref.Namespace, ref.Name))
+	}
+
+	return &alerting.PostableAlert{
+		Labels: map[string]string{
+			"alertname": alertName,
+			"alerttype": "certificate",
+			"severity":  severity,
+			// routes the alert to the tenants of the namespace
+			"namespace": certificate.Namespace,
+			"secret":    certificate.SecretName,
+		},
+		Annotations: map[string]string{
+			"summary": summary,
+			"message": fmt.Sprintf("The certificate of %s, used by %s, is not valid after %s.",
+				strings.Join(certificate.DNSNames, ", "), strings.Join(references, ", "),
+				certificate.NotAfter.UTC().Format(time.RFC3339)),
+		},
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+	}
+}
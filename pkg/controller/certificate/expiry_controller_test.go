@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/kubesphere/pkg/models/gateway"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/gatewayapi"
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+type fakeAlertClient struct {
+	alerts []*alerting.PostableAlert
+}
+
+func (f *fakeAlertClient) PostAlerts(ctx context.Context, alerts ...*alerting.PostableAlert) error {
+	f.alerts = append(f.alerts, alerts...)
+	return nil
+}
+
+// newCertificate returns a self signed certificate of the DNS names for the key in PEM.
+func newCertificate(t *testing.T, key crypto.Signer, notAfter time.Time, dnsNames ...string) []byte {
+	if key == nil {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTLSSecret(t *testing.T, namespace, name string, notAfter time.Time, dnsNames ...string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: newCertificate(t, nil, notAfter, dnsNames...)},
+	}
+}
+
+func TestExpiryReconcile(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+
+	now := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	alertClient := &fakeAlertClient{}
+	indexed := &unstructured.Unstructured{}
+	indexed.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind)
+	r := &ExpiryReconciler{
+		Client: fake.NewClientBuilder().WithScheme(sch).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+			newTLSSecret(t, "test", "expired-tls", now.Add(-time.Hour), "expired.example.com"),
+			newTLSSecret(t, "test", "expiring-tls", now.Add(10*24*time.Hour), "expiring.example.com"),
+			newTLSSecret(t, "test", "valid-tls", now.Add(60*24*time.Hour), "valid.example.com"),
+			&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "web"},
+				Spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{"expired.example.com"}, SecretName: "expired-tls"},
+					{Hosts: []string{"expiring.example.com"}, SecretName: "expiring-tls"},
+					{Hosts: []string{"valid.example.com"}, SecretName: "valid-tls"},
+				}},
+			},
+		).WithIndex(indexed, gateway.GatewaySecretNamespaceIndex, gateway.GatewaySecretNamespaces).Build(),
+		Logger:       ctrl.Log,
+		AlertClient:  alertClient,
+		ExpiryDays:   30,
+		ScanInterval: time.Hour,
+		now:          func() time.Time { return now },
+	}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test"}})
+	if err != nil || result.RequeueAfter != time.Hour {
+		t.Fatalf("unexpected result %v, %v", result, err)
+	}
+	if len(alertClient.alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(alertClient.alerts))
+	}
+
+	expired, expiring := alertClient.alerts[0], alertClient.alerts[1]
+	if expired.Labels["alertname"] != certificateExpiredAlertName || expired.Labels["severity"] != "critical" ||
+		expired.Labels["namespace"] != "test" || expired.Labels["secret"] != "expired-tls" ||
+		!expired.StartsAt.Equal(now.Add(-time.Hour)) || !expired.EndsAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("unexpected alert %+v", expired)
+	}
+	if expiring.Labels["alertname"] != certificateExpiringAlertName || expiring.Labels["severity"] != "warning" ||
+		expiring.Labels["secret"] != "expiring-tls" || !expiring.StartsAt.Equal(now.Add(-20*24*time.Hour)) ||
+		expiring.Annotations["summary"] != "Certificate test/expiring-tls expires in 10 days" {
+		t.Errorf("unexpected alert %+v", expiring)
+	}
+
+	// nothing is alerted of the namespaces deleted
+	result, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "deleted"}})
+	if err != nil || result.RequeueAfter != 0 || len(alertClient.alerts) != 2 {
+		t.Fatalf("unexpected result %v, %v", result, err)
+	}
+}
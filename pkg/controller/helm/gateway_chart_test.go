@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/yaml"
+)
+
+// renderGatewayAPI renders the Gateway API resources of the gateway chart with the spec of the Gateway.
+func renderGatewayAPI(t *testing.T, spec string) (string, error) {
+	chrt, err := loader.Load(filepath.Join("..", "..", "..", "config", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(spec), &vals); err != nil {
+		t.Fatal(err)
+	}
+	values, err := chartutil.ToRenderValues(chrt, vals, chartutil.ReleaseOptions{
+		Name:      "kubesphere-router-demo",
+		Namespace: "kubesphere-controls-system",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := engine.Render(chrt, values)
+	if err != nil {
+		return "", err
+	}
+	return manifests["gateway/templates/gateway-api.yaml"], nil
+}
+
+func TestGatewayChartGatewayAPI(t *testing.T) {
+	manifest, err := renderGatewayAPI(t, `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(manifest) != "" {
+		t.Fatalf("expected no Gateway API resources, got %s", manifest)
+	}
+
+	if _, err := renderGatewayAPI(t, `{"gatewayAPI": {"enabled": true}}`); err == nil {
+		t.Fatal("expected the controller name of the GatewayClass required")
+	}
+
+	manifest, err = renderGatewayAPI(t, `
+controller:
+  scope:
+    enabled: true
+    namespace: demo
+gatewayAPI:
+  enabled: true
+  controllerName: example.com/gateway-controller
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"kind: GatewayClass\nmetadata:\n  name: kubesphere-router-demo\nspec:\n  controllerName: example.com/gateway-controller",
+		"gatewayClassName: kubesphere-router-demo",
+		"- name: http\n    protocol: HTTP\n    port: 80",
+		"from: Selector",
+		"kubernetes.io/metadata.name: demo",
+	} {
+		if !strings.Contains(manifest, expected) {
+			t.Fatalf("expected %q in manifest:\n%s", expected, manifest)
+		}
+	}
+
+	// the routes of all namespaces are allowed for the global gateway
+	manifest, err = renderGatewayAPI(t, `
+gatewayAPI:
+  enabled: true
+  controllerName: example.com/gateway-controller
+  listeners:
+  - name: https
+    protocol: HTTPS
+    port: 443
+    hostname: "*.example.com"
+    certificateRef: example-tls
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"- name: https\n    protocol: HTTPS\n    port: 443\n    hostname: \"*.example.com\"",
+		"certificateRefs:\n      - kind: Secret\n        name: example-tls",
+		"from: All",
+	} {
+		if !strings.Contains(manifest, expected) {
+			t.Fatalf("expected %q in manifest:\n%s", expected, manifest)
+		}
+	}
+	if strings.Contains(manifest, "name: http\n") {
+		t.Fatalf("expected the default listener replaced, got %s", manifest)
+	}
+}
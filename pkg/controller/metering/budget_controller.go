@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	meteringv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+	quotav1alpha2 "kubesphere.io/api/quota/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification"
+)
+
+const (
+	budgetControllerName = "budget-controller"
+
+	// meters are hourly, the costs don't change in between
+	defaultBudgetEvaluationInterval = time.Hour
+
+	budgetAlertName = "BudgetThresholdExceeded"
+)
+
+// BudgetReconciler evaluates the costs of the current period of budgets, notifies the thresholds
+// crossed through the notification manager and applies the restrictions of the budgets through
+// workspace resource quotas until the period ends.
+type BudgetReconciler struct {
+	client.Client
+	Logger    logr.Logger
+	Evaluator metering.BudgetEvaluator
+	// Notifier sends the alerts of the budgets, nothing is notified if it is nil.
+	Notifier notification.Client
+	// EvaluationInterval is the interval the costs of the budgets are evaluated at.
+	EvaluationInterval time.Duration
+
+	now func() time.Time
+}
+
+func (r *BudgetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(budgetControllerName)
+	}
+	if r.EvaluationInterval <= 0 {
+		r.EvaluationInterval = defaultBudgetEvaluationInterval
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(budgetControllerName).
+		// the status updates don't trigger the costs to be evaluated again
+		For(&meteringv1alpha1.Budget{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&quotav1alpha2.ResourceQuota{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=metering.kubesphere.io,resources=budgets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metering.kubesphere.io,resources=budgets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metering.kubesphere.io,resources=ratecards,verbs=get;list;watch
+// +kubebuilder:rbac:groups=quota.kubesphere.io,resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
+func (r *BudgetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("budget", req.Name)
+	budget := &meteringv1alpha1.Budget{}
+	if err := r.Get(ctx, req.NamespacedName, budget); err != nil {
+		// the resource quota of the budget is garbage collected
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !budget.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	now := r.now()
+	start, end := metering.BudgetPeriod(budget.Spec.Period, now)
+	status := budget.Status.DeepCopy()
+	if status.PeriodStart == nil || !status.PeriodStart.Time.Equal(start) {
+		// a new period begins
+		periodStart, periodEnd := metav1.NewTime(start), metav1.NewTime(end)
+		status.PeriodStart, status.PeriodEnd = &periodStart, &periodEnd
+		status.CrossedThresholds = nil
+	}
+
+	cost, currency, err := r.Evaluator.Cost(ctx, budget, start, now)
+	if err != nil {
+		logger.Error(err, "failed to evaluate the cost")
+		return ctrl.Result{}, err
+	}
+	status.Cost, status.Currency, status.Percentage = cost, currency, 0
+	if budget.Spec.Amount > 0 {
+		status.Percentage = math.Round(cost/budget.Spec.Amount*10000) / 100
+	}
+
+	newlyCrossed := crossThresholds(budget, status)
+
+	threshold := metering.EnforcementThreshold(budget)
+	status.Enforced = threshold > 0 && status.Percentage >= float64(threshold)
+	if err := r.enforce(ctx, budget, status.Enforced); err != nil {
+		logger.Error(err, "failed to enforce the budget")
+		return ctrl.Result{}, err
+	}
+
+	evaluatedTime := metav1.NewTime(now)
+	status.LastEvaluatedTime = &evaluatedTime
+	budget.Status = *status
+	// the crossed thresholds are saved before they are notified, a threshold is never notified twice
+	// even if the budget is evaluated again, e.g. when the update conflicts.
+	if err := r.Status().Update(ctx, budget); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(newlyCrossed) > 0 && r.Notifier != nil {
+		alert := budgetAlert(budget, status, newlyCrossed[len(newlyCrossed)-1], now)
+		if err := r.Notifier.SendAlerts(ctx, alert); err != nil {
+			// not retried, as the threshold is recorded as crossed already
+			logger.Error(err, "failed to notify the budget", "threshold", newlyCrossed[len(newlyCrossed)-1])
+		}
+	}
+
+	requeueAfter := r.EvaluationInterval
+	if d := end.Sub(now); d < requeueAfter {
+		requeueAfter = d
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// crossThresholds records the thresholds crossed since the last evaluation in the status and returns them,
+// the highest one is notified. Every threshold is notified once per period.
+func crossThresholds(budget *meteringv1alpha1.Budget, status *meteringv1alpha1.BudgetStatus) []int {
+	crossed := make(map[int]bool, len(status.CrossedThresholds))
+	for _, threshold := range status.CrossedThresholds {
+		crossed[threshold] = true
+	}
+
+	var newlyCrossed []int
+	for _, threshold := range metering.BudgetThresholds(budget) {
+		if status.Percentage >= float64(threshold) && !crossed[threshold] {
+			newlyCrossed = append(newlyCrossed, threshold)
+		}
+	}
+	status.CrossedThresholds = append(status.CrossedThresholds, newlyCrossed...)
+	return newlyCrossed
+}
+
+func budgetAlert(budget *meteringv1alpha1.Budget, status *meteringv1alpha1.BudgetStatus, threshold int, now time.Time) *notification.Alert {
+	severity := "warning"
+	if threshold >= 100 {
+		severity = "critical"
+	}
+
+	labels := map[string]string{
+		"alertname": budgetAlertName,
+		"alerttype": "metering",
+		"severity":  severity,
+		"budget":    budget.Name,
+		"threshold": strconv.Itoa(threshold),
+		"workspace": budget.Spec.Workspace,
+	}
+	scope := fmt.Sprintf("workspace %s", budget.Spec.Workspace)
+	if budget.Spec.Namespace != "" {
+		// routes the alert to the tenants of the namespace
+		labels["namespace"] = budget.Spec.Namespace
+		scope = fmt.Sprintf("namespace %s", budget.Spec.Namespace)
+	}
+	if budget.Spec.Application != "" {
+		labels["application"] = budget.Spec.Application
+		scope = fmt.Sprintf("application %s in namespace %s", budget.Spec.Application, budget.Spec.Namespace)
+	}
+
+	return &notification.Alert{
+		Status: notification.AlertFiring,
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Budget %s has reached %d%% of its amount", budget.Name, threshold),
+			"message": fmt.Sprintf("The cost of %s is %s %s since %s, %s%% of the budget %s %s.",
+				scope, strconv.FormatFloat(status.Cost, 'f', -1, 64), status.Currency,
+				status.PeriodStart.Format(time.RFC3339), strconv.FormatFloat(status.Percentage, 'f', -1, 64),
+				strconv.FormatFloat(budget.Spec.Amount, 'f', -1, 64), status.Currency),
+		},
+		StartsAt: now,
+		EndsAt:   status.PeriodEnd.Time,
+	}
+}
+
+// enforce applies the hard limits of the budget to its namespaces through a workspace resource quota,
+// the resource quota is removed once the costs are below the threshold, e.g. in the next period.
+func (r *BudgetReconciler) enforce(ctx context.Context, budget *meteringv1alpha1.Budget, enforced bool) error {
+	quota := &quotav1alpha2.ResourceQuota{}
+	name := fmt.Sprintf("budget-%s", budget.Name)
+	err := r.Get(ctx, types.NamespacedName{Name: name}, quota)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !enforced {
+		if exists {
+			r.Logger.V(4).Info("lift the restriction of the budget", "budget", budget.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, quota))
+		}
+		return nil
+	}
+
+	selector := map[string]string{constants.WorkspaceLabelKey: budget.Spec.Workspace}
+	if budget.Spec.Namespace != "" {
+		selector[corev1.LabelMetadataName] = budget.Spec.Namespace
+	}
+
+	quota.Name = name
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, quota, func() error {
+		if quota.Labels == nil {
+			quota.Labels = make(map[string]string)
+		}
+		quota.Labels[meteringv1alpha1.BudgetLabel] = budget.Name
+		quota.Labels[constants.WorkspaceLabelKey] = budget.Spec.Workspace
+		quota.Spec.LabelSelector = selector
+		quota.Spec.Quota.Hard = budget.Spec.Enforcement.Hard.DeepCopy()
+		return controllerutil.SetControllerReference(budget, quota, r.Scheme())
+	})
+	return err
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	meteringv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+	quotav1alpha2 "kubesphere.io/api/quota/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification"
+)
+
+type fakeEvaluator struct {
+	cost float64
+}
+
+func (f *fakeEvaluator) Cost(ctx context.Context, budget *meteringv1alpha1.Budget, start, end time.Time) (float64, string, error) {
+	return f.cost, "USD", nil
+}
+
+type fakeNotifier struct {
+	alerts []*notification.Alert
+}
+
+func (f *fakeNotifier) SendAlerts(ctx context.Context, alerts ...*notification.Alert) error {
+	f.alerts = append(f.alerts, alerts...)
+	return nil
+}
+
+func TestReconcileBudget(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = meteringv1alpha1.AddToScheme(sch)
+	_ = quotav1alpha2.AddToScheme(sch)
+
+	budget := &meteringv1alpha1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-budget"},
+		Spec: meteringv1alpha1.BudgetSpec{
+			Workspace: "test-workspace",
+			Namespace: "test-namespace",
+			Period:    meteringv1alpha1.BudgetPeriodMonthly,
+			Amount:    100,
+			Enforcement: &meteringv1alpha1.BudgetEnforcement{
+				Hard: corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("1")},
+			},
+		},
+	}
+	now := time.Date(2023, 12, 10, 12, 0, 0, 0, time.UTC)
+	evaluator := &fakeEvaluator{}
+	notifier := &fakeNotifier{}
+	r := &BudgetReconciler{
+		Client:             fake.NewClientBuilder().WithScheme(sch).WithObjects(budget).Build(),
+		Logger:             ctrl.Log,
+		Evaluator:          evaluator,
+		Notifier:           notifier,
+		EvaluationInterval: defaultBudgetEvaluationInterval,
+		now:                func() time.Time { return now },
+	}
+
+	reconcile := func(cost float64) {
+		evaluator.cost = cost
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: budget.Name}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Get(context.Background(), types.NamespacedName{Name: budget.Name}, budget); err != nil {
+			t.Fatal(err)
+		}
+	}
+	getQuota := func() (*quotav1alpha2.ResourceQuota, error) {
+		quota := &quotav1alpha2.ResourceQuota{}
+		return quota, r.Get(context.Background(), types.NamespacedName{Name: "budget-test-budget"}, quota)
+	}
+
+	// the highest threshold crossed is notified
+	reconcile(85)
+	if len(notifier.alerts) != 1 || notifier.alerts[0].Labels["threshold"] != "80" || notifier.alerts[0].Labels["namespace"] != "test-namespace" {
+		t.Fatalf("unexpected alerts %v", notifier.alerts)
+	}
+	if diff := cmp.Diff(budget.Status.CrossedThresholds, []int{50, 80}); diff != "" {
+		t.Fatalf("crossed thresholds differ (-got, +want): %s", diff)
+	}
+	if budget.Status.Percentage != 85 || budget.Status.Enforced {
+		t.Fatalf("unexpected status %v", budget.Status)
+	}
+	if _, err := getQuota(); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected no resource quota, got %v", err)
+	}
+
+	// the thresholds are notified once per period
+	reconcile(90)
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("unexpected alerts %v", notifier.alerts)
+	}
+
+	// the resources are restricted once the budget is exceeded
+	reconcile(120)
+	if len(notifier.alerts) != 2 || notifier.alerts[1].Labels["severity"] != "critical" {
+		t.Fatalf("unexpected alerts %v", notifier.alerts)
+	}
+	quota, err := getQuota()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSelector := map[string]string{constants.WorkspaceLabelKey: "test-workspace", corev1.LabelMetadataName: "test-namespace"}
+	if diff := cmp.Diff(quota.Spec.LabelSelector, expectedSelector); diff != "" {
+		t.Fatalf("selector differs (-got, +want): %s", diff)
+	}
+	if hard := quota.Spec.Quota.Hard[corev1.ResourceLimitsCPU]; !budget.Status.Enforced || hard.String() != "1" {
+		t.Fatalf("unexpected enforcement %v, %v", budget.Status, quota.Spec.Quota)
+	}
+
+	// the next period begins
+	r.now = func() time.Time { return time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC) }
+	reconcile(10)
+	if len(budget.Status.CrossedThresholds) != 0 || budget.Status.Enforced || !budget.Status.PeriodStart.Time.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected status %v", budget.Status)
+	}
+	if _, err := getQuota(); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the resource quota to be removed, got %v", err)
+	}
+}
+
+// conflictingStatusClient fails the status updates as if the budget was modified in the meantime.
+type conflictingStatusClient struct {
+	client.Client
+}
+
+func (c *conflictingStatusClient) Status() client.SubResourceWriter {
+	return &conflictingStatusWriter{c.Client.Status()}
+}
+
+type conflictingStatusWriter struct {
+	client.SubResourceWriter
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return apierrors.NewConflict(meteringv1alpha1.Resource("budgets"), obj.GetName(), fmt.Errorf("the object has been modified"))
+}
+
+func TestReconcileBudgetConflict(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = meteringv1alpha1.AddToScheme(sch)
+	_ = quotav1alpha2.AddToScheme(sch)
+
+	budget := &meteringv1alpha1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-budget"},
+		Spec:       meteringv1alpha1.BudgetSpec{Workspace: "test-workspace", Amount: 100},
+	}
+	notifier := &fakeNotifier{}
+	r := &BudgetReconciler{
+		Client:             &conflictingStatusClient{fake.NewClientBuilder().WithScheme(sch).WithObjects(budget).Build()},
+		Logger:             ctrl.Log,
+		Evaluator:          &fakeEvaluator{cost: 85},
+		Notifier:           notifier,
+		EvaluationInterval: defaultBudgetEvaluationInterval,
+		now:                func() time.Time { return time.Date(2023, 12, 10, 12, 0, 0, 0, time.UTC) },
+	}
+
+	// the thresholds crossed are notified only once they are saved
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: budget.Name}}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("unexpected alerts %v", notifier.alerts)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	meteringv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/metering"
+)
+
+const (
+	controllerName = "statement-controller"
+
+	defaultRecalculateInterval = 6 * time.Hour
+	defaultCloseDelay          = 24 * time.Hour
+)
+
+// StatementReconciler keeps a statement of the current period for every workspace, recalculates
+// the open statements periodically and closes them once the metering data of the period is settled.
+type StatementReconciler struct {
+	client.Client
+	Logger     logr.Logger
+	Calculator metering.StatementCalculator
+	// RecalculateInterval is the interval the open statements are recalculated at.
+	RecalculateInterval time.Duration
+	// CloseDelay is the delay since the end of the period the statements are closed after.
+	CloseDelay time.Duration
+
+	now func() time.Time
+}
+
+func (r *StatementReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.RecalculateInterval <= 0 {
+		r.RecalculateInterval = defaultRecalculateInterval
+	}
+	if r.CloseDelay <= 0 {
+		r.CloseDelay = defaultCloseDelay
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&tenantv1alpha1.Workspace{}).
+		Watches(&source.Kind{Type: &meteringv1alpha1.Statement{}},
+			handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+				return []reconcile.Request{{
+					NamespacedName: types.NamespacedName{Name: o.(*meteringv1alpha1.Statement).Spec.Workspace},
+				}}
+			})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=tenant.kubesphere.io,resources=workspaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metering.kubesphere.io,resources=ratecards,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metering.kubesphere.io,resources=statements,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=metering.kubesphere.io,resources=statements/status,verbs=get;update;patch
+func (r *StatementReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("workspace", req.Name)
+	now := r.now()
+
+	workspace := &tenantv1alpha1.Workspace{}
+	err := r.Get(ctx, req.NamespacedName, workspace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	// the statements of a deleted workspace are closed, nothing will be billed to it anymore
+	workspaceExists := err == nil && workspace.DeletionTimestamp.IsZero()
+
+	var requeueAfter time.Duration
+	if workspaceExists {
+		start, end := metering.StatementPeriod(now)
+		if err := r.ensureStatement(ctx, req.Name, start, end); err != nil {
+			return ctrl.Result{}, err
+		}
+		// to create the statement of the next period
+		requeueAfter = end.Sub(now)
+	}
+
+	statements := &meteringv1alpha1.StatementList{}
+	if err := r.List(ctx, statements, client.MatchingLabels{constants.WorkspaceLabelKey: req.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for i := range statements.Items {
+		statement := &statements.Items[i]
+		if metering.IsStatementClosed(statement) {
+			continue
+		}
+
+		closeTime := statement.Spec.End.Add(r.CloseDelay)
+		closing := statement.Spec.Closed || !workspaceExists || !now.Before(closeTime)
+		_, recalculate := statement.Annotations[meteringv1alpha1.StatementRecalculateAnnotation]
+		nextCalculation := now
+		if statement.Status.CalculatedTime != nil {
+			nextCalculation = statement.Status.CalculatedTime.Add(r.RecalculateInterval)
+		}
+
+		if closing || recalculate || !now.Before(nextCalculation) {
+			if err := r.calculate(ctx, statement, now, closing, recalculate); err != nil {
+				logger.Error(err, "failed to calculate statement", "statement", statement.Name)
+				return ctrl.Result{}, err
+			}
+			nextCalculation = now.Add(r.RecalculateInterval)
+		}
+		if closing {
+			continue
+		}
+
+		for _, next := range []time.Time{nextCalculation, closeTime} {
+			if d := next.Sub(now); requeueAfter == 0 || d < requeueAfter {
+				requeueAfter = d
+			}
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (r *StatementReconciler) ensureStatement(ctx context.Context, workspace string, start, end time.Time) error {
+	statement := metering.NewStatement(workspace, start, end)
+	err := r.Get(ctx, types.NamespacedName{Name: statement.Name}, &meteringv1alpha1.Statement{})
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := r.Create(ctx, statement); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	r.Logger.V(4).Info("statement created", "statement", statement.Name)
+	return nil
+}
+
+// calculate rolls the metering data up to now into the statement, the statement is immutable
+// once it is closed.
+func (r *StatementReconciler) calculate(ctx context.Context, statement *meteringv1alpha1.Statement, now time.Time, closing, recalculate bool) error {
+	if recalculate {
+		// roll the usage of the whole period up again
+		statement.Status.Namespaces = nil
+		statement.Status.CalculatedUntil = nil
+	}
+	if err := r.Calculator.Calculate(ctx, statement, now); err != nil {
+		return err
+	}
+
+	calculatedTime := metav1.NewTime(now)
+	statement.Status.Phase = meteringv1alpha1.StatementOpen
+	statement.Status.CalculatedTime = &calculatedTime
+	if closing {
+		statement.Status.Phase = meteringv1alpha1.StatementClosed
+		statement.Status.ClosedTime = &calculatedTime
+	}
+	if err := r.Status().Update(ctx, statement); err != nil {
+		return err
+	}
+
+	if recalculate {
+		delete(statement.Annotations, meteringv1alpha1.StatementRecalculateAnnotation)
+		return r.Update(ctx, statement)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	meteringv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/models/metering"
+)
+
+type fakeCalculator struct {
+	calculated []string
+}
+
+func (f *fakeCalculator) Calculate(ctx context.Context, statement *meteringv1alpha1.Statement, end time.Time) error {
+	f.calculated = append(f.calculated, statement.Name)
+	statement.Status.Fee += 1
+	return nil
+}
+
+func newReconciler(now time.Time, objects ...client.Object) (*StatementReconciler, *fakeCalculator) {
+	sch := runtime.NewScheme()
+	_ = meteringv1alpha1.AddToScheme(sch)
+	_ = tenantv1alpha1.AddToScheme(sch)
+
+	calculator := &fakeCalculator{}
+	return &StatementReconciler{
+		Client:              fake.NewClientBuilder().WithScheme(sch).WithObjects(objects...).Build(),
+		Logger:              ctrl.Log,
+		Calculator:          calculator,
+		RecalculateInterval: defaultRecalculateInterval,
+		CloseDelay:          defaultCloseDelay,
+		now:                 func() time.Time { return now },
+	}, calculator
+}
+
+func TestReconcileStatements(t *testing.T) {
+	now := time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC)
+	workspace := &tenantv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "test-workspace"}}
+	// the statement of the last month is closed after the close delay
+	lastMonth := metering.NewStatement("test-workspace", time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC))
+	r, calculator := newReconciler(now, workspace, lastMonth)
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-workspace"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calculator.calculated) != 2 {
+		t.Fatalf("expected both statements to be calculated, got %v", calculator.calculated)
+	}
+	// the next calculation is due before the statement of the last month is closed at 2023-12-02
+	if result.RequeueAfter != defaultRecalculateInterval {
+		t.Fatalf("unexpected requeue after %s", result.RequeueAfter)
+	}
+
+	current := &meteringv1alpha1.Statement{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "test-workspace-202312"}, current); err != nil {
+		t.Fatal(err)
+	}
+	if current.Status.Phase != meteringv1alpha1.StatementOpen || current.Status.CalculatedTime == nil {
+		t.Fatalf("unexpected status of the current statement %v", current.Status)
+	}
+
+	// close the statement of the last month
+	r.now = func() time.Time { return now.Add(24 * time.Hour) }
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-workspace"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: lastMonth.Name}, lastMonth); err != nil {
+		t.Fatal(err)
+	}
+	if !metering.IsStatementClosed(lastMonth) || lastMonth.Status.ClosedTime == nil {
+		t.Fatalf("expected the statement to be closed, got %v", lastMonth.Status)
+	}
+
+	// closed statements are immutable
+	calculator.calculated = nil
+	r.now = func() time.Time { return now.Add(48 * time.Hour) }
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-workspace"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range calculator.calculated {
+		if name == lastMonth.Name {
+			t.Fatal("closed statement is calculated")
+		}
+	}
+}
+
+func TestRecalculateStatement(t *testing.T) {
+	now := time.Date(2023, 12, 10, 12, 0, 0, 0, time.UTC)
+	workspace := &tenantv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "test-workspace"}}
+	calculatedTime := metav1.NewTime(now.Add(-time.Hour))
+	calculatedUntil := metav1.NewTime(now.Add(-time.Hour))
+	statement := metering.NewStatement("test-workspace", time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	statement.Annotations = map[string]string{meteringv1alpha1.StatementRecalculateAnnotation: ""}
+	statement.Status = meteringv1alpha1.StatementStatus{
+		Phase:           meteringv1alpha1.StatementOpen,
+		Namespaces:      []meteringv1alpha1.NamespaceStatement{{Name: "test"}},
+		CalculatedTime:  &calculatedTime,
+		CalculatedUntil: &calculatedUntil,
+	}
+	r, calculator := newReconciler(now, workspace, statement)
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-workspace"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(calculator.calculated) != 1 {
+		t.Fatalf("expected the statement to be recalculated, got %v", calculator.calculated)
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: statement.Name}, statement); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statement.Annotations[meteringv1alpha1.StatementRecalculateAnnotation]; ok {
+		t.Fatal("expected the recalculate annotation to be removed")
+	}
+	if statement.Status.Namespaces != nil || statement.Status.CalculatedUntil != nil {
+		t.Fatalf("expected the usage to be rolled up again, got %v", statement.Status)
+	}
+}
@@ -20,8 +20,6 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"sort"
-	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -94,8 +92,8 @@ type NSNetworkPolicyController struct {
 	namespaceInformer       v1.NamespaceInformer
 	namespaceInformerSynced cache.InformerSynced
 
+	*Translator
 	provider provider.NsNetworkPolicyProvider
-	options  options.NSNPOptions
 
 	nsQueue   workqueue.RateLimitingInterface
 	nsnpQueue workqueue.RateLimitingInterface
@@ -144,223 +142,6 @@ func generateDNSRule(nameServers []string) (netv1.NetworkPolicyEgressRule, error
 	return rule, nil
 }
 
-func (c *NSNetworkPolicyController) generateDNSServiceRule() (netv1.NetworkPolicyEgressRule, error) {
-	peer, ports, err := c.handlerPeerService(DNSNamespace, DNSServiceName, false)
-	if err != nil {
-		peer, ports, err = c.handlerPeerService(DNSNamespace, DNSServiceCoreDNS, false)
-	}
-
-	return netv1.NetworkPolicyEgressRule{
-		Ports: ports,
-		To:    []netv1.NetworkPolicyPeer{peer},
-	}, err
-}
-
-func (c *NSNetworkPolicyController) handlerPeerService(namespace string, name string, ingress bool) (netv1.NetworkPolicyPeer, []netv1.NetworkPolicyPort, error) {
-	peerNP := netv1.NetworkPolicyPeer{}
-	var ports []netv1.NetworkPolicyPort
-
-	service, err := c.serviceInformer.Lister().Services(namespace).Get(name)
-	if err != nil {
-		return peerNP, nil, err
-	}
-
-	peerNP.PodSelector = new(metav1.LabelSelector)
-	peerNP.NamespaceSelector = new(metav1.LabelSelector)
-
-	if len(service.Spec.Selector) <= 0 {
-		return peerNP, nil, fmt.Errorf("service %s/%s has no podselect", namespace, name)
-	}
-
-	peerNP.PodSelector.MatchLabels = make(map[string]string)
-	for key, value := range service.Spec.Selector {
-		peerNP.PodSelector.MatchLabels[key] = value
-	}
-	peerNP.NamespaceSelector.MatchLabels = make(map[string]string)
-	peerNP.NamespaceSelector.MatchLabels[constants.NamespaceLabelKey] = namespace
-
-	//only allow traffic to service exposed ports
-	if !ingress {
-		ports = make([]netv1.NetworkPolicyPort, 0)
-		for _, port := range service.Spec.Ports {
-			protocol := port.Protocol
-			portIntString := intstr.FromInt(int(port.Port))
-			ports = append(ports, netv1.NetworkPolicyPort{
-				Protocol: &protocol,
-				Port:     &portIntString,
-			})
-		}
-	}
-
-	return peerNP, ports, err
-}
-
-func (c *NSNetworkPolicyController) convertPeer(peer v1alpha1.NetworkPolicyPeer, ingress bool) (netv1.NetworkPolicyPeer, []netv1.NetworkPolicyPort, error) {
-	peerNP := netv1.NetworkPolicyPeer{}
-	var ports []netv1.NetworkPolicyPort
-
-	if peer.ServiceSelector != nil {
-		namespace := peer.ServiceSelector.Namespace
-		name := peer.ServiceSelector.Name
-
-		return c.handlerPeerService(namespace, name, ingress)
-	} else if peer.NamespaceSelector != nil {
-		name := peer.NamespaceSelector.Name
-
-		peerNP.NamespaceSelector = new(metav1.LabelSelector)
-		peerNP.NamespaceSelector.MatchLabels = make(map[string]string)
-		peerNP.NamespaceSelector.MatchLabels[constants.NamespaceLabelKey] = name
-	} else if peer.IPBlock != nil {
-		peerNP.IPBlock = peer.IPBlock
-	} else {
-		klog.Errorf("Invalid nsnp peer %v\n", peer)
-		return peerNP, nil, fmt.Errorf("Invalid nsnp peer %v\n", peer)
-	}
-
-	return peerNP, ports, nil
-}
-
-func (c *NSNetworkPolicyController) convertToK8sNP(n *v1alpha1.NamespaceNetworkPolicy) (*netv1.NetworkPolicy, error) {
-	np := &netv1.NetworkPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      v1alpha1.NSNPPrefix + n.Name,
-			Namespace: n.Namespace,
-		},
-		Spec: netv1.NetworkPolicySpec{
-			PodSelector: metav1.LabelSelector{},
-			PolicyTypes: make([]netv1.PolicyType, 0),
-		},
-	}
-
-	if n.Spec.Egress != nil {
-		np.Spec.Egress = make([]netv1.NetworkPolicyEgressRule, 0)
-		for _, egress := range n.Spec.Egress {
-			tmpRule := netv1.NetworkPolicyEgressRule{}
-			for _, peer := range egress.To {
-				peer, ports, err := c.convertPeer(peer, false)
-				if err != nil {
-					return nil, err
-				}
-				if ports != nil {
-					np.Spec.Egress = append(np.Spec.Egress, netv1.NetworkPolicyEgressRule{
-						Ports: ports,
-						To:    []netv1.NetworkPolicyPeer{peer},
-					})
-					continue
-				}
-				tmpRule.To = append(tmpRule.To, peer)
-			}
-			tmpRule.Ports = egress.Ports
-			if tmpRule.To == nil {
-				continue
-			}
-			np.Spec.Egress = append(np.Spec.Egress, tmpRule)
-		}
-		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, netv1.PolicyTypeEgress)
-	}
-
-	if n.Spec.Ingress != nil {
-		np.Spec.Ingress = make([]netv1.NetworkPolicyIngressRule, 0)
-		for _, ingress := range n.Spec.Ingress {
-			tmpRule := netv1.NetworkPolicyIngressRule{}
-			for _, peer := range ingress.From {
-				peer, ports, err := c.convertPeer(peer, true)
-				if err != nil {
-					return nil, err
-				}
-				if ports != nil {
-					np.Spec.Ingress = append(np.Spec.Ingress, netv1.NetworkPolicyIngressRule{
-						Ports: ports,
-						From:  []netv1.NetworkPolicyPeer{peer},
-					})
-				}
-				tmpRule.From = append(tmpRule.From, peer)
-			}
-			tmpRule.Ports = ingress.Ports
-			np.Spec.Ingress = append(np.Spec.Ingress, tmpRule)
-		}
-		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, netv1.PolicyTypeIngress)
-	}
-
-	return np, nil
-}
-
-func (c *NSNetworkPolicyController) generateNodeRule() (netv1.NetworkPolicyIngressRule, error) {
-	var (
-		rule netv1.NetworkPolicyIngressRule
-		ips  []string
-	)
-
-	nodes, err := c.nodeInformer.Lister().List(labels.Everything())
-	if err != nil {
-		return rule, err
-	}
-	for _, node := range nodes {
-		snatIPs := node.Annotations[NodeNSNPAnnotationKey]
-		if snatIPs != "" {
-			ips = append(ips, strings.Split(snatIPs, ";")...)
-		}
-	}
-
-	sort.Strings(ips)
-
-	for _, ip := range ips {
-		cidr, err := stringToCIDR(ip)
-		if err != nil {
-			continue
-		}
-
-		rule.From = append(rule.From, netv1.NetworkPolicyPeer{
-			IPBlock: &netv1.IPBlock{
-				CIDR: cidr,
-			},
-		})
-	}
-
-	return rule, nil
-}
-
-func (c *NSNetworkPolicyController) generateNSNP(workspace string, namespace string, matchWorkspace bool) *netv1.NetworkPolicy {
-	policy := &netv1.NetworkPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      AnnotationNPNAME,
-			Namespace: namespace,
-		},
-		Spec: netv1.NetworkPolicySpec{
-			PodSelector: metav1.LabelSelector{},
-			PolicyTypes: make([]netv1.PolicyType, 0),
-			Ingress: []netv1.NetworkPolicyIngressRule{{
-				From: []netv1.NetworkPolicyPeer{{
-					NamespaceSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{},
-					},
-				}},
-			}},
-		},
-	}
-
-	policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, netv1.PolicyTypeIngress)
-
-	if matchWorkspace {
-		policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels[constants.WorkspaceLabelKey] = workspace
-	} else {
-		policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels[constants.NamespaceLabelKey] = namespace
-	}
-
-	for _, allowedIngressNamespace := range c.options.AllowedIngressNamespaces {
-		defaultAllowedIngress := netv1.NetworkPolicyPeer{
-			NamespaceSelector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					constants.NamespaceLabelKey: allowedIngressNamespace,
-				},
-			},
-		}
-		policy.Spec.Ingress[0].From = append(policy.Spec.Ingress[0].From, defaultAllowedIngress)
-	}
-
-	return policy
-}
-
 func (c *NSNetworkPolicyController) nsEnqueue(ns *corev1.Namespace) {
 	key, err := cache.MetaNamespaceKeyFunc(ns)
 	if err != nil {
@@ -456,17 +237,8 @@ func (c *NSNetworkPolicyController) syncNs(key string) error {
 		return err
 	}
 
-	matchWorkspace := false
-	delete := false
 	nsnpList, err := c.informer.Lister().NamespaceNetworkPolicies(ns.Name).List(labels.Everything())
-	if namespaceNetworkIsolateEnabled(ns) {
-		matchWorkspace = false
-	} else if workspaceNetworkIsolationEnabled(wksp) {
-		matchWorkspace = true
-	} else {
-		delete = true
-	}
-	if delete || matchWorkspace {
+	if !namespaceNetworkIsolateEnabled(ns) {
 		//delete all namespace np when networkisolate not active
 		if err == nil && len(nsnpList) > 0 {
 			if c.ksclient.NamespaceNetworkPolicies(ns.Name).DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{}) != nil {
@@ -475,30 +247,12 @@ func (c *NSNetworkPolicyController) syncNs(key string) error {
 		}
 	}
 
-	policy := c.generateNSNP(workspaceName, ns.Name, matchWorkspace)
-	if shouldAddDNSRule(nsnpList) {
-		ruleDNS, err := generateDNSRule([]string{DNSLocalIP})
-		if err != nil {
-			return err
-		}
-		policy.Spec.Egress = append(policy.Spec.Egress, ruleDNS)
-		ruleDNSService, err := c.generateDNSServiceRule()
-		if err == nil {
-			policy.Spec.Egress = append(policy.Spec.Egress, ruleDNSService)
-		} else {
-			klog.Warningf("Cannot handle service %s or %s", DNSServiceName, DNSServiceCoreDNS)
-		}
-		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, netv1.PolicyTypeEgress)
-	}
-	ruleNode, err := c.generateNodeRule()
+	policy, err := c.IsolationPolicy(ns, wksp, nsnpList)
 	if err != nil {
 		return err
 	}
-	if len(ruleNode.From) > 0 {
-		policy.Spec.Ingress = append(policy.Spec.Ingress, ruleNode)
-	}
 
-	if delete {
+	if policy == nil {
 		c.provider.Delete(c.provider.GetKey(AnnotationNPNAME, ns.Name))
 	} else {
 		err = c.provider.Set(policy)
@@ -635,10 +389,10 @@ func NewNSNetworkPolicyController(
 		workspaceInformerSynced: workspaceInformer.Informer().HasSynced,
 		namespaceInformer:       namespaceInformer,
 		namespaceInformerSynced: namespaceInformer.Informer().HasSynced,
+		Translator:              NewTranslator(serviceInformer.Lister(), nodeInformer.Lister(), options),
 		provider:                policyProvider,
 		nsQueue:                 workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "namespace"),
 		nsnpQueue:               workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "namespacenp"),
-		options:                 options,
 	}
 
 	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -0,0 +1,313 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nsnetworkpolicy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/api/network/v1alpha1"
+	workspacev1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	options "kubesphere.io/kubesphere/pkg/simple/client/network"
+)
+
+// Translator translates the NamespaceNetworkPolicies and the network isolation of the namespaces
+// to k8s NetworkPolicies, which are synced to the provider by the controller, or evaluated statically.
+type Translator struct {
+	serviceLister corelisters.ServiceLister
+	nodeLister    corelisters.NodeLister
+	options       options.NSNPOptions
+}
+
+func NewTranslator(serviceLister corelisters.ServiceLister, nodeLister corelisters.NodeLister, options options.NSNPOptions) *Translator {
+	return &Translator{
+		serviceLister: serviceLister,
+		nodeLister:    nodeLister,
+		options:       options,
+	}
+}
+
+// ConvertToK8sNP converts the NamespaceNetworkPolicy to the k8s NetworkPolicy, the services selected
+// are converted to the pods selected by them.
+func (t *Translator) ConvertToK8sNP(n *v1alpha1.NamespaceNetworkPolicy) (*netv1.NetworkPolicy, error) {
+	return t.convertToK8sNP(n)
+}
+
+// IsolationPolicy returns the NetworkPolicy isolating the namespace or its workspace,
+// it is nil if the network isolation is enabled for neither of them.
+func (t *Translator) IsolationPolicy(ns *corev1.Namespace, wksp *workspacev1alpha1.Workspace, nsnpList []*v1alpha1.NamespaceNetworkPolicy) (*netv1.NetworkPolicy, error) {
+	matchWorkspace := false
+	if namespaceNetworkIsolateEnabled(ns) {
+		matchWorkspace = false
+	} else if workspaceNetworkIsolationEnabled(wksp) {
+		matchWorkspace = true
+	} else {
+		return nil, nil
+	}
+
+	policy := t.generateNSNP(wksp.Name, ns.Name, matchWorkspace)
+	if shouldAddDNSRule(nsnpList) {
+		ruleDNS, err := generateDNSRule([]string{DNSLocalIP})
+		if err != nil {
+			return nil, err
+		}
+		policy.Spec.Egress = append(policy.Spec.Egress, ruleDNS)
+		ruleDNSService, err := t.generateDNSServiceRule()
+		if err == nil {
+			policy.Spec.Egress = append(policy.Spec.Egress, ruleDNSService)
+		} else {
+			klog.Warningf("Cannot handle service %s or %s", DNSServiceName, DNSServiceCoreDNS)
+		}
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, netv1.PolicyTypeEgress)
+	}
+	ruleNode, err := t.generateNodeRule()
+	if err != nil {
+		return nil, err
+	}
+	if len(ruleNode.From) > 0 {
+		policy.Spec.Ingress = append(policy.Spec.Ingress, ruleNode)
+	}
+	return policy, nil
+}
+
+func (t *Translator) generateDNSServiceRule() (netv1.NetworkPolicyEgressRule, error) {
+	peer, ports, err := t.handlerPeerService(DNSNamespace, DNSServiceName, false)
+	if err != nil {
+		peer, ports, err = t.handlerPeerService(DNSNamespace, DNSServiceCoreDNS, false)
+	}
+
+	return netv1.NetworkPolicyEgressRule{
+		Ports: ports,
+		To:    []netv1.NetworkPolicyPeer{peer},
+	}, err
+}
+
+func (t *Translator) handlerPeerService(namespace string, name string, ingress bool) (netv1.NetworkPolicyPeer, []netv1.NetworkPolicyPort, error) {
+	peerNP := netv1.NetworkPolicyPeer{}
+	var ports []netv1.NetworkPolicyPort
+
+	service, err := t.serviceLister.Services(namespace).Get(name)
+	if err != nil {
+		return peerNP, nil, err
+	}
+
+	peerNP.PodSelector = new(metav1.LabelSelector)
+	peerNP.NamespaceSelector = new(metav1.LabelSelector)
+
+	if len(service.Spec.Selector) <= 0 {
+		return peerNP, nil, fmt.Errorf("service %s/%s has no podselect", namespace, name)
+	}
+
+	peerNP.PodSelector.MatchLabels = make(map[string]string)
+	for key, value := range service.Spec.Selector {
+		peerNP.PodSelector.MatchLabels[key] = value
+	}
+	peerNP.NamespaceSelector.MatchLabels = make(map[string]string)
+	peerNP.NamespaceSelector.MatchLabels[constants.NamespaceLabelKey] = namespace
+
+	//only allow traffic to service exposed ports
+	if !ingress {
+		ports = make([]netv1.NetworkPolicyPort, 0)
+		for _, port := range service.Spec.Ports {
+			protocol := port.Protocol
+			portIntString := intstr.FromInt(int(port.Port))
+			ports = append(ports, netv1.NetworkPolicyPort{
+				Protocol: &protocol,
+				Port:     &portIntString,
+			})
+		}
+	}
+
+	return peerNP, ports, err
+}
+
+func (t *Translator) convertPeer(peer v1alpha1.NetworkPolicyPeer, ingress bool) (netv1.NetworkPolicyPeer, []netv1.NetworkPolicyPort, error) {
+	peerNP := netv1.NetworkPolicyPeer{}
+	var ports []netv1.NetworkPolicyPort
+
+	if peer.ServiceSelector != nil {
+		namespace := peer.ServiceSelector.Namespace
+		name := peer.ServiceSelector.Name
+
+		return t.handlerPeerService(namespace, name, ingress)
+	} else if peer.NamespaceSelector != nil {
+		name := peer.NamespaceSelector.Name
+
+		peerNP.NamespaceSelector = new(metav1.LabelSelector)
+		peerNP.NamespaceSelector.MatchLabels = make(map[string]string)
+		peerNP.NamespaceSelector.MatchLabels[constants.NamespaceLabelKey] = name
+	} else if peer.IPBlock != nil {
+		peerNP.IPBlock = peer.IPBlock
+	} else {
+		klog.Errorf("Invalid nsnp peer %v\n", peer)
+		return peerNP, nil, fmt.Errorf("Invalid nsnp peer %v\n", peer)
+	}
+
+	return peerNP, ports, nil
+}
+
+func (t *Translator) convertToK8sNP(n *v1alpha1.NamespaceNetworkPolicy) (*netv1.NetworkPolicy, error) {
+	np := &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v1alpha1.NSNPPrefix + n.Name,
+			Namespace: n.Namespace,
+		},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: make([]netv1.PolicyType, 0),
+		},
+	}
+
+	if n.Spec.Egress != nil {
+		np.Spec.Egress = make([]netv1.NetworkPolicyEgressRule, 0)
+		for _, egress := range n.Spec.Egress {
+			tmpRule := netv1.NetworkPolicyEgressRule{}
+			for _, peer := range egress.To {
+				peer, ports, err := t.convertPeer(peer, false)
+				if err != nil {
+					return nil, err
+				}
+				if ports != nil {
+					np.Spec.Egress = append(np.Spec.Egress, netv1.NetworkPolicyEgressRule{
+						Ports: ports,
+						To:    []netv1.NetworkPolicyPeer{peer},
+					})
+					continue
+				}
+				tmpRule.To = append(tmpRule.To, peer)
+			}
+			tmpRule.Ports = egress.Ports
+			if tmpRule.To == nil {
+				continue
+			}
+			np.Spec.Egress = append(np.Spec.Egress, tmpRule)
+		}
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, netv1.PolicyTypeEgress)
+	}
+
+	if n.Spec.Ingress != nil {
+		np.Spec.Ingress = make([]netv1.NetworkPolicyIngressRule, 0)
+		for _, ingress := range n.Spec.Ingress {
+			tmpRule := netv1.NetworkPolicyIngressRule{}
+			for _, peer := range ingress.From {
+				peer, ports, err := t.convertPeer(peer, true)
+				if err != nil {
+					return nil, err
+				}
+				if ports != nil {
+					np.Spec.Ingress = append(np.Spec.Ingress, netv1.NetworkPolicyIngressRule{
+						Ports: ports,
+						From:  []netv1.NetworkPolicyPeer{peer},
+					})
+				}
+				tmpRule.From = append(tmpRule.From, peer)
+			}
+			tmpRule.Ports = ingress.Ports
+			np.Spec.Ingress = append(np.Spec.Ingress, tmpRule)
+		}
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, netv1.PolicyTypeIngress)
+	}
+
+	return np, nil
+}
+
+func (t *Translator) generateNodeRule() (netv1.NetworkPolicyIngressRule, error) {
+	var (
+		rule netv1.NetworkPolicyIngressRule
+		ips  []string
+	)
+
+	nodes, err := t.nodeLister.List(labels.Everything())
+	if err != nil {
+		return rule, err
+	}
+	for _, node := range nodes {
+		snatIPs := node.Annotations[NodeNSNPAnnotationKey]
+		if snatIPs != "" {
+			ips = append(ips, strings.Split(snatIPs, ";")...)
+		}
+	}
+
+	sort.Strings(ips)
+
+	for _, ip := range ips {
+		cidr, err := stringToCIDR(ip)
+		if err != nil {
+			continue
+		}
+
+		rule.From = append(rule.From, netv1.NetworkPolicyPeer{
+			IPBlock: &netv1.IPBlock{
+				CIDR: cidr,
+			},
+		})
+	}
+
+	return rule, nil
+}
+
+func (t *Translator) generateNSNP(workspace string, namespace string, matchWorkspace bool) *netv1.NetworkPolicy {
+	policy := &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AnnotationNPNAME,
+			Namespace: namespace,
+		},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: make([]netv1.PolicyType, 0),
+			Ingress: []netv1.NetworkPolicyIngressRule{{
+				From: []netv1.NetworkPolicyPeer{{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{},
+					},
+				}},
+			}},
+		},
+	}
+
+	policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, netv1.PolicyTypeIngress)
+
+	if matchWorkspace {
+		policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels[constants.WorkspaceLabelKey] = workspace
+	} else {
+		policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels[constants.NamespaceLabelKey] = namespace
+	}
+
+	for _, allowedIngressNamespace := range t.options.AllowedIngressNamespaces {
+		defaultAllowedIngress := netv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					constants.NamespaceLabelKey: allowedIngressNamespace,
+				},
+			},
+		}
+		policy.Spec.Ingress[0].From = append(policy.Spec.Ingress[0].From, defaultAllowedIngress)
+	}
+
+	return policy
+}
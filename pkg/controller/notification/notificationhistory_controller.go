@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"kubesphere.io/api/notification/v2beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	notificationHistoryControllerName = "notificationhistory-controller"
+
+	DefaultNotificationHistoryRetention = 7 * 24 * time.Hour
+	// the records of a receiver a day are limited to keep the size of the resource bounded
+	DefaultNotificationHistoryMaxRecords = 1000
+)
+
+// NotificationHistoryReconciler removes the NotificationHistory resources beyond the retention, and serves
+// the HistoryReceiver on the webhook server of the manager to record the histories.
+type NotificationHistoryReconciler struct {
+	client.Client
+	Logger     logr.Logger
+	Retention  time.Duration
+	MaxRecords int
+
+	now func() time.Time
+}
+
+func (r *NotificationHistoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(notificationHistoryControllerName)
+	}
+	if r.Retention <= 0 {
+		r.Retention = DefaultNotificationHistoryRetention
+	}
+	if r.MaxRecords <= 0 {
+		r.MaxRecords = DefaultNotificationHistoryMaxRecords
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+
+	// the webhook server runs on every replica, not only the leader
+	mgr.GetWebhookServer().Register(HistoryReceiverPath, &HistoryReceiver{
+		Client:     r.Client,
+		APIReader:  mgr.GetAPIReader(),
+		MaxRecords: r.MaxRecords,
+		now:        r.now,
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(notificationHistoryControllerName).
+		For(&v2beta2.NotificationHistory{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=notification.kubesphere.io,resources=notificationhistories,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=notification.kubesphere.io,resources=notificationhistories/status,verbs=get;update;patch
+func (r *NotificationHistoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	history := &v2beta2.NotificationHistory{}
+	if err := r.Get(ctx, req.NamespacedName, history); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	date, err := time.Parse(v2beta2.NotificationHistoryDateFormat, history.Spec.Date)
+	if err != nil {
+		// not recorded by the history receiver, which is left alone
+		r.Logger.Error(err, "invalid date of the notification history", "notificationhistory", history.Name)
+		return ctrl.Result{}, nil
+	}
+	// the history expires once the last notifications of the day are beyond the retention
+	expiry := date.Add(24*time.Hour + r.Retention)
+	if now := r.now(); now.Before(expiry) {
+		return ctrl.Result{RequeueAfter: expiry.Sub(now)}, nil
+	}
+	r.Logger.V(4).Info("removing the expired notification history", "notificationhistory", history.Name)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, history))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/api/notification/v2beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+func newHistoryClient(t *testing.T) client.Client {
+	sch := runtime.NewScheme()
+	if err := v2beta2.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(sch).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: HistoryTokenSecret, Namespace: constants.NotificationSecretNamespace},
+			Data:       map[string][]byte{HistoryTokenKey: []byte("token")},
+		},
+		&v2beta2.Receiver{ObjectMeta: metav1.ObjectMeta{
+			Name:   "admin-slack",
+			Labels: map[string]string{"type": "tenant", "user": "admin"},
+		}},
+	).Build()
+}
+
+func TestHistoryReceiver(t *testing.T) {
+	c := newHistoryClient(t)
+	now := time.Date(2023, 12, 10, 0, 30, 0, 0, time.UTC)
+	r := &HistoryReceiver{Client: c, APIReader: c, MaxRecords: 2, now: func() time.Time { return now }}
+
+	notification := func(receiver, alertname, latency, errMessage string, minutes int) *HistoryAlert {
+		return &HistoryAlert{
+			Labels: map[string]string{"alertname": alertname, "namespace": "test"},
+			Annotations: map[string]string{
+				HistoryAnnotationReceiver: receiver,
+				HistoryAnnotationLatency:  latency,
+				HistoryAnnotationError:    errMessage,
+				HistoryAnnotationTime:     now.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339),
+			},
+		}
+	}
+	data := &HistoryData{Alerts: []*HistoryAlert{
+		notification("global-email", "PodCrash", "1s", "", -20),
+		notification("admin-slack", "PodCrash", "0.5", "connection refused", -10),
+		// the day before
+		notification("admin-slack", "NodeDown", "1s", "", -60),
+		// without the receiver
+		{Labels: map[string]string{"alertname": "Unknown"}},
+	}}
+	body, _ := json.Marshal(data)
+	post := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, HistoryReceiverPath, bytes.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if code := post(token); code != http.StatusUnauthorized {
+			t.Fatalf("unexpected code %d with the token %q", code, token)
+		}
+	}
+	// the notifications sent again are recorded once
+	for i := 0; i < 2; i++ {
+		if code := post("token"); code != http.StatusOK {
+			t.Fatalf("unexpected code %d", code)
+		}
+	}
+
+	histories := &v2beta2.NotificationHistoryList{}
+	if err := c.List(context.Background(), histories, client.MatchingLabels{
+		v2beta2.NotificationHistoryLabelTenant: "admin",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(histories.Items) != 2 {
+		t.Fatalf("unexpected histories %v", histories.Items)
+	}
+	for _, history := range histories.Items {
+		if history.Spec.Receiver != "admin-slack" || history.Labels[v2beta2.NotificationHistoryLabelReceiver] != "admin-slack" ||
+			len(history.Status.Records) != 1 {
+			t.Fatalf("unexpected history %v", history)
+		}
+		if history.Spec.Date == "2023-12-10" {
+			if record := history.Status.Records[0]; record.Status != v2beta2.NotificationStatusFailed ||
+				record.LatencyMilliseconds != 500 || record.Message != "connection refused" {
+				t.Fatalf("unexpected record %v", record)
+			}
+		} else if history.Spec.Date != "2023-12-09" {
+			t.Fatalf("unexpected history %v", history)
+		}
+	}
+
+	// the earliest records beyond the max are removed
+	if err := r.Record(context.Background(), &HistoryData{Alerts: []*HistoryAlert{
+		notification("global-email", "PodCrash", "1s", "", -5),
+		notification("global-email", "NodeDown", "1s", "", -1),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	history := &v2beta2.NotificationHistory{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: historyName("global-email", "2023-12-10")}, history); err != nil {
+		t.Fatal(err)
+	}
+	if records := history.Status.Records; len(records) != 2 || !records[0].Time.Time.Equal(now.Add(-5*time.Minute)) ||
+		history.Spec.Tenant != "" || history.Labels[v2beta2.NotificationHistoryLabelTenant] != "" {
+		t.Fatalf("unexpected history %v", history)
+	}
+}
+
+func TestNotificationHistoryReconciler(t *testing.T) {
+	c := newHistoryClient(t)
+	history := &v2beta2.NotificationHistory{
+		ObjectMeta: metav1.ObjectMeta{Name: historyName("global-email", "2023-12-10")},
+		Spec:       v2beta2.NotificationHistorySpec{Receiver: "global-email", Date: "2023-12-10"},
+	}
+	if err := c.Create(context.Background(), history); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, 12, 17, 12, 0, 0, 0, time.UTC)
+	r := &NotificationHistoryReconciler{
+		Client:    c,
+		Logger:    ctrl.Log,
+		Retention: DefaultNotificationHistoryRetention,
+		now:       func() time.Time { return now },
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(history)}
+
+	result, err := r.Reconcile(context.Background(), req)
+	if err != nil || result.RequeueAfter != 12*time.Hour {
+		t.Fatalf("unexpected result %v, %v", result, err)
+	}
+
+	now = now.Add(12 * time.Hour)
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.Background(), req.NamespacedName, history); err == nil {
+		t.Fatalf("the expired history is not removed")
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"kubesphere.io/api/notification/v2beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// HistoryReceiverPath is the path of the history receiver on the webhook server of the controller manager,
+	// which is configured as the webhook of the history receiver of the notification manager.
+	HistoryReceiverPath = "/notification-history"
+	// HistoryTokenSecret is the secret in the namespace of the notification secrets holding the bearer token
+	// the notification manager authenticates with, which is set as the bearer token of the history webhook.
+	HistoryTokenSecret = "notification-history-receiver"
+	HistoryTokenKey    = "token"
+
+	// the annotations of the notifications sent to the history receiver
+	HistoryAnnotationReceiver     = "notification.kubesphere.io/receiver"
+	HistoryAnnotationReceiverType = "notification.kubesphere.io/receiver-type"
+	HistoryAnnotationTime         = "notification.kubesphere.io/time"
+	HistoryAnnotationLatency      = "notification.kubesphere.io/latency"
+	HistoryAnnotationError        = "notification.kubesphere.io/error"
+
+	maxHistoryRequestBytes = 10 << 20
+)
+
+// HistoryData is the payload the history receiver of the notification manager is sent, in the format of
+// the alertmanager webhook. Each alert is a notification sent to a receiver, which is told by the annotations.
+type HistoryData struct {
+	Alerts []*HistoryAlert `json:"alerts"`
+}
+
+type HistoryAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// HistoryReceiver records the notifications reported by the notification manager into the NotificationHistory
+// resources, one for each receiver a day. It is served by the webhook server of the controller manager rather
+// than the apiserver, and only the requests with the bearer token in the HistoryTokenSecret are accepted.
+type HistoryReceiver struct {
+	client.Client
+	// APIReader reads the histories to record into, as the cache may fall behind the other replicas.
+	APIReader  client.Reader
+	MaxRecords int
+
+	now func() time.Time
+}
+
+func (r *HistoryReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.authenticate(req); err != nil {
+		klog.V(4).Infof("notification history rejected: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data := &HistoryData{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxHistoryRequestBytes)).Decode(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.Record(req.Context(), data); err != nil {
+		klog.Errorf("failed to record the notification history: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *HistoryReceiver) authenticate(req *http.Request) error {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: constants.NotificationSecretNamespace, Name: HistoryTokenSecret}
+	if err := r.Get(req.Context(), key, secret); err != nil {
+		return err
+	}
+	token := secret.Data[HistoryTokenKey]
+	if len(token) == 0 {
+		return fmt.Errorf("no token is found in the secret %s", HistoryTokenSecret)
+	}
+	auth := req.Header.Get("Authorization")
+	bearer := strings.TrimPrefix(auth, "Bearer ")
+	if bearer == auth || subtle.ConstantTimeCompare([]byte(bearer), token) != 1 {
+		return fmt.Errorf("invalid bearer token")
+	}
+	return nil
+}
+
+// Record stores the notifications sent into the histories of the receivers.
+func (r *HistoryReceiver) Record(ctx context.Context, data *HistoryData) error {
+	histories := make(map[string]*v2beta2.NotificationHistory)
+	for _, alert := range data.Alerts {
+		receiver := alert.Annotations[HistoryAnnotationReceiver]
+		if receiver == "" {
+			klog.V(4).Infof("notification history without the receiver is ignored: %v", alert.Labels)
+			continue
+		}
+		record := r.newRecord(receiver, alert)
+		date := record.Time.UTC().Format(v2beta2.NotificationHistoryDateFormat)
+		name := historyName(receiver, date)
+		history, ok := histories[name]
+		if !ok {
+			history = r.newHistory(ctx, name, receiver, date)
+			histories[name] = history
+		}
+		history.Status.Records = append(history.Status.Records, record)
+	}
+
+	var errs []error
+	for _, history := range histories {
+		if err := r.record(ctx, history); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r *HistoryReceiver) newHistory(ctx context.Context, name, receiver, date string) *v2beta2.NotificationHistory {
+	history := &v2beta2.NotificationHistory{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{v2beta2.NotificationHistoryLabelDate: date},
+		},
+		Spec: v2beta2.NotificationHistorySpec{Receiver: receiver, Date: date},
+	}
+	// the tenant is recorded as the receiver was when the notification was sent
+	obj := &v2beta2.Receiver{}
+	if err := r.Get(ctx, client.ObjectKey{Name: receiver}, obj); err == nil && obj.Labels["type"] == "tenant" {
+		history.Spec.Tenant = obj.Labels["user"]
+	}
+	// the labels index the histories, and the names too long to be labels are only kept in the spec
+	if len(validation.IsValidLabelValue(receiver)) == 0 {
+		history.Labels[v2beta2.NotificationHistoryLabelReceiver] = receiver
+	}
+	if tenant := history.Spec.Tenant; tenant != "" && len(validation.IsValidLabelValue(tenant)) == 0 {
+		history.Labels[v2beta2.NotificationHistoryLabelTenant] = tenant
+	}
+	return history
+}
+
+// record appends the records of the history given into the history stored, which is created if not found.
+func (r *HistoryReceiver) record(ctx context.Context, h *v2beta2.NotificationHistory) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		history := &v2beta2.NotificationHistory{}
+		err := r.APIReader.Get(ctx, client.ObjectKey{Name: h.Name}, history)
+		if errors.IsNotFound(err) {
+			history = h.DeepCopy()
+			history.Status = v2beta2.NotificationHistoryStatus{}
+			if err := r.Create(ctx, history); err != nil {
+				if errors.IsAlreadyExists(err) {
+					// created by another replica, which is retried as a conflict
+					return errors.NewConflict(v2beta2.Resource(v2beta2.ResourcePluralNotificationHistory), h.Name, err)
+				}
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		records := mergeNotificationRecords(history.Status.Records, h.Status.Records, r.MaxRecords)
+		if equality.Semantic.DeepEqual(records, history.Status.Records) {
+			return nil
+		}
+		history.Status.Records = records
+		return r.Status().Update(ctx, history)
+	})
+}
+
+func (r *HistoryReceiver) newRecord(receiver string, alert *HistoryAlert) v2beta2.NotificationRecord {
+	record := v2beta2.NotificationRecord{
+		Time:                metav1.NewTime(r.now()),
+		ReceiverType:        alert.Annotations[HistoryAnnotationReceiverType],
+		AlertName:           alert.Labels["alertname"],
+		Namespace:           alert.Labels["namespace"],
+		Status:              v2beta2.NotificationStatusSuccess,
+		Message:             alert.Annotations[HistoryAnnotationError],
+		Labels:              alert.Labels,
+		LatencyMilliseconds: parseLatency(alert.Annotations[HistoryAnnotationLatency]).Milliseconds(),
+	}
+	if record.Message != "" {
+		record.Status = v2beta2.NotificationStatusFailed
+	}
+	if t, err := time.Parse(time.RFC3339, alert.Annotations[HistoryAnnotationTime]); err == nil {
+		record.Time = metav1.NewTime(t)
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(receiver))
+	keys := make([]string, 0, len(alert.Labels))
+	for k := range alert.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		_, _ = h.Write([]byte(k + "=" + alert.Labels[k] + ","))
+	}
+	record.ID = fmt.Sprintf("%d-%x", record.Time.UnixNano(), h.Sum64())
+	return record
+}
+
+func historyName(receiver, date string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(receiver))
+	return fmt.Sprintf("%s-%x", date, h.Sum64())
+}
+
+// parseLatency parses the latency from either a duration, e.g. 1.5s, or a number of seconds.
+func parseLatency(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second))
+	}
+	return 0
+}
+
+// mergeNotificationRecords appends the records not recorded yet, and removes the earliest records beyond the max.
+func mergeNotificationRecords(records, added []v2beta2.NotificationRecord, max int) []v2beta2.NotificationRecord {
+	recorded := make(map[string]bool, len(records))
+	merged := make([]v2beta2.NotificationRecord, 0, len(records)+len(added))
+	for _, record := range records {
+		recorded[record.ID] = true
+		merged = append(merged, record)
+	}
+	for _, record := range added {
+		if !recorded[record.ID] {
+			recorded[record.ID] = true
+			merged = append(merged, record)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.Before(&merged[j].Time)
+	})
+	if len(merged) > max {
+		merged = merged[len(merged)-max:]
+	}
+	return merged
+}
@@ -192,14 +192,13 @@ func updateHelmApplicationStatus(c client.Client, appId string, inAppStore bool)
 }
 
 func (r *ReconcileHelmApplicationVersion) updateStatus(appVersion *v1alpha1.HelmApplicationVersion) error {
-	appVersion.Status = v1alpha1.HelmApplicationVersionStatus{
-		State: v1alpha1.StateDraft,
-		Audit: []v1alpha1.Audit{
-			{
-				State:    v1alpha1.StateDraft,
-				Time:     appVersion.CreationTimestamp,
-				Operator: appVersion.GetCreator(),
-			},
+	// the verification result of the chart is kept
+	appVersion.Status.State = v1alpha1.StateDraft
+	appVersion.Status.Audit = []v1alpha1.Audit{
+		{
+			State:    v1alpha1.StateDraft,
+			Time:     appVersion.CreationTimestamp,
+			Operator: appVersion.GetCreator(),
 		},
 	}
 
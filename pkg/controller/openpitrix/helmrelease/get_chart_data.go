@@ -21,11 +21,13 @@ import (
 	"path"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/api/application/v1alpha1"
 
+	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmrepoindex"
 )
 
@@ -43,7 +45,7 @@ func (r *ReconcileHelmRelease) GetChartData(rls *v1alpha1.HelmRelease) (chartNam
 
 		if version := index.GetApplicationVersion(rls.Spec.ApplicationId, rls.Spec.ApplicationVersionId); version != nil {
 			url := version.Spec.URLs[0]
-			if !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "s3://")) {
+			if !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "s3://") || helmrepoindex.IsOCIRepo(url)) {
 				url = repo.Spec.Url + "/" + url
 			}
 			buf, err := helmrepoindex.LoadChart(context.TODO(), url, &repo.Spec.Credential)
@@ -53,6 +55,13 @@ func (r *ReconcileHelmRelease) GetChartData(rls *v1alpha1.HelmRelease) (chartNam
 			}
 			chartData = buf.Bytes()
 			chartName = version.Name
+
+			if repo.Spec.Verification != nil {
+				if err := r.verifyChart(&repo, url, chartData); err != nil {
+					klog.Errorf("verify chart %s of repo %s failed, error: %s", url, repo.Name, err)
+					return chartName, nil, ErrVerifyChartFailed
+				}
+			}
 		} else {
 			klog.Errorf("get app version: %s failed", rls.Spec.ApplicationVersionId)
 			return chartName, chartData, ErrGetAppVersionFailed
@@ -79,3 +88,23 @@ func (r *ReconcileHelmRelease) GetChartData(rls *v1alpha1.HelmRelease) (chartNam
 	}
 	return
 }
+
+// verifyChart verifies the signature of the chart loaded from the repo against the trusted keys of the repo.
+func (r *ReconcileHelmRelease) verifyChart(repo *v1alpha1.HelmRepo, url string, chartData []byte) error {
+	verification := repo.Spec.Verification
+	keys := &corev1.Secret{}
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: verification.SecretRef}, keys)
+	if err != nil {
+		return err
+	}
+	signature, err := helmrepoindex.LoadChartSignature(context.TODO(), url, verification.Provider, &repo.Spec.Credential)
+	if err != nil {
+		return err
+	}
+	signer, err := helmrepoindex.VerifyChart(verification.Provider, keys.Data, chartData, signature.Bytes())
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("chart %s of repo %s is signed by %s", url, repo.Name, signer)
+	return nil
+}
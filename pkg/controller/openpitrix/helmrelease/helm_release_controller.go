@@ -19,6 +19,7 @@ package helmrelease
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -47,6 +48,8 @@ import (
 const (
 	HelmReleaseFinalizer = "helmrelease.application.kubesphere.io"
 	MaxBackoffTime       = 15 * time.Minute
+	// DefaultDriftCheckInterval is how often the drift of the releases with the drift policy is checked.
+	DefaultDriftCheckInterval = 5 * time.Minute
 )
 
 var (
@@ -57,6 +60,7 @@ var (
 	ErrLoadChartFailed            = errors.New("load chart failed")
 	ErrS3Config                   = errors.New("invalid s3 config")
 	ErrLoadChartFromStorageFailed = errors.New("load chart from storage failed")
+	ErrVerifyChartFailed          = errors.New("verify chart failed")
 )
 
 var _ reconcile.Reconciler = &ReconcileHelmRelease{}
@@ -76,6 +80,8 @@ type ReconcileHelmRelease struct {
 	MaxConcurrent int
 	// wait time when check release is ready or not
 	WaitTime time.Duration
+	// interval of checking the drift of the releases
+	DriftCheckInterval time.Duration
 
 	StopChan <-chan struct{}
 }
@@ -185,6 +191,9 @@ func (r *ReconcileHelmRelease) reconcile(instance *v1alpha1.HelmRelease) (reconc
 	case v1alpha1.HelmStatusFailed:
 		// Release used to be failed, but instance.Status.Version not equal to instance.Spec.Version
 		if instance.Status.Version > 0 && instance.Status.Version != instance.Spec.Version {
+			if instance.Spec.RollbackRevision > 0 {
+				return r.rollbackHelmRelease(instance)
+			}
 			return r.createOrUpgradeHelmRelease(instance, true)
 		} else {
 			return reconcile.Result{}, nil
@@ -192,12 +201,17 @@ func (r *ReconcileHelmRelease) reconcile(instance *v1alpha1.HelmRelease) (reconc
 	case v1alpha1.HelmStatusActive:
 		// Release used to be active, but instance.Status.Version not equal to instance.Spec.Version
 		if instance.Status.Version != instance.Spec.Version {
+			if instance.Spec.RollbackRevision > 0 {
+				return reconcile.Result{}, r.startRollback(instance)
+			}
 			instance.Status.State = v1alpha1.HelmStatusUpgrading
 			// Update the state first.
 			err = r.Status().Update(context.TODO(), instance)
 			return reconcile.Result{}, err
+		} else if instance.Spec.RollbackRevision > 0 {
+			return reconcile.Result{}, r.clearRollbackRevision(instance)
 		} else {
-			return reconcile.Result{}, nil
+			return r.checkDrift(instance)
 		}
 	case v1alpha1.HelmStatusCreating:
 		// create new release
@@ -209,17 +223,22 @@ func (r *ReconcileHelmRelease) reconcile(instance *v1alpha1.HelmRelease) (reconc
 		if instance.Status.Version != instance.Spec.Version {
 			// Start a new backoff.
 			r.checkReleaseStatusBackoff.DeleteEntry(rlsBackoffKey(instance))
+			if instance.Spec.RollbackRevision > 0 {
+				return reconcile.Result{}, r.startRollback(instance)
+			}
 
 			instance.Status.State = v1alpha1.HelmStatusUpgrading
 			err = r.Status().Update(context.TODO(), instance)
 			return reconcile.Result{}, err
+		} else if instance.Spec.RollbackRevision > 0 {
+			return reconcile.Result{}, r.clearRollbackRevision(instance)
 		} else {
 			retry, err := r.checkReleaseIsReady(instance)
 			return reconcile.Result{RequeueAfter: retry}, err
 		}
 	case v1alpha1.HelmStatusRollbacking:
-		// TODO: rollback helm release
-		return reconcile.Result{}, nil
+		// We can roll back the release now.
+		return r.rollbackHelmRelease(instance)
 	}
 
 	return reconcile.Result{}, nil
@@ -290,6 +309,63 @@ func (r *ReconcileHelmRelease) checkReleaseIsReady(rls *v1alpha1.HelmRelease) (r
 	}
 }
 
+// checkDrift checks whether the resources of the active release drifted from the manifest, and re-applies
+// them if the drift policy is Correct.
+func (r *ReconcileHelmRelease) checkDrift(rls *v1alpha1.HelmRelease) (reconcile.Result, error) {
+	if rls.Spec.DriftPolicy == "" {
+		if rls.Status.Drift != nil {
+			rls.Status.Drift = nil
+			return reconcile.Result{}, r.Status().Update(context.TODO(), rls)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	interval := r.DriftCheckInterval
+	if interval <= 0 {
+		interval = DefaultDriftCheckInterval
+	}
+	if rls.Status.Drift != nil {
+		if next := rls.Status.Drift.LastCheckTime.Add(interval); time.Now().Before(next) {
+			return reconcile.Result{RequeueAfter: time.Until(next)}, nil
+		}
+	}
+
+	clusterName := rls.GetRlsCluster()
+	var clusterConfig string
+	var err error
+	if r.MultiClusterEnable && clusterName != "" {
+		clusterConfig, err = r.clusterClients.GetClusterKubeconfig(clusterName)
+		if err != nil {
+			klog.Errorf("get cluster %s config failed", clusterName)
+			return reconcile.Result{}, err
+		}
+	}
+
+	hw := helmwrapper.NewHelmWrapper(clusterConfig, rls.GetRlsNamespace(), rls.Spec.Name,
+		helmwrapper.SetMock(r.helmMock))
+	correct := rls.Spec.DriftPolicy == v1alpha1.DriftPolicyCorrect
+	resources, err := hw.Drift(correct)
+	if err != nil {
+		klog.Errorf("check drift of release %s/%s failed, error: %s", rls.GetRlsNamespace(), rls.GetTrueName(), err)
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+
+	now := metav1.Now()
+	drift := &v1alpha1.HelmReleaseDrift{LastCheckTime: now, Resources: resources}
+	if rls.Status.Drift != nil {
+		drift.LastCorrectTime = rls.Status.Drift.LastCorrectTime
+	}
+	if len(resources) > 0 {
+		klog.V(2).Infof("%d resources of release %s/%s drifted, corrected: %t", len(resources),
+			rls.GetRlsNamespace(), rls.GetTrueName(), correct)
+		if correct {
+			drift.LastCorrectTime = &now
+		}
+	}
+	rls.Status.Drift = drift
+	return reconcile.Result{RequeueAfter: interval}, r.Status().Update(context.TODO(), rls)
+}
+
 func (r *ReconcileHelmRelease) updateStatus(rls *v1alpha1.HelmRelease, currentState, msg string) error {
 	now := metav1.Now()
 	var deployStatus v1alpha1.HelmReleaseDeployStatus
@@ -303,6 +379,8 @@ func (r *ReconcileHelmRelease) updateStatus(rls *v1alpha1.HelmRelease, currentSt
 		(currentState == v1alpha1.HelmStatusCreated || currentState == v1alpha1.HelmStatusUpgraded) {
 		rls.Status.Version = rls.Spec.Version
 		rls.Status.LastDeployed = &now
+		// check the drift of the resources deployed as soon as the release is active
+		rls.Status.Drift = nil
 	}
 
 	rls.Status.State = currentState
@@ -375,6 +453,49 @@ func (r *ReconcileHelmRelease) createOrUpgradeHelmRelease(rls *v1alpha1.HelmRele
 	return reconcile.Result{}, err
 }
 
+// startRollback records the rollback requested by the spec, then the release is rolled back in the state of rollbacking.
+func (r *ReconcileHelmRelease) startRollback(rls *v1alpha1.HelmRelease) error {
+	return r.updateStatus(rls, v1alpha1.HelmStatusRollbacking, fmt.Sprintf("rollback to revision %d", rls.Spec.RollbackRevision))
+}
+
+// clearRollbackRevision clears the rollback revision of the spec once the release is rolled back, so that
+// the revision is not rolled back to again when the version of the spec changes later.
+func (r *ReconcileHelmRelease) clearRollbackRevision(rls *v1alpha1.HelmRelease) error {
+	patch := client.MergeFromWithOptions(rls.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	rls.Spec.RollbackRevision = 0
+	return r.Patch(context.TODO(), rls, patch)
+}
+
+// rollbackHelmRelease will run helm rollback to roll back the release to the revision of the spec.
+func (r *ReconcileHelmRelease) rollbackHelmRelease(rls *v1alpha1.HelmRelease) (reconcile.Result, error) {
+	clusterName := rls.GetRlsCluster()
+
+	var clusterConfig string
+	var err error
+	if r.MultiClusterEnable && clusterName != "" {
+		clusterConfig, err = r.clusterClients.GetClusterKubeconfig(clusterName)
+		if err != nil {
+			klog.Errorf("get cluster %s config failed", clusterName)
+			return reconcile.Result{}, err
+		}
+	}
+
+	hw := helmwrapper.NewHelmWrapper(clusterConfig, rls.GetRlsNamespace(), rls.Spec.Name,
+		helmwrapper.SetMock(r.helmMock))
+
+	revision := rls.Spec.RollbackRevision
+	// the release rolled back is checked to be ready as the upgraded
+	currentState := v1alpha1.HelmStatusUpgraded
+	msg := fmt.Sprintf("rolled back to revision %d", revision)
+	if err = hw.Rollback(revision); err != nil {
+		currentState = v1alpha1.HelmStatusFailed
+		msg = err.Error()
+	}
+	err = r.updateStatus(rls, currentState, msg)
+
+	return reconcile.Result{}, err
+}
+
 func (r *ReconcileHelmRelease) uninstallHelmRelease(rls *v1alpha1.HelmRelease) error {
 
 	if rls.Status.State != v1alpha1.HelmStatusDeleting {
@@ -23,21 +23,25 @@ import (
 
 	ksapi "kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	"kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
 	"kubesphere.io/kubesphere/pkg/informers"
 	alertingmodels "kubesphere.io/kubesphere/pkg/models/alerting"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
 type handler struct {
-	operator alertingmodels.Operator
+	operator   alertingmodels.Operator
+	backtester alertingmodels.Backtester
 }
 
 func newHandler(informers informers.InformerFactory,
 	promResourceClient promresourcesclient.Interface, ruleClient alerting.RuleClient,
-	option *alerting.Options) *handler {
+	option *alerting.Options, monitoringClient monitoring.Interface) *handler {
 	return &handler{
 		operator: alertingmodels.NewOperator(
 			informers, promResourceClient, ruleClient, option),
+		backtester: alertingmodels.NewBacktester(monitoringClient),
 	}
 }
 
@@ -326,3 +330,28 @@ func (h *handler) handleDeleteCustomAlertingRules(req *restful.Request, resp *re
 	}
 	resp.WriteEntity(bulkResp)
 }
+
+func (h *handler) handleBacktestCustomAlertingRule(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	opts, err := v2beta1.ParseBacktestOptions(req)
+	if err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+	var rule v2alpha1.PostableAlertingRule
+	if err := req.ReadEntity(&rule); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	result, err := h.backtester.BacktestCustomAlertingRule(namespace, &rule, opts)
+	if err != nil {
+		klog.Error(err)
+		ksapi.HandleError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
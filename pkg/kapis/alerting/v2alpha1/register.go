@@ -26,10 +26,12 @@ import (
 
 	ksapi "kubesphere.io/kubesphere/pkg/api"
 	alertingv2alpha1 "kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	alertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
 const (
@@ -40,7 +42,7 @@ var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v2alpha1"}
 
 func AddToContainer(container *restful.Container, informers informers.InformerFactory,
 	promResourceClient promresourcesclient.Interface, ruleClient alerting.RuleClient,
-	option *alerting.Options) error {
+	option *alerting.Options, monitoringClient monitoring.Interface) error {
 
 	ws := runtime.NewWebService(GroupVersion)
 
@@ -57,7 +59,7 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		return nil
 	}
 
-	handler := newHandler(informers, promResourceClient, ruleClient, option)
+	handler := newHandler(informers, promResourceClient, ruleClient, option, monitoringClient)
 
 	ws.Route(ws.GET("/rules").
 		To(handler.handleListCustomAlertingRules).
@@ -102,6 +104,16 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, ksapi.StatusOK, nil).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.POST("/rules/backtest").
+		To(handler.handleBacktestCustomAlertingRule).
+		Doc("evaluate a cluster-level custom alerting rule over a past time range and report the alerts which would have fired").
+		Reads(alertingv2alpha1.PostableAlertingRule{}).
+		Param(ws.QueryParameter(alertingv2beta1.ParameterBacktestStart, "start time of the backtesting range in unix timestamp, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(alertingv2beta1.ParameterBacktestEnd, "end time of the backtesting range in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(alertingv2beta1.ParameterBacktestStep, "interval the rule is evaluated at, defaults to 1m").Required(false)).
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2beta1.BacktestResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	ws.Route(ws.DELETE("/rules").
 		To(handler.handleDeleteCustomAlertingRules).
 		Doc("delete multiple cluster-level custom alerting rules").
@@ -179,6 +191,16 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, ksapi.StatusOK, "").
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.POST("/namespaces/{namespace}/rules/backtest").
+		To(handler.handleBacktestCustomAlertingRule).
+		Doc("evaluate a custom alerting rule in the specified namespace over a past time range and report the alerts which would have fired").
+		Reads(alertingv2alpha1.PostableAlertingRule{}).
+		Param(ws.QueryParameter(alertingv2beta1.ParameterBacktestStart, "start time of the backtesting range in unix timestamp, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(alertingv2beta1.ParameterBacktestEnd, "end time of the backtesting range in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(alertingv2beta1.ParameterBacktestStep, "interval the rule is evaluated at, defaults to 1m").Required(false)).
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2beta1.BacktestResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	ws.Route(ws.POST("/namespaces/{namespace}/bulkrules").
 		To(handler.handleCreateOrUpdateCustomAlertingRules).
 		Doc("create or update custom alerting rules in bulk in the specified namespace").
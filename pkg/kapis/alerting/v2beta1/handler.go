@@ -19,21 +19,35 @@ package v2beta1
 import (
 	"github.com/emicklei/go-restful/v3"
 	"k8s.io/klog/v2"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
 
 	kapi "kubesphere.io/kubesphere/pkg/api"
+	kapialertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/informers"
 	alertingmodels "kubesphere.io/kubesphere/pkg/models/alerting"
+	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
 type handler struct {
-	operator alertingmodels.RuleGroupOperator
+	operator        alertingmodels.RuleGroupOperator
+	backtester      alertingmodels.Backtester
+	silenceOperator alertingmodels.WorkspaceSilenceOperator
+	historyOperator alertingmodels.AlertHistoryOperator
 }
 
-func newHandler(informers informers.InformerFactory, ruleClient alerting.RuleClient) *handler {
+func newHandler(informers informers.InformerFactory, ruleClient alerting.RuleClient, monitoringClient monitoring.Interface,
+	runtimeCache runtimecache.Cache, runtimeClient runtimeclient.Client) *handler {
 	return &handler{
-		operator: alertingmodels.NewRuleGroupOperator(informers, ruleClient),
+		operator:        alertingmodels.NewRuleGroupOperator(informers, ruleClient, runtimeCache),
+		backtester:      alertingmodels.NewBacktester(monitoringClient),
+		silenceOperator: alertingmodels.NewWorkspaceSilenceOperator(runtimeClient),
+		historyOperator: alertingmodels.NewAlertHistoryOperator(runtimeClient),
 	}
 }
 
@@ -147,3 +161,169 @@ func (h *handler) handleListGlobalAlerts(req *restful.Request, resp *restful.Res
 	}
 	resp.WriteEntity(result)
 }
+
+func (h *handler) handleBacktestRuleGroup(req *restful.Request, resp *restful.Response) {
+	opts, err := kapialertingv2beta1.ParseBacktestOptions(req)
+	if err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+	group := &alertingv2beta1.RuleGroup{}
+	if err := req.ReadEntity(group); err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.backtester.BacktestRuleGroup(req.PathParameter("namespace"), group, opts)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleBacktestClusterRuleGroup(req *restful.Request, resp *restful.Response) {
+	opts, err := kapialertingv2beta1.ParseBacktestOptions(req)
+	if err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+	group := &alertingv2beta1.ClusterRuleGroup{}
+	if err := req.ReadEntity(group); err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.backtester.BacktestClusterRuleGroup(group, opts)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleBacktestGlobalRuleGroup(req *restful.Request, resp *restful.Response) {
+	opts, err := kapialertingv2beta1.ParseBacktestOptions(req)
+	if err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+	group := &alertingv2beta1.GlobalRuleGroup{}
+	if err := req.ReadEntity(group); err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.backtester.BacktestGlobalRuleGroup(group, opts)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleListWorkspaceSilences(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+	query := query.ParseQueryParameter(req)
+
+	result, err := h.silenceOperator.ListSilences(req.Request.Context(), workspace, query)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleGetWorkspaceSilence(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+	name := req.PathParameter("name")
+
+	result, err := h.silenceOperator.GetSilence(req.Request.Context(), workspace, name)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleCreateWorkspaceSilence(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+	silence := &alertingv2beta1.ClusterSilence{}
+	if err := req.ReadEntity(silence); err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.silenceOperator.CreateSilence(req.Request.Context(), workspace, silence)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleDeleteWorkspaceSilence(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+	name := req.PathParameter("name")
+
+	if err := h.silenceOperator.DeleteSilence(req.Request.Context(), workspace, name); err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(errors.None)
+}
+
+func (h *handler) handleNamespaceAlertTimeline(req *restful.Request, resp *restful.Response) {
+	opts, err := kapialertingv2beta1.ParseAlertHistoryOptions(req)
+	if err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.historyOperator.NamespaceAlertTimeline(req.Request.Context(), req.PathParameter("namespace"), opts)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleClusterAlertTimeline(req *restful.Request, resp *restful.Response) {
+	opts, err := kapialertingv2beta1.ParseAlertHistoryOptions(req)
+	if err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.historyOperator.ClusterAlertTimeline(req.Request.Context(), opts)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) handleGlobalAlertTimeline(req *restful.Request, resp *restful.Response) {
+	opts, err := kapialertingv2beta1.ParseAlertHistoryOptions(req)
+	if err != nil {
+		kapi.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.historyOperator.GlobalAlertTimeline(req.Request.Context(), opts)
+	if err != nil {
+		klog.Error(err)
+		kapi.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
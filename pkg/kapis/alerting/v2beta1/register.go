@@ -21,6 +21,8 @@ import (
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
 
@@ -30,14 +32,17 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
-func AddToContainer(container *restful.Container, informers informers.InformerFactory, ruleClient alerting.RuleClient) error {
+func AddToContainer(container *restful.Container, informers informers.InformerFactory, ruleClient alerting.RuleClient,
+	monitoringClient monitoring.Interface, runtimeCache runtimecache.Cache, runtimeClient runtimeclient.Client) error {
 
 	ws := runtime.NewWebService(alertingv2beta1.SchemeGroupVersion)
 
-	handler := newHandler(informers, ruleClient)
+	handler := newHandler(informers, ruleClient, monitoringClient, runtimeCache, runtimeClient)
 
 	ws.Route(ws.GET("/namespaces/{namespace}/rulegroups").
 		To(handler.handleListRuleGroups).
@@ -57,6 +62,16 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.RuleGroup{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.POST("/namespaces/{namespace}/rulegroups/backtest").
+		To(handler.handleBacktestRuleGroup).
+		Doc("evaluate the rules of the rulegroup over a past time range in the specified namespace and report the alerts which would have fired").
+		Reads(alertingv2beta1.RuleGroup{}).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestStart, "start time of the backtesting range in unix timestamp, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestEnd, "end time of the backtesting range in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestStep, "interval the rules are evaluated at, defaults to the interval of the rule group, e.g. 1m").Required(false)).
+		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.BacktestResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	ws.Route(ws.GET("/namespaces/{namespace}/alerts").
 		To(handler.handleListAlerts).
 		Doc("list the alerts in the specified namespace").
@@ -88,6 +103,16 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.RuleGroup{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.POST("/clusterrulegroups/backtest").
+		To(handler.handleBacktestClusterRuleGroup).
+		Doc("evaluate the rules of the clusterrulegroup over a past time range and report the alerts which would have fired").
+		Reads(alertingv2beta1.ClusterRuleGroup{}).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestStart, "start time of the backtesting range in unix timestamp, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestEnd, "end time of the backtesting range in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestStep, "interval the rules are evaluated at, defaults to the interval of the rule group, e.g. 1m").Required(false)).
+		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.BacktestResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	ws.Route(ws.GET("/clusteralerts").
 		To(handler.handleListClusterAlerts).
 		Doc("list the alerts of clusterrulegroups in the cluster").
@@ -120,6 +145,16 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.RuleGroup{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.POST("/globalrulegroups/backtest").
+		To(handler.handleBacktestGlobalRuleGroup).
+		Doc("evaluate the rules of the globalrulegroup over a past time range and report the alerts which would have fired").
+		Reads(alertingv2beta1.GlobalRuleGroup{}).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestStart, "start time of the backtesting range in unix timestamp, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestEnd, "end time of the backtesting range in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterBacktestStep, "interval the rules are evaluated at, defaults to the interval of the rule group, e.g. 1m").Required(false)).
+		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.BacktestResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	ws.Route(ws.GET("/globalalerts").
 		To(handler.handleListGlobalAlerts).
 		Doc("list the alerts of globalrulegroups").
@@ -134,6 +169,66 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, kapi.StatusOK, kapi.ListResult{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.GET("/namespaces/{namespace}/alerthistory").
+		To(handler.handleNamespaceAlertTimeline).
+		Doc("get the timeline of the alerts of the rulegroups in the specified namespace, including the alerts resolved").
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryStart, "start time of the timeline in unix timestamp, defaults to 7 days before the end").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryEnd, "end time of the timeline in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryRuleGroup, "limit the timeline to the rule group").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryAlert, "limit the timeline to the rule with the name").Required(false)).
+		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.AlertTimeline{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/clusteralerthistory").
+		To(handler.handleClusterAlertTimeline).
+		Doc("get the timeline of the alerts of the clusterrulegroups, including the alerts resolved").
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryStart, "start time of the timeline in unix timestamp, defaults to 7 days before the end").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryEnd, "end time of the timeline in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryRuleGroup, "limit the timeline to the rule group").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryAlert, "limit the timeline to the rule with the name").Required(false)).
+		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.AlertTimeline{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/globalalerthistory").
+		To(handler.handleGlobalAlertTimeline).
+		Doc("get the timeline of the alerts of the globalrulegroups, including the alerts resolved").
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryStart, "start time of the timeline in unix timestamp, defaults to 7 days before the end").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryEnd, "end time of the timeline in unix timestamp, defaults to now").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryRuleGroup, "limit the timeline to the rule group").Required(false)).
+		Param(ws.QueryParameter(kapialertingv2beta1.ParameterHistoryAlert, "limit the timeline to the rule with the name").Required(false)).
+		Returns(http.StatusOK, kapi.StatusOK, kapialertingv2beta1.AlertTimeline{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/silences").
+		To(handler.handleListWorkspaceSilences).
+		Doc("list the silences of the specified workspace").
+		Param(ws.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
+		Param(ws.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(ws.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Param(ws.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
+		Param(ws.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
+		Returns(http.StatusOK, kapi.StatusOK, kapi.ListResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/silences/{name}").
+		To(handler.handleGetWorkspaceSilence).
+		Doc("get the silence with the specified name of the specified workspace").
+		Returns(http.StatusOK, kapi.StatusOK, alertingv2beta1.ClusterSilence{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/silences").
+		To(handler.handleCreateWorkspaceSilence).
+		Doc("create a silence limited to the alerts of the namespaces of the specified workspace").
+		Reads(alertingv2beta1.ClusterSilence{}).
+		Returns(http.StatusOK, kapi.StatusOK, alertingv2beta1.ClusterSilence{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/silences/{name}").
+		To(handler.handleDeleteWorkspaceSilence).
+		Doc("delete the silence with the specified name of the specified workspace").
+		Returns(http.StatusOK, kapi.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	container.Add(ws)
 
 	return nil
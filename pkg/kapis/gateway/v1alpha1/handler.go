@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/emicklei/go-restful/v3"
@@ -39,6 +40,7 @@ import (
 	servererr "kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
 	loggingclient "kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 	conversionsv1 "kubesphere.io/kubesphere/pkg/utils/conversions/core/v1"
 	"kubesphere.io/kubesphere/pkg/utils/stringutils"
 )
@@ -51,7 +53,8 @@ type handler struct {
 }
 
 // newHandler create an instance of the handler
-func newHandler(options *gateway.Options, cache cache.Cache, client client.Client, factory informers.InformerFactory, k8sClient kubernetes.Interface, loggingClient loggingclient.Client) *handler {
+func newHandler(options *gateway.Options, cache cache.Cache, client client.Client, factory informers.InformerFactory, k8sClient kubernetes.Interface, loggingClient loggingclient.Client,
+	monitoringClient monitoring.Interface) *handler {
 	conversionsv1.RegisterConversions(scheme.Scheme)
 	// Do not register Gateway scheme globally. Which will cause conflict in ks-controller-manager.
 	v1alpha1.AddToScheme(client.Scheme())
@@ -62,7 +65,7 @@ func newHandler(options *gateway.Options, cache cache.Cache, client client.Clien
 	return &handler{
 		options: options,
 		factory: factory,
-		gw:      operator.NewGatewayOperator(client, cache, options, factory, k8sClient),
+		gw:      operator.NewGatewayOperator(client, cache, options, factory, k8sClient, monitoringClient, loggingClient),
 		lo:      lo,
 	}
 }
@@ -253,3 +256,61 @@ func (h *handler) PodLogSearch(request *restful.Request, response *restful.Respo
 	}
 
 }
+
+func (h *handler) Traffic(request *restful.Request, response *restful.Response) {
+	ns := request.PathParameter("namespace")
+	name := request.PathParameter("gateway")
+
+	end := time.Now()
+	if tstr := request.QueryParameter("end_time"); tstr != "" {
+		sec, err := strconv.ParseInt(tstr, 10, 64)
+		if err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		end = time.Unix(sec, 0)
+	}
+	start := end.Add(-time.Hour)
+	if tstr := request.QueryParameter("start_time"); tstr != "" {
+		sec, err := strconv.ParseInt(tstr, 10, 64)
+		if err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		start = time.Unix(sec, 0)
+	}
+	if !start.Before(end) {
+		api.HandleBadRequest(response, request, fmt.Errorf("start_time must be before end_time"))
+		return
+	}
+	limit := operator.DefaultTrafficTopLimit
+	if lstr := request.QueryParameter("limit"); lstr != "" {
+		l, err := strconv.Atoi(lstr)
+		if err != nil || l <= 0 {
+			api.HandleBadRequest(response, request, fmt.Errorf("invalid limit %s", lstr))
+			return
+		}
+		limit = l
+	}
+
+	result, err := h.gw.GetTraffic(ns, name, start, end, limit)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *handler) ListCertificates(request *restful.Request, response *restful.Response) {
+	ns := request.PathParameter("namespace")
+	status := operator.CertificateStatus(request.QueryParameter("status"))
+
+	result, err := h.gw.ListCertificates(ns, status)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
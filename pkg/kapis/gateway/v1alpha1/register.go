@@ -17,10 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"net/http"
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"kubesphere.io/api/gateway/v1alpha1"
@@ -32,17 +34,25 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
+	operator "kubesphere.io/kubesphere/pkg/models/gateway"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
 	loggingclient "kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
 var GroupVersion = schema.GroupVersion{Group: "gateway.kubesphere.io", Version: "v1alpha1"}
 
-func AddToContainer(container *restful.Container, options *gateway.Options, cache cache.Cache, client client.Client, factory informers.InformerFactory, k8sClient kubernetes.Interface, loggingClient loggingclient.Client) error {
+func AddToContainer(container *restful.Container, options *gateway.Options, cache cache.Cache, client client.Client, factory informers.InformerFactory, k8sClient kubernetes.Interface, loggingClient loggingclient.Client,
+	monitoringClient monitoring.Interface) error {
 	ws := runtime.NewWebService(GroupVersion)
 
-	handler := newHandler(options, cache, client, factory, k8sClient, loggingClient)
+	// the certificates of a namespace are scanned with the gateways indexed, unless Gateway API is not installed
+	if err := operator.IndexGatewaySecretNamespaces(context.Background(), cache); err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
+
+	handler := newHandler(options, cache, client, factory, k8sClient, loggingClient, monitoringClient)
 
 	// register gateway apis
 	ws.Route(ws.POST("/namespaces/{namespace}/gateways").
@@ -112,6 +122,32 @@ func AddToContainer(container *restful.Container, options *gateway.Options, cach
 		Returns(http.StatusOK, api.StatusOK, loggingv1alpha2.APIResponse{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GatewayTag}))
 
+	ws.Route(ws.GET("/namespaces/{namespace}/gateways/{gateway}/traffic").
+		To(handler.Traffic).
+		Doc("Retrieve the traffic analytics of the gateway, from the metrics and the access logs of the ingress controller. The traffic is scoped to the namespace unless it is the global gateway.").
+		Param(ws.PathParameter("namespace", "the watching namespace of the gateway")).
+		Param(ws.PathParameter("gateway", "the name of the gateway")).
+		Param(ws.QueryParameter("start_time", "Start time of the query, unix timestamp in seconds. Defaults to one hour before end_time.").DataType("string").Required(false)).
+		Param(ws.QueryParameter("end_time", "End time of the query, unix timestamp in seconds. Defaults to now.").DataType("string").Required(false)).
+		Param(ws.QueryParameter("limit", "The number of the top clients and the top 5xx URLs. Defaults to 10.").DataType("integer").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, operator.TrafficReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GatewayTag}))
+
+	ws.Route(ws.GET("/certificates").
+		To(handler.ListCertificates).
+		Doc("List the TLS certificates referenced by the ingresses and the gateways in all namespaces, the ones needing attention are listed first.").
+		Param(ws.QueryParameter("status", "Filter the certificates by the status, one of Valid, Expiring, Expired, Invalid and Missing.").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{operator.Certificate{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GatewayTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/certificates").
+		To(handler.ListCertificates).
+		Doc("List the TLS certificates referenced by the ingresses and the gateways in the namespace, the ones needing attention are listed first.").
+		Param(ws.PathParameter("namespace", "the namespace of the TLS secrets")).
+		Param(ws.QueryParameter("status", "Filter the certificates by the status, one of Valid, Expiring, Expired, Invalid and Missing.").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{operator.Certificate{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GatewayTag}))
+
 	container.Add(ws)
 	return nil
 }
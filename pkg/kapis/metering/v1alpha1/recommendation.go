@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/common/model"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/models/metering"
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type recommendationHandler struct {
+	recommender metering.Recommender
+}
+
+func newRecommendationHandler(k kubernetes.Interface, m monitoring.Interface, f informers.InformerFactory, meteringOptions *meteringclient.Options) *recommendationHandler {
+	if meteringOptions == nil {
+		meteringOptions = &meteringclient.DefaultMeteringOption
+	}
+	mo := monitoringmodel.NewMonitoringOperator(m, nil, k, f, nil, nil)
+	return &recommendationHandler{
+		recommender: metering.NewRecommender(mo, f.KubernetesSharedInformerFactory(), meteringOptions.Billing.PriceInfo),
+	}
+}
+
+func (h *recommendationHandler) RecommendWorkloads(req *restful.Request, resp *restful.Response) {
+	opts := metering.RecommendationOptions{Kind: req.QueryParameter("kind")}
+	if window := req.QueryParameter("window"); window != "" {
+		d, err := model.ParseDuration(window)
+		if err != nil {
+			api.HandleBadRequest(resp, req, fmt.Errorf("invalid window %s: %s", window, err))
+			return
+		}
+		opts.Window = time.Duration(d)
+	}
+	if percentile := req.QueryParameter("percentile"); percentile != "" {
+		p, err := strconv.ParseFloat(percentile, 64)
+		if err != nil || p <= 0 || p > 100 {
+			api.HandleBadRequest(resp, req, fmt.Errorf("invalid percentile %s", percentile))
+			return
+		}
+		opts.Percentile = p
+	}
+
+	result, err := h.recommender.RecommendWorkloads(req.PathParameter("namespace"), opts)
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
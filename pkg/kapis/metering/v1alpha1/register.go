@@ -28,10 +28,15 @@ import (
 
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
 	monitoringv1alpha3 "kubesphere.io/kubesphere/pkg/kapis/monitoring/v1alpha3"
+	"kubesphere.io/kubesphere/pkg/models/metering"
 	model "kubesphere.io/kubesphere/pkg/models/monitoring"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
@@ -410,6 +415,63 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, meteri
 		Returns(http.StatusOK, respOK, model.Metrics{})).
 		Produces(restful.MIME_JSON)
 
+	sh := newStatementHandler(rtClient, meteringOptions)
+
+	ws.Route(ws.GET("/workspaces/{workspace}/statements").
+		To(sh.ListStatements).
+		Doc("List the billing statements of the workspace, the latest period first.").
+		Param(ws.PathParameter("workspace", "The name of the workspace.").DataType("string").Required(true)).
+		Param(ws.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(ws.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Param(ws.QueryParameter(query.ParameterAscending, "sort parameters, e.g. ascending=false").Required(false).DefaultValue("ascending=false")).
+		Param(ws.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
+		Param(ws.QueryParameter("status", "The phase of the statements, one of Open, Closed.").DataType("string").Required(false)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StatementTag}).
+		Returns(http.StatusOK, respOK, api.ListResult{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.GET("/workspaces/{workspace}/statements/{statement}").
+		To(sh.GetStatement).
+		Doc("Get or download the billing statement of the workspace.").
+		Param(ws.PathParameter("workspace", "The name of the workspace.").DataType("string").Required(true)).
+		Param(ws.PathParameter("statement", "The name of the statement.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("format", "The format of the statement, one of json, csv.").DataType("string").Required(false).DefaultValue(statementFormatJSON)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StatementTag}).
+		Returns(http.StatusOK, respOK, meteringapiv1alpha1.Statement{})).
+		Produces(restful.MIME_JSON, "text/csv")
+
+	ws.Route(ws.POST("/workspaces/{workspace}/statements/{statement}/close").
+		To(sh.CloseStatement).
+		Doc("Close the billing statement before the period is settled, it is calculated for the last time and never changes afterwards.").
+		Param(ws.PathParameter("workspace", "The name of the workspace.").DataType("string").Required(true)).
+		Param(ws.PathParameter("statement", "The name of the statement.").DataType("string").Required(true)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StatementTag}).
+		Returns(http.StatusOK, respOK, meteringapiv1alpha1.Statement{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.POST("/workspaces/{workspace}/statements/{statement}/recalculate").
+		To(sh.RecalculateStatement).
+		Doc("Recalculate the open billing statement from the metering data of the whole period, it fails if the metering data has aged out.").
+		Param(ws.PathParameter("workspace", "The name of the workspace.").DataType("string").Required(true)).
+		Param(ws.PathParameter("statement", "The name of the statement.").DataType("string").Required(true)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StatementTag}).
+		Returns(http.StatusOK, respOK, meteringapiv1alpha1.Statement{})).
+		Produces(restful.MIME_JSON)
+
+	rh := newRecommendationHandler(k8sClient, meteringClient, factory, meteringOptions)
+
+	ws.Route(ws.GET("/namespaces/{namespace}/recommendations").
+		To(rh.RecommendWorkloads).
+		Doc("Recommend right sized requests and limits of the workloads in the namespace from their observed usage, with the estimated monthly cost changes. Idle workloads are recommended to be scaled down.").
+		Param(ws.PathParameter("namespace", "The name of the namespace.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("window", "The time window the usage is observed in. The format is [0-9]+[smhdwy].").DataType("string").Required(false).DefaultValue("7d")).
+		Param(ws.QueryParameter("percentile", "The percentile of the observed usage the requests are recommended by, in (0, 100].").DataType("number").Required(false).DefaultValue("95")).
+		Param(ws.QueryParameter("kind", "The kind of the workloads, one of deployment, statefulset, daemonset. Defaults to all of them.").DataType("string").Required(false)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RecommendationTag}).
+		Writes(metering.WorkloadRecommendations{}).
+		Returns(http.StatusOK, respOK, metering.WorkloadRecommendations{})).
+		Produces(restful.MIME_JSON)
+
 	c.Add(ws)
 	return nil
 }
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/common/model"
+	"k8s.io/klog/v2"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/models/metering"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+)
+
+const (
+	statementFormatJSON = "json"
+	statementFormatCSV  = "csv"
+)
+
+type statementHandler struct {
+	operator metering.StatementOperator
+}
+
+func newStatementHandler(c runtimeclient.Client, meteringOptions *meteringclient.Options) *statementHandler {
+	if meteringOptions == nil || meteringOptions.RetentionDay == "" {
+		meteringOptions = &meteringclient.DefaultMeteringOption
+	}
+	retention, err := model.ParseDuration(meteringOptions.RetentionDay)
+	if err != nil {
+		klog.Warningf("invalid metering retention %s: %s", meteringOptions.RetentionDay, err)
+	}
+	return &statementHandler{operator: metering.NewStatementOperator(c, time.Duration(retention))}
+}
+
+func (h *statementHandler) ListStatements(req *restful.Request, resp *restful.Response) {
+	result, err := h.operator.ListStatements(req.Request.Context(), req.PathParameter("workspace"), query.ParseQueryParameter(req))
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *statementHandler) GetStatement(req *restful.Request, resp *restful.Response) {
+	statement, err := h.operator.GetStatement(req.Request.Context(), req.PathParameter("workspace"), req.PathParameter("statement"))
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	switch format := req.QueryParameter("format"); format {
+	case "", statementFormatJSON:
+		resp.WriteEntity(statement)
+	case statementFormatCSV:
+		resp.Header().Set(restful.HEADER_ContentType, "text/csv")
+		resp.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", statement.Name))
+		if err := metering.WriteStatementCSV(resp, statement); err != nil {
+			klog.Error(err)
+		}
+	default:
+		api.HandleBadRequest(resp, req, fmt.Errorf("unsupported format %s", format))
+	}
+}
+
+func (h *statementHandler) CloseStatement(req *restful.Request, resp *restful.Response) {
+	statement, err := h.operator.CloseStatement(req.Request.Context(), req.PathParameter("workspace"), req.PathParameter("statement"))
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(statement)
+}
+
+func (h *statementHandler) RecalculateStatement(req *restful.Request, resp *restful.Response) {
+	statement, err := h.operator.RecalculateStatement(req.Request.Context(), req.PathParameter("workspace"), req.PathParameter("statement"))
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteEntity(statement)
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"k8s.io/utils/pointer"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	FormatCSV         = "csv"
+	FormatOpenMetrics = "openmetrics"
+	FormatRemoteWrite = "prometheus-remote-write"
+
+	mimeCSV         = "text/csv"
+	mimeOpenMetrics = "application/openmetrics-text"
+	mimeOctetStream = "application/octet-stream"
+
+	// remoteWriteMaxSamples is the max number of samples in a single remote write request.
+	remoteWriteMaxSamples = 10000
+)
+
+// metricsExporter writes the results of range queries in a format other than the Metrics JSON.
+// Metrics are written once exported, so that the response is streamed rather than buffered.
+type metricsExporter interface {
+	ContentType() string
+	Extension() string
+	Export(metric monitoring.Metric) error
+	// Close writes anything the format requires at the end.
+	Close() error
+}
+
+func isValidExportFormat(format string) bool {
+	return format == FormatCSV || format == FormatOpenMetrics || format == FormatRemoteWrite
+}
+
+func newMetricsExporter(format string, w io.Writer) (metricsExporter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvExporter{w: csv.NewWriter(w)}, nil
+	case FormatOpenMetrics:
+		return &openMetricsExporter{w: w}, nil
+	case FormatRemoteWrite:
+		return &remoteWriteExporter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %s", format)
+	}
+}
+
+// csvExporter writes a row per point, with a column for each label of the series.
+// Metrics of different levels have different labels, so the header is written again
+// after an empty line whenever the labels change.
+type csvExporter struct {
+	w      *csv.Writer
+	labels []string
+}
+
+func (e *csvExporter) ContentType() string {
+	return mimeCSV + "; charset=utf-8"
+}
+
+func (e *csvExporter) Extension() string {
+	return "csv"
+}
+
+func (e *csvExporter) Export(metric monitoring.Metric) error {
+	for _, mv := range metric.MetricValues {
+		labels := sortedLabelNames(mv.Metadata)
+		if !equalLabelNames(labels, e.labels) {
+			if e.labels != nil {
+				if err := e.w.Write(nil); err != nil {
+					return err
+				}
+			}
+			header := append([]string{"metric_name"}, labels...)
+			if err := e.w.Write(append(header, "time", "value")); err != nil {
+				return err
+			}
+			e.labels = labels
+		}
+
+		for _, p := range mv.Series {
+			record := []string{metric.MetricName}
+			for _, l := range labels {
+				record = append(record, mv.Metadata[l])
+			}
+			record = append(record, pointTime(p).Format(time.RFC3339), strconv.FormatFloat(p.Value(), 'f', -1, 64))
+			if err := e.w.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// openMetricsExporter writes every metric as a gauge metric family in the OpenMetrics text format,
+// with the points of a series as timestamped samples.
+type openMetricsExporter struct {
+	w io.Writer
+}
+
+func (e *openMetricsExporter) ContentType() string {
+	return string(expfmt.FmtOpenMetrics)
+}
+
+func (e *openMetricsExporter) Extension() string {
+	return "txt"
+}
+
+func (e *openMetricsExporter) Export(metric monitoring.Metric) error {
+	if len(metric.MetricValues) == 0 {
+		return nil
+	}
+
+	family := &dto.MetricFamily{
+		Name: pointer.String(metric.MetricName),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	for _, mv := range metric.MetricValues {
+		var labels []*dto.LabelPair
+		for _, l := range sortedLabelNames(mv.Metadata) {
+			labels = append(labels, &dto.LabelPair{Name: pointer.String(l), Value: pointer.String(mv.Metadata[l])})
+		}
+		for _, p := range mv.Series {
+			family.Metric = append(family.Metric, &dto.Metric{
+				Label:       labels,
+				Gauge:       &dto.Gauge{Value: pointer.Float64(p.Value())},
+				TimestampMs: pointer.Int64(pointTime(p).UnixMilli()),
+			})
+		}
+	}
+
+	_, err := expfmt.MetricFamilyToOpenMetrics(e.w, family)
+	return err
+}
+
+func (e *openMetricsExporter) Close() error {
+	_, err := expfmt.FinalizeOpenMetrics(e.w)
+	return err
+}
+
+// remoteWriteExporter writes a stream of Prometheus remote write requests, each of them is
+// a snappy compressed WriteRequest protobuf message prefixed with its length as uvarint.
+// Every request can be sent to a remote write endpoint as it is.
+type remoteWriteExporter struct {
+	w io.Writer
+}
+
+func (e *remoteWriteExporter) ContentType() string {
+	return mimeOctetStream
+}
+
+func (e *remoteWriteExporter) Extension() string {
+	return "bin"
+}
+
+func (e *remoteWriteExporter) Export(metric monitoring.Metric) error {
+	var req []byte
+	var samples int
+	for _, mv := range metric.MetricValues {
+		if len(mv.Series) == 0 {
+			continue
+		}
+		req = appendTimeSeries(req, metric.MetricName, mv)
+		samples += len(mv.Series)
+		if samples >= remoteWriteMaxSamples {
+			if err := e.write(req); err != nil {
+				return err
+			}
+			req, samples = nil, 0
+		}
+	}
+
+	if len(req) == 0 {
+		return nil
+	}
+	return e.write(req)
+}
+
+func (e *remoteWriteExporter) Close() error {
+	return nil
+}
+
+func (e *remoteWriteExporter) write(req []byte) error {
+	compressed := snappy.Encode(nil, req)
+	if _, err := e.w.Write(binary.AppendUvarint(nil, uint64(len(compressed)))); err != nil {
+		return err
+	}
+	_, err := e.w.Write(compressed)
+	return err
+}
+
+// appendTimeSeries appends the series to the WriteRequest message as a TimeSeries, see
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto for the definition.
+func appendTimeSeries(req []byte, name string, mv monitoring.MetricValue) []byte {
+	// Labels must be sorted by name, including the metric name label.
+	names := append(sortedLabelNames(mv.Metadata), "__name__")
+	sort.Strings(names)
+
+	var ts []byte
+	for _, l := range names {
+		value := mv.Metadata[l]
+		if l == "__name__" {
+			value = name
+		}
+
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l)
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, value)
+
+		ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, label)
+	}
+	for _, p := range mv.Series {
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(p.Value()))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(pointTime(p).UnixMilli()))
+
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+	}
+
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	return protowire.AppendBytes(req, ts)
+}
+
+func pointTime(p monitoring.Point) time.Time {
+	return time.UnixMilli(int64(math.Round(p.Timestamp() * 1000))).UTC()
+}
+
+// sortedLabelNames returns the label names in order, the metric name label is left out
+// as every exporter sets the metric name itself.
+func sortedLabelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if name != "__name__" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalLabelNames(a, b []string) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
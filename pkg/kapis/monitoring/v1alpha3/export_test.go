@@ -0,0 +1,169 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+var exportedMetrics = []monitoring.Metric{
+	{
+		MetricName: "workload_cpu_usage",
+		MetricData: monitoring.MetricData{
+			MetricType: monitoring.MetricTypeMatrix,
+			MetricValues: []monitoring.MetricValue{
+				{
+					Metadata: map[string]string{"namespace": "default", "workload": "Deployment:nginx"},
+					Series:   []monitoring.Point{{1672531200, 0.5}, {1672531260, 0.25}},
+				},
+			},
+		},
+	},
+	{
+		MetricName: "cluster_cpu_usage",
+		MetricData: monitoring.MetricData{
+			MetricType: monitoring.MetricTypeMatrix,
+			MetricValues: []monitoring.MetricValue{
+				{
+					Metadata: map[string]string{},
+					Series:   []monitoring.Point{{1672531200, 2}},
+				},
+			},
+		},
+	},
+}
+
+func export(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	exporter, err := newMetricsExporter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, metric := range exportedMetrics {
+		if err := exporter.Export(metric); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportCSV(t *testing.T) {
+	expected := `metric_name,namespace,workload,time,value
+workload_cpu_usage,default,Deployment:nginx,2023-01-01T00:00:00Z,0.5
+workload_cpu_usage,default,Deployment:nginx,2023-01-01T00:01:00Z,0.25
+
+metric_name,time,value
+cluster_cpu_usage,2023-01-01T00:00:00Z,2
+`
+	if diff := cmp.Diff(string(export(t, FormatCSV)), expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestExportOpenMetrics(t *testing.T) {
+	expected := `# TYPE workload_cpu_usage gauge
+workload_cpu_usage{namespace="default",workload="Deployment:nginx"} 0.5 1.6725312e+09
+workload_cpu_usage{namespace="default",workload="Deployment:nginx"} 0.25 1.67253126e+09
+# TYPE cluster_cpu_usage gauge
+cluster_cpu_usage 2.0 1.6725312e+09
+# EOF
+`
+	if diff := cmp.Diff(string(export(t, FormatOpenMetrics)), expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestExportRemoteWrite(t *testing.T) {
+	b := export(t, FormatRemoteWrite)
+
+	var series []string
+	for len(b) > 0 {
+		l, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("invalid length of the remote write request")
+		}
+		req, err := snappy.Decode(nil, b[n:n+int(l)])
+		if err != nil {
+			t.Fatal(err)
+		}
+		series = append(series, decodeWriteRequest(t, req)...)
+		b = b[n+int(l):]
+	}
+
+	expected := []string{
+		`__name__="workload_cpu_usage",namespace="default",workload="Deployment:nginx" 0.5@1672531200000 0.25@1672531260000`,
+		`__name__="cluster_cpu_usage" 2@1672531200000`,
+	}
+	if diff := cmp.Diff(series, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+// decodeWriteRequest formats every TimeSeries of the WriteRequest as labels followed by value@timestamp.
+func decodeWriteRequest(t *testing.T, b []byte) []string {
+	var res []string
+	for len(b) > 0 {
+		_, _, n := protowire.ConsumeTag(b)
+		ts, m := protowire.ConsumeBytes(b[n:])
+		if m < 0 {
+			t.Fatal(protowire.ParseError(m))
+		}
+		b = b[n+m:]
+
+		var labels, samples string
+		for len(ts) > 0 {
+			num, _, n := protowire.ConsumeTag(ts)
+			field, m := protowire.ConsumeBytes(ts[n:])
+			ts = ts[n+m:]
+
+			switch num {
+			case 1:
+				_, _, n := protowire.ConsumeTag(field)
+				name, m := protowire.ConsumeString(field[n:])
+				field = field[n+m:]
+				_, _, n = protowire.ConsumeTag(field)
+				value, _ := protowire.ConsumeString(field[n:])
+				if labels != "" {
+					labels += ","
+				}
+				labels += fmt.Sprintf("%s=%q", name, value)
+			case 2:
+				_, _, n := protowire.ConsumeTag(field)
+				value, m := protowire.ConsumeFixed64(field[n:])
+				field = field[n+m:]
+				_, _, n = protowire.ConsumeTag(field)
+				timestamp, _ := protowire.ConsumeVarint(field[n:])
+				samples += fmt.Sprintf(" %v@%d", math.Float64frombits(value), int64(timestamp))
+			}
+		}
+		res = append(res, labels+samples)
+	}
+	return res
+}
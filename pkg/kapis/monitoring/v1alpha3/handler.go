@@ -21,6 +21,7 @@ package v1alpha3
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -34,6 +35,7 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	monitoringdashboardv1alpha2 "kubesphere.io/monitoring-dashboard/api/v1alpha2"
@@ -115,6 +117,10 @@ func (h handler) handleNamespaceMetricsQuery(req *restful.Request, resp *restful
 	opt, err := h.makeQueryOptions(params, monitoring.LevelNamespace)
 	if err != nil {
 		if err.Error() == ErrNoHit {
+			if opt.format != "" {
+				h.exportNamedMetrics(resp, opt, nil)
+				return
+			}
 			res := handleNoHit(opt.namedMetrics)
 			resp.WriteAsJson(res)
 			return
@@ -131,6 +137,10 @@ func (h handler) handleWorkloadMetricsQuery(req *restful.Request, resp *restful.
 	opt, err := h.makeQueryOptions(params, monitoring.LevelWorkload)
 	if err != nil {
 		if err.Error() == ErrNoHit {
+			if opt.format != "" {
+				h.exportNamedMetrics(resp, opt, nil)
+				return
+			}
 			res := handleNoHit(opt.namedMetrics)
 			resp.WriteAsJson(res)
 			return
@@ -147,6 +157,10 @@ func (h handler) handlePodMetricsQuery(req *restful.Request, resp *restful.Respo
 	opt, err := h.makeQueryOptions(params, monitoring.LevelPod)
 	if err != nil {
 		if err.Error() == ErrNoHit {
+			if opt.format != "" {
+				h.exportNamedMetrics(resp, opt, nil)
+				return
+			}
 			res := handleNoHit(opt.namedMetrics)
 			resp.WriteAsJson(res)
 			return
@@ -163,6 +177,10 @@ func (h handler) handleContainerMetricsQuery(req *restful.Request, resp *restful
 	opt, err := h.makeQueryOptions(params, monitoring.LevelContainer)
 	if err != nil {
 		if err.Error() == ErrNoHit {
+			if opt.format != "" {
+				h.exportNamedMetrics(resp, opt, nil)
+				return
+			}
 			res := handleNoHit(opt.namedMetrics)
 			resp.WriteAsJson(res)
 			return
@@ -179,6 +197,10 @@ func (h handler) handlePVCMetricsQuery(req *restful.Request, resp *restful.Respo
 	opt, err := h.makeQueryOptions(params, monitoring.LevelPVC)
 	if err != nil {
 		if err.Error() == ErrNoHit {
+			if opt.format != "" {
+				h.exportNamedMetrics(resp, opt, nil)
+				return
+			}
 			res := handleNoHit(opt.namedMetrics)
 			resp.WriteAsJson(res)
 			return
@@ -195,6 +217,10 @@ func (h handler) handleIngressMetricsQuery(req *restful.Request, resp *restful.R
 	opt, err := h.makeQueryOptions(params, monitoring.LevelIngress)
 	if err != nil {
 		if err.Error() == ErrNoHit {
+			if opt.format != "" {
+				h.exportNamedMetrics(resp, opt, nil)
+				return
+			}
 			res := handleNoHit(opt.namedMetrics)
 			resp.WriteAsJson(res)
 			return
@@ -230,8 +256,22 @@ func handleNoHit(namedMetrics []string) model.Metrics {
 func (h handler) handleNamedMetricsQuery(resp *restful.Response, q queryOptions) {
 	var res model.Metrics
 
+	namedMetrics := make([]string, 0, len(q.namedMetrics))
+	namedMetrics = append(namedMetrics, q.namedMetrics...)
+	if q.option != nil {
+		// user defined metrics applying to the level, only queried when named in the metrics filter
+		opts := monitoring.NewQueryOptions()
+		q.option.Apply(opts)
+		names := metricNamesOf(q.metricFilter)
+		for _, t := range h.mo.GetMetricTemplates(opts.Level) {
+			if names[t.Name] {
+				namedMetrics = append(namedMetrics, t.Name)
+			}
+		}
+	}
+
 	var metrics []string
-	for _, metric := range q.namedMetrics {
+	for _, metric := range namedMetrics {
 		if strings.HasPrefix(metric, model.MetricMeterPrefix) {
 			// skip meter metric
 			continue
@@ -241,6 +281,10 @@ func (h handler) handleNamedMetricsQuery(resp *restful.Response, q queryOptions)
 			metrics = append(metrics, metric)
 		}
 	}
+	if q.format != "" {
+		h.exportNamedMetrics(resp, q, metrics)
+		return
+	}
 	if len(metrics) == 0 {
 		resp.WriteAsJson(res)
 		return
@@ -257,6 +301,54 @@ func (h handler) handleNamedMetricsQuery(resp *restful.Response, q queryOptions)
 	resp.WriteAsJson(res)
 }
 
+// exportNamedMetrics queries and exports the metrics one by one, so that a single metric is held
+// in memory at most. Once the first metric is written, a failure can't be reported by the status
+// code any more, the response is ended early instead.
+func (h handler) exportNamedMetrics(resp *restful.Response, q queryOptions, metrics []string) {
+	exporter, err := newMetricsExporter(q.format, resp)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	started := false
+	start := func() {
+		if !started {
+			resp.Header().Set(restful.HEADER_ContentType, exporter.ContentType())
+			resp.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=metrics.%s", exporter.Extension()))
+			resp.WriteHeader(http.StatusOK)
+			started = true
+		}
+	}
+
+	for _, name := range metrics {
+		res := h.mo.GetNamedMetricsOverTime([]string{name}, q.start, q.end, q.step, q.option)
+		for _, metric := range res.Results {
+			if metric.Error != "" {
+				err := fmt.Errorf("failed to export metric %s, %s", metric.MetricName, metric.Error)
+				if !started {
+					api.HandleInternalError(resp, nil, err)
+				} else {
+					klog.Error(err)
+				}
+				return
+			}
+
+			start()
+			if err := exporter.Export(metric); err != nil {
+				klog.Error(err)
+				return
+			}
+		}
+		resp.Flush()
+	}
+
+	start()
+	if err := exporter.Close(); err != nil {
+		klog.Error(err)
+	}
+}
+
 func (h handler) handleMetadataQuery(req *restful.Request, resp *restful.Response) {
 	res := h.mo.GetMetadata(req.PathParameter("namespace"))
 	resp.WriteAsJson(res)
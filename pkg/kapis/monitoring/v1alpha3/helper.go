@@ -57,6 +57,8 @@ const (
 	ErrInvalidPage       = "Invalid parameter 'page'."
 	ErrInvalidLimit      = "Invalid parameter 'limit'."
 	ErrParameterNotfound = "Parmameter [%s] not found"
+	ErrInvalidFormat     = "Invalid parameter 'format'."
+	ErrFormatNotRange    = "'format' requires 'start' and 'end'."
 )
 
 type reqParams struct {
@@ -94,6 +96,7 @@ type reqParams struct {
 	duration                  string
 	pvcFilter                 string
 	queryType                 string
+	format                    string
 }
 
 type queryOptions struct {
@@ -101,6 +104,8 @@ type queryOptions struct {
 	namedMetrics []string
 
 	Operation string
+	// format is the format range query results are exported in, the Metrics JSON if empty.
+	format string
 
 	start time.Time
 	end   time.Time
@@ -157,6 +162,7 @@ func parseRequestParams(req *restful.Request) reqParams {
 	r.expression = req.QueryParameter("expr")
 	r.metric = req.QueryParameter("metric")
 	r.queryType = req.QueryParameter("type")
+	r.format = req.QueryParameter("format")
 
 	return r
 }
@@ -213,6 +219,11 @@ func (h handler) makeQueryOptions(r reqParams, lvl monitoring.Level) (q queryOpt
 		q.Operation = OperationQuery
 	}
 
+	if r.format != "" && !isValidExportFormat(r.format) {
+		return q, errors.New(ErrInvalidFormat)
+	}
+	q.format = r.format
+
 	switch lvl {
 	case monitoring.LevelCluster:
 		q.option = monitoring.ClusterOption{}
@@ -417,6 +428,10 @@ func (h handler) makeQueryOptions(r reqParams, lvl monitoring.Level) (q queryOpt
 		return q, errors.Errorf(ErrParamConflict)
 	}
 
+	if q.format != "" && !q.isRangeQuery() {
+		return q, errors.New(ErrFormatNotRange)
+	}
+
 	// Ensure query start time to be after the namespace creation time
 	if r.namespaceName != "" && !r.metering {
 		ns, err := h.k.CoreV1().Namespaces().Get(context.Background(), r.namespaceName, corev1.GetOptions{})
@@ -552,3 +567,12 @@ func ExportMetrics(resp *restful.Response, metrics model.Metrics, startTime, end
 		return
 	}
 }
+
+// metricNamesOf returns the metric names listed in the metrics filter, e.g. a|b$ lists a and b.
+func metricNamesOf(filter string) map[string]bool {
+	names := make(map[string]bool)
+	for _, name := range strings.Split(filter, "|") {
+		names[strings.Trim(name, "^$()")] = true
+	}
+	return names
+}
@@ -94,6 +94,43 @@ func TestParseRequestParams(t *testing.T) {
 			},
 			expectedErr: false,
 		},
+		{
+			params: reqParams{
+				time:   "1585831995",
+				format: FormatCSV,
+			},
+			lvl:         monitoring.LevelCluster,
+			expectedErr: true,
+		},
+		{
+			params: reqParams{
+				start:  "1585830000",
+				end:    "1585839999",
+				format: "xlsx",
+			},
+			lvl:         monitoring.LevelCluster,
+			expectedErr: true,
+		},
+		{
+			params: reqParams{
+				start:  "1585830000",
+				end:    "1585839999",
+				step:   "1m",
+				format: FormatOpenMetrics,
+			},
+			lvl: monitoring.LevelCluster,
+			expected: queryOptions{
+				start:        time.Unix(1585830000, 0),
+				end:          time.Unix(1585839999, 0),
+				step:         time.Minute,
+				metricFilter: ".*",
+				namedMetrics: model.ClusterMetrics,
+				option:       monitoring.ClusterOption{},
+				Operation:    OperationQuery,
+				format:       FormatOpenMetrics,
+			},
+			expectedErr: false,
+		},
 		{
 			params: reqParams{
 				start:         "1585830000",
@@ -354,6 +391,7 @@ func TestParseRequestParams(t *testing.T) {
 					"meter_pod_net_bytes_transmitted",
 					"meter_pod_net_bytes_received",
 					"meter_pod_pvc_bytes_total",
+					"meter_pod_gpu_usage",
 				},
 				Operation: OperationQuery,
 				option:    monitoring.PodOption{NamespacedResourcesFilter: "test1|test2", ResourceFilter: ".*"},
@@ -478,3 +516,14 @@ func TestGetMetricPosMap(t *testing.T) {
 		t.Fatal("getMetricPosMap failed")
 	}
 }
+
+func TestMetricNamesOf(t *testing.T) {
+	names := metricNamesOf("workload_cpu_usage|workload_http_requests$")
+	if !names["workload_cpu_usage"] || !names["workload_http_requests"] {
+		t.Fatalf("expected both metrics to be listed, got %v", names)
+	}
+
+	if names := metricNamesOf(DefaultFilter); names["workload_http_requests"] {
+		t.Fatal("the default filter should not list any metric")
+	}
+}
@@ -63,6 +63,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ClusterMetricsTag}).
 		Writes(model.Metrics{}).
@@ -77,6 +79,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort nodes by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -96,6 +100,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeMetricsTag}).
 		Writes(model.Metrics{}).
@@ -110,6 +116,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort workspaces by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -128,6 +136,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("type", "Additional operations. Currently available types is statistics. It retrieves the total number of namespaces, devops projects, members and roles in this workspace at the moment.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMetricsTag}).
@@ -144,6 +154,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort namespaces by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -162,6 +174,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort namespaces by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -180,6 +194,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceMetricsTag}).
 		Writes(model.Metrics{}).
@@ -195,6 +211,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort workloads by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -215,6 +233,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort workloads by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -233,6 +253,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort pods by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -252,6 +274,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort pods by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -271,6 +295,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodMetricsTag}).
 		Writes(model.Metrics{}).
@@ -288,6 +314,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort pods by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -308,6 +336,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort pods by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -328,6 +358,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodMetricsTag}).
 		Writes(model.Metrics{}).
@@ -344,6 +376,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort containers by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -364,6 +398,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ContainerMetricsTag}).
 		Writes(model.Metrics{}).
@@ -379,6 +415,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort PVCs by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -398,6 +436,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort PVCs by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -417,6 +457,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PVCMetricsTag}).
 		Writes(model.Metrics{}).
@@ -435,6 +477,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_metric", "Sort PVCs by the specified metric. Not applicable if **start** and **end** are provided.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort_type", "Sort order. One of asc, desc.").DefaultValue("desc.").DataType("string").Required(false)).
@@ -457,6 +501,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.IngressMetricsTag}).
 		Writes(model.Metrics{}).
@@ -471,6 +517,8 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monito
 		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
 		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("format", "Export format of metric data over a time span, one of csv, openmetrics, prometheus-remote-write. It requires both **start** and **end** are provided. The response is streamed metric by metric. Metric labels map to the columns of csv and the series labels of openmetrics and prometheus-remote-write. The prometheus-remote-write format is a stream of snappy compressed remote write requests, each prefixed with its length as uvarint. Defaults to the JSON format.").DataType("string").Required(false)).
+		Produces(restful.MIME_JSON, mimeCSV, mimeOpenMetrics, mimeOctetStream).
 		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ComponentMetricsTag}).
 		Writes(model.Metrics{}).
@@ -17,15 +17,28 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 
+	"kubesphere.io/api/network/v1alpha1"
+
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	requestctx "kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/models/network"
 )
 
 const ScopeQueryUrl = "http://%s/api/topology/services"
@@ -33,6 +46,10 @@ const ScopeQueryUrl = "http://%s/api/topology/services"
 type handler struct {
 	// if weave scope installed in the cluster, it is maybe `weave-scope-app.weave`
 	weaveScopeHost string
+	informers      informers.InformerFactory
+	analyzer       network.Analyzer
+	recommender    network.Recommender
+	authorizer     authorizer.Authorizer
 }
 
 func (h *handler) getScopeUrl() string {
@@ -102,3 +119,178 @@ func (h *handler) getNamespaceNodeTopology(request *restful.Request, response *r
 		klog.Errorf("write response failed %v", err)
 	}
 }
+
+func (h *handler) checkConnectivity(request *restful.Request, response *restful.Response) {
+	var query network.ConnectivityQuery
+	if err := request.ReadEntity(&query); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	// the endpoints of a namespaced query are restricted to the namespace, the IPs are checked once resolved
+	if namespace := request.PathParameter("namespace"); namespace != "" {
+		query.Namespace = namespace
+		for _, e := range []*network.Endpoint{&query.Source, &query.Destination} {
+			if e.Kind == network.KindIP {
+				continue
+			}
+			if e.Namespace == "" {
+				e.Namespace = namespace
+			}
+			if e.Namespace != namespace {
+				api.HandleBadRequest(response, request, fmt.Errorf("endpoint %s/%s is not in namespace %s", e.Namespace, e.Name, namespace))
+				return
+			}
+		}
+	}
+
+	result, err := h.analyzer.Check(&query)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(result)
+}
+
+func (h *handler) getConnectivityMatrix(request *restful.Request, response *restful.Response) {
+	query := &network.MatrixQuery{
+		Level:    network.GroupLevel(request.QueryParameter("level")),
+		Protocol: corev1.Protocol(strings.ToUpper(request.QueryParameter("protocol"))),
+	}
+	if query.Level == "" {
+		query.Level = network.GroupLevelNamespace
+	}
+	if port := request.QueryParameter("port"); port != "" {
+		number, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		query.Port = int32(number)
+	}
+	if ips := request.QueryParameter("ips"); ips != "" {
+		query.ExternalIPs = strings.Split(ips, ",")
+	}
+
+	if namespace := request.PathParameter("namespace"); namespace != "" {
+		query.Namespaces = []string{namespace}
+	} else if workspace := request.PathParameter("workspace"); workspace != "" {
+		namespaces, err := h.informers.KubernetesSharedInformerFactory().Core().V1().Namespaces().Lister().
+			List(labels.SelectorFromSet(labels.Set{constants.WorkspaceLabelKey: workspace}))
+		if err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		// a workspace without namespaces has an empty matrix, rather than the matrix of all the namespaces
+		if len(namespaces) == 0 {
+			response.WriteEntity(&network.ConnectivityMatrix{Level: query.Level, Groups: []network.Group{}, Cells: [][]network.Verdict{}})
+			return
+		}
+		for _, ns := range namespaces {
+			query.Namespaces = append(query.Namespaces, ns.Name)
+		}
+	} else if namespaces := request.QueryParameter("namespaces"); namespaces != "" {
+		query.Namespaces = strings.Split(namespaces, ",")
+	}
+
+	matrix, err := h.analyzer.Matrix(query)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(matrix)
+}
+
+// RecommendationRequest carries the flows observed by a flow-log source.
+type RecommendationRequest struct {
+	Flows []network.Flow `json:"flows"`
+}
+
+func (h *handler) recommendPolicies(request *restful.Request, response *restful.Response) {
+	level := network.GroupLevel(request.QueryParameter("level"))
+	if level == "" {
+		level = network.GroupLevelNamespace
+	}
+	end := time.Now()
+	if tstr := request.QueryParameter("end_time"); tstr != "" {
+		sec, err := strconv.ParseInt(tstr, 10, 64)
+		if err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		end = time.Unix(sec, 0)
+	}
+	start := end.Add(-7 * 24 * time.Hour)
+	if tstr := request.QueryParameter("start_time"); tstr != "" {
+		sec, err := strconv.ParseInt(tstr, 10, 64)
+		if err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		start = time.Unix(sec, 0)
+	}
+	if !start.Before(end) {
+		api.HandleBadRequest(response, request, fmt.Errorf("start_time must be before end_time"))
+		return
+	}
+
+	// the flows are queried from the flow source unless they are posted
+	var flows []network.Flow
+	if request.Request.Method == http.MethodPost {
+		var body RecommendationRequest
+		if err := request.ReadEntity(&body); err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		flows = body.Flows
+		if flows == nil {
+			flows = []network.Flow{}
+		}
+	}
+
+	recommendation, err := h.recommender.Recommend(request.PathParameter("namespace"), level, start, end, flows)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(recommendation)
+}
+
+func (h *handler) applyRecommendation(request *restful.Request, response *restful.Response) {
+	var policies []*v1alpha1.NamespaceNetworkPolicy
+	if err := request.ReadEntity(&policies); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	// the policies are applied by the apiserver, so the user is authorized to apply them first
+	namespace := request.PathParameter("namespace")
+	user, _ := requestctx.UserFrom(request.Request.Context())
+	for _, verb := range []string{"create", "update"} {
+		decision, reason, err := h.authorizer.Authorize(authorizer.AttributesRecord{
+			User:            user,
+			Verb:            verb,
+			APIGroup:        v1alpha1.SchemeGroupVersion.Group,
+			APIVersion:      v1alpha1.SchemeGroupVersion.Version,
+			Resource:        v1alpha1.ResourcePluralNamespaceNetworkPolicy,
+			Namespace:       namespace,
+			ResourceRequest: true,
+			ResourceScope:   requestctx.NamespaceScope,
+		})
+		if err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		if decision != authorizer.DecisionAllow {
+			api.HandleForbidden(response, request, errors.New(reason))
+			return
+		}
+	}
+
+	applied, err := h.recommender.Apply(namespace, policies)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(applied)
+}
@@ -21,19 +21,40 @@ import (
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"kubesphere.io/api/network/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
 	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/models/network"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	networkclient "kubesphere.io/kubesphere/pkg/simple/client/network"
 )
 
 const GroupName = "network.kubesphere.io"
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(c *restful.Container, weaveScopeHost string) error {
+func AddToContainer(c *restful.Container, options *networkclient.Options, factory informers.InformerFactory,
+	ksClient kubesphere.Interface, monitoringClient monitoring.Interface, authorizer authorizer.Authorizer) error {
 	webservice := runtime.NewWebService(GroupVersion)
-	h := handler{weaveScopeHost: weaveScopeHost}
+	var flowSource network.FlowSource
+	if monitoringClient != nil {
+		flowSource = network.NewPrometheusFlowSource(monitoringClient, options.FlowOptions)
+	}
+	h := handler{
+		weaveScopeHost: options.WeaveScopeHost,
+		informers:      factory,
+		analyzer:       network.NewAnalyzer(factory, options),
+		recommender:    network.NewRecommender(factory, ksClient, flowSource),
+		authorizer:     authorizer,
+	}
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/topology").
 		To(h.getNamespaceTopology).
@@ -54,6 +75,76 @@ func AddToContainer(c *restful.Container, weaveScopeHost string) error {
 		Writes(NodeResponse{})).
 		Produces(restful.MIME_JSON)
 
+	webservice.Route(webservice.POST("/connectivity").
+		To(h.checkConnectivity).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+		Doc("Check whether the traffic from the source to the destination is allowed by the network policies, which are evaluated statically.").
+		Reads(network.ConnectivityQuery{}).
+		Returns(http.StatusOK, api.StatusOK, network.ConnectivityResult{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/connectivity").
+		To(h.checkConnectivity).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+		Doc("Check whether the traffic between the endpoints of the namespace is allowed by the network policies, the endpoints out of the cluster are specified by the IPs.").
+		Param(webservice.PathParameter("namespace", "name of the namespace").Required(true)).
+		Reads(network.ConnectivityQuery{}).
+		Returns(http.StatusOK, api.StatusOK, network.ConnectivityResult{}))
+
+	matrixParams := func(builder *restful.RouteBuilder) *restful.RouteBuilder {
+		return builder.
+			Param(webservice.QueryParameter("level", "the level the pods are grouped by, one of namespace and workload").DefaultValue(string(network.GroupLevelNamespace))).
+			Param(webservice.QueryParameter("port", "the port the traffic is checked on, it is checked on any port by default").DataType("integer")).
+			Param(webservice.QueryParameter("protocol", "the protocol of the port").DefaultValue(string(corev1.ProtocolTCP))).
+			Param(webservice.QueryParameter("ips", "the IPs out of the cluster added to the matrix, separated by comma")).
+			Returns(http.StatusOK, api.StatusOK, network.ConnectivityMatrix{})
+	}
+
+	webservice.Route(matrixParams(webservice.GET("/connectivitymatrix").
+		To(h.getConnectivityMatrix).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+		Doc("Get the connectivity matrix of the namespaces, which is evaluated statically from the network policies.").
+		Param(webservice.QueryParameter("namespaces", "the namespaces in the matrix, separated by comma. All the namespaces are in the matrix by default"))))
+
+	webservice.Route(matrixParams(webservice.GET("/workspaces/{workspace}/connectivitymatrix").
+		To(h.getConnectivityMatrix).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+		Doc("Get the connectivity matrix of the namespaces of the workspace.").
+		Param(webservice.PathParameter("workspace", "name of the workspace").Required(true))))
+
+	webservice.Route(matrixParams(webservice.GET("/namespaces/{namespace}/connectivitymatrix").
+		To(h.getConnectivityMatrix).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+		Doc("Get the connectivity matrix of the workloads of the namespace.").
+		Param(webservice.PathParameter("namespace", "name of the namespace").Required(true))))
+
+	recommendationParams := func(builder *restful.RouteBuilder) *restful.RouteBuilder {
+		return builder.
+			Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+			Param(webservice.PathParameter("namespace", "name of the namespace").Required(true)).
+			Param(webservice.QueryParameter("level", "the level of the peers, one of namespace and workload. The peers are the services of the workloads at the workload level").DefaultValue(string(network.GroupLevelNamespace))).
+			Param(webservice.QueryParameter("start_time", "Start time of the learning window, unix timestamp in seconds. Defaults to 7 days before end_time.").DataType("string").Required(false)).
+			Param(webservice.QueryParameter("end_time", "End time of the learning window, unix timestamp in seconds. Defaults to now.").DataType("string").Required(false)).
+			Returns(http.StatusOK, api.StatusOK, network.Recommendation{})
+	}
+
+	webservice.Route(recommendationParams(webservice.GET("/namespaces/{namespace}/networkpolicyrecommendation").
+		To(h.recommendPolicies).
+		Doc("Recommend the NamespaceNetworkPolicies of the namespace from the flows counted by the flow metric in Prometheus.")))
+
+	webservice.Route(recommendationParams(webservice.POST("/namespaces/{namespace}/networkpolicyrecommendation").
+		To(h.recommendPolicies).
+		Doc("Recommend the NamespaceNetworkPolicies of the namespace from the flows posted, which are observed by a flow-log source.").
+		Reads(RecommendationRequest{})))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/networkpolicyrecommendation/apply").
+		To(h.applyRecommendation).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkPolicyTag}).
+		Doc("Apply the reviewed NamespaceNetworkPolicies as a bundle on behalf of the user, none of them is applied if any of them is invalid, "+
+			"and the policies applied are rolled back if any of them fails.").
+		Param(webservice.PathParameter("namespace", "name of the namespace").Required(true)).
+		Reads([]v1alpha1.NamespaceNetworkPolicy{}).
+		Returns(http.StatusOK, api.StatusOK, []v1alpha1.NamespaceNetworkPolicy{}))
+
 	c.Add(webservice)
 
 	return nil
@@ -22,8 +22,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	kapinotificationv2beta2 "kubesphere.io/kubesphere/pkg/api/notification/v2beta2"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
 	"kubesphere.io/kubesphere/pkg/informers"
@@ -33,17 +35,20 @@ import (
 )
 
 type handler struct {
-	operator nmoperator.Operator
+	operator        nmoperator.Operator
+	historyOperator nmoperator.HistoryOperator
 }
 
 func newNotificationHandler(
 	informers informers.InformerFactory,
 	k8sClient kubernetes.Interface,
 	ksClient kubesphere.Interface,
-	options *notification.Options) *handler {
+	options *notification.Options,
+	runtimeCache runtimecache.Cache) *handler {
 
 	return &handler{
-		operator: nmoperator.NewOperator(informers, k8sClient, ksClient, options),
+		operator:        nmoperator.NewOperator(informers, k8sClient, ksClient, options),
+		historyOperator: nmoperator.NewHistoryOperator(informers, runtimeCache),
 	}
 }
 
@@ -159,6 +164,19 @@ func (h *handler) Verify(req *restful.Request, resp *restful.Response) {
 	h.operator.Verify(req, resp)
 }
 
+func (h *handler) QueryHistory(req *restful.Request, resp *restful.Response) {
+
+	user := req.PathParameter("user")
+	opts, err := kapinotificationv2beta2.ParseHistoryOptions(req)
+	if err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	history, err := h.historyOperator.Query(req.Request.Context(), user, opts)
+	handleResponse(req, resp, history, err)
+}
+
 func handleResponse(req *restful.Request, resp *restful.Response, obj interface{}, err error) {
 
 	if err != nil {
@@ -25,8 +25,10 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	kapinotificationv2beta2 "kubesphere.io/kubesphere/pkg/api/notification/v2beta2"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
@@ -48,21 +50,50 @@ func AddToContainer(
 	informers informers.InformerFactory,
 	k8sClient kubernetes.Interface,
 	ksClient kubesphere.Interface,
-	options *notification.Options) error {
+	options *notification.Options,
+	runtimeCache runtimecache.Cache) error {
 
 	ws := runtime.NewWebService(GroupVersion)
-	h := newNotificationHandler(informers, k8sClient, ksClient, options)
+	h := newNotificationHandler(informers, k8sClient, ksClient, options, runtimeCache)
 
 	ws.Route(ws.POST("/verification").
 		Reads("").
 		To(h.Verify).
 		Returns(http.StatusOK, api.StatusOK, http.Response{}.Body)).
-		Doc("Provide validation for notification-manager information")
+		Doc("Send a test notification to verify the receiver, the receiver is verified in process if notification-manager is not available, where the internal addresses are not allowed")
 	ws.Route(ws.POST("/users/{user}/verification").
 		To(h.Verify).
 		Param(ws.PathParameter("user", "user name")).
 		Returns(http.StatusOK, api.StatusOK, http.Response{}.Body)).
-		Doc("Provide validation for notification-manager information")
+		Doc("Send a test notification to verify the receiver, the receiver is verified in process if notification-manager is not available, where the internal addresses are not allowed")
+
+	// apis for notification history, which is recorded by the history receiver in ks-controller-manager
+	ws.Route(ws.GET("/history").
+		To(h.QueryHistory).
+		Doc("Query the notifications sent and the statistics of the receivers").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryReceiver, "name of the receiver").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryTenant, "tenant owning the receiver").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryAlertName, "name of the alert").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryStatus, "status of the notification, success or failed").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryStart, "start time in unix seconds, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryEnd, "end time in unix seconds, defaults to now").Required(false)).
+		Param(ws.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(ws.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, kapinotificationv2beta2.History{}))
+	ws.Route(ws.GET("/users/{user}/history").
+		To(h.QueryHistory).
+		Doc("Query the notifications sent to the receivers of the user and the statistics of the receivers").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("user", "user name")).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryReceiver, "name of the receiver").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryAlertName, "name of the alert").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryStatus, "status of the notification, success or failed").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryStart, "start time in unix seconds, defaults to 24 hours before the end").Required(false)).
+		Param(ws.QueryParameter(kapinotificationv2beta2.ParameterHistoryEnd, "end time in unix seconds, defaults to now").Required(false)).
+		Param(ws.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(ws.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, kapinotificationv2beta2.History{}))
 
 	// apis for global notification config, receiver, and secret
 	ws.Route(ws.GET("/{resources}").
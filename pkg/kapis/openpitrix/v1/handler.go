@@ -41,6 +41,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/server/params"
 	openpitrixoptions "kubesphere.io/kubesphere/pkg/simple/client/openpitrix"
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmrepoindex"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/utils/clusterclient"
 	"kubesphere.io/kubesphere/pkg/utils/idutils"
@@ -60,8 +61,12 @@ func NewOpenpitrixClient(ksInformers informers.InformerFactory, ksClient version
 			klog.Errorf("failed to connect to storage, please check storage service status, error: %v", err)
 		}
 	}
+	var verification *openpitrixoptions.ChartVerificationOptions
+	if option != nil {
+		verification = option.ChartVerification
+	}
 
-	return openpitrix.NewOpenpitrixOperator(ksInformers, ksClient, s3Client, cc)
+	return openpitrix.NewOpenpitrixOperator(ksInformers, ksClient, s3Client, cc, verification)
 }
 
 func (h *openpitrixHandler) CreateRepo(req *restful.Request, resp *restful.Response) {
@@ -125,11 +130,21 @@ func (h *openpitrixHandler) CreateRepo(req *restful.Request, resp *restful.Respo
 		repo.Annotations[v1alpha1.RepoSyncPeriod] = createRepoRequest.SyncPeriod
 	}
 
-	if strings.HasPrefix(createRepoRequest.URL, "https://") || strings.HasPrefix(createRepoRequest.URL, "http://") {
+	if strings.HasPrefix(createRepoRequest.URL, "https://") || strings.HasPrefix(createRepoRequest.URL, "http://") ||
+		helmrepoindex.IsOCIRepo(createRepoRequest.URL) {
 		if userInfo != nil {
 			repo.Spec.Credential.Username = userInfo.Username()
 			repo.Spec.Credential.Password, _ = userInfo.Password()
 		}
+		if helmrepoindex.IsOCIRepo(createRepoRequest.URL) && createRepoRequest.Credential != "" {
+			// the OCI registries without tls are opted in by the credential, e.g. {"plainHTTP": true}
+			cred := v1alpha1.HelmRepoCredential{}
+			if err := json.Unmarshal([]byte(createRepoRequest.Credential), &cred); err != nil {
+				api.HandleBadRequest(resp, nil, err)
+				return
+			}
+			repo.Spec.Credential.PlainHTTP = cred.PlainHTTP
+		}
 	} else if strings.HasPrefix(createRepoRequest.URL, "s3://") {
 		cfg := v1alpha1.S3Config{}
 		err := json.Unmarshal([]byte(createRepoRequest.Credential), &cfg)
@@ -370,8 +385,10 @@ func (h *openpitrixHandler) CreateApp(req *restful.Request, resp *restful.Respon
 
 	if validate {
 		validatePackageRequest := &openpitrix.ValidatePackageRequest{
-			VersionPackage: createAppRequest.VersionPackage,
-			VersionType:    createAppRequest.VersionType,
+			VersionPackage:   createAppRequest.VersionPackage,
+			VersionType:      createAppRequest.VersionType,
+			VersionSignature: createAppRequest.VersionSignature,
+			Workspace:        createAppRequest.Isv,
 		}
 		_ = validatePackageRequest
 		result, err = h.openpitrix.ValidatePackage(validatePackageRequest)
@@ -491,8 +508,10 @@ func (h *openpitrixHandler) CreateAppVersion(req *restful.Request, resp *restful
 
 	if validate {
 		validatePackageRequest := &openpitrix.ValidatePackageRequest{
-			VersionPackage: createAppVersionRequest.Package,
-			VersionType:    createAppVersionRequest.Type,
+			VersionPackage:   createAppVersionRequest.Package,
+			VersionType:      createAppVersionRequest.Type,
+			VersionSignature: createAppVersionRequest.Signature,
+			Workspace:        req.PathParameter("workspace"),
 		}
 		result, err = h.openpitrix.ValidatePackage(validatePackageRequest)
 	} else {
@@ -773,14 +792,121 @@ func (h *openpitrixHandler) UpgradeApplication(req *restful.Request, resp *restf
 
 	err = h.openpitrix.UpgradeApplication(upgradeClusterRequest, applicationId)
 	if err != nil {
-		klog.Errorln(err)
-		api.HandleInternalError(resp, nil, err)
+		handleReleaseError(resp, err)
 		return
 	}
 
 	resp.WriteEntity(errors.None)
 }
 
+func (h *openpitrixHandler) DryRunApplication(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	applicationId := req.PathParameter("application")
+	var upgradeClusterRequest openpitrix.UpgradeClusterRequest
+	err := req.ReadEntity(&upgradeClusterRequest)
+	if err != nil {
+		klog.V(4).Infoln(err)
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	upgradeClusterRequest.Namespace = namespace
+	result, err := h.openpitrix.DryRunApplication(upgradeClusterRequest, applicationId)
+	if err != nil {
+		handleReleaseError(resp, err)
+		return
+	}
+
+	resp.WriteEntity(result)
+}
+
+func (h *openpitrixHandler) ListRevisions(req *restful.Request, resp *restful.Response) {
+	clusterName := req.PathParameter("cluster")
+	workspace := req.PathParameter("workspace")
+	applicationId := req.PathParameter("application")
+	namespace := req.PathParameter("namespace")
+
+	revisions, err := h.openpitrix.ListRevisions(workspace, clusterName, namespace, applicationId)
+	if err != nil {
+		handleReleaseError(resp, err)
+		return
+	}
+
+	resp.WriteEntity(revisions)
+}
+
+func (h *openpitrixHandler) DiffRevisions(req *restful.Request, resp *restful.Response) {
+	clusterName := req.PathParameter("cluster")
+	workspace := req.PathParameter("workspace")
+	applicationId := req.PathParameter("application")
+	namespace := req.PathParameter("namespace")
+
+	from, err := strconv.Atoi(req.QueryParameter("from"))
+	if err != nil {
+		api.HandleBadRequest(resp, nil, fmt.Errorf("invalid revision from: %s", err))
+		return
+	}
+	to := 0
+	if v := req.QueryParameter("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			api.HandleBadRequest(resp, nil, fmt.Errorf("invalid revision to: %s", err))
+			return
+		}
+	}
+
+	diff, err := h.openpitrix.DiffRevisions(workspace, clusterName, namespace, applicationId, from, to)
+	if err != nil {
+		handleReleaseError(resp, err)
+		return
+	}
+
+	resp.WriteEntity(diff)
+}
+
+func (h *openpitrixHandler) RollbackApplication(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	applicationId := req.PathParameter("application")
+	var rollbackRequest openpitrix.RollbackApplicationRequest
+	err := req.ReadEntity(&rollbackRequest)
+	if err != nil {
+		klog.V(4).Infoln(err)
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if rollbackRequest.Revision <= 0 {
+		api.HandleBadRequest(resp, nil, fmt.Errorf("invalid revision %d", rollbackRequest.Revision))
+		return
+	}
+
+	rollbackRequest.Namespace = namespace
+	user, _ := request.UserFrom(req.Request.Context())
+	if user != nil {
+		rollbackRequest.Username = user.GetName()
+	}
+
+	err = h.openpitrix.RollbackApplication(rollbackRequest, applicationId)
+	if err != nil {
+		handleReleaseError(resp, err)
+		return
+	}
+
+	resp.WriteEntity(errors.None)
+}
+
+func handleReleaseError(resp *restful.Response, err error) {
+	switch {
+	case apierrors.IsNotFound(err):
+		klog.V(4).Infoln(err)
+		api.HandleNotFound(resp, nil, err)
+	case apierrors.IsBadRequest(err):
+		klog.V(4).Infoln(err)
+		api.HandleBadRequest(resp, nil, err)
+	default:
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+	}
+}
+
 func (h *openpitrixHandler) ModifyApplication(req *restful.Request, resp *restful.Response) {
 	var modifyClusterAttributesRequest openpitrix.ModifyClusterAttributesRequest
 	applicationId := req.PathParameter("application")
@@ -826,8 +952,7 @@ func (h *openpitrixHandler) CreateApplication(req *restful.Request, resp *restfu
 	err = h.openpitrix.CreateApplication(workspace, clusterName, namespace, createClusterRequest)
 
 	if err != nil {
-		klog.Errorln(err)
-		api.HandleInternalError(resp, nil, err)
+		handleReleaseError(resp, err)
 		return
 	}
 
@@ -564,6 +564,82 @@ func AddToContainer(c *restful.Container, ksInfomrers informers.InformerFactory,
 		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
 		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
 
+	webservice.Route(webservice.GET("/workspaces/{workspace}/clusters/{cluster}/namespaces/{namespace}/applications/{application}/revisions").
+		To(handler.ListRevisions).
+		Doc("List the helm revisions of the application, the latest first").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Returns(http.StatusOK, api.StatusOK, []openpitrix.ReleaseRevision{}).
+		Param(webservice.PathParameter("cluster", "the name of the cluster.").Required(true)).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
+
+	webservice.Route(webservice.GET("/workspaces/{workspace}/clusters/{cluster}/namespaces/{namespace}/applications/{application}/revisions/diff").
+		To(handler.DiffRevisions).
+		Doc("Compare the values and the manifests of the two helm revisions of the application").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Returns(http.StatusOK, api.StatusOK, openpitrix.ReleaseRevisionDiff{}).
+		Param(webservice.PathParameter("cluster", "the name of the cluster.").Required(true)).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)).
+		Param(webservice.QueryParameter("from", "the revision compared from").Required(true)).
+		Param(webservice.QueryParameter("to", "the revision compared to, the latest revision by default").Required(false)))
+
+	webservice.Route(webservice.POST("/workspaces/{workspace}/clusters/{cluster}/namespaces/{namespace}/applications/{application}/dry_run").
+		To(handler.DryRunApplication).
+		Doc("Render the manifests of the application upgraded with the version and the values, and diff them against the live release").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Reads(openpitrix.UpgradeClusterRequest{}).
+		Returns(http.StatusOK, api.StatusOK, openpitrix.ReleaseDryRunResult{}).
+		Param(webservice.PathParameter("cluster", "the name of the cluster.").Required(true)).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
+
+	webservice.Route(webservice.POST("/workspaces/{workspace}/clusters/{cluster}/namespaces/{namespace}/applications/{application}/rollback").
+		To(handler.RollbackApplication).
+		Doc("Roll back the application to the helm revision").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Reads(openpitrix.RollbackApplicationRequest{}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}).
+		Param(webservice.PathParameter("cluster", "the name of the cluster.").Required(true)).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
+
+	webservice.Route(webservice.GET("/workspaces/{workspace}/namespaces/{namespace}/applications/{application}/revisions").
+		To(handler.ListRevisions).
+		Doc("List the helm revisions of the application, the latest first").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Returns(http.StatusOK, api.StatusOK, []openpitrix.ReleaseRevision{}).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
+
+	webservice.Route(webservice.GET("/workspaces/{workspace}/namespaces/{namespace}/applications/{application}/revisions/diff").
+		To(handler.DiffRevisions).
+		Doc("Compare the values and the manifests of the two helm revisions of the application").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Returns(http.StatusOK, api.StatusOK, openpitrix.ReleaseRevisionDiff{}).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)).
+		Param(webservice.QueryParameter("from", "the revision compared from").Required(true)).
+		Param(webservice.QueryParameter("to", "the revision compared to, the latest revision by default").Required(false)))
+
+	webservice.Route(webservice.POST("/workspaces/{workspace}/namespaces/{namespace}/applications/{application}/dry_run").
+		To(handler.DryRunApplication).
+		Doc("Render the manifests of the application upgraded with the version and the values, and diff them against the live release").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Reads(openpitrix.UpgradeClusterRequest{}).
+		Returns(http.StatusOK, api.StatusOK, openpitrix.ReleaseDryRunResult{}).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
+
+	webservice.Route(webservice.POST("/workspaces/{workspace}/namespaces/{namespace}/applications/{application}/rollback").
+		To(handler.RollbackApplication).
+		Doc("Roll back the application to the helm revision").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Reads(openpitrix.RollbackApplicationRequest{}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}).
+		Param(webservice.PathParameter("namespace", "the name of the project").Required(true)).
+		Param(webservice.PathParameter("application", "the id of the application").Required(true)))
+
 	webservice.Route(webservice.POST("/workspaces/{workspace}/clusters/{cluster}/namespaces/{namespace}/applications").
 		To(handler.CreateApplication).
 		Doc("Deploy a new application").
@@ -104,6 +104,7 @@ func (h *tenantHandler) HandlePriceInfoQuery(req *restful.Request, resp *restful
 	priceResponse.IngressNetworkTrafficPerMegabytesPerHour = priceInfo.IngressNetworkTrafficPerMegabytesPerHour
 	priceResponse.EgressNetworkTrafficPerMegabytesPerHour = priceInfo.EgressNetworkTrafficPerMegabytesPerHour
 	priceResponse.PvcPerGigabytesPerHour = priceInfo.PvcPerGigabytesPerHour
+	priceResponse.GpuPerGpuPerHour = priceInfo.GpuPerGpuPerHour
 
 	resp.WriteAsJson(priceResponse)
 }
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// The same limit as Prometheus, to avoid returning too many points in a single series.
+const maxPointsPerSeries = 11000
+
+// The error types of the Prometheus HTTP API.
+const (
+	errorBadData   = "bad_data"
+	errorExecution = "execution"
+	errorForbidden = "forbidden"
+	errorInternal  = "internal"
+)
+
+// prometheusResponse is the envelope of the Prometheus HTTP API,
+// so that the endpoints can be used as a Prometheus datasource, e.g. by Grafana.
+type prometheusResponse struct {
+	Status    string          `json:"status"`
+	Data      *prometheusData `json:"data,omitempty"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+type prometheusData struct {
+	ResultType string             `json:"resultType"`
+	Result     []prometheusSeries `json:"result"`
+}
+
+type prometheusSeries struct {
+	Metric map[string]string        `json:"metric"`
+	Value  *monitoringclient.Point  `json:"value,omitempty"`
+	Values []monitoringclient.Point `json:"values,omitempty"`
+}
+
+func (h *tenantHandler) QueryMetric(req *restful.Request, resp *restful.Response) {
+	u, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		writePrometheusError(resp, http.StatusForbidden, errorForbidden, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+
+	ts := time.Now()
+	if t := req.Request.FormValue("time"); t != "" {
+		var err error
+		if ts, err = parseTime(t); err != nil {
+			writePrometheusError(resp, http.StatusBadRequest, errorBadData, fmt.Errorf("invalid parameter \"time\", %s", err))
+			return
+		}
+	}
+
+	metric, err := h.tenant.QueryMetric(u, req.PathParameter("workspace"), req.Request.FormValue("query"), ts)
+	writePrometheusResult(resp, metric, err)
+}
+
+func (h *tenantHandler) QueryMetricRange(req *restful.Request, resp *restful.Response) {
+	u, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		writePrometheusError(resp, http.StatusForbidden, errorForbidden, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+
+	start, end, step, err := parseRange(req.Request.FormValue("start"), req.Request.FormValue("end"), req.Request.FormValue("step"))
+	if err != nil {
+		writePrometheusError(resp, http.StatusBadRequest, errorBadData, err)
+		return
+	}
+
+	metric, err := h.tenant.QueryMetricOverTime(u, req.PathParameter("workspace"), req.Request.FormValue("query"), start, end, step)
+	writePrometheusResult(resp, metric, err)
+}
+
+func writePrometheusResult(resp *restful.Response, metric monitoringclient.Metric, err error) {
+	switch {
+	case errors.IsBadRequest(err):
+		writePrometheusError(resp, http.StatusBadRequest, errorBadData, err)
+		return
+	case errors.IsForbidden(err):
+		writePrometheusError(resp, http.StatusForbidden, errorForbidden, err)
+		return
+	case err != nil:
+		klog.Errorln(err)
+		writePrometheusError(resp, http.StatusInternalServerError, errorInternal, err)
+		return
+	case metric.Error != "":
+		writePrometheusError(resp, http.StatusUnprocessableEntity, errorExecution, fmt.Errorf("%s", metric.Error))
+		return
+	}
+
+	data := &prometheusData{ResultType: metric.MetricType, Result: make([]prometheusSeries, 0, len(metric.MetricValues))}
+	for _, mv := range metric.MetricValues {
+		series := prometheusSeries{Metric: mv.Metadata, Value: mv.Sample, Values: mv.Series}
+		if series.Metric == nil {
+			series.Metric = map[string]string{}
+		}
+		data.Result = append(data.Result, series)
+	}
+
+	_ = resp.WriteAsJson(prometheusResponse{Status: "success", Data: data})
+}
+
+func writePrometheusError(resp *restful.Response, status int, errorType string, err error) {
+	_ = resp.WriteHeaderAndJson(status, prometheusResponse{
+		Status:    "error",
+		ErrorType: errorType,
+		Error:     err.Error(),
+	}, restful.MIME_JSON)
+}
+
+func parseRange(startParam, endParam, stepParam string) (start, end time.Time, step time.Duration, err error) {
+	if start, err = parseTime(startParam); err != nil {
+		return start, end, step, fmt.Errorf("invalid parameter \"start\", %s", err)
+	}
+	if end, err = parseTime(endParam); err != nil {
+		return start, end, step, fmt.Errorf("invalid parameter \"end\", %s", err)
+	}
+	if end.Before(start) {
+		return start, end, step, fmt.Errorf("invalid parameter \"end\", end timestamp must not be before start time")
+	}
+	if step, err = parseDuration(stepParam); err != nil {
+		return start, end, step, fmt.Errorf("invalid parameter \"step\", %s", err)
+	}
+	if step <= 0 {
+		return start, end, step, fmt.Errorf("zero or negative query resolution step widths are not accepted, try a positive integer")
+	}
+	if end.Sub(start)/step > maxPointsPerSeries {
+		return start, end, step, fmt.Errorf("exceeded maximum resolution of %d points per timeseries, try decreasing the query resolution (?step=XX)", maxPointsPerSeries)
+	}
+	return start, end, step, nil
+}
+
+// parseTime accepts a unix timestamp with optional decimal places or a RFC3339 time, the same as Prometheus.
+func parseTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, ns := math.Modf(t)
+		return time.Unix(int64(sec), int64(math.Round(ns*1000))*int64(time.Millisecond)).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// parseDuration accepts a number of seconds with optional decimal places or a Prometheus duration, e.g. 5m.
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		ts := d * float64(time.Second)
+		if ts > float64(math.MaxInt64) || ts < float64(math.MinInt64) {
+			return 0, fmt.Errorf("cannot parse %q to a valid duration, it overflows int64", s)
+		}
+		return time.Duration(ts), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		start, end, step string
+		expectedStart    time.Time
+		expectedEnd      time.Time
+		expectedStep     time.Duration
+		expectedErr      bool
+	}{
+		{
+			start:         "1672531200",
+			end:           "1672534800.5",
+			step:          "30",
+			expectedStart: time.Unix(1672531200, 0).UTC(),
+			expectedEnd:   time.Unix(1672534800, int64(500*time.Millisecond)).UTC(),
+			expectedStep:  30 * time.Second,
+		},
+		{
+			start:         "2023-01-01T00:00:00Z",
+			end:           "2023-01-01T01:00:00Z",
+			step:          "1m",
+			expectedStart: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC),
+			expectedStep:  time.Minute,
+		},
+		{
+			start:       "1672534800",
+			end:         "1672531200",
+			step:        "30",
+			expectedErr: true,
+		},
+		{
+			start:       "1672531200",
+			end:         "1672534800",
+			step:        "0",
+			expectedErr: true,
+		},
+		{
+			start:       "1672531200",
+			end:         "1672534800",
+			step:        "0.1",
+			expectedErr: true,
+		},
+		{
+			start:       "yesterday",
+			end:         "1672534800",
+			step:        "30",
+			expectedErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		start, end, step, err := parseRange(tt.start, tt.end, tt.step)
+		if err != nil {
+			if !tt.expectedErr {
+				t.Errorf("case %d, %v", i, err)
+			}
+			continue
+		}
+		if tt.expectedErr {
+			t.Errorf("case %d, expected an error", i)
+			continue
+		}
+
+		if !start.Equal(tt.expectedStart) || !end.Equal(tt.expectedEnd) || step != tt.expectedStep {
+			t.Errorf("case %d, got %v %v %v, expected %v %v %v", i, start, end, step, tt.expectedStart, tt.expectedEnd, tt.expectedStep)
+		}
+	}
+}
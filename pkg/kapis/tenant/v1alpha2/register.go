@@ -55,6 +55,8 @@ import (
 
 const (
 	GroupName = "tenant.kubesphere.io"
+
+	contentTypeFormData = "application/x-www-form-urlencoded"
 )
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
@@ -344,6 +346,43 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 		Doc("Get resoure price.").
 		Writes(metering.PriceInfo{}).
 		Returns(http.StatusOK, api.StatusOK, metering.PriceInfo{}))
+
+	// Grafana compatible Prometheus HTTP API, every selector of the query is restricted to
+	// the namespaces the user can see, in the workspace or in all workspaces.
+	for _, prefix := range []string{"/workspaces/{workspace}/prometheus", "/prometheus"} {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			route := ws.Method(method).Path(prefix+"/api/v1/query").
+				To(handler.QueryMetric).
+				Doc("Evaluate an instant PromQL query against the namespaces the user can see. Compatible with the Prometheus HTTP API.").
+				Param(ws.QueryParameter("query", "PromQL expression.").DataType("string").Required(true)).
+				Param(ws.QueryParameter("time", "Evaluation timestamp in Unix time or RFC3339 format. Defaults to now.").DataType("string").Required(false)).
+				Consumes(restful.MIME_JSON, contentTypeFormData).
+				Metadata(restfulspec.KeyOpenAPITags, []string{constants.CustomMetricsTag}).
+				Writes(prometheusResponse{}).
+				Returns(http.StatusOK, api.StatusOK, prometheusResponse{})
+			if prefix != "/prometheus" {
+				route.Param(ws.PathParameter("workspace", "Workspace name."))
+			}
+			ws.Route(route)
+
+			route = ws.Method(method).Path(prefix+"/api/v1/query_range").
+				To(handler.QueryMetricRange).
+				Doc("Evaluate a PromQL query over a range of time against the namespaces the user can see. Compatible with the Prometheus HTTP API.").
+				Param(ws.QueryParameter("query", "PromQL expression.").DataType("string").Required(true)).
+				Param(ws.QueryParameter("start", "Start timestamp in Unix time or RFC3339 format.").DataType("string").Required(true)).
+				Param(ws.QueryParameter("end", "End timestamp in Unix time or RFC3339 format.").DataType("string").Required(true)).
+				Param(ws.QueryParameter("step", "Query resolution step width in duration format or float number of seconds, eg. 30s.").DataType("string").Required(true)).
+				Consumes(restful.MIME_JSON, contentTypeFormData).
+				Metadata(restfulspec.KeyOpenAPITags, []string{constants.CustomMetricsTag}).
+				Writes(prometheusResponse{}).
+				Returns(http.StatusOK, api.StatusOK, prometheusResponse{})
+			if prefix != "/prometheus" {
+				route.Param(ws.PathParameter("workspace", "Workspace name."))
+			}
+			ws.Route(route)
+		}
+	}
+
 	ws.Route(ws.POST("/workspaces/{workspace}/resourcequotas").
 		To(handler.CreateWorkspaceResourceQuota).
 		Reads(quotav1alpha2.ResourceQuota{}).
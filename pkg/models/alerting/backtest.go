@@ -0,0 +1,299 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	promlabels "github.com/prometheus/prometheus/model/labels"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	kapialertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
+	controller "kubesphere.io/kubesphere/pkg/controller/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	// the default evaluation interval of the rule groups
+	defaultBacktestStep = time.Minute
+	// the max resolution of the range queries of Prometheus
+	maxBacktestPoints = 11000
+)
+
+// Backtester evaluates the alerting rules over a past time range and reports the alerts they would
+// have fired, which helps to tune the rules before they are created.
+// The rules are scoped and validated the same way as they are when created, and the disabled rules are skipped.
+type Backtester interface {
+	BacktestRuleGroup(namespace string, group *alertingv2beta1.RuleGroup, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error)
+	BacktestClusterRuleGroup(group *alertingv2beta1.ClusterRuleGroup, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error)
+	BacktestGlobalRuleGroup(group *alertingv2beta1.GlobalRuleGroup, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error)
+	// BacktestCustomAlertingRule backtests a custom alerting rule, the rule is of cluster level if the namespace is empty.
+	BacktestCustomAlertingRule(namespace string, rule *v2alpha1.PostableAlertingRule, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error)
+}
+
+func NewBacktester(monitoringClient monitoring.Interface) Backtester {
+	return &backtester{client: monitoringClient}
+}
+
+type backtester struct {
+	client monitoring.Interface
+}
+
+type backtestRule struct {
+	alertingv2beta1.Rule
+	enforceExpr controller.EnforceExprFunc
+}
+
+func (b *backtester) BacktestRuleGroup(namespace string, group *alertingv2beta1.RuleGroup, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error) {
+	defaulted := group.DeepCopy()
+	defaulted.Namespace = namespace
+	defaulted.Default()
+	if err := defaulted.Validate(); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+
+	// limit the rules to the namespace, the same as the rule group controller does
+	enforceExpr := controller.CreateEnforceExprFunc([]*promlabels.Matcher{{
+		Type:  promlabels.MatchEqual,
+		Name:  controller.RuleLabelKeyNamespace,
+		Value: namespace,
+	}})
+	rules := make([]backtestRule, len(defaulted.Spec.Rules))
+	for i, rule := range defaulted.Spec.Rules {
+		rules[i] = backtestRule{Rule: rule.Rule, enforceExpr: enforceExpr}
+		// the rule ids generated are not reported
+		rules[i].Labels = group.Spec.Rules[i].Labels
+	}
+	return b.backtest(defaulted.Spec.Interval, rules, opts)
+}
+
+func (b *backtester) BacktestClusterRuleGroup(group *alertingv2beta1.ClusterRuleGroup, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error) {
+	defaulted := group.DeepCopy()
+	defaulted.Default()
+	if err := defaulted.Validate(); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+
+	enforceExpr := controller.CreateEnforceExprFunc(nil)
+	rules := make([]backtestRule, len(defaulted.Spec.Rules))
+	for i, rule := range defaulted.Spec.Rules {
+		rules[i] = backtestRule{Rule: rule.Rule, enforceExpr: enforceExpr}
+		rules[i].Labels = group.Spec.Rules[i].Labels
+	}
+	return b.backtest(defaulted.Spec.Interval, rules, opts)
+}
+
+func (b *backtester) BacktestGlobalRuleGroup(group *alertingv2beta1.GlobalRuleGroup, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error) {
+	defaulted := group.DeepCopy()
+	// the cluster and namespace selectors of the rules built by the exprBuilder are set by the defaulting
+	defaulted.Default()
+	if err := defaulted.Validate(); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+
+	rules := make([]backtestRule, len(defaulted.Spec.Rules))
+	for i := range defaulted.Spec.Rules {
+		rule := &defaulted.Spec.Rules[i]
+		rules[i] = backtestRule{
+			Rule:        rule.Rule,
+			enforceExpr: controller.CreateEnforceExprFunc(controller.ParseGlobalRuleEnforceMatchers(rule)),
+		}
+		rules[i].Labels = group.Spec.Rules[i].Labels
+	}
+	return b.backtest(defaulted.Spec.Interval, rules, opts)
+}
+
+func (b *backtester) BacktestCustomAlertingRule(namespace string, rule *v2alpha1.PostableAlertingRule, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+
+	var matchers []*promlabels.Matcher
+	if namespace != "" {
+		matchers = append(matchers, &promlabels.Matcher{
+			Type:  promlabels.MatchEqual,
+			Name:  controller.RuleLabelKeyNamespace,
+			Value: namespace,
+		})
+	}
+	return b.backtest("", []backtestRule{{
+		Rule: alertingv2beta1.Rule{
+			Alert:       rule.Name,
+			Expr:        intstr.FromString(rule.Query),
+			For:         alertingv2beta1.Duration(rule.Duration),
+			Labels:      rule.Labels,
+			Annotations: rule.Annotations,
+		},
+		enforceExpr: controller.CreateEnforceExprFunc(matchers),
+	}}, opts)
+}
+
+func (b *backtester) backtest(interval string, rules []backtestRule, opts kapialertingv2beta1.BacktestOptions) (*kapialertingv2beta1.BacktestResult, error) {
+	step := opts.Step
+	if step <= 0 {
+		step = defaultBacktestStep
+		if interval != "" {
+			d, err := prommodel.ParseDuration(interval)
+			if err != nil {
+				return nil, errors.NewBadRequest(fmt.Sprintf("invalid interval %s: %s", interval, err))
+			}
+			step = time.Duration(d)
+		}
+	}
+	if !opts.End.After(opts.Start) {
+		return nil, errors.NewBadRequest("the end time must be after the start time")
+	}
+	if opts.End.Sub(opts.Start)/step > maxBacktestPoints {
+		return nil, errors.NewBadRequest(fmt.Sprintf("exceeded the maximum of %d evaluations, shorten the time range or increase the step", maxBacktestPoints))
+	}
+
+	result := &kapialertingv2beta1.BacktestResult{
+		Start: opts.Start,
+		End:   opts.End,
+		Step:  prommodel.Duration(step).String(),
+		Rules: []kapialertingv2beta1.BacktestRuleResult{},
+	}
+	for _, rule := range rules {
+		if rule.Disable {
+			continue
+		}
+		result.Rules = append(result.Rules, b.backtestRule(&rule, step, opts))
+	}
+	return result, nil
+}
+
+func (b *backtester) backtestRule(rule *backtestRule, step time.Duration, opts kapialertingv2beta1.BacktestOptions) kapialertingv2beta1.BacktestRuleResult {
+	result := kapialertingv2beta1.BacktestRuleResult{
+		Alert:  rule.Alert,
+		Alerts: []*kapialertingv2beta1.BacktestAlert{},
+	}
+
+	expr, err := rule.enforceExpr(rule.Expr.String())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Expr = expr
+
+	var holdDuration time.Duration
+	if rule.For != "" {
+		// validated already
+		d, _ := prommodel.ParseDuration(string(rule.For))
+		holdDuration = time.Duration(d)
+	}
+
+	// the evaluation starts the hold duration earlier to catch the alerts pending at the start time
+	start := opts.Start.Add(-holdDuration)
+	if opts.End.Sub(start)/step > maxBacktestPoints {
+		start = opts.End.Add(-step * maxBacktestPoints)
+	}
+	metric := b.client.GetMetricOverTime(expr, start, opts.End, step)
+	if metric.Error != "" {
+		result.Error = metric.Error
+		return result
+	}
+
+	for _, series := range metric.MetricValues {
+		labels := backtestAlertLabels(series.Metadata, &rule.Rule)
+		for _, alert := range replayAlerts(series.Series, step, holdDuration, opts.End) {
+			if alert.EndsAt != nil && !alert.EndsAt.After(opts.Start) {
+				continue
+			}
+			alert.Labels = labels
+			result.Alerts = append(result.Alerts, alert)
+		}
+	}
+	sort.SliceStable(result.Alerts, func(i, j int) bool {
+		return result.Alerts[i].StartsAt.Before(result.Alerts[j].StartsAt)
+	})
+	return result
+}
+
+// replayAlerts replays the evaluations of a series the way Prometheus does: an alert becomes active at the
+// first evaluation the series is present at, fires once it has been active for the hold duration and is resolved
+// at the first evaluation the series is absent at.
+func replayAlerts(points []monitoring.Point, step, holdDuration time.Duration, end time.Time) []*kapialertingv2beta1.BacktestAlert {
+	var (
+		alerts   []*kapialertingv2beta1.BacktestAlert
+		firing   *kapialertingv2beta1.BacktestAlert
+		activeAt time.Time
+		last     time.Time
+	)
+	resolve := func(at time.Time) {
+		if firing != nil {
+			firing.EndsAt = &at
+			alerts = append(alerts, firing)
+			firing = nil
+		}
+	}
+
+	for i, point := range points {
+		t := time.UnixMilli(int64(math.Round(point.Timestamp() * 1000))).UTC()
+		// the series is absent at some evaluations in between
+		if i == 0 || t.Sub(last) > step+step/2 {
+			if i > 0 {
+				resolve(last.Add(step))
+			}
+			activeAt = t
+		}
+		last = t
+
+		if firing == nil && t.Sub(activeAt) >= holdDuration {
+			firing = &kapialertingv2beta1.BacktestAlert{
+				ActiveAt: activeAt,
+				StartsAt: t,
+				Value:    strconv.FormatFloat(point.Value(), 'f', -1, 64),
+			}
+		}
+	}
+	if firing != nil {
+		if last.Add(step).After(end) {
+			// still firing at the last evaluation
+			alerts = append(alerts, firing)
+		} else {
+			resolve(last.Add(step))
+		}
+	}
+	return alerts
+}
+
+func backtestAlertLabels(metadata map[string]string, rule *alertingv2beta1.Rule) map[string]string {
+	labels := make(map[string]string, len(metadata)+len(rule.Labels)+2)
+	for name, value := range metadata {
+		if name == prommodel.MetricNameLabel {
+			continue
+		}
+		labels[name] = value
+	}
+	for name, value := range rule.Labels {
+		labels[name] = value
+	}
+	if rule.Severity != "" {
+		labels[controller.RuleLabelKeySeverity] = string(rule.Severity)
+	}
+	labels[prommodel.AlertNameLabel] = rule.Alert
+	return labels
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	kapialertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type fakeMonitoringClient struct {
+	monitoring.Interface
+	series []monitoring.MetricValue
+
+	expr       string
+	start, end time.Time
+	step       time.Duration
+}
+
+func (f *fakeMonitoringClient) GetMetricOverTime(expr string, start, end time.Time, step time.Duration) monitoring.Metric {
+	f.expr, f.start, f.end, f.step = expr, start, end, step
+	return monitoring.Metric{MetricData: monitoring.MetricData{MetricType: "matrix", MetricValues: f.series}}
+}
+
+// points makes the points of the evaluations at the given minutes since the start
+func points(start time.Time, minutes ...int) []monitoring.Point {
+	var ps []monitoring.Point
+	for _, m := range minutes {
+		ps = append(ps, monitoring.Point{float64(start.Add(time.Duration(m) * time.Minute).Unix()), float64(m)})
+	}
+	return ps
+}
+
+func TestReplayAlerts(t *testing.T) {
+	start := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	at := func(m int) time.Time { return start.Add(time.Duration(m) * time.Minute) }
+	atp := func(m int) *time.Time { t := at(m); return &t }
+
+	tests := []struct {
+		name         string
+		minutes      []int
+		holdDuration time.Duration
+		expected     []fakeAlert
+	}{{
+		name:     "fires at once without a hold duration",
+		minutes:  []int{1, 2, 3},
+		expected: []fakeAlert{{at(1), at(1), atp(4)}},
+	}, {
+		name:         "pending for the hold duration",
+		minutes:      []int{1, 2, 3, 4, 6, 7, 8},
+		holdDuration: 2 * time.Minute,
+		expected:     []fakeAlert{{at(1), at(3), atp(5)}, {at(6), at(8), atp(9)}},
+	}, {
+		name:         "still firing at the end",
+		minutes:      []int{7, 8, 9, 10},
+		holdDuration: 2 * time.Minute,
+		expected:     []fakeAlert{{at(7), at(9), nil}},
+	}, {
+		name:    "fires again after resolved",
+		minutes: []int{0, 5},
+		expected: []fakeAlert{
+			{at(0), at(0), atp(1)},
+			{at(5), at(5), atp(6)},
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []fakeAlert
+			for _, alert := range replayAlerts(points(start, test.minutes...), time.Minute, test.holdDuration, end) {
+				got = append(got, fakeAlert{alert.ActiveAt, alert.StartsAt, alert.EndsAt})
+			}
+			if diff := cmp.Diff(got, test.expected); diff != "" {
+				t.Errorf("alerts differ (-got, +want): %s", diff)
+			}
+		})
+	}
+}
+
+type fakeAlert struct {
+	ActiveAt time.Time
+	StartsAt time.Time
+	EndsAt   *time.Time
+}
+
+func TestBacktestRuleGroup(t *testing.T) {
+	start := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	usage := 0.5
+	client := &fakeMonitoringClient{series: []monitoring.MetricValue{{
+		Metadata: map[string]string{"__name__": "namespace:workload_cpu_usage:sum", "namespace": "test", "workload": "deployment:web"},
+		// pending since 5 minutes before the start
+		Series: points(start, -5, -4, -3, -2, -1, 0, 1, 2, 3, 4, 5, 6, 7, 8),
+	}}}
+	group := &alertingv2beta1.RuleGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: alertingv2beta1.RuleGroupSpec{
+			Rules: []alertingv2beta1.NamespaceRule{{
+				Rule: alertingv2beta1.Rule{Alert: "HighCPU", For: "10m", Severity: alertingv2beta1.SeverityWarning},
+				ExprBuilder: &alertingv2beta1.NamespaceRuleExprBuilder{
+					Workload: &alertingv2beta1.WorkloadExprBuilder{
+						WorkloadKind:    alertingv2beta1.WorkloadDeployment,
+						WorkloadNames:   []string{"web"},
+						Comparator:      alertingv2beta1.ComparatorGT,
+						MetricThreshold: alertingv2beta1.WorkloadMetricThreshold{Cpu: &alertingv2beta1.WorkloadCpuThreshold{Usage: &usage}},
+					},
+				},
+			}, {
+				Rule: alertingv2beta1.Rule{Alert: "Disabled", Expr: intstr.FromString("up == 0"), Disable: true},
+			}},
+		},
+	}
+
+	b := NewBacktester(client)
+	result, err := b.BacktestRuleGroup("test", group, kapialertingv2beta1.BacktestOptions{Start: start, End: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Step != "1m" || len(result.Rules) != 1 {
+		t.Fatalf("unexpected result %v", result)
+	}
+	rule := result.Rules[0]
+	if rule.Expr != `namespace:workload_cpu_usage:sum{namespace="test",workload="deployment:web"} > 0.5` {
+		t.Errorf("unexpected expr %s", rule.Expr)
+	}
+	if !client.start.Equal(start.Add(-10*time.Minute)) || client.step != time.Minute {
+		t.Errorf("unexpected range [%s, %s] by %s", client.start, client.end, client.step)
+	}
+	if len(rule.Alerts) != 1 {
+		t.Fatalf("unexpected alerts %v", rule.Alerts)
+	}
+	alert := rule.Alerts[0]
+	if !alert.ActiveAt.Equal(start.Add(-5*time.Minute)) || !alert.StartsAt.Equal(start.Add(5*time.Minute)) ||
+		alert.EndsAt == nil || !alert.EndsAt.Equal(start.Add(9*time.Minute)) || alert.Value != "5" {
+		t.Errorf("unexpected alert %v", alert)
+	}
+	expectedLabels := map[string]string{"alertname": "HighCPU", "severity": "warning", "namespace": "test", "workload": "deployment:web"}
+	if diff := cmp.Diff(alert.Labels, expectedLabels); diff != "" {
+		t.Errorf("labels differ (-got, +want): %s", diff)
+	}
+
+	// the rules are validated before evaluated
+	group.Spec.Rules[0].For = "ten minutes"
+	if _, err := b.BacktestRuleGroup("test", group, kapialertingv2beta1.BacktestOptions{Start: start, End: start.Add(time.Hour)}); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestBacktestCustomAlertingRule(t *testing.T) {
+	start := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeMonitoringClient{}
+	b := NewBacktester(client)
+
+	rule := &v2alpha1.PostableAlertingRule{AlertingRule: v2alpha1.AlertingRule{Name: "test", Query: "up == 0"}}
+	result, err := b.BacktestCustomAlertingRule("", rule, kapialertingv2beta1.BacktestOptions{Start: start, End: start.Add(time.Hour), Step: 5 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.expr != "up == 0" || result.Step != "5m" || len(result.Rules[0].Alerts) != 0 {
+		t.Errorf("unexpected result %v of %s", result, client.expr)
+	}
+
+	if _, err := b.BacktestCustomAlertingRule("test", rule, kapialertingv2beta1.BacktestOptions{Start: start, End: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if client.expr != `up{namespace="test"} == 0` {
+		t.Errorf("unexpected expr %s", client.expr)
+	}
+
+	if _, err := b.BacktestCustomAlertingRule("", rule, kapialertingv2beta1.BacktestOptions{Start: start, End: start.Add(30 * 24 * time.Hour)}); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	kapialertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
+	controller "kubesphere.io/kubesphere/pkg/controller/alerting"
+)
+
+// AlertHistoryOperator queries the timelines of the alerts recorded, including the alerts resolved.
+type AlertHistoryOperator interface {
+	NamespaceAlertTimeline(ctx context.Context, namespace string, opts kapialertingv2beta1.AlertHistoryOptions) (*kapialertingv2beta1.AlertTimeline, error)
+	ClusterAlertTimeline(ctx context.Context, opts kapialertingv2beta1.AlertHistoryOptions) (*kapialertingv2beta1.AlertTimeline, error)
+	GlobalAlertTimeline(ctx context.Context, opts kapialertingv2beta1.AlertHistoryOptions) (*kapialertingv2beta1.AlertTimeline, error)
+}
+
+func NewAlertHistoryOperator(client client.Client) AlertHistoryOperator {
+	return &alertHistoryOperator{client: client, now: time.Now}
+}
+
+type alertHistoryOperator struct {
+	client client.Client
+	now    func() time.Time
+}
+
+func (o *alertHistoryOperator) NamespaceAlertTimeline(ctx context.Context, namespace string,
+	opts kapialertingv2beta1.AlertHistoryOptions) (*kapialertingv2beta1.AlertTimeline, error) {
+	return o.timeline(ctx, opts, client.MatchingLabels{
+		alertingv2beta1.AlertHistoryLabelRuleLevel:     string(controller.RuleLevelNamesapce),
+		alertingv2beta1.AlertHistoryLabelRuleNamespace: namespace,
+	})
+}
+
+func (o *alertHistoryOperator) ClusterAlertTimeline(ctx context.Context,
+	opts kapialertingv2beta1.AlertHistoryOptions) (*kapialertingv2beta1.AlertTimeline, error) {
+	return o.timeline(ctx, opts, client.MatchingLabels{
+		alertingv2beta1.AlertHistoryLabelRuleLevel: string(controller.RuleLevelCluster),
+	})
+}
+
+func (o *alertHistoryOperator) GlobalAlertTimeline(ctx context.Context,
+	opts kapialertingv2beta1.AlertHistoryOptions) (*kapialertingv2beta1.AlertTimeline, error) {
+	return o.timeline(ctx, opts, client.MatchingLabels{
+		alertingv2beta1.AlertHistoryLabelRuleLevel: string(controller.RuleLevelGlobal),
+	})
+}
+
+func (o *alertHistoryOperator) timeline(ctx context.Context, opts kapialertingv2beta1.AlertHistoryOptions,
+	selector client.MatchingLabels) (*kapialertingv2beta1.AlertTimeline, error) {
+	histories := &alertingv2beta1.AlertHistoryList{}
+	if err := o.client.List(ctx, histories, selector); err != nil {
+		return nil, err
+	}
+
+	now := o.now()
+	timeline := &kapialertingv2beta1.AlertTimeline{
+		Start:   opts.Start,
+		End:     opts.End,
+		Rules:   []*kapialertingv2beta1.RuleAlertStatistics{},
+		Records: []*kapialertingv2beta1.AlertTimelineRecord{},
+	}
+	for _, history := range histories.Items {
+		if (opts.RuleGroup != "" && history.Spec.RuleGroup != opts.RuleGroup) ||
+			(opts.Alert != "" && history.Spec.Alert != opts.Alert) {
+			continue
+		}
+		var records []*kapialertingv2beta1.AlertTimelineRecord
+		for _, record := range history.Status.Records {
+			// the alerts never fired and those out of the timeline
+			if record.StartsAt == nil || !record.StartsAt.Time.Before(opts.End) ||
+				(record.EndsAt != nil && !record.EndsAt.Time.After(opts.Start)) {
+				continue
+			}
+			r := &kapialertingv2beta1.AlertTimelineRecord{
+				RuleGroup: history.Spec.RuleGroup,
+				Alert:     history.Spec.Alert,
+				Labels:    record.Labels,
+				ActiveAt:  record.ActiveAt.Time,
+				StartsAt:  record.StartsAt.Time,
+				Value:     record.Value,
+			}
+			end := now
+			if record.EndsAt != nil {
+				r.EndsAt, end = &record.EndsAt.Time, record.EndsAt.Time
+			}
+			r.Duration = end.Sub(r.StartsAt).Seconds()
+			records = append(records, r)
+		}
+		if len(records) == 0 {
+			continue
+		}
+		stats := &kapialertingv2beta1.RuleAlertStatistics{
+			RuleGroup:       history.Spec.RuleGroup,
+			Alert:           history.Spec.Alert,
+			AlertStatistics: alertStatistics(records, opts.Start, opts.End, now),
+		}
+		timeline.Rules = append(timeline.Rules, stats)
+		timeline.Records = append(timeline.Records, records...)
+	}
+
+	timeline.AlertStatistics = alertStatistics(timeline.Records, opts.Start, opts.End, now)
+	sort.Slice(timeline.Rules, func(i, j int) bool {
+		if timeline.Rules[i].Count != timeline.Rules[j].Count {
+			return timeline.Rules[i].Count > timeline.Rules[j].Count
+		}
+		return timeline.Rules[i].FiringDuration > timeline.Rules[j].FiringDuration
+	})
+	sort.SliceStable(timeline.Records, func(i, j int) bool {
+		return timeline.Records[i].StartsAt.After(timeline.Records[j].StartsAt)
+	})
+	return timeline, nil
+}
+
+// alertStatistics counts the alerts started firing within the timeline and those still firing,
+// sums the time the alerts fired within the timeline and averages the time to resolve the alerts
+// resolved within the timeline.
+func alertStatistics(records []*kapialertingv2beta1.AlertTimelineRecord, start, end, now time.Time) kapialertingv2beta1.AlertStatistics {
+	var (
+		stats    kapialertingv2beta1.AlertStatistics
+		resolved int
+		ttr      float64
+	)
+	if end.After(now) {
+		end = now
+	}
+	for _, record := range records {
+		if !record.StartsAt.Before(start) {
+			stats.Count++
+		}
+		firingEnd := now
+		if record.EndsAt == nil {
+			stats.Firing++
+		} else {
+			firingEnd = *record.EndsAt
+			if !firingEnd.Before(start) && !firingEnd.After(end) {
+				resolved++
+				ttr += record.Duration
+			}
+		}
+
+		firingStart := record.StartsAt
+		if firingStart.Before(start) {
+			firingStart = start
+		}
+		if firingEnd.After(end) {
+			firingEnd = end
+		}
+		if firingEnd.After(firingStart) {
+			stats.FiringDuration += firingEnd.Sub(firingStart).Seconds()
+		}
+	}
+	if resolved > 0 {
+		stats.MTTR = ttr / float64(resolved)
+	}
+	return stats
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	kapialertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
+)
+
+func TestNamespaceAlertTimeline(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = alertingv2beta1.AddToScheme(sch)
+
+	t0 := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) *metav1.Time {
+		t := metav1.NewTime(t0.Add(time.Duration(minutes) * time.Minute))
+		return &t
+	}
+	newHistory := func(name, namespace, alert string, records ...alertingv2beta1.AlertRecord) *alertingv2beta1.AlertHistory {
+		return &alertingv2beta1.AlertHistory{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+				alertingv2beta1.AlertHistoryLabelRuleLevel:     "namespace",
+				alertingv2beta1.AlertHistoryLabelRuleNamespace: namespace,
+			}},
+			Spec:   alertingv2beta1.AlertHistorySpec{RuleLevel: "namespace", Namespace: namespace, RuleGroup: "pods", Alert: alert},
+			Status: alertingv2beta1.AlertHistoryStatus{Records: records},
+		}
+	}
+
+	o := &alertHistoryOperator{
+		client: fake.NewClientBuilder().WithScheme(sch).WithObjects(
+			newHistory("a", "test", "PodCrash",
+				// started before the timeline
+				alertingv2beta1.AlertRecord{ActiveAt: *at(0), StartsAt: at(5), EndsAt: at(20)},
+				alertingv2beta1.AlertRecord{ActiveAt: *at(30), StartsAt: at(30), EndsAt: at(50)},
+				// never fired
+				alertingv2beta1.AlertRecord{ActiveAt: *at(55)},
+			),
+			newHistory("b", "test", "PodPending",
+				// still firing
+				alertingv2beta1.AlertRecord{ActiveAt: *at(40), StartsAt: at(50)},
+			),
+			newHistory("c", "other", "PodCrash",
+				alertingv2beta1.AlertRecord{ActiveAt: *at(30), StartsAt: at(30)},
+			),
+		).Build(),
+		now: func() time.Time { return at(60).Time },
+	}
+
+	timeline, err := o.NamespaceAlertTimeline(context.Background(), "test", kapialertingv2beta1.AlertHistoryOptions{
+		Start: at(10).Time,
+		End:   at(120).Time,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeline.Records) != 3 || timeline.Records[0].Alert != "PodPending" || timeline.Records[0].Duration != 600 {
+		t.Fatalf("unexpected records %v", timeline.Records)
+	}
+	// 10m of the first alert within the timeline, 20m of the second one and 10m of the alert still firing
+	if diff := cmp.Diff(timeline.AlertStatistics, kapialertingv2beta1.AlertStatistics{
+		Count: 2, Firing: 1, FiringDuration: 2400, MTTR: 1050,
+	}); diff != "" {
+		t.Fatalf("statistics differ (-got, +want): %s", diff)
+	}
+	if len(timeline.Rules) != 2 || timeline.Rules[0].Alert != "PodCrash" || timeline.Rules[0].MTTR != 1050 {
+		t.Fatalf("unexpected rules %v", timeline.Rules)
+	}
+
+	timeline, err = o.NamespaceAlertTimeline(context.Background(), "test", kapialertingv2beta1.AlertHistoryOptions{
+		Start: at(10).Time,
+		End:   at(120).Time,
+		Alert: "PodPending",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeline.Records) != 1 || len(timeline.Rules) != 1 || timeline.Count != 1 {
+		t.Fatalf("unexpected timeline %v", timeline)
+	}
+}
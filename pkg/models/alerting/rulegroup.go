@@ -16,14 +16,17 @@ package alerting
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	promlabels "github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql/parser"
 	promrules "github.com/prometheus/prometheus/rules"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
 
@@ -51,9 +54,11 @@ type RuleGroupOperator interface {
 	ListClusterAlerts(ctx context.Context, queryParam *query.Query) (*api.ListResult, error)
 }
 
-func NewRuleGroupOperator(informers informers.InformerFactory, ruleClient alerting.RuleClient) RuleGroupOperator {
+func NewRuleGroupOperator(informers informers.InformerFactory, ruleClient alerting.RuleClient,
+	reader client.Reader) RuleGroupOperator {
 	return &ruleGroupOperator{
 		ruleClient:             ruleClient,
+		reader:                 reader,
 		ruleGroupLister:        informers.KubeSphereSharedInformerFactory().Alerting().V2beta1().RuleGroups().Lister(),
 		clusterRuleGroupLister: informers.KubeSphereSharedInformerFactory().Alerting().V2beta1().ClusterRuleGroups().Lister(),
 		globalRuleGroupLister:  informers.KubeSphereSharedInformerFactory().Alerting().V2beta1().GlobalRuleGroups().Lister(),
@@ -65,6 +70,8 @@ type ruleGroupOperator struct {
 	ruleGroupLister        alertinglisters.RuleGroupLister
 	clusterRuleGroupLister alertinglisters.ClusterRuleGroupLister
 	globalRuleGroupLister  alertinglisters.GlobalRuleGroupLister
+	// reader reads the log rule groups from the cache, nil if the log based rules are not served.
+	reader client.Reader
 }
 
 func (o *ruleGroupOperator) listRuleGroups(ctx context.Context, namespace string, selector labels.Selector) ([]runtime.Object, error) {
@@ -243,6 +250,11 @@ func (o *ruleGroupOperator) ListAlerts(ctx context.Context, namespace string,
 			}
 		}
 	}
+	logAlerts, err := o.listLogAlerts(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	alerts = append(alerts, logAlerts...)
 
 	filterAlert, err := o.createFilterAlertFunc(queryParam)
 	if err != nil {
@@ -259,6 +271,46 @@ func (o *ruleGroupOperator) ListAlerts(ctx context.Context, namespace string,
 	return listResult, nil
 }
 
+// listLogAlerts lists the alerts of the log based rules of the namespace, which are pending or firing.
+func (o *ruleGroupOperator) listLogAlerts(ctx context.Context, namespace string) ([]runtime.Object, error) {
+	if o.reader == nil {
+		return nil, nil
+	}
+	groups := &alertingv2beta1.LogRuleGroupList{}
+	if err := o.reader.List(ctx, groups, client.InNamespace(namespace)); err != nil {
+		// the log based rules are not installed
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var alerts []runtime.Object
+	for i := range groups.Items {
+		group := &groups.Items[i]
+		rules := make(map[string]*alertingv2beta1.LogRule, len(group.Spec.Rules))
+		for j := range group.Spec.Rules {
+			rules[group.Spec.Rules[j].Alert] = &group.Spec.Rules[j]
+		}
+		for _, status := range group.Status.RulesStatus {
+			rule, ok := rules[status.Alert]
+			if !ok || rule.Disable || status.ActiveAt == nil ||
+				(status.State != statePendingString && status.State != stateFiringString) {
+				continue
+			}
+			activeAt := status.ActiveAt.Time
+			alerts = append(alerts, &wrapAlert{Alert: kapialertingv2beta1.Alert{
+				ActiveAt:    &activeAt,
+				Annotations: rule.Annotations,
+				Labels:      controller.LogRuleAlertLabels(group, rule),
+				State:       status.State,
+				Value:       strconv.FormatInt(status.Count, 10),
+			}})
+		}
+	}
+	return alerts, nil
+}
+
 func (d *ruleGroupOperator) compareAlert(left, right *kapialertingv2beta1.Alert, field query.Field) bool {
 	switch field {
 	case kapialertingv2beta1.FieldAlertActiveAt:
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	controller "kubesphere.io/kubesphere/pkg/controller/alerting"
+)
+
+func TestListLogAlerts(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = alertingv2beta1.AddToScheme(sch)
+
+	activeAt := metav1.NewTime(time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC))
+	o := &ruleGroupOperator{
+		reader: fake.NewClientBuilder().WithScheme(sch).WithObjects(&alertingv2beta1.LogRuleGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "oom", Namespace: "test"},
+			Spec: alertingv2beta1.LogRuleGroupSpec{Rules: []alertingv2beta1.LogRule{
+				{Alert: "OutOfMemory", Severity: "critical"},
+				{Alert: "Timeout"},
+				{Alert: "Panic", Disable: true},
+			}},
+			Status: alertingv2beta1.LogRuleGroupStatus{RulesStatus: []alertingv2beta1.LogRuleStatus{
+				{Alert: "OutOfMemory", State: "firing", Count: 60, ActiveAt: &activeAt, FiredAt: &activeAt},
+				{Alert: "Timeout", State: "inactive", Count: 1},
+				{Alert: "Panic", State: "firing", ActiveAt: &activeAt, FiredAt: &activeAt},
+			}},
+		}).Build(),
+	}
+
+	alerts, err := o.listLogAlerts(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("unexpected alerts %v", alerts)
+	}
+	alert := alerts[0].(*wrapAlert).Alert
+	if alert.State != "firing" || alert.Value != "60" || alert.Labels["alertname"] != "OutOfMemory" ||
+		alert.Labels[controller.RuleLabelKeyAlertType] != controller.RuleLabelValueAlertTypeLog {
+		t.Fatalf("unexpected alert %v", alert)
+	}
+
+	if alerts, err = o.listLogAlerts(context.Background(), "other"); err != nil || len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %v, %v", alerts, err)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+	controller "kubesphere.io/kubesphere/pkg/controller/alerting"
+	resources "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
+)
+
+// WorkspaceSilenceOperator manages the silences of the workspaces, which are the cluster silences
+// limited to the namespaces of the workspaces, so that they can be managed with the permissions of the workspaces.
+type WorkspaceSilenceOperator interface {
+	ListSilences(ctx context.Context, workspace string, q *query.Query) (*api.ListResult, error)
+	GetSilence(ctx context.Context, workspace, name string) (*alertingv2beta1.ClusterSilence, error)
+	CreateSilence(ctx context.Context, workspace string, silence *alertingv2beta1.ClusterSilence) (*alertingv2beta1.ClusterSilence, error)
+	DeleteSilence(ctx context.Context, workspace, name string) error
+}
+
+func NewWorkspaceSilenceOperator(client client.Client) WorkspaceSilenceOperator {
+	return &workspaceSilenceOperator{client: client}
+}
+
+type workspaceSilenceOperator struct {
+	client client.Client
+}
+
+func (o *workspaceSilenceOperator) ListSilences(ctx context.Context, workspace string, q *query.Query) (*api.ListResult, error) {
+	silences := &alertingv2beta1.ClusterSilenceList{}
+	if err := o.client.List(ctx, silences, client.MatchingLabels{constants.WorkspaceLabelKey: workspace}); err != nil {
+		return nil, err
+	}
+	var objects []runtime.Object
+	for i := range silences.Items {
+		if silences.Items[i].Spec.Workspace == workspace {
+			objects = append(objects, &silences.Items[i])
+		}
+	}
+	return resources.DefaultList(objects, q, func(left, right runtime.Object, field query.Field) bool {
+		return resources.DefaultObjectMetaCompare(left.(*alertingv2beta1.ClusterSilence).ObjectMeta,
+			right.(*alertingv2beta1.ClusterSilence).ObjectMeta, field)
+	}, func(obj runtime.Object, filter query.Filter) bool {
+		return resources.DefaultObjectMetaFilter(obj.(*alertingv2beta1.ClusterSilence).ObjectMeta, filter)
+	}), nil
+}
+
+func (o *workspaceSilenceOperator) GetSilence(ctx context.Context, workspace, name string) (*alertingv2beta1.ClusterSilence, error) {
+	silence := &alertingv2beta1.ClusterSilence{}
+	if err := o.client.Get(ctx, client.ObjectKey{Name: name}, silence); err != nil {
+		return nil, err
+	}
+	// the silences of the other workspaces are invisible
+	if silence.Spec.Workspace != workspace {
+		return nil, errors.NewNotFound(alertingv2beta1.Resource("clustersilences"), name)
+	}
+	return silence, nil
+}
+
+func (o *workspaceSilenceOperator) CreateSilence(ctx context.Context, workspace string, silence *alertingv2beta1.ClusterSilence) (*alertingv2beta1.ClusterSilence, error) {
+	silence.Spec.Workspace = workspace
+	if silence.Labels == nil {
+		silence.Labels = map[string]string{}
+	}
+	silence.Labels[constants.WorkspaceLabelKey] = workspace
+
+	if err := silence.Validate(); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	// the namespaces matched must belong to the workspace
+	for _, m := range silence.Spec.Matchers {
+		if m.Name != controller.RuleLabelKeyNamespace || m.Type != alertingv2beta1.MatchEqual {
+			continue
+		}
+		namespace := &corev1.Namespace{}
+		if err := o.client.Get(ctx, client.ObjectKey{Name: m.Value}, namespace); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if namespace.Labels[constants.WorkspaceLabelKey] != workspace {
+			return nil, errors.NewForbidden(alertingv2beta1.Resource("clustersilences"), silence.Name,
+				fmt.Errorf("namespace %s does not belong to workspace %s", m.Value, workspace))
+		}
+	}
+
+	if err := o.client.Create(ctx, silence); err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+func (o *workspaceSilenceOperator) DeleteSilence(ctx context.Context, workspace, name string) error {
+	silence, err := o.GetSilence(ctx, workspace, name)
+	if err != nil {
+		return err
+	}
+	return o.client.Delete(ctx, silence, &client.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &silence.UID},
+	})
+}
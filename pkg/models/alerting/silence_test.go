@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+func TestWorkspaceSilenceOperator(t *testing.T) {
+	sch := runtime.NewScheme()
+	_ = alertingv2beta1.AddToScheme(sch)
+	_ = corev1.AddToScheme(sch)
+
+	client := fake.NewClientBuilder().WithScheme(sch).WithObjects(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{constants.WorkspaceLabelKey: "ws1"}},
+	}, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns2", Labels: map[string]string{constants.WorkspaceLabelKey: "ws2"}},
+	}).Build()
+	o := NewWorkspaceSilenceOperator(client)
+	ctx := context.Background()
+
+	newSilence := func(name, namespace string) *alertingv2beta1.ClusterSilence {
+		endsAt := metav1.NewTime(time.Now().Add(time.Hour))
+		return &alertingv2beta1.ClusterSilence{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: alertingv2beta1.ClusterSilenceSpec{SilenceSpec: alertingv2beta1.SilenceSpec{
+				Matchers: []alertingv2beta1.SilenceMatcher{{
+					Name:    "namespace",
+					Matcher: alertingv2beta1.Matcher{Type: alertingv2beta1.MatchEqual, Value: namespace},
+				}},
+				EndsAt: &endsAt,
+			}},
+		}
+	}
+
+	if _, err := o.CreateSilence(ctx, "ws1", newSilence("s1", "ns1")); err != nil {
+		t.Fatal(err)
+	}
+	// the namespaces of the other workspaces are forbidden
+	if _, err := o.CreateSilence(ctx, "ws1", newSilence("s2", "ns2")); !errors.IsForbidden(err) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	if _, err := o.CreateSilence(ctx, "ws2", newSilence("s3", "ns2")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := o.ListSilences(ctx, "ws1", query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalItems != 1 || result.Items[0].(*alertingv2beta1.ClusterSilence).Spec.Workspace != "ws1" {
+		t.Fatalf("unexpected silences %v", result.Items)
+	}
+	// the silences of the other workspaces are invisible
+	if err := o.DeleteSilence(ctx, "ws1", "s3"); !errors.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := o.DeleteSilence(ctx, "ws1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+}
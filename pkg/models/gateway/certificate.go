@@ -0,0 +1,360 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/gatewayapi"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+const (
+	// AnnotationTLSACME requests the certificates of the TLS hosts of the ingress to be issued by the ACME issuer.
+	AnnotationTLSACME = "kubernetes.io/tls-acme"
+	// AnnotationACMEIssuer is set on the TLS secrets issued by the ACME issuer, the value is the directory URL.
+	AnnotationACMEIssuer = "gateway.kubesphere.io/acme-issuer"
+
+	// GatewaySecretNamespaceIndex is the index of the gateways by the namespaces of the TLS secrets of their listeners.
+	GatewaySecretNamespaceIndex = "gateway.kubesphere.io/secret-namespaces"
+
+	defaultCertificateExpiryDays = 30
+)
+
+type CertificateStatus string
+
+const (
+	CertificateValid    CertificateStatus = "Valid"
+	CertificateExpiring CertificateStatus = "Expiring"
+	CertificateExpired  CertificateStatus = "Expired"
+	// CertificateInvalid is the status of the secrets without a valid certificate
+	CertificateInvalid CertificateStatus = "Invalid"
+	// CertificateMissing is the status of the secrets referenced but not found
+	CertificateMissing CertificateStatus = "Missing"
+)
+
+// the certificates needing attention are listed first
+var certificateStatusOrder = map[CertificateStatus]int{
+	CertificateExpired:  0,
+	CertificateMissing:  1,
+	CertificateInvalid:  2,
+	CertificateExpiring: 3,
+	CertificateValid:    4,
+}
+
+// CertificateReference is an ingress or a gateway serving the certificate.
+type CertificateReference struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Hosts     []string `json:"hosts,omitempty"`
+}
+
+// Certificate is a TLS secret referenced by the ingresses and the gateways.
+type Certificate struct {
+	Namespace    string            `json:"namespace"`
+	SecretName   string            `json:"secretName"`
+	Status       CertificateStatus `json:"status"`
+	Subject      string            `json:"subject,omitempty"`
+	Issuer       string            `json:"issuer,omitempty"`
+	SerialNumber string            `json:"serialNumber,omitempty"`
+	DNSNames     []string          `json:"dnsNames,omitempty"`
+	NotBefore    *metav1.Time      `json:"notBefore,omitempty"`
+	NotAfter     *metav1.Time      `json:"notAfter,omitempty"`
+	// DaysRemaining is the days until the certificate expires, it is negative once the certificate is expired.
+	DaysRemaining int `json:"daysRemaining"`
+	// UncoveredHosts are the hosts of the references not covered by the certificate.
+	UncoveredHosts []string `json:"uncoveredHosts,omitempty"`
+	// ACME is true if the certificate is issued by the ACME issuer.
+	ACME       bool                   `json:"acme,omitempty"`
+	References []CertificateReference `json:"references"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// ScanCertificates parses the TLS secrets referenced by the ingresses and the Gateway API gateways in the namespace,
+// or in all namespaces if it is empty. The certificates expiring within the expiry days are Expiring.
+// The reader is expected to index the gateways by IndexGatewaySecretNamespaces.
+func ScanCertificates(ctx context.Context, reader client.Reader, namespace string, expiryDays int, now time.Time) ([]Certificate, error) {
+	references, err := certificateReferences(ctx, reader, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := make([]Certificate, 0, len(references))
+	for key, refs := range references {
+		certificate := Certificate{Namespace: key.Namespace, SecretName: key.Name, References: refs}
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, key, secret); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+			certificate.Status = CertificateMissing
+		} else {
+			inspectCertificate(&certificate, secret, expiryDays, now)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	sort.Slice(certificates, func(i, j int) bool {
+		a, b := certificates[i], certificates[j]
+		if a.Status != b.Status {
+			return certificateStatusOrder[a.Status] < certificateStatusOrder[b.Status]
+		}
+		if a.DaysRemaining != b.DaysRemaining {
+			return a.DaysRemaining < b.DaysRemaining
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.SecretName < b.SecretName
+	})
+	return certificates, nil
+}
+
+// certificateReferences returns the references of the TLS secrets in the namespace.
+func certificateReferences(ctx context.Context, reader client.Reader, namespace string) (map[types.NamespacedName][]CertificateReference, error) {
+	references := make(map[types.NamespacedName][]CertificateReference)
+
+	ingresses := &networkingv1.IngressList{}
+	if err := reader.List(ctx, ingresses, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for _, ingress := range ingresses.Items {
+		for _, tls := range ingress.Spec.TLS {
+			// the default certificate of the ingress controller is used without the secret
+			if tls.SecretName == "" {
+				continue
+			}
+			key := types.NamespacedName{Namespace: ingress.Namespace, Name: tls.SecretName}
+			references[key] = append(references[key], CertificateReference{
+				Kind:      "Ingress",
+				Namespace: ingress.Namespace,
+				Name:      ingress.Name,
+				Hosts:     tls.Hosts,
+			})
+		}
+	}
+
+	// the listeners may refer to the secrets in other namespaces, the gateways are listed by the namespaces of the secrets
+	gateways := &unstructured.UnstructuredList{}
+	gateways.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind.GroupVersion().WithKind(gatewayapi.GatewayGroupVersionKind.Kind + "List"))
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.MatchingFields{GatewaySecretNamespaceIndex: namespace})
+	}
+	if err := reader.List(ctx, gateways, opts...); err != nil {
+		// Gateway API is not installed
+		if meta.IsNoMatchError(err) {
+			return references, nil
+		}
+		return nil, err
+	}
+	for i := range gateways.Items {
+		gateway := &gateways.Items[i]
+		for _, ref := range gatewayCertificateRefs(gateway) {
+			if namespace != "" && ref.secret.Namespace != namespace {
+				continue
+			}
+			reference := CertificateReference{Kind: "Gateway", Namespace: gateway.GetNamespace(), Name: gateway.GetName()}
+			if ref.hostname != "" {
+				reference.Hosts = []string{ref.hostname}
+			}
+			references[ref.secret] = append(references[ref.secret], reference)
+		}
+	}
+	return references, nil
+}
+
+// IndexGatewaySecretNamespaces indexes the gateways by the namespaces of the TLS secrets of their listeners,
+// which ScanCertificates lists the gateways of a namespace by.
+func IndexGatewaySecretNamespaces(ctx context.Context, indexer client.FieldIndexer) error {
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind)
+	return indexer.IndexField(ctx, gateway, GatewaySecretNamespaceIndex, GatewaySecretNamespaces)
+}
+
+// GatewaySecretNamespaces returns the namespaces of the TLS secrets the listeners of the gateway refer to.
+func GatewaySecretNamespaces(o client.Object) []string {
+	gateway, ok := o.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	var namespaces []string
+	for _, ref := range gatewayCertificateRefs(gateway) {
+		if !sliceutil.HasString(namespaces, ref.secret.Namespace) {
+			namespaces = append(namespaces, ref.secret.Namespace)
+		}
+	}
+	return namespaces
+}
+
+type gatewayCertificateRef struct {
+	secret   types.NamespacedName
+	hostname string
+}
+
+// gatewayCertificateRefs returns the TLS secrets the listeners of the gateway refer to.
+func gatewayCertificateRefs(gateway *unstructured.Unstructured) []gatewayCertificateRef {
+	var refs []gatewayCertificateRef
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hostname, _, _ := unstructured.NestedString(listener, "hostname")
+		certificateRefs, _, _ := unstructured.NestedSlice(listener, "tls", "certificateRefs")
+		for _, r := range certificateRefs {
+			ref, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			group, _, _ := unstructured.NestedString(ref, "group")
+			kind, _, _ := unstructured.NestedString(ref, "kind")
+			if group != "" || (kind != "" && kind != "Secret") {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(ref, "name")
+			secretNamespace, _, _ := unstructured.NestedString(ref, "namespace")
+			if secretNamespace == "" {
+				secretNamespace = gateway.GetNamespace()
+			}
+			refs = append(refs, gatewayCertificateRef{
+				secret:   types.NamespacedName{Namespace: secretNamespace, Name: name},
+				hostname: hostname,
+			})
+		}
+	}
+	return refs
+}
+
+// inspectCertificate parses the leaf certificate of the secret and checks its expiry and hosts.
+func inspectCertificate(certificate *Certificate, secret *corev1.Secret, expiryDays int, now time.Time) {
+	_, certificate.ACME = secret.Annotations[AnnotationACMEIssuer]
+
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		certificate.Status, certificate.Error = CertificateInvalid, err.Error()
+		return
+	}
+
+	notBefore, notAfter := metav1.NewTime(cert.NotBefore), metav1.NewTime(cert.NotAfter)
+	certificate.Subject = cert.Subject.String()
+	certificate.Issuer = cert.Issuer.String()
+	certificate.SerialNumber = cert.SerialNumber.String()
+	certificate.DNSNames = cert.DNSNames
+	certificate.NotBefore, certificate.NotAfter = &notBefore, &notAfter
+	certificate.DaysRemaining = int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+
+	switch {
+	case !now.Before(cert.NotAfter):
+		certificate.Status = CertificateExpired
+	case cert.NotAfter.Sub(now) < time.Duration(expiryDays)*24*time.Hour:
+		certificate.Status = CertificateExpiring
+	default:
+		certificate.Status = CertificateValid
+	}
+
+	uncovered := make(map[string]bool)
+	for _, ref := range certificate.References {
+		for _, host := range ref.Hosts {
+			if !uncovered[host] && !certificateCovers(cert, host) {
+				uncovered[host] = true
+				certificate.UncoveredHosts = append(certificate.UncoveredHosts, host)
+			}
+		}
+	}
+}
+
+// CertificateRenewalTime returns when the certificate of the secret is to be renewed, which is renewBefore
+// its expiry. It is the zero time if the secret has no valid certificate covering the hosts.
+func CertificateRenewalTime(secret *corev1.Secret, hosts []string, renewBefore time.Duration) time.Time {
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return time.Time{}
+	}
+	for _, host := range hosts {
+		if !certificateCovers(cert, host) {
+			return time.Time{}
+		}
+	}
+	return cert.NotAfter.Add(-renewBefore)
+}
+
+// parseCertificate parses the first certificate of the PEM encoded chain, which is the leaf certificate.
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found in %s", corev1.TLSCertKey)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func certificateCovers(cert *x509.Certificate, host string) bool {
+	// the wildcard hosts are covered by the same wildcard names only
+	if strings.HasPrefix(host, "*.") {
+		for _, name := range cert.DNSNames {
+			if strings.EqualFold(name, host) {
+				return true
+			}
+		}
+		return false
+	}
+	return cert.VerifyHostname(host) == nil
+}
+
+func (c *gatewayOperator) ListCertificates(namespace string, status CertificateStatus) (*api.ListResult, error) {
+	expiryDays := defaultCertificateExpiryDays
+	if c.options != nil && c.options.CertificateExpiryDays > 0 {
+		expiryDays = c.options.CertificateExpiryDays
+	}
+	certificates, err := ScanCertificates(context.TODO(), c.cache, namespace, expiryDays, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &api.ListResult{Items: []interface{}{}}
+	for _, certificate := range certificates {
+		if status == "" || certificate.Status == status {
+			result.Items = append(result.Items, certificate)
+		}
+	}
+	result.TotalItems = len(result.Items)
+	return result, nil
+}
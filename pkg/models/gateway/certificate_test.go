@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/gatewayapi"
+)
+
+// newTLSSecret returns a TLS secret of a self signed certificate of the DNS names valid until notAfter.
+func newTLSSecret(t *testing.T, namespace, name string, notAfter time.Time, dnsNames ...string) *corev1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Namespace: namespace, Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+}
+
+func TestScanCertificates(t *testing.T) {
+	now := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+
+	gw := &unstructured.Unstructured{}
+	gw.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind)
+	gw.SetNamespace("kubesphere-controls-system")
+	gw.SetName("kubesphere-router-project1")
+	gw.Object["spec"] = map[string]interface{}{
+		"listeners": []interface{}{
+			map[string]interface{}{
+				"name":     "https",
+				"hostname": "gw.example.com",
+				"tls": map[string]interface{}{
+					"certificateRefs": []interface{}{
+						map[string]interface{}{"kind": "Secret", "name": "gateway-tls", "namespace": "project1"},
+					},
+				},
+			},
+		},
+	}
+
+	expired := newTLSSecret(t, "project1", "expired-tls", now.Add(-time.Hour), "expired.example.com")
+	expiring := newTLSSecret(t, "project1", "expiring-tls", now.Add(10*24*time.Hour), "*.example.com")
+	valid := newTLSSecret(t, "project1", "gateway-tls", now.Add(60*24*time.Hour), "gw.example.com")
+	valid.Annotations = map[string]string{AnnotationACMEIssuer: "https://localhost:14000/dir"}
+	invalid := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Namespace: "project1", Name: "invalid-tls"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("invalid")},
+	}
+	other := newTLSSecret(t, "project2", "other-tls", now.Add(-time.Hour), "other.example.com")
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: v1.ObjectMeta{Namespace: "project1", Name: "web"},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"expired.example.com"}, SecretName: "expired-tls"},
+				{Hosts: []string{"a.example.com", "b.example.org"}, SecretName: "expiring-tls"},
+				{Hosts: []string{"invalid.example.com"}, SecretName: "invalid-tls"},
+				{Hosts: []string{"missing.example.com"}, SecretName: "missing-tls"},
+				// the default certificate
+				{Hosts: []string{"default.example.com"}},
+			},
+		},
+	}
+	otherIngress := &networkingv1.Ingress{
+		ObjectMeta: v1.ObjectMeta{Namespace: "project2", Name: "web"},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"other.example.com"}, SecretName: "other-tls"}},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	indexed := &unstructured.Unstructured{}
+	indexed.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind)
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(expired, expiring, valid, invalid, other, ingress, otherIngress, gw).
+		WithIndex(indexed, GatewaySecretNamespaceIndex, GatewaySecretNamespaces).Build()
+
+	certificates, err := ScanCertificates(context.Background(), c, "project1", 30, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, certificate := range certificates {
+		names = append(names, string(certificate.Status)+"/"+certificate.SecretName)
+	}
+	expected := []string{"Expired/expired-tls", "Missing/missing-tls", "Invalid/invalid-tls", "Expiring/expiring-tls", "Valid/gateway-tls"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected certificates %v, got %v", expected, names)
+	}
+
+	if certificates[0].DaysRemaining != -1 {
+		t.Errorf("expected -1 days remaining of the expired certificate, got %d", certificates[0].DaysRemaining)
+	}
+	if certificates[2].Error == "" {
+		t.Error("expected the error of the invalid certificate")
+	}
+	if c := certificates[3]; c.DaysRemaining != 10 || !reflect.DeepEqual(c.UncoveredHosts, []string{"b.example.org"}) {
+		t.Errorf("unexpected expiring certificate %+v", c)
+	}
+	gateway := certificates[4]
+	if !gateway.ACME || len(gateway.UncoveredHosts) != 0 || !reflect.DeepEqual(gateway.References, []CertificateReference{{
+		Kind: "Gateway", Namespace: "kubesphere-controls-system", Name: "kubesphere-router-project1", Hosts: []string{"gw.example.com"},
+	}}) {
+		t.Errorf("unexpected gateway certificate %+v", gateway)
+	}
+
+	all, err := ScanCertificates(context.Background(), c, "", 30, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 6 {
+		t.Errorf("expected 6 certificates of all namespaces, got %d", len(all))
+	}
+}
+
+func TestCertificateRenewalTime(t *testing.T) {
+	now := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	secret := newTLSSecret(t, "project1", "web-tls", now.Add(60*24*time.Hour), "a.example.com", "*.example.org")
+
+	if renewAt := CertificateRenewalTime(secret, []string{"a.example.com", "b.example.org"}, 30*24*time.Hour); !renewAt.Equal(now.Add(30 * 24 * time.Hour)) {
+		t.Errorf("unexpected renewal time %s", renewAt)
+	}
+	if renewAt := CertificateRenewalTime(secret, []string{"b.example.com"}, 30*24*time.Hour); !renewAt.IsZero() {
+		t.Errorf("expected the certificate not covering the hosts to be renewed at once, got %s", renewAt)
+	}
+	if renewAt := CertificateRenewalTime(&corev1.Secret{}, nil, 30*24*time.Hour); !renewAt.IsZero() {
+		t.Errorf("expected the secret without certificate to be renewed at once, got %s", renewAt)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -42,8 +43,11 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/gatewayapi"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/pod"
 	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
 const (
@@ -65,6 +69,8 @@ type GatewayOperator interface {
 	ListGateways(query *query.Query) (*api.ListResult, error)
 	GetPods(namespace string, query *query.Query) (*api.ListResult, error)
 	GetPodLogs(ctx context.Context, namespace string, podName string, logOptions *corev1.PodLogOptions, responseWriter io.Writer) error
+	GetTraffic(namespace, name string, start, end time.Time, limit int) (*TrafficReport, error)
+	ListCertificates(namespace string, status CertificateStatus) (*api.ListResult, error)
 }
 
 type gatewayOperator struct {
@@ -73,15 +79,20 @@ type gatewayOperator struct {
 	client    client.Client
 	cache     cache.Cache
 	options   *gateway.Options
+	mo        monitoring.Interface
+	lo        logging.Client
 }
 
-func NewGatewayOperator(client client.Client, cache cache.Cache, options *gateway.Options, factory informers.InformerFactory, k8sclient kubernetes.Interface) GatewayOperator {
+func NewGatewayOperator(client client.Client, cache cache.Cache, options *gateway.Options, factory informers.InformerFactory, k8sclient kubernetes.Interface,
+	monitoringClient monitoring.Interface, loggingClient logging.Client) GatewayOperator {
 	return &gatewayOperator{
 		client:    client,
 		cache:     cache,
 		options:   options,
 		k8sclient: k8sclient,
 		factory:   factory,
+		mo:        monitoringClient,
+		lo:        loggingClient,
 	}
 }
 
@@ -226,6 +237,9 @@ func (c *gatewayOperator) updateStatus(gateway *v1alpha1.Gateway, svc *corev1.Se
 			"service":      svc.Spec.Ports,
 		},
 	}
+	if s := c.gatewayAPIStatus(gateway); s != nil {
+		status.Object["gatewayAPI"] = s
+	}
 
 	target, err := status.MarshalJSON()
 	if err != nil {
@@ -248,6 +262,46 @@ func (c *gatewayOperator) updateStatus(gateway *v1alpha1.Gateway, svc *corev1.Se
 	return gateway, nil
 }
 
+// gatewayAPIStatus returns the status of the Gateway of Gateway API provisioned for the gateway, which has the
+// same name and namespace with the gateway.
+func (c *gatewayOperator) gatewayAPIStatus(gateway *v1alpha1.Gateway) interface{} {
+	if gateway.Spec.GatewayAPI == nil || !gateway.Spec.GatewayAPI.Enabled {
+		return nil
+	}
+	g := &unstructured.Unstructured{}
+	g.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind)
+	if err := c.client.Get(context.TODO(), client.ObjectKeyFromObject(gateway), g); err != nil {
+		klog.Info(err)
+		return nil
+	}
+	return g.Object["status"]
+}
+
+// validateGatewayAPI checks the GatewayClass and the Gateway of Gateway API can be provisioned for the gateway.
+func validateGatewayAPI(gateway *v1alpha1.Gateway) error {
+	spec := gateway.Spec.GatewayAPI
+	if spec == nil || !spec.Enabled {
+		return nil
+	}
+	if spec.ControllerName == "" {
+		return fmt.Errorf("the controller name of the GatewayClass is required")
+	}
+	names := make(map[string]bool, len(spec.Listeners))
+	for _, l := range spec.Listeners {
+		if names[l.Name] {
+			return fmt.Errorf("duplicated listener %s", l.Name)
+		}
+		names[l.Name] = true
+		if l.Port < 1 || l.Port > 65535 {
+			return fmt.Errorf("invalid port %d of listener %s", l.Port, l.Name)
+		}
+		if (l.Protocol == "HTTPS" || l.Protocol == "TLS") && l.CertificateRef == "" {
+			return fmt.Errorf("the certificate of %s listener %s is required", l.Protocol, l.Name)
+		}
+	}
+	return nil
+}
+
 // GetGateways returns all Gateways from the project. There are at most 2 gateways exists in a project,
 // a Global Gateway and a Project Gateway or a Legacy Project Gateway.
 func (c *gatewayOperator) GetGateways(namespace string) ([]*v1alpha1.Gateway, error) {
@@ -306,6 +360,10 @@ func (c *gatewayOperator) CreateGateway(namespace string, obj *v1alpha1.Gateway)
 		return nil, fmt.Errorf("can't create project gateway if legacy gateway exists, please upgrade the gateway firstly")
 	}
 
+	if err := validateGatewayAPI(obj); err != nil {
+		return nil, err
+	}
+
 	c.overrideDefaultValue(obj, namespace)
 	err := c.client.Create(context.TODO(), obj)
 	return obj, err
@@ -327,6 +385,9 @@ func (c *gatewayOperator) UpdateGateway(namespace string, obj *v1alpha1.Gateway)
 	if c.options.Namespace == "" && obj.Namespace != namespace || c.options.Namespace != "" && c.options.Namespace != obj.Namespace {
 		return nil, fmt.Errorf("namespace doesn't match with origin namespace")
 	}
+	if err := validateGatewayAPI(obj); err != nil {
+		return nil, err
+	}
 	c.overrideDefaultValue(obj, namespace)
 	err := c.client.Update(context.TODO(), obj)
 	return obj, err
@@ -25,6 +25,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -35,6 +36,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/gatewayapi"
 	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
 )
 
@@ -391,6 +393,48 @@ func Test_gatewayOperator_CreateGateway(t *testing.T) {
 				return g[0]
 			},
 		},
+		{
+			name: "requires the controller name of Gateway API",
+			fields: fields{
+				client: client,
+				cache:  &fakeClient{Client: client},
+				options: &gateway.Options{
+					Namespace: "kubesphere-controls-system",
+				},
+			},
+			args: args{
+				namespace: "project3",
+				obj: &v1alpha1.Gateway{
+					Spec: v1alpha1.GatewaySpec{
+						GatewayAPI: &v1alpha1.GatewayAPISpec{Enabled: true},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "requires the certificate of HTTPS listeners",
+			fields: fields{
+				client: client,
+				cache:  &fakeClient{Client: client},
+				options: &gateway.Options{
+					Namespace: "kubesphere-controls-system",
+				},
+			},
+			args: args{
+				namespace: "project3",
+				obj: &v1alpha1.Gateway{
+					Spec: v1alpha1.GatewaySpec{
+						GatewayAPI: &v1alpha1.GatewayAPISpec{
+							Enabled:        true,
+							ControllerName: "example.com/gateway-controller",
+							Listeners:      []v1alpha1.Listener{{Name: "https", Protocol: "HTTPS", Port: 443}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -404,6 +448,9 @@ func Test_gatewayOperator_CreateGateway(t *testing.T) {
 				t.Errorf("gatewayOperator.CreateGateway() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			w := tt.want(c, tt.args.namespace)
 			if !reflect.DeepEqual(got, w) {
 				t.Errorf("gatewayOperator.CreateGateway() has wrong object\nDiff:\n %s", diff.ObjectGoPrintSideBySide(w, got))
@@ -842,6 +889,18 @@ func Test_gatewayOperator_status(t *testing.T) {
 	//nolint:staticcheck
 	client2 := fake.NewFakeClientWithScheme(Scheme)
 
+	gatewayAPISpec := &v1alpha1.GatewayAPISpec{Enabled: true, ControllerName: "example.com/gateway-controller"}
+	gatewayAPIGateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"addresses": []interface{}{map[string]interface{}{"type": "IPAddress", "value": "10.0.0.1"}},
+		},
+	}}
+	gatewayAPIGateway.SetGroupVersionKind(gatewayapi.GatewayGroupVersionKind)
+	gatewayAPIGateway.SetNamespace("kubesphere-controls-system")
+	gatewayAPIGateway.SetName("kubesphere-router-project1")
+	//nolint:staticcheck
+	client3 := fake.NewFakeClientWithScheme(Scheme, gatewayAPIGateway)
+
 	fake := &corev1.Node{
 		ObjectMeta: v1.ObjectMeta{
 			Name: "fake-node",
@@ -957,6 +1016,40 @@ func Test_gatewayOperator_status(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Gateway API",
+			fields: fields{
+				client: client3,
+				cache:  &fakeClient{Client: client3},
+				options: &gateway.Options{
+					Namespace: "kubesphere-controls-system",
+				},
+			},
+			args: args{
+				gateway: &v1alpha1.Gateway{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace: "kubesphere-controls-system",
+						Name:      "kubesphere-router-project1",
+					},
+					Spec: v1alpha1.GatewaySpec{GatewayAPI: gatewayAPISpec},
+				},
+				svc: &corev1.Service{
+					Spec: corev1.ServiceSpec{
+						Type: corev1.ServiceTypeLoadBalancer,
+					},
+				},
+			},
+			want: &v1alpha1.Gateway{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "kubesphere-controls-system",
+					Name:      "kubesphere-router-project1",
+				},
+				Spec: v1alpha1.GatewaySpec{GatewayAPI: gatewayAPISpec},
+				Status: runtime.RawExtension{
+					Raw: []byte("{\"gatewayAPI\":{\"addresses\":[{\"type\":\"IPAddress\",\"value\":\"10.0.0.1\"}]},\"loadBalancer\":{},\"service\":null}\n"),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
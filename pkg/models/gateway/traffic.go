@@ -0,0 +1,336 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"kubesphere.io/api/gateway/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+const (
+	// DefaultTrafficTopLimit is the number of the top clients and URLs returned by default
+	DefaultTrafficTopLimit = 10
+	// maxAccessLogs is the max number of the access logs analyzed, which is the max result window of elasticsearch
+	maxAccessLogs = 10000
+)
+
+// accessLogPattern matches the default log format of the nginx ingress controller:
+// $remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"
+// $request_length $request_time [$proxy_upstream_name] ...
+var accessLogPattern = regexp.MustCompile(`^(\S+) - \S+ \[[^\]]+\] "(\S+) (\S+)[^"]*" (\d{3}) \d+ "[^"]*" "[^"]*" \d+ \S+ \[([^\]]*)\]`)
+
+// TrafficReport summarizes the traffic served by a gateway over a time range.
+type TrafficReport struct {
+	Gateway string `json:"gateway"`
+	// Namespace the traffic is scoped to, the traffic of all namespaces is summarized if it is empty
+	Namespace string    `json:"namespace,omitempty"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	// Routes are the traffic of the hosts and paths, from the metrics of the ingress controller
+	Routes []RouteTraffic `json:"routes"`
+	// TopClients and Top5xxURLs are from the access logs of the ingress controller
+	TopClients []ClientTraffic `json:"topClients"`
+	Top5xxURLs []URLErrors     `json:"top5xxURLs"`
+	// SampledRequests is the number of the access logs analyzed, the latest logs are analyzed
+	// if there are too many of them
+	SampledRequests int `json:"sampledRequests"`
+	// Errors of the metrics or the logs, the report is partial if there are errors
+	Errors []string `json:"errors,omitempty"`
+}
+
+type RouteTraffic struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+	// Requests in the time range
+	Requests float64 `json:"requests"`
+	// RequestRate is the requests per second
+	RequestRate      float64 `json:"requestRate"`
+	ClientErrorRatio float64 `json:"clientErrorRatio"`
+	ServerErrorRatio float64 `json:"serverErrorRatio"`
+	// Latency percentiles in seconds
+	LatencyP50 *float64 `json:"latencyP50,omitempty"`
+	LatencyP95 *float64 `json:"latencyP95,omitempty"`
+	LatencyP99 *float64 `json:"latencyP99,omitempty"`
+}
+
+type ClientTraffic struct {
+	Client   string `json:"client"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+}
+
+type URLErrors struct {
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Upstream string `json:"upstream"`
+	Count    int    `json:"count"`
+}
+
+type routeKey struct {
+	host, path string
+}
+
+// GetTraffic summarizes the traffic served by the gateway visible in the namespace. The traffic is scoped to
+// the namespace, unless the namespace is the one of the global gateway.
+func (c *gatewayOperator) GetTraffic(namespace, name string, start, end time.Time, limit int) (*TrafficReport, error) {
+	gateways, err := c.GetGateways(namespace)
+	if err != nil {
+		return nil, err
+	}
+	var gateway *v1alpha1.Gateway
+	for _, g := range gateways {
+		if g.Name == name {
+			gateway = g
+			break
+		}
+	}
+	if gateway == nil {
+		return nil, errors.NewNotFound(v1alpha1.Resource("gateways"), name)
+	}
+	if limit <= 0 {
+		limit = DefaultTrafficTopLimit
+	}
+
+	report := &TrafficReport{
+		Gateway:    name,
+		Start:      start,
+		End:        end,
+		Routes:     []RouteTraffic{},
+		TopClients: []ClientTraffic{},
+		Top5xxURLs: []URLErrors{},
+	}
+	if namespace != globalGatewayNameSuffix {
+		report.Namespace = namespace
+	}
+
+	if c.mo != nil {
+		routes, errs := c.routeTraffic(gateway, report.Namespace, start, end)
+		report.Routes = routes
+		report.Errors = append(report.Errors, errs...)
+	}
+	if c.lo != nil {
+		if err := c.analyzeAccessLogs(report, gateway, limit); err != nil {
+			klog.Error(err)
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+	return report, nil
+}
+
+// routeTraffic queries the request rate, the error ratios and the latency percentiles of each host and path.
+func (c *gatewayOperator) routeTraffic(gateway *v1alpha1.Gateway, namespace string, start, end time.Time) ([]RouteTraffic, []string) {
+	// the pods of the ingress controller are named after the gateway
+	selector := fmt.Sprintf(`controller_namespace="%s", controller_pod=~"%s-[^-]+-[^-]+"`, gateway.Namespace, regexp.QuoteMeta(gateway.Name))
+	if namespace != "" {
+		selector += fmt.Sprintf(`, exported_namespace="%s"`, namespace)
+	}
+	window := fmt.Sprintf("%ds", int64(end.Sub(start).Seconds()))
+
+	exprs := map[string]string{
+		"requests":     fmt.Sprintf(`sum by (host, path) (increase(nginx_ingress_controller_requests{%s}[%s]))`, selector, window),
+		"client_error": fmt.Sprintf(`sum by (host, path) (increase(nginx_ingress_controller_requests{%s, status=~"4.."}[%s]))`, selector, window),
+		"server_error": fmt.Sprintf(`sum by (host, path) (increase(nginx_ingress_controller_requests{%s, status=~"5.."}[%s]))`, selector, window),
+	}
+	for _, q := range []string{"0.5", "0.95", "0.99"} {
+		exprs["p"+q] = fmt.Sprintf(`histogram_quantile(%s, sum by (host, path, le) (rate(nginx_ingress_controller_request_duration_seconds_bucket{%s}[%s])))`, q, selector, window)
+	}
+
+	var errs []string
+	values := make(map[string]map[routeKey]float64, len(exprs))
+	for name, expr := range exprs {
+		metric := c.mo.GetMetric(expr, end)
+		if metric.Error != "" {
+			klog.Errorf("query %s failed, error: %s", expr, metric.Error)
+			errs = append(errs, metric.Error)
+			continue
+		}
+		values[name] = make(map[routeKey]float64, len(metric.MetricValues))
+		for _, v := range metric.MetricValues {
+			if v.Sample == nil || math.IsNaN(v.Sample[1]) || math.IsInf(v.Sample[1], 0) {
+				continue
+			}
+			values[name][routeKey{host: v.Metadata["host"], path: v.Metadata["path"]}] = v.Sample[1]
+		}
+	}
+
+	routes := make([]RouteTraffic, 0, len(values["requests"]))
+	seconds := end.Sub(start).Seconds()
+	for key, requests := range values["requests"] {
+		route := RouteTraffic{Host: key.host, Path: key.path, Requests: requests}
+		if seconds > 0 {
+			route.RequestRate = requests / seconds
+		}
+		if requests > 0 {
+			route.ClientErrorRatio = values["client_error"][key] / requests
+			route.ServerErrorRatio = values["server_error"][key] / requests
+		}
+		route.LatencyP50 = percentile(values["p0.5"], key)
+		route.LatencyP95 = percentile(values["p0.95"], key)
+		route.LatencyP99 = percentile(values["p0.99"], key)
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Requests != routes[j].Requests {
+			return routes[i].Requests > routes[j].Requests
+		}
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes, errs
+}
+
+func percentile(values map[routeKey]float64, key routeKey) *float64 {
+	if v, ok := values[key]; ok {
+		return &v
+	}
+	return nil
+}
+
+// analyzeAccessLogs counts the requests of the clients and the 5xx responses of the URLs from the access logs.
+func (c *gatewayOperator) analyzeAccessLogs(report *TrafficReport, gateway *v1alpha1.Gateway, limit int) error {
+	pods, err := c.factory.KubernetesSharedInformerFactory().Core().V1().Pods().Lister().Pods(gateway.Namespace).List(
+		labels.SelectorFromSet(labels.Set{
+			"app.kubernetes.io/name":     "ingress-nginx",
+			"app.kubernetes.io/instance": gateway.Name + "-ingress",
+		}))
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+
+	sf := logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{gateway.Namespace: nil},
+		Starttime:       report.Start,
+		Endtime:         report.End,
+	}
+	for _, p := range pods {
+		sf.PodFilter = append(sf.PodFilter, p.Name)
+	}
+
+	var upstreams map[string]bool
+	if report.Namespace != "" {
+		if upstreams, err = c.namespaceUpstreams(report.Namespace); err != nil {
+			return err
+		}
+	}
+
+	logs, err := c.lo.SearchLogs(sf, 0, maxAccessLogs, "desc")
+	if err != nil {
+		return err
+	}
+
+	clients := make(map[string]*ClientTraffic)
+	urls := make(map[string]*URLErrors)
+	for _, record := range logs.Records {
+		match := accessLogPattern.FindStringSubmatch(record.Log)
+		if match == nil {
+			continue
+		}
+		remote, method, uri, upstream := match[1], match[2], match[3], match[5]
+		// the requests not proxied to the namespace are out of the scope
+		if upstreams != nil && !upstreams[upstream] {
+			continue
+		}
+		status, _ := strconv.Atoi(match[4])
+		report.SampledRequests++
+
+		client, ok := clients[remote]
+		if !ok {
+			client = &ClientTraffic{Client: remote}
+			clients[remote] = client
+		}
+		client.Requests++
+		if status >= 400 {
+			client.Errors++
+		}
+
+		if status >= 500 {
+			if i := strings.IndexByte(uri, '?'); i >= 0 {
+				uri = uri[:i]
+			}
+			key := method + " " + uri + " " + upstream
+			u, ok := urls[key]
+			if !ok {
+				u = &URLErrors{Method: method, URL: uri, Upstream: upstream}
+				urls[key] = u
+			}
+			u.Count++
+		}
+	}
+
+	for _, client := range clients {
+		report.TopClients = append(report.TopClients, *client)
+	}
+	sort.Slice(report.TopClients, func(i, j int) bool {
+		if report.TopClients[i].Requests != report.TopClients[j].Requests {
+			return report.TopClients[i].Requests > report.TopClients[j].Requests
+		}
+		return report.TopClients[i].Client < report.TopClients[j].Client
+	})
+	if len(report.TopClients) > limit {
+		report.TopClients = report.TopClients[:limit]
+	}
+
+	for _, u := range urls {
+		report.Top5xxURLs = append(report.Top5xxURLs, *u)
+	}
+	sort.Slice(report.Top5xxURLs, func(i, j int) bool {
+		if report.Top5xxURLs[i].Count != report.Top5xxURLs[j].Count {
+			return report.Top5xxURLs[i].Count > report.Top5xxURLs[j].Count
+		}
+		return report.Top5xxURLs[i].URL < report.Top5xxURLs[j].URL
+	})
+	if len(report.Top5xxURLs) > limit {
+		report.Top5xxURLs = report.Top5xxURLs[:limit]
+	}
+	return nil
+}
+
+// namespaceUpstreams returns the upstreams of the services in the namespace, which are named
+// as $namespace-$service-$port by the ingress controller.
+func (c *gatewayOperator) namespaceUpstreams(namespace string) (map[string]bool, error) {
+	services, err := c.factory.KubernetesSharedInformerFactory().Core().V1().Services().Lister().Services(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	upstreams := make(map[string]bool)
+	for _, svc := range services {
+		for _, port := range svc.Spec.Ports {
+			upstreams[fmt.Sprintf("%s-%s-%d", namespace, svc.Name, port.Port)] = true
+			if port.Name != "" {
+				upstreams[fmt.Sprintf("%s-%s-%s", namespace, svc.Name, port.Name)] = true
+			}
+		}
+	}
+	return upstreams, nil
+}
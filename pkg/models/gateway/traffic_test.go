@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"kubesphere.io/api/gateway/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type fakeMonitoringClient struct {
+	monitoring.Interface
+	exprs []string
+}
+
+func (f *fakeMonitoringClient) GetMetric(expr string, ts time.Time) monitoring.Metric {
+	f.exprs = append(f.exprs, expr)
+
+	sample := func(host, path string, value float64) monitoring.MetricValue {
+		return monitoring.MetricValue{
+			Metadata: map[string]string{"host": host, "path": path},
+			Sample:   &monitoring.Point{float64(ts.Unix()), value},
+		}
+	}
+	metric := monitoring.Metric{}
+	switch {
+	case strings.HasPrefix(expr, "histogram_quantile(0.99"):
+		metric.MetricValues = []monitoring.MetricValue{sample("a.example.com", "/", 0.5), sample("b.example.com", "/api", math.NaN())}
+	case strings.HasPrefix(expr, "histogram_quantile"):
+		metric.MetricValues = []monitoring.MetricValue{sample("a.example.com", "/", 0.1)}
+	case strings.Contains(expr, `status=~"4.."`):
+		metric.MetricValues = []monitoring.MetricValue{sample("a.example.com", "/", 36)}
+	case strings.Contains(expr, `status=~"5.."`):
+		metric.MetricValues = []monitoring.MetricValue{sample("b.example.com", "/api", 9)}
+	default:
+		metric.MetricValues = []monitoring.MetricValue{sample("a.example.com", "/", 360), sample("b.example.com", "/api", 90)}
+	}
+	return metric
+}
+
+type fakeLoggingClient struct {
+	records []logging.Record
+	filters []logging.SearchFilter
+}
+
+func (f *fakeLoggingClient) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, error) {
+	return logging.Statistics{}, nil
+}
+
+func (f *fakeLoggingClient) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
+	return logging.Histogram{}, nil
+}
+
+func (f *fakeLoggingClient) SearchLogs(sf logging.SearchFilter, from, size int64, order string) (logging.Logs, error) {
+	f.filters = append(f.filters, sf)
+	return logging.Logs{Total: int64(len(f.records)), Records: f.records}, nil
+}
+
+func (f *fakeLoggingClient) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
+	return nil
+}
+
+func accessLog(client, request string, status, upstream string) logging.Record {
+	return logging.Record{
+		Log: client + ` - - [10/Dec/2023:08:00:00 +0000] "` + request + ` HTTP/1.1" ` + status +
+			` 612 "-" "curl/7.79.1" 78 0.002 [` + upstream + `] [] 10.233.0.10:8080 612 0.002 ` + status + ` 5f0c1`,
+	}
+}
+
+func Test_gatewayOperator_GetTraffic(t *testing.T) {
+	var Scheme = runtime.NewScheme()
+	v1alpha1.AddToScheme(Scheme)
+	corev1.AddToScheme(Scheme)
+
+	//nolint:staticcheck
+	client := fake.NewFakeClientWithScheme(Scheme)
+	client.Create(context.TODO(), &v1alpha1.Gateway{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "kubesphere-router-project1",
+			Namespace: "kubesphere-controls-system",
+		},
+	})
+
+	k8sClient := fakek8s.NewSimpleClientset()
+	factory := informers.NewInformerFactories(k8sClient, fakeks.NewSimpleClientset(), nil, nil, nil, nil)
+	factory.KubernetesSharedInformerFactory().Core().V1().Pods().Informer().GetIndexer().Add(&corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "kubesphere-router-project1-6b8c7d9f5d-x2x7q",
+			Namespace: "kubesphere-controls-system",
+			Labels: map[string]string{
+				"app.kubernetes.io/name":     "ingress-nginx",
+				"app.kubernetes.io/instance": "kubesphere-router-project1-ingress",
+			},
+		},
+	})
+	factory.KubernetesSharedInformerFactory().Core().V1().Services().Informer().GetIndexer().Add(&corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "project1"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+	})
+
+	mo := &fakeMonitoringClient{}
+	lo := &fakeLoggingClient{
+		records: []logging.Record{
+			accessLog("192.168.0.1", "GET /?page=1", "200", "project1-web-80"),
+			accessLog("192.168.0.1", "GET /api?id=1", "502", "project1-web-http"),
+			accessLog("192.168.0.2", "GET /api?id=2", "502", "project1-web-80"),
+			accessLog("192.168.0.2", "POST /login", "404", "project1-web-80"),
+			accessLog("192.168.0.3", "GET /api", "503", "project1-web-80"),
+			// out of the namespace
+			accessLog("192.168.0.9", "GET /", "500", "project2-web-80"),
+			{Log: "invalid log"},
+		},
+	}
+	c := &gatewayOperator{
+		client:  client,
+		cache:   &fakeClient{Client: client},
+		options: &gateway.Options{Namespace: "kubesphere-controls-system"},
+		factory: factory,
+		mo:      mo,
+		lo:      lo,
+	}
+
+	end := time.Date(2023, 12, 10, 8, 0, 0, 0, time.UTC)
+	start := end.Add(-time.Hour)
+
+	if _, err := c.GetTraffic("project1", "kubesphere-router-project2", start, end, 0); err == nil {
+		t.Fatal("expected not found error of the unknown gateway")
+	}
+
+	report, err := c.GetTraffic("project1", "kubesphere-router-project1", start, end, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Namespace != "project1" || len(report.Errors) != 0 {
+		t.Errorf("unexpected report namespace %q, errors %v", report.Namespace, report.Errors)
+	}
+	for _, expr := range mo.exprs {
+		if !strings.Contains(expr, `exported_namespace="project1"`) || !strings.Contains(expr, "[3600s]") {
+			t.Errorf("unexpected query %s", expr)
+		}
+	}
+
+	if len(report.Routes) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", report.Routes)
+	}
+	a, b := report.Routes[0], report.Routes[1]
+	if a.Host != "a.example.com" || a.Requests != 360 || a.RequestRate != 0.1 || a.ClientErrorRatio != 0.1 || a.ServerErrorRatio != 0 {
+		t.Errorf("unexpected route %+v", a)
+	}
+	if a.LatencyP50 == nil || *a.LatencyP50 != 0.1 || a.LatencyP99 == nil || *a.LatencyP99 != 0.5 {
+		t.Errorf("unexpected latency of route %+v", a)
+	}
+	if b.Host != "b.example.com" || b.ServerErrorRatio != 0.1 || b.LatencyP50 != nil || b.LatencyP99 != nil {
+		t.Errorf("unexpected route %+v", b)
+	}
+
+	if len(lo.filters) != 1 || len(lo.filters[0].PodFilter) != 1 || !lo.filters[0].Starttime.Equal(start) {
+		t.Errorf("unexpected log search filters %+v", lo.filters)
+	}
+	if report.SampledRequests != 5 {
+		t.Errorf("expected 5 sampled requests, got %d", report.SampledRequests)
+	}
+	if len(report.TopClients) != 1 || report.TopClients[0] != (ClientTraffic{Client: "192.168.0.1", Requests: 2, Errors: 1}) {
+		t.Errorf("unexpected top clients %+v", report.TopClients)
+	}
+	if len(report.Top5xxURLs) != 1 || report.Top5xxURLs[0] != (URLErrors{Method: "GET", URL: "/api", Upstream: "project1-web-80", Count: 2}) {
+		t.Errorf("unexpected top 5xx URLs %+v", report.Top5xxURLs)
+	}
+}
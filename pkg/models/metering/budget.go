@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+var DefaultBudgetThresholds = []int{50, 80, 100}
+
+const defaultEnforcementThreshold = 100
+
+// BudgetPeriod returns the period of the budget covering the time, periods are in UTC and weeks start on Monday.
+func BudgetPeriod(period meteringapiv1alpha1.BudgetPeriod, t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case meteringapiv1alpha1.BudgetPeriodDaily:
+		return day, day.AddDate(0, 0, 1)
+	case meteringapiv1alpha1.BudgetPeriodWeekly:
+		start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	default:
+		return StatementPeriod(t)
+	}
+}
+
+// BudgetThresholds returns the thresholds of the budget in ascending order.
+func BudgetThresholds(budget *meteringapiv1alpha1.Budget) []int {
+	if len(budget.Spec.Thresholds) == 0 {
+		return DefaultBudgetThresholds
+	}
+	thresholds := append([]int(nil), budget.Spec.Thresholds...)
+	sort.Ints(thresholds)
+	return thresholds
+}
+
+// EnforcementThreshold returns the threshold the restriction of the budget is applied at,
+// 0 if the budget restricts nothing.
+func EnforcementThreshold(budget *meteringapiv1alpha1.Budget) int {
+	if budget.Spec.Enforcement == nil {
+		return 0
+	}
+	if budget.Spec.Enforcement.Threshold > 0 {
+		return budget.Spec.Enforcement.Threshold
+	}
+	return defaultEnforcementThreshold
+}
+
+// BudgetEvaluator computes the costs tracked by budgets with the meters of the metering API.
+type BudgetEvaluator interface {
+	// Cost returns the cost of the workspace, namespace or application of the budget in the
+	// time range (start, end], in the currency of the rate card of the workspace.
+	Cost(ctx context.Context, budget *meteringapiv1alpha1.Budget, start, end time.Time) (float64, string, error)
+}
+
+type budgetEvaluator struct {
+	usageQuerier
+}
+
+func NewBudgetEvaluator(mo monitoringmodel.MonitoringOperator, reader client.Reader, priceInfo meteringclient.PriceInfo) BudgetEvaluator {
+	return &budgetEvaluator{
+		usageQuerier: usageQuerier{
+			mo:        mo,
+			reader:    reader,
+			priceInfo: priceInfo,
+		},
+	}
+}
+
+func (e *budgetEvaluator) Cost(ctx context.Context, budget *meteringapiv1alpha1.Budget, start, end time.Time) (float64, string, error) {
+	rateCard, err := workspaceRateCard(ctx, e.reader, budget.Spec.Workspace)
+	if err != nil {
+		return 0, "", err
+	}
+	currency := currencyOf(rateCard, e.priceInfo)
+
+	// meters are hourly
+	start, end = start.Truncate(time.Hour), end.Truncate(time.Hour)
+	if !end.After(start) {
+		return 0, currency, nil
+	}
+
+	var usage map[string]meteringapiv1alpha1.NamespaceStatement
+	if budget.Spec.Application != "" {
+		usage, err = e.queryApplicationUsage(ctx, budget.Spec.Namespace, budget.Spec.Application, start, end, rateCard)
+	} else {
+		opt := monitoring.NamespaceOption{WorkspaceName: budget.Spec.Workspace, ResourceFilter: ".*"}
+		if budget.Spec.Namespace != "" {
+			opt = monitoring.NamespaceOption{NamespaceName: budget.Spec.Namespace}
+		}
+		// priced by namespace as the statements of the workspace are
+		usage, err = e.queryNamespaceUsage(ctx, opt, start, end, rateCard)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	var cost float64
+	for key := range usage {
+		u := usage[key]
+		fee, _ := priceUsage(&u, rateCard, e.priceInfo)
+		cost += fee
+	}
+	return round(cost), currency, nil
+}
+
+// queryApplicationUsage returns the usage of the application in the time range (start, end], keyed by the
+// application. The CPU, memory and GPU usage is split across node pools by the usage of the pods of its workloads.
+func (e *budgetEvaluator) queryApplicationUsage(ctx context.Context, namespace, application string, start, end time.Time,
+	rateCard *meteringapiv1alpha1.RateCard) (map[string]meteringapiv1alpha1.NamespaceStatement, error) {
+	components := e.mo.GetAppWorkloads(namespace, []string{application})[application]
+	if len(components) == 0 {
+		// the application has no workloads to be metered
+		return nil, nil
+	}
+
+	keyOf := func(monitoring.MetricValue) (string, bool) {
+		return application, true
+	}
+	opt := monitoring.ApplicationOption{NamespaceName: namespace, Application: application, ApplicationComponents: components}
+	usage, err := e.queryUsage(metersOf(monitoringmodel.ApplicationMetrics), opt, keyOf, start, end, rateCard)
+	if err != nil || rateCard == nil || len(rateCard.Spec.NodePools) == 0 {
+		return usage, err
+	}
+
+	meters := []string{"meter_pod_cpu_usage", "meter_pod_memory_usage_wo_cache", "meter_pod_gpu_usage"}
+	var pods monitoringmodel.Metrics
+	for _, component := range components {
+		// components are kind:name, e.g. Deployment:nginx, only the pods of workloads are selected by their owners
+		kind, name, _ := strings.Cut(strings.ToLower(component), ":")
+		if kind != "deployment" && kind != "statefulset" && kind != "daemonset" {
+			continue
+		}
+		opt := monitoring.PodOption{NamespaceName: namespace, WorkloadKind: kind, WorkloadName: name, ResourceFilter: ".*"}
+		metrics, err := e.mo.GetNamedMetersOverTime(meters, start, end, 24*time.Hour, opt, e.priceInfo)
+		if err != nil {
+			return nil, err
+		}
+		pods.Results = append(pods.Results, metrics.Results...)
+	}
+	if err := e.splitNodePoolUsage(ctx, usage, pods, keyOf, rateCard); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
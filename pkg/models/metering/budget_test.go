@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+)
+
+func TestBudgetPeriod(t *testing.T) {
+	// 2023-12-14 is a Thursday
+	now := time.Date(2023, 12, 14, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period     meteringapiv1alpha1.BudgetPeriod
+		start, end time.Time
+	}{
+		{meteringapiv1alpha1.BudgetPeriodDaily, time.Date(2023, 12, 14, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC)},
+		{meteringapiv1alpha1.BudgetPeriodWeekly, time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 18, 0, 0, 0, 0, time.UTC)},
+		{meteringapiv1alpha1.BudgetPeriodMonthly, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"", time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		start, end := BudgetPeriod(test.period, now)
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("unexpected %s period [%s, %s)", test.period, start, end)
+		}
+	}
+
+	// weeks start on Monday
+	start, _ := BudgetPeriod(meteringapiv1alpha1.BudgetPeriodWeekly, time.Date(2023, 12, 17, 23, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start of the week %s", start)
+	}
+}
+
+func TestBudgetThresholds(t *testing.T) {
+	budget := &meteringapiv1alpha1.Budget{}
+	if diff := cmp.Diff(BudgetThresholds(budget), DefaultBudgetThresholds); diff != "" {
+		t.Errorf("thresholds differ (-got, +want): %s", diff)
+	}
+	budget.Spec.Thresholds = []int{90, 30}
+	if diff := cmp.Diff(BudgetThresholds(budget), []int{30, 90}); diff != "" {
+		t.Errorf("thresholds differ (-got, +want): %s", diff)
+	}
+	if threshold := EnforcementThreshold(budget); threshold != 0 {
+		t.Errorf("unexpected enforcement threshold %d", threshold)
+	}
+	budget.Spec.Enforcement = &meteringapiv1alpha1.BudgetEnforcement{}
+	if threshold := EnforcementThreshold(budget); threshold != 100 {
+		t.Errorf("unexpected enforcement threshold %d", threshold)
+	}
+}
+
+func TestBudgetCost(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringapiv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	rateCard := &meteringapiv1alpha1.RateCard{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Currency:   "USD",
+			Workspaces: []string{"test-workspace"},
+			CPU:        &meteringapiv1alpha1.Rate{Price: 0.1},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).WithObjects(rateCard).Build()
+	mo := &fakeMonitoringOperator{cpuUsage: map[string]float64{"ns-a": 2}}
+	evaluator := NewBudgetEvaluator(mo, c, meteringclient.PriceInfo{CpuPerCorePerHour: 1, CurrencyUnit: "CNY"})
+
+	budget := &meteringapiv1alpha1.Budget{
+		Spec: meteringapiv1alpha1.BudgetSpec{Workspace: "test-workspace", Namespace: "ns-a", Amount: 10},
+	}
+	start := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	cost, currency, err := evaluator.Cost(context.Background(), budget, start, start.Add(10*time.Hour+30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 cores for 10 hours at 0.1 per core hour
+	if cost != 2 || currency != "USD" {
+		t.Errorf("unexpected cost %v %s", cost, currency)
+	}
+
+	// nothing is metered within the first hour of the period
+	cost, _, err = evaluator.Cost(context.Background(), budget, start, start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != 0 || len(mo.queries) != 1 {
+		t.Errorf("unexpected cost %v, queries %v", cost, mo.queries)
+	}
+}
+
+func TestBudgetCostBreakdown(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringapiv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	rateCard := &meteringapiv1alpha1.RateCard{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			CPU: &meteringapiv1alpha1.Rate{Price: 0.1},
+			PVC: &meteringapiv1alpha1.Rate{Price: 0.01},
+			NodePools: []meteringapiv1alpha1.NodePoolRate{
+				{
+					Name:         "highmem",
+					NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}},
+					CPU:          &meteringapiv1alpha1.Rate{Price: 0.2},
+				},
+			},
+			StorageClasses: []meteringapiv1alpha1.StorageClassRate{
+				{StorageClassName: "ssd", PVC: meteringapiv1alpha1.Rate{Price: 0.05}},
+			},
+		},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"pool": "highmem"}}}
+	c := fake.NewClientBuilder().WithScheme(sch).WithObjects(rateCard, node).Build()
+	evaluator := NewBudgetEvaluator(&fakeBreakdownOperator{}, c, meteringclient.PriceInfo{CurrencyUnit: "CNY"})
+
+	budget := &meteringapiv1alpha1.Budget{
+		Spec: meteringapiv1alpha1.BudgetSpec{Workspace: "test-workspace", Amount: 10},
+	}
+	start := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	cost, _, err := evaluator.Cost(context.Background(), budget, start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cost is the fee of the statement of the same usage
+	if cost != 2.01 {
+		t.Errorf("unexpected cost %v", cost)
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"sort"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+// RateContext is what is known about the usage to charge, which decides the rate to apply.
+type RateContext struct {
+	Workspace string
+	// NodeLabels is nil if the usage is not on a single node, e.g. usage of a namespace.
+	NodeLabels map[string]string
+	// NodePool is the name of the node pool the usage is on if the nodes are no longer known.
+	NodePool string
+	// NodePoolUsage is the usage on the nodes of every node pool if the usage spans nodes,
+	// e.g. the CPU usage of a namespace in a statement.
+	NodePoolUsage map[string]float64
+	StorageClass  string
+	// StorageClassUsage is the PVC usage of every storage class if the usage spans storage classes,
+	// e.g. the PVC usage of a namespace.
+	StorageClassUsage map[string]float64
+}
+
+// FindRateCard returns the rate card applying to the workspace. A rate card for the workspace
+// takes precedence over the rate cards for all workspaces, rate cards are sorted by name otherwise.
+func FindRateCard(rateCards []meteringapiv1alpha1.RateCard, workspace string) *meteringapiv1alpha1.RateCard {
+	var fallback *meteringapiv1alpha1.RateCard
+	for i := range rateCards {
+		rateCard := &rateCards[i]
+		if len(rateCard.Spec.Workspaces) == 0 {
+			if fallback == nil {
+				fallback = rateCard
+			}
+			continue
+		}
+		if workspace != "" && sliceutil.HasString(rateCard.Spec.Workspaces, workspace) {
+			return rateCard
+		}
+	}
+	return fallback
+}
+
+// FindRate returns the rate of the resource in the rate card, or nil if the rate card has no price
+// for the resource. The prices of the node pool or storage class the usage belongs to take precedence.
+func FindRate(rateCard *meteringapiv1alpha1.RateCard, resourceType int, ctx RateContext) (*meteringapiv1alpha1.Rate, monitoring.Rate) {
+	applied := monitoring.Rate{RateCard: rateCard.Name}
+
+	switch resourceType {
+	case monitoringmodel.METER_RESOURCE_TYPE_CPU, monitoringmodel.METER_RESOURCE_TYPE_MEM, monitoringmodel.METER_RESOURCE_TYPE_GPU:
+		var pool *meteringapiv1alpha1.NodePoolRate
+		if ctx.NodePool != "" {
+			for i := range rateCard.Spec.NodePools {
+				if rateCard.Spec.NodePools[i].Name == ctx.NodePool {
+					pool = &rateCard.Spec.NodePools[i]
+					break
+				}
+			}
+		} else if ctx.NodeLabels != nil {
+			pool = NodePoolOf(rateCard, ctx.NodeLabels)
+		}
+		if pool == nil {
+			break
+		}
+		rate := map[int]*meteringapiv1alpha1.Rate{
+			monitoringmodel.METER_RESOURCE_TYPE_CPU: pool.CPU,
+			monitoringmodel.METER_RESOURCE_TYPE_MEM: pool.Memory,
+			monitoringmodel.METER_RESOURCE_TYPE_GPU: pool.GPU,
+		}[resourceType]
+		if rate != nil {
+			applied.NodePool = pool.Name
+			applied.Price = rate.Price
+			return rate, applied
+		}
+	case monitoringmodel.METER_RESOURCE_TYPE_PVC:
+		for i, sc := range rateCard.Spec.StorageClasses {
+			if ctx.StorageClass != "" && sc.StorageClassName == ctx.StorageClass {
+				applied.StorageClass = sc.StorageClassName
+				applied.Price = sc.PVC.Price
+				return &rateCard.Spec.StorageClasses[i].PVC, applied
+			}
+		}
+	}
+
+	rate := map[int]*meteringapiv1alpha1.Rate{
+		monitoringmodel.METER_RESOURCE_TYPE_CPU:         rateCard.Spec.CPU,
+		monitoringmodel.METER_RESOURCE_TYPE_MEM:         rateCard.Spec.Memory,
+		monitoringmodel.METER_RESOURCE_TYPE_GPU:         rateCard.Spec.GPU,
+		monitoringmodel.METER_RESOURCE_TYPE_NET_INGRESS: rateCard.Spec.NetworkIngress,
+		monitoringmodel.METER_RESOURCE_TYPE_NET_EGRESS:  rateCard.Spec.NetworkEgress,
+		monitoringmodel.METER_RESOURCE_TYPE_PVC:         rateCard.Spec.PVC,
+	}[resourceType]
+	if rate != nil {
+		applied.Price = rate.Price
+	}
+	return rate, applied
+}
+
+// NodePoolOf returns the node pool of the rate card the node with the labels belongs to, or nil if it
+// belongs to none. Only the first matched node pool applies.
+func NodePoolOf(rateCard *meteringapiv1alpha1.RateCard, nodeLabels map[string]string) *meteringapiv1alpha1.NodePoolRate {
+	for i, pool := range rateCard.Spec.NodePools {
+		selector, err := metav1.LabelSelectorAsSelector(&pool.NodeSelector)
+		if err != nil {
+			klog.Warningf("invalid node selector of node pool %s in rate card %s: %s", pool.Name, rateCard.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(nodeLabels)) {
+			return &rateCard.Spec.NodePools[i]
+		}
+	}
+	return nil
+}
+
+// Charge computes the fee of the usage by the rate. The usage above the threshold of a tier is charged
+// at the price of the tier, up to the threshold of the next tier. The tiers are applied in the order of
+// their thresholds whatever order they are listed in.
+func Charge(rate *meteringapiv1alpha1.Rate, usage float64) (float64, []monitoring.TierCharge) {
+	var fee float64
+	var tiers []monitoring.TierCharge
+
+	sorted := make([]meteringapiv1alpha1.RateTier, len(rate.Tiers))
+	copy(sorted, rate.Tiers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].From < sorted[j].From
+	})
+
+	remaining, price, from := usage, rate.Price, 0.0
+	for _, tier := range sorted {
+		if usage <= tier.From {
+			break
+		}
+		fee += (tier.From - from) * price
+		remaining = usage - tier.From
+		price, from = tier.Price, tier.From
+		tiers = append(tiers, monitoring.TierCharge{From: tier.From, Price: tier.Price})
+	}
+	fee += remaining * price
+	if len(tiers) > 0 {
+		tiers[len(tiers)-1].Usage = remaining
+		for i := 0; i < len(tiers)-1; i++ {
+			tiers[i].Usage = tiers[i+1].From - tiers[i].From
+		}
+	}
+	return fee, tiers
+}
+
+// ChargeUsage computes the fee of the usage by the rate card, ok is false if the rate card has no price for it.
+// The usage spanning node pools or storage classes is charged at the price of every node pool or storage class
+// for its part.
+func ChargeUsage(rateCard *meteringapiv1alpha1.RateCard, resourceType int, usage float64, ctx RateContext,
+	priceInfo meteringclient.PriceInfo) (fee float64, applied monitoring.Rate, ok bool) {
+	switch resourceType {
+	case monitoringmodel.METER_RESOURCE_TYPE_CPU, monitoringmodel.METER_RESOURCE_TYPE_MEM, monitoringmodel.METER_RESOURCE_TYPE_GPU:
+		if ctx.NodeLabels == nil && ctx.NodePool == "" && len(ctx.NodePoolUsage) > 0 {
+			if fee, applied, ok := chargeParts(rateCard, resourceType, usage, ctx.NodePoolUsage, func(part string) RateContext {
+				return RateContext{Workspace: ctx.Workspace, NodePool: part}
+			}, priceInfo); ok {
+				return fee, applied, true
+			}
+		}
+	case monitoringmodel.METER_RESOURCE_TYPE_PVC:
+		if ctx.StorageClass == "" && len(ctx.StorageClassUsage) > 0 {
+			if fee, applied, ok := chargeParts(rateCard, resourceType, usage, ctx.StorageClassUsage, func(part string) RateContext {
+				return RateContext{Workspace: ctx.Workspace, StorageClass: part}
+			}, priceInfo); ok {
+				return fee, applied, true
+			}
+		}
+	}
+
+	rate, applied := FindRate(rateCard, resourceType, ctx)
+	if rate == nil {
+		return 0, applied, false
+	}
+	fee, applied.Tiers = Charge(rate, usage)
+	return fee, applied, true
+}
+
+// chargeParts charges every part of the usage the rate card has a node pool or storage class price for at
+// that price, and the rest at the price of the rate card for the resource, or the global price if the rate
+// card has none. ok is false if the rate card has no price for any of the parts.
+func chargeParts(rateCard *meteringapiv1alpha1.RateCard, resourceType int, usage float64, parts map[string]float64,
+	partContext func(part string) RateContext, priceInfo meteringclient.PriceInfo) (fee float64, applied monitoring.Rate, ok bool) {
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	applied = monitoring.Rate{RateCard: rateCard.Name}
+	rest := usage
+	for _, name := range names {
+		partUsage := parts[name]
+		if partUsage <= 0 {
+			continue
+		}
+		rate, partApplied := FindRate(rateCard, resourceType, partContext(name))
+		if rate == nil || (partApplied.NodePool == "" && partApplied.StorageClass == "") {
+			// charged along with the rest
+			continue
+		}
+
+		var partFee float64
+		partFee, partApplied.Tiers = Charge(rate, partUsage)
+		partApplied.Usage = partUsage
+		applied.Parts = append(applied.Parts, partApplied)
+		fee += partFee
+		rest -= partUsage
+	}
+	if len(applied.Parts) == 0 {
+		return 0, applied, false
+	}
+
+	if rest > 0 {
+		rate, restApplied := FindRate(rateCard, resourceType, partContext(""))
+		if rate != nil {
+			var restFee float64
+			restFee, restApplied.Tiers = Charge(rate, rest)
+			fee += restFee
+		} else {
+			// no rate card applies to the rest
+			restApplied = monitoring.Rate{Price: globalPrice(priceInfo, resourceType)}
+			fee += rest * restApplied.Price
+		}
+		restApplied.Usage = rest
+		applied.Parts = append(applied.Parts, restApplied)
+	}
+	return fee, applied, true
+}
+
+// ApplyRateCards recomputes the fees of the meters by the rate cards that apply, the fees computed
+// by the global price info are kept for the resources the rate cards have no price for.
+func ApplyRateCards(metrics *monitoringmodel.Metrics, rateCards []meteringapiv1alpha1.RateCard, priceInfo meteringclient.PriceInfo,
+	rateContextOf func(metricValue *monitoring.MetricValue) RateContext) {
+	if len(rateCards) == 0 {
+		return
+	}
+
+	for i := range metrics.Results {
+		metric := &metrics.Results[i]
+		for j := range metric.MetricValues {
+			mv := &metric.MetricValues[j]
+			if mv.SumValue == "" {
+				continue
+			}
+
+			resourceType, usage, err := monitoringmodel.GetMeterUsage(metric.MetricName, mv.SumValue)
+			if err != nil {
+				klog.Error(err)
+				continue
+			}
+
+			ctx := rateContextOf(mv)
+			rateCard := FindRateCard(rateCards, ctx.Workspace)
+			if rateCard == nil {
+				continue
+			}
+			fee, applied, ok := ChargeUsage(rateCard, resourceType, usage, ctx, priceInfo)
+			if !ok {
+				continue
+			}
+
+			mv.Fee = strconv.FormatFloat(fee, 'f', 3, 64)
+			mv.Rate = &applied
+			mv.CurrencyUnit = priceInfo.CurrencyUnit
+			if rateCard.Spec.Currency != "" {
+				mv.CurrencyUnit = rateCard.Spec.Currency
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+var rateCards = []meteringapiv1alpha1.RateCard{
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Currency: "USD",
+			CPU:      &meteringapiv1alpha1.Rate{Price: 0.05},
+			PVC:      &meteringapiv1alpha1.Rate{Price: 0.01},
+			NodePools: []meteringapiv1alpha1.NodePoolRate{
+				{
+					Name:         "spot",
+					NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"node.kubesphere.io/lifecycle": "spot"}},
+					CPU:          &meteringapiv1alpha1.Rate{Price: 0.02},
+				},
+			},
+			StorageClasses: []meteringapiv1alpha1.StorageClassRate{
+				{StorageClassName: "ssd", PVC: meteringapiv1alpha1.Rate{Price: 0.03}},
+			},
+		},
+	},
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Workspaces: []string{"system-workspace"},
+			CPU: &meteringapiv1alpha1.Rate{
+				Price: 0.05,
+				Tiers: []meteringapiv1alpha1.RateTier{{From: 100, Price: 0.04}, {From: 200, Price: 0.03}},
+			},
+		},
+	},
+}
+
+func TestFindRate(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceType int
+		ctx          RateContext
+		expected     monitoring.Rate
+		noRate       bool
+	}{
+		{
+			name:         "workspace rate card",
+			resourceType: monitoringmodel.METER_RESOURCE_TYPE_CPU,
+			ctx:          RateContext{Workspace: "system-workspace"},
+			expected:     monitoring.Rate{RateCard: "enterprise", Price: 0.05},
+		},
+		{
+			name:         "no price for the resource",
+			resourceType: monitoringmodel.METER_RESOURCE_TYPE_MEM,
+			ctx:          RateContext{Workspace: "system-workspace"},
+			noRate:       true,
+		},
+		{
+			name:         "node pool",
+			resourceType: monitoringmodel.METER_RESOURCE_TYPE_CPU,
+			ctx:          RateContext{Workspace: "test-workspace", NodeLabels: map[string]string{"node.kubesphere.io/lifecycle": "spot"}},
+			expected:     monitoring.Rate{RateCard: "default", NodePool: "spot", Price: 0.02},
+		},
+		{
+			name:         "node out of any node pool",
+			resourceType: monitoringmodel.METER_RESOURCE_TYPE_CPU,
+			ctx:          RateContext{Workspace: "test-workspace", NodeLabels: map[string]string{}},
+			expected:     monitoring.Rate{RateCard: "default", Price: 0.05},
+		},
+		{
+			name:         "storage class",
+			resourceType: monitoringmodel.METER_RESOURCE_TYPE_PVC,
+			ctx:          RateContext{StorageClass: "ssd"},
+			expected:     monitoring.Rate{RateCard: "default", StorageClass: "ssd", Price: 0.03},
+		},
+		{
+			name:         "storage class without price",
+			resourceType: monitoringmodel.METER_RESOURCE_TYPE_PVC,
+			ctx:          RateContext{StorageClass: "hdd"},
+			expected:     monitoring.Rate{RateCard: "default", Price: 0.01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, applied := FindRate(FindRateCard(rateCards, tt.ctx.Workspace), tt.resourceType, tt.ctx)
+			if tt.noRate {
+				if rate != nil {
+					t.Fatalf("expected no rate, got %v", rate)
+				}
+				return
+			}
+			if diff := cmp.Diff(applied, tt.expected); diff != "" {
+				t.Fatalf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestCharge(t *testing.T) {
+	rate := rateCards[1].Spec.CPU
+	tests := []struct {
+		usage         float64
+		expectedFee   float64
+		expectedTiers []monitoring.TierCharge
+	}{
+		{
+			usage:       50,
+			expectedFee: 2.5,
+		},
+		{
+			usage:         150,
+			expectedFee:   7,
+			expectedTiers: []monitoring.TierCharge{{From: 100, Price: 0.04, Usage: 50}},
+		},
+		{
+			usage:       250,
+			expectedFee: 10.5,
+			expectedTiers: []monitoring.TierCharge{
+				{From: 100, Price: 0.04, Usage: 100},
+				{From: 200, Price: 0.03, Usage: 50},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		fee, tiers := Charge(rate, tt.usage)
+		if diff := cmp.Diff(fee, tt.expectedFee, cmp.Comparer(func(x, y float64) bool { return x-y < 1e-9 && y-x < 1e-9 })); diff != "" {
+			t.Fatalf("fee of %v differ (-got, +want): %s", tt.usage, diff)
+		}
+		if diff := cmp.Diff(tiers, tt.expectedTiers); diff != "" {
+			t.Fatalf("tiers of %v differ (-got, +want): %s", tt.usage, diff)
+		}
+	}
+
+	// the tiers listed out of order apply in the order of their thresholds
+	unsorted := rate.DeepCopy()
+	unsorted.Tiers[0], unsorted.Tiers[1] = unsorted.Tiers[1], unsorted.Tiers[0]
+	fee, tiers := Charge(unsorted, 250)
+	if fee < 10.5-1e-9 || fee > 10.5+1e-9 {
+		t.Fatalf("unexpected fee %v of the unsorted tiers", fee)
+	}
+	if diff := cmp.Diff(tiers, tests[2].expectedTiers); diff != "" {
+		t.Fatalf("tiers of the unsorted tiers differ (-got, +want): %s", diff)
+	}
+	if rate.Tiers[0].From != 100 {
+		t.Fatal("expected the tiers of the rate card not modified")
+	}
+}
@@ -0,0 +1,455 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sinformers "k8s.io/client-go/informers"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+)
+
+type RecommendationType string
+
+const (
+	// RecommendationIdle is recommended to the workloads barely using any CPU and receiving no traffic,
+	// which could be scaled down to zero.
+	RecommendationIdle RecommendationType = "Idle"
+	// RecommendationOverProvisioned is recommended to the workloads requesting much more than they use.
+	RecommendationOverProvisioned RecommendationType = "OverProvisioned"
+	// RecommendationUnderProvisioned is recommended to the workloads using more than they request,
+	// or requesting nothing.
+	RecommendationUnderProvisioned RecommendationType = "UnderProvisioned"
+	RecommendationRightSized       RecommendationType = "RightSized"
+)
+
+const (
+	DefaultRecommendationWindow     = 7 * 24 * time.Hour
+	DefaultRecommendationPercentile = 95
+
+	// headroom on top of the observed usage
+	recommendationHeadroom = 0.15
+	// requests within the tolerance of the recommendations are right sized
+	recommendationTolerance = 0.2
+	// workloads using less CPU cores and network bytes per second are idle
+	idleCPUThreshold     = 0.005
+	idleNetworkThreshold = 1024
+
+	minCPURequest    = 0.01
+	minMemoryRequest = 32 * 1024 * 1024
+	// the cost deltas are estimated per month
+	hoursPerMonth = 30 * 24
+)
+
+type RecommendationOptions struct {
+	// Window is the time window the usage is observed in.
+	Window time.Duration
+	// Percentile of the observed usage the requests are recommended by, in (0, 100].
+	Percentile float64
+	// Kind filters the workloads, one of deployment, statefulset or daemonset.
+	Kind string
+}
+
+type ContainerRecommendation struct {
+	Name string `json:"name"`
+	// CPU usage in cores at the percentile.
+	CPUUsage float64 `json:"cpuUsage"`
+	// Memory usage in bytes at the percentile.
+	MemoryUsage float64 `json:"memoryUsage"`
+	// Peak memory usage in bytes.
+	MemoryPeak  float64                     `json:"memoryPeak"`
+	Current     corev1.ResourceRequirements `json:"current"`
+	Recommended corev1.ResourceRequirements `json:"recommended"`
+}
+
+type WorkloadRecommendation struct {
+	Kind      string             `json:"kind"`
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace"`
+	Replicas  int32              `json:"replicas"`
+	Type      RecommendationType `json:"type"`
+	// Network traffic in bytes per second per replica.
+	NetworkUsage float64                   `json:"networkUsage"`
+	Containers   []ContainerRecommendation `json:"containers"`
+	// MonthlyCostDelta is the estimated cost change per month if the recommendation is applied,
+	// idle workloads are estimated to be scaled down to zero.
+	MonthlyCostDelta float64 `json:"monthlyCostDelta"`
+}
+
+type WorkloadRecommendations struct {
+	Window     string                   `json:"window"`
+	Percentile float64                  `json:"percentile"`
+	Currency   string                   `json:"currency"`
+	Items      []WorkloadRecommendation `json:"items"`
+	// MonthlyCostDelta is the total estimated cost change per month.
+	MonthlyCostDelta float64 `json:"monthlyCostDelta"`
+}
+
+// Recommender compares the resource requests of workloads with their usage observed by the
+// monitoring system, and recommends right sized requests and limits.
+type Recommender interface {
+	RecommendWorkloads(namespace string, opts RecommendationOptions) (*WorkloadRecommendations, error)
+}
+
+type recommender struct {
+	mo        monitoringmodel.MonitoringOperator
+	informers k8sinformers.SharedInformerFactory
+	priceInfo meteringclient.PriceInfo
+	now       func() time.Time
+}
+
+func NewRecommender(mo monitoringmodel.MonitoringOperator, informers k8sinformers.SharedInformerFactory, priceInfo meteringclient.PriceInfo) Recommender {
+	return &recommender{
+		mo:        mo,
+		informers: informers,
+		priceInfo: priceInfo,
+		now:       time.Now,
+	}
+}
+
+// workload is a workload with the pod template and the pods the usage is collected from.
+type workload struct {
+	kind     string
+	name     string
+	replicas int32
+	template corev1.PodSpec
+	pods     []string
+}
+
+// usage of a container of a workload, the maximum of the pods of the workload
+type usage struct {
+	cpu, memory, memoryPeak float64
+}
+
+func (r *recommender) RecommendWorkloads(namespace string, opts RecommendationOptions) (*WorkloadRecommendations, error) {
+	if opts.Window <= 0 {
+		opts.Window = DefaultRecommendationWindow
+	}
+	if opts.Percentile == 0 {
+		opts.Percentile = DefaultRecommendationPercentile
+	}
+	if opts.Percentile < 0 || opts.Percentile > 100 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid percentile %v", opts.Percentile))
+	}
+
+	workloads, err := r.listWorkloads(namespace, opts.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	window := model.Duration(opts.Window).String()
+	result := &WorkloadRecommendations{
+		Window:     window,
+		Percentile: opts.Percentile,
+		Currency:   r.priceInfo.CurrencyUnit,
+		Items:      []WorkloadRecommendation{},
+	}
+	if len(workloads) == 0 {
+		return result, nil
+	}
+
+	now := r.now()
+	containerSelector := `job="kubelet", pod!="", container!="", image!=""`
+	cpu, err := r.query(namespace, now, fmt.Sprintf(`quantile_over_time(%g, sum by (namespace, pod, container) (irate(container_cpu_usage_seconds_total{%s}[5m]))[%s:5m])`,
+		opts.Percentile/100, containerSelector, window))
+	if err != nil {
+		return nil, err
+	}
+	memory, err := r.query(namespace, now, fmt.Sprintf(`quantile_over_time(%g, sum by (namespace, pod, container) (container_memory_working_set_bytes{%s})[%s:5m])`,
+		opts.Percentile/100, containerSelector, window))
+	if err != nil {
+		return nil, err
+	}
+	memoryPeak, err := r.query(namespace, now, fmt.Sprintf(`max_over_time(sum by (namespace, pod, container) (container_memory_working_set_bytes{%s})[%s:5m])`,
+		containerSelector, window))
+	if err != nil {
+		return nil, err
+	}
+	network, err := r.query(namespace, now, fmt.Sprintf(`sum by (namespace, pod) (rate({__name__=~"container_network_(receive|transmit)_bytes_total", pod!=""}[%s]))`,
+		window))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range workloads {
+		observed := false
+		usages := make(map[string]*usage)
+		var networkUsage float64
+		for _, pod := range w.pods {
+			for _, c := range w.template.Containers {
+				key := pod + "/" + c.Name
+				if _, ok := cpu[key]; !ok {
+					continue
+				}
+				observed = true
+				u := usages[c.Name]
+				if u == nil {
+					u = &usage{}
+					usages[c.Name] = u
+				}
+				u.cpu = math.Max(u.cpu, cpu[key])
+				u.memory = math.Max(u.memory, memory[key])
+				u.memoryPeak = math.Max(u.memoryPeak, memoryPeak[key])
+			}
+			networkUsage = math.Max(networkUsage, network[pod])
+		}
+		// there is no usage of the workload observed
+		if !observed {
+			continue
+		}
+
+		recommendation := r.recommend(w, usages, networkUsage)
+		recommendation.Namespace = namespace
+		result.Items = append(result.Items, recommendation)
+		result.MonthlyCostDelta += recommendation.MonthlyCostDelta
+	}
+
+	// the most savings first
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		return result.Items[i].MonthlyCostDelta < result.Items[j].MonthlyCostDelta
+	})
+	result.MonthlyCostDelta = round(result.MonthlyCostDelta)
+	return result, nil
+}
+
+// query returns the values of the instant query by pod or pod/container.
+func (r *recommender) query(namespace string, now time.Time, expr string) (map[string]float64, error) {
+	metric, err := r.mo.GetMetric(expr, namespace, now)
+	if err != nil {
+		return nil, err
+	}
+	if metric.Error != "" {
+		return nil, fmt.Errorf("failed to query usage: %s", metric.Error)
+	}
+
+	values := make(map[string]float64, len(metric.MetricValues))
+	for _, mv := range metric.MetricValues {
+		if mv.Sample == nil {
+			continue
+		}
+		key := mv.Metadata["pod"]
+		if container := mv.Metadata["container"]; container != "" {
+			key = key + "/" + container
+		}
+		values[key] = mv.Sample.Value()
+	}
+	return values, nil
+}
+
+func (r *recommender) recommend(w *workload, usages map[string]*usage, networkUsage float64) WorkloadRecommendation {
+	recommendation := WorkloadRecommendation{
+		Kind:         w.kind,
+		Name:         w.name,
+		Replicas:     w.replicas,
+		NetworkUsage: round(networkUsage),
+	}
+
+	idle := networkUsage < idleNetworkThreshold
+	overProvisioned, underProvisioned := false, false
+	var currentCost, recommendedCost float64
+	for _, c := range w.template.Containers {
+		u := usages[c.Name]
+		if u == nil {
+			u = &usage{}
+		}
+		if u.cpu >= idleCPUThreshold {
+			idle = false
+		}
+
+		cpuRequest := math.Max(u.cpu*(1+recommendationHeadroom), minCPURequest)
+		memoryRequest := math.Max(u.memory*(1+recommendationHeadroom), minMemoryRequest)
+		cr := ContainerRecommendation{
+			Name:        c.Name,
+			CPUUsage:    round(u.cpu),
+			MemoryUsage: math.Round(u.memory),
+			MemoryPeak:  math.Round(u.memoryPeak),
+			Current:     *c.Resources.DeepCopy(),
+			Recommended: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    cpuQuantity(cpuRequest),
+					corev1.ResourceMemory: memoryQuantity(memoryRequest),
+				},
+			},
+		}
+
+		// limits are recommended only if they are set, keeping the ratio to the requests
+		if limit, ok := c.Resources.Limits[corev1.ResourceCPU]; ok {
+			ratio := 2.0
+			if request, ok := c.Resources.Requests[corev1.ResourceCPU]; ok && !request.IsZero() {
+				ratio = math.Max(limit.AsApproximateFloat64()/request.AsApproximateFloat64(), 1)
+			}
+			cr.Recommended.Limits = corev1.ResourceList{corev1.ResourceCPU: cpuQuantity(cpuRequest * ratio)}
+		}
+		if _, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+			if cr.Recommended.Limits == nil {
+				cr.Recommended.Limits = corev1.ResourceList{}
+			}
+			// a memory limit below the peak usage gets the container killed
+			cr.Recommended.Limits[corev1.ResourceMemory] = memoryQuantity(math.Max(u.memoryPeak*(1+recommendationHeadroom), memoryRequest))
+		}
+
+		currentCPU, currentMemory := requestOf(c.Resources, corev1.ResourceCPU), requestOf(c.Resources, corev1.ResourceMemory)
+		recommendedCPU := cr.Recommended.Requests.Cpu().AsApproximateFloat64()
+		recommendedMemory := cr.Recommended.Requests.Memory().AsApproximateFloat64()
+		if currentCPU < u.cpu || currentMemory < u.memory {
+			underProvisioned = true
+		} else if currentCPU > recommendedCPU*(1+recommendationTolerance) || currentMemory > recommendedMemory*(1+recommendationTolerance) {
+			overProvisioned = true
+		}
+
+		currentCost += r.hourlyCost(currentCPU, currentMemory)
+		recommendedCost += r.hourlyCost(recommendedCPU, recommendedMemory)
+		recommendation.Containers = append(recommendation.Containers, cr)
+	}
+
+	replicas := float64(w.replicas)
+	switch {
+	case idle:
+		recommendation.Type = RecommendationIdle
+		recommendation.MonthlyCostDelta = -currentCost * replicas * hoursPerMonth
+	case underProvisioned:
+		recommendation.Type = RecommendationUnderProvisioned
+	case overProvisioned:
+		recommendation.Type = RecommendationOverProvisioned
+	default:
+		recommendation.Type = RecommendationRightSized
+	}
+	if !idle {
+		recommendation.MonthlyCostDelta = (recommendedCost - currentCost) * replicas * hoursPerMonth
+	}
+	recommendation.MonthlyCostDelta = round(recommendation.MonthlyCostDelta)
+	return recommendation
+}
+
+func (r *recommender) hourlyCost(cpu, memory float64) float64 {
+	return cpu*r.priceInfo.CpuPerCorePerHour + memory/(1<<30)*r.priceInfo.MemPerGigabytesPerHour
+}
+
+// requestOf returns the request of the resource in cores or bytes, the limit is the request if
+// only the limit is set.
+func requestOf(requirements corev1.ResourceRequirements, name corev1.ResourceName) float64 {
+	if request, ok := requirements.Requests[name]; ok {
+		return request.AsApproximateFloat64()
+	}
+	if limit, ok := requirements.Limits[name]; ok {
+		return limit.AsApproximateFloat64()
+	}
+	return 0
+}
+
+// cpuQuantity rounds the cores up to 5 millicores.
+func cpuQuantity(cores float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(math.Ceil(cores*1000/5))*5, resource.DecimalSI)
+}
+
+// memoryQuantity rounds the bytes up to a mebibyte.
+func memoryQuantity(bytes float64) resource.Quantity {
+	return *resource.NewQuantity(int64(math.Ceil(bytes/(1<<20)))<<20, resource.BinarySI)
+}
+
+func (r *recommender) listWorkloads(namespace, kind string) ([]*workload, error) {
+	kind = strings.ToLower(kind)
+	if kind != "" && kind != "deployment" && kind != "statefulset" && kind != "daemonset" {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid workload kind %s", kind))
+	}
+
+	var workloads []*workload
+	// pods are matched to the workloads by their owners, pods of replica sets to their deployments
+	owners := make(map[string]*workload)
+
+	if kind == "" || kind == "deployment" {
+		deployments, err := r.informers.Apps().V1().Deployments().Lister().Deployments(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		replicaSets, err := r.informers.Apps().V1().ReplicaSets().Lister().ReplicaSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]*workload)
+		for _, d := range deployments {
+			w := &workload{kind: "deployment", name: d.Name, replicas: replicasOf(d.Spec.Replicas), template: d.Spec.Template.Spec}
+			workloads = append(workloads, w)
+			byName[d.Name] = w
+		}
+		for _, rs := range replicaSets {
+			if owner := metav1.GetControllerOf(rs); owner != nil && owner.Kind == "Deployment" && byName[owner.Name] != nil {
+				owners["ReplicaSet/"+rs.Name] = byName[owner.Name]
+			}
+		}
+	}
+	if kind == "" || kind == "statefulset" {
+		statefulSets, err := r.informers.Apps().V1().StatefulSets().Lister().StatefulSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range statefulSets {
+			w := &workload{kind: "statefulset", name: s.Name, replicas: replicasOf(s.Spec.Replicas), template: s.Spec.Template.Spec}
+			workloads = append(workloads, w)
+			owners["StatefulSet/"+s.Name] = w
+		}
+	}
+	if kind == "" || kind == "daemonset" {
+		daemonSets, err := r.informers.Apps().V1().DaemonSets().Lister().DaemonSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range daemonSets {
+			w := &workload{kind: "daemonset", name: d.Name, replicas: d.Status.DesiredNumberScheduled, template: d.Spec.Template.Spec}
+			workloads = append(workloads, w)
+			owners["DaemonSet/"+d.Name] = w
+		}
+	}
+	pods, err := r.informers.Core().V1().Pods().Lister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		if owner := metav1.GetControllerOf(pod); owner != nil {
+			if w := owners[owner.Kind+"/"+owner.Name]; w != nil {
+				w.pods = append(w.pods, pod.Name)
+			}
+		}
+	}
+
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].kind != workloads[j].kind {
+			return workloads[i].kind < workloads[j].kind
+		}
+		return workloads[i].name < workloads[j].name
+	})
+	return workloads, nil
+}
+
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type fakeUsageMonitoringOperator struct {
+	fakeMonitoringOperator
+	// values of the usage by metric, pod and container
+	usage map[string]map[string]float64
+	exprs []string
+}
+
+func (f *fakeUsageMonitoringOperator) GetMetric(expr, namespace string, t time.Time) (monitoring.Metric, error) {
+	f.exprs = append(f.exprs, expr)
+	var name string
+	switch {
+	case strings.Contains(expr, "container_cpu_usage_seconds_total"):
+		name = "cpu"
+	case strings.HasPrefix(expr, "max_over_time"):
+		name = "memoryPeak"
+	case strings.Contains(expr, "container_memory_working_set_bytes"):
+		name = "memory"
+	default:
+		name = "network"
+	}
+
+	metric := monitoring.Metric{}
+	for key, value := range f.usage[name] {
+		metadata := map[string]string{"namespace": namespace}
+		pod, container, _ := strings.Cut(key, "/")
+		metadata["pod"] = pod
+		if container != "" {
+			metadata["container"] = container
+		}
+		metric.MetricValues = append(metric.MetricValues, monitoring.MetricValue{
+			Metadata: metadata,
+			Sample:   &monitoring.Point{float64(t.Unix()), value},
+		})
+	}
+	return metric, nil
+}
+
+func newRecommendationInformers(t *testing.T, objects ...runtime.Object) k8sinformers.SharedInformerFactory {
+	informers := k8sinformers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	for _, object := range objects {
+		var err error
+		switch o := object.(type) {
+		case *appsv1.Deployment:
+			err = informers.Apps().V1().Deployments().Informer().GetIndexer().Add(o)
+		case *appsv1.ReplicaSet:
+			err = informers.Apps().V1().ReplicaSets().Informer().GetIndexer().Add(o)
+		case *appsv1.StatefulSet:
+			err = informers.Apps().V1().StatefulSets().Informer().GetIndexer().Add(o)
+		case *appsv1.DaemonSet:
+			err = informers.Apps().V1().DaemonSets().Informer().GetIndexer().Add(o)
+		case *corev1.Pod:
+			err = informers.Core().V1().Pods().Informer().GetIndexer().Add(o)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return informers
+}
+
+func ownedBy(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func podTemplate(container string, requirements corev1.ResourceRequirements) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: container, Resources: requirements}}}}
+}
+
+func TestRecommendWorkloads(t *testing.T) {
+	replicas := int32(2)
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: podTemplate("app", corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+			}),
+		},
+	}
+	db := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"},
+		Spec:       appsv1.StatefulSetSpec{Template: podTemplate("db", corev1.ResourceRequirements{})},
+	}
+	agent := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "test"},
+		Spec: appsv1.DaemonSetSpec{Template: podTemplate("agent", corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+		})},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 1},
+	}
+	informers := newRecommendationInformers(t, web, db, agent,
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "test", OwnerReferences: ownedBy("Deployment", "web")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-abc-1", Namespace: "test", OwnerReferences: ownedBy("ReplicaSet", "web-abc")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-abc-2", Namespace: "test", OwnerReferences: ownedBy("ReplicaSet", "web-abc")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "test", OwnerReferences: ownedBy("StatefulSet", "db")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "agent-x", Namespace: "test", OwnerReferences: ownedBy("DaemonSet", "agent")}},
+	)
+
+	mo := &fakeUsageMonitoringOperator{usage: map[string]map[string]float64{
+		"cpu":        {"web-abc-1/app": 0.2, "web-abc-2/app": 0.1, "db-0/db": 0.5, "agent-x/agent": 0.001},
+		"memory":     {"web-abc-1/app": 256 << 20, "web-abc-2/app": 128 << 20, "db-0/db": 1 << 30, "agent-x/agent": 16 << 20},
+		"memoryPeak": {"web-abc-1/app": 400 << 20, "db-0/db": 1 << 30, "agent-x/agent": 16 << 20},
+		"network":    {"web-abc-1": 5000, "db-0": 10000, "agent-x": 10},
+	}}
+	r := NewRecommender(mo, informers, meteringclient.PriceInfo{CpuPerCorePerHour: 1, MemPerGigabytesPerHour: 0.5, CurrencyUnit: "CNY"})
+
+	result, err := r.RecommendWorkloads("test", RecommendationOptions{Window: 24 * time.Hour, Percentile: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(mo.exprs[0], "quantile_over_time(0.9,") || !strings.Contains(mo.exprs[0], "[1d:5m]") {
+		t.Errorf("unexpected query %s", mo.exprs[0])
+	}
+	if result.Window != "1d" || result.Currency != "CNY" || len(result.Items) != 3 {
+		t.Fatalf("unexpected recommendations %v", result)
+	}
+
+	// the most savings first
+	expected := []struct {
+		name      string
+		typ       RecommendationType
+		costDelta float64
+		requests  string
+		limits    string
+	}{
+		{"web", RecommendationOverProvisioned, -1621.378, "cpu=230m,memory=295Mi", "cpu=460m,memory=460Mi"},
+		{"agent", RecommendationIdle, -94.5, "cpu=10m,memory=32Mi", ""},
+		{"db", RecommendationUnderProvisioned, 828.141, "cpu=575m,memory=1178Mi", ""},
+	}
+	for i, e := range expected {
+		item := result.Items[i]
+		if item.Name != e.name || item.Type != e.typ || item.MonthlyCostDelta != e.costDelta {
+			t.Errorf("unexpected recommendation %s %s %v, expected %v", item.Name, item.Type, item.MonthlyCostDelta, e)
+		}
+		recommended := item.Containers[0].Recommended
+		if requests := resourceListString(recommended.Requests); requests != e.requests {
+			t.Errorf("unexpected requests %s of %s", requests, item.Name)
+		}
+		if limits := resourceListString(recommended.Limits); limits != e.limits {
+			t.Errorf("unexpected limits %s of %s", limits, item.Name)
+		}
+	}
+	if result.MonthlyCostDelta != -887.737 {
+		t.Errorf("unexpected total cost delta %v", result.MonthlyCostDelta)
+	}
+
+	if _, err := r.RecommendWorkloads("test", RecommendationOptions{Kind: "job"}); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func resourceListString(list corev1.ResourceList) string {
+	var s []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if q, ok := list[name]; ok {
+			s = append(s, string(name)+"="+q.String())
+		}
+	}
+	return strings.Join(s, ",")
+}
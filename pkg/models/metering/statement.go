@@ -0,0 +1,538 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// StatementPeriod returns the monthly period of the statement covering the time.
+func StatementPeriod(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// StatementName returns the name of the statement of the workspace for the period starting at start.
+func StatementName(workspace string, start time.Time) string {
+	return fmt.Sprintf("%s-%s", workspace, start.UTC().Format("200601"))
+}
+
+// StatementCalculator rolls the metering data of workspaces up into statements.
+type StatementCalculator interface {
+	// Calculate adds the usage since the statement was last calculated up to end to the statement,
+	// then prices the usage of the whole period. As the usage is accumulated rather than queried again,
+	// the statement keeps the usage of the metering data aged out.
+	Calculate(ctx context.Context, statement *meteringapiv1alpha1.Statement, end time.Time) error
+}
+
+type statementCalculator struct {
+	usageQuerier
+}
+
+func NewStatementCalculator(mo monitoringmodel.MonitoringOperator, reader client.Reader, priceInfo meteringclient.PriceInfo) StatementCalculator {
+	return &statementCalculator{
+		usageQuerier: usageQuerier{
+			mo:        mo,
+			reader:    reader,
+			priceInfo: priceInfo,
+		},
+	}
+}
+
+func (c *statementCalculator) Calculate(ctx context.Context, statement *meteringapiv1alpha1.Statement, end time.Time) error {
+	status := &statement.Status
+
+	// meters are hourly, the usage of the hours up to end is rolled up only once
+	start := statement.Spec.Start.Time
+	if status.CalculatedUntil != nil {
+		start = status.CalculatedUntil.Time
+	}
+	if statement.Spec.End.Time.Before(end) {
+		end = statement.Spec.End.Time
+	}
+	end = end.Truncate(time.Hour)
+
+	rateCard, err := workspaceRateCard(ctx, c.reader, statement.Spec.Workspace)
+	if err != nil {
+		return err
+	}
+
+	if end.After(start) {
+		opt := monitoring.NamespaceOption{WorkspaceName: statement.Spec.Workspace, ResourceFilter: ".*"}
+		usage, err := c.queryNamespaceUsage(ctx, opt, start, end, rateCard)
+		if err != nil {
+			return err
+		}
+		addNamespaceUsage(status, usage)
+		calculatedUntil := metav1.NewTime(end)
+		status.CalculatedUntil = &calculatedUntil
+	}
+
+	priceStatement(status, rateCard, c.priceInfo)
+	return nil
+}
+
+// workspaceRateCard returns the rate card applied to the workspace, nil if the global prices apply.
+func workspaceRateCard(ctx context.Context, reader client.Reader, workspace string) (*meteringapiv1alpha1.RateCard, error) {
+	rateCardList := &meteringapiv1alpha1.RateCardList{}
+	if err := reader.List(ctx, rateCardList); err != nil {
+		return nil, err
+	}
+	rateCards := rateCardList.Items
+	sort.Slice(rateCards, func(i, j int) bool {
+		return rateCards[i].Name < rateCards[j].Name
+	})
+	return FindRateCard(rateCards, workspace), nil
+}
+
+// usageQuerier queries the usage in the units the resources are priced by from the meters.
+type usageQuerier struct {
+	mo        monitoringmodel.MonitoringOperator
+	reader    client.Reader
+	priceInfo meteringclient.PriceInfo
+}
+
+// queryNamespaceUsage returns the usage of the namespaces queried with opt in the time range (start, end],
+// broken down by the node pools and storage classes the rate card has prices for.
+func (q *usageQuerier) queryNamespaceUsage(ctx context.Context, opt monitoring.NamespaceOption, start, end time.Time,
+	rateCard *meteringapiv1alpha1.RateCard) (map[string]meteringapiv1alpha1.NamespaceStatement, error) {
+	keyOf := func(mv monitoring.MetricValue) (string, bool) {
+		namespace := mv.Metadata["namespace"]
+		return namespace, namespace != ""
+	}
+	usage, err := q.queryUsage(metersOf(monitoringmodel.NamespaceMetrics), opt, keyOf, start, end, rateCard)
+	if err != nil || rateCard == nil || len(rateCard.Spec.NodePools) == 0 {
+		return usage, err
+	}
+
+	meters := []string{"meter_namespace_cpu_usage", "meter_namespace_memory_usage_wo_cache", "meter_namespace_gpu_usage"}
+	requests, err := q.mo.GetNamedMetersOverTime(meters, start, end, 24*time.Hour, monitoring.NodeBreakdownOption{QueryOption: opt}, q.priceInfo)
+	if err != nil {
+		return nil, err
+	}
+	// the requests of the namespaces on the nodes, as the usage of namespaces is not recorded by node
+	if err := q.splitNodePoolUsage(ctx, usage, requests, keyOf, rateCard); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// queryUsage returns the usage of the meters queried with opt in the time range (start, end] grouped by keyOf,
+// the PVC usage is broken down by storage class if the rate card prices storage classes.
+func (q *usageQuerier) queryUsage(meters []string, opt monitoring.QueryOption, keyOf func(monitoring.MetricValue) (string, bool),
+	start, end time.Time, rateCard *meteringapiv1alpha1.RateCard) (map[string]meteringapiv1alpha1.NamespaceStatement, error) {
+	// a step of one day is required by the time ranges longer than 30 days, the usage is summed up anyway
+	metrics, err := q.mo.GetNamedMetersOverTime(meters, start, end, 24*time.Hour, opt, q.priceInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]meteringapiv1alpha1.NamespaceStatement)
+	err = collectUsage(metrics, func(mv monitoring.MetricValue, resourceType int, value float64) {
+		key, ok := keyOf(mv)
+		if !ok {
+			return
+		}
+		u := usage[key]
+		*usageOf(&u.Usage, resourceType) += value
+		usage[key] = u
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rateCard == nil || len(rateCard.Spec.StorageClasses) == 0 {
+		return usage, nil
+	}
+
+	var pvcMeters []string
+	for _, meter := range meters {
+		if monitoringmodel.MeterResourceMap[meter] == monitoringmodel.METER_RESOURCE_TYPE_PVC {
+			pvcMeters = append(pvcMeters, meter)
+		}
+	}
+	metrics, err = q.mo.GetNamedMetersOverTime(pvcMeters, start, end, 24*time.Hour,
+		monitoring.StorageClassBreakdownOption{QueryOption: opt}, q.priceInfo)
+	if err != nil {
+		return nil, err
+	}
+	err = collectUsage(metrics, func(mv monitoring.MetricValue, _ int, value float64) {
+		key, _ := keyOf(mv)
+		storageClass := mv.Metadata["storageclass"]
+		u, ok := usage[key]
+		if !ok || storageClass == "" {
+			return
+		}
+		if u.StorageClasses == nil {
+			u.StorageClasses = make(map[string]float64)
+		}
+		u.StorageClasses[storageClass] += value
+		usage[key] = u
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// splitNodePoolUsage splits the CPU, memory and GPU usage across the node pools of the rate card in proportion
+// to the breakdown, which is the usage or requests on every node grouped by keyOf with node labels.
+func (q *usageQuerier) splitNodePoolUsage(ctx context.Context, usage map[string]meteringapiv1alpha1.NamespaceStatement,
+	breakdown monitoringmodel.Metrics, keyOf func(monitoring.MetricValue) (string, bool), rateCard *meteringapiv1alpha1.RateCard) error {
+	nodePools := make(map[string]string)
+	// the breakdown on the nodes of every node pool, and on all nodes
+	shares := make(map[string]map[string]meteringapiv1alpha1.StatementUsage)
+	totals := make(map[string]meteringapiv1alpha1.StatementUsage)
+	err := collectUsage(breakdown, func(mv monitoring.MetricValue, resourceType int, value float64) {
+		key, _ := keyOf(mv)
+		node := mv.Metadata["node"]
+		if _, ok := usage[key]; !ok || node == "" {
+			return
+		}
+		total := totals[key]
+		*usageOf(&total, resourceType) += value
+		totals[key] = total
+
+		pool, ok := nodePools[node]
+		if !ok {
+			pool = q.nodePoolOf(ctx, rateCard, node)
+			nodePools[node] = pool
+		}
+		if pool == "" {
+			return
+		}
+		if shares[key] == nil {
+			shares[key] = make(map[string]meteringapiv1alpha1.StatementUsage)
+		}
+		share := shares[key][pool]
+		*usageOf(&share, resourceType) += value
+		shares[key][pool] = share
+	})
+	if err != nil {
+		return err
+	}
+
+	for key, pools := range shares {
+		u, total := usage[key], totals[key]
+		for pool, share := range pools {
+			for _, resourceType := range nodePoolResourceTypes {
+				if *usageOf(&total, resourceType) == 0 {
+					continue
+				}
+				ratio := *usageOf(&share, resourceType) / *usageOf(&total, resourceType)
+				*usageOf(&share, resourceType) = *usageOf(&u.Usage, resourceType) * ratio
+			}
+			if u.NodePools == nil {
+				u.NodePools = make(map[string]meteringapiv1alpha1.StatementUsage)
+			}
+			u.NodePools[pool] = share
+		}
+		usage[key] = u
+	}
+	return nil
+}
+
+// nodePoolOf returns the name of the node pool of the rate card the node belongs to, empty if none
+// or the node is gone.
+func (q *usageQuerier) nodePoolOf(ctx context.Context, rateCard *meteringapiv1alpha1.RateCard, name string) string {
+	node := &corev1.Node{}
+	if err := q.reader.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Warningf("failed to get node %s: %s", name, err)
+		}
+		return ""
+	}
+	nodeLabels := node.Labels
+	if nodeLabels == nil {
+		// nodes without labels still match the node pools with empty selectors
+		nodeLabels = map[string]string{}
+	}
+	if pool := NodePoolOf(rateCard, nodeLabels); pool != nil {
+		return pool.Name
+	}
+	return ""
+}
+
+func metersOf(metrics []string) []string {
+	var meters []string
+	for _, metric := range metrics {
+		if strings.HasPrefix(metric, monitoringmodel.MetricMeterPrefix) {
+			meters = append(meters, metric)
+		}
+	}
+	return meters
+}
+
+// collectUsage passes the usage of every metric value of the meters in the units the resources are priced by to add.
+func collectUsage(metrics monitoringmodel.Metrics, add func(mv monitoring.MetricValue, resourceType int, value float64)) error {
+	for _, metric := range metrics.Results {
+		if metric.Error != "" {
+			return fmt.Errorf("failed to query %s: %s", metric.MetricName, metric.Error)
+		}
+		for _, mv := range metric.MetricValues {
+			if mv.SumValue == "" {
+				continue
+			}
+			resourceType, value, err := monitoringmodel.GetMeterUsage(metric.MetricName, mv.SumValue)
+			if err != nil {
+				klog.Error(err)
+				continue
+			}
+			add(mv, resourceType, value)
+		}
+	}
+	return nil
+}
+
+func addNamespaceUsage(status *meteringapiv1alpha1.StatementStatus, usage map[string]meteringapiv1alpha1.NamespaceStatement) {
+	for i := range status.Namespaces {
+		ns := &status.Namespaces[i]
+		if u, ok := usage[ns.Name]; ok {
+			addNamespaceStatementUsage(ns, u)
+			delete(usage, ns.Name)
+		}
+	}
+	for name, u := range usage {
+		ns := meteringapiv1alpha1.NamespaceStatement{Name: name}
+		addNamespaceStatementUsage(&ns, u)
+		status.Namespaces = append(status.Namespaces, ns)
+	}
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Name < status.Namespaces[j].Name
+	})
+}
+
+func addNamespaceStatementUsage(ns *meteringapiv1alpha1.NamespaceStatement, other meteringapiv1alpha1.NamespaceStatement) {
+	addUsage(&ns.Usage, other.Usage)
+	for pool, u := range other.NodePools {
+		if ns.NodePools == nil {
+			ns.NodePools = make(map[string]meteringapiv1alpha1.StatementUsage)
+		}
+		poolUsage := ns.NodePools[pool]
+		addUsage(&poolUsage, u)
+		ns.NodePools[pool] = poolUsage
+	}
+	for storageClass, u := range other.StorageClasses {
+		if ns.StorageClasses == nil {
+			ns.StorageClasses = make(map[string]float64)
+		}
+		ns.StorageClasses[storageClass] += u
+	}
+}
+
+// nodePoolResourceTypes are the resources priced by node pool.
+var nodePoolResourceTypes = []int{
+	monitoringmodel.METER_RESOURCE_TYPE_CPU,
+	monitoringmodel.METER_RESOURCE_TYPE_MEM,
+	monitoringmodel.METER_RESOURCE_TYPE_GPU,
+}
+
+var resourceTypes = []int{
+	monitoringmodel.METER_RESOURCE_TYPE_CPU,
+	monitoringmodel.METER_RESOURCE_TYPE_MEM,
+	monitoringmodel.METER_RESOURCE_TYPE_GPU,
+	monitoringmodel.METER_RESOURCE_TYPE_NET_INGRESS,
+	monitoringmodel.METER_RESOURCE_TYPE_NET_EGRESS,
+	monitoringmodel.METER_RESOURCE_TYPE_PVC,
+}
+
+func usageOf(u *meteringapiv1alpha1.StatementUsage, resourceType int) *float64 {
+	switch resourceType {
+	case monitoringmodel.METER_RESOURCE_TYPE_CPU:
+		return &u.CPU
+	case monitoringmodel.METER_RESOURCE_TYPE_MEM:
+		return &u.Memory
+	case monitoringmodel.METER_RESOURCE_TYPE_GPU:
+		return &u.GPU
+	case monitoringmodel.METER_RESOURCE_TYPE_NET_INGRESS:
+		return &u.NetworkIngress
+	case monitoringmodel.METER_RESOURCE_TYPE_NET_EGRESS:
+		return &u.NetworkEgress
+	default:
+		return &u.PVC
+	}
+}
+
+func addUsage(u *meteringapiv1alpha1.StatementUsage, other meteringapiv1alpha1.StatementUsage) {
+	for _, resourceType := range resourceTypes {
+		*usageOf(u, resourceType) += *usageOf(&other, resourceType)
+	}
+}
+
+func globalPrice(priceInfo meteringclient.PriceInfo, resourceType int) float64 {
+	return map[int]float64{
+		monitoringmodel.METER_RESOURCE_TYPE_CPU:         priceInfo.CpuPerCorePerHour,
+		monitoringmodel.METER_RESOURCE_TYPE_MEM:         priceInfo.MemPerGigabytesPerHour,
+		monitoringmodel.METER_RESOURCE_TYPE_GPU:         priceInfo.GpuPerGpuPerHour,
+		monitoringmodel.METER_RESOURCE_TYPE_NET_INGRESS: priceInfo.IngressNetworkTrafficPerMegabytesPerHour,
+		monitoringmodel.METER_RESOURCE_TYPE_NET_EGRESS:  priceInfo.EgressNetworkTrafficPerMegabytesPerHour,
+		monitoringmodel.METER_RESOURCE_TYPE_PVC:         priceInfo.PvcPerGigabytesPerHour,
+	}[resourceType]
+}
+
+// priceStatement computes the fees of the accumulated usage of the namespaces, so the tiers of
+// the rate card apply to the usage of the whole period.
+func priceStatement(status *meteringapiv1alpha1.StatementStatus, rateCard *meteringapiv1alpha1.RateCard, priceInfo meteringclient.PriceInfo) {
+	status.Currency = currencyOf(rateCard, priceInfo)
+	status.Fee = 0
+	status.Usage = meteringapiv1alpha1.StatementUsage{}
+	status.RateCards = nil
+
+	for i := range status.Namespaces {
+		ns := &status.Namespaces[i]
+		for _, resourceType := range resourceTypes {
+			*usageOf(&ns.Usage, resourceType) = round(*usageOf(&ns.Usage, resourceType))
+		}
+		for pool, u := range ns.NodePools {
+			for _, resourceType := range nodePoolResourceTypes {
+				*usageOf(&u, resourceType) = round(*usageOf(&u, resourceType))
+			}
+			ns.NodePools[pool] = u
+		}
+		for storageClass, u := range ns.StorageClasses {
+			ns.StorageClasses[storageClass] = round(u)
+		}
+		fee, rated := priceUsage(ns, rateCard, priceInfo)
+		ns.Fee = round(fee)
+		ns.RateCards = nil
+		if rated {
+			ns.RateCards = []string{rateCard.Name}
+			status.RateCards = ns.RateCards
+		}
+
+		addUsage(&status.Usage, ns.Usage)
+		status.Fee += fee
+	}
+
+	status.Fee = round(status.Fee)
+	for _, resourceType := range resourceTypes {
+		*usageOf(&status.Usage, resourceType) = round(*usageOf(&status.Usage, resourceType))
+	}
+}
+
+func currencyOf(rateCard *meteringapiv1alpha1.RateCard, priceInfo meteringclient.PriceInfo) string {
+	if rateCard != nil && rateCard.Spec.Currency != "" {
+		return rateCard.Spec.Currency
+	}
+	return priceInfo.CurrencyUnit
+}
+
+// priceUsage returns the fee of the usage of the namespace, the resources are priced by the rate card if it has
+// a rate for them, or by the global prices otherwise. The parts of the usage on the node pools or of the storage
+// classes the rate card has prices for are charged at those prices.
+func priceUsage(ns *meteringapiv1alpha1.NamespaceStatement, rateCard *meteringapiv1alpha1.RateCard, priceInfo meteringclient.PriceInfo) (fee float64, rated bool) {
+	for _, resourceType := range resourceTypes {
+		u := *usageOf(&ns.Usage, resourceType)
+		if u == 0 {
+			continue
+		}
+		if rateCard != nil {
+			ctx := RateContext{StorageClassUsage: ns.StorageClasses}
+			if len(ns.NodePools) > 0 {
+				ctx.NodePoolUsage = make(map[string]float64, len(ns.NodePools))
+				for pool, poolUsage := range ns.NodePools {
+					ctx.NodePoolUsage[pool] = *usageOf(&poolUsage, resourceType)
+				}
+			}
+			if f, _, ok := ChargeUsage(rateCard, resourceType, u, ctx, priceInfo); ok {
+				fee += f
+				rated = true
+				continue
+			}
+		}
+		fee += u * globalPrice(priceInfo, resourceType)
+	}
+	return fee, rated
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+var statementCSVHeader = []string{"namespace", "cpu_core_hours", "memory_gigabyte_hours", "gpu_hours",
+	"net_ingress_megabytes", "net_egress_megabytes", "pvc_gigabyte_hours", "fee", "currency", "rate_cards"}
+
+// WriteStatementCSV writes the statement as CSV, a row per namespace followed by the total of the workspace.
+func WriteStatementCSV(w io.Writer, statement *meteringapiv1alpha1.Statement) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(statementCSVHeader); err != nil {
+		return err
+	}
+
+	row := func(name string, usage meteringapiv1alpha1.StatementUsage, fee float64, rateCards []string) []string {
+		return []string{name, formatFloat(usage.CPU), formatFloat(usage.Memory), formatFloat(usage.GPU),
+			formatFloat(usage.NetworkIngress), formatFloat(usage.NetworkEgress), formatFloat(usage.PVC),
+			formatFloat(fee), statement.Status.Currency, strings.Join(rateCards, " ")}
+	}
+	for _, ns := range statement.Status.Namespaces {
+		if err := writer.Write(row(ns.Name, ns.Usage, ns.Fee, ns.RateCards)); err != nil {
+			return err
+		}
+	}
+	status := statement.Status
+	if err := writer.Write(row("total", status.Usage, status.Fee, status.RateCards)); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// IsStatementClosed returns whether the statement is immutable.
+func IsStatementClosed(statement *meteringapiv1alpha1.Statement) bool {
+	return statement.Status.Phase == meteringapiv1alpha1.StatementClosed
+}
+
+// NewStatement returns an open statement of the workspace for the period starting at start.
+func NewStatement(workspace string, start, end time.Time) *meteringapiv1alpha1.Statement {
+	return &meteringapiv1alpha1.Statement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   StatementName(workspace, start),
+			Labels: map[string]string{constants.WorkspaceLabelKey: workspace},
+		},
+		Spec: meteringapiv1alpha1.StatementSpec{
+			Workspace: workspace,
+			Start:     metav1.NewTime(start),
+			End:       metav1.NewTime(end),
+		},
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+	resourcesv1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
+)
+
+// StatementOperator lists the statements of workspaces and requests them to be closed or recalculated,
+// the statements are calculated by the statement controller.
+type StatementOperator interface {
+	ListStatements(ctx context.Context, workspace string, q *query.Query) (*api.ListResult, error)
+	GetStatement(ctx context.Context, workspace, name string) (*meteringapiv1alpha1.Statement, error)
+	CloseStatement(ctx context.Context, workspace, name string) (*meteringapiv1alpha1.Statement, error)
+	RecalculateStatement(ctx context.Context, workspace, name string) (*meteringapiv1alpha1.Statement, error)
+}
+
+type statementOperator struct {
+	client client.Client
+	// retention of the metering data, the statements of the periods beyond it can't be recalculated
+	retention time.Duration
+	now       func() time.Time
+}
+
+func NewStatementOperator(client client.Client, retention time.Duration) StatementOperator {
+	return &statementOperator{
+		client:    client,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (o *statementOperator) ListStatements(ctx context.Context, workspace string, q *query.Query) (*api.ListResult, error) {
+	statements := &meteringapiv1alpha1.StatementList{}
+	if err := o.client.List(ctx, statements, client.MatchingLabels{constants.WorkspaceLabelKey: workspace}); err != nil {
+		return nil, err
+	}
+
+	var objects []runtime.Object
+	for i := range statements.Items {
+		objects = append(objects, &statements.Items[i])
+	}
+	return resourcesv1alpha3.DefaultList(objects, q, compareStatement, filterStatement), nil
+}
+
+func compareStatement(left, right runtime.Object, field query.Field) bool {
+	leftStatement, ok := left.(*meteringapiv1alpha1.Statement)
+	if !ok {
+		return false
+	}
+	rightStatement, ok := right.(*meteringapiv1alpha1.Statement)
+	if !ok {
+		return false
+	}
+	// the latest period first by default
+	if field == query.FieldCreationTimeStamp || field == query.FieldCreateTime {
+		return leftStatement.Spec.Start.After(rightStatement.Spec.Start.Time)
+	}
+	return resourcesv1alpha3.DefaultObjectMetaCompare(leftStatement.ObjectMeta, rightStatement.ObjectMeta, field)
+}
+
+func filterStatement(object runtime.Object, filter query.Filter) bool {
+	statement, ok := object.(*meteringapiv1alpha1.Statement)
+	if !ok {
+		return false
+	}
+	if filter.Field == query.FieldStatus {
+		return string(statement.Status.Phase) == string(filter.Value)
+	}
+	return resourcesv1alpha3.DefaultObjectMetaFilter(statement.ObjectMeta, filter)
+}
+
+func (o *statementOperator) GetStatement(ctx context.Context, workspace, name string) (*meteringapiv1alpha1.Statement, error) {
+	statement := &meteringapiv1alpha1.Statement{}
+	if err := o.client.Get(ctx, types.NamespacedName{Name: name}, statement); err != nil {
+		return nil, err
+	}
+	// statements are cluster scoped, hide the statements of the other workspaces
+	if statement.Spec.Workspace != workspace {
+		return nil, errors.NewNotFound(meteringapiv1alpha1.Resource(meteringapiv1alpha1.ResourcePluralStatement), name)
+	}
+	return statement, nil
+}
+
+func (o *statementOperator) CloseStatement(ctx context.Context, workspace, name string) (*meteringapiv1alpha1.Statement, error) {
+	statement, err := o.GetStatement(ctx, workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if IsStatementClosed(statement) || statement.Spec.Closed {
+		return statement, nil
+	}
+
+	statement = statement.DeepCopy()
+	statement.Spec.Closed = true
+	if err := o.client.Update(ctx, statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func (o *statementOperator) RecalculateStatement(ctx context.Context, workspace, name string) (*meteringapiv1alpha1.Statement, error) {
+	statement, err := o.GetStatement(ctx, workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	resource := meteringapiv1alpha1.Resource(meteringapiv1alpha1.ResourcePluralStatement)
+	if IsStatementClosed(statement) {
+		return nil, errors.NewConflict(resource, name, fmt.Errorf("statement is closed"))
+	}
+	if o.retention > 0 && statement.Spec.Start.Time.Before(o.now().Add(-o.retention)) {
+		return nil, errors.NewBadRequest(fmt.Sprintf("the metering data of statement %s has aged out", name))
+	}
+
+	statement = statement.DeepCopy()
+	if statement.Annotations == nil {
+		statement.Annotations = make(map[string]string)
+	}
+	statement.Annotations[meteringapiv1alpha1.StatementRecalculateAnnotation] = o.now().UTC().Format(time.RFC3339)
+	if err := o.client.Update(ctx, statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type fakeMonitoringOperator struct {
+	monitoringmodel.MonitoringOperator
+	// cpu usage per hour of the namespaces
+	cpuUsage map[string]float64
+	queries  [][2]time.Time
+}
+
+func (f *fakeMonitoringOperator) GetNamedMetersOverTime(meters []string, start, end time.Time, step time.Duration,
+	opt monitoring.QueryOption, priceInfo meteringclient.PriceInfo) (monitoringmodel.Metrics, error) {
+	f.queries = append(f.queries, [2]time.Time{start, end})
+
+	hours := end.Sub(start).Hours()
+	metric := monitoring.Metric{MetricName: "meter_namespace_cpu_usage"}
+	for namespace, usage := range f.cpuUsage {
+		metric.MetricValues = append(metric.MetricValues, monitoring.MetricValue{
+			Metadata: map[string]string{"namespace": namespace},
+			SumValue: formatFloat(usage * hours),
+		})
+	}
+	return monitoringmodel.Metrics{Results: []monitoring.Metric{metric}}, nil
+}
+
+func TestStatementPeriod(t *testing.T) {
+	start, end := StatementPeriod(time.Date(2023, 12, 15, 8, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected period [%s, %s)", start, end)
+	}
+	if name := StatementName("test-workspace", start); name != "test-workspace-202312" {
+		t.Fatalf("unexpected statement name %s", name)
+	}
+}
+
+func TestCalculateStatement(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringapiv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	rateCard := &meteringapiv1alpha1.RateCard{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Currency:   "USD",
+			Workspaces: []string{"test-workspace"},
+			CPU: &meteringapiv1alpha1.Rate{
+				Price: 0.1,
+				Tiers: []meteringapiv1alpha1.RateTier{{From: 100, Price: 0.05}},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).WithObjects(rateCard).Build()
+	mo := &fakeMonitoringOperator{cpuUsage: map[string]float64{"ns-a": 1, "ns-b": 0.5}}
+	calculator := NewStatementCalculator(mo, c, meteringclient.PriceInfo{CpuPerCorePerHour: 1, MemPerGigabytesPerHour: 1, CurrencyUnit: "CNY"})
+
+	start, end := StatementPeriod(time.Date(2023, 12, 15, 8, 0, 0, 0, time.UTC))
+	statement := NewStatement("test-workspace", start, end)
+
+	// the usage of the first 3 days
+	if err := calculator.Calculate(context.Background(), statement, start.Add(72*time.Hour+30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	// the usage of the next 3 days is added, the tier applies to the usage of the whole period
+	if err := calculator.Calculate(context.Background(), statement, start.Add(144*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedQueries := [][2]time.Time{
+		{start, start.Add(72 * time.Hour)},
+		{start.Add(72 * time.Hour), start.Add(144 * time.Hour)},
+	}
+	if diff := cmp.Diff(mo.queries, expectedQueries); diff != "" {
+		t.Fatalf("queries differ (-got, +want): %s", diff)
+	}
+
+	calculatedUntil := metav1.NewTime(start.Add(144 * time.Hour))
+	expected := meteringapiv1alpha1.StatementStatus{
+		Currency:  "USD",
+		Fee:       19.4,
+		Usage:     meteringapiv1alpha1.StatementUsage{CPU: 216},
+		RateCards: []string{"enterprise"},
+		Namespaces: []meteringapiv1alpha1.NamespaceStatement{
+			{Name: "ns-a", Fee: 12.2, Usage: meteringapiv1alpha1.StatementUsage{CPU: 144}, RateCards: []string{"enterprise"}},
+			{Name: "ns-b", Fee: 7.2, Usage: meteringapiv1alpha1.StatementUsage{CPU: 72}, RateCards: []string{"enterprise"}},
+		},
+		CalculatedUntil: &calculatedUntil,
+	}
+	if diff := cmp.Diff(statement.Status, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	// the usage is not rolled up twice
+	if err := calculator.Calculate(context.Background(), statement, start.Add(144*time.Hour+59*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(mo.queries) != 2 || statement.Status.Usage.CPU != 216 {
+		t.Fatalf("unexpected recalculation %v, %v", mo.queries, statement.Status.Usage)
+	}
+}
+
+// fakeBreakdownOperator returns the usage of the meters of ns-a, broken down by node or storage class
+// as the options ask for.
+type fakeBreakdownOperator struct {
+	monitoringmodel.MonitoringOperator
+}
+
+func (f *fakeBreakdownOperator) GetNamedMetersOverTime(meters []string, start, end time.Time, step time.Duration,
+	opt monitoring.QueryOption, priceInfo meteringclient.PriceInfo) (monitoringmodel.Metrics, error) {
+	opts := monitoring.NewQueryOptions()
+	opt.Apply(opts)
+
+	value := func(name string, metadata map[string]string, sum string) monitoring.Metric {
+		metadata["namespace"] = "ns-a"
+		return monitoring.Metric{
+			MetricName: name,
+			MetricData: monitoring.MetricData{MetricValues: []monitoring.MetricValue{{Metadata: metadata, SumValue: sum}}},
+		}
+	}
+	switch {
+	case opts.ByNode:
+		// the cpu requests on the nodes
+		return monitoringmodel.Metrics{Results: []monitoring.Metric{
+			value("meter_namespace_cpu_usage", map[string]string{"node": "node-a"}, "3"),
+			value("meter_namespace_cpu_usage", map[string]string{"node": "node-b"}, "1"),
+		}}, nil
+	case opts.ByStorageClass:
+		return monitoringmodel.Metrics{Results: []monitoring.Metric{
+			value("meter_namespace_pvc_bytes_total", map[string]string{"storageclass": "ssd"}, "4294967296"),
+			value("meter_namespace_pvc_bytes_total", map[string]string{"storageclass": "standard"}, "6442450944"),
+		}}, nil
+	default:
+		return monitoringmodel.Metrics{Results: []monitoring.Metric{
+			value("meter_namespace_cpu_usage", map[string]string{}, "10"),
+			value("meter_namespace_pvc_bytes_total", map[string]string{}, "10737418240"),
+		}}, nil
+	}
+}
+
+func TestCalculateStatementBreakdown(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringapiv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	rateCard := &meteringapiv1alpha1.RateCard{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Currency: "USD",
+			CPU:      &meteringapiv1alpha1.Rate{Price: 0.1},
+			PVC:      &meteringapiv1alpha1.Rate{Price: 0.01},
+			NodePools: []meteringapiv1alpha1.NodePoolRate{
+				{
+					Name:         "highmem",
+					NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "highmem"}},
+					CPU:          &meteringapiv1alpha1.Rate{Price: 0.2},
+				},
+			},
+			StorageClasses: []meteringapiv1alpha1.StorageClassRate{
+				{StorageClassName: "ssd", PVC: meteringapiv1alpha1.Rate{Price: 0.05}},
+			},
+		},
+	}
+	nodes := []client.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"pool": "highmem"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).WithObjects(rateCard).WithObjects(nodes...).Build()
+	calculator := NewStatementCalculator(&fakeBreakdownOperator{}, c, meteringclient.PriceInfo{CurrencyUnit: "CNY"})
+
+	start, end := StatementPeriod(time.Date(2023, 12, 15, 8, 0, 0, 0, time.UTC))
+	statement := NewStatement("test-workspace", start, end)
+	if err := calculator.Calculate(context.Background(), statement, start.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3/4 of the cpu usage is on the highmem pool: 7.5*0.2 + 2.5*0.1, the ssd part of the pvc usage: 4*0.05 + 6*0.01
+	expected := []meteringapiv1alpha1.NamespaceStatement{
+		{
+			Name:           "ns-a",
+			Fee:            2.01,
+			Usage:          meteringapiv1alpha1.StatementUsage{CPU: 10, PVC: 10},
+			RateCards:      []string{"enterprise"},
+			NodePools:      map[string]meteringapiv1alpha1.StatementUsage{"highmem": {CPU: 7.5}},
+			StorageClasses: map[string]float64{"ssd": 4, "standard": 6},
+		},
+	}
+	if diff := cmp.Diff(statement.Status.Namespaces, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestWriteStatementCSV(t *testing.T) {
+	statement := &meteringapiv1alpha1.Statement{
+		Status: meteringapiv1alpha1.StatementStatus{
+			Currency: "CNY",
+			Fee:      3.5,
+			Usage:    meteringapiv1alpha1.StatementUsage{CPU: 2, Memory: 1.5},
+			Namespaces: []meteringapiv1alpha1.NamespaceStatement{
+				{Name: "ns-a", Fee: 3.5, Usage: meteringapiv1alpha1.StatementUsage{CPU: 2, Memory: 1.5}, RateCards: []string{"default"}},
+			},
+			RateCards: []string{"default"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteStatementCSV(buf, statement); err != nil {
+		t.Fatal(err)
+	}
+	expected := `namespace,cpu_core_hours,memory_gigabyte_hours,gpu_hours,net_ingress_megabytes,net_egress_megabytes,pvc_gigabyte_hours,fee,currency,rate_cards
+ns-a,2,1.5,0,0,0,0,3.5,CNY,default
+total,2,1.5,0,0,0,0,3.5,CNY,default
+`
+	if diff := cmp.Diff(buf.String(), expected); diff != "" {
+		t.Fatalf("csv differ (-got, +want): %s", diff)
+	}
+}
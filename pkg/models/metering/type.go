@@ -26,6 +26,8 @@ type PriceInfo struct {
 	EgressNetworkTrafficPerMegabytesPerHour float64 `json:"egress_network_traffic_per_megabytes_per_hour,omitempty" description:"egress price"`
 	// pvc cost with above currency unit for per GB per hour
 	PvcPerGigabytesPerHour float64 `json:"pvc_per_gigabytes_per_hour,omitempty" description:"pvc price"`
+	// gpu cost with above currency unit for per gpu per hour
+	GpuPerGpuPerHour float64 `json:"gpu_per_gpu_per_hour,omitempty" description:"gpu price"`
 }
 
 type PriceResponse struct {
@@ -33,16 +35,46 @@ type PriceResponse struct {
 	PriceInfo    `json:",inline"`
 }
 
+// Cost is the fee of the usage, with the rate cards applied to compute it.
+type Cost struct {
+	Fee       float64  `json:"fee" description:"fee of the usage"`
+	RateCards []string `json:"rate_cards,omitempty" description:"rate cards applied to compute the fee"`
+}
+
+func (c *Cost) Add(other Cost) {
+	c.Fee += other.Fee
+	for _, rateCard := range other.RateCards {
+		c.AddRateCard(rateCard)
+	}
+}
+
+func (c *Cost) AddRateCard(rateCard string) {
+	for _, r := range c.RateCards {
+		if r == rateCard {
+			return
+		}
+	}
+	c.RateCards = append(c.RateCards, rateCard)
+}
+
 type PodStatistic struct {
 	CPUUsage            float64 `json:"cpu_usage" description:"cpu_usage"`
 	MemoryUsageWoCache  float64 `json:"memory_usage_wo_cache" description:"memory_usage_wo_cache"`
 	NetBytesTransmitted float64 `json:"net_bytes_transmitted" desription:"net_bytes_transmitted"`
 	NetBytesReceived    float64 `json:"net_bytes_received" description:"net_bytes_received"`
 	PVCBytesTotal       float64 `json:"pvc_bytes_total" description:"pvc_bytes_total"`
+	Cost                `json:",inline"`
 }
 
 type PodsStats map[string]*PodStatistic
 
+func (ps *PodsStats) AddCost(podName string, cost Cost) {
+	if _, ok := (*ps)[podName]; !ok {
+		(*ps)[podName] = &PodStatistic{}
+	}
+	(*ps)[podName].Cost.Add(cost)
+}
+
 func (ps *PodsStats) Set(podName, meterName string, value float64) {
 	if _, ok := (*ps)[podName]; !ok {
 		(*ps)[podName] = &PodStatistic{}
@@ -74,6 +106,7 @@ type AppStatistic struct {
 	Deploys             map[string]*DeploymentStatistic  `json:"deployments" description:"deployment statistic"`
 	Statefulsets        map[string]*StatefulsetStatistic `json:"statefulsets" description:"statefulset statistic"`
 	Daemonsets          map[string]*DaemonsetStatistic   `json:"daemonsets" description:"daemonsets statistics"`
+	Cost                `json:",inline"`
 }
 
 func (as *AppStatistic) GetDeployStats(name string) *DeploymentStatistic {
@@ -119,12 +152,14 @@ func (as *AppStatistic) Aggregate() {
 			deployObj.NetBytesTransmitted += podObj.NetBytesTransmitted
 			deployObj.NetBytesReceived += podObj.NetBytesReceived
 			deployObj.PVCBytesTotal += podObj.PVCBytesTotal
+			deployObj.Cost.Add(podObj.Cost)
 		}
 		as.CPUUsage += deployObj.CPUUsage
 		as.MemoryUsageWoCache += deployObj.MemoryUsageWoCache
 		as.NetBytesTransmitted += deployObj.NetBytesTransmitted
 		as.NetBytesReceived += deployObj.NetBytesReceived
 		as.PVCBytesTotal += deployObj.PVCBytesTotal
+		as.Cost.Add(deployObj.Cost)
 	}
 
 	// aggregate statfulset stats
@@ -135,12 +170,14 @@ func (as *AppStatistic) Aggregate() {
 			statfulObj.NetBytesTransmitted += podObj.NetBytesTransmitted
 			statfulObj.NetBytesReceived += podObj.NetBytesReceived
 			statfulObj.PVCBytesTotal += podObj.PVCBytesTotal
+			statfulObj.Cost.Add(podObj.Cost)
 		}
 		as.CPUUsage += statfulObj.CPUUsage
 		as.MemoryUsageWoCache += statfulObj.MemoryUsageWoCache
 		as.NetBytesTransmitted += statfulObj.NetBytesTransmitted
 		as.NetBytesReceived += statfulObj.NetBytesReceived
 		as.PVCBytesTotal += statfulObj.PVCBytesTotal
+		as.Cost.Add(statfulObj.Cost)
 	}
 
 	// aggregate daemonset stats
@@ -151,12 +188,14 @@ func (as *AppStatistic) Aggregate() {
 			daemonsetObj.NetBytesTransmitted += podObj.NetBytesTransmitted
 			daemonsetObj.NetBytesReceived += podObj.NetBytesReceived
 			daemonsetObj.PVCBytesTotal += podObj.PVCBytesTotal
+			daemonsetObj.Cost.Add(podObj.Cost)
 		}
 		as.CPUUsage += daemonsetObj.CPUUsage
 		as.MemoryUsageWoCache += daemonsetObj.MemoryUsageWoCache
 		as.NetBytesTransmitted += daemonsetObj.NetBytesTransmitted
 		as.NetBytesReceived += daemonsetObj.NetBytesReceived
 		as.PVCBytesTotal += daemonsetObj.PVCBytesTotal
+		as.Cost.Add(daemonsetObj.Cost)
 	}
 }
 
@@ -166,6 +205,7 @@ type ServiceStatistic struct {
 	NetBytesTransmitted float64                  `json:"net_bytes_transmitted" description:"net_bytes_transmitted"`
 	NetBytesReceived    float64                  `json:"net_bytes_received" description:"net_bytes_received"`
 	Pods                map[string]*PodStatistic `json:"pods" description:"pod statistic"`
+	Cost                `json:",inline"`
 }
 
 func (ss *ServiceStatistic) SetPodStats(name string, podStat *PodStatistic) {
@@ -195,6 +235,7 @@ func (ss *ServiceStatistic) Aggregate() {
 		ss.MemoryUsageWoCache += ss.GetPodStats(key).MemoryUsageWoCache
 		ss.NetBytesTransmitted += ss.GetPodStats(key).NetBytesTransmitted
 		ss.NetBytesReceived += ss.GetPodStats(key).NetBytesReceived
+		ss.Cost.Add(ss.GetPodStats(key).Cost)
 	}
 }
 
@@ -205,6 +246,7 @@ type DeploymentStatistic struct {
 	NetBytesReceived    float64                  `json:"net_bytes_received" description:"net_bytes_received"`
 	PVCBytesTotal       float64                  `json:"pvc_bytes_total" description:"pvc_bytes_total"`
 	Pods                map[string]*PodStatistic `json:"pods" description:"pod statistic"`
+	Cost                `json:",inline"`
 }
 
 func (ds *DeploymentStatistic) GetPodStats(name string) *PodStatistic {
@@ -235,6 +277,7 @@ func (ds *DeploymentStatistic) Aggregate() {
 		ds.NetBytesTransmitted += ds.GetPodStats(key).NetBytesTransmitted
 		ds.NetBytesReceived += ds.GetPodStats(key).NetBytesReceived
 		ds.PVCBytesTotal += ds.GetPodStats(key).PVCBytesTotal
+		ds.Cost.Add(ds.GetPodStats(key).Cost)
 	}
 }
 
@@ -245,6 +288,7 @@ type StatefulsetStatistic struct {
 	NetBytesReceived    float64                  `json:"net_bytes_received" description:"net_bytes_received"`
 	PVCBytesTotal       float64                  `json:"pvc_bytes_total" description:"pvc_bytes_total"`
 	Pods                map[string]*PodStatistic `json:"pods" description:"pod statistic"`
+	Cost                `json:",inline"`
 }
 
 func (ss *StatefulsetStatistic) GetPodStats(name string) *PodStatistic {
@@ -275,6 +319,7 @@ func (ss *StatefulsetStatistic) Aggregate() {
 		ss.NetBytesTransmitted += ss.GetPodStats(key).NetBytesTransmitted
 		ss.NetBytesReceived += ss.GetPodStats(key).NetBytesReceived
 		ss.PVCBytesTotal += ss.GetPodStats(key).PVCBytesTotal
+		ss.Cost.Add(ss.GetPodStats(key).Cost)
 	}
 }
 
@@ -285,6 +330,7 @@ type DaemonsetStatistic struct {
 	NetBytesReceived    float64                  `json:"net_bytes_received" description:"net_bytes_received"`
 	PVCBytesTotal       float64                  `json:"pvc_bytes_total" description:"pvc_bytes_total"`
 	Pods                map[string]*PodStatistic `json:"pods" description:"pod statistic"`
+	Cost                `json:",inline"`
 }
 
 func (ds *DaemonsetStatistic) GetPodStats(name string) *PodStatistic {
@@ -314,6 +360,7 @@ func (ds *DaemonsetStatistic) Aggregate() {
 		ds.NetBytesTransmitted += ds.GetPodStats(key).NetBytesTransmitted
 		ds.NetBytesReceived += ds.GetPodStats(key).NetBytesReceived
 		ds.PVCBytesTotal += ds.GetPodStats(key).PVCBytesTotal
+		ds.Cost.Add(ds.GetPodStats(key).Cost)
 	}
 }
 
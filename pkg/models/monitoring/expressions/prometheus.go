@@ -17,6 +17,11 @@ limitations under the License.
 package expressions
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/prometheus-community/prom-label-proxy/injectproxy"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql/parser"
@@ -24,6 +29,7 @@ import (
 
 func init() {
 	register("prometheus", labelReplace)
+	registerEnforcer("prometheus", enforceNamespaces)
 }
 
 func labelReplace(input, ns string) (string, error) {
@@ -43,3 +49,65 @@ func labelReplace(input, ns string) (string, error) {
 
 	return root.String(), nil
 }
+
+// enforceNamespaces restricts every selector of the expression to the given namespaces.
+// Unlike labelReplace, the namespace matchers already in the expression are kept, so a
+// selector narrowed down to some of the namespaces keeps its meaning. The expression is
+// rejected if a selector explicitly asks for a namespace out of the given ones, or if
+// there is no namespace at all, since it can't be constrained without changing the result.
+func enforceNamespaces(input string, namespaces []string) (string, error) {
+	if len(namespaces) == 0 {
+		return "", fmt.Errorf("no namespace to constrain the expression to")
+	}
+
+	allowed := make(map[string]bool, len(namespaces))
+	quoted := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if !allowed[ns] {
+			allowed[ns] = true
+			quoted = append(quoted, regexp.QuoteMeta(ns))
+		}
+	}
+	sort.Strings(quoted)
+
+	matcher, err := labels.NewMatcher(labels.MatchRegexp, "namespace", strings.Join(quoted, "|"))
+	if err != nil {
+		return "", err
+	}
+
+	root, err := parser.ParseExpr(input)
+	if err != nil {
+		return "", err
+	}
+
+	// Walk through all the nodes rather than only the expressions injectproxy knows about,
+	// e.g. the parameter of aggregations like topk and count_values may be a selector as well.
+	parser.Inspect(root, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok || err != nil {
+			return nil
+		}
+
+		constrained := false
+		for _, m := range vs.LabelMatchers {
+			if m.Name != "namespace" || m.Type != labels.MatchEqual {
+				continue
+			}
+			if !allowed[m.Value] {
+				err = fmt.Errorf("selector %s is out of the namespaces in scope", vs.String())
+				return nil
+			}
+			constrained = true
+		}
+
+		if !constrained {
+			vs.LabelMatchers = append(vs.LabelMatchers, matcher)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return root.String(), nil
+}
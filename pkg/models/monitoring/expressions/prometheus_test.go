@@ -66,3 +66,71 @@ func TestLabelReplace(t *testing.T) {
 		})
 	}
 }
+
+func TestEnforceNamespaces(t *testing.T) {
+	tests := []struct {
+		expr        string
+		namespaces  []string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			expr:       "up",
+			namespaces: []string{"default"},
+			expected:   `up{namespace=~"default"}`,
+		},
+		{
+			expr:       `sum by (namespace) (rate(container_cpu_usage_seconds_total{job="kubelet"}[5m]))`,
+			namespaces: []string{"kube-system", "default"},
+			expected:   `sum by (namespace) (rate(container_cpu_usage_seconds_total{job="kubelet",namespace=~"default|kube-system"}[5m]))`,
+		},
+		{
+			expr:       `up{namespace="default"} / up{namespace!="default"}`,
+			namespaces: []string{"kube-system", "default"},
+			expected:   `up{namespace="default"} / up{namespace!="default",namespace=~"default|kube-system"}`,
+		},
+		{
+			expr:       `topk(scalar(max(kube_pod_info)), max_over_time(up[1h:5m]))`,
+			namespaces: []string{"default"},
+			expected:   `topk(scalar(max(kube_pod_info{namespace=~"default"})), max_over_time(up{namespace=~"default"}[1h:5m]))`,
+		},
+		{
+			expr:       "1 + 1",
+			namespaces: []string{"default"},
+			expected:   "1 + 1",
+		},
+		{
+			expr:        `up{namespace="kube-system"}`,
+			namespaces:  []string{"default"},
+			expectedErr: true,
+		},
+		{
+			expr:        "up",
+			expectedErr: true,
+		},
+		{
+			expr:        `@@@@`,
+			namespaces:  []string{"default"},
+			expectedErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			result, err := enforceNamespaces(tt.expr, tt.namespaces)
+			if err != nil {
+				if !tt.expectedErr {
+					t.Fatal(err)
+				}
+				return
+			}
+			if tt.expectedErr {
+				t.Fatalf("expected an error, got %s", result)
+			}
+
+			if diff := cmp.Diff(result, tt.expected); diff != "" {
+				t.Fatalf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
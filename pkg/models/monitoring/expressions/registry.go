@@ -18,8 +18,16 @@ package expressions
 
 type labelReplaceFn func(expr, ns string) (string, error)
 
+type namespacesEnforceFn func(expr string, namespaces []string) (string, error)
+
 var ReplaceNamespaceFns = make(map[string]labelReplaceFn)
 
+var EnforceNamespacesFns = make(map[string]namespacesEnforceFn)
+
 func register(name string, fn labelReplaceFn) {
 	ReplaceNamespaceFns[name] = fn
 }
+
+func registerEnforcer(name string, fn namespacesEnforceFn) {
+	EnforceNamespacesFns[name] = fn
+}
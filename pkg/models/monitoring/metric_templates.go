@@ -0,0 +1,295 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// MetricTemplateLabel marks the ConfigMaps in the monitoring namespace holding user defined metric templates.
+// Each key of the ConfigMap data is the name of a metric, and the value is a MetricTemplate in YAML, e.g.
+//
+//	workload_http_request_latency_p99: |
+//	  levels:
+//	  - LevelWorkload
+//	  expr: histogram_quantile(0.99, sum by (le, workload) (rate(http_request_duration_seconds_bucket{$1}[5m])))
+const MetricTemplateLabel = "monitoring.kubesphere.io/metric-template"
+
+// The placeholder substituted with the selector of the level the metric is queried at,
+// built-in metrics of the level use the same placeholders.
+const scopePlaceholder = "__kubesphere_scope__"
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// MetricTemplateLevels are the levels user defined metrics can be queried at.
+var MetricTemplateLevels = map[string]monitoring.Level{
+	"LevelCluster":   monitoring.LevelCluster,
+	"LevelNode":      monitoring.LevelNode,
+	"LevelWorkspace": monitoring.LevelWorkspace,
+	"LevelNamespace": monitoring.LevelNamespace,
+	"LevelWorkload":  monitoring.LevelWorkload,
+	"LevelPod":       monitoring.LevelPod,
+	"LevelContainer": monitoring.LevelContainer,
+	"LevelPVC":       monitoring.LevelPVC,
+	"LevelIngress":   monitoring.LevelIngress,
+	"LevelComponent": monitoring.LevelComponent,
+}
+
+// scopedPlaceholders are the placeholders carrying the workspace or namespace selector of the level.
+// Every vector selector in templates of these levels must have one of them,
+// so that a metric never returns data out of the scope the caller is authorized to.
+var scopedPlaceholders = map[monitoring.Level][]string{
+	monitoring.LevelWorkspace: {"$1"},
+	monitoring.LevelNamespace: {"$1"},
+	monitoring.LevelWorkload:  {"$1"},
+	monitoring.LevelPod:       {"$2"},
+	monitoring.LevelContainer: {"$1"},
+	monitoring.LevelPVC:       {"$1"},
+	monitoring.LevelIngress:   {"$1"},
+}
+
+type MetricTemplate struct {
+	Name        string   `json:"name,omitempty" description:"metric name"`
+	Description string   `json:"description,omitempty" description:"metric description"`
+	Levels      []string `json:"levels" description:"levels the metric applies to, e.g. LevelWorkload, LevelPod"`
+	Expr        string   `json:"expr" description:"PromQL template using the label substitution of the levels"`
+}
+
+// Validate checks the template is a valid PromQL for every level it applies to once substituted.
+func (t *MetricTemplate) Validate() error {
+	if !metricNameRegexp.MatchString(t.Name) {
+		return fmt.Errorf("invalid metric name %s", t.Name)
+	}
+	if strings.HasPrefix(t.Name, MetricMeterPrefix) {
+		return fmt.Errorf("metric name %s must not start with %s", t.Name, MetricMeterPrefix)
+	}
+	if _, ok := builtinMetrics()[t.Name]; ok {
+		return fmt.Errorf("metric name %s conflicts with a built-in metric", t.Name)
+	}
+	if len(t.Levels) == 0 {
+		return fmt.Errorf("metric %s has no level", t.Name)
+	}
+
+	for _, l := range t.Levels {
+		level, ok := MetricTemplateLevels[l]
+		if !ok {
+			return fmt.Errorf("metric %s has unsupported level %s", t.Name, l)
+		}
+		if err := validateExpr(t.Expr, t.scopedPlaceholders(level)); err != nil {
+			return fmt.Errorf("metric %s is invalid at %s, %s", t.Name, l, err)
+		}
+	}
+
+	return nil
+}
+
+// scopedPlaceholders returns the placeholders scoping the template at the level. At LevelWorkload,
+// $2 is only substituted with the namespaced kind selector for metrics named after a workload kind,
+// otherwise it is left empty.
+func (t *MetricTemplate) scopedPlaceholders(level monitoring.Level) []string {
+	scoped := scopedPlaceholders[level]
+	if level == monitoring.LevelWorkload {
+		for _, kind := range []string{"deployment", "statefulset", "daemonset"} {
+			if strings.Contains(t.Name, kind) {
+				return append([]string{"$2"}, scoped...)
+			}
+		}
+	}
+	return scoped
+}
+
+func validateExpr(tmpl string, scoped []string) error {
+	replacements := []string{"$3", "5m"}
+	for _, placeholder := range []string{"$1", "$2"} {
+		matcher := fmt.Sprintf(`__kubesphere_%s__="true"`, placeholder[1:])
+		for _, s := range scoped {
+			if s == placeholder {
+				matcher = fmt.Sprintf(`%s="true"`, scopePlaceholder)
+			}
+		}
+		replacements = append(replacements, placeholder, matcher)
+	}
+
+	expr, err := parser.ParseExpr(strings.NewReplacer(replacements...).Replace(tmpl))
+	if err != nil {
+		return err
+	}
+
+	if len(scoped) == 0 {
+		return nil
+	}
+
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok || err != nil {
+			return nil
+		}
+		for _, m := range vs.LabelMatchers {
+			if m.Name == scopePlaceholder {
+				return nil
+			}
+		}
+		err = fmt.Errorf("selector %s is not scoped by %s", vs.String(), strings.Join(scoped, " or "))
+		return nil
+	})
+	return err
+}
+
+func builtinMetrics() map[string]struct{} {
+	builtin := make(map[string]struct{})
+	for _, metrics := range [][]string{ClusterMetrics, NodeMetrics, WorkspaceMetrics, NamespaceMetrics,
+		ApplicationMetrics, WorkloadMetrics, ServiceMetrics, PodMetrics, ContainerMetrics, PVCMetrics,
+		IngressMetrics, EtcdMetrics, APIServerMetrics, SchedulerMetrics} {
+		for _, m := range metrics {
+			builtin[m] = struct{}{}
+		}
+	}
+	return builtin
+}
+
+// metricTemplateCache keeps the valid user defined metric templates parsed from the ConfigMaps,
+// they are parsed again on the first query after the ConfigMaps change.
+type metricTemplateCache struct {
+	lister corev1listers.ConfigMapLister
+
+	mutex     sync.Mutex
+	synced    bool
+	templates []MetricTemplate
+}
+
+func newMetricTemplateCache(informer corev1informers.ConfigMapInformer) *metricTemplateCache {
+	c := &metricTemplateCache{lister: informer.Lister()}
+	informer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isMetricTemplateConfigMap,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { c.invalidate() },
+			UpdateFunc: func(interface{}, interface{}) { c.invalidate() },
+			DeleteFunc: func(interface{}) { c.invalidate() },
+		},
+	})
+	return c
+}
+
+func isMetricTemplateConfigMap(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	return ok && cm.Namespace == constants.KubeSphereMonitoringNamespace && cm.Labels[MetricTemplateLabel] == "true"
+}
+
+func (c *metricTemplateCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.synced = false
+}
+
+// list returns the valid user defined metric templates, invalid ones are skipped.
+func (c *metricTemplateCache) list() []MetricTemplate {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.synced {
+		return c.templates
+	}
+
+	cms, err := c.lister.ConfigMaps(constants.KubeSphereMonitoringNamespace).
+		List(labels.SelectorFromSet(labels.Set{MetricTemplateLabel: "true"}))
+	if err != nil {
+		klog.Error(err)
+		return nil
+	}
+
+	// Keep the templates in a stable order, the first one wins if a name is defined more than once.
+	sort.Slice(cms, func(i, j int) bool {
+		return cms[i].Name < cms[j].Name
+	})
+
+	var templates []MetricTemplate
+	seen := make(map[string]struct{})
+	for _, cm := range cms {
+		for _, t := range parseMetricTemplates(cm) {
+			if _, ok := seen[t.Name]; ok {
+				klog.Warningf("metric template %s in configmap %s is defined more than once", t.Name, cm.Name)
+				continue
+			}
+			seen[t.Name] = struct{}{}
+			templates = append(templates, t)
+		}
+	}
+
+	c.templates, c.synced = templates, true
+	return templates
+}
+
+// at returns the templates of the user defined metrics applying to the level.
+func (c *metricTemplateCache) at(level monitoring.Level) []MetricTemplate {
+	var res []MetricTemplate
+	for _, t := range c.list() {
+		for _, l := range t.Levels {
+			if MetricTemplateLevels[l] == level {
+				res = append(res, t)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func parseMetricTemplates(cm *corev1.ConfigMap) []MetricTemplate {
+	names := make([]string, 0, len(cm.Data))
+	for name := range cm.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var templates []MetricTemplate
+	for _, name := range names {
+		t := MetricTemplate{}
+		if err := yaml.Unmarshal([]byte(cm.Data[name]), &t); err != nil {
+			klog.Warningf("invalid metric template %s in configmap %s, %s", name, cm.Name, err)
+			continue
+		}
+		t.Name = name
+		if err := t.Validate(); err != nil {
+			klog.Warningf("invalid metric template in configmap %s, %s", cm.Name, err)
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+func TestMetricTemplateValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template MetricTemplate
+		valid    bool
+	}{
+		{
+			name: "workload latency",
+			template: MetricTemplate{
+				Name:   "workload_http_request_latency_p99",
+				Levels: []string{"LevelWorkload"},
+				Expr:   `histogram_quantile(0.99, sum by (le, workload) (rate(http_request_duration_seconds_bucket{$1}[5m])))`,
+			},
+			valid: true,
+		},
+		{
+			name: "pod scoped by the pod selector",
+			template: MetricTemplate{
+				Name:   "pod_http_requests",
+				Levels: []string{"LevelPod"},
+				Expr:   `sum by (namespace, pod) (rate(http_requests_total{$2}[5m]))`,
+			},
+			valid: true,
+		},
+		{
+			name: "workload kind selector",
+			template: MetricTemplate{
+				Name:   "workload_deployment_http_requests",
+				Levels: []string{"LevelWorkload"},
+				Expr:   `sum by (deployment) (rate(http_requests_total{$2}[5m]))`,
+			},
+			valid: true,
+		},
+		{
+			name: "workload kind selector left empty",
+			template: MetricTemplate{
+				Name:   "workload_http_requests",
+				Levels: []string{"LevelWorkload"},
+				Expr:   `sum by (workload) (rate(http_requests_total{$2}[5m]))`,
+			},
+		},
+		{
+			name: "cluster level is not scoped",
+			template: MetricTemplate{
+				Name:   "cluster_http_requests",
+				Levels: []string{"LevelCluster"},
+				Expr:   `sum(rate(http_requests_total[5m]))`,
+			},
+			valid: true,
+		},
+		{
+			name: "namespaced level with an unscoped selector",
+			template: MetricTemplate{
+				Name:   "namespace_http_requests",
+				Levels: []string{"LevelNamespace"},
+				Expr:   `sum by (namespace) (rate(http_requests_total{$1}[5m])) / sum(rate(http_requests_total[5m]))`,
+			},
+		},
+		{
+			name: "invalid PromQL",
+			template: MetricTemplate{
+				Name:   "cluster_broken",
+				Levels: []string{"LevelCluster"},
+				Expr:   `sum(rate(http_requests_total[5m])`,
+			},
+		},
+		{
+			name: "unsupported level",
+			template: MetricTemplate{
+				Name:   "service_http_requests",
+				Levels: []string{"LevelService"},
+				Expr:   `sum(rate(http_requests_total{$1}[5m]))`,
+			},
+		},
+		{
+			name: "conflicts with a built-in metric",
+			template: MetricTemplate{
+				Name:   "workload_cpu_usage",
+				Levels: []string{"LevelWorkload"},
+				Expr:   `sum(rate(container_cpu_usage_seconds_total{$1}[5m]))`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.template.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("expected template to be valid, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected template to be invalid")
+			}
+		})
+	}
+}
+
+func TestGetMetricTemplates(t *testing.T) {
+	cms := []*corev1.ConfigMap{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-metrics",
+				Namespace: constants.KubeSphereMonitoringNamespace,
+				Labels:    map[string]string{MetricTemplateLabel: "true"},
+			},
+			Data: map[string]string{
+				"workload_http_request_latency_p99": `
+levels:
+- LevelWorkload
+expr: histogram_quantile(0.99, sum by (le, workload) (rate(http_request_duration_seconds_bucket{$1}[5m])))
+`,
+				"namespace_unscoped": `
+levels:
+- LevelNamespace
+expr: sum(http_requests_total)
+`,
+				"pod_http_requests": `
+levels:
+- LevelPod
+expr: sum by (namespace, pod) (rate(http_requests_total{$1}[5m]))
+`,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "not-templates",
+				Namespace: constants.KubeSphereMonitoringNamespace,
+			},
+			Data: map[string]string{
+				"workload_ignored": `
+levels:
+- LevelWorkload
+expr: sum(up{$1})
+`,
+			},
+		},
+	}
+
+	var objects []runtime.Object
+	for _, cm := range cms {
+		objects = append(objects, cm)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	informer := informers.NewSharedInformerFactory(client, 0)
+	mo := monitoringOperator{templates: newMetricTemplateCache(informer.Core().V1().ConfigMaps())}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informer.Start(stopCh)
+	informer.WaitForCacheSync(stopCh)
+
+	var names []string
+	for _, tmpl := range mo.GetMetricTemplates(monitoring.LevelWorkload) {
+		names = append(names, tmpl.Name)
+	}
+	if diff := cmp.Diff(names, []string{"workload_http_request_latency_p99"}); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", names, diff)
+	}
+
+	// The pod level substitutes the workload selector for $1, which is not scoped by namespace.
+	if templates := mo.GetMetricTemplates(monitoring.LevelPod); len(templates) != 0 {
+		t.Fatalf("expected no template at pod level, got %v", templates)
+	}
+	if templates := mo.GetMetricTemplates(monitoring.LevelNamespace); len(templates) != 0 {
+		t.Fatalf("expected no template at namespace level, got %v", templates)
+	}
+
+	opt := mo.withMetricTemplates([]string{"workload_cpu_usage", "workload_http_request_latency_p99"},
+		monitoring.WorkloadOption{NamespaceName: "default"})
+	expected := monitoring.MetricTemplatesOption{
+		QueryOption: monitoring.WorkloadOption{NamespaceName: "default"},
+		Templates: map[string]string{
+			"workload_http_request_latency_p99": `histogram_quantile(0.99, sum by (le, workload) (rate(http_request_duration_seconds_bucket{$1}[5m])))`,
+		},
+	}
+	if diff := cmp.Diff(opt, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	// Templates are parsed again once the ConfigMap changes.
+	cm := cms[0].DeepCopy()
+	delete(cm.Data, "workload_http_request_latency_p99")
+	if _, err := client.CoreV1().ConfigMaps(cm.Namespace).Update(context.Background(), cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return len(mo.GetMetricTemplates(monitoring.LevelWorkload)) == 0, nil
+	}); err != nil {
+		t.Fatal("expected the removed template to be dropped")
+	}
+}
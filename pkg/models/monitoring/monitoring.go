@@ -63,6 +63,9 @@ type MonitoringOperator interface {
 	GetNamedMeters(metrics []string, time time.Time, opt monitoring.QueryOption, priceInfo meteringclient.PriceInfo) (Metrics, error)
 	GetAppWorkloads(ns string, apps []string) map[string][]string
 	GetSerivePodsMap(ns string, services []string) map[string][]string
+
+	// user defined metrics
+	GetMetricTemplates(level monitoring.Level) []MetricTemplate
 }
 
 type monitoringOperator struct {
@@ -72,10 +75,11 @@ type monitoringOperator struct {
 	ks             ksinformers.SharedInformerFactory
 	op             openpitrix.Interface
 	resourceGetter *resourcev1alpha3.ResourceGetter
+	templates      *metricTemplateCache
 }
 
 func NewMonitoringOperator(monitoringClient monitoring.Interface, metricsClient monitoring.Interface, k8s kubernetes.Interface, factory informers.InformerFactory, resourceGetter *resourcev1alpha3.ResourceGetter, op openpitrix.Interface) MonitoringOperator {
-	return &monitoringOperator{
+	mo := &monitoringOperator{
 		prometheus:     monitoringClient,
 		metricsserver:  metricsClient,
 		k8s:            k8s,
@@ -83,6 +87,10 @@ func NewMonitoringOperator(monitoringClient monitoring.Interface, metricsClient
 		resourceGetter: resourceGetter,
 		op:             op,
 	}
+	if factory.KubernetesSharedInformerFactory() != nil {
+		mo.templates = newMetricTemplateCache(factory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps())
+	}
+	return mo
 }
 
 func (mo monitoringOperator) GetMetric(expr, namespace string, time time.Time) (monitoring.Metric, error) {
@@ -116,7 +124,7 @@ func (mo monitoringOperator) GetMetricOverTime(expr, namespace string, start, en
 }
 
 func (mo monitoringOperator) GetNamedMetrics(metrics []string, time time.Time, opt monitoring.QueryOption) Metrics {
-	ress := mo.prometheus.GetNamedMetrics(metrics, time, opt)
+	ress := mo.prometheus.GetNamedMetrics(metrics, time, mo.withMetricTemplates(metrics, opt))
 
 	opts := &monitoring.QueryOptions{}
 	opt.Apply(opts)
@@ -154,7 +162,7 @@ func (mo monitoringOperator) GetNamedMetrics(metrics []string, time time.Time, o
 }
 
 func (mo monitoringOperator) GetNamedMetricsOverTime(metrics []string, start, end time.Time, step time.Duration, opt monitoring.QueryOption) Metrics {
-	ress := mo.prometheus.GetNamedMetricsOverTime(metrics, start, end, step, opt)
+	ress := mo.prometheus.GetNamedMetricsOverTime(metrics, start, end, step, mo.withMetricTemplates(metrics, opt))
 
 	if mo.metricsserver != nil {
 
@@ -183,6 +191,33 @@ func (mo monitoringOperator) GetNamedMetricsOverTime(metrics []string, start, en
 	return Metrics{Results: ress}
 }
 
+func (mo monitoringOperator) GetMetricTemplates(level monitoring.Level) []MetricTemplate {
+	return mo.templates.at(level)
+}
+
+// withMetricTemplates attaches the templates of the user defined metrics among the queried ones to the query option.
+func (mo monitoringOperator) withMetricTemplates(metrics []string, opt monitoring.QueryOption) monitoring.QueryOption {
+	opts := monitoring.NewQueryOptions()
+	opt.Apply(opts)
+
+	templates := make(map[string]string)
+	for _, t := range mo.templates.at(opts.Level) {
+		templates[t.Name] = t.Expr
+	}
+
+	queried := make(map[string]string)
+	for _, metric := range metrics {
+		if tmpl, ok := templates[metric]; ok {
+			queried[metric] = tmpl
+		}
+	}
+	if len(queried) == 0 {
+		return opt
+	}
+
+	return monitoring.MetricTemplatesOption{QueryOption: opt, Templates: queried}
+}
+
 func (mo monitoringOperator) GetMetadata(namespace string) Metadata {
 	data := mo.prometheus.GetMetadata(namespace)
 	return Metadata{Data: data}
@@ -87,6 +87,7 @@ var ClusterMetrics = []string{
 	"meter_cluster_net_bytes_transmitted",
 	"meter_cluster_net_bytes_received",
 	"meter_cluster_pvc_bytes_total",
+	"meter_cluster_gpu_usage",
 }
 
 var NodeMetrics = []string{
@@ -132,6 +133,7 @@ var NodeMetrics = []string{
 	"meter_node_net_bytes_transmitted",
 	"meter_node_net_bytes_received",
 	"meter_node_pvc_bytes_total",
+	"meter_node_gpu_usage",
 }
 
 var WorkspaceMetrics = []string{
@@ -164,6 +166,7 @@ var WorkspaceMetrics = []string{
 	"meter_workspace_net_bytes_transmitted",
 	"meter_workspace_net_bytes_received",
 	"meter_workspace_pvc_bytes_total",
+	"meter_workspace_gpu_usage",
 }
 
 var NamespaceMetrics = []string{
@@ -201,6 +204,7 @@ var NamespaceMetrics = []string{
 	"meter_namespace_net_bytes_transmitted",
 	"meter_namespace_net_bytes_received",
 	"meter_namespace_pvc_bytes_total",
+	"meter_namespace_gpu_usage",
 }
 
 var ApplicationMetrics = []string{
@@ -211,6 +215,7 @@ var ApplicationMetrics = []string{
 	"meter_application_net_bytes_transmitted",
 	"meter_application_net_bytes_received",
 	"meter_application_pvc_bytes_total",
+	"meter_application_gpu_usage",
 }
 
 var WorkloadMetrics = []string{
@@ -235,6 +240,7 @@ var WorkloadMetrics = []string{
 	"meter_workload_net_bytes_transmitted",
 	"meter_workload_net_bytes_received",
 	"meter_workload_pvc_bytes_total",
+	"meter_workload_gpu_usage",
 }
 
 var ServiceMetrics = []string{
@@ -243,6 +249,7 @@ var ServiceMetrics = []string{
 	"meter_service_memory_usage_wo_cache",
 	"meter_service_net_bytes_transmitted",
 	"meter_service_net_bytes_received",
+	"meter_service_gpu_usage",
 }
 
 var PodMetrics = []string{
@@ -258,6 +265,7 @@ var PodMetrics = []string{
 	"meter_pod_net_bytes_transmitted",
 	"meter_pod_net_bytes_received",
 	"meter_pod_pvc_bytes_total",
+	"meter_pod_gpu_usage",
 }
 
 var ContainerMetrics = []string{
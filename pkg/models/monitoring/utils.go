@@ -16,6 +16,7 @@ package monitoring
 import (
 	"fmt"
 	"math/big"
+	"strconv"
 
 	"k8s.io/klog/v2"
 
@@ -29,6 +30,7 @@ const (
 	METER_RESOURCE_TYPE_NET_INGRESS
 	METER_RESOURCE_TYPE_NET_EGRESS
 	METER_RESOURCE_TYPE_PVC
+	METER_RESOURCE_TYPE_GPU
 
 	meteringDefaultPrecision = 10
 	meteringFeePrecision     = 3
@@ -40,6 +42,7 @@ var meterResourceUnitMap = map[int]string{
 	METER_RESOURCE_TYPE_NET_INGRESS: "bytes",
 	METER_RESOURCE_TYPE_NET_EGRESS:  "bytes",
 	METER_RESOURCE_TYPE_PVC:         "bytes",
+	METER_RESOURCE_TYPE_GPU:         "gpus",
 }
 
 var MeterResourceMap = map[string]int{
@@ -48,40 +51,48 @@ var MeterResourceMap = map[string]int{
 	"meter_cluster_net_bytes_transmitted":     METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_cluster_net_bytes_received":        METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_cluster_pvc_bytes_total":           METER_RESOURCE_TYPE_PVC,
+	"meter_cluster_gpu_usage":                 METER_RESOURCE_TYPE_GPU,
 	"meter_node_cpu_usage":                    METER_RESOURCE_TYPE_CPU,
 	"meter_node_memory_usage_wo_cache":        METER_RESOURCE_TYPE_MEM,
 	"meter_node_net_bytes_transmitted":        METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_node_net_bytes_received":           METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_node_pvc_bytes_total":              METER_RESOURCE_TYPE_PVC,
+	"meter_node_gpu_usage":                    METER_RESOURCE_TYPE_GPU,
 	"meter_workspace_cpu_usage":               METER_RESOURCE_TYPE_CPU,
 	"meter_workspace_memory_usage":            METER_RESOURCE_TYPE_MEM,
 	"meter_workspace_net_bytes_transmitted":   METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_workspace_net_bytes_received":      METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_workspace_pvc_bytes_total":         METER_RESOURCE_TYPE_PVC,
+	"meter_workspace_gpu_usage":               METER_RESOURCE_TYPE_GPU,
 	"meter_namespace_cpu_usage":               METER_RESOURCE_TYPE_CPU,
 	"meter_namespace_memory_usage_wo_cache":   METER_RESOURCE_TYPE_MEM,
 	"meter_namespace_net_bytes_transmitted":   METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_namespace_net_bytes_received":      METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_namespace_pvc_bytes_total":         METER_RESOURCE_TYPE_PVC,
+	"meter_namespace_gpu_usage":               METER_RESOURCE_TYPE_GPU,
 	"meter_application_cpu_usage":             METER_RESOURCE_TYPE_CPU,
 	"meter_application_memory_usage_wo_cache": METER_RESOURCE_TYPE_MEM,
 	"meter_application_net_bytes_transmitted": METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_application_net_bytes_received":    METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_application_pvc_bytes_total":       METER_RESOURCE_TYPE_PVC,
+	"meter_application_gpu_usage":             METER_RESOURCE_TYPE_GPU,
 	"meter_workload_cpu_usage":                METER_RESOURCE_TYPE_CPU,
 	"meter_workload_memory_usage_wo_cache":    METER_RESOURCE_TYPE_MEM,
 	"meter_workload_net_bytes_transmitted":    METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_workload_net_bytes_received":       METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_workload_pvc_bytes_total":          METER_RESOURCE_TYPE_PVC,
+	"meter_workload_gpu_usage":                METER_RESOURCE_TYPE_GPU,
 	"meter_service_cpu_usage":                 METER_RESOURCE_TYPE_CPU,
 	"meter_service_memory_usage_wo_cache":     METER_RESOURCE_TYPE_MEM,
 	"meter_service_net_bytes_transmitted":     METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_service_net_bytes_received":        METER_RESOURCE_TYPE_NET_INGRESS,
+	"meter_service_gpu_usage":                 METER_RESOURCE_TYPE_GPU,
 	"meter_pod_cpu_usage":                     METER_RESOURCE_TYPE_CPU,
 	"meter_pod_memory_usage_wo_cache":         METER_RESOURCE_TYPE_MEM,
 	"meter_pod_net_bytes_transmitted":         METER_RESOURCE_TYPE_NET_EGRESS,
 	"meter_pod_net_bytes_received":            METER_RESOURCE_TYPE_NET_INGRESS,
 	"meter_pod_pvc_bytes_total":               METER_RESOURCE_TYPE_PVC,
+	"meter_pod_gpu_usage":                     METER_RESOURCE_TYPE_GPU,
 }
 
 func getMaxPointValue(points []monitoring.Point) string {
@@ -202,12 +213,38 @@ func getFeeWithMeterName(meterName string, sum string, priceInfo meteringclient.
 			s.Quo(s, oneGiga)
 
 			return fmt.Sprintf(generateFloatFormat(meteringFeePrecision), s.Mul(s, PvcPerGigabytesPerHour))
+		case METER_RESOURCE_TYPE_GPU:
+			GpuPerGpuPerHour := new(big.Float).SetFloat64(priceInfo.GpuPerGpuPerHour)
+
+			return fmt.Sprintf(generateFloatFormat(meteringFeePrecision), s.Mul(s, GpuPerGpuPerHour))
 		}
 
 		return ""
 	}
 }
 
+// GetMeterUsage returns the resource type of the meter and the sum value in the unit the resource
+// is priced by, e.g. gigabytes rather than bytes for memory.
+func GetMeterUsage(meterName string, sum string) (int, float64, error) {
+	resourceType, ok := MeterResourceMap[meterName]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid meter %v", meterName)
+	}
+
+	usage, err := strconv.ParseFloat(sum, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch resourceType {
+	case METER_RESOURCE_TYPE_MEM, METER_RESOURCE_TYPE_PVC:
+		usage /= 1073741824
+	case METER_RESOURCE_TYPE_NET_INGRESS, METER_RESOURCE_TYPE_NET_EGRESS:
+		usage /= 1048576
+	}
+	return resourceType, usage, nil
+}
+
 func updateMetricStatData(metric monitoring.Metric, scalingMap map[string]float64, priceInfo meteringclient.PriceInfo) monitoring.MetricData {
 	metricName := metric.MetricName
 	metricData := metric.MetricData
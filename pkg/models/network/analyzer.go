@@ -0,0 +1,661 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/api/network/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/controller/network/nsnetworkpolicy"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/simple/client/network"
+)
+
+const (
+	KindService = "service"
+	KindIP      = "ip"
+
+	// the matrix is limited in size, since the traffic is checked between every pair of the classes of pods
+	maxMatrixGroups  = 500
+	maxMatrixClasses = 1000
+)
+
+type Verdict string
+
+const (
+	VerdictAllowed Verdict = "Allowed"
+	VerdictDenied  Verdict = "Denied"
+	// VerdictPartial means the traffic is allowed between some of the pods only
+	VerdictPartial Verdict = "Partial"
+)
+
+type GroupLevel string
+
+const (
+	GroupLevelNamespace GroupLevel = "namespace"
+	GroupLevelWorkload  GroupLevel = "workload"
+)
+
+// Endpoint is the source or the destination of the traffic.
+type Endpoint struct {
+	// Kind is one of pod, service, ip, or the kind of a workload, such as deployment
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// IP of the ip endpoint, it is the pod of the IP if there is any
+	IP string `json:"ip,omitempty"`
+}
+
+type ConnectivityQuery struct {
+	Source      Endpoint `json:"source"`
+	Destination Endpoint `json:"destination"`
+	// Port of the destination, it is the port of the service if the destination is a service.
+	// The traffic is checked on any port if it is empty.
+	Port int32 `json:"port,omitempty"`
+	// Protocol of the port, it is TCP by default
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Namespace restricts the endpoints to the namespace if it is set, including the pods the IPs resolve to
+	Namespace string `json:"-"`
+}
+
+type ConnectivityResult struct {
+	Verdict Verdict `json:"verdict"`
+	// Paths are the verdicts between every pair of the source and the destination pods
+	Paths []PathVerdict `json:"paths"`
+	// Warnings of the policies which can't be evaluated, such as a NamespaceNetworkPolicy selecting a missing service
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type PathVerdict struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	// Protocol and Port of the destination pod the traffic is allowed on, the port is empty if it is any port
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	Port     int32           `json:"port,omitempty"`
+	Allowed  bool            `json:"allowed"`
+	Egress   Decision        `json:"egress"`
+	Ingress  Decision        `json:"ingress"`
+	Reason   string          `json:"reason,omitempty"`
+}
+
+type MatrixQuery struct {
+	// Namespaces in the matrix, all the namespaces are in the matrix if it is empty
+	Namespaces []string
+	Level      GroupLevel
+	// Port and Protocol the traffic is checked on, it is checked on any port if the port is 0
+	Port     int32
+	Protocol corev1.Protocol
+	// ExternalIPs are added to the matrix as the groups out of the cluster
+	ExternalIPs []string
+}
+
+type Group struct {
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	Pods      int    `json:"pods"`
+}
+
+type ConnectivityMatrix struct {
+	Level    GroupLevel      `json:"level"`
+	Port     int32           `json:"port,omitempty"`
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Groups are the namespaces or the workloads with running pods, and the external IPs
+	Groups []Group `json:"groups"`
+	// Cells[i][j] is the verdict of the traffic from Groups[i] to Groups[j]
+	Cells    [][]Verdict `json:"cells"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+// Analyzer evaluates the NetworkPolicies, the NamespaceNetworkPolicies and the network isolation of
+// the namespaces and the workspaces statically, without testing the live traffic.
+type Analyzer interface {
+	// Check checks whether the traffic from the source to the destination is allowed
+	Check(query *ConnectivityQuery) (*ConnectivityResult, error)
+	// Matrix evaluates the connectivity between the namespaces or the workloads
+	Matrix(query *MatrixQuery) (*ConnectivityMatrix, error)
+}
+
+type analyzer struct {
+	informers  informers.InformerFactory
+	options    *network.Options
+	translator *nsnetworkpolicy.Translator
+}
+
+func NewAnalyzer(informers informers.InformerFactory, options *network.Options) Analyzer {
+	k8sInformers := informers.KubernetesSharedInformerFactory()
+	return &analyzer{
+		informers: informers,
+		options:   options,
+		translator: nsnetworkpolicy.NewTranslator(k8sInformers.Core().V1().Services().Lister(),
+			k8sInformers.Core().V1().Nodes().Lister(), options.NSNPOptions),
+	}
+}
+
+// snapshot is the policies and the pods evaluated by a query.
+type snapshot struct {
+	namespaces map[string]*corev1.Namespace
+	// policies in effect by namespace
+	policies map[string][]*policy
+	pods     []*corev1.Pod
+	podsByIP map[string]*corev1.Pod
+	// ipBlocks are the IP blocks of the peers of the policies
+	ipBlocks           []*netv1.IPBlock
+	namespaceLabelSets map[string]labels.Set
+	warnings           []string
+}
+
+func (a *analyzer) snapshot() (*snapshot, error) {
+	k8sInformers := a.informers.KubernetesSharedInformerFactory()
+	s := &snapshot{
+		namespaces:         map[string]*corev1.Namespace{},
+		policies:           map[string][]*policy{},
+		podsByIP:           map[string]*corev1.Pod{},
+		namespaceLabelSets: map[string]labels.Set{},
+	}
+
+	namespaces, err := k8sInformers.Core().V1().Namespaces().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		s.namespaces[ns.Name] = ns
+	}
+
+	pods, err := k8sInformers.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		// the pods on the host network are not isolated by the policies and can't be selected by the selectors
+		if pod.Spec.HostNetwork || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		s.pods = append(s.pods, pod)
+		if pod.Status.PodIP != "" {
+			s.podsByIP[pod.Status.PodIP] = pod
+		}
+	}
+	sort.Slice(s.pods, func(i, j int) bool {
+		if s.pods[i].Namespace != s.pods[j].Namespace {
+			return s.pods[i].Namespace < s.pods[j].Namespace
+		}
+		return s.pods[i].Name < s.pods[j].Name
+	})
+
+	nps, err := k8sInformers.Networking().V1().NetworkPolicies().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, np := range nps {
+		// the policies synced by the nsnetworkpolicy controller are translated from the sources below
+		if a.options.EnableNetworkPolicy && strings.HasPrefix(np.Name, v1alpha1.NSNPPrefix) {
+			continue
+		}
+		s.addPolicy(np, PolicyReference{Kind: PolicyKindNetworkPolicy, Namespace: np.Namespace, Name: np.Name})
+	}
+
+	if a.options.EnableNetworkPolicy {
+		if err := a.addNamespaceNetworkPolicies(s, namespaces); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// addNamespaceNetworkPolicies translates the NamespaceNetworkPolicies and the network isolation the same way
+// as the nsnetworkpolicy controller does.
+func (a *analyzer) addNamespaceNetworkPolicies(s *snapshot, namespaces []*corev1.Namespace) error {
+	nsnpLister := a.informers.KubeSphereSharedInformerFactory().Network().V1alpha1().NamespaceNetworkPolicies().Lister()
+	workspaceLister := a.informers.KubeSphereSharedInformerFactory().Tenant().V1alpha1().Workspaces().Lister()
+
+	for _, ns := range namespaces {
+		nsnps, err := nsnpLister.NamespaceNetworkPolicies(ns.Name).List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, nsnp := range nsnps {
+			np, err := a.translator.ConvertToK8sNP(nsnp)
+			if err != nil {
+				s.warnings = append(s.warnings, fmt.Sprintf("NamespaceNetworkPolicy %s/%s is not in effect: %v", nsnp.Namespace, nsnp.Name, err))
+				continue
+			}
+			s.addPolicy(np, PolicyReference{Kind: PolicyKindNamespaceNetworkPolicy, Namespace: nsnp.Namespace, Name: nsnp.Name})
+		}
+
+		workspaceName := ns.Labels[constants.WorkspaceLabelKey]
+		if workspaceName == "" {
+			continue
+		}
+		wksp, err := workspaceLister.Get(workspaceName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		np, err := a.translator.IsolationPolicy(ns, wksp, nsnps)
+		if err != nil {
+			s.warnings = append(s.warnings, fmt.Sprintf("the network isolation of namespace %s is not in effect: %v", ns.Name, err))
+			continue
+		}
+		if np == nil {
+			continue
+		}
+		ref := PolicyReference{Kind: PolicyKindNamespace, Name: ns.Name}
+		if ns.Annotations[nsnetworkpolicy.NamespaceNPAnnotationKey] != nsnetworkpolicy.NamespaceNPAnnotationEnabled {
+			ref = PolicyReference{Kind: PolicyKindWorkspace, Name: wksp.Name}
+		}
+		s.addPolicy(np, ref)
+	}
+	return nil
+}
+
+func (s *snapshot) addPolicy(np *netv1.NetworkPolicy, ref PolicyReference) {
+	p, err := newPolicy(np, ref)
+	if err != nil {
+		klog.Warningf("invalid pod selector of %s %s/%s: %v", ref.Kind, ref.Namespace, ref.Name, err)
+		s.warnings = append(s.warnings, fmt.Sprintf("%s %s/%s has an invalid pod selector: %v", ref.Kind, ref.Namespace, ref.Name, err))
+		return
+	}
+	s.policies[np.Namespace] = append(s.policies[np.Namespace], p)
+
+	var peers []netv1.NetworkPolicyPeer
+	for _, rule := range np.Spec.Ingress {
+		peers = append(peers, rule.From...)
+	}
+	for _, rule := range np.Spec.Egress {
+		peers = append(peers, rule.To...)
+	}
+	for _, peer := range peers {
+		if peer.IPBlock != nil {
+			s.ipBlocks = append(s.ipBlocks, peer.IPBlock)
+		}
+	}
+}
+
+func (a *analyzer) Check(query *ConnectivityQuery) (*ConnectivityResult, error) {
+	s, err := a.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	protocol := query.Protocol
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+
+	sources, err := a.resolve(s, query.Source)
+	if err != nil {
+		return nil, err
+	}
+	destinations, err := a.resolve(s, query.Destination)
+	if err != nil {
+		return nil, err
+	}
+	if query.Namespace != "" {
+		for _, endpoints := range [][]*endpoint{sources, destinations} {
+			for _, e := range endpoints {
+				if e.pod != nil && e.pod.Namespace != query.Namespace {
+					return nil, errors.NewBadRequest(fmt.Sprintf("endpoint %s is not in namespace %s", e, query.Namespace))
+				}
+			}
+		}
+	}
+
+	// the traffic to a service is checked on the target ports of the service ports
+	var servicePorts []corev1.ServicePort
+	if query.Destination.Kind == KindService {
+		service, err := a.informers.KubernetesSharedInformerFactory().Core().V1().Services().Lister().
+			Services(query.Destination.Namespace).Get(query.Destination.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, sp := range service.Spec.Ports {
+			if query.Port == 0 || (sp.Port == query.Port && protocolOf(&sp.Protocol) == protocol) {
+				servicePorts = append(servicePorts, sp)
+			}
+		}
+		if len(servicePorts) == 0 {
+			return nil, errors.NewBadRequest(fmt.Sprintf("service %s/%s has no port %d/%s", service.Namespace, service.Name, query.Port, protocol))
+		}
+	}
+
+	result := &ConnectivityResult{Paths: []PathVerdict{}, Warnings: s.warnings}
+	allowed := 0
+	for _, source := range sources {
+		for _, destination := range destinations {
+			var path PathVerdict
+			if servicePorts != nil {
+				path = s.checkService(source, destination, servicePorts)
+			} else if query.Port != 0 {
+				path = s.check(source, destination, []port{{protocol: protocol, number: query.Port}})
+			} else {
+				path = s.check(source, destination, s.candidatePorts(source, destination))
+			}
+			if path.Allowed {
+				allowed++
+			}
+			result.Paths = append(result.Paths, path)
+		}
+	}
+	result.Verdict = verdictOf(allowed, len(result.Paths))
+	return result, nil
+}
+
+// check checks the traffic on the ports, it is allowed if it is allowed on any of the ports.
+func (s *snapshot) check(source, destination *endpoint, ports []port) PathVerdict {
+	path := PathVerdict{Source: source.String(), Destination: destination.String()}
+	if source.pod != nil && source.pod == destination.pod {
+		path.Allowed = true
+		path.Egress = Decision{Allowed: true}
+		path.Ingress = Decision{Allowed: true}
+		path.Reason = "the traffic of a pod to itself is always allowed"
+		return path
+	}
+
+	for i, p := range ports {
+		egress := s.evaluate(source, destination, destination, p, netv1.PolicyTypeEgress)
+		ingress := s.evaluate(destination, source, destination, p, netv1.PolicyTypeIngress)
+		if egress.Allowed && ingress.Allowed || i == 0 {
+			path.Protocol, path.Port = p.protocol, p.number
+			path.Allowed = egress.Allowed && ingress.Allowed
+			path.Egress, path.Ingress = egress, ingress
+		}
+		if path.Allowed {
+			break
+		}
+	}
+	return path
+}
+
+func (s *snapshot) checkService(source, destination *endpoint, servicePorts []corev1.ServicePort) PathVerdict {
+	var ports []port
+	for _, sp := range servicePorts {
+		protocol := protocolOf(&sp.Protocol)
+		switch {
+		case sp.TargetPort.Type == intstr.String:
+			if number := containerPort(destination.pod, sp.TargetPort.StrVal, protocol); number != 0 {
+				ports = append(ports, port{protocol: protocol, number: number})
+			}
+		case sp.TargetPort.IntVal != 0:
+			ports = append(ports, port{protocol: protocol, number: sp.TargetPort.IntVal})
+		default:
+			ports = append(ports, port{protocol: protocol, number: sp.Port})
+		}
+	}
+	if len(ports) == 0 {
+		return PathVerdict{
+			Source:      source.String(),
+			Destination: destination.String(),
+			Reason:      "the pod doesn't expose the target port of the service",
+		}
+	}
+	return s.check(source, destination, ports)
+}
+
+// resolve resolves the endpoint to the pods, or the IP out of the pod network.
+func (a *analyzer) resolve(s *snapshot, e Endpoint) ([]*endpoint, error) {
+	switch e.Kind {
+	case KindIP:
+		ip := net.ParseIP(e.IP)
+		if ip == nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("invalid ip %q", e.IP))
+		}
+		if pod, ok := s.podsByIP[ip.String()]; ok {
+			return []*endpoint{podEndpoint(pod)}, nil
+		}
+		return []*endpoint{{ip: ip}}, nil
+	case KindPod:
+		for _, pod := range s.pods {
+			if pod.Namespace == e.Namespace && pod.Name == e.Name {
+				return []*endpoint{podEndpoint(pod)}, nil
+			}
+		}
+		return nil, errors.NewNotFound(corev1.Resource("pods"), e.Name)
+	case KindService:
+		service, err := a.informers.KubernetesSharedInformerFactory().Core().V1().Services().Lister().Services(e.Namespace).Get(e.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(service.Spec.Selector) == 0 {
+			return nil, errors.NewBadRequest(fmt.Sprintf("service %s/%s has no selector", e.Namespace, e.Name))
+		}
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		var endpoints []*endpoint
+		for _, pod := range s.pods {
+			if pod.Namespace == e.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				endpoints = append(endpoints, podEndpoint(pod))
+			}
+		}
+		return endpoints, nil
+	case KindDeployment, KindStatefulSet, KindDaemonSet, KindReplicaSet, KindJob:
+		var endpoints []*endpoint
+		for _, pod := range s.pods {
+			if kind, name := WorkloadOf(pod); pod.Namespace == e.Namespace && kind == e.Kind && name == e.Name {
+				endpoints = append(endpoints, podEndpoint(pod))
+			}
+		}
+		if len(endpoints) == 0 {
+			return nil, errors.NewNotFound(corev1.Resource("pods"), fmt.Sprintf("%s/%s", e.Kind, e.Name))
+		}
+		return endpoints, nil
+	default:
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown kind of endpoint %q", e.Kind))
+	}
+}
+
+// group is a row and a column of the matrix, the pods of the group are divided into the classes of the
+// pods the policies can't tell apart, so the traffic is checked between the classes rather than every pair of pods.
+type group struct {
+	Group
+	classes []*class
+}
+
+// class is the pods of a group in the same namespace, with the same labels, the same ports and the same
+// IP blocks of the policies matching their IPs, or an external IP.
+type class struct {
+	key string
+	// endpoints are up to two pods of the class, the second one is checked against the first one
+	// for the traffic between the different pods of the class
+	endpoints []*endpoint
+	size      int
+}
+
+func (a *analyzer) Matrix(query *MatrixQuery) (*ConnectivityMatrix, error) {
+	if query.Level != GroupLevelNamespace && query.Level != GroupLevelWorkload {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown level %q", query.Level))
+	}
+	s, err := a.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	protocol := query.Protocol
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+
+	namespaces := map[string]bool{}
+	for _, namespace := range query.Namespaces {
+		if _, ok := s.namespaces[namespace]; !ok {
+			return nil, errors.NewNotFound(corev1.Resource("namespaces"), namespace)
+		}
+		namespaces[namespace] = true
+	}
+
+	var groups []*group
+	index := map[Group]*group{}
+	classes := 0
+	for _, pod := range s.pods {
+		if len(namespaces) > 0 && !namespaces[pod.Namespace] {
+			continue
+		}
+		key := Group{Namespace: pod.Namespace, Kind: "namespace"}
+		if query.Level == GroupLevelWorkload {
+			key.Kind, key.Name = WorkloadOf(pod)
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &group{Group: key}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.Pods++
+		if g.add(s.classKey(pod), podEndpoint(pod)) {
+			classes++
+		}
+	}
+	// the pods are sorted by namespace and name, so are the namespaces, the workloads are sorted here
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Namespace != groups[j].Namespace {
+			return groups[i].Namespace < groups[j].Namespace
+		}
+		if groups[i].Kind != groups[j].Kind {
+			return groups[i].Kind < groups[j].Kind
+		}
+		return groups[i].Name < groups[j].Name
+	})
+
+	for _, ip := range query.ExternalIPs {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("invalid ip %q", ip))
+		}
+		g := &group{Group: Group{Kind: KindIP, IP: parsed.String()}}
+		g.add(KindIP+"/"+parsed.String(), &endpoint{ip: parsed})
+		groups = append(groups, g)
+		classes++
+	}
+
+	if len(groups) > maxMatrixGroups || classes > maxMatrixClasses {
+		return nil, errors.NewBadRequest(fmt.Sprintf("the matrix of %d groups and %d classes of pods exceeds the limit of %d groups and %d classes, "+
+			"please narrow down the namespaces", len(groups), classes, maxMatrixGroups, maxMatrixClasses))
+	}
+
+	matrix := &ConnectivityMatrix{
+		Level:    query.Level,
+		Port:     query.Port,
+		Groups:   make([]Group, 0, len(groups)),
+		Cells:    make([][]Verdict, len(groups)),
+		Warnings: s.warnings,
+	}
+	if query.Port != 0 {
+		matrix.Protocol = protocol
+	}
+	// the verdicts between the different pods of the classes, the classes with the same key in different
+	// groups are the same to the policies
+	verdicts := map[[2]string]bool{}
+	allows := func(from, to *class, source, destination *endpoint) bool {
+		key := [2]string{from.key, to.key}
+		allowed, ok := verdicts[key]
+		if !ok {
+			ports := []port{{protocol: protocol, number: query.Port}}
+			if query.Port == 0 {
+				ports = s.candidatePorts(source, destination)
+			}
+			allowed = s.check(source, destination, ports).Allowed
+			verdicts[key] = allowed
+		}
+		return allowed
+	}
+	for i, from := range groups {
+		matrix.Groups = append(matrix.Groups, from.Group)
+		matrix.Cells[i] = make([]Verdict, len(groups))
+		for j, to := range groups {
+			allowed, total := 0, 0
+			for _, source := range from.classes {
+				for _, destination := range to.classes {
+					pairs := source.size * destination.size
+					total += pairs
+					if source != destination {
+						if allows(source, destination, source.endpoints[0], destination.endpoints[0]) {
+							allowed += pairs
+						}
+						continue
+					}
+					// the traffic of a pod to itself is always allowed
+					allowed += source.size
+					if source.size > 1 && allows(source, destination, source.endpoints[0], source.endpoints[1]) {
+						allowed += pairs - source.size
+					}
+				}
+			}
+			matrix.Cells[i][j] = verdictOf(allowed, total)
+		}
+	}
+	return matrix, nil
+}
+
+// add adds the endpoint to the class of the key, it returns true if the class is new.
+func (g *group) add(key string, e *endpoint) bool {
+	for _, c := range g.classes {
+		if c.key == key {
+			if len(c.endpoints) < 2 {
+				c.endpoints = append(c.endpoints, e)
+			}
+			c.size++
+			return false
+		}
+	}
+	g.classes = append(g.classes, &class{key: key, endpoints: []*endpoint{e}, size: 1})
+	return true
+}
+
+// classKey returns the key of the pods the policies can't tell apart. Apart from the namespace, the labels
+// and the ports, the pods are told apart by the IP blocks of the policies matching their IPs.
+func (s *snapshot) classKey(pod *corev1.Pod) string {
+	var ports []string
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			ports = append(ports, fmt.Sprintf("%s/%s/%d", cp.Name, protocolOf(&cp.Protocol), cp.ContainerPort))
+		}
+	}
+	sort.Strings(ports)
+
+	ip := net.ParseIP(pod.Status.PodIP)
+	blocks := make([]byte, len(s.ipBlocks))
+	for i, block := range s.ipBlocks {
+		blocks[i] = '0'
+		if ipBlockMatches(block, ip) {
+			blocks[i] = '1'
+		}
+	}
+	return strings.Join([]string{pod.Namespace, labels.Set(pod.Labels).String(), strings.Join(ports, ","), string(blocks)}, "|")
+}
+
+func verdictOf(allowed, total int) Verdict {
+	switch {
+	case total > 0 && allowed == total:
+		return VerdictAllowed
+	case allowed > 0:
+		return VerdictPartial
+	default:
+		return VerdictDenied
+	}
+}
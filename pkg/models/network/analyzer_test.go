@@ -0,0 +1,418 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/api/network/v1alpha1"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/controller/network/nsnetworkpolicy"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/simple/client/network"
+)
+
+func newPod(namespace, name, ip string, labels map[string]string, owner *metav1.OwnerReference, ports ...corev1.ContainerPort) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Ports: ports}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func controller(kind, name string) *metav1.OwnerReference {
+	isController := true
+	return &metav1.OwnerReference{Kind: kind, Name: name, Controller: &isController}
+}
+
+func newTestAnalyzer(t *testing.T) Analyzer {
+	tcp := corev1.ProtocolTCP
+	db := intstr.FromInt(5432)
+	k8sObjects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo",
+			Labels:      map[string]string{constants.WorkspaceLabelKey: "ws", constants.NamespaceLabelKey: "demo"},
+			Annotations: map[string]string{nsnetworkpolicy.NamespaceNPAnnotationKey: nsnetworkpolicy.NamespaceNPAnnotationEnabled}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other",
+			Labels: map[string]string{constants.WorkspaceLabelKey: "ws", constants.NamespaceLabelKey: "other"}}},
+		newPod("demo", "web-5d8f7-abcde", "10.233.0.1", map[string]string{"app": "web", "pod-template-hash": "5d8f7"},
+			controller("ReplicaSet", "web-5d8f7"), corev1.ContainerPort{Name: "http", ContainerPort: 8080, Protocol: tcp}),
+		newPod("demo", "db-0", "10.233.0.2", map[string]string{"app": "db"},
+			controller("StatefulSet", "db"), corev1.ContainerPort{Name: "pg", ContainerPort: 5432, Protocol: tcp}),
+		newPod("other", "client", "10.233.0.3", map[string]string{"app": "client"}, nil),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "web"},
+				Ports:    []corev1.ServicePort{{Port: 8080, TargetPort: intstr.FromString("http"), Protocol: tcp}},
+			},
+		},
+		&netv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "db"},
+			Spec: netv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				Ingress: []netv1.NetworkPolicyIngressRule{{
+					From:  []netv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}},
+					Ports: []netv1.NetworkPolicyPort{{Port: &db}},
+				}},
+			},
+		},
+		&netv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web-egress"},
+			Spec: netv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeEgress},
+				Egress: []netv1.NetworkPolicyEgressRule{{
+					To: []netv1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: "10.0.0.0/8"}}},
+				}},
+			},
+		},
+		// synced by the nsnetworkpolicy controller, which is evaluated from the namespace instead
+		&netv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: nsnetworkpolicy.AnnotationNPNAME},
+			Spec:       netv1.NetworkPolicySpec{Ingress: []netv1.NetworkPolicyIngressRule{{}}},
+		},
+	}
+	ksObjects := []runtime.Object{
+		&tenantv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "ws"}},
+		&v1alpha1.NamespaceNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "to-web"},
+			Spec: v1alpha1.NamespaceNetworkPolicySpec{
+				Egress: []v1alpha1.NetworkPolicyEgressRule{{
+					To: []v1alpha1.NetworkPolicyPeer{{ServiceSelector: &v1alpha1.ServiceSelector{Namespace: "demo", Name: "web"}}},
+				}},
+			},
+		},
+		&v1alpha1.NamespaceNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "office"},
+			Spec: v1alpha1.NamespaceNetworkPolicySpec{
+				Ingress: []v1alpha1.NetworkPolicyIngressRule{{
+					From: []v1alpha1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: "192.168.0.0/16", Except: []string{"192.168.1.0/24"}}}},
+				}},
+			},
+		},
+	}
+
+	return newAnalyzerOf(t, k8sObjects, ksObjects)
+}
+
+func newAnalyzerOf(t *testing.T, k8sObjects, ksObjects []runtime.Object) Analyzer {
+	factory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), fakeks.NewSimpleClientset(), nil, nil, nil, nil)
+	for _, obj := range k8sObjects {
+		var err error
+		switch o := obj.(type) {
+		case *corev1.Namespace:
+			err = factory.KubernetesSharedInformerFactory().Core().V1().Namespaces().Informer().GetIndexer().Add(o)
+		case *corev1.Pod:
+			err = factory.KubernetesSharedInformerFactory().Core().V1().Pods().Informer().GetIndexer().Add(o)
+		case *corev1.Service:
+			err = factory.KubernetesSharedInformerFactory().Core().V1().Services().Informer().GetIndexer().Add(o)
+		case *netv1.NetworkPolicy:
+			err = factory.KubernetesSharedInformerFactory().Networking().V1().NetworkPolicies().Informer().GetIndexer().Add(o)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, obj := range ksObjects {
+		var err error
+		switch o := obj.(type) {
+		case *tenantv1alpha1.Workspace:
+			err = factory.KubeSphereSharedInformerFactory().Tenant().V1alpha1().Workspaces().Informer().GetIndexer().Add(o)
+		case *v1alpha1.NamespaceNetworkPolicy:
+			err = factory.KubeSphereSharedInformerFactory().Network().V1alpha1().NamespaceNetworkPolicies().Informer().GetIndexer().Add(o)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := network.NewNetworkOptions()
+	options.EnableNetworkPolicy = true
+	return NewAnalyzer(factory, options)
+}
+
+func TestCheck(t *testing.T) {
+	analyzer := newTestAnalyzer(t)
+
+	tests := []struct {
+		name     string
+		query    ConnectivityQuery
+		expected Verdict
+		// policies deciding the direction denying the traffic, or the ingress allowing it
+		policies []PolicyReference
+	}{
+		{
+			name: "allowed by the namespace isolation and the NetworkPolicy",
+			query: ConnectivityQuery{
+				Source:      Endpoint{Kind: KindDeployment, Namespace: "demo", Name: "web"},
+				Destination: Endpoint{Kind: KindPod, Namespace: "demo", Name: "db-0"},
+				Port:        5432,
+			},
+			expected: VerdictAllowed,
+			policies: []PolicyReference{
+				{Kind: PolicyKindNetworkPolicy, Namespace: "demo", Name: "db"},
+				{Kind: PolicyKindNamespace, Name: "demo"},
+			},
+		},
+		{
+			name: "allowed by the namespace isolation on the port not allowed by the NetworkPolicy",
+			query: ConnectivityQuery{
+				Source:      Endpoint{Kind: KindPod, Namespace: "demo", Name: "web-5d8f7-abcde"},
+				Destination: Endpoint{Kind: KindStatefulSet, Namespace: "demo", Name: "db"},
+				Port:        8080,
+			},
+			expected: VerdictAllowed,
+			policies: []PolicyReference{{Kind: PolicyKindNamespace, Name: "demo"}},
+		},
+		{
+			name: "allowed by the egress to the service but denied by the namespace isolation",
+			query: ConnectivityQuery{
+				Source:      Endpoint{Kind: KindPod, Namespace: "other", Name: "client"},
+				Destination: Endpoint{Kind: KindService, Namespace: "demo", Name: "web"},
+				Port:        8080,
+			},
+			expected: VerdictDenied,
+			policies: []PolicyReference{
+				{Kind: PolicyKindNamespace, Name: "demo"},
+				{Kind: PolicyKindNamespaceNetworkPolicy, Namespace: "demo", Name: "office"},
+			},
+		},
+		{
+			name: "allowed from the IP block",
+			query: ConnectivityQuery{
+				Source:      Endpoint{Kind: KindIP, IP: "192.168.0.10"},
+				Destination: Endpoint{Kind: KindPod, Namespace: "demo", Name: "web-5d8f7-abcde"},
+			},
+			expected: VerdictAllowed,
+			policies: []PolicyReference{{Kind: PolicyKindNamespaceNetworkPolicy, Namespace: "demo", Name: "office"}},
+		},
+		{
+			name: "denied from the IP excepted",
+			query: ConnectivityQuery{
+				Source:      Endpoint{Kind: KindIP, IP: "192.168.1.10"},
+				Destination: Endpoint{Kind: KindPod, Namespace: "demo", Name: "web-5d8f7-abcde"},
+			},
+			expected: VerdictDenied,
+			policies: []PolicyReference{
+				{Kind: PolicyKindNamespace, Name: "demo"},
+				{Kind: PolicyKindNamespaceNetworkPolicy, Namespace: "demo", Name: "office"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := analyzer.Check(&test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Verdict != test.expected || len(result.Paths) != 1 {
+				t.Fatalf("unexpected result %+v", result)
+			}
+			path := result.Paths[0]
+			policies := path.Ingress.Policies
+			if path.Ingress.Allowed && !path.Egress.Allowed {
+				policies = path.Egress.Policies
+			}
+			if !samePolicies(policies, test.policies) {
+				t.Fatalf("expected policies %v, got %v", test.policies, policies)
+			}
+		})
+	}
+}
+
+func TestCheckNotFound(t *testing.T) {
+	analyzer := newTestAnalyzer(t)
+	_, err := analyzer.Check(&ConnectivityQuery{
+		Source:      Endpoint{Kind: KindPod, Namespace: "demo", Name: "missing"},
+		Destination: Endpoint{Kind: KindPod, Namespace: "demo", Name: "db-0"},
+	})
+	if err == nil {
+		t.Fatal("expected error for the missing pod")
+	}
+	_, err = analyzer.Check(&ConnectivityQuery{
+		Source:      Endpoint{Kind: KindPod, Namespace: "other", Name: "client"},
+		Destination: Endpoint{Kind: KindService, Namespace: "demo", Name: "web"},
+		Port:        80,
+	})
+	if err == nil {
+		t.Fatal("expected error for the port not exposed by the service")
+	}
+	// the IPs of the pods in other namespaces are out of the namespaced query
+	_, err = analyzer.Check(&ConnectivityQuery{
+		Source:      Endpoint{Kind: KindIP, IP: "10.233.0.3"},
+		Destination: Endpoint{Kind: KindPod, Namespace: "demo", Name: "db-0"},
+		Namespace:   "demo",
+	})
+	if err == nil {
+		t.Fatal("expected error for the pod in the other namespace")
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	analyzer := newTestAnalyzer(t)
+
+	matrix, err := analyzer.Matrix(&MatrixQuery{Level: GroupLevelWorkload, ExternalIPs: []string{"192.168.0.10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedGroups := []Group{
+		{Namespace: "demo", Kind: KindDeployment, Name: "web", Pods: 1},
+		{Namespace: "demo", Kind: KindStatefulSet, Name: "db", Pods: 1},
+		{Namespace: "other", Kind: KindPod, Name: "client", Pods: 1},
+		{Kind: KindIP, IP: "192.168.0.10"},
+	}
+	if len(matrix.Groups) != len(expectedGroups) {
+		t.Fatalf("unexpected groups %v", matrix.Groups)
+	}
+	for i := range expectedGroups {
+		if matrix.Groups[i] != expectedGroups[i] {
+			t.Fatalf("expected group %v, got %v", expectedGroups[i], matrix.Groups[i])
+		}
+	}
+
+	// web, db, client, 192.168.0.10
+	expectedCells := [][]Verdict{
+		// the egress of web is allowed to the pod network only
+		{VerdictAllowed, VerdictAllowed, VerdictAllowed, VerdictDenied},
+		{VerdictAllowed, VerdictAllowed, VerdictAllowed, VerdictAllowed},
+		// the egress of client is allowed to the web service only
+		{VerdictDenied, VerdictDenied, VerdictAllowed, VerdictDenied},
+		// the IP block is allowed to all the pods of demo
+		{VerdictAllowed, VerdictAllowed, VerdictAllowed, VerdictAllowed},
+	}
+	for i := range expectedCells {
+		for j := range expectedCells[i] {
+			if matrix.Cells[i][j] != expectedCells[i][j] {
+				t.Errorf("expected %s from %v to %v, got %s", expectedCells[i][j], matrix.Groups[i], matrix.Groups[j], matrix.Cells[i][j])
+			}
+		}
+	}
+
+	// the traffic from db to the IP is allowed, but not the one from web
+	matrix, err = analyzer.Matrix(&MatrixQuery{Namespaces: []string{"demo"}, Level: GroupLevelNamespace, Port: 8080, ExternalIPs: []string{"192.168.0.10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matrix.Groups) != 2 || matrix.Groups[0].Pods != 2 || matrix.Cells[0][0] != VerdictAllowed || matrix.Cells[0][1] != VerdictPartial {
+		t.Fatalf("unexpected matrix %+v", matrix)
+	}
+}
+
+func TestMatrixClasses(t *testing.T) {
+	var objects []runtime.Object
+	for i, ip := range []string{"10.233.1.1", "10.233.1.2", "10.233.2.1"} {
+		objects = append(objects, newPod("demo", fmt.Sprintf("web-5d8f7-%d", i), ip, map[string]string{"app": "web", "pod-template-hash": "5d8f7"},
+			controller("ReplicaSet", "web-5d8f7")))
+	}
+	objects = append(objects,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo"}},
+		newPod("demo", "client", "10.233.1.3", map[string]string{"app": "client"}, nil),
+		// the pods of web are told apart by the IP blocks
+		&netv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+			Spec: netv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Ingress: []netv1.NetworkPolicyIngressRule{{
+					From: []netv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}}},
+						{IPBlock: &netv1.IPBlock{CIDR: "10.233.2.0/24"}},
+					},
+				}},
+			},
+		},
+	)
+	analyzer := newAnalyzerOf(t, objects, nil)
+
+	matrix, err := analyzer.Matrix(&MatrixQuery{Level: GroupLevelWorkload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// web, client
+	if len(matrix.Groups) != 2 || matrix.Groups[0].Pods != 3 {
+		t.Fatalf("unexpected groups %v", matrix.Groups)
+	}
+	// the traffic between the pods of web is allowed from 10.233.2.1 and to the pods themselves only,
+	// which is 5 of the 9 pairs
+	expectedCells := [][]Verdict{
+		{VerdictPartial, VerdictAllowed},
+		{VerdictAllowed, VerdictAllowed},
+	}
+	for i := range expectedCells {
+		for j := range expectedCells[i] {
+			if matrix.Cells[i][j] != expectedCells[i][j] {
+				t.Errorf("expected %s from %v to %v, got %s", expectedCells[i][j], matrix.Groups[i], matrix.Groups[j], matrix.Cells[i][j])
+			}
+		}
+	}
+
+	ips := make([]string, maxMatrixGroups)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("192.168.%d.%d", i/256, i%256)
+	}
+	if _, err := analyzer.Matrix(&MatrixQuery{Level: GroupLevelWorkload, ExternalIPs: ips}); err == nil {
+		t.Fatal("expected error for the matrix beyond the limit")
+	}
+}
+
+func TestWorkloadOf(t *testing.T) {
+	tests := []struct {
+		pod        *corev1.Pod
+		kind, name string
+	}{
+		{newPod("demo", "web-5d8f7-abcde", "", map[string]string{"pod-template-hash": "5d8f7"}, controller("ReplicaSet", "web-5d8f7")), KindDeployment, "web"},
+		{newPod("demo", "rs-abcde", "", nil, controller("ReplicaSet", "rs")), KindReplicaSet, "rs"},
+		{newPod("demo", "db-0", "", nil, controller("StatefulSet", "db")), KindStatefulSet, "db"},
+		{newPod("demo", "static", "", nil, controller("Node", "node1")), KindPod, "static"},
+	}
+	for _, test := range tests {
+		if kind, name := WorkloadOf(test.pod); kind != test.kind || name != test.name {
+			t.Errorf("expected %s/%s of pod %s, got %s/%s", test.kind, test.name, test.pod.Name, kind, name)
+		}
+	}
+}
+
+func samePolicies(a, b []PolicyReference) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := map[PolicyReference]bool{}
+	for _, p := range a {
+		set[p] = true
+	}
+	for _, p := range b {
+		if !set[p] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/network"
+)
+
+const (
+	DefaultFlowMetric           = "conntrack_flows_total"
+	DefaultFlowSourceLabel      = "src_ip"
+	DefaultFlowDestinationLabel = "dst_ip"
+	DefaultFlowPortLabel        = "dst_port"
+	DefaultFlowProtocolLabel    = "protocol"
+)
+
+// Flow is the traffic observed from the source IP to the destination IP and port.
+type Flow struct {
+	SourceIP      string          `json:"sourceIP"`
+	DestinationIP string          `json:"destinationIP"`
+	Port          int32           `json:"port"`
+	Protocol      corev1.Protocol `json:"protocol,omitempty"`
+	// Connections observed in the learning window
+	Connections float64 `json:"connections,omitempty"`
+}
+
+// MaxFlows is the max number of the flows queried from Prometheus, the busiest flows are kept beyond it.
+const MaxFlows = 10000
+
+// FlowSource provides the flows observed in a time range.
+type FlowSource interface {
+	// Flows returns the flows from or to the IPs, the IPs of the pods and the services of a namespace.
+	Flows(ips []string, start, end time.Time) ([]Flow, error)
+}
+
+type prometheusFlowSource struct {
+	client  monitoring.Interface
+	options network.FlowOptions
+}
+
+// NewPrometheusFlowSource returns the flows counted by the metric of a conntrack-style exporter in Prometheus.
+func NewPrometheusFlowSource(client monitoring.Interface, options network.FlowOptions) FlowSource {
+	if options.Metric == "" {
+		options.Metric = DefaultFlowMetric
+	}
+	if options.SourceLabel == "" {
+		options.SourceLabel = DefaultFlowSourceLabel
+	}
+	if options.DestinationLabel == "" {
+		options.DestinationLabel = DefaultFlowDestinationLabel
+	}
+	if options.PortLabel == "" {
+		options.PortLabel = DefaultFlowPortLabel
+	}
+	if options.ProtocolLabel == "" {
+		options.ProtocolLabel = DefaultFlowProtocolLabel
+	}
+	return &prometheusFlowSource{client: client, options: options}
+}
+
+func (p *prometheusFlowSource) Flows(ips []string, start, end time.Time) ([]Flow, error) {
+	if len(ips) == 0 {
+		return nil, nil
+	}
+	o := p.options
+	window := fmt.Sprintf("%ds", int64(end.Sub(start).Seconds()))
+	quoted := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		// escaped in the regular expression, and then in the string of PromQL
+		quoted = append(quoted, strings.ReplaceAll(regexp.QuoteMeta(ip), `\`, `\\`))
+	}
+	matcher := strings.Join(quoted, "|")
+	increase := func(label string) string {
+		return fmt.Sprintf(`sum by (%s, %s, %s, %s) (increase(%s{%s=~"%s"}[%s]))`,
+			o.SourceLabel, o.DestinationLabel, o.PortLabel, o.ProtocolLabel, o.Metric, label, matcher, window)
+	}
+	expr := fmt.Sprintf(`topk(%d, (%s or %s) > 0)`, MaxFlows, increase(o.SourceLabel), increase(o.DestinationLabel))
+
+	metric := p.client.GetMetric(expr, end)
+	if metric.Error != "" {
+		klog.Errorf("query %s failed, error: %s", expr, metric.Error)
+		return nil, fmt.Errorf("failed to query the flows: %s", metric.Error)
+	}
+
+	var flows []Flow
+	for _, v := range metric.MetricValues {
+		if v.Sample == nil || math.IsNaN(v.Sample[1]) {
+			continue
+		}
+		port, err := strconv.ParseInt(v.Metadata[o.PortLabel], 10, 32)
+		if err != nil {
+			continue
+		}
+		flows = append(flows, Flow{
+			SourceIP:      v.Metadata[o.SourceLabel],
+			DestinationIP: v.Metadata[o.DestinationLabel],
+			Port:          int32(port),
+			Protocol:      parseProtocol(v.Metadata[o.ProtocolLabel]),
+			Connections:   v.Sample[1],
+		})
+	}
+	return flows, nil
+}
+
+// parseProtocol parses the protocol by name or by the IP protocol number, it is TCP by default.
+func parseProtocol(protocol string) corev1.Protocol {
+	switch strings.ToUpper(protocol) {
+	case "UDP", "17":
+		return corev1.ProtocolUDP
+	case "SCTP", "132":
+		return corev1.ProtocolSCTP
+	default:
+		return corev1.ProtocolTCP
+	}
+}
+
+// ipBlockOf returns the IPBlock of the single IP.
+func ipBlockOf(ip net.IP) string {
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
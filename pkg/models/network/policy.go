@@ -0,0 +1,344 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	PolicyKindNetworkPolicy          = "NetworkPolicy"
+	PolicyKindNamespaceNetworkPolicy = "NamespaceNetworkPolicy"
+	// the network isolation of a namespace or a workspace
+	PolicyKindNamespace = "Namespace"
+	PolicyKindWorkspace = "Workspace"
+)
+
+// PolicyReference refers to the object a NetworkPolicy in effect comes from.
+type PolicyReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Decision is the verdict of the policies selecting a pod, in the ingress or the egress direction.
+type Decision struct {
+	Allowed bool `json:"allowed"`
+	// Isolated is true if any policy selects the pod in the direction, all the traffic is allowed otherwise
+	Isolated bool `json:"isolated"`
+	// Policies allowing the traffic if it is allowed, or the policies isolating the pod if it is denied
+	Policies []PolicyReference `json:"policies,omitempty"`
+}
+
+type policy struct {
+	*netv1.NetworkPolicy
+	ref      PolicyReference
+	selector labels.Selector
+	// peerSelectors are the selectors of the peers of the rules, which are compiled once rather than
+	// on every evaluation
+	peerSelectors map[*metav1.LabelSelector]labels.Selector
+}
+
+func newPolicy(np *netv1.NetworkPolicy, ref PolicyReference) (*policy, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+	if err != nil {
+		return nil, err
+	}
+	p := &policy{NetworkPolicy: np, ref: ref, selector: selector, peerSelectors: map[*metav1.LabelSelector]labels.Selector{}}
+	for _, rule := range np.Spec.Ingress {
+		p.compilePeers(rule.From)
+	}
+	for _, rule := range np.Spec.Egress {
+		p.compilePeers(rule.To)
+	}
+	return p, nil
+}
+
+func (p *policy) compilePeers(peers []netv1.NetworkPolicyPeer) {
+	for _, peer := range peers {
+		for _, ls := range []*metav1.LabelSelector{peer.NamespaceSelector, peer.PodSelector} {
+			if ls == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(ls)
+			if err != nil {
+				// an invalid selector selects nothing
+				selector = labels.Nothing()
+			}
+			p.peerSelectors[ls] = selector
+		}
+	}
+}
+
+func (p *policy) hasType(policyType netv1.PolicyType) bool {
+	if len(p.Spec.PolicyTypes) == 0 {
+		// Ingress is always set, Egress is set if there are any egress rules
+		return policyType == netv1.PolicyTypeIngress || len(p.Spec.Egress) > 0
+	}
+	for _, t := range p.Spec.PolicyTypes {
+		if t == policyType {
+			return true
+		}
+	}
+	return false
+}
+
+// endpoint is a pod, or an IP out of the pod network which is not isolated by any policy.
+type endpoint struct {
+	pod *corev1.Pod
+	ip  net.IP
+}
+
+func podEndpoint(pod *corev1.Pod) *endpoint {
+	return &endpoint{pod: pod, ip: net.ParseIP(pod.Status.PodIP)}
+}
+
+func (e *endpoint) String() string {
+	if e.pod != nil {
+		return e.pod.Namespace + "/" + e.pod.Name
+	}
+	return e.ip.String()
+}
+
+// port is the destination port of the traffic. The port is 0 if it stands for the ports
+// which are not listed by any policy, so it is only allowed by the rules allowing all the ports.
+type port struct {
+	protocol corev1.Protocol
+	number   int32
+}
+
+// evaluate evaluates the policies selecting the target in the direction, the target is the source of
+// the traffic in the egress direction, or the destination in the ingress direction.
+func (s *snapshot) evaluate(target, peer, destination *endpoint, p port, direction netv1.PolicyType) Decision {
+	decision := Decision{}
+	if target.pod == nil {
+		decision.Allowed = true
+		return decision
+	}
+
+	var isolating []PolicyReference
+	for _, policy := range s.policies[target.pod.Namespace] {
+		if !policy.hasType(direction) || !policy.selector.Matches(labels.Set(target.pod.Labels)) {
+			continue
+		}
+		decision.Isolated = true
+		isolating = append(isolating, policy.ref)
+		if s.policyAllows(policy, peer, destination, p, direction) {
+			decision.Allowed = true
+			decision.Policies = append(decision.Policies, policy.ref)
+		}
+	}
+
+	if !decision.Isolated {
+		decision.Allowed = true
+	} else if !decision.Allowed {
+		decision.Policies = isolating
+	}
+	return decision
+}
+
+func (s *snapshot) policyAllows(policy *policy, peer, destination *endpoint, p port, direction netv1.PolicyType) bool {
+	if direction == netv1.PolicyTypeIngress {
+		for _, rule := range policy.Spec.Ingress {
+			if s.peersMatch(policy, rule.From, peer) && portsMatch(rule.Ports, destination, p) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rule := range policy.Spec.Egress {
+		if s.peersMatch(policy, rule.To, peer) && portsMatch(rule.Ports, destination, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// peersMatch checks whether the endpoint is one of the peers of the policy, empty peers match all the endpoints.
+func (s *snapshot) peersMatch(policy *policy, peers []netv1.NetworkPolicyPeer, e *endpoint) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if s.peerMatches(policy, peer, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *snapshot) peerMatches(policy *policy, peer netv1.NetworkPolicyPeer, e *endpoint) bool {
+	if peer.IPBlock != nil {
+		return ipBlockMatches(peer.IPBlock, e.ip)
+	}
+	// the selectors select pods only
+	if e.pod == nil {
+		return false
+	}
+	if peer.NamespaceSelector == nil {
+		if e.pod.Namespace != policy.Namespace {
+			return false
+		}
+	} else if !policy.peerSelectors[peer.NamespaceSelector].Matches(s.namespaceLabels(e.pod.Namespace)) {
+		return false
+	}
+	return peer.PodSelector == nil || policy.peerSelectors[peer.PodSelector].Matches(labels.Set(e.pod.Labels))
+}
+
+// namespaceLabels returns the labels of the namespace, including the label of the namespace name,
+// which is set by the namespace controller and selected by the translated NamespaceNetworkPolicies.
+func (s *snapshot) namespaceLabels(namespace string) labels.Set {
+	if set, ok := s.namespaceLabelSets[namespace]; ok {
+		return set
+	}
+	set := labels.Set{constants.NamespaceLabelKey: namespace}
+	if ns, ok := s.namespaces[namespace]; ok {
+		for k, v := range ns.Labels {
+			set[k] = v
+		}
+	}
+	s.namespaceLabelSets[namespace] = set
+	return set
+}
+
+func ipBlockMatches(block *netv1.IPBlock, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil || !cidr.Contains(ip) {
+		return false
+	}
+	for _, except := range block.Except {
+		if _, cidr, err := net.ParseCIDR(except); err == nil && cidr.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// portsMatch checks whether the port of the destination is one of the ports, empty ports match all the ports.
+func portsMatch(ports []netv1.NetworkPolicyPort, destination *endpoint, p port) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, np := range ports {
+		if protocolOf(np.Protocol) != p.protocol {
+			continue
+		}
+		if np.Port == nil {
+			return true
+		}
+		if p.number == 0 {
+			continue
+		}
+		if np.Port.Type == intstr.Int {
+			end := np.Port.IntVal
+			if np.EndPort != nil {
+				end = *np.EndPort
+			}
+			if p.number >= np.Port.IntVal && p.number <= end {
+				return true
+			}
+		} else if containerPort(destination.pod, np.Port.StrVal, p.protocol) == p.number {
+			return true
+		}
+	}
+	return false
+}
+
+func protocolOf(protocol *corev1.Protocol) corev1.Protocol {
+	if protocol == nil || *protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return *protocol
+}
+
+// containerPort returns the number of the named port of the pod, it is 0 if the port is not found.
+func containerPort(pod *corev1.Pod, name string, protocol corev1.Protocol) int32 {
+	if pod == nil {
+		return 0
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Name == name && protocolOf(&cp.Protocol) == protocol {
+				return cp.ContainerPort
+			}
+		}
+	}
+	return 0
+}
+
+// candidatePorts returns the ports to check if the traffic is allowed on any port. Apart from the ports of
+// the destination pod, it is enough to check the start of every port range listed by the policies and a
+// port not listed at all, since the verdict doesn't change between them.
+func (s *snapshot) candidatePorts(source, destination *endpoint) []port {
+	seen := map[port]bool{}
+	var ports []port
+	add := func(p port) {
+		if !seen[p] {
+			seen[p] = true
+			ports = append(ports, p)
+		}
+	}
+	add(port{protocol: corev1.ProtocolTCP})
+
+	if destination.pod != nil {
+		for _, c := range destination.pod.Spec.Containers {
+			for _, cp := range c.Ports {
+				add(port{protocol: protocolOf(&cp.Protocol), number: cp.ContainerPort})
+			}
+		}
+	}
+	addRulePorts := func(rulePorts []netv1.NetworkPolicyPort) {
+		for _, np := range rulePorts {
+			protocol := protocolOf(np.Protocol)
+			add(port{protocol: protocol})
+			if np.Port == nil {
+				continue
+			}
+			if np.Port.Type == intstr.Int {
+				add(port{protocol: protocol, number: np.Port.IntVal})
+			} else if number := containerPort(destination.pod, np.Port.StrVal, protocol); number != 0 {
+				add(port{protocol: protocol, number: number})
+			}
+		}
+	}
+	if source.pod != nil {
+		for _, policy := range s.policies[source.pod.Namespace] {
+			for _, rule := range policy.Spec.Egress {
+				addRulePorts(rule.Ports)
+			}
+		}
+	}
+	if destination.pod != nil {
+		for _, policy := range s.policies[destination.pod.Namespace] {
+			for _, rule := range policy.Spec.Ingress {
+				addRulePorts(rule.Ports)
+			}
+		}
+	}
+	return ports
+}
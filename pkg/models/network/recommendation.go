@@ -0,0 +1,588 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"kubesphere.io/api/network/v1alpha1"
+
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/controller/network/nsnetworkpolicy"
+	"kubesphere.io/kubesphere/pkg/informers"
+)
+
+const (
+	RecommendedIngressPolicyName = "recommended-ingress"
+	RecommendedEgressPolicyName  = "recommended-egress"
+	// AnnotationRecommendationWindow is the learning window of a recommended policy, in the form of start/end in RFC3339
+	AnnotationRecommendationWindow = "network.kubesphere.io/recommendation-window"
+
+	// the IPs out of the cluster in a direction beyond it are collapsed into blocks
+	maxExternalPeers = 8
+)
+
+// Recommendation is the bundle of the NamespaceNetworkPolicies recommended for a namespace, which
+// allows the flows observed in the learning window only.
+type Recommendation struct {
+	Namespace string     `json:"namespace"`
+	Level     GroupLevel `json:"level"`
+	Start     time.Time  `json:"start"`
+	End       time.Time  `json:"end"`
+	// Flows of the namespace the policies are recommended from
+	Flows int `json:"flows"`
+	// Policies to be reviewed and applied as a bundle. The policy of a direction is absent if there is
+	// no flow observed in the direction, since it would deny all the traffic in the direction.
+	Policies []*v1alpha1.NamespaceNetworkPolicy `json:"policies"`
+	Warnings []string                           `json:"warnings,omitempty"`
+}
+
+// Recommender recommends the NamespaceNetworkPolicies from the observed flows. The NamespaceNetworkPolicies
+// apply to all the pods of the namespace, so the level only decides the peers of the rules: the peers are the
+// namespaces at the namespace level, and the services of the workloads at the workload level, falling back to
+// the namespaces of the workloads without services. The peers out of the pod network are the IP blocks.
+type Recommender interface {
+	// Recommend recommends the policies of the namespace from the flows, the flows are queried from
+	// the flow source if they are nil.
+	Recommend(namespace string, level GroupLevel, start, end time.Time, flows []Flow) (*Recommendation, error)
+	// Apply creates or updates the policies of the namespace as a bundle, none of them is applied
+	// if any of them is invalid, and the policies applied are rolled back if any of them fails.
+	Apply(namespace string, policies []*v1alpha1.NamespaceNetworkPolicy) ([]*v1alpha1.NamespaceNetworkPolicy, error)
+}
+
+type recommender struct {
+	informers  informers.InformerFactory
+	ksClient   kubesphere.Interface
+	flowSource FlowSource
+}
+
+// NewRecommender returns a Recommender, the flow source can be nil if the flows are always provided.
+func NewRecommender(informers informers.InformerFactory, ksClient kubesphere.Interface, flowSource FlowSource) Recommender {
+	return &recommender{
+		informers:  informers,
+		ksClient:   ksClient,
+		flowSource: flowSource,
+	}
+}
+
+// observed is an endpoint of the observed flows, it is a service if the flow is observed before the
+// service IP is translated.
+type observed struct {
+	pod     *corev1.Pod
+	service *corev1.Service
+	ip      net.IP
+}
+
+// namespace returns the namespace of the pod or the service, it is empty if the endpoint is out of the cluster.
+func (o *observed) namespace() string {
+	switch {
+	case o.pod != nil:
+		return o.pod.Namespace
+	case o.service != nil:
+		return o.service.Namespace
+	default:
+		return ""
+	}
+}
+
+type ipIndex struct {
+	pods     map[string]*corev1.Pod
+	services map[string]*corev1.Service
+	// services by namespace, to look up the services of the pods
+	namespaceServices map[string][]*corev1.Service
+}
+
+func (r *recommender) index() (*ipIndex, error) {
+	k8sInformers := r.informers.KubernetesSharedInformerFactory()
+	index := &ipIndex{
+		pods:              map[string]*corev1.Pod{},
+		services:          map[string]*corev1.Service{},
+		namespaceServices: map[string][]*corev1.Service{},
+	}
+
+	pods, err := k8sInformers.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		// the pods on the host network share the IPs of the nodes
+		if pod.Spec.HostNetwork || pod.Status.PodIP == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		index.pods[pod.Status.PodIP] = pod
+	}
+
+	services, err := k8sInformers.Core().V1().Services().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	for _, service := range services {
+		if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != corev1.ClusterIPNone {
+			index.services[service.Spec.ClusterIP] = service
+		}
+		if len(service.Spec.Selector) > 0 {
+			index.namespaceServices[service.Namespace] = append(index.namespaceServices[service.Namespace], service)
+		}
+	}
+	return index, nil
+}
+
+func (i *ipIndex) resolve(ip string) *observed {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil
+	}
+	if pod, ok := i.pods[parsed.String()]; ok {
+		return &observed{pod: pod, ip: parsed}
+	}
+	if service, ok := i.services[parsed.String()]; ok {
+		return &observed{service: service, ip: parsed}
+	}
+	return &observed{ip: parsed}
+}
+
+// namespaceIPs returns the IPs of the pods and the services of the namespace.
+func (i *ipIndex) namespaceIPs(namespace string) []string {
+	var ips []string
+	for ip, pod := range i.pods {
+		if pod.Namespace == namespace {
+			ips = append(ips, ip)
+		}
+	}
+	for ip, service := range i.services {
+		if service.Namespace == namespace {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// serviceOf returns the first service selecting the pod by name.
+func (i *ipIndex) serviceOf(pod *corev1.Pod) *corev1.Service {
+	for _, service := range i.namespaceServices[pod.Namespace] {
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			return service
+		}
+	}
+	return nil
+}
+
+// peerOf returns the peer of the endpoint at the level, and the key to merge the rules by.
+func (i *ipIndex) peerOf(o *observed, level GroupLevel) (string, v1alpha1.NetworkPolicyPeer) {
+	var namespace string
+	var service *corev1.Service
+	switch {
+	case o.service != nil:
+		namespace, service = o.service.Namespace, o.service
+	case o.pod != nil:
+		namespace = o.pod.Namespace
+		if level == GroupLevelWorkload {
+			service = i.serviceOf(o.pod)
+		}
+	default:
+		cidr := ipBlockOf(o.ip)
+		return "ip/" + cidr, v1alpha1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: cidr}}
+	}
+
+	if level == GroupLevelWorkload && service != nil {
+		return "service/" + service.Namespace + "/" + service.Name,
+			v1alpha1.NetworkPolicyPeer{ServiceSelector: &v1alpha1.ServiceSelector{Namespace: service.Namespace, Name: service.Name}}
+	}
+	return "namespace/" + namespace, v1alpha1.NetworkPolicyPeer{NamespaceSelector: &v1alpha1.NamespaceSelector{Name: namespace}}
+}
+
+// rulePort is the port the policies are enforced on, which is the target port if the flow is to a service IP.
+type rulePort struct {
+	protocol corev1.Protocol
+	port     intstr.IntOrString
+}
+
+func portOf(flow Flow, destination *observed) rulePort {
+	protocol := flow.Protocol
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	p := rulePort{protocol: protocol, port: intstr.FromInt(int(flow.Port))}
+	if destination.service == nil {
+		return p
+	}
+	for _, sp := range destination.service.Spec.Ports {
+		if sp.Port == flow.Port && protocolOf(&sp.Protocol) == protocol {
+			if sp.TargetPort.Type == intstr.String || sp.TargetPort.IntVal != 0 {
+				p.port = sp.TargetPort
+			}
+			break
+		}
+	}
+	return p
+}
+
+func (p rulePort) String() string {
+	return string(p.protocol) + "/" + p.port.String()
+}
+
+// ruleSet merges the flows to the minimal rules, the peers of the same ports share a rule.
+type ruleSet struct {
+	peers map[string]v1alpha1.NetworkPolicyPeer
+	ports map[string]map[rulePort]bool
+}
+
+func newRuleSet() *ruleSet {
+	return &ruleSet{
+		peers: map[string]v1alpha1.NetworkPolicyPeer{},
+		ports: map[string]map[rulePort]bool{},
+	}
+}
+
+func (s *ruleSet) add(key string, peer v1alpha1.NetworkPolicyPeer, p rulePort) {
+	s.peers[key] = peer
+	if s.ports[key] == nil {
+		s.ports[key] = map[rulePort]bool{}
+	}
+	s.ports[key][p] = true
+}
+
+type rule struct {
+	peers []v1alpha1.NetworkPolicyPeer
+	ports []netv1.NetworkPolicyPort
+}
+
+func (s *ruleSet) rules() []rule {
+	keys := make([]string, 0, len(s.peers))
+	for key := range s.peers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var rules []rule
+	index := map[string]int{}
+	for _, key := range keys {
+		ports := make([]rulePort, 0, len(s.ports[key]))
+		for p := range s.ports[key] {
+			ports = append(ports, p)
+		}
+		sort.Slice(ports, func(i, j int) bool {
+			if ports[i].protocol != ports[j].protocol {
+				return ports[i].protocol < ports[j].protocol
+			}
+			if ports[i].port.Type != ports[j].port.Type {
+				return ports[i].port.Type < ports[j].port.Type
+			}
+			if ports[i].port.IntVal != ports[j].port.IntVal {
+				return ports[i].port.IntVal < ports[j].port.IntVal
+			}
+			return ports[i].port.StrVal < ports[j].port.StrVal
+		})
+		portKeys := make([]string, 0, len(ports))
+		for _, p := range ports {
+			portKeys = append(portKeys, p.String())
+		}
+		portsKey := strings.Join(portKeys, ",")
+
+		if i, ok := index[portsKey]; ok {
+			rules[i].peers = append(rules[i].peers, s.peers[key])
+			continue
+		}
+		r := rule{peers: []v1alpha1.NetworkPolicyPeer{s.peers[key]}}
+		for _, p := range ports {
+			protocol, port := p.protocol, p.port
+			r.ports = append(r.ports, netv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+		}
+		index[portsKey] = len(rules)
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// externalFlow is a flow from or to an IP out of the cluster.
+type externalFlow struct {
+	ip   net.IP
+	port rulePort
+}
+
+// addExternal adds the peers out of the cluster. The IPs are the peers by themselves if there are few of them,
+// otherwise they are collapsed into the /24 or /64 blocks, or a single block of any IP if there are still too
+// many blocks, which is told by the warning returned.
+func (s *ruleSet) addExternal(flows []externalFlow, direction string) string {
+	if len(flows) == 0 {
+		return ""
+	}
+	ips := map[string]bool{}
+	for _, flow := range flows {
+		ips[flow.ip.String()] = true
+	}
+	collapses := []func(net.IP) string{ipBlockOf, subnetOf, anyIPOf}
+	for i, collapse := range collapses {
+		blocks := map[string]bool{}
+		for _, flow := range flows {
+			blocks[collapse(flow.ip)] = true
+		}
+		if len(blocks) > maxExternalPeers && i < len(collapses)-1 {
+			continue
+		}
+		for _, flow := range flows {
+			cidr := collapse(flow.ip)
+			s.add("ip/"+cidr, v1alpha1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: cidr}}, flow.port)
+		}
+		switch i {
+		case 0:
+			return ""
+		case 1:
+			return fmt.Sprintf("the %d IPs out of the cluster observed in the %s are collapsed into %d blocks", len(ips), direction, len(blocks))
+		default:
+			return fmt.Sprintf("the %d IPs out of the cluster observed in the %s are allowed as any IP, please review the %s rules", len(ips), direction, direction)
+		}
+	}
+	return ""
+}
+
+// subnetOf returns the /24 block of the IPv4, or the /64 block of the IPv6.
+func subnetOf(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// anyIPOf returns the block of any IP of the family of the IP.
+func anyIPOf(ip net.IP) string {
+	if ip.To4() != nil {
+		return "0.0.0.0/0"
+	}
+	return "::/0"
+}
+
+func isDNS(o *observed, p rulePort) bool {
+	if p.port.IntValue() != nsnetworkpolicy.DNSPort {
+		return false
+	}
+	return (o.pod != nil && o.pod.Namespace == nsnetworkpolicy.DNSNamespace) ||
+		(o.service != nil && o.service.Namespace == nsnetworkpolicy.DNSNamespace)
+}
+
+func (r *recommender) Recommend(namespace string, level GroupLevel, start, end time.Time, flows []Flow) (*Recommendation, error) {
+	if level != GroupLevelNamespace && level != GroupLevelWorkload {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown level %q", level))
+	}
+	if _, err := r.informers.KubernetesSharedInformerFactory().Core().V1().Namespaces().Lister().Get(namespace); err != nil {
+		return nil, err
+	}
+	index, err := r.index()
+	if err != nil {
+		return nil, err
+	}
+
+	recommendation := &Recommendation{
+		Namespace: namespace,
+		Level:     level,
+		Start:     start,
+		End:       end,
+		Policies:  []*v1alpha1.NamespaceNetworkPolicy{},
+	}
+	if flows == nil {
+		if r.flowSource == nil {
+			return nil, errors.NewBadRequest("no flow source is configured, the flows must be provided")
+		}
+		if flows, err = r.flowSource.Flows(index.namespaceIPs(namespace), start, end); err != nil {
+			return nil, err
+		}
+		if len(flows) >= MaxFlows {
+			recommendation.Warnings = append(recommendation.Warnings, fmt.Sprintf("only the %d busiest flows are queried", MaxFlows))
+		}
+	}
+
+	ingress, egress := newRuleSet(), newRuleSet()
+	var externalIngress, externalEgress []externalFlow
+	invalid := 0
+	for _, flow := range flows {
+		source, destination := index.resolve(flow.SourceIP), index.resolve(flow.DestinationIP)
+		if source == nil || destination == nil || flow.Port <= 0 {
+			invalid++
+			continue
+		}
+		p := portOf(flow, destination)
+		observedInNamespace := false
+		if destination.namespace() == namespace {
+			if source.namespace() == "" {
+				externalIngress = append(externalIngress, externalFlow{ip: source.ip, port: p})
+			} else {
+				key, peer := index.peerOf(source, level)
+				ingress.add(key, peer, p)
+			}
+			observedInNamespace = true
+		}
+		if source.namespace() == namespace {
+			switch {
+			case isDNS(destination, p):
+				// the traffic to the DNS is allowed by the egress rules added by the nsnetworkpolicy controller
+			case destination.namespace() == "":
+				externalEgress = append(externalEgress, externalFlow{ip: destination.ip, port: p})
+			default:
+				key, peer := index.peerOf(destination, level)
+				egress.add(key, peer, p)
+			}
+			observedInNamespace = true
+		}
+		if observedInNamespace {
+			recommendation.Flows++
+		}
+	}
+	if invalid > 0 {
+		recommendation.Warnings = append(recommendation.Warnings, fmt.Sprintf("%d flows with invalid IPs or ports are ignored", invalid))
+	}
+	if warning := ingress.addExternal(externalIngress, "ingress"); warning != "" {
+		recommendation.Warnings = append(recommendation.Warnings, warning)
+	}
+	if warning := egress.addExternal(externalEgress, "egress"); warning != "" {
+		recommendation.Warnings = append(recommendation.Warnings, warning)
+	}
+
+	if rules := ingress.rules(); len(rules) > 0 {
+		policy := r.newPolicy(namespace, RecommendedIngressPolicyName, start, end)
+		for _, rule := range rules {
+			policy.Spec.Ingress = append(policy.Spec.Ingress, v1alpha1.NetworkPolicyIngressRule{From: rule.peers, Ports: rule.ports})
+		}
+		policy.Spec.PolicyTypes = []netv1.PolicyType{netv1.PolicyTypeIngress}
+		recommendation.Policies = append(recommendation.Policies, policy)
+	} else {
+		recommendation.Warnings = append(recommendation.Warnings, "no ingress flow is observed, the ingress is left open")
+	}
+	if rules := egress.rules(); len(rules) > 0 {
+		policy := r.newPolicy(namespace, RecommendedEgressPolicyName, start, end)
+		for _, rule := range rules {
+			policy.Spec.Egress = append(policy.Spec.Egress, v1alpha1.NetworkPolicyEgressRule{To: rule.peers, Ports: rule.ports})
+		}
+		policy.Spec.PolicyTypes = []netv1.PolicyType{netv1.PolicyTypeEgress}
+		recommendation.Policies = append(recommendation.Policies, policy)
+	} else {
+		recommendation.Warnings = append(recommendation.Warnings, "no egress flow is observed, the egress is left open")
+	}
+	return recommendation, nil
+}
+
+func (r *recommender) newPolicy(namespace, name string, start, end time.Time) *v1alpha1.NamespaceNetworkPolicy {
+	return &v1alpha1.NamespaceNetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.ResourceKindNamespaceNetworkPolicy,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				AnnotationRecommendationWindow: start.UTC().Format(time.RFC3339) + "/" + end.UTC().Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+func (r *recommender) Apply(namespace string, policies []*v1alpha1.NamespaceNetworkPolicy) ([]*v1alpha1.NamespaceNetworkPolicy, error) {
+	for _, policy := range policies {
+		if policy.Name == "" {
+			return nil, errors.NewBadRequest("the name of the policy is required")
+		}
+		if policy.Namespace != "" && policy.Namespace != namespace {
+			return nil, errors.NewBadRequest(fmt.Sprintf("policy %s/%s is not in namespace %s", policy.Namespace, policy.Name, namespace))
+		}
+	}
+
+	// the bundle is validated by a dry run before any of the policies is applied
+	for _, policy := range policies {
+		if _, _, err := r.apply(namespace, policy, []string{metav1.DryRunAll}); err != nil {
+			return nil, err
+		}
+	}
+
+	// the policies applied are rolled back if any of them fails, e.g. modified in the meantime
+	applied := make([]*v1alpha1.NamespaceNetworkPolicy, 0, len(policies))
+	previous := make([]*v1alpha1.NamespaceNetworkPolicy, 0, len(policies))
+	for _, policy := range policies {
+		result, existing, err := r.apply(namespace, policy, nil)
+		if err != nil {
+			if rollbackErr := r.rollback(namespace, applied, previous); rollbackErr != nil {
+				return nil, fmt.Errorf("%v, and failed to roll back the policies applied: %v", err, rollbackErr)
+			}
+			return nil, err
+		}
+		applied = append(applied, result)
+		previous = append(previous, existing)
+	}
+	return applied, nil
+}
+
+// apply creates or updates the policy, the policy existing before is returned if it is updated.
+func (r *recommender) apply(namespace string, policy *v1alpha1.NamespaceNetworkPolicy, dryRun []string) (*v1alpha1.NamespaceNetworkPolicy, *v1alpha1.NamespaceNetworkPolicy, error) {
+	client := r.ksClient.NetworkV1alpha1().NamespaceNetworkPolicies(namespace)
+	existing, err := client.Get(context.Background(), policy.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		policy = policy.DeepCopy()
+		policy.Namespace = namespace
+		policy.ResourceVersion = ""
+		created, err := client.Create(context.Background(), policy, metav1.CreateOptions{DryRun: dryRun})
+		return created, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated := existing.DeepCopy()
+	updated.Spec = policy.Spec
+	for k, v := range policy.Annotations {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[k] = v
+	}
+	updated, err = client.Update(context.Background(), updated, metav1.UpdateOptions{DryRun: dryRun})
+	return updated, existing, err
+}
+
+// rollback deletes the policies created, and restores the policies updated to the previous ones.
+func (r *recommender) rollback(namespace string, applied, previous []*v1alpha1.NamespaceNetworkPolicy) error {
+	client := r.ksClient.NetworkV1alpha1().NamespaceNetworkPolicies(namespace)
+	var errs []error
+	for i := len(applied) - 1; i >= 0; i-- {
+		if previous[i] == nil {
+			if err := client.Delete(context.Background(), applied[i].Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		restored := applied[i].DeepCopy()
+		restored.Spec = previous[i].Spec
+		restored.Annotations = previous[i].Annotations
+		if _, err := client.Update(context.Background(), restored, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kubesphere.io/api/network/v1alpha1"
+
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/network"
+)
+
+type fakeMonitoringClient struct {
+	monitoring.Interface
+	exprs []string
+}
+
+func (f *fakeMonitoringClient) GetMetric(expr string, ts time.Time) monitoring.Metric {
+	f.exprs = append(f.exprs, expr)
+
+	sample := func(src, dst, port, protocol string) monitoring.MetricValue {
+		return monitoring.MetricValue{
+			Metadata: map[string]string{"src_ip": src, "dst_ip": dst, "dst_port": port, "protocol": protocol},
+			Sample:   &monitoring.Point{float64(ts.Unix()), 10},
+		}
+	}
+	return monitoring.Metric{MetricData: monitoring.MetricData{MetricValues: []monitoring.MetricValue{
+		// client to the IP of the web service
+		sample("10.233.0.3", "10.96.0.10", "80", "tcp"),
+		// web to db
+		sample("10.233.0.1", "10.233.0.2", "5432", "6"),
+		// web to the DNS
+		sample("10.233.0.1", "10.233.0.9", "53", "17"),
+		// web to the internet
+		sample("10.233.0.1", "203.0.113.5", "443", "tcp"),
+		// the internet to web
+		sample("198.51.100.7", "10.233.0.1", "8080", "tcp"),
+		sample("invalid", "10.233.0.1", "8080", "tcp"),
+	}}}
+}
+
+func newTestRecommender(t *testing.T, ksClient *fakeks.Clientset) (Recommender, *fakeMonitoringClient) {
+	tcp := corev1.ProtocolTCP
+	factory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), ksClient, nil, nil, nil, nil)
+	k8sInformers := factory.KubernetesSharedInformerFactory()
+	for _, ns := range []string{"demo", "other", "kube-system"} {
+		if err := k8sInformers.Core().V1().Namespaces().Informer().GetIndexer().Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pods := []*corev1.Pod{
+		newPod("demo", "web-5d8f7-abcde", "10.233.0.1", map[string]string{"app": "web"}, nil),
+		newPod("demo", "db-0", "10.233.0.2", map[string]string{"app": "db"}, nil),
+		newPod("other", "client", "10.233.0.3", map[string]string{"app": "client"}, nil),
+		newPod("kube-system", "coredns", "10.233.0.9", map[string]string{"k8s-app": "kube-dns"}, nil),
+	}
+	for _, pod := range pods {
+		if err := k8sInformers.Core().V1().Pods().Informer().GetIndexer().Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	services := []*corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.96.0.10",
+				Selector:  map[string]string{"app": "web"},
+				Ports:     []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080), Protocol: tcp}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "db"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: corev1.ClusterIPNone,
+				Selector:  map[string]string{"app": "db"},
+				Ports:     []corev1.ServicePort{{Port: 5432, Protocol: tcp}},
+			},
+		},
+	}
+	for _, service := range services {
+		if err := k8sInformers.Core().V1().Services().Informer().GetIndexer().Add(service); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	monitoringClient := &fakeMonitoringClient{}
+	return NewRecommender(factory, ksClient, NewPrometheusFlowSource(monitoringClient, network.FlowOptions{})), monitoringClient
+}
+
+func ingressRule(ports []int32, peers ...v1alpha1.NetworkPolicyPeer) v1alpha1.NetworkPolicyIngressRule {
+	return v1alpha1.NetworkPolicyIngressRule{From: peers, Ports: tcpPorts(ports...)}
+}
+
+func egressRule(ports []int32, peers ...v1alpha1.NetworkPolicyPeer) v1alpha1.NetworkPolicyEgressRule {
+	return v1alpha1.NetworkPolicyEgressRule{To: peers, Ports: tcpPorts(ports...)}
+}
+
+func tcpPorts(ports ...int32) []netv1.NetworkPolicyPort {
+	var result []netv1.NetworkPolicyPort
+	for _, p := range ports {
+		protocol, port := corev1.ProtocolTCP, intstr.FromInt(int(p))
+		result = append(result, netv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+	}
+	return result
+}
+
+func namespacePeer(name string) v1alpha1.NetworkPolicyPeer {
+	return v1alpha1.NetworkPolicyPeer{NamespaceSelector: &v1alpha1.NamespaceSelector{Name: name}}
+}
+
+func servicePeer(namespace, name string) v1alpha1.NetworkPolicyPeer {
+	return v1alpha1.NetworkPolicyPeer{ServiceSelector: &v1alpha1.ServiceSelector{Namespace: namespace, Name: name}}
+}
+
+func ipPeer(cidr string) v1alpha1.NetworkPolicyPeer {
+	return v1alpha1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: cidr}}
+}
+
+func TestRecommend(t *testing.T) {
+	end := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-time.Hour)
+
+	tests := []struct {
+		level   GroupLevel
+		ingress []v1alpha1.NetworkPolicyIngressRule
+		egress  []v1alpha1.NetworkPolicyEgressRule
+	}{
+		{
+			level: GroupLevelNamespace,
+			ingress: []v1alpha1.NetworkPolicyIngressRule{
+				// the flow to the service IP is allowed on the target port
+				ingressRule([]int32{8080}, ipPeer("198.51.100.7/32"), namespacePeer("other")),
+				ingressRule([]int32{5432}, namespacePeer("demo")),
+			},
+			egress: []v1alpha1.NetworkPolicyEgressRule{
+				egressRule([]int32{443}, ipPeer("203.0.113.5/32")),
+				egressRule([]int32{5432}, namespacePeer("demo")),
+			},
+		},
+		{
+			level: GroupLevelWorkload,
+			ingress: []v1alpha1.NetworkPolicyIngressRule{
+				ingressRule([]int32{8080}, ipPeer("198.51.100.7/32"), namespacePeer("other")),
+				ingressRule([]int32{5432}, servicePeer("demo", "web")),
+			},
+			egress: []v1alpha1.NetworkPolicyEgressRule{
+				egressRule([]int32{443}, ipPeer("203.0.113.5/32")),
+				egressRule([]int32{5432}, servicePeer("demo", "db")),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.level), func(t *testing.T) {
+			recommender, monitoringClient := newTestRecommender(t, fakeks.NewSimpleClientset())
+			recommendation, err := recommender.Recommend("demo", test.level, start, end, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the flows from or to the IPs of the pods and the services of the namespace are queried
+			if len(monitoringClient.exprs) != 1 || !strings.Contains(monitoringClient.exprs[0],
+				`increase(conntrack_flows_total{dst_ip=~"10\\.233\\.0\\.1|10\\.233\\.0\\.2|10\\.96\\.0\\.10"}[3600s])`) {
+				t.Fatalf("unexpected queries %v", monitoringClient.exprs)
+			}
+			if recommendation.Flows != 5 || len(recommendation.Warnings) != 1 || len(recommendation.Policies) != 2 {
+				t.Fatalf("unexpected recommendation %+v", recommendation)
+			}
+			ingress, egress := recommendation.Policies[0], recommendation.Policies[1]
+			if ingress.Name != RecommendedIngressPolicyName || ingress.Namespace != "demo" ||
+				ingress.Annotations[AnnotationRecommendationWindow] != "2023-12-09T23:00:00Z/2023-12-10T00:00:00Z" {
+				t.Fatalf("unexpected policy %+v", ingress.ObjectMeta)
+			}
+			if !reflect.DeepEqual(ingress.Spec.Ingress, test.ingress) {
+				t.Errorf("expected ingress %+v, got %+v", test.ingress, ingress.Spec.Ingress)
+			}
+			if egress.Name != RecommendedEgressPolicyName || !reflect.DeepEqual(egress.Spec.Egress, test.egress) {
+				t.Errorf("expected egress %+v, got %+v", test.egress, egress.Spec.Egress)
+			}
+		})
+	}
+}
+
+func TestRecommendFromPostedFlows(t *testing.T) {
+	recommender, monitoringClient := newTestRecommender(t, fakeks.NewSimpleClientset())
+	now := time.Now()
+	recommendation, err := recommender.Recommend("other", GroupLevelNamespace, now.Add(-time.Hour), now, []Flow{
+		{SourceIP: "10.233.0.3", DestinationIP: "10.233.0.1", Port: 8080},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monitoringClient.exprs) != 0 {
+		t.Fatalf("unexpected queries %v", monitoringClient.exprs)
+	}
+	// no ingress of namespace other is observed
+	if len(recommendation.Policies) != 1 || recommendation.Policies[0].Name != RecommendedEgressPolicyName ||
+		!reflect.DeepEqual(recommendation.Policies[0].Spec.Egress, []v1alpha1.NetworkPolicyEgressRule{egressRule([]int32{8080}, namespacePeer("demo"))}) {
+		t.Fatalf("unexpected recommendation %+v", recommendation)
+	}
+}
+
+func TestRecommendExternalPeers(t *testing.T) {
+	recommender, _ := newTestRecommender(t, fakeks.NewSimpleClientset())
+	var flows []Flow
+	for i := 0; i <= maxExternalPeers; i++ {
+		// the IPs of the same block are collapsed into the block
+		flows = append(flows, Flow{SourceIP: "10.233.0.1", DestinationIP: fmt.Sprintf("203.0.113.%d", i+1), Port: 443})
+		// the blocks beyond the max are collapsed into any IP
+		flows = append(flows, Flow{SourceIP: fmt.Sprintf("198.51.%d.7", i), DestinationIP: "10.233.0.1", Port: 8080})
+	}
+	now := time.Now()
+	recommendation, err := recommender.Recommend("demo", GroupLevelNamespace, now.Add(-time.Hour), now, flows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recommendation.Policies) != 2 || len(recommendation.Warnings) != 2 {
+		t.Fatalf("unexpected recommendation %+v", recommendation)
+	}
+	if ingress := recommendation.Policies[0].Spec.Ingress; !reflect.DeepEqual(ingress, []v1alpha1.NetworkPolicyIngressRule{ingressRule([]int32{8080}, ipPeer("0.0.0.0/0"))}) {
+		t.Errorf("unexpected ingress %+v", ingress)
+	}
+	if egress := recommendation.Policies[1].Spec.Egress; !reflect.DeepEqual(egress, []v1alpha1.NetworkPolicyEgressRule{egressRule([]int32{443}, ipPeer("203.0.113.0/24"))}) {
+		t.Errorf("unexpected egress %+v", egress)
+	}
+}
+
+func TestApply(t *testing.T) {
+	ksClient := fakeks.NewSimpleClientset(&v1alpha1.NamespaceNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: RecommendedIngressPolicyName, Labels: map[string]string{"app": "demo"}},
+	})
+	recommender, _ := newTestRecommender(t, ksClient)
+	now := time.Now()
+	recommendation, err := recommender.Recommend("demo", GroupLevelNamespace, now.Add(-time.Hour), now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := recommendation.Policies[0].DeepCopy()
+	invalid.Namespace = "other"
+	if _, err := recommender.Apply("demo", append(recommendation.Policies, invalid)); err == nil {
+		t.Fatal("expected error for the policy of another namespace")
+	}
+
+	applied, err := recommender.Apply("demo", recommendation.Policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("unexpected policies %v", applied)
+	}
+	for _, expected := range recommendation.Policies {
+		policy, err := ksClient.NetworkV1alpha1().NamespaceNetworkPolicies("demo").Get(context.Background(), expected.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(policy.Spec, expected.Spec) || policy.Annotations[AnnotationRecommendationWindow] == "" {
+			t.Fatalf("unexpected policy %+v", policy)
+		}
+	}
+	// the labels of the existing policy are kept
+	policy, _ := ksClient.NetworkV1alpha1().NamespaceNetworkPolicies("demo").Get(context.Background(), RecommendedIngressPolicyName, metav1.GetOptions{})
+	if policy.Labels["app"] != "demo" {
+		t.Fatalf("unexpected labels %v", policy.Labels)
+	}
+}
+
+func TestApplyRollback(t *testing.T) {
+	ksClient := fakeks.NewSimpleClientset(&v1alpha1.NamespaceNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: RecommendedIngressPolicyName},
+	})
+	recommender, _ := newTestRecommender(t, ksClient)
+	now := time.Now()
+	recommendation, err := recommender.Recommend("demo", GroupLevelNamespace, now.Add(-time.Hour), now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the fake clientset doesn't dry run, the writes of the dry run are not stored here,
+	// and the creation of the egress policy fails
+	writes := 0
+	ksClient.PrependReactor("*", "namespacenetworkpolicies", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetVerb() != "create" && action.GetVerb() != "update" {
+			return false, nil, nil
+		}
+		writes++
+		switch {
+		case writes <= len(recommendation.Policies):
+			return true, action.(k8stesting.CreateAction).GetObject(), nil
+		case action.GetVerb() == "create":
+			return true, nil, fmt.Errorf("conflict")
+		}
+		return false, nil, nil
+	})
+
+	if _, err := recommender.Apply("demo", recommendation.Policies); err == nil {
+		t.Fatal("expected error for the policy failed")
+	}
+	policy, err := ksClient.NetworkV1alpha1().NamespaceNetworkPolicies("demo").Get(context.Background(), RecommendedIngressPolicyName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Spec.Ingress) != 0 || policy.Annotations[AnnotationRecommendationWindow] != "" {
+		t.Fatalf("expected the policy updated to be rolled back, got %+v", policy)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	KindPod         = "pod"
+	KindDeployment  = "deployment"
+	KindStatefulSet = "statefulset"
+	KindDaemonSet   = "daemonset"
+	KindReplicaSet  = "replicaset"
+	KindJob         = "job"
+)
+
+// WorkloadOf returns the kind and the name of the workload the pod belongs to, which is looked up
+// by the controller of the pod. A pod without a known controller is a workload by itself.
+func WorkloadOf(pod *corev1.Pod) (string, string) {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		switch owner.Kind {
+		case "ReplicaSet":
+			// the ReplicaSets of a Deployment are named by the Deployment and the hash of the pod template
+			if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				return KindDeployment, strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+			return KindReplicaSet, owner.Name
+		case "StatefulSet", "DaemonSet", "Job":
+			return strings.ToLower(owner.Kind), owner.Name
+		}
+	}
+	return KindPod, pod.Name
+}
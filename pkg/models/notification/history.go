@@ -0,0 +1,205 @@
+// Copyright 2023 The KubeSphere Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package notification
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"kubesphere.io/api/notification/v2beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kapinotificationv2beta2 "kubesphere.io/kubesphere/pkg/api/notification/v2beta2"
+	notificationlisters "kubesphere.io/kubesphere/pkg/client/listers/notification/v2beta2"
+	"kubesphere.io/kubesphere/pkg/informers"
+)
+
+// maxHistorySelectedDays is the most dates of the histories selected by the label.
+const maxHistorySelectedDays = 31
+
+type HistoryOperator interface {
+	// Query returns the records matched and the statistics of the receivers of them. Only the records of
+	// the receivers owned by the user are returned if the user is not empty.
+	Query(ctx context.Context, user string, opts *kapinotificationv2beta2.HistoryOptions) (*kapinotificationv2beta2.History, error)
+}
+
+// historyOperator queries the NotificationHistory resources recorded by the history receiver in the
+// controller manager, which are read from the cache and selected by the labels of them.
+type historyOperator struct {
+	reader         client.Reader
+	receiverLister notificationlisters.ReceiverLister
+}
+
+func NewHistoryOperator(informers informers.InformerFactory, reader client.Reader) HistoryOperator {
+	return &historyOperator{
+		reader:         reader,
+		receiverLister: informers.KubeSphereSharedInformerFactory().Notification().V2beta2().Receivers().Lister(),
+	}
+}
+
+func (o *historyOperator) Query(ctx context.Context, user string, opts *kapinotificationv2beta2.HistoryOptions) (*kapinotificationv2beta2.History, error) {
+	selector, err := historySelector(user, opts)
+	if err != nil {
+		return nil, err
+	}
+	histories := &v2beta2.NotificationHistoryList{}
+	if err := o.reader.List(ctx, histories, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	owned := make(map[string]bool)
+	isOwned := func(receiver string) bool {
+		if user == "" {
+			return true
+		}
+		if v, ok := owned[receiver]; ok {
+			return v
+		}
+		r, err := o.receiverLister.Get(receiver)
+		owned[receiver] = err == nil && isOwner(user, r)
+		return owned[receiver]
+	}
+
+	var records []*kapinotificationv2beta2.HistoryRecord
+	for i := range histories.Items {
+		history := &histories.Items[i]
+		// the names too long to be labels are only kept in the spec
+		if (opts.Receiver != "" && history.Spec.Receiver != opts.Receiver) ||
+			(opts.Tenant != "" && history.Spec.Tenant != opts.Tenant) ||
+			(user != "" && history.Spec.Tenant != user) || !isOwned(history.Spec.Receiver) {
+			continue
+		}
+		for _, r := range history.Status.Records {
+			record := &kapinotificationv2beta2.HistoryRecord{
+				ID:           r.ID,
+				Time:         r.Time.Time,
+				Receiver:     history.Spec.Receiver,
+				ReceiverType: r.ReceiverType,
+				Tenant:       history.Spec.Tenant,
+				AlertName:    r.AlertName,
+				Namespace:    r.Namespace,
+				Status:       r.Status,
+				Latency:      float64(r.LatencyMilliseconds) / 1000,
+				Message:      r.Message,
+				Labels:       r.Labels,
+			}
+			if opts.Match(record) {
+				records = append(records, record)
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Time.Equal(records[j].Time) {
+			return records[i].ID > records[j].ID
+		}
+		return records[i].Time.After(records[j].Time)
+	})
+
+	history := &kapinotificationv2beta2.History{
+		TotalItems: len(records),
+		Receivers:  receiverStatistics(records),
+	}
+	start, end := 0, len(records)
+	if opts.Pagination != nil {
+		start, end = opts.Pagination.GetValidPagination(len(records))
+	}
+	history.Items = records[start:end]
+	return history, nil
+}
+
+// historySelector selects the histories of the dates within the time range, and those of the receiver
+// and the tenant filtered by. The dates are not selected by if the time range is too long, in which case
+// the histories are only kept for the retention anyway.
+func historySelector(user string, opts *kapinotificationv2beta2.HistoryOptions) (labels.Selector, error) {
+	selector := labels.NewSelector()
+	if opts.End.Sub(opts.Start) <= maxHistorySelectedDays*24*time.Hour {
+		var dates []string
+		last := opts.End.UTC().Format(v2beta2.NotificationHistoryDateFormat)
+		for t := opts.Start.UTC(); ; t = t.Add(24 * time.Hour) {
+			date := t.Format(v2beta2.NotificationHistoryDateFormat)
+			dates = append(dates, date)
+			if date >= last {
+				break
+			}
+		}
+		requirement, err := labels.NewRequirement(v2beta2.NotificationHistoryLabelDate, selection.In, dates)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*requirement)
+	}
+
+	equals := map[string]string{
+		v2beta2.NotificationHistoryLabelReceiver: opts.Receiver,
+		v2beta2.NotificationHistoryLabelTenant:   opts.Tenant,
+	}
+	if user != "" {
+		equals[v2beta2.NotificationHistoryLabelTenant] = user
+	}
+	for key, value := range equals {
+		if value == "" || len(validation.IsValidLabelValue(value)) > 0 {
+			continue
+		}
+		requirement, err := labels.NewRequirement(key, selection.Equals, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector, nil
+}
+
+func receiverStatistics(records []*kapinotificationv2beta2.HistoryRecord) []*kapinotificationv2beta2.ReceiverStatistics {
+	stats := make(map[string]*kapinotificationv2beta2.ReceiverStatistics)
+	latencies := make(map[string][]float64)
+	for _, record := range records {
+		s, ok := stats[record.Receiver]
+		if !ok {
+			s = &kapinotificationv2beta2.ReceiverStatistics{Receiver: record.Receiver, ReceiverType: record.ReceiverType}
+			stats[record.Receiver] = s
+		}
+		s.Total++
+		if record.Status == kapinotificationv2beta2.HistoryStatusSuccess {
+			s.Success++
+		} else {
+			s.Failed++
+		}
+		latencies[record.Receiver] = append(latencies[record.Receiver], record.Latency)
+	}
+
+	result := make([]*kapinotificationv2beta2.ReceiverStatistics, 0, len(stats))
+	for receiver, s := range stats {
+		s.SuccessRate = float64(s.Success) / float64(s.Total)
+		l := latencies[receiver]
+		sort.Float64s(l)
+		var sum float64
+		for _, v := range l {
+			sum += v
+		}
+		s.AverageLatency = sum / float64(len(l))
+		s.P95Latency = l[int(math.Ceil(0.95*float64(len(l))))-1]
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Receiver < result[j].Receiver
+	})
+	return result
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 The KubeSphere Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"kubesphere.io/api/notification/v2beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kapinotificationv2beta2 "kubesphere.io/kubesphere/pkg/api/notification/v2beta2"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/informers"
+)
+
+func TestHistoryOperator(t *testing.T) {
+	fakeInformerFactory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), fakeks.NewSimpleClientset(), nil, nil, nil, nil)
+	indexer := fakeInformerFactory.KubeSphereSharedInformerFactory().Notification().V2beta2().Receivers().Informer().GetIndexer()
+	_ = indexer.Add(&v2beta2.Receiver{ObjectMeta: metav1.ObjectMeta{
+		Name:   "global-email",
+		Labels: map[string]string{"type": "global"},
+	}})
+	_ = indexer.Add(&v2beta2.Receiver{ObjectMeta: metav1.ObjectMeta{
+		Name:   "admin-slack",
+		Labels: map[string]string{"type": "tenant", "user": "admin"},
+	}})
+
+	now := time.Date(2023, 12, 10, 0, 30, 0, 0, time.UTC)
+	record := func(alertname string, latency int64, errMessage string, minutes int) v2beta2.NotificationRecord {
+		r := v2beta2.NotificationRecord{
+			ID:                  alertname + now.Add(time.Duration(minutes)*time.Minute).String(),
+			Time:                metav1.NewTime(now.Add(time.Duration(minutes) * time.Minute)),
+			AlertName:           alertname,
+			Namespace:           "test",
+			Status:              v2beta2.NotificationStatusSuccess,
+			LatencyMilliseconds: latency,
+			Message:             errMessage,
+		}
+		if errMessage != "" {
+			r.Status = v2beta2.NotificationStatusFailed
+		}
+		return r
+	}
+	newHistory := func(name, receiver, tenant, date string, records ...v2beta2.NotificationRecord) *v2beta2.NotificationHistory {
+		h := &v2beta2.NotificationHistory{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+				v2beta2.NotificationHistoryLabelReceiver: receiver,
+				v2beta2.NotificationHistoryLabelDate:     date,
+			}},
+			Spec:   v2beta2.NotificationHistorySpec{Receiver: receiver, Tenant: tenant, Date: date},
+			Status: v2beta2.NotificationHistoryStatus{Records: records},
+		}
+		if tenant != "" {
+			h.Labels[v2beta2.NotificationHistoryLabelTenant] = tenant
+		}
+		return h
+	}
+	sch := runtime.NewScheme()
+	if err := v2beta2.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	reader := fake.NewClientBuilder().WithScheme(sch).WithObjects(
+		newHistory("email-1210", "global-email", "", "2023-12-10",
+			record("PodCrash", 1000, "", -20), record("PodCrash", 3000, "connection refused", -10)),
+		newHistory("slack-1210", "admin-slack", "admin", "2023-12-10", record("PodCrash", 500, "", -5)),
+		// beyond the time range
+		newHistory("slack-1209", "admin-slack", "admin", "2023-12-09", record("NodeDown", 1000, "", -120)),
+		newHistory("slack-1201", "admin-slack", "admin", "2023-12-01", record("NodeDown", 1000, "", -9*24*60)),
+	).Build()
+	o := NewHistoryOperator(fakeInformerFactory, reader)
+
+	opts := &kapinotificationv2beta2.HistoryOptions{
+		Start:      now.Add(-time.Hour),
+		End:        now,
+		Pagination: query.NoPagination,
+	}
+	history, err := o.Query(context.Background(), "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.TotalItems != 3 || history.Items[0].Receiver != "admin-slack" || history.Items[0].Tenant != "admin" ||
+		history.Items[0].Latency != 0.5 {
+		t.Fatalf("unexpected history %v", history.Items)
+	}
+	if len(history.Receivers) != 2 {
+		t.Fatalf("unexpected statistics %v", history.Receivers)
+	}
+	email := history.Receivers[1]
+	if email.Receiver != "global-email" || email.Total != 2 || email.Failed != 1 || email.SuccessRate != 0.5 ||
+		email.AverageLatency != 2 || email.P95Latency != 3 {
+		t.Fatalf("unexpected statistics %v", email)
+	}
+
+	// the tenant only sees the records of the receivers owned
+	history, err = o.Query(context.Background(), "admin", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.TotalItems != 1 || history.Items[0].Receiver != "admin-slack" {
+		t.Fatalf("unexpected history %v", history.Items)
+	}
+	if history, _ = o.Query(context.Background(), "tester", opts); history.TotalItems != 0 {
+		t.Fatalf("unexpected history %v", history.Items)
+	}
+
+	opts.Status = kapinotificationv2beta2.HistoryStatusFailed
+	if history, _ = o.Query(context.Background(), "", opts); history.TotalItems != 1 || history.Items[0].Message != "connection refused" {
+		t.Fatalf("unexpected history %v", history.Items)
+	}
+
+	// the time range too long to select the dates by
+	opts.Status, opts.Start = "", now.Add(-60*24*time.Hour)
+	if history, _ = o.Query(context.Background(), "admin", opts); history.TotalItems != 3 {
+		t.Fatalf("unexpected history %v", history.Items)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -44,6 +45,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/simple/client/notification"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification/verifier"
 )
 
 const (
@@ -91,6 +93,8 @@ type Data struct {
 type Result struct {
 	Code    int    `json:"Status"`
 	Message string `json:"Message"`
+	// Receivers are the results of the receivers verified in process.
+	Receivers []*verifier.ReceiverResult `json:"Receivers,omitempty"`
 }
 
 func NewOperator(
@@ -514,15 +518,9 @@ func (o *operator) IsKnownResource(resource, version, subresource string) bool {
 	return res
 }
 
+// Verify sends a test notification to the receiver through the notification manager. The receiver is
+// verified in process if the notification manager is not configured or not reachable.
 func (o *operator) Verify(request *restful.Request, response *restful.Response) {
-	if o.options == nil || len(o.options.Endpoint) == 0 {
-		_ = response.WriteAsJson(Result{
-			http.StatusInternalServerError,
-			"Cannot find Notification Manager endpoint",
-		})
-		return
-	}
-
 	reqBody, err := io.ReadAll(request.Request.Body)
 	if err != nil {
 		klog.Error(err)
@@ -546,6 +544,11 @@ func (o *operator) Verify(request *restful.Request, response *restful.Response)
 		return
 	}
 
+	if o.options == nil || len(o.options.Endpoint) == 0 {
+		o.verifyInProcess(request, response, &data)
+		return
+	}
+
 	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", o.options.Endpoint, VerificationAPIPath), bytes.NewReader(reqBody))
 	if err != nil {
 		klog.Error(err)
@@ -557,8 +560,8 @@ func (o *operator) Verify(request *restful.Request, response *restful.Response)
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		klog.Error(err)
-		_ = response.WriteHeaderAndEntity(http.StatusInternalServerError, err)
+		klog.Warningf("notification manager is not reachable, verify the receiver in process: %v", err)
+		o.verifyInProcess(request, response, &data)
 		return
 	}
 	defer func() {
@@ -578,6 +581,91 @@ func (o *operator) Verify(request *restful.Request, response *restful.Response)
 	_, _ = response.Write(body)
 }
 
+func (o *operator) verifyInProcess(request *restful.Request, response *restful.Response, data *Data) {
+	v := verifier.NewVerifier(func(credential *v2beta2.Credential) (string, error) {
+		return o.resolveCredential(request.Request.Context(), request.PathParameter("user"), &data.Config, credential)
+	})
+	results, err := v.Verify(request.Request.Context(), &data.Config, &data.Receiver)
+	if err != nil {
+		_ = response.WriteAsJson(Result{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	result := Result{Code: http.StatusOK, Message: "Verified successfully", Receivers: results}
+	for _, r := range results {
+		if !r.Success {
+			result.Code, result.Message = http.StatusInternalServerError, "Verification failed"
+			break
+		}
+	}
+	_ = response.WriteAsJson(result)
+}
+
+// resolveCredential reads the value of the credential from the secret, which can only be
+// in the namespace of the notification secrets, and must be owned by the user if any, or
+// be used by the global email config to send to the same smart host as the config.
+func (o *operator) resolveCredential(ctx context.Context, user string, config *v2beta2.Config, credential *v2beta2.Credential) (string, error) {
+	ref := credential.ValueFrom.SecretKeyRef
+	if ref == nil {
+		return "", fmt.Errorf("the secret of the credential is not specified")
+	}
+	if ref.Namespace != "" && ref.Namespace != constants.NotificationSecretNamespace {
+		return "", fmt.Errorf("the secret of the credential must be in the namespace %s", constants.NotificationSecretNamespace)
+	}
+	secret, err := o.k8sClient.CoreV1().Secrets(constants.NotificationSecretNamespace).Get(ctx, ref.Name, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	// the secrets of others must not be sent to the receivers of the user, except the secret of the
+	// global email config, which is shared by the email receivers of the tenants
+	if user != "" && !isOwner(user, secret) && !o.usedByGlobalEmailConfig(config, ref) {
+		return "", errors.NewForbidden(corev1.Resource(Secret), ref.Name,
+			fmt.Errorf("user '%s' is not the owner of the secret", user))
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("the key %s is not found in the secret %s", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
+
+// usedByGlobalEmailConfig reports whether the secret is referenced by the global email config,
+// and the config sends to the same smart host, so that the secret is never sent to other hosts.
+func (o *operator) usedByGlobalEmailConfig(config *v2beta2.Config, ref *v2beta2.SecretKeySelector) bool {
+	if config == nil || config.Spec.Email == nil {
+		return false
+	}
+	globals, err := o.informers.KubeSphereSharedInformerFactory().Notification().V2beta2().Configs().Lister().
+		List(labels.SelectorFromSet(labels.Set{"type": "default"}))
+	if err != nil {
+		klog.Error(err)
+		return false
+	}
+	for _, global := range globals {
+		email := global.Spec.Email
+		if email == nil || email.SmartHost != config.Spec.Email.SmartHost {
+			continue
+		}
+		credentials := []*v2beta2.Credential{email.AuthPassword, email.AuthSecret}
+		if email.TLS != nil {
+			credentials = append(credentials, email.TLS.RootCA)
+			if email.TLS.ClientCertificate != nil {
+				credentials = append(credentials, email.TLS.Cert, email.TLS.Key)
+			}
+		}
+		for _, c := range credentials {
+			if c == nil || c.ValueFrom == nil || c.ValueFrom.SecretKeyRef == nil {
+				continue
+			}
+			if r := c.ValueFrom.SecretKeyRef; r.Name == ref.Name && r.Key == ref.Key &&
+				(r.Namespace == "" || r.Namespace == constants.NotificationSecretNamespace) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Does the user has permission to access this object.
 func authorizer(user string, obj runtime.Object) error {
 	// If the user is not nil, it must equal to the tenant specified in labels of the object.
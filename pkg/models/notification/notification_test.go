@@ -17,6 +17,7 @@ limitations under the License.
 package notification
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -25,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"kubesphere.io/api/notification/v2beta2"
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
@@ -173,6 +175,67 @@ func TestOperator_Delete(t *testing.T) {
 	}
 }
 
+func TestOperator_ResolveCredential(t *testing.T) {
+	k8sClient := fakek8s.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook",
+			Namespace: constants.NotificationSecretNamespace,
+			Labels:    map[string]string{"type": "tenant", "user": "admin"},
+		},
+		Data: map[string][]byte{"token": []byte("secret")},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "email",
+			Namespace: constants.NotificationSecretNamespace,
+		},
+		Data: map[string][]byte{"password": []byte("password")},
+	})
+	fakeInformerFactory := informers.NewInformerFactories(k8sClient, fakeks.NewSimpleClientset(), nil, nil, nil, nil)
+	credential := func(namespace, name, key string) *v2beta2.Credential {
+		return &v2beta2.Credential{ValueFrom: &v2beta2.ValueSource{SecretKeyRef: &v2beta2.SecretKeySelector{
+			Namespace: namespace, Name: name, Key: key,
+		}}}
+	}
+	emailConfig := func(host string) *v2beta2.Config {
+		return &v2beta2.Config{Spec: v2beta2.ConfigSpec{Email: &v2beta2.EmailConfig{
+			SmartHost:    v2beta2.HostPort{Host: host, Port: 25},
+			AuthPassword: credential("", "email", "password"),
+		}}}
+	}
+	global := emailConfig("smtp.example.com")
+	global.Name, global.Labels = "default-email-config", map[string]string{"type": "default"}
+	_ = fakeInformerFactory.KubeSphereSharedInformerFactory().Notification().V2beta2().Configs().Informer().GetIndexer().Add(global)
+	o := &operator{k8sClient: k8sClient, informers: fakeInformerFactory}
+
+	value, err := o.resolveCredential(context.Background(), "admin", nil, credential("", "webhook", "token"))
+	if err != nil || value != "secret" {
+		t.Fatalf("unexpected value %s, %v", value, err)
+	}
+	// the secret of the global email config is shared with the tenants
+	value, err = o.resolveCredential(context.Background(), "tester", emailConfig("smtp.example.com"), credential("", "email", "password"))
+	if err != nil || value != "password" {
+		t.Fatalf("unexpected value %s, %v", value, err)
+	}
+	for _, test := range []struct {
+		user       string
+		config     *v2beta2.Config
+		credential *v2beta2.Credential
+	}{
+		// the secret of another user
+		{user: "tester", credential: credential("", "webhook", "token")},
+		// the secret out of the namespace of the notification secrets
+		{credential: credential("default", "webhook", "token")},
+		{user: "admin", credential: credential("", "webhook", "unknown")},
+		// the secret of the global email config sent to another host
+		{user: "tester", config: emailConfig("smtp.attacker.com"), credential: credential("", "email", "password")},
+		{user: "tester", credential: credential("", "email", "password")},
+	} {
+		if _, err := o.resolveCredential(context.Background(), test.user, test.config, test.credential); err == nil {
+			t.Fatalf("expected an error resolving %v for user %q", test.credential.ValueFrom.SecretKeyRef, test.user)
+		}
+	}
+}
+
 var (
 	secret1 = &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
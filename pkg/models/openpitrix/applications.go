@@ -28,6 +28,8 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	k8sinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -40,6 +42,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/models"
 	"kubesphere.io/kubesphere/pkg/server/params"
+	openpitrixoptions "kubesphere.io/kubesphere/pkg/simple/client/openpitrix"
 	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmrepoindex"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/utils/idutils"
@@ -81,10 +84,14 @@ type applicationOperator struct {
 	ctgLister  listers_v1alpha1.HelmCategoryLister
 	rlsLister  listers_v1alpha1.HelmReleaseLister
 
+	// the verification policies of the workspaces and the secrets of the trusted keys to verify the charts
+	verification *openpitrixoptions.ChartVerificationOptions
+	secretLister corelisters.SecretLister
+
 	cachedRepos reposcache.ReposCache
 }
 
-func newApplicationOperator(cached reposcache.ReposCache, informers externalversions.SharedInformerFactory, ksClient versioned.Interface, storeClient s3.Interface) ApplicationInterface {
+func newApplicationOperator(cached reposcache.ReposCache, k8sFactory k8sinformers.SharedInformerFactory, informers externalversions.SharedInformerFactory, ksClient versioned.Interface, storeClient s3.Interface, verification *openpitrixoptions.ChartVerificationOptions) ApplicationInterface {
 	op := &applicationOperator{
 		backingStoreClient: storeClient,
 		informers:          informers,
@@ -99,6 +106,9 @@ func newApplicationOperator(cached reposcache.ReposCache, informers externalvers
 		ctgLister:   informers.Application().V1alpha1().HelmCategories().Lister(),
 		rlsLister:   informers.Application().V1alpha1().HelmReleases().Lister(),
 		cachedRepos: cached,
+
+		verification: verification,
+		secretLister: k8sFactory.Core().V1().Secrets().Lister(),
 	}
 
 	return op
@@ -177,6 +187,11 @@ func (c *applicationOperator) ValidatePackage(request *ValidatePackageRequest) (
 		result.Description = chrt.GetDescription()
 		result.URL = chrt.GetUrls()
 		result.Icon = chrt.GetIcon()
+
+		result.Verification, err = c.verifyChart(request.Workspace, request.VersionPackage, request.VersionSignature)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return result, nil
@@ -243,6 +258,10 @@ func (c *applicationOperator) CreateApp(req *CreateAppRequest) (*CreateAppRespon
 		klog.Errorf("load package %s/%s failed, error: %s", req.Isv, req.Name, err)
 		return nil, err
 	}
+	verification, err := c.verifyChart(req.Isv, req.VersionPackage, req.VersionSignature)
+	if err != nil {
+		return nil, err
+	}
 
 	// create helm application
 	name := idutils.GetUuid36(v1alpha1.HelmApplicationIdPrefix)
@@ -276,6 +295,7 @@ func (c *applicationOperator) CreateApp(req *CreateAppRequest) (*CreateAppRespon
 	// create app version
 	chartPackage := req.VersionPackage.String()
 	ver := buildApplicationVersion(app, chrt, &chartPackage, req.Username)
+	ver.Status.Verification = verification
 	ver, err = c.createApplicationVersion(ver)
 
 	if err != nil {
@@ -81,8 +81,13 @@ func (c *applicationOperator) CreateAppVersion(request *CreateAppVersionRequest)
 		klog.Errorf("get app %s failed, error: %s", request.AppId, err)
 		return nil, err
 	}
+	verification, err := c.verifyChart(app.GetWorkspace(), request.Package, request.Signature)
+	if err != nil {
+		return nil, err
+	}
 	chartPackage := request.Package.String()
 	version := buildApplicationVersion(app, chrt, &chartPackage, request.Username)
+	version.Status.Verification = verification
 	version, err = c.createApplicationVersion(version)
 
 	if err != nil {
@@ -165,6 +170,7 @@ func (c *applicationOperator) ModifyAppVersion(id string, request *ModifyAppVers
 
 	versionCopy := version.DeepCopy()
 	spec := &versionCopy.Spec
+	var verification *v1alpha1.ChartVerificationResult
 
 	// extract information from chart package
 	if len(request.Package) > 0 {
@@ -194,6 +200,11 @@ func (c *applicationOperator) ModifyAppVersion(id string, request *ModifyAppVers
 			}
 		}
 
+		verification, err = c.verifyChart(version.GetWorkspace(), request.Package, request.Signature)
+		if err != nil {
+			return err
+		}
+
 		// 2. update crd info
 		spec.Version = chart.GetVersion()
 		spec.AppVersion = chart.GetAppVersion()
@@ -239,15 +250,17 @@ func (c *applicationOperator) ModifyAppVersion(id string, request *ModifyAppVers
 	}
 
 	// data == "{}", need not to patch
-	if len(data) == 2 {
-		return nil
-	}
-
-	_, err = c.appVersionClient.Patch(context.TODO(), id, patch.Type(), data, metav1.PatchOptions{})
+	if len(data) > 2 {
+		_, err = c.appVersionClient.Patch(context.TODO(), id, patch.Type(), data, metav1.PatchOptions{})
 
-	if err != nil {
-		klog.Error(err)
-		return err
+		if err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+	if len(request.Package) > 0 {
+		// the package is replaced, so is the verification result, which is removed if not required
+		return c.updateAppVersionVerification(id, verification)
 	}
 	return nil
 }
@@ -381,6 +394,7 @@ func (c *applicationOperator) DoAppVersionAction(versionId string, request *Acti
 	case ActionRecover:
 		state = v1alpha1.StateActive
 		audit.State = v1alpha1.StateActive
+		err = c.checkChartVerification(version)
 	case ActionReject:
 		// todo check status
 		state = v1alpha1.StateRejected
@@ -396,6 +410,8 @@ func (c *applicationOperator) DoAppVersionAction(versionId string, request *Acti
 		// release to app store
 		state = v1alpha1.StateActive
 		audit.State = v1alpha1.StateActive
+		// only the signed charts are released if the workspace requires
+		err = c.checkChartVerification(version)
 	default:
 		err = errors.New("action not support")
 	}
@@ -496,6 +512,10 @@ func (c *applicationOperator) createApplicationVersion(ver *v1alpha1.HelmApplica
 	version, err := c.appVersionClient.Create(context.TODO(), ver, metav1.CreateOptions{})
 	if err == nil {
 		klog.V(4).Infof("create helm application %s version success", version.Name)
+		// the status is not saved on creation
+		if ver.Status.Verification != nil {
+			err = c.updateAppVersionVerification(version.Name, ver.Status.Verification)
+		}
 	}
 
 	return version, err
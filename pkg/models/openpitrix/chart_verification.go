@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openpitrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/api/application/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmrepoindex"
+)
+
+// chartVerification returns the verification policy of the workspace, which is nil if the charts uploaded to
+// the workspace are not required to be signed. The policies are configured by the platform administrators only.
+func (c *applicationOperator) chartVerification(workspace string) *v1alpha1.ChartVerification {
+	if workspace == "" || c.verification == nil {
+		return nil
+	}
+	return c.verification.Workspaces[workspace]
+}
+
+// verifyChart verifies the signature of the chart package uploaded to the workspace.
+// The result is nil if the workspace does not require signed charts.
+func (c *applicationOperator) verifyChart(workspace string, chartPackage, signature []byte) (*v1alpha1.ChartVerificationResult, error) {
+	verification := c.chartVerification(workspace)
+	if verification == nil {
+		return nil, nil
+	}
+
+	result := &v1alpha1.ChartVerificationResult{
+		Provider: verification.Provider,
+		Time:     metav1.Now(),
+	}
+	keys, err := c.secretLister.Secrets(constants.KubeSphereNamespace).Get(verification.SecretRef)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("get trusted keys %s failed, error: %s", verification.SecretRef, err)
+			return nil, err
+		}
+		result.Message = fmt.Sprintf("trusted keys %s not found", verification.SecretRef)
+		return result, nil
+	}
+
+	signer, err := helmrepoindex.VerifyChart(verification.Provider, keys.Data, chartPackage, signature)
+	if err != nil {
+		result.Message = err.Error()
+	} else {
+		result.Verified = true
+		result.Signer = signer
+	}
+	return result, nil
+}
+
+// checkChartVerification forbids the version to be released unless it is signed by a trusted key,
+// if the workspace requires signed charts.
+func (c *applicationOperator) checkChartVerification(version *v1alpha1.HelmApplicationVersion) error {
+	verification := c.chartVerification(version.GetWorkspace())
+	if verification == nil {
+		return nil
+	}
+	result := version.Status.Verification
+	if result == nil || !result.Verified || result.Provider != verification.Provider {
+		return apierrors.NewForbidden(v1alpha1.Resource(v1alpha1.ResourcePluralHelmApplicationVersion), version.Name,
+			fmt.Errorf("the chart is required to be signed with %s by a trusted key", verification.Provider))
+	}
+	return nil
+}
+
+// updateAppVersionVerification patches the verification result to the status of the version, the other fields
+// of the status are left to the controller. The result is removed if nil.
+func (c *applicationOperator) updateAppVersionVerification(versionId string, result *v1alpha1.ChartVerificationResult) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"verification": result},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.appVersionClient.Patch(context.TODO(), versionId, types.MergePatchType, data, metav1.PatchOptions{}, "status")
+	if err != nil {
+		klog.Errorf("update verification of app version %s failed, error: %s", versionId, err)
+	}
+	return err
+}
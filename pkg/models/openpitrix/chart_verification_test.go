@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openpitrix
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/api/application/v1alpha1"
+
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+	openpitrixoptions "kubesphere.io/kubesphere/pkg/simple/client/openpitrix"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3/fake"
+	"kubesphere.io/kubesphere/pkg/utils/reposcache"
+)
+
+func TestChartVerification(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verification := &openpitrixoptions.ChartVerificationOptions{
+		Workspaces: map[string]*v1alpha1.ChartVerification{
+			testWorkspace: {Provider: v1alpha1.ChartVerificationCosign, SecretRef: "trusted-keys"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: constants.KubeSphereNamespace, Name: "trusted-keys"},
+		Data:       map[string][]byte{"cosign.pub": pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})},
+	}
+
+	ksClient := fakeks.NewSimpleClientset()
+	informerFactory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), ksClient, nil, nil, nil, nil)
+	ksInformers := informerFactory.KubeSphereSharedInformerFactory()
+	_ = informerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets().Informer().GetIndexer().Add(secret)
+	appOperator := newApplicationOperator(reposcache.NewReposCache(), informerFactory.KubernetesSharedInformerFactory(), ksInformers, ksClient, fake.NewFakeS3(), verification)
+
+	// sync the objects created to the listers
+	syncListers := func() {
+		apps, _ := ksClient.ApplicationV1alpha1().HelmApplications().List(context.TODO(), metav1.ListOptions{})
+		for i := range apps.Items {
+			_ = ksInformers.Application().V1alpha1().HelmApplications().Informer().GetIndexer().Update(&apps.Items[i])
+		}
+		versions, _ := ksClient.ApplicationV1alpha1().HelmApplicationVersions().List(context.TODO(), metav1.ListOptions{})
+		for i := range versions.Items {
+			_ = ksInformers.Application().V1alpha1().HelmApplicationVersions().Informer().GetIndexer().Update(&versions.Items[i])
+		}
+	}
+
+	chartData, _ := base64.RawStdEncoding.DecodeString(rawChartData)
+	digest := sha256.Sum256(chartData)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := []byte(base64.StdEncoding.EncodeToString(sig))
+
+	validateResp, err := appOperator.ValidatePackage(&ValidatePackageRequest{
+		VersionPackage:   chartData,
+		VersionSignature: signature,
+		Workspace:        testWorkspace,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validateResp.Verification == nil || !validateResp.Verification.Verified || validateResp.Verification.Signer != "cosign.pub" {
+		t.Fatalf("unexpected verification %v", validateResp.Verification)
+	}
+	// the charts uploaded to the other workspaces are not required to be signed
+	validateResp, err = appOperator.ValidatePackage(&ValidatePackageRequest{VersionPackage: chartData, Workspace: "other"})
+	if err != nil || validateResp.Verification != nil {
+		t.Fatalf("unexpected verification %v, %v", validateResp, err)
+	}
+
+	// the unsigned chart can be uploaded, but not released
+	createAppResp, err := appOperator.CreateApp(&CreateAppRequest{
+		Isv:            testWorkspace,
+		Name:           "test-chart",
+		VersionName:    "0.1.0",
+		VersionPackage: strfmt.Base64(chartData),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncListers()
+
+	version, err := appOperator.DescribeAppVersion(createAppResp.VersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Verification == nil || version.Verification.Verified || version.Verification.Message != "the chart is not signed" {
+		t.Fatalf("unexpected verification %v", version.Verification)
+	}
+	err = appOperator.DoAppVersionAction(createAppResp.VersionID, &ActionRequest{Action: ActionRelease})
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("expected the unsigned version not released, got %v", err)
+	}
+
+	// the package is replaced by the signed one
+	err = appOperator.ModifyAppVersion(createAppResp.VersionID, &ModifyAppVersionRequest{Package: chartData, Signature: signature})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncListers()
+
+	version, err = appOperator.DescribeAppVersion(createAppResp.VersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Verification == nil || !version.Verification.Verified || version.Verification.Provider != v1alpha1.ChartVerificationCosign {
+		t.Fatalf("unexpected verification %v", version.Verification)
+	}
+	if err := appOperator.DoAppVersionAction(createAppResp.VersionID, &ActionRequest{Action: ActionRelease}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -45,6 +45,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/models"
 	"kubesphere.io/kubesphere/pkg/server/params"
 	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmwrapper"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/utils/clusterclient"
 	"kubesphere.io/kubesphere/pkg/utils/idutils"
 	"kubesphere.io/kubesphere/pkg/utils/reposcache"
@@ -59,6 +60,14 @@ type ReleaseInterface interface {
 	ModifyApplication(request ModifyClusterAttributesRequest) error
 	DeleteApplication(workspace, clusterName, namespace, id string) error
 	UpgradeApplication(request UpgradeClusterRequest, applicationId string) error
+	// ListRevisions lists the helm revisions of the application, the latest first
+	ListRevisions(workspace, clusterName, namespace, applicationId string) ([]*ReleaseRevision, error)
+	// DiffRevisions compares the values and the manifests of the two revisions, to is the latest revision if it is 0
+	DiffRevisions(workspace, clusterName, namespace, applicationId string, from, to int) (*ReleaseRevisionDiff, error)
+	RollbackApplication(request RollbackApplicationRequest, applicationId string) error
+	// DryRunApplication renders the application upgraded with the request without changing anything,
+	// and compares the manifest rendered with the live one
+	DryRunApplication(request UpgradeClusterRequest, applicationId string) (*ReleaseDryRunResult, error)
 }
 
 type releaseOperator struct {
@@ -68,16 +77,20 @@ type releaseOperator struct {
 	appVersionLister listers_v1alpha1.HelmApplicationVersionLister
 	cachedRepos      reposcache.ReposCache
 	clusterClients   clusterclient.ClusterClients
+	storeClient      s3.Interface
+	helmWrapper      func(kubeconfig, namespace, name string, options ...helmwrapper.Option) helmwrapper.HelmWrapper
 }
 
-func newReleaseOperator(cached reposcache.ReposCache, k8sFactory informers.SharedInformerFactory, ksFactory externalversions.SharedInformerFactory, ksClient versioned.Interface, cc clusterclient.ClusterClients) ReleaseInterface {
+func newReleaseOperator(cached reposcache.ReposCache, k8sFactory informers.SharedInformerFactory, ksFactory externalversions.SharedInformerFactory, ksClient versioned.Interface, cc clusterclient.ClusterClients, storeClient s3.Interface) ReleaseInterface {
 	c := &releaseOperator{
 		informers:        k8sFactory,
 		rlsClient:        ksClient.ApplicationV1alpha1().HelmReleases(),
 		rlsLister:        ksFactory.Application().V1alpha1().HelmReleases().Lister(),
 		cachedRepos:      cached,
 		clusterClients:   cc,
+		storeClient:      storeClient,
 		appVersionLister: ksFactory.Application().V1alpha1().HelmApplicationVersions().Lister(),
+		helmWrapper:      newHelmWrapper,
 	}
 
 	return c
@@ -121,10 +134,14 @@ func (c *releaseOperator) UpgradeApplication(request UpgradeClusterRequest, appl
 	newRls.Spec.RepoId = version.GetHelmRepoId()
 	newRls.Spec.ChartVersion = version.GetChartVersion()
 	newRls.Spec.ChartAppVersion = version.GetChartAppVersion()
+	newRls.Spec.RollbackRevision = 0
 	// Use the new conf if the client has one, or server will just use the old conf.
 	if request.Conf != "" {
 		newRls.Spec.Values = strfmt.Base64(request.Conf)
 	}
+	if err := c.validateValues(request.VersionId, newRls.Spec.Values); err != nil {
+		return err
+	}
 
 	patch := client.MergeFrom(oldRls)
 	data, _ := patch.Data(newRls)
@@ -162,6 +179,10 @@ func (c *releaseOperator) CreateApplication(workspace, clusterName, namespace st
 		return err
 	}
 
+	if err := c.validateValues(request.VersionId, []byte(request.Conf)); err != nil {
+		return err
+	}
+
 	rls := &v1alpha1.HelmRelease{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: idutils.GetUuid36(v1alpha1.HelmReleasePrefix),
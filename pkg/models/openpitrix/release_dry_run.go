@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openpitrix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/api/application/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmwrapper"
+)
+
+// getAppVersionWithData gets the app version with the chart package, which is loaded from the repo,
+// or from the storage if the version is uploaded to the app store.
+func (c *releaseOperator) getAppVersionWithData(versionId string) (*v1alpha1.HelmApplicationVersion, error) {
+	if version, exists, err := c.cachedRepos.GetAppVersionWithData(versionId); exists {
+		if err != nil {
+			return nil, err
+		}
+		return version, nil
+	}
+
+	version, err := c.appVersionLister.Get(versionId)
+	if err != nil {
+		klog.Errorf("get app version %s failed, error: %s", versionId, err)
+		return nil, err
+	}
+	if c.storeClient == nil {
+		return nil, invalidS3Config
+	}
+	data, err := c.storeClient.Read(dataKeyInStorage(version.GetWorkspace(), versionId))
+	if err != nil {
+		klog.Errorf("load chart data for app version: %s/%s failed, error : %s", version.GetWorkspace(),
+			version.GetTrueName(), err)
+		return nil, downloadFileFailed
+	}
+	version = version.DeepCopy()
+	version.Spec.Data = data
+	return version, nil
+}
+
+// loadChart loads the chart of the app version.
+func (c *releaseOperator) loadChart(versionId string) (*v1alpha1.HelmApplicationVersion, *chart.Chart, error) {
+	version, err := c.getAppVersionWithData(versionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	chrt, err := loader.LoadArchive(bytes.NewReader(version.Spec.Data))
+	if err != nil {
+		klog.Errorf("load chart of app version %s failed, error: %s", versionId, err)
+		return nil, nil, err
+	}
+	return version, chrt, nil
+}
+
+// validateValues validates the values against the values.schema.json of the chart of the app version,
+// so that the mistakes are rejected before the release is created or upgraded.
+func (c *releaseOperator) validateValues(versionId string, values []byte) error {
+	_, chrt, err := c.loadChart(versionId)
+	if err != nil {
+		return err
+	}
+	return validateChartValues(chrt, values)
+}
+
+func validateChartValues(chrt *chart.Chart, values []byte) error {
+	vals, err := chartutil.ReadValues(values)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("invalid values: %s", err))
+	}
+	// the schema is validated with the default values of the chart, as helm does
+	coalesced, err := chartutil.CoalesceValues(chrt, vals)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("invalid values: %s", err))
+	}
+	if err := chartutil.ValidateAgainstSchema(chrt, coalesced); err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	return nil
+}
+
+func (c *releaseOperator) DryRunApplication(request UpgradeClusterRequest, applicationId string) (*ReleaseDryRunResult, error) {
+	rls, err := c.getRelease(request.Namespace, applicationId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the current version and values are used unless the request has new ones
+	versionId := request.VersionId
+	if versionId == "" {
+		versionId = rls.Spec.ApplicationVersionId
+	}
+	values := []byte(rls.Spec.Values)
+	if request.Conf != "" {
+		values = []byte(request.Conf)
+	}
+
+	version, chrt, err := c.loadChart(versionId)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateChartValues(chrt, values); err != nil {
+		return nil, err
+	}
+
+	clusterConfig, err := c.clusterConfig(rls)
+	if err != nil {
+		return nil, err
+	}
+	// the same labels and annotations as the controller, so that they are not taken as changes
+	hw := c.helmWrapper(clusterConfig, rls.GetRlsNamespace(), rls.Spec.Name,
+		helmwrapper.SetAnnotations(map[string]string{constants.CreatorAnnotationKey: rls.GetCreator()}),
+		helmwrapper.SetLabels(map[string]string{
+			v1alpha1.ApplicationInstance: rls.GetTrueName(),
+		}))
+
+	manifest, err := hw.DryRun(version.GetTrueName(), string(version.Spec.Data), string(values))
+	if err != nil {
+		klog.Errorf("dry run release %s/%s failed, error: %s", request.Namespace, applicationId, err)
+		return nil, err
+	}
+	live, err := hw.Manifest()
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, err
+	}
+
+	result := &ReleaseDryRunResult{Manifest: manifest}
+	result.Diff, err = unifiedDiff(live, manifest, "live", "dry-run", "manifest.yaml")
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openpitrix
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/api/application/v1alpha1"
+
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmwrapper"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3/fake"
+	"kubesphere.io/kubesphere/pkg/utils/reposcache"
+)
+
+var valuesSchema = []byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer", "minimum": 1}
+  },
+  "required": ["replicas"]
+}`)
+
+// packageChart packages the chart with the values schema as `helm package` does.
+func packageChart(t *testing.T) []byte {
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "nginx", Version: "1.0.0"},
+		Raw:      []*chart.File{{Name: chartutil.ValuesfileName, Data: []byte("replicas: 1\n")}},
+		Schema:   valuesSchema,
+	}
+	name, err := chartutil.Save(chrt, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReleaseDryRun(t *testing.T) {
+	rls := &v1alpha1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "rls-nginx",
+			Labels: map[string]string{constants.NamespaceLabelKey: "default"},
+		},
+		Spec: v1alpha1.HelmReleaseSpec{
+			Name:                 "nginx",
+			ChartName:            "nginx",
+			ChartVersion:         "1.0.0",
+			ApplicationId:        "app-nginx",
+			ApplicationVersionId: "appv-nginx",
+			Values:               []byte("replicas: 1\n"),
+			Version:              1,
+		},
+		Status: v1alpha1.HelmReleaseStatus{State: v1alpha1.HelmStatusActive, Version: 1},
+	}
+	appVersion := &v1alpha1.HelmApplicationVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "appv-nginx",
+			Labels: map[string]string{
+				constants.ChartApplicationIdLabelKey: "app-nginx",
+				constants.WorkspaceLabelKey:          testWorkspace,
+			},
+		},
+		Spec: v1alpha1.HelmApplicationVersionSpec{Metadata: &v1alpha1.Metadata{Name: "nginx", Version: "1.0.0"}},
+	}
+
+	ksClient := fakeks.NewSimpleClientset(rls)
+	informerFactory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), ksClient, nil, nil, nil, nil)
+	ksInformers := informerFactory.KubeSphereSharedInformerFactory()
+	_ = ksInformers.Application().V1alpha1().HelmReleases().Informer().GetIndexer().Add(rls)
+	_ = ksInformers.Application().V1alpha1().HelmApplicationVersions().Informer().GetIndexer().Add(appVersion)
+
+	storeClient := fake.NewFakeS3()
+	if err := storeClient.Upload(dataKeyInStorage(testWorkspace, appVersion.Name), appVersion.Name,
+		strings.NewReader(string(packageChart(t))), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	o := newReleaseOperator(reposcache.NewReposCache(), informerFactory.KubernetesSharedInformerFactory(), ksInformers, ksClient, nil, storeClient).(*releaseOperator)
+	wrapper := &fakeHelmWrapper{manifest: "kind: Deployment\nspec:\n  replicas: 1\n"}
+	o.helmWrapper = func(kubeconfig, namespace, name string, options ...helmwrapper.Option) helmwrapper.HelmWrapper {
+		return wrapper
+	}
+
+	if err := o.validateValues(appVersion.Name, []byte("replicas: 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	// the default values of the chart are validated as well
+	if err := o.validateValues(appVersion.Name, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, values := range []string{"replicas: 0\n", "replicas: two\n", "replicas: [\n"} {
+		if err := o.validateValues(appVersion.Name, []byte(values)); !apierrors.IsBadRequest(err) {
+			t.Fatalf("expected values %q rejected, got %v", values, err)
+		}
+	}
+
+	err := o.CreateApplication(testWorkspace, "", "default", CreateClusterRequest{
+		Name:      "nginx-invalid",
+		AppId:     "app-nginx",
+		VersionId: appVersion.Name,
+		Conf:      "replicas: 0\n",
+	})
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("expected the release with invalid values not created, got %v", err)
+	}
+	err = o.UpgradeApplication(UpgradeClusterRequest{
+		Namespace: "default",
+		AppId:     "app-nginx",
+		VersionId: appVersion.Name,
+		Conf:      "replicas: -1\n",
+	}, rls.Name)
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("expected the release not upgraded with invalid values, got %v", err)
+	}
+
+	result, err := o.DryRunApplication(UpgradeClusterRequest{Namespace: "default", Conf: "replicas: 3\n"}, rls.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Manifest != "kind: Deployment\nspec:\n  replicas: 3\n" ||
+		!strings.Contains(result.Diff, "-  replicas: 1\n+  replicas: 3\n") {
+		t.Fatalf("unexpected dry run result %v", result)
+	}
+
+	// the values of the release are used if the request has none
+	result, err = o.DryRunApplication(UpgradeClusterRequest{Namespace: "default"}, rls.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapper.values != "replicas: 1\n" || result.Diff != "" {
+		t.Fatalf("unexpected dry run result %v", result)
+	}
+
+	if _, err := o.DryRunApplication(UpgradeClusterRequest{Namespace: "default", Conf: "replicas: 0\n"}, rls.Name); !apierrors.IsBadRequest(err) {
+		t.Fatalf("expected the dry run with invalid values rejected, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openpitrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/pmezard/go-difflib/difflib"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/api/application/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmwrapper"
+)
+
+func newHelmWrapper(kubeconfig, namespace, name string, options ...helmwrapper.Option) helmwrapper.HelmWrapper {
+	return helmwrapper.NewHelmWrapper(kubeconfig, namespace, name, options...)
+}
+
+// getRelease gets the release in the namespace.
+func (c *releaseOperator) getRelease(namespace, applicationId string) (*v1alpha1.HelmRelease, error) {
+	rls, err := c.rlsLister.Get(applicationId)
+	if err != nil {
+		klog.Errorf("get release %s/%s failed, error: %s", namespace, applicationId, err)
+		return nil, err
+	}
+	if namespace != "" && rls.GetRlsNamespace() != namespace {
+		return nil, apierrors.NewNotFound(v1alpha1.Resource(v1alpha1.ResourcePluralHelmRelease), applicationId)
+	}
+	return rls, nil
+}
+
+// clusterConfig returns the kubeconfig of the cluster the release is installed in,
+// which is empty if the release is installed in current host.
+func (c *releaseOperator) clusterConfig(rls *v1alpha1.HelmRelease) (string, error) {
+	clusterName := rls.GetRlsCluster()
+	if clusterName == "" || c.clusterClients == nil {
+		return "", nil
+	}
+	cluster, err := c.clusterClients.Get(clusterName)
+	if err != nil {
+		klog.Errorf("get cluster config failed, error: %s", err)
+		return "", err
+	}
+	if c.clusterClients.IsHostCluster(cluster) {
+		return "", nil
+	}
+	clusterConfig, err := c.clusterClients.GetClusterKubeconfig(clusterName)
+	if err != nil {
+		klog.Errorf("get cluster config failed, error: %s", err)
+		return "", err
+	}
+	return clusterConfig, nil
+}
+
+// releaseHistory returns the helm revisions of the release, the oldest first.
+func (c *releaseOperator) releaseHistory(rls *v1alpha1.HelmRelease) ([]*helmrelease.Release, error) {
+	clusterConfig, err := c.clusterConfig(rls)
+	if err != nil {
+		return nil, err
+	}
+	hw := c.helmWrapper(clusterConfig, rls.GetRlsNamespace(), rls.Spec.Name)
+	return hw.History()
+}
+
+func (c *releaseOperator) ListRevisions(workspace, clusterName, namespace, applicationId string) ([]*ReleaseRevision, error) {
+	rls, err := c.getRelease(namespace, applicationId)
+	if err != nil {
+		return nil, err
+	}
+	history, err := c.releaseHistory(rls)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*ReleaseRevision, 0, len(history))
+	// the latest first
+	for i := len(history) - 1; i >= 0; i-- {
+		revisions = append(revisions, convertRevision(history[i]))
+	}
+	return revisions, nil
+}
+
+func (c *releaseOperator) DiffRevisions(workspace, clusterName, namespace, applicationId string, from, to int) (*ReleaseRevisionDiff, error) {
+	rls, err := c.getRelease(namespace, applicationId)
+	if err != nil {
+		return nil, err
+	}
+	history, err := c.releaseHistory(rls)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, apierrors.NewBadRequest("the release has no revision")
+	}
+
+	// compare with the latest revision by default
+	if to == 0 {
+		to = history[len(history)-1].Version
+	}
+	fromRelease, toRelease := findRevision(history, from), findRevision(history, to)
+	if fromRelease == nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("revision %d not found", from))
+	}
+	if toRelease == nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("revision %d not found", to))
+	}
+	return diffRevisions(fromRelease, toRelease)
+}
+
+func (c *releaseOperator) RollbackApplication(request RollbackApplicationRequest, applicationId string) error {
+	oldRls, err := c.getRelease(request.Namespace, applicationId)
+	if err != nil {
+		return err
+	}
+
+	switch oldRls.Status.State {
+	case v1alpha1.HelmStatusActive, v1alpha1.HelmStatusUpgraded, v1alpha1.HelmStatusCreated, v1alpha1.HelmStatusFailed:
+		// no operation
+	default:
+		return errors.New("can not rollback application now")
+	}
+
+	history, err := c.releaseHistory(oldRls)
+	if err != nil {
+		return err
+	}
+	target := findRevision(history, request.Revision)
+	if target == nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("revision %d not found", request.Revision))
+	}
+	if target.Info != nil && target.Info.Status == helmrelease.StatusDeployed {
+		return apierrors.NewBadRequest(fmt.Sprintf("revision %d is deployed already", request.Revision))
+	}
+
+	newRls := oldRls.DeepCopy()
+	newRls.Spec.Version += 1
+	newRls.Spec.RollbackRevision = request.Revision
+	// the spec follows the revision rolled back to, so that the release can be upgraded from it
+	newRls.Spec.Values, err = revisionValues(target)
+	if err != nil {
+		return err
+	}
+	if target.Chart != nil && target.Chart.Metadata != nil {
+		newRls.Spec.ChartVersion = target.Chart.Metadata.Version
+		newRls.Spec.ChartAppVersion = target.Chart.Metadata.AppVersion
+		if version := c.findAppVersion(oldRls, target.Chart.Metadata.Version); version != nil {
+			newRls.Spec.ApplicationVersionId = version.Name
+		}
+	}
+
+	patch := client.MergeFrom(oldRls)
+	data, _ := patch.Data(newRls)
+
+	_, err = c.rlsClient.Patch(context.TODO(), applicationId, patch.Type(), data, metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("patch release %s/%s failed, error: %s", request.Namespace, applicationId, err)
+		return err
+	}
+	klog.V(2).Infof("rollback release %s/%s to revision %d by %s", request.Namespace, applicationId, request.Revision, request.Username)
+	return nil
+}
+
+// findAppVersion finds the version of the application of the release by the chart version.
+func (c *releaseOperator) findAppVersion(rls *v1alpha1.HelmRelease, chartVersion string) *v1alpha1.HelmApplicationVersion {
+	var versions []*v1alpha1.HelmApplicationVersion
+	if rls.Spec.RepoId != "" && rls.Spec.RepoId != v1alpha1.AppStoreRepoId {
+		versions, _ = c.cachedRepos.ListAppVersionsByAppId(rls.Spec.ApplicationId)
+	} else {
+		var err error
+		versions, err = c.appVersionLister.List(labels.SelectorFromSet(map[string]string{constants.ChartApplicationIdLabelKey: rls.Spec.ApplicationId}))
+		if err != nil {
+			klog.Errorf("list app version failed, error: %s", err)
+			return nil
+		}
+	}
+	for _, version := range versions {
+		if version.GetChartVersion() == chartVersion {
+			return version
+		}
+	}
+	return nil
+}
+
+func findRevision(history []*helmrelease.Release, revision int) *helmrelease.Release {
+	for _, rel := range history {
+		if rel.Version == revision {
+			return rel
+		}
+	}
+	return nil
+}
+
+// revisionValues returns the values supplied to the revision in yaml.
+func revisionValues(rel *helmrelease.Release) ([]byte, error) {
+	if len(rel.Config) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(rel.Config)
+}
+
+func convertRevision(rel *helmrelease.Release) *ReleaseRevision {
+	revision := &ReleaseRevision{Revision: rel.Version}
+	if rel.Info != nil {
+		revision.Status = rel.Info.Status.String()
+		revision.Description = rel.Info.Description
+		if !rel.Info.LastDeployed.IsZero() {
+			updated := strfmt.DateTime(rel.Info.LastDeployed.Time)
+			revision.Updated = &updated
+		}
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		revision.ChartName = rel.Chart.Metadata.Name
+		revision.ChartVersion = rel.Chart.Metadata.Version
+		revision.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	if values, err := revisionValues(rel); err == nil {
+		revision.Values = string(values)
+	}
+	return revision
+}
+
+func diffRevisions(from, to *helmrelease.Release) (*ReleaseRevisionDiff, error) {
+	result := &ReleaseRevisionDiff{From: from.Version, To: to.Version}
+
+	fromRevision, toRevision := convertRevision(from), convertRevision(to)
+	if fromRevision.ChartVersion != toRevision.ChartVersion {
+		result.ChartVersion = fmt.Sprintf("%s -> %s", fromRevision.ChartVersion, toRevision.ChartVersion)
+	}
+
+	var err error
+	fromDir, toDir := fmt.Sprintf("revision-%d", from.Version), fmt.Sprintf("revision-%d", to.Version)
+	result.Values, err = unifiedDiff(fromRevision.Values, toRevision.Values, fromDir, toDir, "values.yaml")
+	if err != nil {
+		return nil, err
+	}
+	result.Manifest, err = unifiedDiff(from.Manifest, to.Manifest, fromDir, toDir, "manifest.yaml")
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func unifiedDiff(a, b string, fromDir, toDir, name string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: fromDir + "/" + name,
+		ToFile:   toDir + "/" + name,
+		Context:  3,
+	})
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openpitrix
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/api/application/v1alpha1"
+
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmwrapper"
+	"kubesphere.io/kubesphere/pkg/utils/reposcache"
+)
+
+type fakeHelmWrapper struct {
+	helmwrapper.HelmWrapper
+	history  []*helmrelease.Release
+	manifest string
+	// values of the last dry run
+	values string
+}
+
+func (w *fakeHelmWrapper) History() ([]*helmrelease.Release, error) {
+	return w.history, nil
+}
+
+func (w *fakeHelmWrapper) Manifest() (string, error) {
+	return w.manifest, nil
+}
+
+func (w *fakeHelmWrapper) DryRun(chartName, chartData, values string) (string, error) {
+	w.values = values
+	return "kind: Deployment\nspec:\n  " + strings.TrimSpace(values) + "\n", nil
+}
+
+func newRevision(revision int, status helmrelease.Status, chartVersion string, values map[string]interface{}, manifest string) *helmrelease.Release {
+	return &helmrelease.Release{
+		Name:     "nginx",
+		Version:  revision,
+		Info:     &helmrelease.Info{Status: status},
+		Chart:    &chart.Chart{Metadata: &chart.Metadata{Name: "nginx", Version: chartVersion}},
+		Config:   values,
+		Manifest: manifest,
+	}
+}
+
+func TestReleaseRevisions(t *testing.T) {
+	rls := &v1alpha1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "rls-nginx",
+			Labels: map[string]string{constants.NamespaceLabelKey: "default"},
+		},
+		Spec: v1alpha1.HelmReleaseSpec{
+			Name:                 "nginx",
+			ChartName:            "nginx",
+			ChartVersion:         "1.1.0",
+			ApplicationId:        "app-nginx",
+			ApplicationVersionId: "appv-nginx-2",
+			Values:               []byte("replicas: 3\n"),
+			Version:              2,
+		},
+		Status: v1alpha1.HelmReleaseStatus{State: v1alpha1.HelmStatusActive, Version: 2},
+	}
+	appVersion := &v1alpha1.HelmApplicationVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "appv-nginx-1",
+			Labels: map[string]string{constants.ChartApplicationIdLabelKey: "app-nginx"},
+		},
+		Spec: v1alpha1.HelmApplicationVersionSpec{Metadata: &v1alpha1.Metadata{Name: "nginx", Version: "1.0.0"}},
+	}
+
+	ksClient := fakeks.NewSimpleClientset(rls)
+	informerFactory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), ksClient, nil, nil, nil, nil)
+	ksInformers := informerFactory.KubeSphereSharedInformerFactory()
+	_ = ksInformers.Application().V1alpha1().HelmReleases().Informer().GetIndexer().Add(rls)
+	_ = ksInformers.Application().V1alpha1().HelmApplicationVersions().Informer().GetIndexer().Add(appVersion)
+
+	o := newReleaseOperator(reposcache.NewReposCache(), informerFactory.KubernetesSharedInformerFactory(), ksInformers, ksClient, nil, nil).(*releaseOperator)
+	wrapper := &fakeHelmWrapper{history: []*helmrelease.Release{
+		newRevision(1, helmrelease.StatusSuperseded, "1.0.0", map[string]interface{}{"replicas": 1},
+			"kind: Deployment\nspec:\n  replicas: 1\n"),
+		newRevision(2, helmrelease.StatusDeployed, "1.1.0", map[string]interface{}{"replicas": 3},
+			"kind: Deployment\nspec:\n  replicas: 3\n"),
+	}}
+	o.helmWrapper = func(kubeconfig, namespace, name string, options ...helmwrapper.Option) helmwrapper.HelmWrapper {
+		return wrapper
+	}
+
+	revisions, err := o.ListRevisions("", "", "default", "rls-nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 2 || revisions[0].Status != "deployed" ||
+		revisions[1].ChartVersion != "1.0.0" || revisions[1].Values != "replicas: 1\n" {
+		t.Fatalf("unexpected revisions %v", revisions)
+	}
+	if _, err := o.ListRevisions("", "", "kube-system", "rls-nginx"); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the release not found in other namespaces, got %v", err)
+	}
+
+	diff, err := o.DiffRevisions("", "", "default", "rls-nginx", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.To != 2 || diff.ChartVersion != "1.0.0 -> 1.1.0" ||
+		!strings.Contains(diff.Values, "-replicas: 1\n+replicas: 3\n") ||
+		!strings.Contains(diff.Manifest, "-  replicas: 1\n+  replicas: 3\n") {
+		t.Fatalf("unexpected diff %v", diff)
+	}
+	if _, err := o.DiffRevisions("", "", "default", "rls-nginx", 3, 0); !apierrors.IsBadRequest(err) {
+		t.Fatalf("expected the revision not found, got %v", err)
+	}
+
+	if err := o.RollbackApplication(RollbackApplicationRequest{Namespace: "default", Revision: 2}, "rls-nginx"); !apierrors.IsBadRequest(err) {
+		t.Fatalf("expected the deployed revision not to be rolled back to, got %v", err)
+	}
+	if err := o.RollbackApplication(RollbackApplicationRequest{Namespace: "default", Revision: 1}, "rls-nginx"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ksClient.ApplicationV1alpha1().HelmReleases().Get(context.TODO(), "rls-nginx", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Version != 3 || got.Spec.RollbackRevision != 1 || got.Spec.ChartVersion != "1.0.0" ||
+		got.Spec.ApplicationVersionId != "appv-nginx-1" || string(got.Spec.Values) != "replicas: 1\n" {
+		t.Fatalf("unexpected spec %v", got.Spec)
+	}
+}
@@ -28,10 +28,13 @@ import (
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/server/params"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3/fake"
 )
 
 func TestOpenPitrixRelease(t *testing.T) {
-	appOperator := prepareAppOperator()
+	// the charts uploaded are stored in the store, from which the releases load them
+	storeClient := fake.NewFakeS3()
+	appOperator := prepareAppOperator(storeClient)
 
 	chartData, _ := base64.RawStdEncoding.DecodeString(rawChartData)
 
@@ -71,7 +74,7 @@ func TestOpenPitrixRelease(t *testing.T) {
 		}
 	}
 
-	rlsOperator := newReleaseOperator(reposcache.NewReposCache(), fakeInformerFactory.KubernetesSharedInformerFactory(), fakeInformerFactory.KubeSphereSharedInformerFactory(), ksClient, nil)
+	rlsOperator := newReleaseOperator(reposcache.NewReposCache(), fakeInformerFactory.KubernetesSharedInformerFactory(), fakeInformerFactory.KubeSphereSharedInformerFactory(), ksClient, nil, storeClient)
 
 	req := CreateClusterRequest{
 		Name:      "test-rls",
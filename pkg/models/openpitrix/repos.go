@@ -215,11 +215,20 @@ func (c *repoOperator) ModifyRepo(id string, request *ModifyRepoRequest) error {
 		// trim the credential from url
 		parsedUrl.User = nil
 		cred := &v1alpha1.HelmRepoCredential{}
-		if strings.HasPrefix(*request.URL, "https://") || strings.HasPrefix(*request.URL, "http://") {
+		if strings.HasPrefix(*request.URL, "https://") || strings.HasPrefix(*request.URL, "http://") ||
+			helmrepoindex.IsOCIRepo(*request.URL) {
 			if userInfo != nil {
 				cred.Password, _ = userInfo.Password()
 				cred.Username = userInfo.Username()
 			}
+			if helmrepoindex.IsOCIRepo(*request.URL) && request.Credential != nil && *request.Credential != "" {
+				// the OCI registries without tls are opted in by the credential, e.g. {"plainHTTP": true}
+				opts := v1alpha1.HelmRepoCredential{}
+				if err := json.Unmarshal([]byte(*request.Credential), &opts); err != nil {
+					return err
+				}
+				cred.PlainHTTP = opts.PlainHTTP
+			}
 		} else if strings.HasPrefix(*request.URL, "s3://") {
 			cfg := v1alpha1.S3Config{}
 			err := json.Unmarshal([]byte(*request.Credential), &cfg)
@@ -15,6 +15,8 @@ package openpitrix
 
 import (
 	"github.com/go-openapi/strfmt"
+
+	"kubesphere.io/api/application/v1alpha1"
 )
 
 type ModifyAppRequest struct {
@@ -68,6 +70,9 @@ type ModifyAppVersionRequest struct {
 	// package of app to replace other
 	Package []byte `json:"package,omitempty"`
 
+	// provenance file or cosign signature of the package
+	Signature []byte `json:"signature,omitempty"`
+
 	// filename map to file_content
 	PackageFiles map[string][]byte `json:"package_files,omitempty"`
 
@@ -117,6 +122,12 @@ type ValidatePackageRequest struct {
 
 	// optional, vmbased/helm
 	VersionType string `json:"version_type,omitempty"`
+
+	// optional, provenance file or cosign signature of the package
+	VersionSignature strfmt.Base64 `json:"version_signature,omitempty"`
+
+	// workspace the package is uploaded to
+	Workspace string `json:"-"`
 }
 type App struct {
 
@@ -283,6 +294,9 @@ type AppVersionReview struct {
 
 	// Workspace of the app version
 	Workspace string `json:"workspace,omitempty"`
+
+	// signature verification of the app version
+	Verification *v1alpha1.ChartVerificationResult `json:"verification,omitempty"`
 }
 
 type CreateAppRequest struct {
@@ -305,6 +319,9 @@ type CreateAppRequest struct {
 	// optional, vmbased/helm
 	VersionType string `json:"version_type,omitempty"`
 
+	// optional, provenance file or cosign signature of the package
+	VersionSignature strfmt.Base64 `json:"version_signature,omitempty"`
+
 	Username string `json:"-"`
 }
 
@@ -386,6 +403,9 @@ type AppVersion struct {
 	VersionId string `json:"version_id,omitempty"`
 
 	ClusterTotal *int `json:"cluster_total,omitempty"`
+
+	// signature verification of the app version
+	Verification *v1alpha1.ChartVerificationResult `json:"verification,omitempty"`
 }
 
 type CreateAppVersionResponse struct {
@@ -415,6 +435,9 @@ type ValidatePackageResponse struct {
 	VersionName string `json:"version_name,omitempty"`
 
 	Icon string `json:"icon,omitempty"`
+
+	// signature verification of the package, set if the workspace requires signed charts
+	Verification *v1alpha1.ChartVerificationResult `json:"verification,omitempty"`
 }
 
 type CreateAppVersionRequest struct {
@@ -431,6 +454,9 @@ type CreateAppVersionRequest struct {
 	// package of app of specific version
 	Package strfmt.Base64 `json:"package,omitempty"`
 
+	// optional, provenance file or cosign signature of the package
+	Signature strfmt.Base64 `json:"signature,omitempty"`
+
 	// optional: vmbased/helm
 	Type string `json:"type,omitempty"`
 
@@ -802,6 +828,67 @@ type UpgradeClusterRequest struct {
 	Username string `json:"-"`
 }
 
+type RollbackApplicationRequest struct {
+	// release namespace
+	Namespace string `json:"namespace,omitempty"`
+
+	// required, helm revision to roll back to
+	Revision int `json:"revision"`
+
+	Username string `json:"-"`
+}
+
+type ReleaseRevision struct {
+	// helm revision
+	Revision int `json:"revision"`
+
+	// status of the revision, e.g. deployed, superseded or failed
+	Status string `json:"status"`
+
+	// description of the revision, e.g. Upgrade complete
+	Description string `json:"description,omitempty"`
+
+	// chart name
+	ChartName string `json:"chart_name"`
+
+	// chart version
+	ChartVersion string `json:"chart_version"`
+
+	// app version of the chart
+	AppVersion string `json:"app_version,omitempty"`
+
+	// values supplied, in yaml
+	Values string `json:"values,omitempty"`
+
+	// time the revision is deployed
+	Updated *strfmt.DateTime `json:"updated,omitempty"`
+}
+
+type ReleaseRevisionDiff struct {
+	// the revision compared from
+	From int `json:"from"`
+
+	// the revision compared to
+	To int `json:"to"`
+
+	// chart versions of the revisions if they differ, e.g. 1.0.0 -> 1.1.0
+	ChartVersion string `json:"chart_version,omitempty"`
+
+	// unified diff of the values supplied
+	Values string `json:"values,omitempty"`
+
+	// unified diff of the manifests rendered
+	Manifest string `json:"manifest,omitempty"`
+}
+
+type ReleaseDryRunResult struct {
+	// manifest rendered with the chart and the values
+	Manifest string `json:"manifest"`
+
+	// unified diff of the manifest of the live release and the rendered one
+	Diff string `json:"diff,omitempty"`
+}
+
 type Cluster struct {
 
 	// additional info
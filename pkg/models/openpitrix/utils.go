@@ -417,6 +417,7 @@ func convertAppVersion(in *v1alpha1.HelmApplicationVersion) *AppVersion {
 	out.Name = in.GetVersionName()
 	out.PackageName = fmt.Sprintf("%s-%s.tgz", in.GetTrueName(), in.GetChartVersion())
 	out.VersionId = in.GetHelmApplicationVersionId()
+	out.Verification = in.Status.Verification
 	return &out
 }
 
@@ -817,6 +818,7 @@ func convertAppVersionReview(app *v1alpha1.HelmApplication, appVersion *v1alpha1
 
 	review.StatusTime = strfmt.DateTime(status.Audit[0].Time.Time)
 	review.AppName = app.GetTrueName()
+	review.Verification = status.Verification
 	return review
 }
 
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewayapi
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
+)
+
+const (
+	// /httproutes?workspace=demo lists the routes in the namespaces of the workspace
+	fieldWorkspace query.Field = "workspace"
+	// /httproutes?gateway=kubesphere-controls-system/kubesphere-router-demo lists the routes attached to the gateway,
+	// the namespace of the gateway can be omitted
+	fieldGateway query.Field = "gateway"
+)
+
+var (
+	GatewayGroupVersionKind   = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"}
+	HTTPRouteGroupVersionKind = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+	GRPCRouteGroupVersionKind = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "GRPCRoute"}
+)
+
+// routeGetter lists the routes of Gateway API, which are read as unstructured objects
+// since the types of Gateway API are not built in.
+type routeGetter struct {
+	c   client.Reader
+	gvk schema.GroupVersionKind
+}
+
+func NewHTTPRouteGetter(c client.Reader) v1alpha3.Interface {
+	return &routeGetter{c: c, gvk: HTTPRouteGroupVersionKind}
+}
+
+func NewGRPCRouteGetter(c client.Reader) v1alpha3.Interface {
+	return &routeGetter{c: c, gvk: GRPCRouteGroupVersionKind}
+}
+
+func (r *routeGetter) Get(namespace, name string) (runtime.Object, error) {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(r.gvk)
+	err := r.c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, route)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return route, nil
+}
+
+func (r *routeGetter) List(namespace string, query *query.Query) (*api.ListResult, error) {
+	routes := &unstructured.UnstructuredList{}
+	routes.SetGroupVersionKind(r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List"))
+	err := r.c.List(context.Background(), routes, &client.ListOptions{Namespace: namespace, LabelSelector: query.Selector()})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	// the namespaces of the workspace are looked up once for all the routes
+	var workspaceNamespaces map[string]bool
+	if workspace := query.Filters[fieldWorkspace]; workspace != "" {
+		if workspaceNamespaces, err = r.workspaceNamespaces(string(workspace)); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []runtime.Object
+	for i := range routes.Items {
+		if workspaceNamespaces != nil && !workspaceNamespaces[routes.Items[i].GetNamespace()] {
+			continue
+		}
+		result = append(result, &routes.Items[i])
+	}
+
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+}
+
+func (r *routeGetter) workspaceNamespaces(workspace string) (map[string]bool, error) {
+	namespaces := &corev1.NamespaceList{}
+	err := r.c.List(context.Background(), namespaces, client.MatchingLabels{constants.WorkspaceLabelKey: workspace})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	result := make(map[string]bool, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		result[ns.Name] = true
+	}
+	return result, nil
+}
+
+func (r *routeGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftRoute, ok := left.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+
+	rightRoute, ok := right.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+
+	return v1alpha3.DefaultObjectMetaCompare(objectMeta(leftRoute), objectMeta(rightRoute), field)
+}
+
+func (r *routeGetter) filter(object runtime.Object, filter query.Filter) bool {
+	route, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+
+	switch filter.Field {
+	case fieldWorkspace:
+		// filtered when listing
+		return true
+	case fieldGateway:
+		return attachedTo(route, string(filter.Value))
+	default:
+		return v1alpha3.DefaultObjectMetaFilter(objectMeta(route), filter)
+	}
+}
+
+// attachedTo checks whether the route is attached to the gateway by the parentRefs.
+func attachedTo(route *unstructured.Unstructured, gateway string) bool {
+	namespace, name := "", gateway
+	if i := strings.Index(gateway, "/"); i >= 0 {
+		namespace, name = gateway[:i], gateway[i+1:]
+	}
+
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	for _, item := range parentRefs {
+		parentRef, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// the parent is a Gateway unless the kind is specified
+		if kind, _, _ := unstructured.NestedString(parentRef, "kind"); kind != "" && kind != "Gateway" {
+			continue
+		}
+		refName, _, _ := unstructured.NestedString(parentRef, "name")
+		refNamespace, _, _ := unstructured.NestedString(parentRef, "namespace")
+		if refNamespace == "" {
+			refNamespace = route.GetNamespace()
+		}
+		if refName == name && (namespace == "" || refNamespace == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+func objectMeta(obj *unstructured.Unstructured) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+		UID:               obj.GetUID(),
+		CreationTimestamp: obj.GetCreationTimestamp(),
+		Labels:            obj.GetLabels(),
+		Annotations:       obj.GetAnnotations(),
+		OwnerReferences:   obj.GetOwnerReferences(),
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatewayapi
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+func newHTTPRoute(namespace, name string, parentRefs ...interface{}) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"parentRefs": parentRefs},
+	}}
+	route.SetGroupVersionKind(HTTPRouteGroupVersionKind)
+	route.SetNamespace(namespace)
+	route.SetName(name)
+	return route
+}
+
+func TestListHTTPRoutes(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := corev1.AddToScheme(sch); err != nil {
+		t.Fatalf("unable add APIs to scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).Build()
+
+	objects := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo", Labels: map[string]string{constants.WorkspaceLabelKey: "ws"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+		newHTTPRoute("demo", "web", map[string]interface{}{
+			"name":      "kubesphere-router-demo",
+			"namespace": "kubesphere-controls-system",
+		}),
+		newHTTPRoute("demo", "api", map[string]interface{}{
+			"name":      "kubesphere-router-kubesphere-system",
+			"namespace": "kubesphere-controls-system",
+		}),
+		// attached to the gateway in the same namespace
+		newHTTPRoute("other", "web", map[string]interface{}{"name": "kubesphere-router-demo"}),
+	}
+	for _, obj := range objects {
+		if err := c.Create(context.Background(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	getter := NewHTTPRouteGetter(c)
+
+	results, err := getter.List("", query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 3 {
+		t.Fatalf("expected 3 routes, got %d", results.TotalItems)
+	}
+
+	results, err = getter.List("demo", query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 2 {
+		t.Fatalf("expected 2 routes in namespace demo, got %d", results.TotalItems)
+	}
+
+	q := query.New()
+	q.Filters[fieldWorkspace] = "ws"
+	results, err = getter.List("", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 2 {
+		t.Fatalf("expected 2 routes in workspace ws, got %d", results.TotalItems)
+	}
+
+	q = query.New()
+	q.Filters[fieldGateway] = "kubesphere-controls-system/kubesphere-router-demo"
+	results, err = getter.List("", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 1 || results.Items[0].(*unstructured.Unstructured).GetNamespace() != "demo" {
+		t.Fatalf("expected the route attached to the gateway, got %v", results.Items)
+	}
+
+	q = query.New()
+	q.Filters[fieldGateway] = "kubesphere-router-demo"
+	results, err = getter.List("", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 2 {
+		t.Fatalf("expected 2 routes attached to the gateways named kubesphere-router-demo, got %d", results.TotalItems)
+	}
+
+	result, err := getter.Get("demo", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(*unstructured.Unstructured).GetName() != "api" {
+		t.Fatalf("unexpected route %v", result)
+	}
+}
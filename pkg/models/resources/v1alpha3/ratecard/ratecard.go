@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratecard
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	meteringv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
+)
+
+type rateCardGetter struct {
+	c client.Reader
+}
+
+func New(c client.Reader) v1alpha3.Interface {
+	return &rateCardGetter{c}
+}
+
+func (r *rateCardGetter) Get(_, name string) (runtime.Object, error) {
+	rateCard := meteringv1alpha1.RateCard{}
+	err := r.c.Get(context.Background(), types.NamespacedName{Name: name}, &rateCard)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return &rateCard, nil
+}
+
+func (r *rateCardGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
+	rateCards := meteringv1alpha1.RateCardList{}
+	err := r.c.List(context.Background(), &rateCards, &client.ListOptions{LabelSelector: query.Selector()})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	var result []runtime.Object
+	for i := range rateCards.Items {
+		result = append(result, &rateCards.Items[i])
+	}
+
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+}
+
+func (r *rateCardGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftRateCard, ok := left.(*meteringv1alpha1.RateCard)
+	if !ok {
+		return false
+	}
+
+	rightRateCard, ok := right.(*meteringv1alpha1.RateCard)
+	if !ok {
+		return false
+	}
+
+	return v1alpha3.DefaultObjectMetaCompare(leftRateCard.ObjectMeta, rightRateCard.ObjectMeta, field)
+}
+
+func (r *rateCardGetter) filter(object runtime.Object, filter query.Filter) bool {
+	rateCard, ok := object.(*meteringv1alpha1.RateCard)
+	if !ok {
+		return false
+	}
+
+	return v1alpha3.DefaultObjectMetaFilter(rateCard.ObjectMeta, filter)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratecard
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	meteringv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+)
+
+func TestListRateCards(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatalf("unable add APIs to scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).Build()
+
+	rateCards := []*meteringv1alpha1.RateCard{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec:       meteringv1alpha1.RateCardSpec{CPU: &meteringv1alpha1.Rate{Price: 0.05}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "spot", Labels: map[string]string{"pool": "spot"}},
+			Spec:       meteringv1alpha1.RateCardSpec{CPU: &meteringv1alpha1.Rate{Price: 0.02}},
+		},
+	}
+	for _, rateCard := range rateCards {
+		if err := c.Create(context.Background(), rateCard); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	getter := New(c)
+
+	results, err := getter.List("", query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != len(rateCards) {
+		t.Fatalf("expected %d rate cards, got %d", len(rateCards), results.TotalItems)
+	}
+
+	q := query.New()
+	q.LabelSelector = "pool=spot"
+	results, err = getter.List("", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalItems != 1 || results.Items[0].(*meteringv1alpha1.RateCard).Name != "spot" {
+		t.Fatalf("expected the spot rate card, got %v", results.Items)
+	}
+
+	result, err := getter.Get("", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(*meteringv1alpha1.RateCard).Spec.CPU.Price != 0.05 {
+		t.Fatalf("unexpected rate card %v", result)
+	}
+}
@@ -61,6 +61,14 @@ type QueryOptions struct {
 	Limit      int
 
 	Option monitoring.QueryOption
+
+	// The scope of the query, which decides the rate cards to apply.
+	WorkspaceName    string
+	NamespaceName    string
+	StorageClassName string
+
+	// byStorageClass breaks the PVC meters down by storage class.
+	byStorageClass bool
 }
 
 func (q QueryOptions) isRangeQuery() bool {
@@ -71,6 +79,13 @@ func (q QueryOptions) shouldSort() bool {
 	return q.Target != "" && q.Identifier != ""
 }
 
+func (q QueryOptions) meterOption(opt monitoring.QueryOption) monitoring.QueryOption {
+	if q.byStorageClass {
+		return monitoring.StorageClassBreakdownOption{QueryOption: opt}
+	}
+	return opt
+}
+
 func (t *tenantOperator) makeQueryOptions(user user.Info, q meteringv1alpha1.Query, lvl monitoring.Level) (qo QueryOptions, err error) {
 	if q.ResourceFilter == "" {
 		q.ResourceFilter = meteringv1alpha1.DefaultFilter
@@ -80,6 +95,9 @@ func (t *tenantOperator) makeQueryOptions(user user.Info, q meteringv1alpha1.Que
 	if q.MetricFilter == "" {
 		qo.MetricFilter = meteringv1alpha1.DefaultFilter
 	}
+	qo.WorkspaceName = q.WorkspaceName
+	qo.NamespaceName = q.NamespaceName
+	qo.StorageClassName = q.StorageClassName
 
 	var decision authorizer.Decision
 	switch lvl {
@@ -566,6 +584,14 @@ func (t *tenantOperator) ProcessNamedMetersQuery(q QueryOptions, priceInfo meter
 		return
 	}
 
+	metrics, err = t.queryMeters(meters, q, priceInfo)
+	if err == nil {
+		t.applyRateCards(q, &metrics, priceInfo)
+	}
+	return
+}
+
+func (t *tenantOperator) queryMeters(meters []string, q QueryOptions, priceInfo meteringclient.PriceInfo) (metrics monitoringmodel.Metrics, err error) {
 	_, ok := q.Option.(monitoring.ApplicationsOption)
 	if ok {
 		metrics, err = t.processApplicationMetersQuery(meters, q, priceInfo)
@@ -579,9 +605,9 @@ func (t *tenantOperator) ProcessNamedMetersQuery(q QueryOptions, priceInfo meter
 	}
 
 	if q.isRangeQuery() {
-		metrics, err = t.mo.GetNamedMetersOverTime(meters, q.Start, q.End, q.Step, q.Option, priceInfo)
+		metrics, err = t.mo.GetNamedMetersOverTime(meters, q.Start, q.End, q.Step, q.meterOption(q.Option), priceInfo)
 	} else {
-		metrics, err = t.mo.GetNamedMeters(meters, q.Time, q.Option, priceInfo)
+		metrics, err = t.mo.GetNamedMeters(meters, q.Time, q.meterOption(q.Option), priceInfo)
 		if q.shouldSort() {
 			metrics = *metrics.Sort(q.Target, q.Order, q.Identifier).Page(q.Page, q.Limit)
 		}
@@ -621,9 +647,9 @@ func (t *tenantOperator) processApplicationMetersQuery(meters []string, q QueryO
 		}
 
 		if q.isRangeQuery() {
-			current_res, err = t.mo.GetNamedMetersOverTime(meters, q.Start, q.End, q.Step, opt, priceInfo)
+			current_res, err = t.mo.GetNamedMetersOverTime(meters, q.Start, q.End, q.Step, q.meterOption(opt), priceInfo)
 		} else {
-			current_res, err = t.mo.GetNamedMeters(meters, q.Time, opt, priceInfo)
+			current_res, err = t.mo.GetNamedMeters(meters, q.Time, q.meterOption(opt), priceInfo)
 		}
 
 		if res.Results == nil {
@@ -668,9 +694,9 @@ func (t *tenantOperator) processServiceMetersQuery(meters []string, q QueryOptio
 		}
 
 		if q.isRangeQuery() {
-			current_res, err = t.mo.GetNamedMetersOverTime(meters, q.Start, q.End, q.Step, opt, priceInfo)
+			current_res, err = t.mo.GetNamedMetersOverTime(meters, q.Start, q.End, q.Step, q.meterOption(opt), priceInfo)
 		} else {
-			current_res, err = t.mo.GetNamedMeters(meters, q.Time, opt, priceInfo)
+			current_res, err = t.mo.GetNamedMeters(meters, q.Time, q.meterOption(opt), priceInfo)
 		}
 
 		if res.Results == nil {
@@ -709,6 +735,14 @@ func (t *tenantOperator) transformMetricData(metrics monitoringmodel.Metrics) me
 			} else {
 				podsStats.Set(podName, metricName, s)
 			}
+
+			if fee, err := strconv.ParseFloat(metricValue.Fee, 64); err == nil {
+				cost := metering.Cost{Fee: fee}
+				if metricValue.Rate != nil {
+					cost.RateCards = []string{metricValue.Rate.RateCard}
+				}
+				podsStats.AddCost(podName, cost)
+			}
 		}
 	}
 
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenant
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/models/monitoring/expressions"
+	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// QueryMetric evaluates the PromQL expression at the given time, with every selector
+// restricted to the namespaces the user can see in the workspace, or in all workspaces
+// if the workspace is empty.
+func (t *tenantOperator) QueryMetric(user user.Info, workspace, expr string, time time.Time) (monitoringclient.Metric, error) {
+	expr, err := t.enforceNamespaces(user, workspace, expr)
+	if err != nil {
+		return monitoringclient.Metric{}, err
+	}
+	// The namespaces are enforced already, no namespace to replace any more.
+	return t.mo.GetMetric(expr, "", time)
+}
+
+// QueryMetricOverTime is the same as QueryMetric, but evaluates the expression over a range of time.
+func (t *tenantOperator) QueryMetricOverTime(user user.Info, workspace, expr string, start, end time.Time, step time.Duration) (monitoringclient.Metric, error) {
+	expr, err := t.enforceNamespaces(user, workspace, expr)
+	if err != nil {
+		return monitoringclient.Metric{}, err
+	}
+	return t.mo.GetMetricOverTime(expr, "", start, end, step)
+}
+
+func (t *tenantOperator) enforceNamespaces(user user.Info, workspace, expr string) (string, error) {
+	result, err := t.ListNamespaces(user, workspace, query.New())
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+
+	namespaces := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		namespaces = append(namespaces, item.(*corev1.Namespace).Name)
+	}
+
+	if len(namespaces) == 0 {
+		return "", errors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "",
+			fmt.Errorf("user %s has no namespace to query metrics against", user.GetName()))
+	}
+
+	// Different monitoring backend implementations have different ways to enforce namespace isolation,
+	// we hard code "prometheus" here because we only support this datasource so far.
+	expr, err = expressions.EnforceNamespacesFns["prometheus"](expr, namespaces)
+	if err != nil {
+		return "", errors.NewBadRequest(err.Error())
+	}
+	return expr, nil
+}
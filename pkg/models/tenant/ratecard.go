@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenant
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/metering"
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+func (t *tenantOperator) listRateCards() []meteringapiv1alpha1.RateCard {
+	if t.rateCardGetter == nil {
+		return nil
+	}
+
+	result, err := t.rateCardGetter.List("", query.New())
+	if err != nil {
+		// fall back to the global price info rather than failing the metering
+		klog.Warningf("failed to list rate cards: %s", err)
+		return nil
+	}
+
+	rateCards := make([]meteringapiv1alpha1.RateCard, 0, len(result.Items))
+	for _, item := range result.Items {
+		rateCards = append(rateCards, *item.(*meteringapiv1alpha1.RateCard))
+	}
+	sort.Slice(rateCards, func(i, j int) bool {
+		return rateCards[i].Name < rateCards[j].Name
+	})
+	return rateCards
+}
+
+// applyRateCards recomputes the fees of the meters by the rate cards that apply to the workspaces,
+// node pools and storage classes of the usage.
+func (t *tenantOperator) applyRateCards(q QueryOptions, metrics *monitoringmodel.Metrics, priceInfo meteringclient.PriceInfo) {
+	rateCards := t.listRateCards()
+	if len(rateCards) == 0 {
+		return
+	}
+
+	workspaces := make(map[string]string)
+	nodeLabels := make(map[string]map[string]string)
+	storageClassUsage := t.storageClassUsage(q, metrics, rateCards, priceInfo)
+
+	metering.ApplyRateCards(metrics, rateCards, priceInfo, func(mv *monitoring.MetricValue) metering.RateContext {
+		ctx := metering.RateContext{
+			Workspace:         t.workspaceOfMetricValue(q, mv.Metadata, workspaces),
+			StorageClass:      q.StorageClassName,
+			StorageClassUsage: storageClassUsage[metricValueKey(mv.Metadata)],
+		}
+		if node := mv.Metadata["node"]; node != "" {
+			ctx.NodeLabels = t.nodeLabels(node, nodeLabels)
+		}
+		return ctx
+	})
+}
+
+// storageClassUsage breaks the PVC usage of the meters down by storage class, keyed by the metric values,
+// if the query spans storage classes and any rate card prices storage classes differently.
+func (t *tenantOperator) storageClassUsage(q QueryOptions, metrics *monitoringmodel.Metrics,
+	rateCards []meteringapiv1alpha1.RateCard, priceInfo meteringclient.PriceInfo) map[string]map[string]float64 {
+	if q.StorageClassName != "" {
+		return nil
+	}
+
+	priced := false
+	for _, rateCard := range rateCards {
+		priced = priced || len(rateCard.Spec.StorageClasses) > 0
+	}
+	if !priced {
+		return nil
+	}
+
+	var meters []string
+	for _, metric := range metrics.Results {
+		if monitoringmodel.MeterResourceMap[metric.MetricName] == monitoringmodel.METER_RESOURCE_TYPE_PVC {
+			meters = append(meters, metric.MetricName)
+		}
+	}
+	if len(meters) == 0 {
+		return nil
+	}
+
+	// every metric value rather than a page of them
+	q.byStorageClass, q.Target = true, ""
+	breakdown, err := t.queryMeters(meters, q, priceInfo)
+	if err != nil {
+		klog.Warningf("failed to break the pvc usage down by storage class: %s", err)
+		return nil
+	}
+
+	usage := make(map[string]map[string]float64)
+	for _, metric := range breakdown.Results {
+		for _, mv := range metric.MetricValues {
+			storageClass := mv.Metadata["storageclass"]
+			if storageClass == "" || mv.SumValue == "" {
+				continue
+			}
+			_, value, err := monitoringmodel.GetMeterUsage(metric.MetricName, mv.SumValue)
+			if err != nil {
+				klog.Error(err)
+				continue
+			}
+			key := metricValueKey(mv.Metadata)
+			if usage[key] == nil {
+				usage[key] = make(map[string]float64)
+			}
+			usage[key][storageClass] += value
+		}
+	}
+	return usage
+}
+
+// metricValueKey identifies the resource of a metric value by its labels other than the storage class.
+func metricValueKey(metadata map[string]string) string {
+	var labels []string
+	for name, value := range metadata {
+		if name != "storageclass" && name != "__name__" {
+			labels = append(labels, name+"="+value)
+		}
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ",")
+}
+
+// workspaceOfMetricValue returns the workspace the metric value belongs to, from its labels
+// or the query if the labels are not specific enough.
+func (t *tenantOperator) workspaceOfMetricValue(q QueryOptions, metadata map[string]string, cache map[string]string) string {
+	if workspace := metadata["workspace"]; workspace != "" {
+		return workspace
+	}
+
+	namespace := metadata["namespace"]
+	if namespace == "" {
+		namespace = q.NamespaceName
+	}
+	if namespace == "" {
+		return q.WorkspaceName
+	}
+
+	workspace, ok := cache[namespace]
+	if !ok {
+		if obj, err := t.resourceGetter.Get("namespaces", "", namespace); err != nil {
+			klog.Warningf("failed to get namespace %s: %s", namespace, err)
+		} else {
+			workspace = obj.(*corev1.Namespace).Labels[constants.WorkspaceLabelKey]
+		}
+		cache[namespace] = workspace
+	}
+	return workspace
+}
+
+func (t *tenantOperator) nodeLabels(node string, cache map[string]map[string]string) map[string]string {
+	nodeLabels, ok := cache[node]
+	if !ok {
+		if obj, err := t.resourceGetter.Get("nodes", "", node); err != nil {
+			klog.Warningf("failed to get node %s: %s", node, err)
+		} else {
+			// nodes without labels still match the node pools with empty selectors
+			nodeLabels = obj.(*corev1.Node).Labels
+			if nodeLabels == nil {
+				nodeLabels = map[string]string{}
+			}
+		}
+		cache[node] = nodeLabels
+	}
+	return nodeLabels
+}
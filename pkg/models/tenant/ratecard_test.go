@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/ratecard"
+	meteringclient "kubesphere.io/kubesphere/pkg/simple/client/metering"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+var rateCards = []meteringapiv1alpha1.RateCard{
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Currency: "USD",
+			CPU:      &meteringapiv1alpha1.Rate{Price: 0.05},
+			PVC:      &meteringapiv1alpha1.Rate{Price: 0.01},
+			NodePools: []meteringapiv1alpha1.NodePoolRate{
+				{
+					Name:         "spot",
+					NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"node.kubesphere.io/lifecycle": "spot"}},
+					CPU:          &meteringapiv1alpha1.Rate{Price: 0.02},
+				},
+			},
+			StorageClasses: []meteringapiv1alpha1.StorageClassRate{
+				{StorageClassName: "ssd", PVC: meteringapiv1alpha1.Rate{Price: 0.03}},
+			},
+		},
+	},
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "enterprise"},
+		Spec: meteringapiv1alpha1.RateCardSpec{
+			Workspaces: []string{"system-workspace"},
+			CPU: &meteringapiv1alpha1.Rate{
+				Price: 0.05,
+				Tiers: []meteringapiv1alpha1.RateTier{{From: 100, Price: 0.04}, {From: 200, Price: 0.03}},
+			},
+		},
+	},
+}
+
+func TestApplyRateCards(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringapiv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).Build()
+	for i := range rateCards {
+		if err := c.Create(context.Background(), rateCards[i].DeepCopy()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	to := prepare().(*tenantOperator)
+	to.rateCardGetter = ratecard.New(c)
+
+	metrics := monitoringmodel.Metrics{
+		Results: []monitoring.Metric{
+			{
+				MetricName: "meter_workspace_cpu_usage",
+				MetricData: monitoring.MetricData{
+					MetricValues: []monitoring.MetricValue{
+						{Metadata: map[string]string{"workspace": "system-workspace"}, SumValue: "150", Fee: "15.000"},
+						{Metadata: map[string]string{"workspace": "test-workspace"}, SumValue: "150", Fee: "15.000"},
+					},
+				},
+			},
+			{
+				MetricName: "meter_workspace_memory_usage",
+				MetricData: monitoring.MetricData{
+					MetricValues: []monitoring.MetricValue{
+						{Metadata: map[string]string{"workspace": "system-workspace"}, SumValue: "1073741824", Fee: "1.000", CurrencyUnit: "CNY"},
+					},
+				},
+			},
+		},
+	}
+	to.applyRateCards(QueryOptions{}, &metrics, meteringclient.PriceInfo{CurrencyUnit: "CNY"})
+
+	expected := monitoring.MetricValues{
+		{
+			Metadata:     map[string]string{"workspace": "system-workspace"},
+			SumValue:     "150",
+			Fee:          "7.000",
+			CurrencyUnit: "CNY",
+			Rate: &monitoring.Rate{
+				RateCard: "enterprise",
+				Price:    0.05,
+				Tiers:    []monitoring.TierCharge{{From: 100, Price: 0.04, Usage: 50}},
+			},
+		},
+		{
+			Metadata:     map[string]string{"workspace": "test-workspace"},
+			SumValue:     "150",
+			Fee:          "7.500",
+			CurrencyUnit: "USD",
+			Rate:         &monitoring.Rate{RateCard: "default", Price: 0.05},
+		},
+	}
+	if diff := cmp.Diff(metrics.Results[0].MetricValues, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	// the enterprise rate card has no memory price, the fee of the global price info is kept
+	if mv := metrics.Results[1].MetricValues[0]; mv.Fee != "1.000" || mv.Rate != nil {
+		t.Fatalf("expected the fee of the global price info, got %v", mv)
+	}
+}
+
+// fakeMeterOperator returns the metrics of the PVC usage broken down by storage class.
+type fakeMeterOperator struct {
+	monitoringmodel.MonitoringOperator
+	metrics monitoringmodel.Metrics
+}
+
+func (f *fakeMeterOperator) GetNamedMeters(meters []string, _ time.Time, opt monitoring.QueryOption, _ meteringclient.PriceInfo) (monitoringmodel.Metrics, error) {
+	opts := monitoring.NewQueryOptions()
+	opt.Apply(opts)
+	if !opts.ByStorageClass {
+		return monitoringmodel.Metrics{}, fmt.Errorf("expected the meters %v to be broken down by storage class", meters)
+	}
+	return f.metrics, nil
+}
+
+func TestApplyStorageClassRateCards(t *testing.T) {
+	sch := runtime.NewScheme()
+	if err := meteringapiv1alpha1.AddToScheme(sch); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(sch).Build()
+	for i := range rateCards {
+		if err := c.Create(context.Background(), rateCards[i].DeepCopy()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	demo := map[string]string{"namespace": "demo", "workspace": "test-workspace"}
+	to := prepare().(*tenantOperator)
+	to.rateCardGetter = ratecard.New(c)
+	to.mo = &fakeMeterOperator{
+		metrics: monitoringmodel.Metrics{
+			Results: []monitoring.Metric{
+				{
+					MetricName: "meter_namespace_pvc_bytes_total",
+					MetricData: monitoring.MetricData{
+						MetricValues: []monitoring.MetricValue{
+							{Metadata: map[string]string{"namespace": "demo", "workspace": "test-workspace", "storageclass": "ssd"}, SumValue: "1073741824"},
+							{Metadata: map[string]string{"namespace": "demo", "workspace": "test-workspace", "storageclass": "standard"}, SumValue: "2147483648"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	metrics := monitoringmodel.Metrics{
+		Results: []monitoring.Metric{
+			{
+				MetricName: "meter_namespace_pvc_bytes_total",
+				MetricData: monitoring.MetricData{
+					MetricValues: []monitoring.MetricValue{
+						{Metadata: demo, SumValue: "3221225472", Fee: "0.300"},
+					},
+				},
+			},
+		},
+	}
+	q := QueryOptions{Time: time.Now(), Option: monitoring.NamespaceOption{ResourceFilter: ".*"}}
+	to.applyRateCards(q, &metrics, meteringclient.PriceInfo{CurrencyUnit: "CNY"})
+
+	// the ssd part is charged at the price of the storage class, and the rest at the pvc price
+	expected := monitoring.MetricValues{
+		{
+			Metadata:     demo,
+			SumValue:     "3221225472",
+			Fee:          "0.050",
+			CurrencyUnit: "USD",
+			Rate: &monitoring.Rate{
+				RateCard: "default",
+				Parts: []monitoring.Rate{
+					{RateCard: "default", StorageClass: "ssd", Price: 0.03, Usage: 1},
+					{RateCard: "default", Price: 0.01, Usage: 2},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(metrics.Results[0].MetricValues, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
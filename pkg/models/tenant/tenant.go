@@ -38,6 +38,7 @@ import (
 	"k8s.io/klog/v2"
 
 	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+	meteringapiv1alpha1 "kubesphere.io/api/metering/v1alpha1"
 	quotav1alpha2 "kubesphere.io/api/quota/v1alpha2"
 	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
 	tenantv1alpha2 "kubesphere.io/api/tenant/v1alpha2"
@@ -107,6 +108,8 @@ type Interface interface {
 	DeleteWorkspaceResourceQuota(workspace string, resourceQuotaName string) error
 	UpdateWorkspaceResourceQuota(workspace string, resourceQuota *quotav1alpha2.ResourceQuota) (*quotav1alpha2.ResourceQuota, error)
 	DescribeWorkspaceResourceQuota(workspace string, resourceQuotaName string) (*quotav1alpha2.ResourceQuota, error)
+	QueryMetric(user user.Info, workspace, expr string, time time.Time) (monitoringclient.Metric, error)
+	QueryMetricOverTime(user user.Info, workspace, expr string, start, end time.Time, step time.Duration) (monitoringclient.Metric, error)
 }
 
 type tenantOperator struct {
@@ -122,9 +125,14 @@ type tenantOperator struct {
 	mo             monitoring.MonitoringOperator
 	opRelease      openpitrix.ReleaseInterface
 	clusterClient  clusterclient.ClusterClients
+	rateCardGetter resources.Interface
 }
 
 func New(informers informers.InformerFactory, k8sclient kubernetes.Interface, ksclient kubesphere.Interface, evtsClient eventsclient.Client, loggingClient loggingclient.Client, auditingclient auditingclient.Client, am am.AccessManagementInterface, im im.IdentityManagementInterface, authorizer authorizer.Authorizer, monitoringclient monitoringclient.Interface, resourceGetter *resourcev1alpha3.ResourceGetter, opClient openpitrix.Interface) Interface {
+	var rateCardGetter resources.Interface
+	if resourceGetter != nil {
+		rateCardGetter = resourceGetter.TryResource(true, meteringapiv1alpha1.ResourcePluralRateCard)
+	}
 	return &tenantOperator{
 		am:             am,
 		im:             im,
@@ -138,6 +146,7 @@ func New(informers informers.InformerFactory, k8sclient kubernetes.Interface, ks
 		mo:             monitoring.NewMonitoringOperator(monitoringclient, nil, k8sclient, informers, resourceGetter, nil),
 		opRelease:      opClient,
 		clusterClient:  clusterclient.NewClusterClient(informers.KubeSphereSharedInformerFactory().Cluster().V1alpha1().Clusters()),
+		rateCardGetter: rateCardGetter,
 	}
 }
 
@@ -131,6 +131,60 @@ func TestTenantOperator_ListNamespaces(t *testing.T) {
 	}
 }
 
+func TestTenantOperator_EnforceNamespaces(t *testing.T) {
+	tenantOperator := prepare().(*tenantOperator)
+	tests := []struct {
+		username    string
+		workspace   string
+		expr        string
+		expected    string
+		expectError bool
+	}{
+		{
+			username:  admin.Name,
+			workspace: systemWorkspace.Name,
+			expr:      "up",
+			expected:  `up{namespace=~"default|kubesphere-system"}`,
+		},
+		{
+			username:  tester2.Name,
+			workspace: testWorkspace.Name,
+			expr:      `sum(rate(http_requests_total{job="app"}[5m]))`,
+			expected:  `sum(rate(http_requests_total{job="app",namespace=~"test-namespace"}[5m]))`,
+		},
+		{
+			username:    tester2.Name,
+			workspace:   testWorkspace.Name,
+			expr:        `up{namespace="kubesphere-system"}`,
+			expectError: true,
+		},
+		{
+			username:    tester1.Name,
+			workspace:   systemWorkspace.Name,
+			expr:        "up",
+			expectError: true,
+		},
+	}
+
+	for i, test := range tests {
+		result, err := tenantOperator.enforceNamespaces(&user.DefaultInfo{Name: test.username}, test.workspace, test.expr)
+		if err != nil {
+			if !test.expectError {
+				t.Errorf("case %d, %v", i, err)
+			}
+			continue
+		}
+		if test.expectError {
+			t.Errorf("case %d, expected an error, got %s", i, result)
+			continue
+		}
+
+		if diff := cmp.Diff(result, test.expected); diff != "" {
+			t.Errorf("case %d, %s", i, diff)
+		}
+	}
+}
+
 func TestTenantOperator_DescribeNamespace(t *testing.T) {
 	tenantOperator := prepare()
 	tests := []struct {
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	xacme "golang.org/x/crypto/acme"
+)
+
+// Issuer obtains the certificates from an ACME server.
+type Issuer interface {
+	// Issue obtains a certificate of the hosts for the key, the certificate chain is returned in PEM.
+	Issue(ctx context.Context, key crypto.Signer, hosts []string) ([]byte, error)
+}
+
+type issuer struct {
+	client *xacme.Client
+	email  string
+	solver ChallengeSolver
+
+	// the account is registered once
+	mutex      sync.Mutex
+	registered bool
+}
+
+// NewIssuer creates an issuer with the account key, the challenges are solved by the solver.
+func NewIssuer(options *Options, accountKey crypto.Signer, solver ChallengeSolver) (Issuer, error) {
+	if !options.IsEnabled() {
+		return nil, fmt.Errorf("acme directory url is not configured")
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if options.InsecureSkipVerify {
+		httpClient.Transport = &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			// #nosec G402 the ACME server is trusted explicitly, e.g. pebble in tests
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	return &issuer{
+		client: &xacme.Client{
+			Key:          accountKey,
+			DirectoryURL: options.DirectoryURL,
+			HTTPClient:   httpClient,
+			UserAgent:    "kubesphere",
+		},
+		email:  options.Email,
+		solver: solver,
+	}, nil
+}
+
+func (i *issuer) register(ctx context.Context) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if i.registered {
+		return nil
+	}
+	account := &xacme.Account{}
+	if i.email != "" {
+		account.Contact = []string{"mailto:" + i.email}
+	}
+	if _, err := i.client.Register(ctx, account, xacme.AcceptTOS); err != nil && !errors.Is(err, xacme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register the acme account: %w", err)
+	}
+	i.registered = true
+	return nil
+}
+
+func (i *issuer) Issue(ctx context.Context, key crypto.Signer, hosts []string) ([]byte, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts to issue the certificate for")
+	}
+	if err := i.register(ctx); err != nil {
+		return nil, err
+	}
+
+	order, err := i.client.AuthorizeOrder(ctx, xacme.DomainIDs(hosts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the order: %w", err)
+	}
+	for _, url := range order.AuthzURLs {
+		if err := i.authorize(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = i.client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, fmt.Errorf("failed to wait for the order: %w", err)
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: hosts}, key)
+	if err != nil {
+		return nil, err
+	}
+	chain, _, err := i.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize the order: %w", err)
+	}
+	var certs []byte
+	for _, der := range chain {
+		certs = append(certs, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	return certs, nil
+}
+
+// authorize solves the HTTP-01 challenge of the authorization unless it is valid already.
+func (i *issuer) authorize(ctx context.Context, url string) error {
+	authz, err := i.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if authz.Status == xacme.StatusValid {
+		return nil
+	}
+
+	var challenge *xacme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "http-01" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("no http-01 challenge offered for %s", authz.Identifier.Value)
+	}
+
+	keyAuth, err := i.client.HTTP01ChallengeResponse(challenge.Token)
+	if err != nil {
+		return err
+	}
+	host := authz.Identifier.Value
+	if err := i.solver.Present(ctx, host, challenge.Token, keyAuth); err != nil {
+		return err
+	}
+	defer func() {
+		_ = i.solver.CleanUp(ctx, host, challenge.Token)
+	}()
+
+	if _, err := i.client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("failed to accept the challenge of %s: %w", host, err)
+	}
+	if _, err := i.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("failed to authorize %s: %w", host, err)
+	}
+	return nil
+}
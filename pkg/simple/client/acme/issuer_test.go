@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newFakeSolver() *HTTP01Solver {
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	return NewHTTP01Solver(c, c, ":8090")
+}
+
+func TestHTTP01Solver(t *testing.T) {
+	leader := newFakeSolver()
+	if err := leader.Present(context.Background(), "example.com", "token", "token.thumbprint"); err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.Present(context.Background(), "example.org", "other", "other.thumbprint"); err != nil {
+		t.Fatal(err)
+	}
+	// the challenges presented by the leader are served by the other replicas
+	solver := NewHTTP01Solver(nil, leader.reader, ":8090")
+
+	tests := []struct {
+		host, path string
+		code       int
+	}{
+		{host: "example.com", path: HTTP01ChallengePath + "token", code: http.StatusOK},
+		{host: "example.com:80", path: HTTP01ChallengePath + "token", code: http.StatusOK},
+		{host: "example.org", path: HTTP01ChallengePath + "token", code: http.StatusNotFound},
+		{host: "example.org", path: HTTP01ChallengePath + "other", code: http.StatusOK},
+		{host: "example.com", path: HTTP01ChallengePath + "unknown", code: http.StatusNotFound},
+		{host: "example.com", path: "/token", code: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://"+test.host+test.path, nil)
+		w := httptest.NewRecorder()
+		solver.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s%s: expected %d, got %d", test.host, test.path, test.code, w.Code)
+		}
+		if test.code == http.StatusOK && w.Body.String() != strings.TrimPrefix(test.path, HTTP01ChallengePath)+".thumbprint" {
+			t.Errorf("unexpected key authorization %s", w.Body.String())
+		}
+	}
+
+	if err := leader.CleanUp(context.Background(), "example.com", "token"); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	solver.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com"+HTTP01ChallengePath+"token", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected the challenge to be cleaned up, got %d", w.Code)
+	}
+}
+
+// TestIssuePebble issues a certificate from a pebble server started in the pebble repository, e.g.
+//
+//	PEBBLE_VA_ALWAYS_VALID=1 pebble -config test/config/pebble-config.json
+//	PEBBLE_DIRECTORY_URL=https://localhost:14000/dir go test ./pkg/simple/client/acme/
+//
+// the challenges are taken as valid by pebble, since the solver is not reachable from it.
+func TestIssuePebble(t *testing.T) {
+	directory := os.Getenv("PEBBLE_DIRECTORY_URL")
+	if directory == "" {
+		t.Skip("PEBBLE_DIRECTORY_URL is not set")
+	}
+
+	accountKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	options := NewACMEOptions()
+	options.DirectoryURL, options.InsecureSkipVerify = directory, true
+	issuer, err := NewIssuer(options, accountKey, newFakeSolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	hosts := []string{"example.com", "www.example.com"}
+	chain, err := issuer.Issue(ctx, key, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(chain)
+	if block == nil {
+		t.Fatalf("invalid certificate chain %s", chain)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cert.DNSNames, hosts) && !reflect.DeepEqual(cert.DNSNames, []string{hosts[1], hosts[0]}) {
+		t.Errorf("unexpected dns names %v", cert.DNSNames)
+	}
+	if !cert.PublicKey.(*ecdsa.PublicKey).Equal(key.Public()) {
+		t.Error("the certificate is not issued for the key")
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options contains the configuration of the ACME issuer, which issues the certificates of the ingresses
+// annotated with kubernetes.io/tls-acme.
+type Options struct {
+	// DirectoryURL is the directory of the ACME server, e.g. https://acme-v02.api.letsencrypt.org/directory
+	DirectoryURL string `json:"directoryURL,omitempty" yaml:"directoryURL,omitempty"`
+	Email        string `json:"email,omitempty" yaml:"email,omitempty"`
+	// InsecureSkipVerify skips the verification of the certificate of the ACME server, e.g. pebble in tests.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+	// RenewBefore is how long before the certificates expire to renew them.
+	RenewBefore time.Duration `json:"renewBefore,omitempty" yaml:"renewBefore,omitempty"`
+	// SolverBindAddress is the address the HTTP-01 challenges are served at.
+	SolverBindAddress string `json:"solverBindAddress,omitempty" yaml:"solverBindAddress,omitempty"`
+	// SolverService is the host and port of the service the HTTP-01 challenges are routed to by the ingresses,
+	// e.g. ks-controller-manager.kubesphere-system.svc:8090
+	SolverService string `json:"solverService,omitempty" yaml:"solverService,omitempty"`
+}
+
+func NewACMEOptions() *Options {
+	return &Options{
+		DirectoryURL:      "",
+		RenewBefore:       30 * 24 * time.Hour,
+		SolverBindAddress: ":8090",
+		SolverService:     "ks-controller-manager.kubesphere-system.svc:8090",
+	}
+}
+
+func (s *Options) IsEnabled() bool {
+	return s != nil && s.DirectoryURL != ""
+}
+
+func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&s.DirectoryURL, "acme-directory-url", c.DirectoryURL, "Directory URL of the ACME server issuing the certificates "+
+		"of the ingresses annotated with kubernetes.io/tls-acme, ACME is disabled if it is empty.")
+	fs.StringVar(&s.Email, "acme-email", c.Email, "Contact email of the ACME account.")
+	fs.BoolVar(&s.InsecureSkipVerify, "acme-insecure-skip-verify", c.InsecureSkipVerify, "Skip verifying the certificate of the ACME server.")
+	fs.DurationVar(&s.RenewBefore, "acme-renew-before", c.RenewBefore, "How long before the certificates expire to renew them.")
+	fs.StringVar(&s.SolverBindAddress, "acme-solver-bind-address", c.SolverBindAddress, "The address the HTTP-01 challenges are served at.")
+	fs.StringVar(&s.SolverService, "acme-solver-service", c.SolverService, "The host and port of the service the HTTP-01 challenges are routed to.")
+}
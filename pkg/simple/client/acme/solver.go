@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+// HTTP01ChallengePath is the path prefix the HTTP-01 challenges are requested at by the ACME server.
+const HTTP01ChallengePath = "/.well-known/acme-challenge/"
+
+// the key authorizations of the challenges presented are kept in the secret, shared by all the replicas
+var challengeSecret = types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: "kubesphere-acme-challenges"}
+
+// ChallengeSolver presents the responses of the challenges to the ACME server.
+type ChallengeSolver interface {
+	// Present makes the key authorization of the challenge of the host available to the ACME server.
+	Present(ctx context.Context, host, token, keyAuth string) error
+	// CleanUp removes the challenge once it is validated or failed.
+	CleanUp(ctx context.Context, host, token string) error
+}
+
+// HTTP01Solver serves the key authorizations of the HTTP-01 challenges presented, the requests of the ACME
+// server are routed to it by the ingresses of the hosts. The challenges are presented by the leader, and
+// the key authorizations are kept in a secret so that they are served by every replica behind the solver service.
+type HTTP01Solver struct {
+	client client.Client
+	// reader reads the secret bypassing the cache, as the challenges are validated right after presented
+	reader  client.Reader
+	address string
+}
+
+func NewHTTP01Solver(client client.Client, reader client.Reader, address string) *HTTP01Solver {
+	return &HTTP01Solver{client: client, reader: reader, address: address}
+}
+
+func (s *HTTP01Solver) Present(ctx context.Context, host, token, keyAuth string) error {
+	return s.update(ctx, func(data map[string][]byte) {
+		data[challengeKey(host, token)] = []byte(keyAuth)
+	})
+}
+
+func (s *HTTP01Solver) CleanUp(ctx context.Context, host, token string) error {
+	return s.update(ctx, func(data map[string][]byte) {
+		delete(data, challengeKey(host, token))
+	})
+}
+
+// update modifies the key authorizations in the secret, which is created if not found.
+func (s *HTTP01Solver) update(ctx context.Context, modify func(data map[string][]byte)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret := &corev1.Secret{}
+		err := s.reader.Get(ctx, challengeSecret, secret)
+		if apierrors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: challengeSecret.Namespace, Name: challengeSecret.Name},
+				Data:       make(map[string][]byte),
+			}
+			modify(secret.Data)
+			err := s.client.Create(ctx, secret)
+			if apierrors.IsAlreadyExists(err) {
+				// created by another reconcile, which is retried as a conflict
+				return apierrors.NewConflict(corev1.Resource("secrets"), challengeSecret.Name, err)
+			}
+			return err
+		} else if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		modify(secret.Data)
+		return s.client.Update(ctx, secret)
+	})
+}
+
+func (s *HTTP01Solver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, HTTP01ChallengePath) {
+		http.NotFound(w, r)
+		return
+	}
+	// the port is not part of the identifier validated
+	host := r.Host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	token := strings.TrimPrefix(r.URL.Path, HTTP01ChallengePath)
+
+	secret := &corev1.Secret{}
+	if err := s.reader.Get(r.Context(), challengeSecret, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	keyAuth, ok := secret.Data[challengeKey(host, token)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write(keyAuth)
+}
+
+// Start serves the challenges at the address until the context is done.
+func (s *HTTP01Solver) Start(ctx context.Context) error {
+	server := &http.Server{Addr: s.address, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection is false since the solver service routes the challenges to any of the replicas.
+func (s *HTTP01Solver) NeedLeaderElection() bool {
+	return false
+}
+
+// challengeKey is the key of the challenge in the secret, the hosts and the tokens are hashed to fit the
+// limit of the length of the keys.
+func challengeKey(host, token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(host+"/"+token)))
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const epAlerts = "/api/v2/alerts"
+
+// PostableAlert is an alert sent to the alertmanager, which is routed, silenced and notified
+// the same as the alerts sent by the rulers.
+type PostableAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt,omitempty"`
+	// EndsAt is when the alert is resolved if it is not sent again, the alert is resolved at once if it is in the past.
+	EndsAt       time.Time `json:"endsAt,omitempty"`
+	GeneratorURL string    `json:"generatorURL,omitempty"`
+}
+
+type AlertClient interface {
+	// PostAlerts sends the alerts to the alertmanager.
+	PostAlerts(ctx context.Context, alerts ...*PostableAlert) error
+}
+
+func NewAlertClient(options *Options) (AlertClient, error) {
+	if options == nil || options.AlertmanagerEndpoint == "" {
+		return nil, fmt.Errorf("alertmanager endpoint is not configured")
+	}
+	return newAlertmanagerClient(options), nil
+}
+
+func (c *alertmanagerClient) PostAlerts(ctx context.Context, alerts ...*PostableAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+epAlerts, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = c.do(req)
+	return err
+}
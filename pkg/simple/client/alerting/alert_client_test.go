@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostAlerts(t *testing.T) {
+	var posted []*PostableAlert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != epAlerts {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewAlertClient(&Options{AlertmanagerEndpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nothing is sent without alerts
+	if err := c.PostAlerts(context.Background()); err != nil || posted != nil {
+		t.Fatalf("unexpected alerts %v posted: %v", posted, err)
+	}
+	err = c.PostAlerts(context.Background(), &PostableAlert{
+		Labels: map[string]string{"alertname": "OutOfMemory", "namespace": "test"},
+		EndsAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 1 || posted[0].Labels["alertname"] != "OutOfMemory" {
+		t.Fatalf("unexpected alerts posted: %v", posted)
+	}
+}
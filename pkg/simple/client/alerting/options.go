@@ -32,6 +32,9 @@ type Options struct {
 	PrometheusEndpoint       string `json:"prometheusEndpoint" yaml:"prometheusEndpoint"`
 	ThanosRulerEndpoint      string `json:"thanosRulerEndpoint" yaml:"thanosRulerEndpoint"`
 	ThanosRuleResourceLabels string `json:"thanosRuleResourceLabels" yaml:"thanosRuleResourceLabels"`
+
+	// AlertmanagerEndpoint is the endpoint of the alertmanager the silences are synced to.
+	AlertmanagerEndpoint string `json:"alertmanagerEndpoint" yaml:"alertmanagerEndpoint"`
 }
 
 func NewAlertingOptions() *Options {
@@ -73,4 +76,6 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"Thanos ruler service endpoint from which custom alerting rules are fetched(alerting v2alpha1 or higher required)")
 	fs.StringVar(&o.ThanosRuleResourceLabels, "alerting-thanos-rule-resource-labels", c.ThanosRuleResourceLabels,
 		"Labels used by Thanos Ruler to select PrometheusRule custom resources. eg: thanosruler=thanos-ruler,role=custom-alerting-rules (alerting v2alpha1 or higher required)")
+	fs.StringVar(&o.AlertmanagerEndpoint, "alerting-alertmanager-endpoint", c.AlertmanagerEndpoint,
+		"Alertmanager service endpoint to which silences are synced(alerting v2beta1 or higher required)")
 }
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	epSilences = "/api/v2/silences"
+	epSilence  = "/api/v2/silence/"
+)
+
+// ErrSilenceNotFound is returned if the silence to update or expire does not exist in the alertmanager.
+var ErrSilenceNotFound = fmt.Errorf("silence not found")
+
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// Silence is the silence in the alertmanager.
+type Silence struct {
+	// ID is the id of the silence to update, a new silence is created if it is empty.
+	ID        string           `json:"id,omitempty"`
+	Matchers  []SilenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+type SilenceClient interface {
+	// PostSilence creates or updates the silence, and returns the id of the silence.
+	// The alertmanager replaces the silence with a new one if it cannot be updated in place,
+	// e.g. its matchers change, so the id returned may be different from the given one.
+	PostSilence(ctx context.Context, silence *Silence) (string, error)
+	// ExpireSilence expires the silence with the given id.
+	ExpireSilence(ctx context.Context, id string) error
+}
+
+// alertmanagerClient requests the API v2 of the alertmanager.
+type alertmanagerClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewSilenceClient(options *Options) (SilenceClient, error) {
+	if options == nil || options.AlertmanagerEndpoint == "" {
+		return nil, fmt.Errorf("alertmanager endpoint is not configured")
+	}
+	return newAlertmanagerClient(options), nil
+}
+
+func newAlertmanagerClient(options *Options) *alertmanagerClient {
+	return &alertmanagerClient{
+		endpoint: strings.TrimSuffix(options.AlertmanagerEndpoint, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *alertmanagerClient) PostSilence(ctx context.Context, silence *Silence) (string, error) {
+	body, err := json.Marshal(silence)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+epSilences, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err = c.do(req)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	return result.SilenceID, nil
+}
+
+func (c *alertmanagerClient) ExpireSilence(ctx context.Context, id string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.endpoint+epSilence+id, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req)
+	return err
+}
+
+func (c *alertmanagerClient) do(req *http.Request) ([]byte, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return body, nil
+	case resp.StatusCode == http.StatusNotFound,
+		// the alertmanager responds with 500 when expiring an expired silence
+		strings.Contains(string(body), "already expired"):
+		return nil, ErrSilenceNotFound
+	}
+	return nil, fmt.Errorf("failed to request the alertmanager: %s, %s", resp.Status, body)
+}
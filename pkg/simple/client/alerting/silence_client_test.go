@@ -0,0 +1,86 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostSilence(t *testing.T) {
+	var posted Silence
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != epSilences {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if posted.ID == "unknown" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"silenceID":"new-id"}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewSilenceClient(&Options{AlertmanagerEndpoint: srv.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	silence := &Silence{
+		Matchers: []SilenceMatcher{{Name: "namespace", Value: "test", IsEqual: true}},
+		StartsAt: time.Now(),
+		EndsAt:   time.Now().Add(time.Hour),
+		Comment:  "maintenance",
+	}
+	id, err := c.PostSilence(context.Background(), silence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "new-id" || posted.Comment != "maintenance" || len(posted.Matchers) != 1 {
+		t.Fatalf("unexpected silence %s posted: %v", id, posted)
+	}
+
+	silence.ID = "unknown"
+	if _, err := c.PostSilence(context.Background(), silence); err != ErrSilenceNotFound {
+		t.Fatalf("expected ErrSilenceNotFound, got %v", err)
+	}
+}
+
+func TestExpireSilence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodDelete:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == epSilence+"active":
+		case r.URL.Path == epSilence+"expired":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`"silence expired-id already expired"`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewSilenceClient(&Options{AlertmanagerEndpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ExpireSilence(context.Background(), "active"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"expired", "unknown"} {
+		if err := c.ExpireSilence(context.Background(), id); err != ErrSilenceNotFound {
+			t.Fatalf("expected ErrSilenceNotFound for %s, got %v", id, err)
+		}
+	}
+
+	if _, err := NewSilenceClient(&Options{}); err == nil {
+		t.Fatal("expected error without the alertmanager endpoint")
+	}
+}
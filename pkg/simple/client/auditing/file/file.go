@@ -0,0 +1,381 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const (
+	// maxResultWindow is the maximum of from + size of a search, the same as the default
+	// index.max_result_window of elasticsearch. It bounds the events held in memory.
+	maxResultWindow = 10000
+	// recentAuditIDs is the number of the latest audit IDs remembered to count the distinct
+	// ones. Events of the same request are written close to each other, so the count is
+	// accurate as long as they are not further apart, similar to the approximate elasticsearch
+	// cardinality aggregation.
+	recentAuditIDs = 1024
+)
+
+// client queries auditing events from the log files written by Writer.
+// Every query streams the events from the files on disk, so the memory used is bounded
+// by the requested page instead of the size of the files.
+//
+// The files are local to the ks-apiserver writing them, so the file backend only works
+// with a single ks-apiserver replica, other replicas would answer from their own files.
+type client struct {
+	path string
+}
+
+func NewClient(options *auditing.Options) (auditing.Client, error) {
+	if options.LogPath == "" {
+		return nil, fmt.Errorf("auditing log path is empty")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(options.LogPath), 0755); err != nil {
+		return nil, err
+	}
+
+	return &client{path: options.LogPath}, nil
+}
+
+func (c *client) SearchAuditingEvent(filter *auditing.Filter, from, size int64,
+	sort string) (*auditing.Events, error) {
+
+	if from < 0 {
+		from = 0
+	}
+	if size < 0 {
+		size = 0
+	}
+	if from+size > maxResultWindow {
+		return nil, fmt.Errorf("result window is too large, from + size must be less than or equal to %d", maxResultWindow)
+	}
+
+	events := &auditing.Events{}
+	if sort == "asc" {
+		err := c.scan(filter, func(e *v1alpha1.Event) {
+			if events.Total >= from && events.Total < from+size {
+				events.Records = append(events.Records, e)
+			}
+			events.Total++
+		})
+		return events, err
+	}
+
+	// Keep the latest from + size events in a ring, the page is picked from them newest first.
+	window := from + size
+	ring := make([]*v1alpha1.Event, window)
+	err := c.scan(filter, func(e *v1alpha1.Event) {
+		if window > 0 {
+			ring[events.Total%window] = e
+		}
+		events.Total++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := from; i < from+size && i < events.Total; i++ {
+		events.Records = append(events.Records, ring[(events.Total-1-i)%window])
+	}
+	return events, nil
+}
+
+func (c *client) CountOverTime(filter *auditing.Filter, interval string) (*auditing.Histogram, error) {
+
+	if interval == "" {
+		interval = "15m"
+	}
+
+	d, err := parseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	h := auditing.Histogram{}
+	step := d.Milliseconds()
+	counts := make(map[int64]int64)
+	var start, end int64
+	err = c.scan(filter, func(e *v1alpha1.Event) {
+		t := e.RequestReceivedTimestamp.UnixMilli() / step * step
+		if h.Total == 0 || t < start {
+			start = t
+		}
+		if h.Total == 0 || t > end {
+			end = t
+		}
+		counts[t]++
+		h.Total++
+	})
+	if err != nil || h.Total == 0 {
+		return &h, err
+	}
+
+	// Buckets are aligned to the interval and the empty ones between the first
+	// and the last event are kept, the same as elasticsearch date histogram does.
+	for t := start; t <= end; t += step {
+		h.Buckets = append(h.Buckets, auditing.Bucket{Time: t, Count: counts[t]})
+	}
+	return &h, nil
+}
+
+func (c *client) StatisticsOnResources(filter *auditing.Filter) (*auditing.Statistics, error) {
+
+	s := &auditing.Statistics{}
+	recent := make(map[string]struct{}, recentAuditIDs)
+	ring := make([]string, recentAuditIDs)
+	err := c.scan(filter, func(e *v1alpha1.Event) {
+		s.Events++
+
+		id := string(e.AuditID)
+		if _, ok := recent[id]; ok {
+			return
+		}
+		s.Resources++
+
+		i := s.Resources % recentAuditIDs
+		delete(recent, ring[i])
+		ring[i] = id
+		recent[id] = struct{}{}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// files returns the rotated log files in the order they were rotated, followed by the log file,
+// which is the order the events were written in.
+func (c *client) files() ([]string, error) {
+	dir := filepath.Dir(c.path)
+	filename := filepath.Base(c.path)
+	ext := filepath.Ext(filename)
+	prefix := filename[:len(filename)-len(ext)]
+
+	// Rotated files are named prefix-timestamp.ext by lumberjack, the timestamp sorts in time order.
+	backups, err := filepath.Glob(filepath.Join(dir, prefix+"-*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(backups)
+
+	return append(backups, c.path), nil
+}
+
+// scan calls fn with the events matching the filter in the order they were written,
+// which is approximately the order they were received.
+func (c *client) scan(filter *auditing.Filter, fn func(e *v1alpha1.Event)) error {
+	paths, err := c.files()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := scanFile(path, filter, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanFile decodes the complete lines of the file, a trailing partial line is still being written.
+func scanFile(path string, filter *auditing.Filter, fn func(e *v1alpha1.Event)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		// The file may have been removed by rotation since it was listed.
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		e := &v1alpha1.Event{}
+		if err := json.Unmarshal(line, e); err != nil {
+			klog.Errorf("failed to decode auditing event in %s, %s", path, err)
+			continue
+		}
+
+		if matchEvent(filter, e) {
+			fn(e)
+		}
+	}
+}
+
+func matchEvent(f *auditing.Filter, e *v1alpha1.Event) bool {
+	if f == nil {
+		return true
+	}
+
+	var namespace, name, resource, subresource string
+	if e.ObjectRef != nil {
+		namespace = e.ObjectRef.Namespace
+		name = e.ObjectRef.Name
+		resource = e.ObjectRef.Resource
+		subresource = e.ObjectRef.Subresource
+	}
+
+	var code int32
+	var status string
+	if e.ResponseStatus != nil {
+		code = e.ResponseStatus.Code
+		status = e.ResponseStatus.Status
+	}
+
+	received := e.RequestReceivedTimestamp.Time
+
+	if len(f.ObjectRefNamespaceMap) > 0 || len(f.WorkspaceMap) > 0 {
+		matched := false
+		if t, ok := f.ObjectRefNamespaceMap[namespace]; ok && !received.Before(t) {
+			matched = true
+		}
+		if t, ok := f.WorkspaceMap[e.Workspace]; ok && !received.Before(t) {
+			matched = true
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if !f.StartTime.IsZero() && received.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && received.After(f.EndTime) {
+		return false
+	}
+
+	return matchAny(f.ObjectRefNamespaces, namespace, equal) &&
+		matchAny(f.ObjectRefNamespaceFuzzy, namespace, strings.Contains) &&
+		matchAny(f.Workspaces, e.Workspace, equal) &&
+		matchAny(f.WorkspaceFuzzy, e.Workspace, strings.Contains) &&
+		matchAny(f.ObjectRefNames, name, equal) &&
+		matchAny(f.ObjectRefNameFuzzy, name, strings.Contains) &&
+		matchAny(f.Verbs, e.Verb, equal) &&
+		matchAny(f.Levels, string(e.Level), equal) &&
+		matchAnyOf(f.SourceIpFuzzy, e.SourceIPs, strings.Contains) &&
+		matchAny(f.Users, e.User.Username, equal) &&
+		matchAny(f.UserFuzzy, e.User.Username, strings.Contains) &&
+		matchAnyOf(f.GroupFuzzy, e.User.Groups, strings.Contains) &&
+		matchAny(f.ObjectRefResources, resource, strings.HasPrefix) &&
+		matchAny(f.ObjectRefSubresources, subresource, strings.HasPrefix) &&
+		matchAnyCode(f.ResponseCodes, code) &&
+		matchAny(f.ResponseStatus, status, equal)
+}
+
+func equal(a, b string) bool {
+	return a == b
+}
+
+// matchAny returns true if there is no condition, or the value matches any of the conditions.
+func matchAny(conditions []string, value string, fn func(value, condition string) bool) bool {
+	if len(conditions) == 0 {
+		return true
+	}
+	for _, condition := range conditions {
+		if fn(value, condition) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyOf returns true if there is no condition, or any of the values matches any of the conditions.
+func matchAnyOf(conditions []string, values []string, fn func(value, condition string) bool) bool {
+	if len(conditions) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if matchAny(conditions, value, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyCode(codes []int32, code int32) bool {
+	if len(codes) == 0 {
+		return true
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInterval parses the histogram interval in the elasticsearch format, such as 30s, 15m, 1h, 1d and 1w.
+func parseInterval(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("invalid interval %s", interval)
+	}
+
+	var unit time.Duration
+	switch interval[len(interval)-1] {
+	case 's':
+		unit = time.Second
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid interval %s", interval)
+	}
+
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid interval %s", interval)
+	}
+	return time.Duration(n) * unit, nil
+}
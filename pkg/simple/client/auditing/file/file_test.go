@@ -0,0 +1,285 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/apis/audit"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+var base = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newEvent(id, workspace, namespace, user, verb string, code int32, offset time.Duration) v1alpha1.Event {
+	return v1alpha1.Event{
+		Workspace: workspace,
+		Event: audit.Event{
+			AuditID: types.UID(id),
+			Level:   audit.LevelMetadata,
+			Verb:    verb,
+			User: authenticationv1.UserInfo{
+				Username: user,
+				Groups:   []string{"system:authenticated"},
+			},
+			SourceIPs: []string{"10.0.0.1"},
+			ObjectRef: &audit.ObjectReference{
+				Resource:  "deployments",
+				Namespace: namespace,
+				Name:      "nginx",
+			},
+			ResponseStatus:           &metav1.Status{Code: code},
+			RequestReceivedTimestamp: metav1.NewMicroTime(base.Add(offset)),
+		},
+	}
+}
+
+func newTestClient(t *testing.T, events ...v1alpha1.Event) (*Writer, auditing.Client) {
+	options := auditing.NewAuditingOptions()
+	options.LogPath = filepath.Join(t.TempDir(), "audit.log")
+
+	w, err := NewWriter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	if err := w.Write(events); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, c
+}
+
+func TestSearchAuditingEvent(t *testing.T) {
+	_, c := newTestClient(t,
+		newEvent("1", "ws1", "ns1", "admin", "create", 201, 0),
+		newEvent("2", "ws1", "ns1", "admin", "delete", 200, time.Minute),
+		newEvent("3", "ws2", "ns2", "tester", "create", 403, 2*time.Minute),
+	)
+
+	var tests = []struct {
+		description string
+		filter      auditing.Filter
+		sort        string
+		from        int64
+		size        int64
+		total       int64
+		expected    []string
+	}{
+		{
+			description: "all events in descending order",
+			filter:      auditing.Filter{},
+			expected:    []string{"3", "2", "1"},
+		},
+		{
+			description: "all events in ascending order",
+			filter:      auditing.Filter{},
+			sort:        "asc",
+			expected:    []string{"1", "2", "3"},
+		},
+		{
+			description: "second page in descending order",
+			filter:      auditing.Filter{},
+			from:        2,
+			size:        2,
+			total:       3,
+			expected:    []string{"1"},
+		},
+		{
+			description: "second page in ascending order",
+			filter:      auditing.Filter{},
+			sort:        "asc",
+			from:        1,
+			size:        1,
+			total:       3,
+			expected:    []string{"2"},
+		},
+		{
+			description: "filter by workspace and verb",
+			filter:      auditing.Filter{Workspaces: []string{"ws1"}, Verbs: []string{"create"}},
+			expected:    []string{"1"},
+		},
+		{
+			description: "search by user and response code",
+			filter:      auditing.Filter{UserFuzzy: []string{"test"}, ResponseCodes: []int32{403}},
+			expected:    []string{"3"},
+		},
+		{
+			description: "namespaces visible since creation",
+			filter: auditing.Filter{ObjectRefNamespaceMap: map[string]time.Time{
+				"ns1": base.Add(30 * time.Second),
+				"ns2": base,
+			}},
+			expected: []string{"3", "2"},
+		},
+		{
+			description: "time range and resource prefix",
+			filter: auditing.Filter{
+				ObjectRefResources: []string{"deploy"},
+				StartTime:          base.Add(time.Minute),
+				EndTime:            base.Add(time.Minute),
+			},
+			expected: []string{"2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if test.size == 0 {
+				test.size = 10
+			}
+			if test.total == 0 {
+				test.total = int64(len(test.expected))
+			}
+			events, err := c.SearchAuditingEvent(&test.filter, test.from, test.size, test.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.total, events.Total)
+			var ids []string
+			for _, record := range events.Records {
+				ids = append(ids, string(record.(*v1alpha1.Event).AuditID))
+			}
+			assert.Equal(t, test.expected, ids)
+		})
+	}
+
+	if _, err := c.SearchAuditingEvent(&auditing.Filter{}, maxResultWindow, 10, ""); err == nil {
+		t.Fatal("expected an error for a result window beyond the maximum")
+	}
+}
+
+func TestCountOverTime(t *testing.T) {
+	_, c := newTestClient(t,
+		newEvent("1", "ws1", "ns1", "admin", "create", 201, 0),
+		newEvent("2", "ws1", "ns1", "admin", "delete", 200, 5*time.Minute),
+		newEvent("3", "ws1", "ns1", "admin", "create", 201, 40*time.Minute),
+	)
+
+	h, err := c.CountOverTime(&auditing.Filter{}, "15m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &auditing.Histogram{
+		Total: 3,
+		Buckets: []auditing.Bucket{
+			{Time: base.UnixMilli(), Count: 2},
+			{Time: base.Add(15 * time.Minute).UnixMilli(), Count: 0},
+			{Time: base.Add(30 * time.Minute).UnixMilli(), Count: 1},
+		},
+	}
+	assert.Equal(t, expected, h)
+
+	if _, err := c.CountOverTime(&auditing.Filter{}, "15x"); err == nil {
+		t.Fatal("expected an error for an invalid interval")
+	}
+}
+
+func TestStatisticsOnResources(t *testing.T) {
+	w, c := newTestClient(t,
+		newEvent("1", "ws1", "ns1", "admin", "create", 201, 0),
+		newEvent("2", "ws1", "ns1", "admin", "delete", 200, time.Minute),
+	)
+
+	s, err := c.StatisticsOnResources(&auditing.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &auditing.Statistics{Resources: 2, Events: 2}, s)
+
+	// Events appended after the client is created are read on the next query.
+	if err := w.Write([]v1alpha1.Event{newEvent("3", "ws1", "ns1", "admin", "update", 200, 2*time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = c.StatisticsOnResources(&auditing.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &auditing.Statistics{Resources: 3, Events: 3}, s)
+}
+
+func TestRotation(t *testing.T) {
+	options := auditing.NewAuditingOptions()
+	options.LogPath = filepath.Join(t.TempDir(), "audit.log")
+	options.LogMaxBackups = 2
+
+	w, err := NewWriter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	c, err := NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, id := range []string{"1", "2", "3", "4"} {
+		if err := w.Write([]v1alpha1.Event{newEvent(id, "ws1", "ns1", "admin", "create", 201, time.Duration(i)*time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			// Query the files in the middle of rotation.
+			if _, err := c.StatisticsOnResources(&auditing.Filter{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Rotate after every event, backup names are based on milliseconds.
+		time.Sleep(2 * time.Millisecond)
+		if err := w.logger.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Old backups are removed in the background.
+	backups := filepath.Join(filepath.Dir(options.LogPath), "audit-*.log")
+	assert.Eventually(t, func() bool {
+		files, err := filepath.Glob(backups)
+		return err == nil && len(files) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	events, err := c.SearchAuditingEvent(&auditing.Filter{}, 0, 10, "asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, record := range events.Records {
+		ids = append(ids, string(record.(*v1alpha1.Event).AuditID))
+	}
+	assert.Equal(t, []string{"3", "4"}, ids)
+
+	if _, err := os.Stat(options.LogPath); err != nil {
+		t.Fatal(err)
+	}
+}
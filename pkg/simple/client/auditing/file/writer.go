@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const (
+	DefaultMaxSize    = 100
+	DefaultMaxBackups = 10
+)
+
+// Writer appends auditing events to the auditing log file, one JSON encoded event per line.
+// The file is rotated by lumberjack when it grows beyond the max size, rotated files are
+// named after the log file with the rotation time inserted before the extension, and only
+// the latest max backups are retained.
+type Writer struct {
+	logger *lumberjack.Logger
+}
+
+func NewWriter(options *auditing.Options) (*Writer, error) {
+	if options.LogPath == "" {
+		return nil, fmt.Errorf("auditing log path is empty")
+	}
+
+	w := &Writer{
+		logger: &lumberjack.Logger{
+			Filename:   options.LogPath,
+			MaxSize:    options.LogMaxSize,
+			MaxBackups: options.LogMaxBackups,
+		},
+	}
+
+	if w.logger.MaxSize == 0 {
+		w.logger.MaxSize = DefaultMaxSize
+	}
+
+	if w.logger.MaxBackups == 0 {
+		w.logger.MaxBackups = DefaultMaxBackups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(options.LogPath), 0755); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Writer) Write(events []v1alpha1.Event) error {
+	for i := range events {
+		bs, err := json.Marshal(&events[i])
+		if err != nil {
+			// Same as sending to the webhook, drop the ResponseObject which
+			// normally causes the failure and try to serialize again.
+			if events[i].ResponseObject == nil {
+				return err
+			}
+			events[i].ResponseObject = nil
+			if bs, err = json.Marshal(&events[i]); err != nil {
+				return err
+			}
+		}
+
+		// Every event is written in a single call, so that a line never spans two files.
+		if _, err := w.logger.Write(append(bs, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) Close() error {
+	return w.logger.Close()
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package auditing
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -24,6 +25,11 @@ import (
 	"kubesphere.io/kubesphere/pkg/utils/reflectutils"
 )
 
+const (
+	BackendElasticsearch = "elasticsearch"
+	BackendFile          = "file"
+)
+
 type Options struct {
 	Enable     bool   `json:"enable" yaml:"enable"`
 	WebhookUrl string `json:"webhookUrl" yaml:"webhookUrl"`
@@ -39,6 +45,17 @@ type Options struct {
 	Password           string        `json:"password" yaml:"password"`
 	IndexPrefix        string        `json:"indexPrefix,omitempty" yaml:"indexPrefix,omitempty"`
 	Version            string        `json:"version" yaml:"version"`
+	// The store auditing events are queried from, elasticsearch or file.
+	// If left blank, elasticsearch is used. The file backend reads the files local to
+	// ks-apiserver, so it only works with a single ks-apiserver replica.
+	Backend string `json:"backend,omitempty" yaml:"backend,omitempty"`
+	// The file ks-apiserver writes auditing events to, one JSON encoded event per line.
+	// It is also the file the file backend reads auditing events from.
+	LogPath string `json:"logPath,omitempty" yaml:"logPath,omitempty"`
+	// The maximum size in megabytes of the auditing log file before it gets rotated.
+	LogMaxSize int `json:"logMaxSize,omitempty" yaml:"logMaxSize,omitempty"`
+	// The maximum number of rotated auditing log files to retain.
+	LogMaxBackups int `json:"logMaxBackups,omitempty" yaml:"logMaxBackups,omitempty"`
 }
 
 func NewAuditingOptions() *Options {
@@ -50,13 +67,24 @@ func NewAuditingOptions() *Options {
 }
 
 func (s *Options) ApplyTo(options *Options) {
-	if s.Host != "" {
+	if s.Host != "" || s.LogPath != "" {
 		reflectutils.Override(options, s)
 	}
 }
 
 func (s *Options) Validate() []error {
 	errs := make([]error, 0)
+
+	switch s.Backend {
+	case "", BackendElasticsearch:
+	case BackendFile:
+		if s.LogPath == "" {
+			errs = append(errs, fmt.Errorf("auditing log path must be set when the backend is %s", BackendFile))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported auditing backend %s", s.Backend))
+	}
+
 	return errs
 }
 
@@ -95,6 +123,20 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"Index name prefix. KubeSphere will retrieve auditing against indices matching the prefix.")
 
 	fs.StringVar(&s.Version, "auditing-elasticsearch-version", c.Version, ""+
-		"Elasticsearch major version, e.g. 5/6/7, if left blank, will detect automatically."+
+		"Elasticsearch major version, e.g. 5/6/7/8, if left blank, will detect automatically."+
 		"Currently, minimum supported version is 5.x")
+
+	fs.StringVar(&s.Backend, "auditing-backend", c.Backend, ""+
+		"The store auditing events are queried from, elasticsearch or file. If left blank, elasticsearch is used. "+
+		"The file backend reads the files local to ks-apiserver, so it only works with a single ks-apiserver replica.")
+
+	fs.StringVar(&s.LogPath, "auditing-log-path", c.LogPath, ""+
+		"If set, ks-apiserver writes auditing events to this file, one JSON encoded event per line. "+
+		"It is required when the auditing backend is file.")
+
+	fs.IntVar(&s.LogMaxSize, "auditing-log-maxsize", c.LogMaxSize,
+		"The maximum size in megabytes of the auditing log file before it gets rotated, 100 if left blank.")
+
+	fs.IntVar(&s.LogMaxBackups, "auditing-log-maxbackup", c.LogMaxBackups,
+		"The maximum number of rotated auditing log files to retain, 10 if left blank.")
 }
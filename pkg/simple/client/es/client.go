@@ -32,6 +32,7 @@ import (
 	v5 "kubesphere.io/kubesphere/pkg/simple/client/es/versions/v5"
 	v6 "kubesphere.io/kubesphere/pkg/simple/client/es/versions/v6"
 	v7 "kubesphere.io/kubesphere/pkg/simple/client/es/versions/v7"
+	v8 "kubesphere.io/kubesphere/pkg/simple/client/es/versions/v8"
 	"kubesphere.io/kubesphere/pkg/utils/esutil"
 )
 
@@ -39,6 +40,7 @@ const (
 	ElasticV5    = "5"
 	ElasticV6    = "6"
 	ElasticV7    = "7"
+	ElasticV8    = "8"
 	OpenSearchV1 = "opensearchv1"
 	OpenSearchV2 = "opensearchv2"
 )
@@ -78,6 +80,8 @@ func NewClient(host string, basicAuth bool, username, password, indexPrefix, ver
 		es.c, err = v6.New(es.host, es.basicAuth, es.username, es.password, es.index)
 	case ElasticV7:
 		es.c, err = v7.New(es.host, es.basicAuth, es.username, es.password, es.index)
+	case ElasticV8:
+		es.c, err = v8.New(es.host, es.basicAuth, es.username, es.password, es.index)
 	case "":
 		es.c = nil
 	default:
@@ -102,7 +106,7 @@ func (c *Client) loadClient() error {
 	}
 
 	// Detect Elasticsearch server version using Info API.
-	// Info API is backward compatible across v5, v6 and v7.
+	// Info API is backward compatible across v5, v6, v7 and v8.
 	esv6, err := v6.New(c.host, c.basicAuth, c.username, c.password, c.index)
 	if err != nil {
 		return err
@@ -153,6 +157,8 @@ func (c *Client) loadClient() error {
 		vc, err = v6.New(c.host, c.basicAuth, c.username, c.password, c.index)
 	case ElasticV7:
 		vc, err = v7.New(c.host, c.basicAuth, c.username, c.password, c.index)
+	case ElasticV8:
+		vc, err = v8.New(c.host, c.basicAuth, c.username, c.password, c.index)
 	default:
 		err = fmt.Errorf("unsupported elasticsearch version %s", version)
 	}
@@ -179,7 +185,12 @@ func (c *Client) Search(builder *query.Builder, startTime, endTime time.Time, sc
 		return nil, err
 	}
 
-	res, err := c.c.Search(esutil.ResolveIndexNames(c.index, startTime, endTime), body, scroll)
+	indices := esutil.ResolveIndexNames(c.index, startTime, endTime)
+	if r, ok := c.c.(versions.IndexResolver); ok {
+		indices = r.ResolveIndexNames(c.index, startTime, endTime)
+	}
+
+	res, err := c.c.Search(indices, body, scroll)
 	if err != nil {
 		return nil, err
 	}
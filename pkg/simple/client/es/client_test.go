@@ -43,6 +43,10 @@ func TestNewClient(t *testing.T) {
 			fakeResp: "es7_detect_version_major_200.json",
 			expected: ElasticV7,
 		},
+		{
+			fakeResp: "es8_detect_version_major_200.json",
+			expected: ElasticV8,
+		},
 		{
 			fakeResp: "opensearchv2_detect_version_major_200.json",
 			expected: OpenSearchV2,
@@ -155,6 +159,92 @@ func TestOpensearchClient_Search(t *testing.T) {
 	}
 }
 
+func TestElasticV8Client_Scroll(t *testing.T) {
+	var searched []string
+	var closed string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_data_stream/", func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(`{"data_streams":[{"name":"ks-logstash-log"}]}`))
+	})
+	mux.HandleFunc("/ks-logstash-log*/_pit", func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(`{"id":"pit-0"}`))
+	})
+	mux.HandleFunc("/_pit", func(res http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		_ = jsoniter.NewDecoder(req.Body).Decode(&body)
+		closed = body["id"]
+		_, _ = res.Write([]byte(`{"succeeded":true,"num_freed":1}`))
+	})
+	mux.HandleFunc("/_search", func(res http.ResponseWriter, req *http.Request) {
+		var body struct {
+			From        *int64            `json:"from"`
+			Pit         map[string]string `json:"pit"`
+			SearchAfter []int64           `json:"search_after"`
+		}
+		_ = jsoniter.NewDecoder(req.Body).Decode(&body)
+		if body.From != nil {
+			t.Errorf("from must not be set with search_after")
+		}
+		searched = append(searched, fmt.Sprintf("%s%v", body.Pit["id"], body.SearchAfter))
+
+		switch len(searched) {
+		case 1:
+			_, _ = res.Write([]byte(`{"pit_id":"pit-1","hits":{"total":{"value":2,"relation":"eq"},` +
+				`"hits":[{"_source":{"log":"a"},"sort":[1672531200000,9007199254740993]}]}}`))
+		case 2:
+			_, _ = res.Write([]byte(`{"pit_id":"pit-2","hits":{"total":{"value":2,"relation":"eq"},` +
+				`"hits":[{"_source":{"log":"b"},"sort":[1672531100000,2]}]}}`))
+		default:
+			_, _ = res.Write([]byte(`{"pit_id":"pit-2","hits":{"total":{"value":2,"relation":"eq"},"hits":[]}}`))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, false, "", "", "ks-logstash-log", ElasticV8)
+	if err != nil {
+		t.Fatalf("create client error, %s", err)
+	}
+
+	b := query.NewBuilder().WithSort("time", "desc").WithFrom(0).WithSize(1)
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := c.Search(b, start, start.Add(time.Hour), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GetTotalHitCount(resp.Total) != 2 || len(resp.AllHits) != 1 {
+		t.Fatalf("unexpected first page %v", resp)
+	}
+
+	var logs []interface{}
+	logs = append(logs, resp.AllHits[0].Source)
+	id := resp.ScrollId
+	for {
+		resp, err = c.Scroll(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id = resp.ScrollId
+		if len(resp.AllHits) == 0 {
+			break
+		}
+		logs = append(logs, resp.AllHits[0].Source)
+	}
+	c.ClearScroll(id)
+
+	expected := []string{"pit-0[]", "pit-1[1672531200000 9007199254740993]", "pit-2[1672531100000 2]"}
+	if diff := cmp.Diff(searched, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+	if diff := cmp.Diff(logs, []interface{}{map[string]interface{}{"log": "a"}, map[string]interface{}{"log": "b"}}); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", logs, diff)
+	}
+	if closed != "pit-2" {
+		t.Fatalf("expected the last point in time to be closed, got %s", closed)
+	}
+}
+
 func mockElasticsearchService(pattern, fakeResp string, fakeCode int) *httptest.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc(pattern, func(res http.ResponseWriter, req *http.Request) {
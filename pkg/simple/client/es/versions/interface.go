@@ -1,5 +1,7 @@
 package versions
 
+import "time"
+
 // versioned es client interface
 type Client interface {
 	Search(indices string, body []byte, scroll bool) ([]byte, error)
@@ -7,3 +9,9 @@ type Client interface {
 	ClearScroll(id string)
 	GetTotalHitCount(v interface{}) int64
 }
+
+// IndexResolver is implemented by the versioned es clients which resolve the indices
+// to search by themselves, e.g. to search data streams rather than date suffixed indices.
+type IndexResolver interface {
+	ResolveIndexNames(prefix string, start, end time.Time) string
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v8
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	jsoniter "github.com/json-iterator/go"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/es/versions"
+	"kubesphere.io/kubesphere/pkg/utils/esutil"
+)
+
+// Numbers are decoded as json.Number, so that the sort values passed to search_after keep their precision.
+var json = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	UseNumber:              true,
+}.Froze()
+
+const (
+	// keepAlive is how long a point in time is kept between two pages, the same as the scroll of other versions.
+	keepAlive = "1m"
+	// dataStreamsResync is how often the data streams matching the index prefix are detected again.
+	dataStreamsResync = 5 * time.Minute
+)
+
+// Elastic talks to Elasticsearch 8 with plain REST requests, the vendored go-elasticsearch
+// client is only used as the transport.
+// Indices are resolved to the data streams matching the index prefix if there are any,
+// and deep pagination uses point in time with search_after instead of the deprecated scroll API.
+// The point in time id and the search_after values are encoded into an opaque cursor
+// returned as the scroll id, so callers use it the same way as the scroll of other versions.
+type Elastic struct {
+	client *elasticsearch.Client
+	index  string
+
+	mutex       sync.Mutex
+	dataStreams bool
+	detectedAt  time.Time
+}
+
+// cursor is the state of a point in time search between two pages.
+type cursor struct {
+	PitId       string              `json:"pit_id"`
+	Body        jsoniter.RawMessage `json:"body"`
+	SearchAfter []interface{}       `json:"search_after,omitempty"`
+}
+
+func New(address string, basicAuth bool, username, password, index string) (*Elastic, error) {
+	var client *elasticsearch.Client
+	var err error
+
+	if !basicAuth {
+		username = ""
+		password = ""
+	}
+
+	client, err = elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{address},
+		Username:  username,
+		Password:  password,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	})
+
+	return &Elastic{client: client, index: index}, err
+}
+
+// ResolveIndexNames returns the pattern matching the data streams and the indices
+// with the prefix when there are data streams matching the prefix. Data streams
+// manage their backing indices themselves, so the time range is left to the query.
+// Otherwise, the date suffixed index names are resolved the same as other versions.
+func (e *Elastic) ResolveIndexNames(prefix string, start, end time.Time) string {
+	if e.hasDataStreams(prefix) {
+		return fmt.Sprintf("%s*", prefix)
+	}
+	return esutil.ResolveIndexNames(prefix, start, end)
+}
+
+func (e *Elastic) Search(indices string, body []byte, scroll bool) ([]byte, error) {
+	if scroll {
+		return e.openPointInTime(indices, body)
+	}
+
+	params := url.Values{}
+	params.Set("track_total_hits", "true")
+	params.Set("ignore_unavailable", "true")
+	return e.perform(http.MethodPost, "/"+indices+"/_search", params, body)
+}
+
+func (e *Elastic) Scroll(id string) ([]byte, error) {
+	c, err := decodeCursor(id)
+	if err != nil {
+		return nil, err
+	}
+	return e.searchAfter(c)
+}
+
+func (e *Elastic) ClearScroll(id string) {
+	c, err := decodeCursor(id)
+	if err != nil {
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{"id": c.PitId})
+	if err != nil {
+		return
+	}
+	_, _ = e.perform(http.MethodDelete, "/_pit", nil, body)
+}
+
+func (e *Elastic) GetTotalHitCount(v interface{}) int64 {
+	m, _ := v.(map[string]interface{})
+	f, _ := m["value"].(float64)
+	return int64(f)
+}
+
+func (e *Elastic) openPointInTime(indices string, body []byte) ([]byte, error) {
+	params := url.Values{}
+	params.Set("keep_alive", keepAlive)
+	params.Set("ignore_unavailable", "true")
+	b, err := e.perform(http.MethodPost, "/"+indices+"/_pit", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pit struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(b, &pit); err != nil {
+		return nil, err
+	}
+
+	return e.searchAfter(&cursor{PitId: pit.Id, Body: body})
+}
+
+// searchAfter fetches the page following the cursor, and returns the response
+// with the cursor of the next page as the scroll id.
+func (e *Elastic) searchAfter(c *cursor) ([]byte, error) {
+	body := make(map[string]interface{})
+	if len(c.Body) != 0 {
+		if err := json.Unmarshal(c.Body, &body); err != nil {
+			return nil, err
+		}
+	}
+
+	// The index is bound to the point in time, and search_after requires from to be 0.
+	delete(body, "from")
+	body["pit"] = map[string]string{"id": c.PitId, "keep_alive": keepAlive}
+	if len(c.SearchAfter) != 0 {
+		body["search_after"] = c.SearchAfter
+	}
+
+	bs, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("track_total_hits", "true")
+	b, err := e.perform(http.MethodPost, "/_search", params, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]interface{})
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	// The point in time id may change between searches, always continue with the latest one.
+	next := &cursor{PitId: c.PitId, Body: c.Body, SearchAfter: c.SearchAfter}
+	if id, ok := res["pit_id"].(string); ok && id != "" {
+		next.PitId = id
+	}
+	hits, _ := res["hits"].(map[string]interface{})
+	if all, _ := hits["hits"].([]interface{}); len(all) != 0 {
+		last, _ := all[len(all)-1].(map[string]interface{})
+		if sort, ok := last["sort"].([]interface{}); ok {
+			next.SearchAfter = sort
+		}
+	}
+
+	id, err := encodeCursor(next)
+	if err != nil {
+		return nil, err
+	}
+	res["_scroll_id"] = id
+	delete(res, "pit_id")
+
+	return json.Marshal(res)
+}
+
+func (e *Elastic) hasDataStreams(prefix string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if !e.detectedAt.IsZero() && time.Since(e.detectedAt) < dataStreamsResync {
+		return e.dataStreams
+	}
+
+	b, err := e.perform(http.MethodGet, "/_data_stream/"+prefix+"*", nil, nil)
+	if err != nil {
+		// Keep the last detected result, and detect again on the next search.
+		return e.dataStreams
+	}
+
+	var res struct {
+		DataStreams []interface{} `json:"data_streams"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return e.dataStreams
+	}
+
+	e.dataStreams = len(res.DataStreams) != 0
+	e.detectedAt = time.Now()
+	return e.dataStreams
+}
+
+func (e *Elastic) perform(method, path string, params url.Values, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		req.URL.RawQuery = params.Encode()
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := e.client.Perform(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode > 299 {
+		return nil, parseError(response.StatusCode, b)
+	}
+
+	return b, nil
+}
+
+func encodeCursor(c *cursor) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(id string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scroll id, %s", err)
+	}
+
+	c := &cursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid scroll id, %s", err)
+	}
+	if strings.TrimSpace(c.PitId) == "" {
+		return nil, fmt.Errorf("invalid scroll id, point in time id is empty")
+	}
+	return c, nil
+}
+
+func parseError(status int, body []byte) error {
+	var e versions.Error
+	if err := json.Unmarshal(body, &e); err != nil || e.Details == nil {
+		return fmt.Errorf("[%d] %s", status, string(body))
+	}
+
+	// Print the response status and error information.
+	if len(e.Details.RootCause) != 0 {
+		return fmt.Errorf("type: %v, reason: %v", e.Details.Type, e.Details.RootCause[0].Reason)
+	}
+	return fmt.Errorf("type: %v, reason: %v", e.Details.Type, e.Details.Reason)
+}
@@ -75,6 +75,6 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"Index name prefix. KubeSphere will retrieve events against indices matching the prefix.")
 
 	fs.StringVar(&s.Version, "events-elasticsearch-version", c.Version, ""+
-		"Elasticsearch major version, e.g. 5/6/7, if left blank, will detect automatically."+
+		"Elasticsearch major version, e.g. 5/6/7/8, if left blank, will detect automatically."+
 		"Currently, minimum supported version is 5.x")
 }
@@ -17,8 +17,11 @@ limitations under the License.
 package gateway
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 
+	"kubesphere.io/kubesphere/pkg/simple/client/acme"
 	"kubesphere.io/kubesphere/pkg/utils/reflectutils"
 )
 
@@ -28,6 +31,10 @@ type Options struct {
 	Namespace   string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
 	Repository  string `json:"repository,omitempty" yaml:"repository,omitempty"`
 	Tag         string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	// CertificateExpiryDays is how many days before the TLS certificates of the gateways and ingresses expire
+	// to alert.
+	CertificateExpiryDays int           `json:"certificateExpiryDays,omitempty" yaml:"certificateExpiryDays,omitempty"`
+	ACME                  *acme.Options `json:"acme,omitempty" yaml:"acme,omitempty"`
 }
 
 // NewGatewayOptions creates a default Gateway Option
@@ -37,6 +44,9 @@ func NewGatewayOptions() *Options {
 		Namespace:   "", // constants.KubeSphereControlNamespace
 		Repository:  "",
 		Tag:         "",
+
+		CertificateExpiryDays: 30,
+		ACME:                  acme.NewACMEOptions(),
 	}
 }
 
@@ -48,6 +58,9 @@ func (s *Options) IsEmpty() bool {
 func (s *Options) Validate() []error {
 	var errors []error
 
+	if s.CertificateExpiryDays < 0 {
+		errors = append(errors, fmt.Errorf("certificate expiry days must not be negative"))
+	}
 	return errors
 }
 
@@ -65,4 +78,14 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.StringVar(&s.Namespace, "namespace", c.Namespace, "Working Namespace of the Gateway's Ingress Controller.")
 	fs.StringVar(&s.Repository, "repository", c.Repository, "The Gateway Controller's image repository")
 	fs.StringVar(&s.Tag, "tag", c.Tag, "The Gateway Controller's image tag")
+	fs.IntVar(&s.CertificateExpiryDays, "certificate-expiry-days", c.CertificateExpiryDays,
+		"How many days before the TLS certificates of the gateways and ingresses expire to alert.")
+	defaults := c.ACME
+	if defaults == nil {
+		defaults = acme.NewACMEOptions()
+	}
+	if s.ACME == nil {
+		s.ACME = acme.NewACMEOptions()
+	}
+	s.ACME.AddFlags(fs, defaults)
 }
@@ -158,9 +158,12 @@ func (c *client) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
 		return err
 	}
 
-	defer c.c.ClearScroll(id)
-
 	id = resp.ScrollId
+	// The scroll id may change between scrolls, clear the last one.
+	defer func() {
+		c.c.ClearScroll(id)
+	}()
+
 	for _, hit := range resp.AllHits {
 		data = append(data, c.getSource(hit.Source).Log)
 	}
@@ -80,7 +80,7 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"Index name prefix. KubeSphere will retrieve logs against indices matching the prefix.")
 
 	fs.StringVar(&s.Version, "logging-elasticsearch-version", c.Version, ""+
-		"Elasticsearch major version, e.g. 5/6/7, if left blank, will detect automatically."+
+		"Elasticsearch major version, e.g. 5/6/7/8, if left blank, will detect automatically."+
 		"Currently, minimum supported version is 5.x")
 
 	fs.IntVar(&s.ExportLogsLimit, "logging-export-logs-limit", c.ExportLogsLimit, ""+
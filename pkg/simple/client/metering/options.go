@@ -1,5 +1,7 @@
 package metering
 
+import "time"
+
 type PriceInfo struct {
 	// currency unit, currently support CNY and USD
 	CpuPerCorePerHour float64 `json:"cpuPerCorePerHour" yaml:"cpuPerCorePerHour"`
@@ -12,6 +14,8 @@ type PriceInfo struct {
 	// egress network traffice cost with above currency unit for per MB per hour
 	PvcPerGigabytesPerHour float64 `json:"pvcPerGigabytesPerHour" yaml:"pvcPerGigabytesPerHour"`
 	// pvc cost with above currency unit for per GB per hour
+	GpuPerGpuPerHour float64 `json:"gpuPerGpuPerHour" yaml:"gpuPerGpuPerHour"`
+	// gpu cost with above currency unit for per gpu per hour
 	CurrencyUnit string `json:"currencyUnit" yaml:"currencyUnit"`
 }
 
@@ -19,9 +23,17 @@ type Billing struct {
 	PriceInfo PriceInfo `json:"priceInfo" yaml:"priceInfo"`
 }
 
+type Statement struct {
+	// open statements are recalculated at the interval until they are closed
+	RecalculateInterval time.Duration `json:"recalculateInterval" yaml:"recalculateInterval"`
+	// statements are closed after the delay since the end of the period, when the metering data is settled
+	CloseDelay time.Duration `json:"closeDelay" yaml:"closeDelay"`
+}
+
 type Options struct {
-	RetentionDay string  `json:"retentionDay" yaml:"retentionDay"`
-	Billing      Billing `json:"billing" yaml:"billing"`
+	RetentionDay string    `json:"retentionDay" yaml:"retentionDay"`
+	Billing      Billing   `json:"billing" yaml:"billing"`
+	Statement    Statement `json:"statement" yaml:"statement"`
 }
 
 var DefaultMeteringOption = Options{
@@ -33,9 +45,14 @@ var DefaultMeteringOption = Options{
 			IngressNetworkTrafficPerMegabytesPerHour: 0,
 			EgressNetworkTrafficPerMegabytesPerHour:  0,
 			PvcPerGigabytesPerHour:                   0,
+			GpuPerGpuPerHour:                         0,
 			CurrencyUnit:                             "",
 		},
 	},
+	Statement: Statement{
+		RecalculateInterval: 6 * time.Hour,
+		CloseDelay:          24 * time.Hour,
+	},
 }
 
 func NewMeteringOptions() *Options {
@@ -361,6 +361,9 @@ func parseQueryResp(value model.Value, metricFilter func(metric model.Metric) bo
 }
 
 func genMetricFilter(o monitoring.QueryOption) func(metric model.Metric) bool {
+	if to, ok := o.(monitoring.MetricTemplatesOption); ok {
+		o = to.QueryOption
+	}
 	if o != nil {
 		if po, ok := o.(monitoring.PodOption); ok {
 			if po.NamespacedResourcesFilter != "" {
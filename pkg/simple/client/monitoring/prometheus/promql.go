@@ -264,7 +264,11 @@ var promQLTemplates = map[string]string{
 }
 
 func makeExpr(metric string, opts monitoring.QueryOptions) string {
-	tmpl := promQLTemplates[metric]
+	tmpl, ok := promQLTemplates[metric]
+	if !ok {
+		// user defined named metrics
+		tmpl = opts.MetricTemplates[metric]
+	}
 	switch opts.Level {
 	case monitoring.LevelCluster:
 		return tmpl
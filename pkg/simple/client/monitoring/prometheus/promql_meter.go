@@ -110,8 +110,14 @@ round(
 )`,
 
 	"meter_cluster_pvc_bytes_total": `
+sum by ($storageClassLabel) (
+	topk(1, avg_over_time(namespace:pvc_bytes_total:sum{}[$step])$storageClassJoin) by (persistentvolumeclaim$storageClassGroup)
+)`,
+
+	// gpu requests of the nvidia device plugin
+	"meter_cluster_gpu_usage": `
 sum(
-	topk(1, avg_over_time(namespace:pvc_bytes_total:sum{}[$step])) by (persistentvolumeclaim)
+	avg_over_time(kube_pod_container_resource_requests{resource="nvidia_com_gpu"}[$step])
 )`,
 
 	// node
@@ -229,9 +235,14 @@ sum(
 		1,
 		avg_over_time(
 			namespace:pvc_bytes_total:sum{$nodeSelector}[$step]
-		)
-	) by (persistentvolumeclaim, node)
-) by (node)`,
+		)$storageClassJoin
+	) by (persistentvolumeclaim, node$storageClassGroup)
+) by (node$storageClassGroup)`,
+
+	"meter_node_gpu_usage": `
+sum by (node) (
+	avg_over_time(kube_pod_container_resource_requests{$nodeSelector, resource="nvidia_com_gpu"}[$step])
+)`,
 
 	// workspace
 	"meter_workspace_cpu_usage": `
@@ -360,9 +371,16 @@ round(
 sum (
 	topk(
 		1,
-		avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])
-	) by (persistentvolumeclaim, workspace)
-) by (workspace)`,
+		avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])$storageClassJoin
+	) by (persistentvolumeclaim, workspace$storageClassGroup)
+) by (workspace$storageClassGroup)`,
+
+	"meter_workspace_gpu_usage": `
+sum by (workspace) (
+	avg_over_time(kube_pod_container_resource_requests{namespace!="", resource="nvidia_com_gpu"}[$step])
+	* on (namespace) group_left(workspace)
+	kube_namespace_labels{$1}
+)`,
 
 	// namespace
 	"meter_namespace_cpu_usage": `
@@ -489,9 +507,16 @@ round(
 sum (
 	topk(
 		1,
-		avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])
-	) by (persistentvolumeclaim, namespace)
-) by (namespace)`,
+		avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])$storageClassJoin
+	) by (persistentvolumeclaim, namespace$storageClassGroup)
+) by (namespace$storageClassGroup)`,
+
+	"meter_namespace_gpu_usage": `
+sum by (namespace) (
+	avg_over_time(kube_pod_container_resource_requests{namespace!="", resource="nvidia_com_gpu"}[$step])
+	* on (namespace) group_left(workspace)
+	kube_namespace_labels{$1}
+)`,
 
 	// application
 	"meter_application_cpu_usage": `
@@ -651,9 +676,22 @@ round(
 )`,
 
 	"meter_application_pvc_bytes_total": `
+sum by (namespace, application$storageClassGroup) (
+	label_replace(
+		topk(1, avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])$storageClassJoin) by (persistentvolumeclaim$storageClassGroup),
+		"application",
+		"$app",
+		"",
+		""
+	)
+)`,
+
+	"meter_application_gpu_usage": `
 sum by (namespace, application) (
 	label_replace(
-		topk(1, avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])) by (persistentvolumeclaim),
+		avg_over_time(
+			namespace:kube_workload_resource_request:sum{workload!~"Job:.+", resource="nvidia_com_gpu", $1}[$step]
+		),
 		"application",
 		"$app",
 		"",
@@ -751,11 +789,18 @@ round(
 )`,
 
 	"meter_workload_pvc_bytes_total": `
-sum by (namespace, workload) (
+sum by (namespace, workload$storageClassGroup) (
 	topk(
 		1,
-		avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])
-	) by (persistentvolumeclaim, namespace, workload)
+		avg_over_time(namespace:pvc_bytes_total:sum{$1}[$step])$storageClassJoin
+	) by (persistentvolumeclaim, namespace, workload$storageClassGroup)
+)`,
+
+	"meter_workload_gpu_usage": `
+sum by (namespace, workload) (
+	avg_over_time(
+		namespace:kube_workload_resource_request:sum{workload!~"Job:.+", resource="nvidia_com_gpu", $1}[$step]
+	)
 )`,
 
 	// service
@@ -951,6 +996,21 @@ round(
 	1
 )`,
 
+	"meter_service_gpu_usage": `
+sum by (namespace, service) (
+	label_replace(
+		sum by (namespace, pod) (
+			avg_over_time(
+				namespace:kube_pod_resource_request:sum{owner_kind!="Job", resource="nvidia_com_gpu", $1}[$step]
+			)
+		),
+		"service",
+		"$svc",
+		"",
+		""
+	)
+)`,
+
 	// pod
 	"meter_pod_cpu_usage": `
 round(
@@ -1095,17 +1155,49 @@ sum by (namespace, pod) (
 * on (namespace, pod) group_left(node) kube_pod_info{$2}`,
 
 	"meter_pod_pvc_bytes_total": `
+sum by (namespace, pod$storageClassGroup) (
+	avg_over_time(namespace:pvc_bytes_total:sum{$internalPodSelector}[$step])$storageClassJoin
+)
+* on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{$1}
+* on (namespace, pod) group_left(node) kube_pod_info{$2}`,
+
+	"meter_pod_gpu_usage": `
 sum by (namespace, pod) (
-	avg_over_time(namespace:pvc_bytes_total:sum{$internalPodSelector}[$step])
+	avg_over_time(kube_pod_container_resource_requests{resource="nvidia_com_gpu", $internalPodSelector}[$step])
 )
 * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{$1}
 * on (namespace, pod) group_left(node) kube_pod_info{$2}`,
 }
 
+// promQLMeterNodeBreakdownTemplates are the requests of the namespaces on every node, which the usage of the
+// namespace meters is split across the nodes by, as the usage of namespaces is not recorded by node.
+var promQLMeterNodeBreakdownTemplates = map[string]string{
+	"meter_namespace_cpu_usage": `
+sum by (namespace, node) (
+	avg_over_time(kube_pod_container_resource_requests{namespace!="", resource="cpu", unit="core"}[$step])
+	* on (namespace) group_left(workspace)
+	kube_namespace_labels{$1}
+)`,
+
+	"meter_namespace_memory_usage_wo_cache": `
+sum by (namespace, node) (
+	avg_over_time(kube_pod_container_resource_requests{namespace!="", resource="memory"}[$step])
+	* on (namespace) group_left(workspace)
+	kube_namespace_labels{$1}
+)`,
+
+	"meter_namespace_gpu_usage": `
+sum by (namespace, node) (
+	avg_over_time(kube_pod_container_resource_requests{namespace!="", resource="nvidia_com_gpu"}[$step])
+	* on (namespace) group_left(workspace)
+	kube_namespace_labels{$1}
+)`,
+}
+
 func makeMeterExpr(meter string, o monitoring.QueryOptions) string {
 
 	var tmpl string
-	if tmpl = getMeterTemplate(meter); len(tmpl) == 0 {
+	if tmpl = getMeterTemplate(meter, o); len(tmpl) == 0 {
 		klog.Errorf("invalid meter %s", meter)
 		return ""
 	}
@@ -1134,8 +1226,17 @@ func makeMeterExpr(meter string, o monitoring.QueryOptions) string {
 
 }
 
-func getMeterTemplate(meter string) string {
-	if tmpl, ok := promQLMeterTemplates[meter]; !ok {
+func getMeterTemplate(meter string, o monitoring.QueryOptions) string {
+	templates := promQLMeterTemplates
+	if o.ByNode {
+		if o.Level != monitoring.LevelNamespace {
+			klog.Errorf("meter %s can not be broken down by node at level %v", meter, o.Level)
+			return ""
+		}
+		templates = promQLMeterNodeBreakdownTemplates
+	}
+
+	if tmpl, ok := templates[meter]; !ok {
 		klog.Errorf("invalid meter %s", meter)
 		return ""
 	} else {
@@ -1149,6 +1250,7 @@ func renderMeterTemplate(tmpl string, o monitoring.QueryOptions) string {
 		return ""
 	}
 
+	tmpl = replaceStorageClassSelector(tmpl, o)
 	tmpl = replaceStepSelector(tmpl, o)
 	tmpl = replacePVCSelector(tmpl, o)
 	tmpl = replaceNodeSelector(tmpl, o)
@@ -1263,6 +1365,24 @@ func replacePVCSelector(tmpl string, o monitoring.QueryOptions) string {
 	return strings.Replace(tmpl, "$pvc", strings.Join(filterConditions, ","), -1)
 }
 
+// replaceStorageClassSelector joins the PVCs to their storage classes, to select the PVCs
+// of the storage class queried, or to break the usage down by storage class.
+func replaceStorageClassSelector(tmpl string, o monitoring.QueryOptions) string {
+	var join, group, label string
+	if o.StorageClassName != "" || o.ByStorageClass {
+		var selector string
+		if o.StorageClassName != "" {
+			selector = fmt.Sprintf(`storageclass="%s"`, o.StorageClassName)
+		}
+		join = fmt.Sprintf(` * on (namespace, persistentvolumeclaim) group_left(storageclass) `+
+			`max by (namespace, persistentvolumeclaim, storageclass) (kube_persistentvolumeclaim_info{%s})`, selector)
+	}
+	if o.ByStorageClass {
+		group, label = ", storageclass", "storageclass"
+	}
+	return strings.NewReplacer("$storageClassJoin", join, "$storageClassGroup", group, "$storageClassLabel", label).Replace(tmpl)
+}
+
 func replaceFactor(tmpl string, o monitoring.QueryOptions) string {
 	stepStr := strconv.Itoa(int(o.MeterOptions.Step.Hours()))
 
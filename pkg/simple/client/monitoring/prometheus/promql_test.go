@@ -17,9 +17,12 @@ limitations under the License.
 package prometheus
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/prometheus/promql/parser"
 
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus/testdata"
@@ -202,6 +205,18 @@ func TestMakeExpr(t *testing.T) {
 				Level: monitoring.LevelComponent,
 			},
 		},
+		{
+			name: "workload_http_request_latency_p99",
+			opts: monitoring.QueryOptions{
+				Level:          monitoring.LevelWorkload,
+				WorkloadKind:   "deployment",
+				NamespaceName:  "default",
+				ResourceFilter: "apiserver|coredns",
+				MetricTemplates: map[string]string{
+					"workload_http_request_latency_p99": `histogram_quantile(0.99, sum by (le, workload) (rate(http_request_duration_seconds_bucket{$1}[5m])))`,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -214,3 +229,68 @@ func TestMakeExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeMeterExpr(t *testing.T) {
+	levels := map[string]monitoring.Level{
+		"cluster":     monitoring.LevelCluster,
+		"node":        monitoring.LevelNode,
+		"workspace":   monitoring.LevelWorkspace,
+		"namespace":   monitoring.LevelNamespace,
+		"application": monitoring.LevelApplication,
+		"workload":    monitoring.LevelWorkload,
+		"service":     monitoring.LevelService,
+		"pod":         monitoring.LevelPod,
+	}
+
+	for meter := range promQLMeterTemplates {
+		level := levels[strings.Split(meter, "_")[1]]
+		for _, opts := range []monitoring.QueryOptions{
+			{},
+			{StorageClassName: "csi-standard"},
+			{ByStorageClass: true},
+		} {
+			opts.Level = level
+			opts.NamespaceName = "default"
+			opts.ResourceFilter = ".*"
+			if level == monitoring.LevelApplication || level == monitoring.LevelService {
+				// the selector of the workloads or pods of the application or service
+				opts.ResourceFilter = `namespace="default", pod=~"nginx-.+"`
+			}
+			opts.MeterOptions = &monitoring.Meteroptions{Step: time.Hour}
+
+			expr := makeMeterExpr(meter, opts)
+			if _, err := parser.ParseExpr(expr); err != nil {
+				t.Fatalf("invalid expr of %s with %+v: %s\n%s", meter, opts, err, expr)
+			}
+
+			if !strings.HasSuffix(meter, "_pvc_bytes_total") {
+				continue
+			}
+			if opts.StorageClassName != "" && !strings.Contains(expr, `kube_persistentvolumeclaim_info{storageclass="csi-standard"}`) {
+				t.Fatalf("expected %s to select the storage class, got %s", meter, expr)
+			}
+			if opts.ByStorageClass && !strings.Contains(expr, "storageclass)") {
+				t.Fatalf("expected %s to be grouped by storage class, got %s", meter, expr)
+			}
+		}
+	}
+	for meter := range promQLMeterNodeBreakdownTemplates {
+		opts := monitoring.QueryOptions{
+			Level:          monitoring.LevelNamespace,
+			WorkspaceName:  "system-workspace",
+			ResourceFilter: ".*",
+			ByNode:         true,
+			MeterOptions:   &monitoring.Meteroptions{Step: time.Hour},
+		}
+		expr := makeMeterExpr(meter, opts)
+		if _, err := parser.ParseExpr(expr); err != nil {
+			t.Fatalf("invalid expr of %s broken down by node: %s\n%s", meter, err, expr)
+		}
+		if !strings.Contains(expr, "by (namespace, node)") || !strings.Contains(expr, `workspace="system-workspace"`) {
+			t.Fatalf("expected %s of the workspace to be grouped by node, got %s", meter, expr)
+		}
+		if _, ok := promQLMeterTemplates[meter]; !ok {
+			t.Fatalf("%s broken down by node is not a meter", meter)
+		}
+	}
+}
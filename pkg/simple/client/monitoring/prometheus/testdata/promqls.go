@@ -27,6 +27,7 @@ var PromQLs = map[string]string{
 	"namespace_memory_usage":                `namespace:container_memory_usage_bytes:sum{namespace!="", namespace=~"kube-system|default"}`,
 	"namespace_memory_usage_wo_cache":       `namespace:container_memory_usage_bytes_wo_cache:sum{namespace!="", workspace="system-workspace", namespace=~"kube-system|default"}`,
 	"workload_cpu_usage":                    `round(namespace:workload_cpu_usage:sum{namespace="default", workload=~"Deployment:(apiserver|coredns)"}, 0.001)`,
+	"workload_http_request_latency_p99":     `histogram_quantile(0.99, sum by (le, workload) (rate(http_request_duration_seconds_bucket{namespace="default", workload=~"Deployment:(apiserver|coredns)"}[5m])))`,
 	"workload_deployment_replica_available": `label_join(sum (label_join(label_replace(kube_deployment_status_replicas_available{namespace="default", deployment!="", deployment=~"apiserver|coredns"}, "owner_kind", "Deployment", "", ""), "workload", "", "deployment")) by (namespace, owner_kind, workload), "workload", ":", "owner_kind", "workload")`,
 	"pod_cpu_usage":                         `round(sum by (namespace, pod) (irate(container_cpu_usage_seconds_total{job="kubelet", pod!="", image!=""}[5m])) * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{owner_kind="ReplicaSet", owner_name=~"^elasticsearch-[^-]{1,10}$"} * on (namespace, pod) group_left(node) kube_pod_info{pod=~"elasticsearch-0", namespace="default"}, 0.001)`,
 	"pod_memory_usage":                      `sum by (namespace, pod) (container_memory_usage_bytes{job="kubelet", pod!="", image!=""}) * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{} * on (namespace, pod) group_left(node) kube_pod_info{pod="elasticsearch-12345", namespace="default"}`,
@@ -86,6 +86,12 @@ type QueryOptions struct {
 	Job                       string
 	Duration                  *time.Duration
 	MeterOptions              *Meteroptions
+	// PromQL templates of the user defined named metrics, keyed by metric name.
+	MetricTemplates map[string]string
+	// ByStorageClass breaks the PVC meters down by the storage classes of the PVCs.
+	ByStorageClass bool
+	// ByNode breaks the CPU, memory and GPU meters of namespaces down by node.
+	ByNode bool
 }
 
 func NewQueryOptions() *QueryOptions {
@@ -319,6 +325,42 @@ func (_ ComponentOption) Apply(o *QueryOptions) {
 	o.Level = LevelComponent
 }
 
+// MetricTemplatesOption carries the PromQL templates of the user defined named metrics
+// along with the query option of the level they are queried at.
+// The templates use the same label substitution as the built-in metrics of the level.
+type MetricTemplatesOption struct {
+	QueryOption
+	Templates map[string]string
+}
+
+func (mo MetricTemplatesOption) Apply(o *QueryOptions) {
+	mo.QueryOption.Apply(o)
+	o.MetricTemplates = mo.Templates
+}
+
+// StorageClassBreakdownOption breaks the PVC meters queried with the option down by storage class,
+// the metric values of the meters have a storageclass label then.
+type StorageClassBreakdownOption struct {
+	QueryOption
+}
+
+func (so StorageClassBreakdownOption) Apply(o *QueryOptions) {
+	so.QueryOption.Apply(o)
+	o.ByStorageClass = true
+}
+
+// NodeBreakdownOption breaks the CPU, memory and GPU meters of namespaces queried with the option down by node.
+// The metric values of the meters are the requests of the namespaces on the nodes with a node label then,
+// the share of the usage of the namespaces on every node is in proportion to them.
+type NodeBreakdownOption struct {
+	QueryOption
+}
+
+func (no NodeBreakdownOption) Apply(o *QueryOptions) {
+	no.QueryOption.Apply(o)
+	o.ByNode = true
+}
+
 type MeterOption struct {
 	Start time.Time
 	End   time.Time
@@ -77,6 +77,28 @@ type MetricValue struct {
 	Fee          string `json:"fee" description:"resource fee"`
 	ResourceUnit string `json:"resource_unit"`
 	CurrencyUnit string `json:"currency_unit"`
+	// Rate is set if the fee is computed by a rate card rather than the global price info.
+	Rate *Rate `json:"rate,omitempty" description:"the rate applied to compute the fee"`
+}
+
+// Rate describes where the price of a fee comes from, so that the cost breakdown tells which rate applied.
+type Rate struct {
+	RateCard     string       `json:"rate_card" description:"name of the rate card"`
+	NodePool     string       `json:"node_pool,omitempty" description:"node pool of the rate card whose price applied"`
+	StorageClass string       `json:"storage_class,omitempty" description:"storage class of the rate card whose price applied"`
+	Price        float64      `json:"price" description:"price per unit of the usage below the first tier"`
+	Tiers        []TierCharge `json:"tiers,omitempty" description:"usage charged by every discount tier"`
+	// Usage and Parts are set if the usage is split into parts priced differently,
+	// e.g. the PVC usage of a namespace spanning storage classes.
+	Usage float64 `json:"usage,omitempty" description:"usage charged by the rate if it is a part of the usage"`
+	Parts []Rate  `json:"parts,omitempty" description:"rates applied to the parts of the usage priced differently"`
+}
+
+// TierCharge is the usage charged at the price of a discount tier.
+type TierCharge struct {
+	From  float64 `json:"from" description:"threshold of the tier"`
+	Price float64 `json:"price" description:"price per unit of the usage in the tier"`
+	Usage float64 `json:"usage" description:"usage charged at the price of the tier"`
 }
 
 func (mv *MetricValue) TransferToExportedMetricValue() {
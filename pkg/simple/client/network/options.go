@@ -26,11 +26,23 @@ type NSNPOptions struct {
 	AllowedIngressNamespaces []string `json:"allowedIngressNamespaces,omitempty" yaml:"allowedIngressNamespaces,omitempty"`
 }
 
+// FlowOptions describes the metric of the flows observed by a conntrack-style exporter, which counts the
+// connections by the source IP, the destination IP, the destination port and the protocol.
+// The defaults are used for the empty fields.
+type FlowOptions struct {
+	Metric           string `json:"metric,omitempty" yaml:"metric,omitempty"`
+	SourceLabel      string `json:"sourceLabel,omitempty" yaml:"sourceLabel,omitempty"`
+	DestinationLabel string `json:"destinationLabel,omitempty" yaml:"destinationLabel,omitempty"`
+	PortLabel        string `json:"portLabel,omitempty" yaml:"portLabel,omitempty"`
+	ProtocolLabel    string `json:"protocolLabel,omitempty" yaml:"protocolLabel,omitempty"`
+}
+
 type Options struct {
 	EnableNetworkPolicy bool        `json:"enableNetworkPolicy,omitempty" yaml:"enableNetworkPolicy,omitempty"`
 	NSNPOptions         NSNPOptions `json:"nsnpOptions,omitempty" yaml:"nsnpOptions,omitempty"`
 	WeaveScopeHost      string      `json:"weaveScopeHost,omitempty" yaml:"weaveScopeHost,omitempty"`
 	IPPoolType          string      `json:"ippoolType,omitempty" yaml:"ippoolType,omitempty"`
+	FlowOptions         FlowOptions `json:"flowOptions,omitempty" yaml:"flowOptions,omitempty"`
 }
 
 // NewNetworkOptions returns a `zero` instance
@@ -61,6 +73,7 @@ func (s *Options) ApplyTo(options *Options) {
 	options.IPPoolType = s.IPPoolType
 	options.NSNPOptions = s.NSNPOptions
 	options.WeaveScopeHost = s.WeaveScopeHost
+	options.FlowOptions = s.FlowOptions
 }
 
 func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
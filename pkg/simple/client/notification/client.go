@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	AlertsAPIPath = "/api/v2/alerts"
+
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert is an alert sent to the notification manager, which routes it to the receivers
+// the same way as the alerts from the alertmanager. The alert is routed to the receivers of
+// the tenants of the namespace if the label namespace is set.
+type Alert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt,omitempty"`
+}
+
+// data is the payload of the alertmanager webhook accepted by the notification manager.
+type data struct {
+	Status string   `json:"status"`
+	Alerts []*Alert `json:"alerts"`
+}
+
+type Client interface {
+	// SendAlerts sends the alerts to the notification manager.
+	SendAlerts(ctx context.Context, alerts ...*Alert) error
+}
+
+type client struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewClient(options *Options) (Client, error) {
+	if options == nil || !options.IsEnabled() {
+		return nil, fmt.Errorf("notification manager endpoint is not configured")
+	}
+	return &client{
+		endpoint: options.Endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *client) SendAlerts(ctx context.Context, alerts ...*Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	// the alerts are firing as a whole if any of them is firing
+	d := &data{Status: AlertResolved, Alerts: alerts}
+	for _, alert := range alerts {
+		if alert.Status != AlertResolved {
+			d.Status = AlertFiring
+		}
+	}
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+AlertsAPIPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to send alerts to notification manager: %s, %s", resp.Status, msg)
+	}
+	return nil
+}
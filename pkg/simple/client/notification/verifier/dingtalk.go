@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubesphere.io/api/notification/v2beta2"
+)
+
+const (
+	dingTalkTargetChatBot      = "chatbot"
+	dingTalkTargetConversation = "conversation"
+
+	// the error code of the chatbot rejecting the signature or the keywords
+	dingTalkErrCodeSecurity = 310000
+)
+
+type dingTalkResponse struct {
+	ErrCode     int    `json:"errcode"`
+	ErrMsg      string `json:"errmsg"`
+	AccessToken string `json:"access_token,omitempty"`
+}
+
+type dingTalkText struct {
+	Content string `json:"content"`
+}
+
+type dingTalkMessage struct {
+	MsgType string        `json:"msgtype"`
+	Text    *dingTalkText `json:"text"`
+}
+
+type dingTalkChatMessage struct {
+	ChatID string           `json:"chatid"`
+	Msg    *dingTalkMessage `json:"msg"`
+}
+
+func (v *Verifier) verifyDingTalk(ctx context.Context, config *v2beta2.DingTalkConfig, receiver *v2beta2.DingTalkReceiver) []*ReceiverResult {
+	var results []*ReceiverResult
+	if receiver.ChatBot != nil {
+		results = append(results, v.verifyDingTalkChatBot(ctx, receiver.ChatBot))
+	}
+	if receiver.Conversation != nil {
+		results = append(results, v.verifyDingTalkConversation(ctx, config, receiver.Conversation))
+	}
+	if len(results) == 0 {
+		result := &ReceiverResult{Type: ReceiverTypeDingTalk}
+		result.record(StepDelivery, "", fmt.Errorf("either the chatbot or the conversation is required"))
+		results = append(results, result)
+	}
+	return results
+}
+
+func (v *Verifier) verifyDingTalkChatBot(ctx context.Context, chatbot *v2beta2.DingTalkChatBot) *ReceiverResult {
+	result := &ReceiverResult{Type: ReceiverTypeDingTalk, Target: dingTalkTargetChatBot}
+
+	webhook, err := v.resolve(chatbot.Webhook)
+	if err == nil && webhook == "" {
+		err = fmt.Errorf("the webhook of the chatbot is required")
+	}
+	if err != nil {
+		result.record(StepDNS, "", err)
+		return result
+	}
+	client, ok := v.probe(ctx, result, webhook, nil)
+	if !ok {
+		return result
+	}
+
+	secret, err := v.resolve(chatbot.Secret)
+	if err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+	if secret != "" {
+		webhook = signDingTalkURL(webhook, secret, time.Now())
+	}
+	// the message must contain one of the keywords if any is set
+	content := testMessage
+	if len(chatbot.Keywords) > 0 {
+		content = fmt.Sprintf("[%s] %s", strings.Join(chatbot.Keywords, ", "), content)
+	}
+	resp := &dingTalkResponse{}
+	err = call(ctx, client, http.MethodPost, webhook, "", &dingTalkMessage{MsgType: "text", Text: &dingTalkText{Content: content}}, resp)
+	if err != nil {
+		result.record(StepDelivery, "", err)
+		return result
+	}
+
+	switch {
+	case resp.ErrCode == dingTalkErrCodeSecurity:
+		result.record(StepAuth, "", fmt.Errorf("the chatbot rejected the signature or the keywords: %s", resp.ErrMsg))
+		return result
+	case secret != "":
+		result.record(StepAuth, "the chatbot accepted the signature", nil)
+	default:
+		result.skip(StepAuth, "no secret is configured")
+	}
+	if resp.ErrCode != 0 {
+		result.record(StepDelivery, "", fmt.Errorf("the chatbot responded %d: %s", resp.ErrCode, resp.ErrMsg))
+		return result
+	}
+	result.record(StepDelivery, "delivered to the chatbot", nil)
+	return result
+}
+
+func (v *Verifier) verifyDingTalkConversation(ctx context.Context, config *v2beta2.DingTalkConfig,
+	conversation *v2beta2.DingTalkConversation) *ReceiverResult {
+	result := &ReceiverResult{Type: ReceiverTypeDingTalk, Target: dingTalkTargetConversation}
+
+	client, ok := v.probe(ctx, result, v.DingTalkURL, nil)
+	if !ok {
+		return result
+	}
+
+	if config == nil || config.Conversation == nil {
+		result.record(StepAuth, "", fmt.Errorf("the app key and secret in the dingtalk config are required"))
+		return result
+	}
+	appKey, err := v.resolve(config.Conversation.AppKey)
+	if err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+	appSecret, err := v.resolve(config.Conversation.AppSecret)
+	if err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+	query := url.Values{"appkey": {appKey}, "appsecret": {appSecret}}
+	resp := &dingTalkResponse{}
+	if err := call(ctx, client, http.MethodGet, v.DingTalkURL+"/gettoken?"+query.Encode(), "", nil, resp); err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+	if resp.ErrCode != 0 {
+		result.record(StepAuth, "", fmt.Errorf("dingtalk rejected the app key and secret: %s", resp.ErrMsg))
+		return result
+	}
+	result.record(StepAuth, "got the access token of the app", nil)
+
+	if len(conversation.ChatIDs) == 0 {
+		result.record(StepDelivery, "", fmt.Errorf("no chat to deliver to"))
+		return result
+	}
+	sendURL := v.DingTalkURL + "/chat/send?" + url.Values{"access_token": {resp.AccessToken}}.Encode()
+	var failures []string
+	for _, chatID := range conversation.ChatIDs {
+		resp := &dingTalkResponse{}
+		err := call(ctx, client, http.MethodPost, sendURL, "", &dingTalkChatMessage{
+			ChatID: chatID,
+			Msg:    &dingTalkMessage{MsgType: "text", Text: &dingTalkText{Content: testMessage}},
+		}, resp)
+		if err == nil && resp.ErrCode != 0 {
+			err = fmt.Errorf("%d: %s", resp.ErrCode, resp.ErrMsg)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", chatID, err))
+		}
+	}
+	if len(failures) > 0 {
+		result.record(StepDelivery, "", fmt.Errorf("failed to deliver to %s", strings.Join(failures, "; ")))
+		return result
+	}
+	result.record(StepDelivery, fmt.Sprintf("delivered to %s", strings.Join(conversation.ChatIDs, ", ")), nil)
+	return result
+}
+
+// signDingTalkURL appends the timestamp and the signature required by the chatbot with a secret.
+func signDingTalkURL(webhook, secret string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	separator := "?"
+	if strings.Contains(webhook, "?") {
+		separator = "&"
+	}
+	return webhook + separator + url.Values{"timestamp": {timestamp}, "sign": {sign}}.Encode()
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubesphere.io/api/notification/v2beta2"
+)
+
+// the port of SMTP over implicit TLS, other ports start TLS on demand
+const smtpsPort = 465
+
+func (v *Verifier) verifyEmail(ctx context.Context, config *v2beta2.EmailConfig, receiver *v2beta2.EmailReceiver) *ReceiverResult {
+	result := &ReceiverResult{Type: ReceiverTypeEmail}
+
+	host := config.SmartHost.Host
+	if host == "" || config.SmartHost.Port <= 0 {
+		result.record(StepDNS, "", fmt.Errorf("invalid smart host %s:%d", host, config.SmartHost.Port))
+		return result
+	}
+	message, err := v.lookup(ctx, host)
+	if !result.record(StepDNS, message, err) {
+		return result
+	}
+
+	c, ok := v.connectSMTP(ctx, result, config)
+	if !ok {
+		return result
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	if !v.authenticateSMTP(result, c, config) {
+		return result
+	}
+
+	if len(receiver.To) == 0 {
+		result.record(StepDelivery, "", fmt.Errorf("no recipient to deliver to"))
+		return result
+	}
+	if err := c.Mail(config.From); err != nil {
+		result.record(StepDelivery, "", fmt.Errorf("the sender %s is rejected: %v", config.From, err))
+		return result
+	}
+	var accepted, failures []string
+	for _, to := range receiver.To {
+		if err := c.Rcpt(to); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", to, err))
+			continue
+		}
+		accepted = append(accepted, to)
+	}
+	if len(accepted) == 0 {
+		result.record(StepDelivery, "", fmt.Errorf("all the recipients are rejected: %s", strings.Join(failures, "; ")))
+		return result
+	}
+	if err := sendMail(c, config.From, accepted); err != nil {
+		result.record(StepDelivery, "", err)
+		return result
+	}
+	_ = c.Quit()
+	if len(failures) > 0 {
+		result.record(StepDelivery, "", fmt.Errorf("delivered to %s, but failed to deliver to %s",
+			strings.Join(accepted, ", "), strings.Join(failures, "; ")))
+		return result
+	}
+	result.record(StepDelivery, fmt.Sprintf("delivered to %s", strings.Join(accepted, ", ")), nil)
+	return result
+}
+
+// connectSMTP connects to the smart host and runs the tls step.
+func (v *Verifier) connectSMTP(ctx context.Context, result *ReceiverResult, config *v2beta2.EmailConfig) (*smtp.Client, bool) {
+	host := config.SmartHost.Host
+	addr := net.JoinHostPort(host, strconv.Itoa(config.SmartHost.Port))
+	tlsConfig, err := v.tlsConfig(config.TLS, host)
+	if err != nil {
+		return nil, result.record(StepTLS, "", err)
+	}
+
+	dialer := v.dialer()
+	var conn net.Conn
+	if config.SmartHost.Port == smtpsPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, result.record(StepTLS, "", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(v.timeout()))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, result.record(StepTLS, "", err)
+	}
+	hello := "localhost"
+	if config.Hello != nil && *config.Hello != "" {
+		hello = *config.Hello
+	}
+	if err := c.Hello(hello); err != nil {
+		_ = c.Close()
+		return nil, result.record(StepTLS, "", err)
+	}
+
+	if state, ok := c.TLSConnectionState(); ok {
+		return c, result.record(StepTLS, fmt.Sprintf("connected over %s", tlsVersion(state.Version)), nil)
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			_ = c.Close()
+			return nil, result.record(StepTLS, "", fmt.Errorf("failed to start TLS: %v", err))
+		}
+		state, _ := c.TLSConnectionState()
+		return c, result.record(StepTLS, fmt.Sprintf("started %s", tlsVersion(state.Version)), nil)
+	}
+	// TLS is required by default, the same as the notification manager
+	if config.RequireTLS == nil || *config.RequireTLS {
+		_ = c.Close()
+		return nil, result.record(StepTLS, "", fmt.Errorf("TLS is required but the server does not support STARTTLS"))
+	}
+	result.skip(StepTLS, "the server does not support STARTTLS and TLS is not required")
+	return c, true
+}
+
+// authenticateSMTP runs the auth step with the mechanism the server supports.
+func (v *Verifier) authenticateSMTP(result *ReceiverResult, c *smtp.Client, config *v2beta2.EmailConfig) bool {
+	if config.AuthUsername == nil || *config.AuthUsername == "" {
+		result.skip(StepAuth, "no authentication is configured")
+		return true
+	}
+	ok, mechanisms := c.Extension("AUTH")
+	if !ok {
+		return result.record(StepAuth, "", fmt.Errorf("the server does not support authentication"))
+	}
+
+	username := *config.AuthUsername
+	var auth smtp.Auth
+	var mechanism string
+	switch {
+	case config.AuthSecret != nil && strings.Contains(mechanisms, "CRAM-MD5"):
+		secret, err := v.resolve(config.AuthSecret)
+		if err != nil {
+			return result.record(StepAuth, "", err)
+		}
+		auth, mechanism = smtp.CRAMMD5Auth(username, secret), "CRAM-MD5"
+	case config.AuthPassword != nil && strings.Contains(mechanisms, "PLAIN"):
+		password, err := v.resolve(config.AuthPassword)
+		if err != nil {
+			return result.record(StepAuth, "", err)
+		}
+		identity := ""
+		if config.AuthIdentify != nil {
+			identity = *config.AuthIdentify
+		}
+		auth, mechanism = smtp.PlainAuth(identity, username, password, config.SmartHost.Host), "PLAIN"
+	case config.AuthPassword != nil && strings.Contains(mechanisms, "LOGIN"):
+		password, err := v.resolve(config.AuthPassword)
+		if err != nil {
+			return result.record(StepAuth, "", err)
+		}
+		auth, mechanism = &loginAuth{username: username, password: password}, "LOGIN"
+	default:
+		return result.record(StepAuth, "", fmt.Errorf("no mechanism supported by the server (%s) matches the credentials", mechanisms))
+	}
+	if err := c.Auth(auth); err != nil {
+		return result.record(StepAuth, "", fmt.Errorf("%s authentication failed: %v", mechanism, err))
+	}
+	return result.record(StepAuth, fmt.Sprintf("authenticated with %s", mechanism), nil)
+}
+
+func sendMail(c *smtp.Client, from string, to []string) error {
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n",
+		from, strings.Join(to, ", "), testTitle, time.Now().Format(time.RFC1123Z))
+	if _, err := w.Write([]byte(header + testMessage + "\r\n")); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// loginAuth implements the LOGIN mechanism, which is not provided by net/smtp but supported by
+// many servers, e.g. Office 365.
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(string(fromServer)) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected challenge: %s", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"kubesphere.io/api/notification/v2beta2"
+)
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	Team  string `json:"team,omitempty"`
+}
+
+type slackMessage struct {
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+}
+
+func (v *Verifier) verifySlack(ctx context.Context, config *v2beta2.SlackConfig, receiver *v2beta2.SlackReceiver) *ReceiverResult {
+	result := &ReceiverResult{Type: ReceiverTypeSlack}
+
+	client, ok := v.probe(ctx, result, v.SlackURL, nil)
+	if !ok {
+		return result
+	}
+
+	token, err := v.resolve(config.SlackTokenSecret)
+	if err == nil && token == "" {
+		err = fmt.Errorf("the slack token is required")
+	}
+	if err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+	resp := &slackResponse{}
+	if err := call(ctx, client, http.MethodPost, v.SlackURL+"/auth.test", token, nil, resp); err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+	if !resp.OK {
+		result.record(StepAuth, "", fmt.Errorf("slack rejected the token: %s", resp.Error))
+		return result
+	}
+	result.record(StepAuth, fmt.Sprintf("authenticated to the team %s", resp.Team), nil)
+
+	if len(receiver.Channels) == 0 {
+		result.record(StepDelivery, "", fmt.Errorf("no channel to deliver to"))
+		return result
+	}
+	var failures []string
+	for _, channel := range receiver.Channels {
+		resp := &slackResponse{}
+		err := call(ctx, client, http.MethodPost, v.SlackURL+"/chat.postMessage", token,
+			&slackMessage{Channel: channel, Text: testMessage}, resp)
+		if err == nil && !resp.OK {
+			err = fmt.Errorf("%s", resp.Error)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", channel, err))
+		}
+	}
+	if len(failures) > 0 {
+		result.record(StepDelivery, "", fmt.Errorf("failed to deliver to %s", strings.Join(failures, "; ")))
+		return result
+	}
+	result.record(StepDelivery, fmt.Sprintf("delivered to %s", strings.Join(receiver.Channels, ", ")), nil)
+	return result
+}
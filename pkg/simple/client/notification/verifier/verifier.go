@@ -0,0 +1,355 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package verifier sends test notifications to the receivers in process, so that the receivers can be
+// verified without a running notification manager. Each receiver is verified step by step, i.e. resolving
+// the host, setting up the TLS connection, authenticating and delivering, and the steps after a failed one
+// are not run.
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"kubesphere.io/api/notification/v2beta2"
+)
+
+type Step string
+
+const (
+	StepDNS      Step = "dns"
+	StepTLS      Step = "tls"
+	StepAuth     Step = "auth"
+	StepDelivery Step = "delivery"
+
+	ReceiverTypeDingTalk = "dingtalk"
+	ReceiverTypeEmail    = "email"
+	ReceiverTypeSlack    = "slack"
+	ReceiverTypeWebhook  = "webhook"
+
+	DefaultSlackURL    = "https://slack.com/api"
+	DefaultDingTalkURL = "https://oapi.dingtalk.com"
+
+	defaultTimeout = 10 * time.Second
+
+	testTitle   = "KubeSphere notification test"
+	testMessage = "This is a test notification sent by KubeSphere to verify the receiver."
+)
+
+type StepResult struct {
+	Step    Step   `json:"step" description:"step of the verification, one of dns, tls, auth and delivery"`
+	Success bool   `json:"success" description:"whether the step succeeded"`
+	Skipped bool   `json:"skipped,omitempty" description:"whether the step is not needed by the receiver"`
+	Message string `json:"message,omitempty" description:"details of the step, or the error if the step failed"`
+}
+
+type ReceiverResult struct {
+	Type    string       `json:"type" description:"receiver type, one of dingtalk, email, slack and webhook"`
+	Target  string       `json:"target,omitempty" description:"target of the receiver type having several, e.g. the chatbot or conversation of dingtalk"`
+	Success bool         `json:"success" description:"whether the test notification is delivered"`
+	Steps   []StepResult `json:"steps" description:"results of the steps run"`
+}
+
+// CredentialResolver resolves the value of the credential, which may be read from a secret.
+type CredentialResolver func(credential *v2beta2.Credential) (string, error)
+
+type Verifier struct {
+	Resolver CredentialResolver
+	// SlackURL and DingTalkURL are the api endpoints, which are replaced by the stand-ins in tests.
+	SlackURL    string
+	DingTalkURL string
+	Timeout     time.Duration
+
+	resolver *net.Resolver
+	// allowAddress reports whether the address can be connected to, which is replaced in tests
+	// to reach the stand-ins on the loopback address. Nil means allowedAddress.
+	allowAddress func(ip net.IP) bool
+}
+
+func NewVerifier(resolver CredentialResolver) *Verifier {
+	return &Verifier{
+		Resolver:    resolver,
+		SlackURL:    DefaultSlackURL,
+		DingTalkURL: DefaultDingTalkURL,
+		Timeout:     defaultTimeout,
+		resolver:    net.DefaultResolver,
+	}
+}
+
+// Verify sends a test notification to each of the receiver types supported by the receiver,
+// using the config for the settings shared by the receivers, e.g. the SMTP server.
+func (v *Verifier) Verify(ctx context.Context, config *v2beta2.Config, receiver *v2beta2.Receiver) ([]*ReceiverResult, error) {
+	var spec v2beta2.ConfigSpec
+	if config != nil {
+		spec = config.Spec
+	}
+
+	var results []*ReceiverResult
+	if r := receiver.Spec.DingTalk; r != nil {
+		results = append(results, v.verifyDingTalk(ctx, spec.DingTalk, r)...)
+	}
+	if r := receiver.Spec.Email; r != nil {
+		if spec.Email == nil {
+			return nil, fmt.Errorf("the email config is required to verify the email receiver")
+		}
+		results = append(results, v.verifyEmail(ctx, spec.Email, r))
+	}
+	if r := receiver.Spec.Slack; r != nil {
+		if spec.Slack == nil {
+			return nil, fmt.Errorf("the slack config is required to verify the slack receiver")
+		}
+		results = append(results, v.verifySlack(ctx, spec.Slack, r))
+	}
+	if r := receiver.Spec.Webhook; r != nil {
+		results = append(results, v.verifyWebhook(ctx, r))
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no receiver of the types supported: dingtalk, email, slack and webhook")
+	}
+	return results, nil
+}
+
+// record appends the result of the step, and returns whether the step succeeded.
+func (r *ReceiverResult) record(step Step, message string, err error) bool {
+	result := StepResult{Step: step, Success: err == nil, Message: message}
+	if err != nil {
+		result.Message = err.Error()
+	}
+	r.Steps = append(r.Steps, result)
+	// the receiver succeeds once the notification is delivered
+	r.Success = err == nil && step == StepDelivery
+	return err == nil
+}
+
+func (r *ReceiverResult) skip(step Step, message string) {
+	r.Steps = append(r.Steps, StepResult{Step: step, Success: true, Skipped: true, Message: message})
+}
+
+func (v *Verifier) resolve(credential *v2beta2.Credential) (string, error) {
+	if credential == nil {
+		return "", nil
+	}
+	if credential.ValueFrom == nil {
+		return credential.Value, nil
+	}
+	if v.Resolver == nil {
+		return "", fmt.Errorf("credentials from secrets are not supported")
+	}
+	return v.Resolver(credential)
+}
+
+// cgnatNet is the shared address space of RFC 6598, which is often used by the pod networks.
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// allowedAddress reports whether the address is a public one. The loopback, link-local and private
+// addresses cover the apiserver itself, the cloud metadata services and the pod and service networks
+// of the cluster, which must not be reached on behalf of the users. The receivers in the cluster can
+// only be verified by the notification manager.
+func allowedAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() ||
+		ip.IsPrivate() || cgnatNet.Contains(ip))
+}
+
+func (v *Verifier) allowed(ip net.IP) bool {
+	if v.allowAddress != nil {
+		return v.allowAddress(ip)
+	}
+	return allowedAddress(ip)
+}
+
+func (v *Verifier) lookup(ctx context.Context, host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if !v.allowed(ip) {
+			return "", fmt.Errorf("%s is an internal address, which is not allowed", host)
+		}
+		return fmt.Sprintf("%s is an IP address", host), nil
+	}
+	resolver := v.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && !v.allowed(ip) {
+			return "", fmt.Errorf("%s resolved to the internal address %s, which is not allowed", host, addr)
+		}
+	}
+	return fmt.Sprintf("%s resolved to %s", host, strings.Join(addrs, ", ")), nil
+}
+
+// dialer checks the address again when connecting, as the host may resolve differently from the
+// dns step, and the http client may follow redirects to other hosts.
+func (v *Verifier) dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: v.timeout(),
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !v.allowed(ip) {
+				return fmt.Errorf("connecting to the internal address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+}
+
+func (v *Verifier) tlsConfig(config *v2beta2.TLSConfig, serverName string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: serverName}
+	if config == nil {
+		return tlsConfig, nil
+	}
+	if config.ServerName != "" {
+		tlsConfig.ServerName = config.ServerName
+	}
+	tlsConfig.InsecureSkipVerify = config.InsecureSkipVerify
+	if config.RootCA != nil {
+		ca, err := v.resolve(config.RootCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, fmt.Errorf("invalid root CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.ClientCertificate != nil {
+		cert, err := v.resolve(config.Cert)
+		if err != nil {
+			return nil, err
+		}
+		key, err := v.resolve(config.Key)
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	return tlsConfig, nil
+}
+
+// probe runs the dns and tls steps against the host of the url, and returns the http client
+// to deliver the notification with if the steps succeed.
+func (v *Verifier) probe(ctx context.Context, result *ReceiverResult, rawURL string, config *v2beta2.TLSConfig) (*http.Client, bool) {
+	u, err := url.Parse(rawURL)
+	if err == nil && (u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "") {
+		err = fmt.Errorf("invalid url: %s", rawURL)
+	}
+	if err != nil {
+		return nil, result.record(StepDNS, "", err)
+	}
+	message, err := v.lookup(ctx, u.Hostname())
+	if !result.record(StepDNS, message, err) {
+		return nil, false
+	}
+
+	tlsConfig, err := v.tlsConfig(config, u.Hostname())
+	if err != nil {
+		return nil, result.record(StepTLS, "", err)
+	}
+	client := &http.Client{
+		Timeout:   v.timeout(),
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, DialContext: v.dialer().DialContext},
+	}
+	if u.Scheme != "https" {
+		result.skip(StepTLS, "plain HTTP is used")
+		return client, true
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	dialer := &tls.Dialer{NetDialer: v.dialer(), Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, result.record(StepTLS, "", err)
+	}
+	state := conn.(*tls.Conn).ConnectionState()
+	_ = conn.Close()
+	return client, result.record(StepTLS, fmt.Sprintf("TLS handshake succeeded with %s", tlsVersion(state.Version)), nil)
+}
+
+// call sends the request in json to the api, and decodes the response in json into out.
+func call(ctx context.Context, client *http.Client, method, rawURL, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the api responded %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (v *Verifier) timeout() time.Duration {
+	if v.Timeout > 0 {
+		return v.Timeout
+	}
+	return defaultTimeout
+}
+
+func tlsVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("TLS version %x", version)
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"kubesphere.io/api/notification/v2beta2"
+)
+
+func steps(result *ReceiverResult) string {
+	var s []string
+	for _, step := range result.Steps {
+		switch {
+		case step.Skipped:
+			s = append(s, string(step.Step)+":skipped")
+		case step.Success:
+			s = append(s, string(step.Step)+":ok")
+		default:
+			s = append(s, string(step.Step)+":failed")
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+// newTestVerifier allows the loopback address of the stand-ins.
+func newTestVerifier(resolver CredentialResolver) *Verifier {
+	v := NewVerifier(resolver)
+	v.allowAddress = func(net.IP) bool { return true }
+	return v
+}
+
+func verify(t *testing.T, v *Verifier, config *v2beta2.Config, receiver *v2beta2.Receiver) []*ReceiverResult {
+	results, err := v.Verify(context.Background(), config, receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); ok && (user != "admin" || password != "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data := &webhookData{}
+		if err := json.NewDecoder(r.Body).Decode(data); err != nil || len(data.Alerts) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	secrets := map[string]string{"password": "secret"}
+	v := newTestVerifier(func(credential *v2beta2.Credential) (string, error) {
+		return secrets[credential.ValueFrom.SecretKeyRef.Key], nil
+	})
+	receiver := &v2beta2.Receiver{Spec: v2beta2.ReceiverSpec{Webhook: &v2beta2.WebhookReceiver{URL: &srv.URL}}}
+	if s := steps(verify(t, v, nil, receiver)[0]); s != "dns:ok,tls:skipped,auth:skipped,delivery:ok" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+
+	receiver.Spec.Webhook.HTTPConfig = &v2beta2.HTTPClientConfig{BasicAuth: &v2beta2.BasicAuth{
+		Username: "admin",
+		Password: &v2beta2.Credential{ValueFrom: &v2beta2.ValueSource{SecretKeyRef: &v2beta2.SecretKeySelector{Key: "password"}}},
+	}}
+	result := verify(t, v, nil, receiver)[0]
+	if s := steps(result); !result.Success || s != "dns:ok,tls:skipped,auth:ok,delivery:ok" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+
+	secrets["password"] = "wrong"
+	result = verify(t, v, nil, receiver)[0]
+	if s := steps(result); result.Success || s != "dns:ok,tls:skipped,auth:failed" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+
+	// https is verified with the tls config
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsSrv.Close()
+	receiver.Spec.Webhook = &v2beta2.WebhookReceiver{URL: &tlsSrv.URL}
+	if s := steps(verify(t, v, nil, receiver)[0]); s != "dns:ok,tls:failed" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+	receiver.Spec.Webhook.HTTPConfig = &v2beta2.HTTPClientConfig{TLSConfig: &v2beta2.TLSConfig{InsecureSkipVerify: true}}
+	if s := steps(verify(t, v, nil, receiver)[0]); s != "dns:ok,tls:ok,auth:skipped,delivery:ok" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+}
+
+func TestVerifyInternalAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the internal address is reached: %s", r.URL)
+	}))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, "http://10.96.0.1/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]:8080/"} {
+		receiver := &v2beta2.Receiver{Spec: v2beta2.ReceiverSpec{Webhook: &v2beta2.WebhookReceiver{URL: &url}}}
+		result := verify(t, NewVerifier(nil), nil, receiver)[0]
+		if s := steps(result); result.Success || s != "dns:failed" {
+			t.Fatalf("unexpected steps %s for %s: %v", s, url, result.Steps)
+		}
+	}
+
+	// the address is checked again when connecting, e.g. after a redirect
+	v := NewVerifier(nil)
+	if _, err := v.dialer().DialContext(context.Background(), "tcp", srv.Listener.Addr().String()); err == nil ||
+		!strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, ip := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		if !allowedAddress(net.ParseIP(ip)) {
+			t.Fatalf("%s is not allowed", ip)
+		}
+	}
+}
+
+func TestVerifySlack(t *testing.T) {
+	var channels []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/auth.test":
+			_, _ = w.Write([]byte(`{"ok":true,"team":"ops"}`))
+		case "/chat.postMessage":
+			message := &slackMessage{}
+			_ = json.NewDecoder(r.Body).Decode(message)
+			if message.Channel == "unknown" {
+				_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+				return
+			}
+			channels = append(channels, message.Channel)
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := newTestVerifier(nil)
+	v.SlackURL = srv.URL
+	config := &v2beta2.Config{Spec: v2beta2.ConfigSpec{Slack: &v2beta2.SlackConfig{
+		SlackTokenSecret: &v2beta2.Credential{Value: "xoxb-token"},
+	}}}
+	receiver := &v2beta2.Receiver{Spec: v2beta2.ReceiverSpec{Slack: &v2beta2.SlackReceiver{Channels: []string{"alerts"}}}}
+	result := verify(t, v, config, receiver)[0]
+	if s := steps(result); !result.Success || s != "dns:ok,tls:skipped,auth:ok,delivery:ok" || len(channels) != 1 {
+		t.Fatalf("unexpected steps %s", s)
+	}
+
+	receiver.Spec.Slack.Channels = []string{"alerts", "unknown"}
+	result = verify(t, v, config, receiver)[0]
+	if s := steps(result); result.Success || s != "dns:ok,tls:skipped,auth:ok,delivery:failed" ||
+		!strings.Contains(result.Steps[3].Message, "channel_not_found") {
+		t.Fatalf("unexpected steps %s: %v", s, result.Steps)
+	}
+
+	config.Spec.Slack.SlackTokenSecret.Value = "wrong"
+	if s := steps(verify(t, v, config, receiver)[0]); s != "dns:ok,tls:skipped,auth:failed" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+
+	if _, err := v.Verify(context.Background(), nil, receiver); err == nil {
+		t.Fatal("expected an error without the slack config")
+	}
+}
+
+func TestVerifyDingTalk(t *testing.T) {
+	var messages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robot/send":
+			if r.URL.Query().Get("sign") == "" {
+				_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+				return
+			}
+			message := &dingTalkMessage{}
+			_ = json.NewDecoder(r.Body).Decode(message)
+			messages = append(messages, message.Text.Content)
+			_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		case "/gettoken":
+			if r.URL.Query().Get("appsecret") != "app-secret" {
+				_, _ = w.Write([]byte(`{"errcode":40089,"errmsg":"invalid appkey or appsecret"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"token"}`))
+		case "/chat/send":
+			if r.URL.Query().Get("access_token") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := newTestVerifier(nil)
+	v.DingTalkURL = srv.URL
+	config := &v2beta2.Config{Spec: v2beta2.ConfigSpec{DingTalk: &v2beta2.DingTalkConfig{
+		Conversation: &v2beta2.DingTalkApplicationConfig{
+			AppKey:    &v2beta2.Credential{Value: "app-key"},
+			AppSecret: &v2beta2.Credential{Value: "app-secret"},
+		},
+	}}}
+	receiver := &v2beta2.Receiver{Spec: v2beta2.ReceiverSpec{DingTalk: &v2beta2.DingTalkReceiver{
+		ChatBot: &v2beta2.DingTalkChatBot{
+			Webhook:  &v2beta2.Credential{Value: srv.URL + "/robot/send?access_token=robot"},
+			Keywords: []string{"alert"},
+			Secret:   &v2beta2.Credential{Value: "SEC"},
+		},
+		Conversation: &v2beta2.DingTalkConversation{ChatIDs: []string{"chat1"}},
+	}}}
+	results := verify(t, v, config, receiver)
+	if len(results) != 2 || results[0].Target != dingTalkTargetChatBot || results[1].Target != dingTalkTargetConversation {
+		t.Fatalf("unexpected results %v", results)
+	}
+	for _, result := range results {
+		if s := steps(result); !result.Success || s != "dns:ok,tls:skipped,auth:ok,delivery:ok" {
+			t.Fatalf("unexpected steps of %s: %s", result.Target, s)
+		}
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "alert") {
+		t.Fatalf("unexpected messages %v", messages)
+	}
+
+	receiver.Spec.DingTalk.ChatBot.Secret = nil
+	config.Spec.DingTalk.Conversation.AppSecret.Value = "wrong"
+	results = verify(t, v, config, receiver)
+	for _, result := range results {
+		if s := steps(result); result.Success || !strings.HasSuffix(s, "auth:failed") {
+			t.Fatalf("unexpected steps of %s: %s", result.Target, s)
+		}
+	}
+}
+
+// serveSMTP runs a minimal SMTP server accepting the PLAIN authentication of admin/secret,
+// and rejecting the recipients of the domain invalid.
+func serveSMTP(t *testing.T, delivered chan<- string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				reply := func(s string) { _, _ = fmt.Fprintf(conn, "%s\r\n", s) }
+				reply("220 localhost ESMTP")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimRight(line, "\r\n")
+					switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+					case "EHLO":
+						reply("250-localhost")
+						reply("250 AUTH PLAIN")
+					case "AUTH":
+						credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+						if string(credentials) != "\x00admin\x00secret" {
+							reply("535 authentication failed")
+							continue
+						}
+						reply("235 authenticated")
+					case "MAIL":
+						reply("250 OK")
+					case "RCPT":
+						if strings.Contains(line, "@invalid") {
+							reply("550 no such user")
+							continue
+						}
+						reply("250 OK")
+					case "DATA":
+						reply("354 go ahead")
+						var data strings.Builder
+						for {
+							line, err := r.ReadString('\n')
+							if err != nil || line == ".\r\n" {
+								break
+							}
+							data.WriteString(line)
+						}
+						delivered <- data.String()
+						reply("250 OK")
+					case "QUIT":
+						reply("221 bye")
+						return
+					default:
+						reply("502 unsupported")
+					}
+				}
+			}(conn)
+		}
+	}()
+	return l
+}
+
+func TestVerifyEmail(t *testing.T) {
+	delivered := make(chan string, 10)
+	l := serveSMTP(t, delivered)
+	defer l.Close()
+
+	port, _ := strconv.Atoi(strings.Split(l.Addr().String(), ":")[1])
+	username, requireTLS := "admin", false
+	config := &v2beta2.Config{Spec: v2beta2.ConfigSpec{Email: &v2beta2.EmailConfig{
+		From:         "ks@example.com",
+		SmartHost:    v2beta2.HostPort{Host: "127.0.0.1", Port: port},
+		AuthUsername: &username,
+		AuthPassword: &v2beta2.Credential{Value: "secret"},
+		RequireTLS:   &requireTLS,
+	}}}
+	receiver := &v2beta2.Receiver{Spec: v2beta2.ReceiverSpec{Email: &v2beta2.EmailReceiver{
+		To: []string{"ops@example.com"},
+	}}}
+	result := verify(t, newTestVerifier(nil), config, receiver)[0]
+	if s := steps(result); !result.Success || s != "dns:ok,tls:skipped,auth:ok,delivery:ok" {
+		t.Fatalf("unexpected steps %s: %v", s, result.Steps)
+	}
+	if data := <-delivered; !strings.Contains(data, "To: ops@example.com") || !strings.Contains(data, testMessage) {
+		t.Fatalf("unexpected mail %s", data)
+	}
+
+	// partially delivered
+	receiver.Spec.Email.To = []string{"ops@example.com", "nobody@invalid"}
+	result = verify(t, newTestVerifier(nil), config, receiver)[0]
+	if s := steps(result); result.Success || s != "dns:ok,tls:skipped,auth:ok,delivery:failed" ||
+		!strings.Contains(result.Steps[3].Message, "nobody@invalid") {
+		t.Fatalf("unexpected steps %s: %v", s, result.Steps)
+	}
+	<-delivered
+
+	config.Spec.Email.AuthPassword.Value = "wrong"
+	if s := steps(verify(t, newTestVerifier(nil), config, receiver)[0]); s != "dns:ok,tls:skipped,auth:failed" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+
+	// TLS is required by default
+	config.Spec.Email.RequireTLS = nil
+	if s := steps(verify(t, newTestVerifier(nil), config, receiver)[0]); s != "dns:ok,tls:failed" {
+		t.Fatalf("unexpected steps %s", s)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"kubesphere.io/api/notification/v2beta2"
+)
+
+// webhookData is the payload of the test notification, in the format of the alertmanager webhook.
+type webhookData struct {
+	Status string          `json:"status"`
+	Alerts []*webhookAlert `json:"alerts"`
+}
+
+type webhookAlert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+}
+
+func (v *Verifier) verifyWebhook(ctx context.Context, receiver *v2beta2.WebhookReceiver) *ReceiverResult {
+	result := &ReceiverResult{Type: ReceiverTypeWebhook}
+
+	var rawURL string
+	switch {
+	case receiver.URL != nil:
+		rawURL = *receiver.URL
+	case receiver.Service != nil:
+		rawURL = serviceURL(receiver.Service)
+	default:
+		result.record(StepDNS, "", fmt.Errorf("either the url or the service of the webhook is required"))
+		return result
+	}
+
+	var tlsConfig *v2beta2.TLSConfig
+	if receiver.HTTPConfig != nil {
+		tlsConfig = receiver.HTTPConfig.TLSConfig
+	}
+	client, ok := v.probe(ctx, result, rawURL, tlsConfig)
+	if !ok {
+		return result
+	}
+
+	body, _ := json.Marshal(&webhookData{
+		Status: "firing",
+		Alerts: []*webhookAlert{{
+			Status:      "firing",
+			Labels:      map[string]string{"alertname": "NotificationTest"},
+			Annotations: map[string]string{"summary": testTitle, "message": testMessage},
+			StartsAt:    time.Now(),
+		}},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		result.record(StepDelivery, "", err)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authenticated, err := v.authenticate(req, receiver.HTTPConfig)
+	if err != nil {
+		result.record(StepAuth, "", err)
+		return result
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.record(StepDelivery, "", err)
+		return result
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if !authenticated {
+		result.skip(StepAuth, "no authentication is configured")
+	} else if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		result.record(StepAuth, "", fmt.Errorf("the webhook rejected the credentials: %s", resp.Status))
+		return result
+	} else {
+		result.record(StepAuth, "the webhook accepted the credentials", nil)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		result.record(StepDelivery, "", fmt.Errorf("the webhook responded %s: %s", resp.Status, message))
+		return result
+	}
+	result.record(StepDelivery, fmt.Sprintf("the webhook responded %s", resp.Status), nil)
+	return result
+}
+
+// authenticate sets the credentials of the http config to the request, and returns whether any is set.
+func (v *Verifier) authenticate(req *http.Request, config *v2beta2.HTTPClientConfig) (bool, error) {
+	if config == nil {
+		return false, nil
+	}
+	if config.BasicAuth != nil {
+		password, err := v.resolve(config.BasicAuth.Password)
+		if err != nil {
+			return false, err
+		}
+		req.SetBasicAuth(config.BasicAuth.Username, password)
+		return true, nil
+	}
+	if config.BearerToken != nil {
+		token, err := v.resolve(config.BearerToken)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return true, nil
+	}
+	return false, nil
+}
+
+func serviceURL(service *v2beta2.ServiceReference) string {
+	scheme, port, path := "http", int32(443), ""
+	if service.Scheme != nil {
+		scheme = *service.Scheme
+	}
+	if service.Port != nil {
+		port = *service.Port
+	}
+	if service.Path != nil {
+		path = *service.Path
+	}
+	return fmt.Sprintf("%s://%s.%s:%d%s", scheme, service.Name, service.Namespace, port, path)
+}
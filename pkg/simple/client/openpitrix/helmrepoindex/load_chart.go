@@ -19,6 +19,7 @@ package helmrepoindex
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -56,7 +57,12 @@ func loadData(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential)
 		return nil, err
 	}
 	var resp *bytes.Buffer
-	if strings.HasPrefix(u, "s3://") {
+	if IsOCIRepo(u) {
+		resp, err = loadOCIChart(ctx, u, cred)
+		if err != nil {
+			return nil, err
+		}
+	} else if strings.HasPrefix(u, "s3://") {
 		region, endpoint, bucket, p := parseS3Url(parsedURL)
 		client, err := s3.NewS3Client(&s3.Options{
 			Endpoint:        endpoint,
@@ -105,3 +111,23 @@ func loadData(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential)
 func LoadChart(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential) (*bytes.Buffer, error) {
 	return loadData(ctx, u, cred)
 }
+
+// LoadChartSignature loads the signature of the chart, which is the provenance file next to the chart package,
+// or the cosign signature with the suffix ".sig". The provenance files are pulled from the chart manifests
+// of the OCI registries.
+func LoadChartSignature(ctx context.Context, u, provider string, cred *v1alpha1.HelmRepoCredential) (*bytes.Buffer, error) {
+	switch provider {
+	case v1alpha1.ChartVerificationProvenance:
+		if IsOCIRepo(u) {
+			return loadOCIProvenance(ctx, u, cred)
+		}
+		return loadData(ctx, u+".prov", cred)
+	case v1alpha1.ChartVerificationCosign:
+		if IsOCIRepo(u) {
+			return nil, errors.New("cosign signatures of the charts in OCI registries are not supported")
+		}
+		return loadData(ctx, u+".sig", cred)
+	default:
+		return nil, fmt.Errorf("unknown verification provider %s", provider)
+	}
+}
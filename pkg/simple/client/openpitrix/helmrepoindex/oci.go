@@ -0,0 +1,351 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrepoindex
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/registry"
+	helmrepo "helm.sh/helm/v3/pkg/repo"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/klog/v2"
+	orasauth "oras.land/oras-go/pkg/auth"
+	dockerauth "oras.land/oras-go/pkg/auth/docker"
+	orascontent "oras.land/oras-go/pkg/content"
+	"oras.land/oras-go/pkg/oras"
+
+	"kubesphere.io/api/application/v1alpha1"
+)
+
+const (
+	OCIScheme = "oci://"
+
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+
+	// helm pushes every version of a chart as a tag, which is rarely overwritten, so the versions are cached
+	// for a while rather than pulled for every tag each time the repo is synced.
+	ociChartVersionCacheSize = 4096
+	ociChartVersionCacheTTL  = 24 * time.Hour
+)
+
+var ociChartVersions = cache.NewLRUExpireCache(ociChartVersionCacheSize)
+
+// IsOCIRepo returns whether the url is of a repo of the OCI registry, e.g. oci://harbor.example.com/library/nginx.
+func IsOCIRepo(u string) bool {
+	return strings.HasPrefix(u, OCIScheme)
+}
+
+// ociClient is the helm registry client logged in to the registry with the credential of the repo,
+// which is stored in a credentials file of the client only.
+type ociClient struct {
+	*registry.Client
+	host      string
+	plainHTTP bool
+	// the directory of the credentials file
+	dir string
+}
+
+func newOCIClient(ctx context.Context, host string, cred *v1alpha1.HelmRepoCredential) (*ociClient, error) {
+	if cred == nil {
+		cred = &v1alpha1.HelmRepoCredential{}
+	}
+	// the helm registry client verifies the certificates with the system roots only
+	if cred.InsecureSkipTLSVerify != nil && *cred.InsecureSkipTLSVerify {
+		return nil, errors.New("skipping the tls verification of the OCI registries is not supported, " +
+			"set plainHTTP of the credential for the registries without tls")
+	}
+	if cred.CertFile != "" || cred.KeyFile != "" || cred.CAFile != "" {
+		return nil, errors.New("the certificates of the credential are not supported by the OCI registries")
+	}
+	if !cred.PlainHTTP {
+		if err := ensureOCITLS(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "helm-registry-")
+	if err != nil {
+		return nil, err
+	}
+	c := &ociClient{host: host, plainHTTP: cred.PlainHTTP, dir: dir}
+	c.Client, err = registry.NewClient(
+		registry.ClientOptCredentialsFile(c.credentialsFile()),
+		registry.ClientOptWriter(io.Discard),
+	)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if cred.Username != "" {
+		err = c.Login(host, registry.LoginOptBasicAuth(cred.Username, cred.Password), registry.LoginOptInsecure(cred.PlainHTTP))
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("login to %s failed: %s", host, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *ociClient) credentialsFile() string {
+	return filepath.Join(c.dir, registry.CredentialsFileBasename)
+}
+
+// Close removes the credentials file of the client.
+func (c *ociClient) Close() {
+	if err := os.RemoveAll(c.dir); err != nil {
+		klog.Warningf("remove the credentials of %s failed, error: %s", c.host, err)
+	}
+}
+
+// ensureOCITLS checks the registry is served over https with a trusted certificate. The helm registry client
+// lists the tags over plain http once the registry answers https with http, which would send the credential
+// in plain text, so the registries without tls have to be opted in by the credential of the repo.
+func ensureOCITLS(ctx context.Context, host string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", host), nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("access %s over https failed, set plainHTTP of the credential for the registries without tls: %s", host, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ociArtifact is the chart pulled from the registry, with the layers of the media types pulled.
+type ociArtifact struct {
+	manifest ocispec.Manifest
+	metadata *chart.Metadata
+	chart    []byte
+	prov     []byte
+}
+
+// pull pulls the chart of the reference, e.g. harbor.example.com/library/nginx:1.0.0. The config is always
+// pulled, and the chart package and the provenance file only if asked.
+func (c *ociClient) pull(ctx context.Context, ref string, withChart, withProv bool) (*ociArtifact, error) {
+	if c.plainHTTP {
+		return c.pullPlainHTTP(ctx, ref, withChart, withProv)
+	}
+	// the config is only pulled along with the chart or the provenance file, which is ignored if missing
+	result, err := c.Pull(ref,
+		registry.PullOptWithChart(withChart),
+		registry.PullOptWithProv(withProv || !withChart),
+		registry.PullOptIgnoreMissingProv(!withProv))
+	if err != nil {
+		return nil, err
+	}
+	artifact := &ociArtifact{metadata: result.Chart.Meta, chart: result.Chart.Data, prov: result.Prov.Data}
+	if err := json.Unmarshal(result.Manifest.Data, &artifact.manifest); err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// pullPlainHTTP pulls the chart over plain http, which the registry client of helm only does for the
+// registries on the localhost.
+func (c *ociClient) pullPlainHTTP(ctx context.Context, ref string, withChart, withProv bool) (*ociArtifact, error) {
+	authClient, err := dockerauth.NewClient(c.credentialsFile())
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := authClient.ResolverWithOpts(orasauth.WithResolverPlainHTTP())
+	if err != nil {
+		return nil, err
+	}
+
+	mediaTypes := []string{registry.ConfigMediaType}
+	if withChart {
+		mediaTypes = append(mediaTypes, registry.ChartLayerMediaType, registry.LegacyChartLayerMediaType)
+	}
+	if withProv {
+		mediaTypes = append(mediaTypes, registry.ProvLayerMediaType)
+	}
+	store := orascontent.NewMemory()
+	desc, err := oras.Copy(ctx, orascontent.Registry{Resolver: resolver}, ref, store, "",
+		oras.WithPullEmptyNameAllowed(),
+		oras.WithAllowedMediaTypes(mediaTypes))
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &ociArtifact{}
+	_, data, ok := store.Get(desc)
+	if !ok {
+		return nil, fmt.Errorf("manifest of %s not found", ref)
+	}
+	if err := json.Unmarshal(data, &artifact.manifest); err != nil {
+		return nil, err
+	}
+	if artifact.manifest.Config.MediaType != registry.ConfigMediaType {
+		return nil, fmt.Errorf("could not load config with mediatype %s", registry.ConfigMediaType)
+	}
+	if _, data, ok = store.Get(artifact.manifest.Config); !ok {
+		return nil, fmt.Errorf("config of %s not found", ref)
+	}
+	if err := json.Unmarshal(data, &artifact.metadata); err != nil {
+		return nil, err
+	}
+	for _, layer := range artifact.manifest.Layers {
+		switch layer.MediaType {
+		case registry.ChartLayerMediaType, registry.LegacyChartLayerMediaType:
+			_, artifact.chart, _ = store.Get(layer)
+		case registry.ProvLayerMediaType:
+			_, artifact.prov, _ = store.Get(layer)
+		}
+	}
+	if withChart && artifact.chart == nil {
+		return nil, fmt.Errorf("manifest does not contain a layer with mediatype %s", registry.ChartLayerMediaType)
+	}
+	if withProv && artifact.prov == nil {
+		return nil, fmt.Errorf("manifest does not contain a layer with mediatype %s", registry.ProvLayerMediaType)
+	}
+	return artifact, nil
+}
+
+// loadOCIRepoIndex builds the index of the chart in the OCI repo, e.g. oci://harbor.example.com/library/nginx,
+// whose versions are the tags. The registries only list the repositories of a project to the administrators,
+// so every chart is a repo of its own.
+func loadOCIRepoIndex(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential) (*helmrepo.IndexFile, error) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	repository := strings.Trim(parsedURL.Path, "/")
+	if repository == "" {
+		return nil, fmt.Errorf("no chart found in %s, the url of the chart is expected, e.g. oci://harbor.example.com/library/nginx", u)
+	}
+	client, err := newOCIClient(ctx, parsedURL.Host, cred)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ref := fmt.Sprintf("%s/%s", parsedURL.Host, repository)
+	// the tags are the semantic versions sorted, in which the "_" of the tags is replaced with "+"
+	tags, err := client.Tags(ref)
+	if err != nil {
+		return nil, err
+	}
+	index := helmrepo.NewIndexFile()
+	for _, tag := range tags {
+		tagRef := fmt.Sprintf("%s:%s", ref, strings.ReplaceAll(tag, "+", "_"))
+		version, err := loadOCIChartVersion(ctx, client, tagRef, cred)
+		if err != nil {
+			// a tag which is not a chart, or fails to be pulled, does not fail the other versions
+			klog.Warningf("failed to load chart %s, error: %s", tagRef, err)
+			continue
+		}
+		if version != nil {
+			index.Entries[version.Name] = append(index.Entries[version.Name], version)
+		}
+	}
+	index.SortEntries()
+	return index, nil
+}
+
+type ociChartVersionKey struct {
+	ref string
+	// the hash of the credential, so that a version is only cached for the credential it is pulled with
+	credential [sha256.Size]byte
+}
+
+// loadOCIChartVersion loads the chart version of the reference from the config pulled, or from the cache.
+func loadOCIChartVersion(ctx context.Context, client *ociClient, ref string, cred *v1alpha1.HelmRepoCredential) (*helmrepo.ChartVersion, error) {
+	key := ociChartVersionKey{ref: ref}
+	if cred != nil {
+		key.credential = sha256.Sum256([]byte(cred.Username + "\x00" + cred.Password))
+	}
+	if version, ok := ociChartVersions.Get(key); ok {
+		copied := *version.(*helmrepo.ChartVersion)
+		return &copied, nil
+	}
+
+	artifact, err := client.pull(ctx, ref, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := artifact.metadata.Validate(); err != nil {
+		klog.Warningf("invalid chart %s, error: %s", ref, err)
+		return nil, nil
+	}
+	version := &helmrepo.ChartVersion{
+		Metadata: artifact.metadata,
+		URLs:     []string{OCIScheme + ref},
+		Created:  time.Now(),
+	}
+	for _, layer := range artifact.manifest.Layers {
+		if layer.MediaType == registry.ChartLayerMediaType || layer.MediaType == registry.LegacyChartLayerMediaType {
+			version.Digest = layer.Digest.Encoded()
+		}
+	}
+	if created, err := time.Parse(time.RFC3339, artifact.manifest.Annotations[ociCreatedAnnotation]); err == nil {
+		version.Created = created
+	}
+	ociChartVersions.Add(key, version, ociChartVersionCacheTTL)
+	copied := *version
+	return &copied, nil
+}
+
+// loadOCIChart pulls the chart package, e.g. oci://harbor.example.com/library/nginx:1.0.0.
+func loadOCIChart(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential) (*bytes.Buffer, error) {
+	artifact, err := pullOCIChart(ctx, u, cred, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(artifact.chart), nil
+}
+
+// loadOCIProvenance pulls the provenance file pushed along with the chart package.
+func loadOCIProvenance(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential) (*bytes.Buffer, error) {
+	artifact, err := pullOCIChart(ctx, u, cred, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(artifact.prov), nil
+}
+
+func pullOCIChart(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential, withChart, withProv bool) (*ociArtifact, error) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(parsedURL.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid chart reference %s", u)
+	}
+	client, err := newOCIClient(ctx, parsedURL.Host, cred)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return client.pull(ctx, strings.TrimPrefix(u, OCIScheme), withChart, withProv)
+}
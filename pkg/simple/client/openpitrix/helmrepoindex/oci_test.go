@@ -0,0 +1,268 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrepoindex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/registry"
+
+	"kubesphere.io/api/application/v1alpha1"
+)
+
+// fakeRegistry serves the charts of the repositories by the tags, and authenticates with the bearer tokens
+// which are issued to the user admin.
+type fakeRegistry struct {
+	*httptest.Server
+	lock  sync.Mutex
+	blobs map[digest.Digest][]byte
+	// the manifests by the tags and the digests, e.g. library/nginx:1.0.0
+	manifests map[string][]byte
+	tags      map[string][]string
+	// the manifests pulled
+	pulled int
+}
+
+func newFakeRegistry(tls bool) *fakeRegistry {
+	r := &fakeRegistry{blobs: make(map[digest.Digest][]byte), manifests: make(map[string][]byte), tags: make(map[string][]string)}
+	if tls {
+		r.Server = httptest.NewTLSServer(r)
+	} else {
+		r.Server = httptest.NewServer(r)
+	}
+	return r
+}
+
+func (r *fakeRegistry) host() string {
+	return r.Listener.Addr().String()
+}
+
+func (r *fakeRegistry) addBlob(mediaType string, data []byte) ocispec.Descriptor {
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(data), Size: int64(len(data))}
+	r.blobs[desc.Digest] = data
+	return desc
+}
+
+func (r *fakeRegistry) addChart(repository, name, version string) {
+	config := r.addBlob(registry.ConfigMediaType,
+		[]byte(fmt.Sprintf(`{"apiVersion":"v2","name":%q,"version":%q,"description":"chart %s"}`, name, version, name)))
+	content := r.addBlob(registry.ChartLayerMediaType, []byte(name+"-"+version+".tgz"))
+	prov := r.addBlob(registry.ProvLayerMediaType, []byte(name+"-"+version+".tgz.prov"))
+	data, _ := json.Marshal(&ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		Config:      config,
+		Layers:      []ocispec.Descriptor{content, prov},
+		Annotations: map[string]string{ociCreatedAnnotation: "2023-01-02T03:04:05Z"},
+	})
+	tag := strings.ReplaceAll(version, "+", "_")
+	r.manifests[repository+":"+tag] = data
+	r.manifests[repository+":"+digest.FromBytes(data).String()] = data
+	r.tags[repository] = append(r.tags[repository], tag)
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if req.URL.Path == "/token" {
+		if username, password, _ := req.BasicAuth(); username != "admin" || password != "P@88w0rd" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "token-" + req.URL.Query().Get("scope")})
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	scope, authorized := "", strings.HasPrefix(req.Header.Get("Authorization"), "Bearer token-")
+	for _, sep := range []string{"/tags/list", "/manifests/", "/blobs/"} {
+		if i := strings.Index(path, sep); i > 0 {
+			scope = "repository:" + path[:i] + ":pull"
+			authorized = req.Header.Get("Authorization") == "Bearer token-"+scope
+		}
+	}
+	if !authorized {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake",scope="%s"`, r.URL, scope))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case path == "":
+		w.WriteHeader(http.StatusOK)
+	case strings.HasSuffix(path, "/tags/list"):
+		repository := strings.TrimSuffix(path, "/tags/list")
+		tags, ok := r.tags[repository]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tags})
+	case strings.Contains(path, "/manifests/"):
+		parts := strings.SplitN(path, "/manifests/", 2)
+		data, ok := r.manifests[parts[0]+":"+parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.writeContent(w, req, ocispec.MediaTypeImageManifest, data)
+		if req.Method == http.MethodGet {
+			r.pulled++
+		}
+	case strings.Contains(path, "/blobs/"):
+		data, ok := r.blobs[digest.Digest(path[strings.LastIndex(path, "/")+1:])]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.writeContent(w, req, "application/octet-stream", data)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) writeContent(w http.ResponseWriter, req *http.Request, mediaType string, data []byte) {
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(data).String())
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+func TestLoadOCIRepoIndex(t *testing.T) {
+	registry := newFakeRegistry(false)
+	defer registry.Close()
+	registry.addChart("library/nginx", "nginx", "1.0.0")
+	registry.addChart("library/nginx", "nginx", "1.1.0+build.1")
+	// not a semantic version
+	registry.tags["library/nginx"] = append(registry.tags["library/nginx"], "latest")
+
+	host := registry.host()
+	cred := &v1alpha1.HelmRepoCredential{Username: "admin", Password: "P@88w0rd", PlainHTTP: true}
+	u := fmt.Sprintf("oci://%s/library/nginx", host)
+
+	index, err := LoadRepoIndex(context.TODO(), u, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := index.Entries["nginx"]
+	if len(index.Entries) != 1 || len(entries) != 2 {
+		t.Fatalf("unexpected entries %v", index.Entries)
+	}
+	for i, version := range []string{"1.1.0+build.1", "1.0.0"} {
+		if entries[i].Version != version || entries[i].Created.Year() != 2023 {
+			t.Fatalf("unexpected version %v", entries[i])
+		}
+	}
+
+	nginx := entries[0]
+	expectedURL := fmt.Sprintf("oci://%s/library/nginx:1.1.0_build.1", host)
+	if nginx.URLs[0] != expectedURL {
+		t.Fatalf("expected url %s, got %s", expectedURL, nginx.URLs[0])
+	}
+	data, err := LoadChart(context.TODO(), nginx.URLs[0], cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "nginx-1.1.0+build.1.tgz" || nginx.Digest != fmt.Sprintf("%x", sha256.Sum256(data.Bytes())) {
+		t.Fatalf("unexpected chart %s", data.String())
+	}
+	prov, err := LoadChartSignature(context.TODO(), nginx.URLs[0], v1alpha1.ChartVerificationProvenance, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prov.String() != "nginx-1.1.0+build.1.tgz.prov" {
+		t.Fatalf("unexpected provenance %s", prov.String())
+	}
+
+	// the versions loaded are not pulled again
+	pulled := registry.pulled
+	registry.addChart("library/nginx", "nginx", "1.2.0")
+	if index, err = LoadRepoIndex(context.TODO(), u, cred); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Entries["nginx"]) != 3 || registry.pulled != pulled+1 {
+		t.Fatalf("unexpected entries %v, pulled %d", index.Entries, registry.pulled-pulled)
+	}
+
+	if _, err := LoadRepoIndex(context.TODO(), u, &v1alpha1.HelmRepoCredential{PlainHTTP: true}); err == nil {
+		t.Fatal("expected the anonymous user to be unauthorized")
+	}
+	if _, err := LoadRepoIndex(context.TODO(), fmt.Sprintf("oci://%s/library/notfound", host), cred); err == nil {
+		t.Fatal("expected the chart not found")
+	}
+	if _, err := LoadRepoIndex(context.TODO(), fmt.Sprintf("oci://%s", host), cred); err == nil {
+		t.Fatal("expected the chart repository required")
+	}
+}
+
+func TestLoadOCIRepoIndexWithBadTags(t *testing.T) {
+	registry := newFakeRegistry(false)
+	defer registry.Close()
+	registry.addChart("library/redis", "redis", "1.0.0")
+	// the manifest of the tag is not found
+	registry.tags["library/redis"] = append(registry.tags["library/redis"], "1.1.0")
+	// an image rather than a chart
+	config := registry.addBlob(ocispec.MediaTypeImageConfig, []byte(`{}`))
+	data, _ := json.Marshal(&ocispec.Manifest{Versioned: specs.Versioned{SchemaVersion: 2}, Config: config})
+	registry.manifests["library/redis:1.2.0"] = data
+	registry.tags["library/redis"] = append(registry.tags["library/redis"], "1.2.0")
+	registry.addChart("library/redis", "redis", "1.3.0")
+
+	cred := &v1alpha1.HelmRepoCredential{Username: "admin", Password: "P@88w0rd", PlainHTTP: true}
+	index, err := LoadRepoIndex(context.TODO(), fmt.Sprintf("oci://%s/library/redis", registry.host()), cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := index.Entries["redis"]
+	if len(entries) != 2 || entries[0].Version != "1.3.0" || entries[1].Version != "1.0.0" {
+		t.Fatalf("unexpected entries %v", index.Entries)
+	}
+}
+
+func TestLoadOCIRepoIndexOverTLS(t *testing.T) {
+	for _, tls := range []bool{false, true} {
+		registry := newFakeRegistry(tls)
+		registry.addChart("library/nginx", "nginx", "1.0.0")
+		cred := &v1alpha1.HelmRepoCredential{Username: "admin", Password: "P@88w0rd"}
+		// the registries without tls are only accessed if opted in, and the certificates are verified
+		_, err := LoadRepoIndex(context.TODO(), fmt.Sprintf("oci://%s/library/nginx", registry.host()), cred)
+		registry.Close()
+		if err == nil {
+			t.Fatalf("expected the registry refused, tls: %t", tls)
+		}
+	}
+
+	skip := true
+	cred := &v1alpha1.HelmRepoCredential{InsecureSkipTLSVerify: &skip}
+	if _, err := LoadChart(context.TODO(), "oci://harbor.example.com/library/nginx:1.0.0", cred); err == nil {
+		t.Fatal("expected skipping the tls verification refused")
+	}
+}
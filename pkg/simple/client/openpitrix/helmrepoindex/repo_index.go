@@ -40,6 +40,10 @@ import (
 const IndexYaml = "index.yaml"
 
 func LoadRepoIndex(ctx context.Context, u string, cred *v1alpha1.HelmRepoCredential) (*helmrepo.IndexFile, error) {
+	// the OCI registries have no index, which is built from the tags of the charts
+	if IsOCIRepo(u) {
+		return loadOCIRepoIndex(ctx, u, cred)
+	}
 
 	if !strings.HasSuffix(u, "/") {
 		u = fmt.Sprintf("%s/%s", u, IndexYaml)
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrepoindex
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"           //nolint
+	"golang.org/x/crypto/openpgp/clearsign" //nolint
+	"helm.sh/helm/v3/pkg/provenance"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/api/application/v1alpha1"
+)
+
+// VerifyChart verifies the signature of the chart package against the trusted keys, and returns the identity
+// of the key which signed the chart. The signature is the provenance file of the chart for provenance, or the
+// output of `cosign sign-blob` for cosign.
+func VerifyChart(provider string, keys map[string][]byte, chartData, signature []byte) (string, error) {
+	if len(signature) == 0 {
+		return "", errors.New("the chart is not signed")
+	}
+	switch provider {
+	case v1alpha1.ChartVerificationProvenance:
+		return verifyProvenance(keys, chartData, signature)
+	case v1alpha1.ChartVerificationCosign:
+		return verifyCosignSignature(keys, chartData, signature)
+	default:
+		return "", fmt.Errorf("unknown verification provider %s", provider)
+	}
+}
+
+func verifyProvenance(keys map[string][]byte, chartData, prov []byte) (string, error) {
+	block, _ := clearsign.Decode(prov)
+	if block == nil {
+		return "", errors.New("invalid provenance file, signature block not found")
+	}
+
+	var keyring openpgp.EntityList
+	for _, name := range sortedKeyNames(keys) {
+		entities, err := readKeyRing(keys[name])
+		if err != nil {
+			klog.Warningf("invalid keyring %s, error: %s", name, err)
+			continue
+		}
+		keyring = append(keyring, entities...)
+	}
+	signer, err := openpgp.CheckDetachedSignature(keyring, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return "", fmt.Errorf("the chart is not signed by any trusted key: %s", err)
+	}
+
+	// the message block is the metadata and the digests of the chart packages separated by "..."
+	parts := bytes.Split(block.Plaintext, []byte("\n...\n"))
+	if len(parts) < 2 {
+		return "", errors.New("invalid provenance file, digests not found")
+	}
+	sums := &provenance.SumCollection{}
+	if err := yaml.Unmarshal(parts[1], sums); err != nil {
+		return "", fmt.Errorf("invalid provenance file: %s", err)
+	}
+	digest, err := provenance.Digest(bytes.NewReader(chartData))
+	if err != nil {
+		return "", err
+	}
+	for _, sum := range sums.Files {
+		if sum == "sha256:"+digest {
+			return entityName(signer), nil
+		}
+	}
+	return "", errors.New("the digest of the chart does not match the provenance file")
+}
+
+// readKeyRing reads the armored or the binary keyring.
+func readKeyRing(data []byte) (openpgp.EntityList, error) {
+	if entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data)); err == nil {
+		return entities, nil
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(data))
+}
+
+func entityName(entity *openpgp.Entity) string {
+	names := make([]string, 0, len(entity.Identities))
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return entity.PrimaryKey.KeyIdString()
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+func verifyCosignSignature(keys map[string][]byte, chartData, signature []byte) (string, error) {
+	// cosign writes the signature encoded in base64, the raw signature is accepted as well
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		sig = signature
+	}
+	digest := sha256.Sum256(chartData)
+
+	for _, name := range sortedKeyNames(keys) {
+		key, err := parsePublicKey(keys[name])
+		if err != nil {
+			klog.Warningf("invalid public key %s, error: %s", name, err)
+			continue
+		}
+		if verifySignature(key, chartData, digest[:], sig) {
+			return name, nil
+		}
+	}
+	return "", errors.New("the chart is not signed by any trusted key")
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM block not found")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func verifySignature(key crypto.PublicKey, data, digest, sig []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest, sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, data, sig)
+	default:
+		return false
+	}
+}
+
+func sortedKeyNames(keys map[string][]byte) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
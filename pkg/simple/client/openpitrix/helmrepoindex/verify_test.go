@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrepoindex
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"           //nolint
+	"golang.org/x/crypto/openpgp/armor"     //nolint
+	"golang.org/x/crypto/openpgp/clearsign" //nolint
+
+	"kubesphere.io/api/application/v1alpha1"
+)
+
+var chartPackage = []byte("nginx-1.0.0.tgz")
+
+// signProvenance signs the chart as `helm package --sign` does.
+func signProvenance(t *testing.T, entity *openpgp.Entity, chart []byte) []byte {
+	message := fmt.Sprintf("apiVersion: v2\nname: nginx\nversion: 1.0.0\n\n...\nfiles:\n  nginx-1.0.0.tgz: sha256:%x\n", sha256.Sum256(chart))
+	out := &bytes.Buffer{}
+	w, err := clearsign.Encode(out, entity.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(message)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) []byte {
+	out := &bytes.Buffer{}
+	w, err := armor.Encode(out, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestVerifyProvenance(t *testing.T) {
+	trusted, err := openpgp.NewEntity("KubeSphere", "", "kubesphere@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrusted, err := openpgp.NewEntity("Someone", "", "someone@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string][]byte{"kubesphere.asc": armoredPublicKey(t, trusted)}
+
+	signer, err := VerifyChart(v1alpha1.ChartVerificationProvenance, keys, chartPackage, signProvenance(t, trusted, chartPackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != "KubeSphere <kubesphere@example.com>" {
+		t.Fatalf("unexpected signer %s", signer)
+	}
+
+	if _, err := VerifyChart(v1alpha1.ChartVerificationProvenance, keys, chartPackage, signProvenance(t, untrusted, chartPackage)); err == nil {
+		t.Fatal("expected the chart signed by the untrusted key not verified")
+	}
+	if _, err := VerifyChart(v1alpha1.ChartVerificationProvenance, keys, []byte("tampered"), signProvenance(t, trusted, chartPackage)); err == nil {
+		t.Fatal("expected the tampered chart not verified")
+	}
+	if _, err := VerifyChart(v1alpha1.ChartVerificationProvenance, keys, chartPackage, nil); err == nil {
+		t.Fatal("expected the unsigned chart not verified")
+	}
+}
+
+func TestVerifyCosignSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string][]byte{
+		"cosign.pub": pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		"invalid":    []byte("not a key"),
+	}
+
+	digest := sha256.Sum256(chartPackage)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, signature := range [][]byte{[]byte(base64.StdEncoding.EncodeToString(sig) + "\n"), sig} {
+		signer, err := VerifyChart(v1alpha1.ChartVerificationCosign, keys, chartPackage, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signer != "cosign.pub" {
+			t.Fatalf("unexpected signer %s", signer)
+		}
+	}
+	if _, err := VerifyChart(v1alpha1.ChartVerificationCosign, keys, []byte("tampered"), sig); err == nil {
+		t.Fatal("expected the tampered chart not verified")
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmwrapper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/api/application/v1alpha1"
+)
+
+// Drift compares the resources in the manifest of the release with the live objects and returns the drifted ones.
+// The drifted resources are re-applied from the manifest if correct is true.
+func (c *helmWrapper) Drift(correct bool) ([]v1alpha1.DriftedResource, error) {
+	if c.mock {
+		return nil, nil
+	}
+
+	manifest, err := c.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	client := c.helmConf.KubeClient
+	resources, err := client.Build(bytes.NewBufferString(manifest), false)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []v1alpha1.DriftedResource
+	var targets kube.ResourceList
+	for _, info := range resources {
+		d, err := driftOf(info)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			drifted = append(drifted, *d)
+			targets = append(targets, info)
+		}
+	}
+
+	if correct && len(targets) > 0 {
+		// the missing resources are created and the modified are patched back to the manifest
+		if _, err := client.Update(targets, targets, false); err != nil {
+			klog.Errorf("namespace: %s, name: %s, correct drift failed, error: %v", c.Namespace, c.ReleaseName, err)
+			return drifted, err
+		}
+		klog.V(2).Infof("namespace: %s, name: %s, %d drifted resources corrected", c.Namespace, c.ReleaseName, len(targets))
+	}
+	return drifted, nil
+}
+
+func driftOf(info *cliresource.Info) (*v1alpha1.DriftedResource, error) {
+	gvk := info.Object.GetObjectKind().GroupVersionKind()
+	drifted := &v1alpha1.DriftedResource{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  info.Namespace,
+		Name:       info.Name,
+	}
+
+	live, err := cliresource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			drifted.Reason = v1alpha1.DriftReasonMissing
+			return drifted, nil
+		}
+		return nil, err
+	}
+
+	desiredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+	if err != nil {
+		return nil, err
+	}
+	liveObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+	if err != nil {
+		return nil, err
+	}
+	if fields := DriftedFields(desiredObj, liveObj); len(fields) > 0 {
+		drifted.Reason = v1alpha1.DriftReasonModified
+		drifted.Fields = fields
+		return drifted, nil
+	}
+	return nil, nil
+}
+
+// DriftedFields returns the paths of the fields of the desired object which differ from the live object.
+// The desired object is expected to be a subset of the live one, so that the fields defaulted or added by
+// the controllers and the webhooks, e.g. the injected sidecars, are not taken as drifts. The status and
+// the metadata other than the labels and the annotations are ignored.
+func DriftedFields(desired, live map[string]interface{}) []string {
+	if desired["kind"] == "Secret" {
+		desired = mergeStringData(desired)
+	}
+
+	var fields []string
+	for _, key := range sortedKeys(desired) {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			desiredMeta, _ := desired[key].(map[string]interface{})
+			liveMeta, _ := live[key].(map[string]interface{})
+			for _, k := range []string{"labels", "annotations"} {
+				fields = append(fields, diff(desiredMeta[k], liveMeta[k], "metadata."+k)...)
+			}
+		default:
+			fields = append(fields, diff(desired[key], live[key], key)...)
+		}
+	}
+	return fields
+}
+
+func diff(desired, live interface{}, path string) []string {
+	switch d := desired.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if live == nil && len(d) == 0 {
+			return nil
+		}
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		var fields []string
+		for _, key := range sortedKeys(d) {
+			fields = append(fields, diff(d[key], l[key], path+"."+key)...)
+		}
+		return fields
+	case []interface{}:
+		if live == nil && len(d) == 0 {
+			return nil
+		}
+		l, ok := live.([]interface{})
+		if !ok || len(l) < len(d) {
+			return []string{path}
+		}
+		var fields []string
+		for i := range d {
+			fields = append(fields, diff(d[i], l[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return fields
+	default:
+		// the zero values are omitted by the api server
+		if live == nil && reflect.ValueOf(desired).IsZero() {
+			return nil
+		}
+		if !equalValue(desired, live) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+// equalValue compares the values of the fields, which are equal if they are the same quantity,
+// e.g. 0.5 and 500m of the cpu, or 1 and "1" of the ports.
+func equalValue(desired, live interface{}) bool {
+	if reflect.DeepEqual(desired, live) {
+		return true
+	}
+	if live == nil {
+		return false
+	}
+	if _, ok := desired.(bool); ok {
+		return false
+	}
+	d, l := fmt.Sprint(desired), fmt.Sprint(live)
+	if d == l {
+		return true
+	}
+	dq, err := resource.ParseQuantity(d)
+	if err != nil {
+		return false
+	}
+	lq, err := resource.ParseQuantity(l)
+	if err != nil {
+		return false
+	}
+	return dq.Cmp(lq) == 0
+}
+
+// mergeStringData merges the stringData of the secret into the data, as the api server does.
+func mergeStringData(secret map[string]interface{}) map[string]interface{} {
+	stringData, ok := secret["stringData"].(map[string]interface{})
+	if !ok {
+		return secret
+	}
+	merged := runtime.DeepCopyJSON(secret)
+	data, _ := merged["data"].(map[string]interface{})
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	for k, v := range stringData {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(v)))
+	}
+	merged["data"] = data
+	delete(merged, "stringData")
+	return merged
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmwrapper
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const desiredDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    app: nginx
+spec:
+  replicas: 2
+  template:
+    spec:
+      hostNetwork: false
+      containers:
+      - name: nginx
+        image: nginx:1.25
+        ports:
+        - containerPort: 80
+        resources:
+          limits:
+            cpu: 0.5
+            memory: 128Mi
+`
+
+func TestDriftedFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		desired  string
+		live     string
+		expected []string
+	}{
+		{
+			name:    "defaulted and injected fields are not drifts",
+			desired: desiredDeployment,
+			live: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  uid: 0bd9e9ad-6f58-4c39-a0ac-b3e0b7b0f2d4
+  labels:
+    app: nginx
+  annotations:
+    deployment.kubernetes.io/revision: "1"
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.25
+        imagePullPolicy: IfNotPresent
+        ports:
+        - containerPort: 80
+          protocol: TCP
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+      - name: istio-proxy
+        image: istio/proxyv2
+status:
+  replicas: 2
+`,
+		},
+		{
+			name:    "modified fields",
+			desired: desiredDeployment,
+			live: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  labels:
+    app: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      hostNetwork: true
+      containers:
+      - name: nginx
+        image: nginx:latest
+        resources:
+          limits:
+            cpu: "1"
+            memory: 128Mi
+`,
+			expected: []string{
+				"metadata.labels.app",
+				"spec.replicas",
+				"spec.template.spec.containers[0].image",
+				"spec.template.spec.containers[0].ports",
+				"spec.template.spec.containers[0].resources.limits.cpu",
+				"spec.template.spec.hostNetwork",
+			},
+		},
+		{
+			name: "string data of secrets",
+			desired: `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: password
+stringData:
+  password: P@88w0rd
+`,
+			live: `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: password
+data:
+  password: UEA4OHcwcmQ=
+type: Opaque
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desired, live := map[string]interface{}{}, map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(test.desired), &desired); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(test.live), &live); err != nil {
+				t.Fatal(err)
+			}
+			if fields := DriftedFields(desired, live); !reflect.DeepEqual(fields, test.expected) {
+				t.Fatalf("expected drifted fields %v, got %v", test.expected, fields)
+			}
+		})
+	}
+}
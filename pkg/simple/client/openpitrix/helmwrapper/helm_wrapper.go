@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"helm.sh/helm/v3/pkg/action"
@@ -34,12 +35,16 @@ import (
 	"k8s.io/klog/v2"
 	kpath "k8s.io/utils/path"
 
+	"kubesphere.io/api/application/v1alpha1"
+
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/utils/idutils"
 )
 
 const (
 	workspaceBase = "/tmp/helm-operator"
+	// the max number of the revisions listed
+	maxHistory = 256
 )
 
 var (
@@ -66,6 +71,14 @@ type HelmWrapper interface {
 
 	// IsReleaseReady check helm release is ready or not
 	IsReleaseReady(timeout time.Duration) (bool, error)
+	// History returns the revisions of the release, the oldest first
+	History() ([]*helmrelease.Release, error)
+	// Rollback rolls back the release to the revision
+	Rollback(revision int) error
+	// Drift returns the resources drifted from the manifest, and re-applies them if correct is true
+	Drift(correct bool) ([]v1alpha1.DriftedResource, error)
+	// DryRun simulates the install or the upgrade of the release, and returns the rendered manifest
+	DryRun(chartName, chartData, values string) (string, error)
 }
 
 // IsReleaseReady check helm releases is ready or not
@@ -363,6 +376,29 @@ func (c *helmWrapper) helmInstall(chart *chart.Chart, values map[string]interfac
 }
 
 func (c *helmWrapper) writeAction(chartName, chartData, values string, upgrade bool) error {
+	_, err := c.runAction(chartName, chartData, values, upgrade)
+	return err
+}
+
+// DryRun installs the release if it does not exist, or upgrades it otherwise, without changing anything.
+func (c *helmWrapper) DryRun(chartName, chartData, values string) (string, error) {
+	upgrade := false
+	sts, err := c.Status()
+	if err == nil {
+		upgrade = sts.Info != nil && sts.Info.Status == helmrelease.StatusDeployed
+	} else if err.Error() != StatusNotFoundFormat {
+		return "", err
+	}
+
+	c.dryRun = true
+	rel, err := c.runAction(chartName, chartData, values, upgrade)
+	if err != nil {
+		return "", err
+	}
+	return rel.Manifest, nil
+}
+
+func (c *helmWrapper) runAction(chartName, chartData, values string, upgrade bool) (*helmrelease.Release, error) {
 	if klog.V(2).Enabled() {
 		start := time.Now()
 		defer func() {
@@ -371,23 +407,23 @@ func (c *helmWrapper) writeAction(chartName, chartData, values string, upgrade b
 	}
 
 	if err := c.ensureWorkspace(); err != nil {
-		return err
+		return nil, err
 	}
 	defer c.cleanup()
 
 	if err := c.createChart(chartName, chartData, values); err != nil {
-		return err
+		return nil, err
 	}
 	klog.V(8).Infof("namespace: %s, name: %s, chart values: %s", c.Namespace, c.ReleaseName, values)
 
 	chartRequested, err := loader.Load(c.chartPath())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	valuePath := filepath.Join(c.Workspace(), "values.yaml")
 	helmValues, err := chartutil.ReadValuesFile(valuePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var rel *helmrelease.Release
@@ -403,12 +439,12 @@ func (c *helmWrapper) writeAction(chartName, chartData, values string, upgrade b
 
 	if err != nil {
 		klog.Errorf("namespace: %s, name: %s,  error: %v", c.Namespace, c.ReleaseName, err)
-		return err
+		return nil, err
 	}
 
 	klog.V(2).Infof("namespace: %s, name: %s, run command success", c.Namespace, c.ReleaseName)
 	klog.V(8).Infof("namespace: %s, name: %s, run command success, manifest: %s", c.Namespace, c.ReleaseName, rel.Manifest)
-	return nil
+	return rel, nil
 }
 
 func (c *helmWrapper) Manifest() (string, error) {
@@ -424,3 +460,42 @@ func (c *helmWrapper) Manifest() (string, error) {
 	klog.V(8).Infof("namespace: %s, name: %s, run command success, manifest: %s", c.Namespace, c.ReleaseName, rel.Manifest)
 	return rel.Manifest, nil
 }
+
+// helm history
+func (c *helmWrapper) History() ([]*helmrelease.Release, error) {
+	history := action.NewHistory(c.helmConf)
+	history.Max = maxHistory
+
+	releases, err := history.Run(c.ReleaseName)
+	if err != nil {
+		klog.Errorf("namespace: %s, name: %s, run command failed, error: %v", c.Namespace, c.ReleaseName, err)
+		return nil, err
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version < releases[j].Version
+	})
+	return releases, nil
+}
+
+// helm rollback
+func (c *helmWrapper) Rollback(revision int) error {
+	start := time.Now()
+	defer func() {
+		klog.V(2).Infof("run command end, namespace: %s, name: %s elapsed: %v", c.Namespace, c.ReleaseName, time.Since(start))
+	}()
+
+	if c.mock {
+		return nil
+	}
+
+	rollback := action.NewRollback(c.helmConf)
+	rollback.Version = revision
+	rollback.DryRun = c.dryRun
+	if err := rollback.Run(c.ReleaseName); err != nil {
+		klog.Errorf("namespace: %s, name: %s, rollback to revision %d failed, error: %v", c.Namespace, c.ReleaseName, revision, err)
+		return err
+	}
+
+	klog.V(2).Infof("namespace: %s, name: %s, rollback to revision %d success", c.Namespace, c.ReleaseName, revision)
+	return nil
+}
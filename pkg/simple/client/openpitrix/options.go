@@ -17,10 +17,13 @@ limitations under the License.
 package openpitrix
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/pflag"
 
+	"kubesphere.io/api/application/v1alpha1"
+
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/utils/reflectutils"
 )
@@ -28,6 +31,14 @@ import (
 type Options struct {
 	S3Options                *s3.Options               `json:"s3,omitempty" yaml:"s3,omitempty" mapstructure:"s3"`
 	ReleaseControllerOptions *ReleaseControllerOptions `json:"releaseControllerOptions,omitempty" yaml:"releaseControllerOptions,omitempty" mapstructure:"releaseControllerOptions"`
+	// the charts uploaded to the workspaces are required to be signed by the policies,
+	// which are only configured by the platform administrators
+	ChartVerification *ChartVerificationOptions `json:"chartVerification,omitempty" yaml:"chartVerification,omitempty" mapstructure:"chartVerification"`
+}
+
+type ChartVerificationOptions struct {
+	// the policies by the workspaces, the charts uploaded to the other workspaces are not required to be signed
+	Workspaces map[string]*v1alpha1.ChartVerification `json:"workspaces,omitempty" yaml:"workspaces,omitempty" mapstructure:"workspaces"`
 }
 
 type ReleaseControllerOptions struct {
@@ -49,6 +60,20 @@ func NewOptions() *Options {
 func (s *Options) Validate() []error {
 	var errors []error
 
+	if s.ChartVerification != nil {
+		for workspace, verification := range s.ChartVerification.Workspaces {
+			if verification == nil {
+				continue
+			}
+			if verification.Provider != v1alpha1.ChartVerificationProvenance && verification.Provider != v1alpha1.ChartVerificationCosign {
+				errors = append(errors, fmt.Errorf("unknown chart verification provider %q of workspace %s", verification.Provider, workspace))
+			}
+			if verification.SecretRef == "" {
+				errors = append(errors, fmt.Errorf("no trusted keys of the chart verification of workspace %s", workspace))
+			}
+		}
+	}
+
 	return errors
 }
 
@@ -65,6 +90,10 @@ func (s *Options) ApplyTo(options *Options) {
 	if s.ReleaseControllerOptions != nil {
 		reflectutils.Override(options, s)
 	}
+
+	if s.ChartVerification != nil {
+		reflectutils.Override(options, s)
+	}
 }
 
 // AddFlags add options flags to command line flags,
@@ -17,6 +17,7 @@ limitations under the License.
 package fake
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
@@ -69,6 +70,8 @@ func (s *FakeS3) Read(key string) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		// the object can be read again
+		o.Body = bytes.NewReader(data)
 		return data, nil
 	}
 	return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such object", nil)
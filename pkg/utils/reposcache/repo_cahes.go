@@ -413,7 +413,7 @@ func (c *cachedRepos) getAppVersion(versionId string, withData bool) (ret *v1alp
 
 			c.RUnlock()
 			url := version.Spec.URLs[0]
-			if !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "s3://")) {
+			if !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "s3://") || helmrepoindex.IsOCIRepo(url)) {
 				url = repo.Spec.Url + "/" + url
 			}
 
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindAlertHistory   = "AlertHistory"
+	ResourcePluralAlertHistory = "alerthistories"
+
+	// AlertHistoryLabelRuleLevel is the label of the level of the rule, one of namespace, cluster and global.
+	AlertHistoryLabelRuleLevel = "alerting.kubesphere.io/rule-level"
+	// AlertHistoryLabelRuleNamespace is the label of the namespace of the namespace level rule.
+	AlertHistoryLabelRuleNamespace = "alerting.kubesphere.io/rule-namespace"
+)
+
+// AlertRecord is an episode of an alert, from the time it became active to the time it was resolved.
+type AlertRecord struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	// ActiveAt is when the alert became pending.
+	ActiveAt metav1.Time `json:"activeAt"`
+	// StartsAt is when the alert started firing, it is empty while the alert is pending.
+	// +optional
+	StartsAt *metav1.Time `json:"startsAt,omitempty"`
+	// EndsAt is when the alert was resolved, it is empty while the alert is active.
+	// +optional
+	EndsAt *metav1.Time `json:"endsAt,omitempty"`
+	// Value of the alert when it started firing.
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// AlertHistorySpec identifies the rule whose alerts are recorded.
+type AlertHistorySpec struct {
+	RuleLevel string `json:"ruleLevel"`
+	// Namespace of the rule group, only set for the namespace level rules.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	RuleGroup string `json:"ruleGroup"`
+	Alert     string `json:"alert"`
+}
+
+type AlertHistoryStatus struct {
+	// Records of the alerts, the latest last. The records resolved beyond the retention are removed.
+	// +optional
+	Records []AlertRecord `json:"records,omitempty"`
+	// LastSampleTime is the last time the alerts of the rule were sampled from the ruler.
+	// +optional
+	LastSampleTime *metav1.Time `json:"lastSampleTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Level",type="string",JSONPath=".spec.ruleLevel"
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.namespace"
+// +kubebuilder:printcolumn:name="Group",type="string",JSONPath=".spec.ruleGroup"
+// +kubebuilder:printcolumn:name="Alert",type="string",JSONPath=".spec.alert"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +genclient
+// +genclient:nonNamespaced
+
+// AlertHistory records the alerts of a rule, so that they are kept after resolved.
+type AlertHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AlertHistorySpec   `json:"spec"`
+	Status AlertHistoryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AlertHistoryList contains a list of AlertHistory
+type AlertHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AlertHistory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AlertHistory{}, &AlertHistoryList{})
+}
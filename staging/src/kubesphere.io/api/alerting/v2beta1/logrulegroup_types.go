@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindLogRuleGroup = "LogRuleGroup"
+)
+
+// LogFilter selects the logs of the namespace of the rule group to count, the same as the log search does.
+// The fields of search match literally and the fields of filter match fuzzily, a log is selected if it
+// matches any of the values of each field specified.
+type LogFilter struct {
+	WorkloadSearch  []string `json:"workloadSearch,omitempty"`
+	WorkloadFilter  []string `json:"workloadFilter,omitempty"`
+	PodSearch       []string `json:"podSearch,omitempty"`
+	PodFilter       []string `json:"podFilter,omitempty"`
+	ContainerSearch []string `json:"containerSearch,omitempty"`
+	ContainerFilter []string `json:"containerFilter,omitempty"`
+	// LogSearch are the keywords of the log messages, e.g. OutOfMemoryError.
+	LogSearch []string `json:"logSearch,omitempty"`
+}
+
+// LogCondition is met if the count of the logs selected within the window compared to the threshold is true,
+// e.g. more than 50 logs within 5 minutes.
+type LogCondition struct {
+	Filter LogFilter `json:"filter,omitempty"`
+	// Window the logs are counted within, e.g. 5m.
+	Window     Duration   `json:"window"`
+	Comparator Comparator `json:"comparator"`
+	Threshold  int64      `json:"threshold"`
+}
+
+type LogRule struct {
+	Alert     string       `json:"alert"`
+	Condition LogCondition `json:"condition"`
+
+	For      Duration `json:"for,omitempty"`
+	Severity Severity `json:"severity,omitempty"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	Disable bool `json:"disable,omitempty"`
+}
+
+// LogRuleGroupSpec defines the desired state of LogRuleGroup
+type LogRuleGroupSpec struct {
+	// Interval the rules are evaluated at, defaults to 1m.
+	Interval Duration  `json:"interval,omitempty"`
+	Rules    []LogRule `json:"rules"`
+}
+
+// LogRuleStatus is the state of the alert of a rule.
+type LogRuleStatus struct {
+	Alert string `json:"alert"`
+	// State is one of inactive, pending and firing.
+	State string `json:"state,omitempty"`
+	// Count of the logs at the last evaluation.
+	Count int64 `json:"count,omitempty"`
+	// ActiveAt is when the alert became pending.
+	ActiveAt *metav1.Time `json:"activeAt,omitempty"`
+	// FiredAt is when the alert started firing.
+	FiredAt        *metav1.Time `json:"firedAt,omitempty"`
+	LastEvaluation *metav1.Time `json:"lastEvaluation,omitempty"`
+	LastError      string       `json:"lastError,omitempty"`
+}
+
+// LogRuleGroupStatus defines the observed state of LogRuleGroup
+type LogRuleGroupStatus struct {
+	RulesStatus []LogRuleStatus `json:"rulesStatus,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Interval",type="string",JSONPath=".spec.interval"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +genclient
+
+// LogRuleGroup is the Schema for the log based alerting rules of a namespace, whose alerts are sent to
+// the alertmanager the same as the alerts of the RuleGroup.
+type LogRuleGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogRuleGroupSpec   `json:"spec,omitempty"`
+	Status LogRuleGroupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// LogRuleGroupList contains a list of LogRuleGroup
+type LogRuleGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LogRuleGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LogRuleGroup{}, &LogRuleGroupList{})
+}
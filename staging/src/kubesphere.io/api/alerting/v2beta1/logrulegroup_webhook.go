@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var logrulegrouplog = logf.Log.WithName("logrulegroup")
+
+func (r *LogRuleGroup) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+var _ webhook.Validator = &LogRuleGroup{}
+
+func (r *LogRuleGroup) ValidateCreate() error {
+	return r.Validate()
+}
+func (r *LogRuleGroup) ValidateUpdate(old runtime.Object) error {
+	return r.Validate()
+}
+func (r *LogRuleGroup) ValidateDelete() error {
+	return nil
+}
+func (r *LogRuleGroup) Validate() error {
+	log := logrulegrouplog.WithValues("name", r.Namespace+"/"+r.Name)
+	log.Info("validate")
+
+	if len(r.Spec.Rules) > MaxRuleCountPerGroup {
+		return fmt.Errorf("the rule group has %d rules, exceeding the max count (%d)", len(r.Spec.Rules), MaxRuleCountPerGroup)
+	}
+	if r.Spec.Interval != "" {
+		if d, err := model.ParseDuration(string(r.Spec.Interval)); err != nil || d <= 0 {
+			return fmt.Errorf("invalid 'interval': %s", r.Spec.Interval)
+		}
+	}
+
+	alerts := make(map[string]bool, len(r.Spec.Rules))
+	for _, rule := range r.Spec.Rules {
+		if rule.Alert == "" {
+			return fmt.Errorf("'alert' cannot be empty")
+		}
+		// the alerts of the rules are told apart by the names
+		if alerts[rule.Alert] {
+			return fmt.Errorf("duplicate 'alert': %s", rule.Alert)
+		}
+		alerts[rule.Alert] = true
+		if err := rule.Condition.Validate(); err != nil {
+			return fmt.Errorf("invalid condition of rule %s: %v", rule.Alert, err)
+		}
+		if rule.For != "" {
+			if _, err := model.ParseDuration(string(rule.For)); err != nil {
+				return fmt.Errorf("invalid 'for': %s", rule.For)
+			}
+		}
+		for name := range rule.Labels {
+			if !model.LabelName(name).IsValid() {
+				return fmt.Errorf("invalid label name: %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *LogCondition) Validate() error {
+	if d, err := model.ParseDuration(string(c.Window)); err != nil || d <= 0 {
+		return fmt.Errorf("invalid 'window': %s", c.Window)
+	}
+	switch c.Comparator {
+	case ComparatorLT, ComparatorLE, ComparatorGT, ComparatorGE:
+	default:
+		return fmt.Errorf("invalid 'comparator': %s", c.Comparator)
+	}
+	if c.Threshold < 0 {
+		return fmt.Errorf("'threshold' cannot be negative")
+	}
+	return nil
+}
+
+// Compare compares the count of the logs to the threshold, and returns true if the condition is met.
+func (c *LogCondition) Compare(count int64) bool {
+	switch c.Comparator {
+	case ComparatorLT:
+		return count < c.Threshold
+	case ComparatorLE:
+		return count <= c.Threshold
+	case ComparatorGT:
+		return count > c.Threshold
+	case ComparatorGE:
+		return count >= c.Threshold
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindSilence        = "Silence"
+	ResourceKindClusterSilence = "ClusterSilence"
+
+	// SilenceFinalizer makes sure the silences are expired in the alertmanager before the resources are removed.
+	SilenceFinalizer = "finalizers.alerting.kubesphere.io/silence"
+)
+
+type SilenceState string
+
+const (
+	SilenceStatePending SilenceState = "pending"
+	SilenceStateActive  SilenceState = "active"
+	SilenceStateExpired SilenceState = "expired"
+)
+
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type Weekday string
+
+// SilenceMatcher matches the label of the alerts to silence.
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Matcher `json:",inline"`
+}
+
+// SilenceSchedule makes a silence a maintenance window recurring on the days of the week,
+// e.g. every Sunday from 02:00 to 04:00.
+type SilenceSchedule struct {
+	// Days of the week the window starts on, the window recurs every day if empty.
+	DaysOfWeek []Weekday `json:"daysOfWeek,omitempty"`
+	// Start time of the window in the format of HH:MM, e.g. 02:00.
+	// +kubebuilder:validation:Pattern:="^([01][0-9]|2[0-3]):[0-5][0-9]$"
+	Start string `json:"start"`
+	// End time of the window in the format of HH:MM, the window ends on the next day if the end is not after the start.
+	// +kubebuilder:validation:Pattern:="^([01][0-9]|2[0-3]):[0-5][0-9]$"
+	End string `json:"end"`
+	// TimeZone of the window in the IANA format, e.g. Asia/Shanghai. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// SilenceSpec defines the desired state of Silence
+type SilenceSpec struct {
+	// Matchers of the labels of the alerts to silence, an alert is silenced if all of them match.
+	// The alerts are limited to the scope of the silence in addition.
+	Matchers []SilenceMatcher `json:"matchers"`
+	// StartsAt is when the silence starts, it starts once created if not specified.
+	// It is when the schedule takes effect if the schedule is specified.
+	StartsAt *metav1.Time `json:"startsAt,omitempty"`
+	// EndsAt is when the silence ends, which is required if the schedule is not specified.
+	// It is when the schedule stops taking effect if the schedule is specified.
+	EndsAt *metav1.Time `json:"endsAt,omitempty"`
+	// Schedule of the recurring maintenance windows.
+	Schedule *SilenceSchedule `json:"schedule,omitempty"`
+	Comment  string           `json:"comment,omitempty"`
+}
+
+// SilenceStatus defines the observed state of Silence
+type SilenceStatus struct {
+	State SilenceState `json:"state,omitempty"`
+	// ID of the silence of the current or the next window in the alertmanager.
+	SilenceID string `json:"silenceID,omitempty"`
+	// StartsAt is when the current or the next window starts.
+	StartsAt *metav1.Time `json:"startsAt,omitempty"`
+	// EndsAt is when the current or the next window ends.
+	EndsAt       *metav1.Time `json:"endsAt,omitempty"`
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Message of the failure of the last sync.
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Starts",type="date",JSONPath=".status.startsAt"
+// +kubebuilder:printcolumn:name="Ends",type="date",JSONPath=".status.endsAt"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +genclient
+
+// Silence silences the alerts of its namespace in the alertmanager.
+type Silence struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SilenceSpec   `json:"spec,omitempty"`
+	Status SilenceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SilenceList contains a list of Silence
+type SilenceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Silence `json:"items"`
+}
+
+// ClusterSilenceSpec defines the desired state of ClusterSilence
+type ClusterSilenceSpec struct {
+	// Workspace limits the silence to the alerts of the namespaces of the workspace,
+	// the alerts of the whole cluster are silenced if not specified.
+	Workspace   string `json:"workspace,omitempty"`
+	SilenceSpec `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Workspace",type="string",JSONPath=".spec.workspace"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Starts",type="date",JSONPath=".status.startsAt"
+// +kubebuilder:printcolumn:name="Ends",type="date",JSONPath=".status.endsAt"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +genclient
+// +genclient:nonNamespaced
+
+// ClusterSilence silences the alerts of a workspace or the whole cluster in the alertmanager.
+type ClusterSilence struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterSilenceSpec `json:"spec,omitempty"`
+	Status SilenceStatus      `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterSilenceList contains a list of ClusterSilence
+type ClusterSilenceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSilence `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Silence{}, &SilenceList{})
+	SchemeBuilder.Register(&ClusterSilence{}, &ClusterSilenceList{})
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// SilenceScheduleTimeLayout is the layout of the start and end time of the schedules.
+	SilenceScheduleTimeLayout = "15:04"
+
+	silenceLabelNamespace = "namespace"
+)
+
+var silencelog = logf.Log.WithName("silence")
+
+func (r *Silence) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+var _ webhook.Validator = &Silence{}
+
+func (r *Silence) ValidateCreate() error {
+	return r.Validate()
+}
+func (r *Silence) ValidateUpdate(old runtime.Object) error {
+	return r.Validate()
+}
+func (r *Silence) ValidateDelete() error {
+	return nil
+}
+func (r *Silence) Validate() error {
+	log := silencelog.WithValues("name", r.Namespace+"/"+r.Name)
+	log.Info("validate")
+
+	if err := r.Spec.Validate(); err != nil {
+		return err
+	}
+	// the alerts of the other namespaces are out of the scope of the silence
+	for _, m := range r.Spec.Matchers {
+		if m.Name == silenceLabelNamespace && m.Type == MatchEqual && m.Value != r.Namespace {
+			return fmt.Errorf("the silence in namespace %s cannot silence the alerts of namespace %s", r.Namespace, m.Value)
+		}
+	}
+	return nil
+}
+
+func (r *ClusterSilence) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+var _ webhook.Validator = &ClusterSilence{}
+
+func (r *ClusterSilence) ValidateCreate() error {
+	return r.Validate()
+}
+func (r *ClusterSilence) ValidateUpdate(old runtime.Object) error {
+	return r.Validate()
+}
+func (r *ClusterSilence) ValidateDelete() error {
+	return nil
+}
+func (r *ClusterSilence) Validate() error {
+	log := silencelog.WithValues("name", r.Name)
+	log.Info("validate")
+
+	return r.Spec.SilenceSpec.Validate()
+}
+
+func (s *SilenceSpec) Validate() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("at least one matcher must be specified")
+	}
+	for _, m := range s.Matchers {
+		if !model.LabelName(m.Name).IsValid() {
+			return fmt.Errorf("invalid label name [%s] of the matcher", m.Name)
+		}
+		if err := m.Matcher.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.Schedule == nil && s.EndsAt == nil {
+		return fmt.Errorf("'endsAt' must be specified for a silence without the schedule")
+	}
+	if s.StartsAt != nil && s.EndsAt != nil && !s.EndsAt.After(s.StartsAt.Time) {
+		return fmt.Errorf("'endsAt' must be after 'startsAt'")
+	}
+	if s.Schedule != nil {
+		return s.Schedule.Validate()
+	}
+	return nil
+}
+
+func (s *SilenceSchedule) Validate() error {
+	for _, t := range []string{s.Start, s.End} {
+		if _, err := time.Parse(SilenceScheduleTimeLayout, t); err != nil {
+			return fmt.Errorf("invalid time [%s] of the schedule, which must be in the format of HH:MM", t)
+		}
+	}
+	if s.Start == s.End {
+		return fmt.Errorf("the start and the end of the schedule must be different")
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone [%s] of the schedule: %v", s.TimeZone, err)
+	}
+	for _, day := range s.DaysOfWeek {
+		if _, ok := ParseWeekday(day); !ok {
+			return fmt.Errorf("invalid day [%s] of the week", day)
+		}
+	}
+	return nil
+}
+
+func ParseWeekday(day Weekday) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if string(day) == d.String() {
+			return d, true
+		}
+	}
+	return 0, false
+}
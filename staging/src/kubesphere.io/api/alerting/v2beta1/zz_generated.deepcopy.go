@@ -25,6 +25,137 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertHistory) DeepCopyInto(out *AlertHistory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertHistory.
+func (in *AlertHistory) DeepCopy() *AlertHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AlertHistory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertHistoryList) DeepCopyInto(out *AlertHistoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AlertHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertHistoryList.
+func (in *AlertHistoryList) DeepCopy() *AlertHistoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertHistoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AlertHistoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertHistorySpec) DeepCopyInto(out *AlertHistorySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertHistorySpec.
+func (in *AlertHistorySpec) DeepCopy() *AlertHistorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertHistorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertHistoryStatus) DeepCopyInto(out *AlertHistoryStatus) {
+	*out = *in
+	if in.Records != nil {
+		in, out := &in.Records, &out.Records
+		*out = make([]AlertRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSampleTime != nil {
+		in, out := &in.LastSampleTime, &out.LastSampleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertHistoryStatus.
+func (in *AlertHistoryStatus) DeepCopy() *AlertHistoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertHistoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertRecord) DeepCopyInto(out *AlertRecord) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.ActiveAt.DeepCopyInto(&out.ActiveAt)
+	if in.StartsAt != nil {
+		in, out := &in.StartsAt, &out.StartsAt
+		*out = (*in).DeepCopy()
+	}
+	if in.EndsAt != nil {
+		in, out := &in.EndsAt, &out.EndsAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertRecord.
+func (in *AlertRecord) DeepCopy() *AlertRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterRule) DeepCopyInto(out *ClusterRule) {
 	*out = *in
@@ -162,6 +293,81 @@ func (in *ClusterRuleGroupStatus) DeepCopy() *ClusterRuleGroupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSilence) DeepCopyInto(out *ClusterSilence) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSilence.
+func (in *ClusterSilence) DeepCopy() *ClusterSilence {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSilence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterSilence) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSilenceList) DeepCopyInto(out *ClusterSilenceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterSilence, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSilenceList.
+func (in *ClusterSilenceList) DeepCopy() *ClusterSilenceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSilenceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterSilenceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSilenceSpec) DeepCopyInto(out *ClusterSilenceSpec) {
+	*out = *in
+	in.SilenceSpec.DeepCopyInto(&out.SilenceSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSilenceSpec.
+func (in *ClusterSilenceSpec) DeepCopy() *ClusterSilenceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSilenceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalRule) DeepCopyInto(out *GlobalRule) {
 	*out = *in
@@ -314,6 +520,232 @@ func (in *GlobalRuleGroupStatus) DeepCopy() *GlobalRuleGroupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCondition) DeepCopyInto(out *LogCondition) {
+	*out = *in
+	in.Filter.DeepCopyInto(&out.Filter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCondition.
+func (in *LogCondition) DeepCopy() *LogCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogFilter) DeepCopyInto(out *LogFilter) {
+	*out = *in
+	if in.WorkloadSearch != nil {
+		in, out := &in.WorkloadSearch, &out.WorkloadSearch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkloadFilter != nil {
+		in, out := &in.WorkloadFilter, &out.WorkloadFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSearch != nil {
+		in, out := &in.PodSearch, &out.PodSearch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodFilter != nil {
+		in, out := &in.PodFilter, &out.PodFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContainerSearch != nil {
+		in, out := &in.ContainerSearch, &out.ContainerSearch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContainerFilter != nil {
+		in, out := &in.ContainerFilter, &out.ContainerFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LogSearch != nil {
+		in, out := &in.LogSearch, &out.LogSearch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogFilter.
+func (in *LogFilter) DeepCopy() *LogFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(LogFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRule) DeepCopyInto(out *LogRule) {
+	*out = *in
+	in.Condition.DeepCopyInto(&out.Condition)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRule.
+func (in *LogRule) DeepCopy() *LogRule {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRuleGroup) DeepCopyInto(out *LogRuleGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRuleGroup.
+func (in *LogRuleGroup) DeepCopy() *LogRuleGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRuleGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LogRuleGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRuleGroupList) DeepCopyInto(out *LogRuleGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LogRuleGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRuleGroupList.
+func (in *LogRuleGroupList) DeepCopy() *LogRuleGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRuleGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LogRuleGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRuleGroupSpec) DeepCopyInto(out *LogRuleGroupSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]LogRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRuleGroupSpec.
+func (in *LogRuleGroupSpec) DeepCopy() *LogRuleGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRuleGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRuleGroupStatus) DeepCopyInto(out *LogRuleGroupStatus) {
+	*out = *in
+	if in.RulesStatus != nil {
+		in, out := &in.RulesStatus, &out.RulesStatus
+		*out = make([]LogRuleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRuleGroupStatus.
+func (in *LogRuleGroupStatus) DeepCopy() *LogRuleGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRuleGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogRuleStatus) DeepCopyInto(out *LogRuleStatus) {
+	*out = *in
+	if in.ActiveAt != nil {
+		in, out := &in.ActiveAt, &out.ActiveAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FiredAt != nil {
+		in, out := &in.FiredAt, &out.FiredAt
+		*out = (*in).DeepCopy()
+	}
+	if in.LastEvaluation != nil {
+		in, out := &in.LastEvaluation, &out.LastEvaluation
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogRuleStatus.
+func (in *LogRuleStatus) DeepCopy() *LogRuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogRuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Matcher) DeepCopyInto(out *Matcher) {
 	*out = *in
@@ -828,6 +1260,161 @@ func (in *ScopedWorkloadNames) DeepCopy() *ScopedWorkloadNames {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Silence) DeepCopyInto(out *Silence) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Silence.
+func (in *Silence) DeepCopy() *Silence {
+	if in == nil {
+		return nil
+	}
+	out := new(Silence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Silence) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SilenceList) DeepCopyInto(out *SilenceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Silence, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SilenceList.
+func (in *SilenceList) DeepCopy() *SilenceList {
+	if in == nil {
+		return nil
+	}
+	out := new(SilenceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SilenceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SilenceMatcher) DeepCopyInto(out *SilenceMatcher) {
+	*out = *in
+	out.Matcher = in.Matcher
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SilenceMatcher.
+func (in *SilenceMatcher) DeepCopy() *SilenceMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(SilenceMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SilenceSchedule) DeepCopyInto(out *SilenceSchedule) {
+	*out = *in
+	if in.DaysOfWeek != nil {
+		in, out := &in.DaysOfWeek, &out.DaysOfWeek
+		*out = make([]Weekday, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SilenceSchedule.
+func (in *SilenceSchedule) DeepCopy() *SilenceSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(SilenceSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SilenceSpec) DeepCopyInto(out *SilenceSpec) {
+	*out = *in
+	if in.Matchers != nil {
+		in, out := &in.Matchers, &out.Matchers
+		*out = make([]SilenceMatcher, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartsAt != nil {
+		in, out := &in.StartsAt, &out.StartsAt
+		*out = (*in).DeepCopy()
+	}
+	if in.EndsAt != nil {
+		in, out := &in.EndsAt, &out.EndsAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(SilenceSchedule)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SilenceSpec.
+func (in *SilenceSpec) DeepCopy() *SilenceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SilenceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SilenceStatus) DeepCopyInto(out *SilenceStatus) {
+	*out = *in
+	if in.StartsAt != nil {
+		in, out := &in.StartsAt, &out.StartsAt
+		*out = (*in).DeepCopy()
+	}
+	if in.EndsAt != nil {
+		in, out := &in.EndsAt, &out.EndsAt
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SilenceStatus.
+func (in *SilenceStatus) DeepCopy() *SilenceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SilenceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadCpuThreshold) DeepCopyInto(out *WorkloadCpuThreshold) {
 	*out = *in
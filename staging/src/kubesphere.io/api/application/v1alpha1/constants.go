@@ -43,6 +43,18 @@ const (
 	HelmStatusCreated     = "created"
 	HelmStatusUpgraded    = "upgraded"
 
+	// helm release drift policy
+	DriftPolicyDetect  = "Detect"
+	DriftPolicyCorrect = "Correct"
+
+	// reason of the drifted resources
+	DriftReasonMissing  = "Missing"
+	DriftReasonModified = "Modified"
+
+	// kind of the chart signatures
+	ChartVerificationProvenance = "provenance"
+	ChartVerificationCosign     = "cosign"
+
 	AttachmentTypeScreenshot = "screenshot"
 	AttachmentTypeIcon       = "icon"
 
@@ -54,6 +54,22 @@ type HelmApplicationVersionSpec struct {
 type HelmApplicationVersionStatus struct {
 	State string  `json:"state,omitempty"`
 	Audit []Audit `json:"audit,omitempty"`
+	// result of the signature verification of the chart, set if the workspace requires signed charts
+	// when the package is uploaded
+	Verification *ChartVerificationResult `json:"verification,omitempty"`
+}
+
+type ChartVerificationResult struct {
+	// kind of the signature verified, provenance or cosign
+	Provider string `json:"provider"`
+	// whether the chart is signed by a trusted key
+	Verified bool `json:"verified"`
+	// identity of the key which signed the chart
+	Signer string `json:"signer,omitempty"`
+	// A human readable message indicating why the chart is not verified.
+	Message string `json:"message,omitempty"`
+	// time of the verification
+	Time metav1.Time `json:"time"`
 }
 
 // +kubebuilder:object:root=true
@@ -61,6 +77,7 @@ type HelmApplicationVersionStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="application name",type=string,JSONPath=`.spec.name`
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Verified",type="boolean",JSONPath=".status.verification.verified"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +genclient
 // +genclient:nonNamespaced
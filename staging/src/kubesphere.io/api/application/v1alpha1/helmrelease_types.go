@@ -53,6 +53,16 @@ type HelmReleaseSpec struct {
 	// expected release version, when this version is not equal status.version, the release need upgrade
 	// this filed should be modified when any filed of the spec modified.
 	Version int `json:"version"`
+	// RollbackRevision is the helm revision the release is rolled back to when the version changes,
+	// it is cleared once the release is rolled back, and should be cleared when the release is upgraded.
+	// +optional
+	RollbackRevision int `json:"rollbackRevision,omitempty"`
+	// DriftPolicy is how the changes made to the resources of the release outside of helm are handled.
+	// The drifts are only reported in the status if it is Detect, and the resources are re-applied if it is Correct.
+	// The drifts are not checked if it is empty.
+	// +kubebuilder:validation:Enum=Detect;Correct
+	// +optional
+	DriftPolicy string `json:"driftPolicy,omitempty"`
 }
 
 type HelmReleaseDeployStatus struct {
@@ -78,6 +88,28 @@ type HelmReleaseStatus struct {
 	LastUpdate metav1.Time `json:"lastUpdate,omitempty"`
 	// last deploy time or upgrade time
 	LastDeployed *metav1.Time `json:"lastDeployed,omitempty"`
+	// drift of the resources of the release from the manifest, only checked if the drift policy is set
+	Drift *HelmReleaseDrift `json:"drift,omitempty"`
+}
+
+type HelmReleaseDrift struct {
+	// last time the drift is checked
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
+	// last time the drifted resources are re-applied
+	LastCorrectTime *metav1.Time `json:"lastCorrectTime,omitempty"`
+	// resources drifted from the manifest of the release when last checked
+	Resources []DriftedResource `json:"resources,omitempty"`
+}
+
+type DriftedResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Missing if the resource is deleted, or Modified if the fields differ from the manifest
+	Reason string `json:"reason"`
+	// paths of the fields modified, e.g. spec.replicas
+	Fields []string `json:"fields,omitempty"`
 }
 
 // +kubebuilder:object:root=true
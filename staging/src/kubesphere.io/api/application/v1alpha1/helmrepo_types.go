@@ -41,10 +41,23 @@ type HelmRepoCredential struct {
 	CAFile string `json:"caFile,omitempty"`
 	// skip tls certificate checks for the repository, default is ture
 	InsecureSkipTLSVerify *bool `json:"insecureSkipTLSVerify,omitempty"`
+	// access the OCI registry over plain http, only for the registries without tls.
+	// The certificates of the OCI registries are always verified otherwise.
+	PlainHTTP bool `json:"plainHTTP,omitempty"`
 
 	S3Config `json:",inline"`
 }
 
+// ChartVerification defines the signatures the charts are required to be signed with.
+type ChartVerification struct {
+	// kind of the signatures, the helm provenance files or the cosign signatures
+	// +kubebuilder:validation:Enum=provenance;cosign
+	Provider string `json:"provider"`
+	// name of the secret in kubesphere-system which stores the trusted keys, every item of the secret
+	// is a PGP public keyring for provenance, or a PEM encoded public key for cosign
+	SecretRef string `json:"secretRef"`
+}
+
 type S3Config struct {
 	AccessKeyID     string `json:"accessKeyID,omitempty"`
 	SecretAccessKey string `json:"secretAccessKey,omitempty"`
@@ -65,6 +78,8 @@ type HelmRepoSpec struct {
 	// expected repo version, when this version is not equal status.version, the repo need upgrade
 	// this filed should be modified when any filed of the spec modified.
 	Version int `json:"version,omitempty"`
+	// the charts of the repo are verified before installed if set
+	Verification *ChartVerification `json:"verification,omitempty"`
 }
 
 type HelmRepoSyncState struct {
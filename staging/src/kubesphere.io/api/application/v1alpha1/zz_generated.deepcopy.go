@@ -41,6 +41,37 @@ func (in *Audit) DeepCopy() *Audit {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartVerification) DeepCopyInto(out *ChartVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartVerification.
+func (in *ChartVerification) DeepCopy() *ChartVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartVerificationResult) DeepCopyInto(out *ChartVerificationResult) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartVerificationResult.
+func (in *ChartVerificationResult) DeepCopy() *ChartVerificationResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartVerificationResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Dependency) DeepCopyInto(out *Dependency) {
 	*out = *in
@@ -61,6 +92,26 @@ func (in *Dependency) DeepCopy() *Dependency {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftedResource) DeepCopyInto(out *DriftedResource) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftedResource.
+func (in *DriftedResource) DeepCopy() *DriftedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmApplication) DeepCopyInto(out *HelmApplication) {
 	*out = *in
@@ -266,6 +317,11 @@ func (in *HelmApplicationVersionStatus) DeepCopyInto(out *HelmApplicationVersion
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(ChartVerificationResult)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmApplicationVersionStatus.
@@ -410,6 +466,33 @@ func (in *HelmReleaseDeployStatus) DeepCopy() *HelmReleaseDeployStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmReleaseDrift) DeepCopyInto(out *HelmReleaseDrift) {
+	*out = *in
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+	if in.LastCorrectTime != nil {
+		in, out := &in.LastCorrectTime, &out.LastCorrectTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]DriftedResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmReleaseDrift.
+func (in *HelmReleaseDrift) DeepCopy() *HelmReleaseDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmReleaseDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmReleaseList) DeepCopyInto(out *HelmReleaseList) {
 	*out = *in
@@ -477,6 +560,11 @@ func (in *HelmReleaseStatus) DeepCopyInto(out *HelmReleaseStatus) {
 		in, out := &in.LastDeployed, &out.LastDeployed
 		*out = (*in).DeepCopy()
 	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = new(HelmReleaseDrift)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmReleaseStatus.
@@ -573,6 +661,11 @@ func (in *HelmRepoList) DeepCopyObject() runtime.Object {
 func (in *HelmRepoSpec) DeepCopyInto(out *HelmRepoSpec) {
 	*out = *in
 	in.Credential.DeepCopyInto(&out.Credential)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(ChartVerification)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmRepoSpec.
@@ -27,6 +27,8 @@ type GatewaySpec struct {
 	Controller ControllerSpec `json:"controller,omitempty"`
 	Service    ServiceSpec    `json:"service,omitempty"`
 	Deployment DeploymentSpec `json:"deployment,omitempty"`
+	// +optional
+	GatewayAPI *GatewayAPISpec `json:"gatewayAPI,omitempty"`
 }
 
 type ControllerSpec struct {
@@ -60,6 +62,32 @@ type DeploymentSpec struct {
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
+// GatewayAPISpec describes the GatewayClass and the Gateway of Kubernetes Gateway API (gateway.networking.k8s.io)
+// provisioned along with the gateway. Both of them are named after the gateway, and the routes are allowed to be
+// attached to the Gateway from the namespaces in the scope of the gateway.
+type GatewayAPISpec struct {
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// ControllerName of the GatewayClass, which is the implementation of Gateway API managing the Gateway.
+	// +optional
+	ControllerName string `json:"controllerName,omitempty"`
+	// Listeners of the Gateway, a HTTP listener on port 80 is provisioned if it is empty.
+	// +optional
+	Listeners []Listener `json:"listeners,omitempty"`
+}
+
+type Listener struct {
+	Name string `json:"name"`
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	Port     int32  `json:"port"`
+	// +kubebuilder:validation:Enum=HTTP;HTTPS;TLS;TCP;UDP
+	Protocol string `json:"protocol"`
+	// CertificateRef is the name of the secret holding the certificate, which is required by HTTPS and TLS listeners.
+	// +optional
+	CertificateRef string `json:"certificateRef,omitempty"`
+}
+
 type Scope struct {
 	Enabled   bool   `json:"enabled,omitempty"`
 	Namespace string `json:"namespace,omitempty"`
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindBudget     = "Budget"
+	ResourceSingularBudget = "budget"
+	ResourcePluralBudget   = "budgets"
+
+	// BudgetLabel is the label of the resource quotas applied by budgets.
+	BudgetLabel = "metering.kubesphere.io/budget"
+)
+
+type BudgetPeriod string
+
+const (
+	BudgetPeriodDaily   BudgetPeriod = "Daily"
+	BudgetPeriodWeekly  BudgetPeriod = "Weekly"
+	BudgetPeriodMonthly BudgetPeriod = "Monthly"
+)
+
+func init() {
+	SchemeBuilder.Register(&Budget{}, &BudgetList{})
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:categories="metering",scope="Cluster",path=budgets
+// +kubebuilder:printcolumn:name="Workspace",type="string",JSONPath=".spec.workspace"
+// +kubebuilder:printcolumn:name="Period",type="string",JSONPath=".spec.period"
+// +kubebuilder:printcolumn:name="Amount",type="number",JSONPath=".spec.amount"
+// +kubebuilder:printcolumn:name="Cost",type="number",JSONPath=".status.cost"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Budget limits the costs of a workspace, a namespace or an application over a period.
+// Notifications are sent once the costs cross the thresholds of the budget, and the
+// resources of the workspace can be restricted until the next period.
+type Budget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BudgetSpec   `json:"spec"`
+	Status BudgetStatus `json:"status,omitempty"`
+}
+
+type BudgetSpec struct {
+	// Workspace the budget is attached to, the costs of the whole workspace are tracked
+	// if neither namespace nor application is specified.
+	Workspace string `json:"workspace"`
+	// Namespace of the workspace the budget is attached to.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Application of the namespace the budget is attached to.
+	// +optional
+	Application string `json:"application,omitempty"`
+	// Period the costs are reset at, periods are in UTC and weeks start on Monday.
+	// +kubebuilder:validation:Enum=Daily;Weekly;Monthly
+	// +optional
+	Period BudgetPeriod `json:"period,omitempty"`
+	// Amount of the budget in the currency of the rate card of the workspace.
+	Amount float64 `json:"amount"`
+	// Thresholds in percentages of the amount, a notification is sent once per period when
+	// the costs cross a threshold. Defaults to 50, 80 and 100.
+	// +optional
+	Thresholds []int `json:"thresholds,omitempty"`
+	// Enforcement restricts the resources once the costs cross the threshold.
+	// +optional
+	Enforcement *BudgetEnforcement `json:"enforcement,omitempty"`
+}
+
+type BudgetEnforcement struct {
+	// Threshold in percentages of the amount the restriction is applied at, defaults to 100.
+	// +optional
+	Threshold int `json:"threshold,omitempty"`
+	// Hard is the set of hard limits applied to the namespaces of the budget through
+	// a workspace resource quota until the period ends.
+	Hard corev1.ResourceList `json:"hard"`
+}
+
+type BudgetStatus struct {
+	// Start of the current period.
+	// +optional
+	PeriodStart *metav1.Time `json:"periodStart,omitempty"`
+	// End of the current period.
+	// +optional
+	PeriodEnd *metav1.Time `json:"periodEnd,omitempty"`
+	// Cost of the current period.
+	// +optional
+	Cost float64 `json:"cost,omitempty"`
+	// +optional
+	Currency string `json:"currency,omitempty"`
+	// Percentage of the amount spent in the current period.
+	// +optional
+	Percentage float64 `json:"percentage,omitempty"`
+	// Thresholds crossed in the current period, which have been notified.
+	// +optional
+	CrossedThresholds []int `json:"crossedThresholds,omitempty"`
+	// Enforced tells whether the restriction is applied.
+	// +optional
+	Enforced bool `json:"enforced,omitempty"`
+	// +optional
+	LastEvaluatedTime *metav1.Time `json:"lastEvaluatedTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type BudgetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Budget `json:"items"`
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindRateCard     = "RateCard"
+	ResourceSingularRateCard = "ratecard"
+	ResourcePluralRateCard   = "ratecards"
+)
+
+func init() {
+	SchemeBuilder.Register(&RateCard{}, &RateCardList{})
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories="metering",scope="Cluster",path=ratecards
+// +kubebuilder:printcolumn:name="Currency",type="string",JSONPath=".spec.currency"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RateCard defines the prices used to compute the fees of metering.
+// A rate card with workspaces applies to those workspaces only and takes precedence
+// over the rate cards without workspaces, which apply to all workspaces.
+// The global price info of metering is used when no rate card applies.
+type RateCard struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RateCardSpec `json:"spec"`
+}
+
+// RateCardSpec defines the prices of the rate card, a resource without price falls back to
+// the global price info of metering.
+type RateCardSpec struct {
+	// Currency of the prices, e.g. CNY or USD.
+	// +optional
+	Currency string `json:"currency,omitempty"`
+	// Workspaces the rate card applies to, the rate card applies to all workspaces if empty.
+	// +optional
+	Workspaces []string `json:"workspaces,omitempty"`
+	// CPU is the price per core per hour.
+	// +optional
+	CPU *Rate `json:"cpu,omitempty"`
+	// Memory is the price per gigabyte per hour.
+	// +optional
+	Memory *Rate `json:"memory,omitempty"`
+	// GPU is the price per GPU per hour.
+	// +optional
+	GPU *Rate `json:"gpu,omitempty"`
+	// NetworkIngress is the price per megabyte of ingress network traffic.
+	// +optional
+	NetworkIngress *Rate `json:"networkIngress,omitempty"`
+	// NetworkEgress is the price per megabyte of egress network traffic.
+	// +optional
+	NetworkEgress *Rate `json:"networkEgress,omitempty"`
+	// PVC is the price per gigabyte of persistent volume claims per hour.
+	// +optional
+	PVC *Rate `json:"pvc,omitempty"`
+	// NodePools override the CPU, memory and GPU prices of the usage on the nodes of the pool,
+	// e.g. the spot instances. The first matched node pool applies.
+	// +optional
+	NodePools []NodePoolRate `json:"nodePools,omitempty"`
+	// StorageClasses override the PVC price of the storage classes.
+	// +optional
+	StorageClasses []StorageClassRate `json:"storageClasses,omitempty"`
+}
+
+// Rate is the price of a resource, optionally with volume discount tiers.
+type Rate struct {
+	// Price per unit of the usage below the first tier.
+	Price float64 `json:"price"`
+	// Tiers discount the usage above a threshold. Every tier applies to the usage from its
+	// threshold up to the threshold of the next tier, tiers must be sorted by the threshold.
+	// +optional
+	Tiers []RateTier `json:"tiers,omitempty"`
+}
+
+// RateTier is the price of the usage above the threshold.
+type RateTier struct {
+	// From is the threshold of the usage, in the unit of the price.
+	From float64 `json:"from"`
+	// Price per unit of the usage above the threshold.
+	Price float64 `json:"price"`
+}
+
+// NodePoolRate is the prices of the nodes matched by the selector.
+type NodePoolRate struct {
+	// Name of the node pool, shown in the cost breakdown.
+	Name string `json:"name"`
+	// NodeSelector selects the nodes of the pool by labels.
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+	// +optional
+	CPU *Rate `json:"cpu,omitempty"`
+	// +optional
+	Memory *Rate `json:"memory,omitempty"`
+	// +optional
+	GPU *Rate `json:"gpu,omitempty"`
+}
+
+// StorageClassRate is the PVC price of the storage class.
+type StorageClassRate struct {
+	StorageClassName string `json:"storageClassName"`
+	PVC              Rate   `json:"pvc"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RateCardList contains a list of RateCard
+type RateCardList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RateCard `json:"items"`
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindStatement     = "Statement"
+	ResourceSingularStatement = "statement"
+	ResourcePluralStatement   = "statements"
+
+	// StatementRecalculateAnnotation requests the statement to be recalculated, it is removed
+	// once the statement is recalculated.
+	StatementRecalculateAnnotation = "metering.kubesphere.io/recalculate"
+)
+
+type StatementPhase string
+
+const (
+	// StatementOpen is the phase of a statement still recalculated periodically.
+	StatementOpen StatementPhase = "Open"
+	// StatementClosed is the phase of a statement which is immutable.
+	StatementClosed StatementPhase = "Closed"
+)
+
+func init() {
+	SchemeBuilder.Register(&Statement{}, &StatementList{})
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:categories="metering",scope="Cluster",path=statements
+// +kubebuilder:printcolumn:name="Workspace",type="string",JSONPath=".spec.workspace"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Fee",type="number",JSONPath=".status.fee"
+// +kubebuilder:printcolumn:name="Currency",type="string",JSONPath=".status.currency"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Statement is the billing statement of a workspace over a period, usually a month.
+// The statement is recalculated periodically until it is closed, a closed statement
+// keeps the costs after the metering data ages out and never changes.
+type Statement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StatementSpec   `json:"spec"`
+	Status StatementStatus `json:"status,omitempty"`
+}
+
+type StatementSpec struct {
+	// Workspace the statement bills.
+	Workspace string `json:"workspace"`
+	// Start of the period, inclusive.
+	Start metav1.Time `json:"start"`
+	// End of the period, exclusive.
+	End metav1.Time `json:"end"`
+	// Closed requests the statement to be closed before the period is settled.
+	// +optional
+	Closed bool `json:"closed,omitempty"`
+}
+
+type StatementStatus struct {
+	// +optional
+	Phase StatementPhase `json:"phase,omitempty"`
+	// +optional
+	Currency string `json:"currency,omitempty"`
+	// Fee is the total fee of the workspace.
+	// +optional
+	Fee float64 `json:"fee,omitempty"`
+	// Usage is the total usage of the workspace.
+	// +optional
+	Usage StatementUsage `json:"usage,omitempty"`
+	// RateCards applied to compute the fees.
+	// +optional
+	RateCards []string `json:"rateCards,omitempty"`
+	// Namespaces break the usage and fee down by namespace.
+	// +optional
+	Namespaces []NamespaceStatement `json:"namespaces,omitempty"`
+	// CalculatedTime is the last time the statement was calculated.
+	// +optional
+	CalculatedTime *metav1.Time `json:"calculatedTime,omitempty"`
+	// CalculatedUntil is the end of the metering data rolled up into the statement. The usage
+	// is accumulated, so the statement keeps the usage of the metering data aged out.
+	// +optional
+	CalculatedUntil *metav1.Time `json:"calculatedUntil,omitempty"`
+	// ClosedTime is the time the statement was closed.
+	// +optional
+	ClosedTime *metav1.Time `json:"closedTime,omitempty"`
+}
+
+// StatementUsage is the usage in the units the resources are priced by.
+type StatementUsage struct {
+	// CPU usage in core hours.
+	// +optional
+	CPU float64 `json:"cpu,omitempty"`
+	// Memory usage in gigabyte hours.
+	// +optional
+	Memory float64 `json:"memory,omitempty"`
+	// GPU usage in GPU hours.
+	// +optional
+	GPU float64 `json:"gpu,omitempty"`
+	// NetworkIngress in megabytes.
+	// +optional
+	NetworkIngress float64 `json:"networkIngress,omitempty"`
+	// NetworkEgress in megabytes.
+	// +optional
+	NetworkEgress float64 `json:"networkEgress,omitempty"`
+	// PVC usage in gigabyte hours.
+	// +optional
+	PVC float64 `json:"pvc,omitempty"`
+}
+
+// NamespaceStatement is the usage and fee of a namespace of the workspace.
+type NamespaceStatement struct {
+	Name string `json:"name"`
+	// +optional
+	Fee float64 `json:"fee,omitempty"`
+	// +optional
+	Usage StatementUsage `json:"usage,omitempty"`
+	// +optional
+	RateCards []string `json:"rateCards,omitempty"`
+	// NodePools break the CPU, memory and GPU usage down by the node pool of the rate card
+	// the nodes belonged to when the usage was calculated.
+	// +optional
+	NodePools map[string]StatementUsage `json:"nodePools,omitempty"`
+	// StorageClasses break the PVC usage down by storage class.
+	// +optional
+	StorageClasses map[string]float64 `json:"storageClasses,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StatementList contains a list of Statement
+type StatementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Statement `json:"items"`
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindNotificationHistory   = "NotificationHistory"
+	ResourcePluralNotificationHistory = "notificationhistories"
+
+	// NotificationHistoryLabelReceiver is the label of the receiver of the notifications recorded.
+	NotificationHistoryLabelReceiver = "notification.kubesphere.io/receiver"
+	// NotificationHistoryLabelTenant is the label of the tenant owning the receiver, absent for the global receivers.
+	NotificationHistoryLabelTenant = "notification.kubesphere.io/tenant"
+	// NotificationHistoryLabelDate is the label of the date of the notifications recorded, e.g. 2023-12-10.
+	NotificationHistoryLabelDate = "notification.kubesphere.io/date"
+
+	// NotificationHistoryDateFormat is the format of the dates of the histories, which are in UTC.
+	NotificationHistoryDateFormat = "2006-01-02"
+
+	NotificationStatusSuccess = "success"
+	NotificationStatusFailed  = "failed"
+)
+
+// NotificationRecord is a notification sent to the receiver.
+type NotificationRecord struct {
+	// ID identifies the notification, so that the notifications sent again are recorded once.
+	ID   string      `json:"id"`
+	Time metav1.Time `json:"time"`
+	// +optional
+	ReceiverType string `json:"receiverType,omitempty"`
+	// +optional
+	AlertName string `json:"alertname,omitempty"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Status of the notification, one of success and failed.
+	// +kubebuilder:validation:Enum=success;failed
+	Status string `json:"status"`
+	// LatencyMilliseconds is the time taken to send the notification.
+	// +optional
+	LatencyMilliseconds int64 `json:"latencyMilliseconds,omitempty"`
+	// Message is the error if the notification failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NotificationHistorySpec identifies the receiver and the date of the notifications recorded.
+type NotificationHistorySpec struct {
+	Receiver string `json:"receiver"`
+	// Tenant owning the receiver when the notifications were sent, empty for the global receivers.
+	// +optional
+	Tenant string `json:"tenant,omitempty"`
+	// Date of the notifications recorded in UTC, in the format of 2006-01-02.
+	Date string `json:"date"`
+}
+
+type NotificationHistoryStatus struct {
+	// Records of the notifications, the latest last. The earliest records beyond the max are removed.
+	// +optional
+	Records []NotificationRecord `json:"records,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=notification-manager
+// +kubebuilder:printcolumn:name="Receiver",type="string",JSONPath=".spec.receiver"
+// +kubebuilder:printcolumn:name="Tenant",type="string",JSONPath=".spec.tenant"
+// +kubebuilder:printcolumn:name="Date",type="string",JSONPath=".spec.date"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +genclient
+// +genclient:nonNamespaced
+
+// NotificationHistory records the notifications sent to a receiver in a day, which are reported by the
+// history receiver of the notification manager.
+type NotificationHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NotificationHistorySpec   `json:"spec"`
+	Status NotificationHistoryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NotificationHistoryList contains a list of NotificationHistory
+type NotificationHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationHistory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationHistory{}, &NotificationHistoryList{})
+}